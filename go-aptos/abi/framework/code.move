@@ -0,0 +1,4 @@
+// 0x1::code 中绑定的函数签名，摘自 aptos-framework，只用于 movebind 恢复参数名
+module aptos_framework::code {
+    public entry fun publish_package_txn(owner: &signer, metadata_serialized: vector<u8>, code: vector<vector<u8>>);
+}
@@ -0,0 +1,8 @@
+// 0x1::coin 中绑定的函数签名，摘自 aptos-framework，只用于 movebind 恢复参数名
+module aptos_framework::coin {
+    public fun balance<CoinType>(owner: address): u64;
+    public fun is_account_registered<CoinType>(account_addr: address): bool;
+    public fun supply<CoinType>(): Option<u128>;
+    public fun paired_metadata<CoinType>(): Option<Object<Metadata>>;
+    public entry fun migrate_to_fungible_store<CoinType>(account: &signer);
+}
@@ -0,0 +1,6 @@
+// 0x1::primary_fungible_store 中绑定的函数签名，摘自 aptos-framework，只用于 movebind 恢复参数名
+module aptos_framework::primary_fungible_store {
+    public fun balance<T: key>(account: address, metadata: Object<T>): u64;
+    public fun primary_store_exists<T: key>(account: address, metadata: Object<T>): bool;
+    public entry fun transfer<T: key>(sender: &signer, metadata: Object<T>, recipient: address, amount: u64);
+}
@@ -0,0 +1,658 @@
+package main
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/api"
+)
+
+// serve 的默认监听地址
+const defaultServeListenAddr = "127.0.0.1:8080"
+
+// 赎回请求的处理状态
+const (
+	RedeemStatusPending   = "pending"   // 交易在内存池中
+	RedeemStatusFailed    = "failed"    // 交易执行失败
+	RedeemStatusRequested = "requested" // 已销毁 TWBTC，等待 redeem_prepare
+	RedeemStatusPrepared  = "prepared"
+)
+
+// 未被存款跟踪记录且未铸币的存款状态
+const DepositUnknown = "unknown"
+
+// 请求体的最大字节数
+const maxAPIRequestBytes = 1 << 20
+
+// openAPISpec serve 接口的 OpenAPI 描述
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// apiError 带 HTTP 状态码的接口错误
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func newAPIError(status int, format string, args ...any) *apiError {
+	return &apiError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+// apiRoute 一个接口，Pattern 与 OpenAPI 中的路径写法相同
+type apiRoute struct {
+	Method  string
+	Pattern string
+	Admin   bool // 需要 Authorization: Bearer <SERVE_ADMIN_TOKEN>
+	Handle  func(r *http.Request) (any, error)
+}
+
+// APIServer 桥的 HTTP JSON 接口，只读接口直接查询全节点，事件从 events 读取，管理接口由 admin 签名提交交易
+type APIServer struct {
+	client         *aptos.Client
+	events         EventSource
+	moduleAddress  string
+	admin          aptos.TransactionSigner // 为空时不提供管理接口
+	adminToken     string
+	finalityPolicy FinalityPolicy // 存款状态读取 btc-deposit 的跟踪状态文件
+
+	// adminMu 串行执行管理接口的写操作：admin 的交易按链上序列号依次提交，
+	// 同时避免两个请求都通过已铸币检查后重复提交
+	adminMu sync.Mutex
+	indexes map[string]*eventIndex // 事件句柄名 -> 按键索引的事件
+}
+
+// NewAPIServer 创建 HTTP 接口，admin 或 adminToken 为空时管理接口返回 403
+func NewAPIServer(client *aptos.Client, events EventSource, moduleAddress string, admin aptos.TransactionSigner, adminToken string, finalityPolicy FinalityPolicy) *APIServer {
+	return &APIServer{
+		client:         client,
+		events:         events,
+		moduleAddress:  moduleAddress,
+		admin:          admin,
+		adminToken:     adminToken,
+		finalityPolicy: finalityPolicy,
+		indexes: map[string]*eventIndex{
+			"mint": newEventIndex(func(event SequencedBridgeEvent) string {
+				return event.Data.(*BridgeMintEvent).BtcTxId
+			}),
+			"redeem_prepare": newEventIndex(func(event SequencedBridgeEvent) string {
+				return event.Data.(*BridgeRedeemPrepareEvent).EthTxHash
+			}),
+		},
+	}
+}
+
+// routes 所有接口
+func (s *APIServer) routes() []apiRoute {
+	return []apiRoute{
+		{http.MethodGet, "/api/v1/balances/{address}", false, s.handleBalances},
+		{http.MethodGet, "/api/v1/bridge/config", false, s.handleBridgeConfig},
+		{http.MethodGet, "/api/v1/events/{stream}", false, s.handleEvents},
+		{http.MethodGet, "/api/v1/deposits/{btc_tx_id}", false, s.handleDeposit},
+		{http.MethodGet, "/api/v1/redeems/{hash}", false, s.handleRedeem},
+		{http.MethodPost, "/api/v1/admin/mint", true, s.handleAdminMint},
+		{http.MethodPost, "/api/v1/admin/redeem-prepare", true, s.handleAdminRedeemPrepare},
+	}
+}
+
+// Handler 返回处理所有接口的 http.Handler
+func (s *APIServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPISpec)
+	})
+	for _, route := range s.routes() {
+		mux.HandleFunc(route.Method+" "+route.Pattern, func(w http.ResponseWriter, r *http.Request) {
+			if route.Admin {
+				err := s.authorize(r)
+				if err != nil {
+					writeAPIError(w, err)
+					return
+				}
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxAPIRequestBytes)
+			result, err := route.Handle(r)
+			if err != nil {
+				writeAPIError(w, err)
+				return
+			}
+			writeAPIJSON(w, http.StatusOK, result)
+		})
+	}
+	return mux
+}
+
+// authorize 检查管理接口的 Bearer 令牌
+func (s *APIServer) authorize(r *http.Request) error {
+	if s.admin == nil || s.adminToken == "" {
+		return newAPIError(http.StatusForbidden, "管理接口未启用，需要设置 PRIVATE_KEY 和 SERVE_ADMIN_TOKEN")
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+		return newAPIError(http.StatusUnauthorized, "管理令牌无效")
+	}
+	return nil
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeAPIError 输出 {"error": ...}，不是 apiError 的错误视为全节点请求失败
+func writeAPIError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		status = apiErr.status
+	}
+	writeAPIJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// parseAPIAddress 解析路径或请求体中的 Aptos 地址
+func parseAPIAddress(value string) (aptos.AccountAddress, error) {
+	address := aptos.AccountAddress{}
+	err := address.ParseStringRelaxed(value)
+	if err != nil {
+		return address, newAPIError(http.StatusBadRequest, "无效的地址 %s: %v", value, err)
+	}
+	return address, nil
+}
+
+// queryUint 读取可选的非负整数查询参数
+func queryUint(r *http.Request, name string, defaultValue uint64) (uint64, error) {
+	valueStr := r.URL.Query().Get(name)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.ParseUint(valueStr, 10, 64)
+	if err != nil {
+		return 0, newAPIError(http.StatusBadRequest, "无效的 %s: %s", name, valueStr)
+	}
+	return value, nil
+}
+
+// decodeAPIRequest 解析 JSON 请求体，不允许未知字段
+func decodeAPIRequest(r *http.Request, out any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(out)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return newAPIError(http.StatusRequestEntityTooLarge, "请求体超过 %d 字节", tooLarge.Limit)
+	}
+	if err != nil {
+		return newAPIError(http.StatusBadRequest, "解析请求体失败: %v", err)
+	}
+	return nil
+}
+
+// BalanceResponse GET /api/v1/balances/{address}
+type BalanceResponse struct {
+	Address       string  `json:"address"`
+	LedgerVersion uint64  `json:"ledger_version"`
+	Registered    bool    `json:"registered"`
+	Migrated      bool    `json:"migrated"`
+	Coin          uint64  `json:"coin"`
+	FungibleAsset uint64  `json:"fungible_asset"`
+	Total         uint64  `json:"total"`
+	Metadata      *string `json:"metadata"`
+}
+
+func (s *APIServer) handleBalances(r *http.Request) (any, error) {
+	address, err := parseAPIAddress(r.PathValue("address"))
+	if err != nil {
+		return nil, err
+	}
+	balances, err := GetTWBTCBalances(s.client, address, s.moduleAddress)
+	if err != nil {
+		return nil, err
+	}
+	response := &BalanceResponse{
+		Address:       address.String(),
+		LedgerVersion: balances.LedgerVersion,
+		Registered:    balances.Registered(),
+		Migrated:      balances.Migrated(),
+		Coin:          balances.Coin,
+		FungibleAsset: balances.FungibleAsset,
+		Total:         balances.Total(),
+	}
+	if balances.Metadata != nil {
+		metadata := balances.Metadata.String()
+		response.Metadata = &metadata
+	}
+	return response, nil
+}
+
+// BridgeConfigResponse GET /api/v1/bridge/config
+type BridgeConfigResponse struct {
+	LedgerVersion  uint64  `json:"ledger_version"`
+	Admin          string  `json:"admin"`
+	Fee            uint64  `json:"fee"`
+	FeeAccount     string  `json:"fee_account"`
+	Supply         string  `json:"supply"` // u128，以字符串表示
+	Metadata       *string `json:"metadata"`
+	MintedCount    int     `json:"minted_count"`
+	PreparedCount  int     `json:"prepared_count"`
+	UsedBtcTxCount int     `json:"used_btc_tx_count"`
+}
+
+func (s *APIServer) handleBridgeConfig(r *http.Request) (any, error) {
+	state, err := ReadBridgeState(s.client, s.moduleAddress)
+	if err != nil {
+		return nil, err
+	}
+	response := &BridgeConfigResponse{
+		LedgerVersion:  state.LedgerVersion,
+		Admin:          state.Config.Admin,
+		Fee:            state.Config.Fee,
+		FeeAccount:     state.Config.FeeAccount,
+		Supply:         state.Supply.String(),
+		MintedCount:    len(state.Minted),
+		PreparedCount:  len(state.Prepared),
+		UsedBtcTxCount: len(state.Used),
+	}
+	if state.Metadata != nil {
+		metadata := state.Metadata.String()
+		response.Metadata = &metadata
+	}
+	return response, nil
+}
+
+// handleEvents 按序列号分页，start 默认为 0，limit 默认为 25
+func (s *APIServer) handleEvents(r *http.Request) (any, error) {
+	_, err := findBridgeEventStream(r.PathValue("stream"))
+	if err != nil {
+		return nil, newAPIError(http.StatusNotFound, "%v", err)
+	}
+	start, err := queryUint(r, "start", 0)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := queryUint(r, "limit", 25)
+	if err != nil {
+		return nil, err
+	}
+	if limit == 0 || limit > maxEventPageLimit {
+		return nil, newAPIError(http.StatusBadRequest, "limit 必须在 1 到 %d 之间", maxEventPageLimit)
+	}
+	return s.events.EventPage(r.PathValue("stream"), start, limit)
+}
+
+// DepositResponse GET /api/v1/deposits/{btc_tx_id}
+type DepositResponse struct {
+	BtcTxId   string                `json:"btc_tx_id"`
+	Status    string                `json:"status"` // minted、pending、paused 或 unknown
+	Minted    bool                  `json:"minted"`
+	MintEvent *SequencedBridgeEvent `json:"mint_event"`
+	Tracked   *TrackedDeposit       `json:"tracked"` // btc-deposit 跟踪状态文件中的记录
+}
+
+func (s *APIServer) handleDeposit(r *http.Request) (any, error) {
+	btcTxId := r.PathValue("btc_tx_id")
+	response := &DepositResponse{BtcTxId: btcTxId, Status: DepositUnknown}
+	engine, err := LoadFinalityEngine(nil, s.finalityPolicy)
+	if err != nil {
+		return nil, err
+	}
+	response.Tracked = engine.Deposit(btcTxId)
+	if response.Tracked != nil {
+		response.Status = response.Tracked.Status
+	}
+
+	minted, err := isBtcTxMinted(s.client, s.moduleAddress, btcTxId)
+	if err != nil {
+		return nil, err
+	}
+	if !minted {
+		return response, nil
+	}
+	response.Minted = true
+	response.Status = DepositMinted
+	response.MintEvent, err = s.findEvent("mint", btcTxId)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// eventIndex 按键索引一个事件句柄中已读取的事件，事件只会追加，每次查找只需读取上次之后的新事件
+type eventIndex struct {
+	mu     sync.Mutex
+	key    func(SequencedBridgeEvent) string
+	next   uint64 // 下一个要读取的序列号
+	events map[string]SequencedBridgeEvent
+}
+
+func newEventIndex(key func(SequencedBridgeEvent) string) *eventIndex {
+	return &eventIndex{key: key, events: map[string]SequencedBridgeEvent{}}
+}
+
+// findEvent 查找句柄中键为 key 的事件，没有时返回 nil；合约保证键不重复，已索引的事件直接返回
+func (s *APIServer) findEvent(streamName string, key string) (*SequencedBridgeEvent, error) {
+	index := s.indexes[streamName]
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	if event, ok := index.events[key]; ok {
+		return &event, nil
+	}
+	for {
+		page, err := s.events.EventPage(streamName, index.next, maxEventPageLimit)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range page.Events {
+			index.events[index.key(event)] = event
+			index.next = event.SequenceNumber + 1
+		}
+		if page.Next == nil {
+			break
+		}
+	}
+	event, ok := index.events[key]
+	if !ok {
+		return nil, nil
+	}
+	return &event, nil
+}
+
+// RedeemResponse GET /api/v1/redeems/{hash}
+type RedeemResponse struct {
+	Hash         string                `json:"hash"`
+	Status       string                `json:"status"` // pending、failed、requested 或 prepared
+	Version      uint64                `json:"version,omitempty"`
+	VmStatus     string                `json:"vm_status,omitempty"`
+	Sender       string                `json:"sender"`
+	Amount       uint64                `json:"amount"`   // 请求赎回的数量，含费用
+	Receiver     string                `json:"receiver"` // BTC 接收地址
+	PrepareEvent *SequencedBridgeEvent `json:"prepare_event"`
+}
+
+func (s *APIServer) handleRedeem(r *http.Request) (any, error) {
+	return s.redeemStatus(r.PathValue("hash"))
+}
+
+// redeemStatus 按赎回请求交易的哈希查询处理状态
+func (s *APIServer) redeemStatus(hash string) (*RedeemResponse, error) {
+	txn, err := s.client.TransactionByHash(hash)
+	if isResourceNotFound(err) {
+		return nil, newAPIError(http.StatusNotFound, "交易 %s 不存在", hash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询交易失败: %v", err)
+	}
+
+	response := &RedeemResponse{Hash: hash}
+	var payload *api.TransactionPayload
+	switch txn.Type {
+	case api.TransactionVariantPending:
+		pending, err := txn.PendingTransaction()
+		if err != nil {
+			return nil, err
+		}
+		response.Status = RedeemStatusPending
+		response.Sender = pending.Sender.String()
+		payload = pending.Payload
+	case api.TransactionVariantUser:
+		user, err := txn.UserTransaction()
+		if err != nil {
+			return nil, err
+		}
+		response.Status = RedeemStatusRequested
+		if !user.Success {
+			response.Status = RedeemStatusFailed
+		}
+		response.Version = user.Version
+		response.VmStatus = user.VmStatus
+		response.Sender = user.Sender.String()
+		payload = user.Payload
+	default:
+		return nil, newAPIError(http.StatusUnprocessableEntity, "交易 %s 不是用户交易", hash)
+	}
+
+	entryFunction, ok := payload.Inner.(*api.TransactionPayloadEntryFunction)
+	if !ok || !s.isBridgeFunction(entryFunction.Function, "redeem_request") || len(entryFunction.Arguments) != 2 {
+		return nil, newAPIError(http.StatusUnprocessableEntity, "交易 %s 不是赎回请求", hash)
+	}
+	// redeem_request(amount: u64, receiver: String)
+	amountStr, _ := entryFunction.Arguments[0].(string)
+	response.Amount, err = strconv.ParseUint(amountStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("解析赎回数量失败: %v", err)
+	}
+	response.Receiver, _ = entryFunction.Arguments[1].(string)
+	if response.Status != RedeemStatusRequested {
+		return response, nil
+	}
+
+	prepared, err := GetPreparedRedeems(s.client, s.moduleAddress)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(prepared, hash) {
+		return response, nil
+	}
+	response.Status = RedeemStatusPrepared
+	response.PrepareEvent, err = s.findEvent("redeem_prepare", hash)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// isBridgeFunction 判断 address::module::function 是否为桥合约的入口函数，地址可能未补零
+func (s *APIServer) isBridgeFunction(function string, name string) bool {
+	parts := strings.Split(function, "::")
+	if len(parts) != 3 || parts[1] != BridgeModuleName || parts[2] != name {
+		return false
+	}
+	address, moduleAddress := aptos.AccountAddress{}, aptos.AccountAddress{}
+	return address.ParseStringRelaxed(parts[0]) == nil &&
+		moduleAddress.ParseStringRelaxed(s.moduleAddress) == nil &&
+		address == moduleAddress
+}
+
+// AdminMintRequest POST /api/v1/admin/mint
+type AdminMintRequest struct {
+	BtcTxId  string `json:"btc_tx_id"`
+	Receiver string `json:"receiver"`
+	Amount   uint64 `json:"amount"`
+}
+
+// AdminTxResponse 管理接口提交交易后的结果
+type AdminTxResponse struct {
+	TxHash string `json:"tx_hash"`
+}
+
+func (s *APIServer) handleAdminMint(r *http.Request) (any, error) {
+	request := &AdminMintRequest{}
+	err := decodeAPIRequest(r, request)
+	if err != nil {
+		return nil, err
+	}
+	s.adminMu.Lock()
+	defer s.adminMu.Unlock()
+	if request.BtcTxId == "" || request.Amount == 0 {
+		return nil, newAPIError(http.StatusBadRequest, "btc_tx_id 不能为空且 amount 必须大于 0")
+	}
+	receiver, err := parseAPIAddress(request.Receiver)
+	if err != nil {
+		return nil, err
+	}
+	// 与 btc-deposit 使用同一确认策略：只为确认数足够的存款铸币，重组暂停期间拒绝
+	engine, err := LoadFinalityEngine(nil, s.finalityPolicy)
+	if err != nil {
+		return nil, err
+	}
+	if paused, reason := engine.Paused(); paused {
+		return nil, newAPIError(http.StatusConflict, "铸币已暂停: %s", reason)
+	}
+	deposit := engine.Deposit(request.BtcTxId)
+	if deposit == nil || !slices.Contains(engine.Ready(), deposit) {
+		return nil, newAPIError(http.StatusConflict, "存款 %s 未跟踪、已铸币或确认数不足 %d", request.BtcTxId, s.finalityPolicy.RequiredConfirmations)
+	}
+	trackedReceiver := aptos.AccountAddress{}
+	err = trackedReceiver.ParseStringRelaxed(deposit.Receiver)
+	if err != nil || trackedReceiver != receiver || deposit.Amount != request.Amount {
+		return nil, newAPIError(http.StatusBadRequest, "接收地址或数量与跟踪的存款 %s 不一致", request.BtcTxId)
+	}
+	minted, err := isBtcTxMinted(s.client, s.moduleAddress, request.BtcTxId)
+	if err != nil {
+		return nil, err
+	}
+	if minted {
+		return nil, newAPIError(http.StatusConflict, "BTC交易 %s 已铸币", request.BtcTxId)
+	}
+	txHash, err := mintTWBTC(s.client, s.admin, s.moduleAddress, receiver, request.Amount, request.BtcTxId)
+	if err != nil {
+		return nil, newAPIError(http.StatusUnprocessableEntity, "铸币失败: %v", err)
+	}
+	logSuccess(fmt.Sprintf("接口铸币 %d 给 %s (btc_tx_id %s)，交易哈希: %s", request.Amount, receiver.String(), request.BtcTxId, txHash))
+	err = engine.MarkMinted(request.BtcTxId, txHash)
+	if err == nil {
+		err = engine.Save()
+	}
+	if err != nil {
+		// 交易已上链，btc-deposit 处理时会查到链上记录并补记
+		logWarning(fmt.Sprintf("记录存款 %s 已铸币失败: %v", request.BtcTxId, err))
+	}
+	return &AdminTxResponse{TxHash: txHash}, nil
+}
+
+// AdminRedeemPrepareRequest POST /api/v1/admin/redeem-prepare，
+// 请求者、接收地址和数量从赎回请求交易中读取
+type AdminRedeemPrepareRequest struct {
+	RedeemRequestTxHash string   `json:"redeem_request_tx_hash"`
+	OutpointTxIds       []string `json:"outpoint_tx_ids"`
+	OutpointIdxs        []uint64 `json:"outpoint_idxs"`
+}
+
+func (s *APIServer) handleAdminRedeemPrepare(r *http.Request) (any, error) {
+	request := &AdminRedeemPrepareRequest{}
+	err := decodeAPIRequest(r, request)
+	if err != nil {
+		return nil, err
+	}
+	s.adminMu.Lock()
+	defer s.adminMu.Unlock()
+	if len(request.OutpointTxIds) == 0 {
+		return nil, newAPIError(http.StatusBadRequest, "outpoint_tx_ids 不能为空")
+	}
+	redeem, err := s.redeemStatus(request.RedeemRequestTxHash)
+	if err != nil {
+		return nil, err
+	}
+	switch redeem.Status {
+	case RedeemStatusPrepared:
+		return nil, newAPIError(http.StatusConflict, "赎回请求 %s 已准备", redeem.Hash)
+	case RedeemStatusPending, RedeemStatusFailed:
+		return nil, newAPIError(http.StatusConflict, "赎回请求 %s 状态为 %s，不能准备", redeem.Hash, redeem.Status)
+	}
+	requester, err := parseAPIAddress(redeem.Sender)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := buildRedeemPreparePayload(s.moduleAddress, redeem.Hash, requester, redeem.Receiver, redeem.Amount, request.OutpointTxIds, request.OutpointIdxs)
+	if err != nil {
+		return nil, newAPIError(http.StatusBadRequest, "%v", err)
+	}
+	txHash, err := submitAndConfirm(s.client, s.admin, payload, func() (bool, error) {
+		prepared, err := GetPreparedRedeems(s.client, s.moduleAddress)
+		return slices.Contains(prepared, redeem.Hash), err
+	})
+	if err != nil {
+		return nil, newAPIError(http.StatusUnprocessableEntity, "准备赎回失败: %v", err)
+	}
+	logSuccess(fmt.Sprintf("接口准备赎回 %s，交易哈希: %s", redeem.Hash, txHash))
+	return &AdminTxResponse{TxHash: txHash}, nil
+}
+
+// printServeUsage 打印 HTTP 接口命令的帮助
+func printServeUsage() {
+	fmt.Println("HTTP JSON接口用法:")
+	fmt.Println("  启动: ./main serve [监听地址]")
+	fmt.Println("    只读接口不需要私钥；设置 PRIVATE_KEY 和 SERVE_ADMIN_TOKEN 后启用管理接口，请求需带 Authorization: Bearer <SERVE_ADMIN_TOKEN>")
+	fmt.Println("    存款状态读取 BTC_DEPOSIT_STATE_FILE 中的跟踪记录，事件按 EVENT_SOURCE 从全节点或索引器读取，接口描述见 GET /openapi.json")
+}
+
+// runServeCommand 处理 serve 子命令，管理接口需要私钥
+func runServeCommand(args []string) {
+	if len(args) > 0 && (args[0] == "help" || args[0] == "-h") {
+		printServeUsage()
+		return
+	}
+
+	listenAddr := defaultServeListenAddr
+	if len(args) > 0 {
+		listenAddr = args[0]
+	}
+	moduleAddress, err := getModuleAddress()
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+	client, err := createClient()
+	if err != nil {
+		logError(fmt.Sprintf("创建客户端失败: %v", err))
+		os.Exit(1)
+	}
+	policy, err := loadFinalityPolicy()
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+	events, err := newEventSource(client, moduleAddress)
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+	var admin aptos.TransactionSigner
+	adminToken := os.Getenv("SERVE_ADMIN_TOKEN")
+	if privateKey := os.Getenv("PRIVATE_KEY"); privateKey != "" && adminToken != "" {
+		account, err := createSigningAccount(privateKey)
+		if err != nil {
+			logError(fmt.Sprintf("创建账户失败: %v", err))
+			os.Exit(1)
+		}
+		admin = account
+		logInfo(fmt.Sprintf("管理接口已启用，签名地址: %s", account.Address.String()))
+	} else {
+		logWarning("未设置 PRIVATE_KEY 或 SERVE_ADMIN_TOKEN，管理接口不可用")
+	}
+
+	server := &http.Server{
+		Addr:              listenAddr,
+		Handler:           NewAPIServer(client, events, moduleAddress, admin, adminToken, policy).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		<-interrupt
+		server.Close()
+	}()
+	logSuccess(fmt.Sprintf("HTTP接口已启动: http://%s/api/v1，接口描述: http://%s/openapi.json", listenAddr, listenAddr))
+	err = server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logError(fmt.Sprintf("HTTP接口退出: %v", err))
+		os.Exit(1)
+	}
+}
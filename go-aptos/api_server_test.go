@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+const testAdminToken = "serve-test-token"
+
+// countingEventSource 记录 EventPage 每次调用的起始序列号
+type countingEventSource struct {
+	EventSource
+	mu     sync.Mutex
+	starts []uint64
+}
+
+func (s *countingEventSource) EventPage(streamName string, start uint64, limit uint64) (*BridgeEventPage, error) {
+	s.mu.Lock()
+	s.starts = append(s.starts, start)
+	s.mu.Unlock()
+	return s.EventSource.EventPage(streamName, start, limit)
+}
+
+func (s *countingEventSource) takeStarts() []uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	starts := s.starts
+	s.starts = nil
+	return starts
+}
+
+// testAPIServer 桥已初始化、user 已注册的模拟节点上的接口
+type testAPIServer struct {
+	client  *aptos.Client
+	admin   *aptos.Account
+	user    *aptos.Account
+	events  *countingEventSource
+	policy  FinalityPolicy
+	server  *APIServer
+	handler http.Handler
+}
+
+func newTestAPIServer(t *testing.T) *testAPIServer {
+	t.Helper()
+	user := newTestAccount(t, t.Name()+"-user")
+	_, client, admin := newTestBridgeNode(t, user)
+	moduleAddress := admin.Address.String()
+	events := &countingEventSource{EventSource: &restEventSource{client: client, moduleAddress: moduleAddress}}
+	policy := FinalityPolicy{RequiredConfirmations: defaultFinalityPolicy.RequiredConfirmations, StateFile: filepath.Join(t.TempDir(), "btc_deposits.json")}
+	server := NewAPIServer(client, events, moduleAddress, admin, testAdminToken, policy)
+	return &testAPIServer{client: client, admin: admin, user: user, events: events, policy: policy, server: server, handler: server.Handler()}
+}
+
+// call 调用接口并检查状态码，out 不为空时把响应体解码到 out
+func (s *testAPIServer) call(t *testing.T, handler http.Handler, method string, path string, token string, body any, status int, out any) {
+	t.Helper()
+	var data []byte
+	switch body := body.(type) {
+	case nil:
+	case []byte:
+		data = body
+	default:
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	request := httptest.NewRequest(method, path, bytes.NewReader(data))
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != status {
+		t.Fatalf("%s %s 应返回 %d，实际 %d: %s", method, path, status, recorder.Code, strings.TrimSpace(recorder.Body.String()))
+	}
+	if out == nil {
+		return
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), out)
+	if err != nil {
+		t.Fatalf("解析 %s 的响应失败: %v, 响应体: %s", path, err, recorder.Body.String())
+	}
+}
+
+// track 在存款跟踪状态文件中记录一笔发给 user 的存款
+func (s *testAPIServer) track(t *testing.T, btcTxId string, amount uint64, confirmations int64) {
+	t.Helper()
+	engine, err := LoadFinalityEngine(nil, s.policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.state.Deposits = append(engine.state.Deposits, &TrackedDeposit{
+		BtcTxId:       btcTxId,
+		Receiver:      s.user.Address.String(),
+		Amount:        amount,
+		Confirmations: confirmations,
+		Status:        DepositPending,
+	})
+	err = engine.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mint 记录确认数足够的存款并通过管理接口铸币
+func (s *testAPIServer) mint(t *testing.T, btcTxId string, amount uint64) {
+	t.Helper()
+	s.track(t, btcTxId, amount, s.policy.RequiredConfirmations)
+	minted := &AdminTxResponse{}
+	request := AdminMintRequest{BtcTxId: btcTxId, Receiver: s.user.Address.String(), Amount: amount}
+	s.call(t, s.handler, http.MethodPost, "/api/v1/admin/mint", testAdminToken, request, http.StatusOK, minted)
+	if minted.TxHash == "" {
+		t.Fatalf("铸币应返回交易哈希")
+	}
+}
+
+func TestAPIServerOpenAPICoversRoutes(t *testing.T) {
+	api := newTestAPIServer(t)
+	var spec struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+	api.call(t, api.handler, http.MethodGet, "/openapi.json", "", nil, http.StatusOK, &spec)
+	routes := api.server.routes()
+	for _, route := range routes {
+		if _, ok := spec.Paths[route.Pattern][strings.ToLower(route.Method)]; !ok {
+			t.Fatalf("OpenAPI 描述缺少 %s %s", route.Method, route.Pattern)
+		}
+	}
+	operations := 0
+	for _, methods := range spec.Paths {
+		operations += len(methods)
+	}
+	if operations != len(routes) {
+		t.Fatalf("OpenAPI 描述有 %d 个接口，实际实现 %d 个", operations, len(routes))
+	}
+}
+
+func TestAPIServerAdminRequests(t *testing.T) {
+	api := newTestAPIServer(t)
+	mint := AdminMintRequest{BtcTxId: "deposit-1", Receiver: api.user.Address.String(), Amount: 20000}
+	api.call(t, api.handler, http.MethodPost, "/api/v1/admin/mint", "", mint, http.StatusUnauthorized, nil)
+	api.call(t, api.handler, http.MethodPost, "/api/v1/admin/mint", "wrong-token", mint, http.StatusUnauthorized, nil)
+	readOnly := NewAPIServer(api.client, api.events, api.admin.Address.String(), nil, "", api.policy).Handler()
+	api.call(t, readOnly, http.MethodPost, "/api/v1/admin/mint", testAdminToken, mint, http.StatusForbidden, nil)
+	api.call(t, api.handler, http.MethodPost, "/api/v1/admin/mint", testAdminToken, map[string]any{"btc_tx_id": "x", "amount": 1, "extra": true}, http.StatusBadRequest, nil)
+
+	oversized := []byte(fmt.Sprintf(`{"btc_tx_id": "%s", "receiver": "0x1", "amount": 20000}`, strings.Repeat("a", maxAPIRequestBytes)))
+	api.call(t, api.handler, http.MethodPost, "/api/v1/admin/mint", testAdminToken, oversized, http.StatusRequestEntityTooLarge, nil)
+
+	api.mint(t, "deposit-1", 20000)
+	api.call(t, api.handler, http.MethodPost, "/api/v1/admin/mint", testAdminToken, mint, http.StatusConflict, nil)
+}
+
+func TestAPIServerAdminMintFollowsFinality(t *testing.T) {
+	api := newTestAPIServer(t)
+	mint := func(btcTxId string, amount uint64, status int) {
+		t.Helper()
+		request := AdminMintRequest{BtcTxId: btcTxId, Receiver: api.user.Address.String(), Amount: amount}
+		api.call(t, api.handler, http.MethodPost, "/api/v1/admin/mint", testAdminToken, request, status, nil)
+	}
+	mint("untracked", 20000, http.StatusConflict)
+	api.track(t, "unconfirmed", 20000, api.policy.RequiredConfirmations-1)
+	mint("unconfirmed", 20000, http.StatusConflict)
+	api.track(t, "confirmed", 20000, api.policy.RequiredConfirmations)
+	mint("confirmed", 30000, http.StatusBadRequest)
+
+	// 已铸币的存款被重组后暂停所有铸币
+	engine, err := LoadFinalityEngine(nil, api.policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.state.Paused = true
+	engine.state.PauseReason = "已铸币的存款被重组"
+	err = engine.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mint("confirmed", 20000, http.StatusConflict)
+	config := &BridgeConfigResponse{}
+	api.call(t, api.handler, http.MethodGet, "/api/v1/bridge/config", "", nil, http.StatusOK, config)
+	if config.MintedCount != 0 {
+		t.Fatalf("未确认、未跟踪或暂停期间不应铸币，实际 %d 笔", config.MintedCount)
+	}
+
+	err = engine.Resume("all")
+	if err == nil {
+		err = engine.Save()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	mint("confirmed", 20000, http.StatusOK)
+	engine, err = LoadFinalityEngine(nil, api.policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deposit := engine.Deposit("confirmed"); deposit.Status != DepositMinted || deposit.MintTxHash == "" {
+		t.Fatalf("铸币后存款应记为已铸币: %+v", deposit)
+	}
+	if deposit := engine.Deposit("unconfirmed"); deposit.Status != DepositPending {
+		t.Fatalf("确认数不足的存款应仍在等待: %+v", deposit)
+	}
+	mint("confirmed", 20000, http.StatusConflict)
+}
+
+func TestAPIServerConcurrentAdminMints(t *testing.T) {
+	api := newTestAPIServer(t)
+	const mints = 8
+	for i := range mints {
+		api.track(t, fmt.Sprintf("deposit-%d", i), 20000, api.policy.RequiredConfirmations)
+	}
+	codes := make([]int, mints)
+	var wg sync.WaitGroup
+	for i := range mints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, _ := json.Marshal(AdminMintRequest{BtcTxId: fmt.Sprintf("deposit-%d", i), Receiver: api.user.Address.String(), Amount: 20000})
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/admin/mint", bytes.NewReader(data))
+			request.Header.Set("Authorization", "Bearer "+testAdminToken)
+			recorder := httptest.NewRecorder()
+			api.handler.ServeHTTP(recorder, request)
+			codes[i] = recorder.Code
+		}()
+	}
+	wg.Wait()
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Fatalf("并发铸币 deposit-%d 应成功，实际状态码 %d", i, code)
+		}
+	}
+	config := &BridgeConfigResponse{}
+	api.call(t, api.handler, http.MethodGet, "/api/v1/bridge/config", "", nil, http.StatusOK, config)
+	if config.MintedCount != mints {
+		t.Fatalf("应有 %d 笔铸币，实际 %d", mints, config.MintedCount)
+	}
+}
+
+func TestAPIServerBalancesAndEvents(t *testing.T) {
+	api := newTestAPIServer(t)
+	// 代币合约从每笔铸币中扣除 twbtcTokenMintFee 给管理员
+	for i, amount := range []uint64{20000, 30000, 40000} {
+		api.mint(t, fmt.Sprintf("deposit-%d", i+1), amount)
+	}
+
+	balance := &BalanceResponse{}
+	api.call(t, api.handler, http.MethodGet, "/api/v1/balances/"+api.user.Address.String(), "", nil, http.StatusOK, balance)
+	if expected := 90000 - 3*twbtcTokenMintFee; !balance.Registered || balance.Total != expected || balance.Coin != expected {
+		t.Fatalf("用户余额应为 %d: %+v", expected, balance)
+	}
+	api.call(t, api.handler, http.MethodGet, "/api/v1/balances/not-an-address", "", nil, http.StatusBadRequest, nil)
+
+	page := &BridgeEventPage{}
+	api.call(t, api.handler, http.MethodGet, "/api/v1/events/mint?limit=2", "", nil, http.StatusOK, page)
+	if page.Total != 3 || len(page.Events) != 2 || page.Events[0].SequenceNumber != 0 || page.Next == nil || *page.Next != 2 {
+		t.Fatalf("第一页应为序列号 0、1，下一页从 2 开始: %+v", page)
+	}
+	page = &BridgeEventPage{}
+	api.call(t, api.handler, http.MethodGet, "/api/v1/events/mint?start=2&limit=2", "", nil, http.StatusOK, page)
+	if len(page.Events) != 1 || page.Next != nil || page.Events[0].Data.(map[string]any)["btc_tx_id"] != "deposit-3" {
+		t.Fatalf("第二页应只有 deposit-3 且没有下一页: %+v", page)
+	}
+	api.call(t, api.handler, http.MethodGet, "/api/v1/events/unknown", "", nil, http.StatusNotFound, nil)
+	api.call(t, api.handler, http.MethodGet, "/api/v1/events/mint?limit=0", "", nil, http.StatusBadRequest, nil)
+}
+
+func TestAPIServerDepositStatus(t *testing.T) {
+	api := newTestAPIServer(t)
+	tracker := NewFinalityEngine(nil, api.policy)
+	tracker.state.Deposits = append(tracker.state.Deposits, &TrackedDeposit{
+		BtcTxId:       "tracked-pending",
+		Receiver:      api.user.Address.String(),
+		Amount:        50000,
+		Confirmations: 2,
+		Status:        DepositPending,
+	})
+	err := tracker.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 3 {
+		api.mint(t, fmt.Sprintf("deposit-%d", i+1), 20000)
+	}
+	api.events.takeStarts()
+
+	deposit := &DepositResponse{}
+	api.call(t, api.handler, http.MethodGet, "/api/v1/deposits/deposit-2", "", nil, http.StatusOK, deposit)
+	if deposit.Status != DepositMinted || deposit.MintEvent == nil || deposit.MintEvent.SequenceNumber != 1 {
+		t.Fatalf("deposit-2 应已铸币且对应序列号 1 的铸币事件: %+v", deposit)
+	}
+	if starts := api.events.takeStarts(); len(starts) != 1 || starts[0] != 0 {
+		t.Fatalf("首次查找应从序列号 0 读取一页事件，实际起点 %v", starts)
+	}
+
+	// 已索引的事件不再读取，新事件只读取增量
+	deposit = &DepositResponse{}
+	api.call(t, api.handler, http.MethodGet, "/api/v1/deposits/deposit-1", "", nil, http.StatusOK, deposit)
+	if deposit.MintEvent == nil || deposit.MintEvent.SequenceNumber != 0 {
+		t.Fatalf("deposit-1 应对应序列号 0 的铸币事件: %+v", deposit)
+	}
+	if starts := api.events.takeStarts(); len(starts) != 0 {
+		t.Fatalf("已索引的事件不应再读取事件，实际起点 %v", starts)
+	}
+	api.mint(t, "deposit-4", 20000)
+	deposit = &DepositResponse{}
+	api.call(t, api.handler, http.MethodGet, "/api/v1/deposits/deposit-4", "", nil, http.StatusOK, deposit)
+	if deposit.MintEvent == nil || deposit.MintEvent.SequenceNumber != 3 {
+		t.Fatalf("deposit-4 应对应序列号 3 的铸币事件: %+v", deposit)
+	}
+	if starts := api.events.takeStarts(); len(starts) != 1 || starts[0] != 3 {
+		t.Fatalf("新事件应从序列号 3 开始读取，实际起点 %v", starts)
+	}
+
+	deposit = &DepositResponse{}
+	api.call(t, api.handler, http.MethodGet, "/api/v1/deposits/tracked-pending", "", nil, http.StatusOK, deposit)
+	if deposit.Status != DepositPending || deposit.Minted || deposit.Tracked == nil || deposit.Tracked.Confirmations != 2 {
+		t.Fatalf("tracked-pending 应为等待确认: %+v", deposit)
+	}
+	deposit = &DepositResponse{}
+	api.call(t, api.handler, http.MethodGet, "/api/v1/deposits/never-seen", "", nil, http.StatusOK, deposit)
+	if deposit.Status != DepositUnknown || deposit.MintEvent != nil || deposit.Tracked != nil {
+		t.Fatalf("never-seen 应为未知存款: %+v", deposit)
+	}
+}
+
+func TestAPIServerRedeemPrepare(t *testing.T) {
+	api := newTestAPIServer(t)
+	moduleAddress := api.admin.Address.String()
+	api.mint(t, "deposit-1", 50000)
+	redeemHash, err := redeemRequest(api.client, api.user, moduleAddress, defaultBootstrapTestnetReceiver, 15000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redeem := &RedeemResponse{}
+	api.call(t, api.handler, http.MethodGet, "/api/v1/redeems/"+redeemHash, "", nil, http.StatusOK, redeem)
+	if redeem.Status != RedeemStatusRequested || redeem.Amount != 15000 || redeem.Receiver != defaultBootstrapTestnetReceiver || redeem.Sender != api.user.Address.String() {
+		t.Fatalf("赎回请求应为 requested: %+v", redeem)
+	}
+	prepare := AdminRedeemPrepareRequest{RedeemRequestTxHash: redeemHash, OutpointTxIds: []string{"deposit-1"}, OutpointIdxs: []uint64{0}}
+	api.call(t, api.handler, http.MethodPost, "/api/v1/admin/redeem-prepare", testAdminToken, prepare, http.StatusOK, &AdminTxResponse{})
+
+	redeem = &RedeemResponse{}
+	api.call(t, api.handler, http.MethodGet, "/api/v1/redeems/"+redeemHash, "", nil, http.StatusOK, redeem)
+	if redeem.Status != RedeemStatusPrepared || redeem.PrepareEvent == nil || redeem.PrepareEvent.Data.(map[string]any)["eth_tx_hash"] != redeemHash {
+		t.Fatalf("赎回请求应为 prepared 并带有准备事件: %+v", redeem)
+	}
+	api.call(t, api.handler, http.MethodPost, "/api/v1/admin/redeem-prepare", testAdminToken, prepare, http.StatusConflict, nil)
+
+	mintHash, err := mintTWBTC(api.client, api.admin, moduleAddress, api.user.Address, 20000, "deposit-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.call(t, api.handler, http.MethodGet, "/api/v1/redeems/"+mintHash, "", nil, http.StatusUnprocessableEntity, nil)
+	api.call(t, api.handler, http.MethodGet, "/api/v1/redeems/0x"+strings.Repeat("0", 64), "", nil, http.StatusNotFound, nil)
+}
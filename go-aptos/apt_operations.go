@@ -45,7 +45,7 @@ func sendAPT(ctx context.Context, client *aptos.Client, senderAccount *aptos.Acc
 	}
 
 	// 构建、签名并提交交易
-	resp, err := client.BuildSignAndSubmitTransaction(senderAccount, aptos.TransactionPayload{Payload: payload})
+	resp, err := buildSignAndSubmitTransaction(client, senderAccount, aptos.TransactionPayload{Payload: payload})
 	if err != nil {
 		return "", fmt.Errorf("构建、签名并提交交易失败: %v", err)
 	}
@@ -0,0 +1,20 @@
+package main
+
+// 桥合约和用到的框架视图函数的 Go 绑定由 tools/movebind 根据 abi/ 下的模块 ABI 快照生成，
+// 不要手工修改 bindings_gen.go。模块迁移到新地址或改名后，从节点或编译产物刷新快照并重新生成，例如：
+//
+//	go run ./tools/movebind -node https://api.devnet.aptoslabs.com -address <模块地址> -save abi \
+//	    -out /dev/null btc_bridgev3=Bridge btc_tokenv3=Token
+//	go run ./tools/movebind -compiled ../my-aptos-dapp/contract -save abi \
+//	    -out /dev/null btc_bridgev3=Bridge btc_tokenv3=Token
+//	go generate
+//
+// abi/coin.json、abi/primary_fungible_store.json 和 abi/code.json 只保留了框架模块中用到的函数，从节点刷新时会得到完整的模块，
+// 生成时按下面命令中列出的函数过滤，结果不变。框架源码不在仓库中，参数名取自 abi/framework 下的签名摘录：
+//
+//	go run ./tools/movebind -node https://api.devnet.aptoslabs.com -save abi -out /dev/null \
+//	    0x1::coin=Coin 0x1::primary_fungible_store=PrimaryStore 0x1::code=Code
+//
+// 模块改名时同时修改上面和下面命令中的模块名，Go 中的标识符由 = 后面的前缀决定，保持不变。
+
+//go:generate go run ./tools/movebind -abi abi -sources ../my-aptos-dapp/contract/sources,abi/framework -out bindings_gen.go btc_bridgev3=Bridge btc_tokenv3=Token 0x1::coin=Coin:balance,is_account_registered,supply,paired_metadata,migrate_to_fungible_store 0x1::primary_fungible_store=PrimaryStore:balance,primary_store_exists,transfer 0x1::code=Code:publish_package_txn
@@ -0,0 +1,830 @@
+// Code generated by movebind from abi/btc_bridgev3.json, abi/btc_tokenv3.json, abi/coin.json, abi/primary_fungible_store.json, abi/code.json. DO NOT EDIT.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/bcs"
+)
+
+// bindingModuleId 解析模块地址并与模块名组成模块 ID
+func bindingModuleId(moduleAddress string, name string) (aptos.ModuleId, error) {
+	address := aptos.AccountAddress{}
+	err := address.ParseStringRelaxed(moduleAddress)
+	if err != nil {
+		return aptos.ModuleId{}, fmt.Errorf("解析地址失败: %v", err)
+	}
+	return aptos.ModuleId{Address: address, Name: name}, nil
+}
+
+// BridgeModuleName btc_bridgev3 模块的名称
+const BridgeModuleName = "btc_bridgev3"
+
+// BridgeModuleAddress 生成绑定时 btc_bridgev3 模块的发布地址，运行时以配置的模块地址为准
+const BridgeModuleAddress = "0x1319db9743efbef92e2ed32e122a4690f466fbbb8e34cd6ccffb93e8cb68447d"
+
+// BridgeConfig 对应 btc_bridgev3::BridgeConfig 资源
+type BridgeConfig struct {
+	Admin      string `json:"admin"`
+	Fee        uint64 `json:"fee"`
+	FeeAccount string `json:"fee_account"`
+}
+
+// BridgeConfigStructTag btc_bridgev3::BridgeConfig 不含地址的结构体标签
+const BridgeConfigStructTag = BridgeModuleName + "::BridgeConfig"
+
+// BridgeMintedTransactions 对应 btc_bridgev3::MintedTransactions 资源
+type BridgeMintedTransactions struct {
+	Minted []string `json:"minted"`
+}
+
+// BridgeMintedTransactionsStructTag btc_bridgev3::MintedTransactions 不含地址的结构体标签
+const BridgeMintedTransactionsStructTag = BridgeModuleName + "::MintedTransactions"
+
+// BridgePreparedRedeems 对应 btc_bridgev3::PreparedRedeems 资源
+type BridgePreparedRedeems struct {
+	Prepared []string `json:"prepared"`
+}
+
+// BridgePreparedRedeemsStructTag btc_bridgev3::PreparedRedeems 不含地址的结构体标签
+const BridgePreparedRedeemsStructTag = BridgeModuleName + "::PreparedRedeems"
+
+// BridgeUsedBtcTxIds 对应 btc_bridgev3::UsedBtcTxIds 资源
+type BridgeUsedBtcTxIds struct {
+	Used []string `json:"used"`
+}
+
+// BridgeUsedBtcTxIdsStructTag btc_bridgev3::UsedBtcTxIds 不含地址的结构体标签
+const BridgeUsedBtcTxIdsStructTag = BridgeModuleName + "::UsedBtcTxIds"
+
+// BridgeEvents 对应 btc_bridgev3::BridgeEvents 资源
+type BridgeEvents struct {
+	MintEvents          EventHandle `json:"mint_events"`
+	RedeemRequestEvents EventHandle `json:"redeem_request_events"`
+	RedeemPrepareEvents EventHandle `json:"redeem_prepare_events"`
+}
+
+// BridgeEventsStructTag btc_bridgev3::BridgeEvents 不含地址的结构体标签
+const BridgeEventsStructTag = BridgeModuleName + "::BridgeEvents"
+
+// BridgeMintEvent 对应 btc_bridgev3::MintEvent 事件
+type BridgeMintEvent struct {
+	BtcTxId  string `json:"btc_tx_id"`
+	Receiver string `json:"receiver"`
+	Amount   uint64 `json:"amount"`
+}
+
+// BridgeMintEventStructTag btc_bridgev3::MintEvent 不含地址的结构体标签
+const BridgeMintEventStructTag = BridgeModuleName + "::MintEvent"
+
+// BridgeRedeemRequestEvent 对应 btc_bridgev3::RedeemRequestEvent 事件
+type BridgeRedeemRequestEvent struct {
+	Sender   string `json:"sender"`
+	Amount   uint64 `json:"amount"`
+	Receiver string `json:"receiver"`
+}
+
+// BridgeRedeemRequestEventStructTag btc_bridgev3::RedeemRequestEvent 不含地址的结构体标签
+const BridgeRedeemRequestEventStructTag = BridgeModuleName + "::RedeemRequestEvent"
+
+// BridgeRedeemPrepareEvent 对应 btc_bridgev3::RedeemPrepareEvent 事件
+type BridgeRedeemPrepareEvent struct {
+	EthTxHash     string   `json:"eth_tx_hash"`
+	Requester     string   `json:"requester"`
+	Receiver      string   `json:"receiver"`
+	Amount        uint64   `json:"amount"`
+	OutpointTxIds []string `json:"outpoint_tx_ids"`
+	OutpointIdxs  []uint64 `json:"outpoint_idxs"`
+}
+
+// BridgeRedeemPrepareEventStructTag btc_bridgev3::RedeemPrepareEvent 不含地址的结构体标签
+const BridgeRedeemPrepareEventStructTag = BridgeModuleName + "::RedeemPrepareEvent"
+
+// BridgeInitializePayload 构建 btc_bridgev3::initialize 的交易负载
+// 签名方为 admin
+func BridgeInitializePayload(moduleAddress string, feeAccount aptos.AccountAddress, fee uint64) (aptos.TransactionPayload, error) {
+	module, err := bindingModuleId(moduleAddress, BridgeModuleName)
+	if err != nil {
+		return aptos.TransactionPayload{}, err
+	}
+	args := make([][]byte, 2)
+	args[0], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		feeAccount.MarshalBCS(ser)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 fee_account 失败: %v", err)
+	}
+	args[1], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		ser.U64(fee)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 fee 失败: %v", err)
+	}
+	return aptos.TransactionPayload{
+		Payload: &aptos.EntryFunction{
+			Module:   module,
+			Function: "initialize",
+			ArgTypes: []aptos.TypeTag{},
+			Args:     args,
+		},
+	}, nil
+}
+
+// BridgeMintPayload 构建 btc_bridgev3::mint 的交易负载
+// 签名方为 admin
+func BridgeMintPayload(moduleAddress string, btcTxId string, receiver aptos.AccountAddress, amount uint64) (aptos.TransactionPayload, error) {
+	module, err := bindingModuleId(moduleAddress, BridgeModuleName)
+	if err != nil {
+		return aptos.TransactionPayload{}, err
+	}
+	args := make([][]byte, 3)
+	args[0], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		ser.WriteString(btcTxId)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 btc_tx_id 失败: %v", err)
+	}
+	args[1], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		receiver.MarshalBCS(ser)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 receiver 失败: %v", err)
+	}
+	args[2], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		ser.U64(amount)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 amount 失败: %v", err)
+	}
+	return aptos.TransactionPayload{
+		Payload: &aptos.EntryFunction{
+			Module:   module,
+			Function: "mint",
+			ArgTypes: []aptos.TypeTag{},
+			Args:     args,
+		},
+	}, nil
+}
+
+// BridgeRedeemRequestPayload 构建 btc_bridgev3::redeem_request 的交易负载
+// 签名方为 user
+func BridgeRedeemRequestPayload(moduleAddress string, amount uint64, receiver string) (aptos.TransactionPayload, error) {
+	module, err := bindingModuleId(moduleAddress, BridgeModuleName)
+	if err != nil {
+		return aptos.TransactionPayload{}, err
+	}
+	args := make([][]byte, 2)
+	args[0], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		ser.U64(amount)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 amount 失败: %v", err)
+	}
+	args[1], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		ser.WriteString(receiver)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 receiver 失败: %v", err)
+	}
+	return aptos.TransactionPayload{
+		Payload: &aptos.EntryFunction{
+			Module:   module,
+			Function: "redeem_request",
+			ArgTypes: []aptos.TypeTag{},
+			Args:     args,
+		},
+	}, nil
+}
+
+// BridgeRedeemPreparePayload 构建 btc_bridgev3::redeem_prepare 的交易负载
+// 签名方为 admin
+func BridgeRedeemPreparePayload(moduleAddress string, redeemRequestTxHash string, requester aptos.AccountAddress, receiver string, amount uint64, outpointTxIds []string, outpointIdxs []uint64) (aptos.TransactionPayload, error) {
+	module, err := bindingModuleId(moduleAddress, BridgeModuleName)
+	if err != nil {
+		return aptos.TransactionPayload{}, err
+	}
+	args := make([][]byte, 6)
+	args[0], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		ser.WriteString(redeemRequestTxHash)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 redeem_request_tx_hash 失败: %v", err)
+	}
+	args[1], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		requester.MarshalBCS(ser)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 requester 失败: %v", err)
+	}
+	args[2], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		ser.WriteString(receiver)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 receiver 失败: %v", err)
+	}
+	args[3], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		ser.U64(amount)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 amount 失败: %v", err)
+	}
+	args[4], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		bcs.SerializeSequenceWithFunction(outpointTxIds, ser, func(ser *bcs.Serializer, item0 string) {
+			ser.WriteString(item0)
+		})
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 outpoint_tx_ids 失败: %v", err)
+	}
+	args[5], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		bcs.SerializeSequenceWithFunction(outpointIdxs, ser, func(ser *bcs.Serializer, item0 uint64) {
+			ser.U64(item0)
+		})
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 outpoint_idxs 失败: %v", err)
+	}
+	return aptos.TransactionPayload{
+		Payload: &aptos.EntryFunction{
+			Module:   module,
+			Function: "redeem_prepare",
+			ArgTypes: []aptos.TypeTag{},
+			Args:     args,
+		},
+	}, nil
+}
+
+// TokenModuleName btc_tokenv3 模块的名称
+const TokenModuleName = "btc_tokenv3"
+
+// TokenModuleAddress 生成绑定时 btc_tokenv3 模块的发布地址，运行时以配置的模块地址为准
+const TokenModuleAddress = "0x1319db9743efbef92e2ed32e122a4690f466fbbb8e34cd6ccffb93e8cb68447d"
+
+// TokenBTC 对应 btc_tokenv3::BTC
+type TokenBTC struct {
+	DummyField bool `json:"dummy_field"`
+}
+
+// TokenBTCCapabilities 对应 btc_tokenv3::BTCCapabilities 资源
+type TokenBTCCapabilities struct {
+	MintCap   CoinCapability `json:"mint_cap"`
+	BurnCap   CoinCapability `json:"burn_cap"`
+	FreezeCap CoinCapability `json:"freeze_cap"`
+}
+
+// TokenBTCCapabilitiesStructTag btc_tokenv3::BTCCapabilities 不含地址的结构体标签
+const TokenBTCCapabilitiesStructTag = TokenModuleName + "::BTCCapabilities"
+
+// TokenBridgeEvents 对应 btc_tokenv3::BridgeEvents 资源
+type TokenBridgeEvents struct {
+	MintEvents EventHandle `json:"mint_events"`
+	BurnEvents EventHandle `json:"burn_events"`
+}
+
+// TokenBridgeEventsStructTag btc_tokenv3::BridgeEvents 不含地址的结构体标签
+const TokenBridgeEventsStructTag = TokenModuleName + "::BridgeEvents"
+
+// TokenMintEvent 对应 btc_tokenv3::MintEvent 事件
+type TokenMintEvent struct {
+	Amount    uint64 `json:"amount"`
+	Recipient string `json:"recipient"`
+	BtcTxId   string `json:"btc_txid"`
+}
+
+// TokenMintEventStructTag btc_tokenv3::MintEvent 不含地址的结构体标签
+const TokenMintEventStructTag = TokenModuleName + "::MintEvent"
+
+// TokenBurnEvent 对应 btc_tokenv3::BurnEvent 事件
+type TokenBurnEvent struct {
+	Amount     uint64 `json:"amount"`
+	Burner     string `json:"burner"`
+	BtcAddress string `json:"btc_address"`
+}
+
+// TokenBurnEventStructTag btc_tokenv3::BurnEvent 不含地址的结构体标签
+const TokenBurnEventStructTag = TokenModuleName + "::BurnEvent"
+
+// TokenInitializeModulePayload 构建 btc_tokenv3::initialize_module 的交易负载
+// 签名方为 account
+func TokenInitializeModulePayload(moduleAddress string) (aptos.TransactionPayload, error) {
+	module, err := bindingModuleId(moduleAddress, TokenModuleName)
+	if err != nil {
+		return aptos.TransactionPayload{}, err
+	}
+	args := make([][]byte, 0)
+	return aptos.TransactionPayload{
+		Payload: &aptos.EntryFunction{
+			Module:   module,
+			Function: "initialize_module",
+			ArgTypes: []aptos.TypeTag{},
+			Args:     args,
+		},
+	}, nil
+}
+
+// TokenRegisterPayload 构建 btc_tokenv3::register 的交易负载
+// 签名方为 account
+func TokenRegisterPayload(moduleAddress string) (aptos.TransactionPayload, error) {
+	module, err := bindingModuleId(moduleAddress, TokenModuleName)
+	if err != nil {
+		return aptos.TransactionPayload{}, err
+	}
+	args := make([][]byte, 0)
+	return aptos.TransactionPayload{
+		Payload: &aptos.EntryFunction{
+			Module:   module,
+			Function: "register",
+			ArgTypes: []aptos.TypeTag{},
+			Args:     args,
+		},
+	}, nil
+}
+
+// TokenRegisterv2Payload 构建 btc_tokenv3::registerv2 的交易负载
+// 签名方依次为 admin、account，需要以多代理交易提交
+func TokenRegisterv2Payload(moduleAddress string) (aptos.TransactionPayload, error) {
+	module, err := bindingModuleId(moduleAddress, TokenModuleName)
+	if err != nil {
+		return aptos.TransactionPayload{}, err
+	}
+	args := make([][]byte, 0)
+	return aptos.TransactionPayload{
+		Payload: &aptos.EntryFunction{
+			Module:   module,
+			Function: "registerv2",
+			ArgTypes: []aptos.TypeTag{},
+			Args:     args,
+		},
+	}, nil
+}
+
+// TokenMintTokensPayload 构建 btc_tokenv3::mint_tokens 的交易负载
+// 签名方为 admin
+func TokenMintTokensPayload(moduleAddress string, recipient aptos.AccountAddress, amount uint64, btcTxId string) (aptos.TransactionPayload, error) {
+	module, err := bindingModuleId(moduleAddress, TokenModuleName)
+	if err != nil {
+		return aptos.TransactionPayload{}, err
+	}
+	args := make([][]byte, 3)
+	args[0], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		recipient.MarshalBCS(ser)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 recipient 失败: %v", err)
+	}
+	args[1], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		ser.U64(amount)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 amount 失败: %v", err)
+	}
+	args[2], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		ser.WriteString(btcTxId)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 btc_txid 失败: %v", err)
+	}
+	return aptos.TransactionPayload{
+		Payload: &aptos.EntryFunction{
+			Module:   module,
+			Function: "mint_tokens",
+			ArgTypes: []aptos.TypeTag{},
+			Args:     args,
+		},
+	}, nil
+}
+
+// TokenTransferPayload 构建 btc_tokenv3::transfer 的交易负载
+// 签名方为 from
+func TokenTransferPayload(moduleAddress string, to aptos.AccountAddress, amount uint64) (aptos.TransactionPayload, error) {
+	module, err := bindingModuleId(moduleAddress, TokenModuleName)
+	if err != nil {
+		return aptos.TransactionPayload{}, err
+	}
+	args := make([][]byte, 2)
+	args[0], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		to.MarshalBCS(ser)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 to 失败: %v", err)
+	}
+	args[1], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		ser.U64(amount)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 amount 失败: %v", err)
+	}
+	return aptos.TransactionPayload{
+		Payload: &aptos.EntryFunction{
+			Module:   module,
+			Function: "transfer",
+			ArgTypes: []aptos.TypeTag{},
+			Args:     args,
+		},
+	}, nil
+}
+
+// CoinModuleName coin 模块的名称
+const CoinModuleName = "coin"
+
+// CoinModuleAddress coin 模块所在的框架地址
+const CoinModuleAddress = "0x1"
+
+// CoinBalanceView 调用 coin::balance 视图函数，可以指定账本版本
+func CoinBalanceView(client *aptos.Client, moduleAddress string, typeArgs []aptos.TypeTag, owner aptos.AccountAddress, ledgerVersion ...uint64) (result uint64, err error) {
+	module, err := bindingModuleId(moduleAddress, CoinModuleName)
+	if err != nil {
+		return
+	}
+	if len(typeArgs) != 1 {
+		err = fmt.Errorf("%s::balance 需要 1 个类型参数, 实际为 %d", CoinModuleName, len(typeArgs))
+		return
+	}
+	args := make([][]byte, 1)
+	args[0], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		owner.MarshalBCS(ser)
+	})
+	if err != nil {
+		err = fmt.Errorf("序列化参数 owner 失败: %v", err)
+		return
+	}
+	values, err := client.View(&aptos.ViewPayload{
+		Module:   module,
+		Function: "balance",
+		ArgTypes: typeArgs,
+		Args:     args,
+	}, ledgerVersion...)
+	if err != nil {
+		err = fmt.Errorf("调用 %s::balance 失败: %v", CoinModuleName, err)
+		return
+	}
+	if len(values) != 1 {
+		err = fmt.Errorf("%s::balance 返回 %d 个值, 期望 1 个", CoinModuleName, len(values))
+		return
+	}
+	err = decodeMoveValue(values[0], &result)
+	if err != nil {
+		err = fmt.Errorf("解析 %s::balance 的返回值失败: %v", CoinModuleName, err)
+		return
+	}
+	return
+}
+
+// CoinIsAccountRegisteredView 调用 coin::is_account_registered 视图函数，可以指定账本版本
+func CoinIsAccountRegisteredView(client *aptos.Client, moduleAddress string, typeArgs []aptos.TypeTag, accountAddr aptos.AccountAddress, ledgerVersion ...uint64) (result bool, err error) {
+	module, err := bindingModuleId(moduleAddress, CoinModuleName)
+	if err != nil {
+		return
+	}
+	if len(typeArgs) != 1 {
+		err = fmt.Errorf("%s::is_account_registered 需要 1 个类型参数, 实际为 %d", CoinModuleName, len(typeArgs))
+		return
+	}
+	args := make([][]byte, 1)
+	args[0], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		accountAddr.MarshalBCS(ser)
+	})
+	if err != nil {
+		err = fmt.Errorf("序列化参数 account_addr 失败: %v", err)
+		return
+	}
+	values, err := client.View(&aptos.ViewPayload{
+		Module:   module,
+		Function: "is_account_registered",
+		ArgTypes: typeArgs,
+		Args:     args,
+	}, ledgerVersion...)
+	if err != nil {
+		err = fmt.Errorf("调用 %s::is_account_registered 失败: %v", CoinModuleName, err)
+		return
+	}
+	if len(values) != 1 {
+		err = fmt.Errorf("%s::is_account_registered 返回 %d 个值, 期望 1 个", CoinModuleName, len(values))
+		return
+	}
+	err = decodeMoveValue(values[0], &result)
+	if err != nil {
+		err = fmt.Errorf("解析 %s::is_account_registered 的返回值失败: %v", CoinModuleName, err)
+		return
+	}
+	return
+}
+
+// CoinSupplyView 调用 coin::supply 视图函数，可以指定账本版本
+func CoinSupplyView(client *aptos.Client, moduleAddress string, typeArgs []aptos.TypeTag, ledgerVersion ...uint64) (result struct {
+	Vec []string `json:"vec"`
+}, err error) {
+	module, err := bindingModuleId(moduleAddress, CoinModuleName)
+	if err != nil {
+		return
+	}
+	if len(typeArgs) != 1 {
+		err = fmt.Errorf("%s::supply 需要 1 个类型参数, 实际为 %d", CoinModuleName, len(typeArgs))
+		return
+	}
+	args := make([][]byte, 0)
+	values, err := client.View(&aptos.ViewPayload{
+		Module:   module,
+		Function: "supply",
+		ArgTypes: typeArgs,
+		Args:     args,
+	}, ledgerVersion...)
+	if err != nil {
+		err = fmt.Errorf("调用 %s::supply 失败: %v", CoinModuleName, err)
+		return
+	}
+	if len(values) != 1 {
+		err = fmt.Errorf("%s::supply 返回 %d 个值, 期望 1 个", CoinModuleName, len(values))
+		return
+	}
+	err = decodeMoveValue(values[0], &result)
+	if err != nil {
+		err = fmt.Errorf("解析 %s::supply 的返回值失败: %v", CoinModuleName, err)
+		return
+	}
+	return
+}
+
+// CoinPairedMetadataView 调用 coin::paired_metadata 视图函数，可以指定账本版本
+func CoinPairedMetadataView(client *aptos.Client, moduleAddress string, typeArgs []aptos.TypeTag, ledgerVersion ...uint64) (result struct {
+	Vec []struct {
+		Inner string `json:"inner"`
+	} `json:"vec"`
+}, err error) {
+	module, err := bindingModuleId(moduleAddress, CoinModuleName)
+	if err != nil {
+		return
+	}
+	if len(typeArgs) != 1 {
+		err = fmt.Errorf("%s::paired_metadata 需要 1 个类型参数, 实际为 %d", CoinModuleName, len(typeArgs))
+		return
+	}
+	args := make([][]byte, 0)
+	values, err := client.View(&aptos.ViewPayload{
+		Module:   module,
+		Function: "paired_metadata",
+		ArgTypes: typeArgs,
+		Args:     args,
+	}, ledgerVersion...)
+	if err != nil {
+		err = fmt.Errorf("调用 %s::paired_metadata 失败: %v", CoinModuleName, err)
+		return
+	}
+	if len(values) != 1 {
+		err = fmt.Errorf("%s::paired_metadata 返回 %d 个值, 期望 1 个", CoinModuleName, len(values))
+		return
+	}
+	err = decodeMoveValue(values[0], &result)
+	if err != nil {
+		err = fmt.Errorf("解析 %s::paired_metadata 的返回值失败: %v", CoinModuleName, err)
+		return
+	}
+	return
+}
+
+// CoinMigrateToFungibleStorePayload 构建 coin::migrate_to_fungible_store 的交易负载
+// 签名方为 account
+func CoinMigrateToFungibleStorePayload(moduleAddress string, typeArgs []aptos.TypeTag) (aptos.TransactionPayload, error) {
+	module, err := bindingModuleId(moduleAddress, CoinModuleName)
+	if err != nil {
+		return aptos.TransactionPayload{}, err
+	}
+	if len(typeArgs) != 1 {
+		return aptos.TransactionPayload{}, fmt.Errorf("%s::migrate_to_fungible_store 需要 1 个类型参数, 实际为 %d", CoinModuleName, len(typeArgs))
+	}
+	args := make([][]byte, 0)
+	return aptos.TransactionPayload{
+		Payload: &aptos.EntryFunction{
+			Module:   module,
+			Function: "migrate_to_fungible_store",
+			ArgTypes: typeArgs,
+			Args:     args,
+		},
+	}, nil
+}
+
+// PrimaryStoreModuleName primary_fungible_store 模块的名称
+const PrimaryStoreModuleName = "primary_fungible_store"
+
+// PrimaryStoreModuleAddress primary_fungible_store 模块所在的框架地址
+const PrimaryStoreModuleAddress = "0x1"
+
+// PrimaryStoreBalanceView 调用 primary_fungible_store::balance 视图函数，可以指定账本版本
+func PrimaryStoreBalanceView(client *aptos.Client, moduleAddress string, typeArgs []aptos.TypeTag, account aptos.AccountAddress, metadata aptos.AccountAddress, ledgerVersion ...uint64) (result uint64, err error) {
+	module, err := bindingModuleId(moduleAddress, PrimaryStoreModuleName)
+	if err != nil {
+		return
+	}
+	if len(typeArgs) != 1 {
+		err = fmt.Errorf("%s::balance 需要 1 个类型参数, 实际为 %d", PrimaryStoreModuleName, len(typeArgs))
+		return
+	}
+	args := make([][]byte, 2)
+	args[0], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		account.MarshalBCS(ser)
+	})
+	if err != nil {
+		err = fmt.Errorf("序列化参数 account 失败: %v", err)
+		return
+	}
+	args[1], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		metadata.MarshalBCS(ser)
+	})
+	if err != nil {
+		err = fmt.Errorf("序列化参数 metadata 失败: %v", err)
+		return
+	}
+	values, err := client.View(&aptos.ViewPayload{
+		Module:   module,
+		Function: "balance",
+		ArgTypes: typeArgs,
+		Args:     args,
+	}, ledgerVersion...)
+	if err != nil {
+		err = fmt.Errorf("调用 %s::balance 失败: %v", PrimaryStoreModuleName, err)
+		return
+	}
+	if len(values) != 1 {
+		err = fmt.Errorf("%s::balance 返回 %d 个值, 期望 1 个", PrimaryStoreModuleName, len(values))
+		return
+	}
+	err = decodeMoveValue(values[0], &result)
+	if err != nil {
+		err = fmt.Errorf("解析 %s::balance 的返回值失败: %v", PrimaryStoreModuleName, err)
+		return
+	}
+	return
+}
+
+// PrimaryStoreExistsView 调用 primary_fungible_store::primary_store_exists 视图函数，可以指定账本版本
+func PrimaryStoreExistsView(client *aptos.Client, moduleAddress string, typeArgs []aptos.TypeTag, account aptos.AccountAddress, metadata aptos.AccountAddress, ledgerVersion ...uint64) (result bool, err error) {
+	module, err := bindingModuleId(moduleAddress, PrimaryStoreModuleName)
+	if err != nil {
+		return
+	}
+	if len(typeArgs) != 1 {
+		err = fmt.Errorf("%s::primary_store_exists 需要 1 个类型参数, 实际为 %d", PrimaryStoreModuleName, len(typeArgs))
+		return
+	}
+	args := make([][]byte, 2)
+	args[0], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		account.MarshalBCS(ser)
+	})
+	if err != nil {
+		err = fmt.Errorf("序列化参数 account 失败: %v", err)
+		return
+	}
+	args[1], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		metadata.MarshalBCS(ser)
+	})
+	if err != nil {
+		err = fmt.Errorf("序列化参数 metadata 失败: %v", err)
+		return
+	}
+	values, err := client.View(&aptos.ViewPayload{
+		Module:   module,
+		Function: "primary_store_exists",
+		ArgTypes: typeArgs,
+		Args:     args,
+	}, ledgerVersion...)
+	if err != nil {
+		err = fmt.Errorf("调用 %s::primary_store_exists 失败: %v", PrimaryStoreModuleName, err)
+		return
+	}
+	if len(values) != 1 {
+		err = fmt.Errorf("%s::primary_store_exists 返回 %d 个值, 期望 1 个", PrimaryStoreModuleName, len(values))
+		return
+	}
+	err = decodeMoveValue(values[0], &result)
+	if err != nil {
+		err = fmt.Errorf("解析 %s::primary_store_exists 的返回值失败: %v", PrimaryStoreModuleName, err)
+		return
+	}
+	return
+}
+
+// PrimaryStoreTransferPayload 构建 primary_fungible_store::transfer 的交易负载
+// 签名方为 sender
+func PrimaryStoreTransferPayload(moduleAddress string, typeArgs []aptos.TypeTag, metadata aptos.AccountAddress, recipient aptos.AccountAddress, amount uint64) (aptos.TransactionPayload, error) {
+	module, err := bindingModuleId(moduleAddress, PrimaryStoreModuleName)
+	if err != nil {
+		return aptos.TransactionPayload{}, err
+	}
+	if len(typeArgs) != 1 {
+		return aptos.TransactionPayload{}, fmt.Errorf("%s::transfer 需要 1 个类型参数, 实际为 %d", PrimaryStoreModuleName, len(typeArgs))
+	}
+	args := make([][]byte, 3)
+	args[0], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		metadata.MarshalBCS(ser)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 metadata 失败: %v", err)
+	}
+	args[1], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		recipient.MarshalBCS(ser)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 recipient 失败: %v", err)
+	}
+	args[2], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		ser.U64(amount)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 amount 失败: %v", err)
+	}
+	return aptos.TransactionPayload{
+		Payload: &aptos.EntryFunction{
+			Module:   module,
+			Function: "transfer",
+			ArgTypes: typeArgs,
+			Args:     args,
+		},
+	}, nil
+}
+
+// CodeModuleName code 模块的名称
+const CodeModuleName = "code"
+
+// CodeModuleAddress code 模块所在的框架地址
+const CodeModuleAddress = "0x1"
+
+// CodePublishPackageTxnPayload 构建 code::publish_package_txn 的交易负载
+// 签名方为 owner
+func CodePublishPackageTxnPayload(moduleAddress string, metadataSerialized []byte, code [][]byte) (aptos.TransactionPayload, error) {
+	module, err := bindingModuleId(moduleAddress, CodeModuleName)
+	if err != nil {
+		return aptos.TransactionPayload{}, err
+	}
+	args := make([][]byte, 2)
+	args[0], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		ser.WriteBytes(metadataSerialized)
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 metadata_serialized 失败: %v", err)
+	}
+	args[1], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {
+		bcs.SerializeSequenceWithFunction(code, ser, func(ser *bcs.Serializer, item0 []byte) {
+			ser.WriteBytes(item0)
+		})
+	})
+	if err != nil {
+		return aptos.TransactionPayload{}, fmt.Errorf("序列化参数 code 失败: %v", err)
+	}
+	return aptos.TransactionPayload{
+		Payload: &aptos.EntryFunction{
+			Module:   module,
+			Function: "publish_package_txn",
+			ArgTypes: []aptos.TypeTag{},
+			Args:     args,
+		},
+	}, nil
+}
+
+// bindingEntryFunctions 入口函数的非 signer 参数，按 module::function 索引
+var bindingEntryFunctions = map[string][]MoveParam{
+	BridgeModuleName + "::initialize": {
+		{"fee_account", "address"},
+		{"fee", "u64"},
+	},
+	BridgeModuleName + "::mint": {
+		{"btc_tx_id", "0x1::string::String"},
+		{"receiver", "address"},
+		{"amount", "u64"},
+	},
+	BridgeModuleName + "::redeem_request": {
+		{"amount", "u64"},
+		{"receiver", "0x1::string::String"},
+	},
+	BridgeModuleName + "::redeem_prepare": {
+		{"redeem_request_tx_hash", "0x1::string::String"},
+		{"requester", "address"},
+		{"receiver", "0x1::string::String"},
+		{"amount", "u64"},
+		{"outpoint_tx_ids", "vector<0x1::string::String>"},
+		{"outpoint_idxs", "vector<u64>"},
+	},
+	TokenModuleName + "::initialize_module": {},
+	TokenModuleName + "::register":          {},
+	TokenModuleName + "::registerv2":        {},
+	TokenModuleName + "::mint_tokens": {
+		{"recipient", "address"},
+		{"amount", "u64"},
+		{"btc_txid", "0x1::string::String"},
+	},
+	TokenModuleName + "::transfer": {
+		{"to", "address"},
+		{"amount", "u64"},
+	},
+	CoinModuleName + "::migrate_to_fungible_store": {},
+	PrimaryStoreModuleName + "::transfer": {
+		{"metadata", "0x1::object::Object<T0>"},
+		{"recipient", "address"},
+		{"amount", "u64"},
+	},
+	CodeModuleName + "::publish_package_txn": {
+		{"metadata_serialized", "vector<u8>"},
+		{"code", "vector<vector<u8>>"},
+	},
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+const (
+	// 每个账户至少需要的 APT，不足时从水龙头领取
+	defaultBootstrapMinOctas uint64 = 1_0000_0000
+
+	// 冒烟测试铸币的金额 (satoshi)，需要大于桥费用和代币铸币手续费
+	defaultBootstrapMintAmount uint64 = 100000
+
+	// 冒烟测试铸币使用的 btc_tx_id，记录在 MintedTransactions 中，重复执行时据此跳过
+	bootstrapSmokeBtcTxId = "bootstrap-smoke-test"
+
+	// BTC_NETWORK 为 testnet 时默认的赎回接收地址 (BIP-173 测试向量)
+	defaultBootstrapTestnetReceiver = "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"
+
+	// 主网的链 ID，主网没有水龙头
+	aptosMainnetChainId uint8 = 1
+)
+
+// BootstrapConfig bootstrap 的参数
+type BootstrapConfig struct {
+	Package        *CompiledPackage
+	FeeAccount     aptos.TransactionSigner // 为 nil 时由管理员收取费用
+	Fee            uint64
+	MinOctas       uint64
+	MintAmount     uint64
+	RedeemAmount   uint64
+	RedeemReceiver string // 为空时不能执行测试赎回
+	// 主网上测试铸币和赎回会真实发行和销毁 TWBTC，默认跳过，为 true 时才执行
+	MainnetSmokeTest bool
+}
+
+// loadBootstrapConfig 从环境变量读取 bootstrap 的可选参数：
+// FEE_ACCOUNT_PRIVATE_KEY 费用账户私钥(不设置时由管理员收取费用)，BOOTSTRAP_MIN_OCTAS 每个账户至少需要的 APT，
+// BOOTSTRAP_MINT_AMOUNT 测试铸币金额(测试赎回金额为其一半)，BOOTSTRAP_REDEEM_RECEIVER 测试赎回的 BTC 接收地址，
+// BOOTSTRAP_MAINNET_SMOKE_TEST=true 在主网上也执行测试铸币和赎回
+func loadBootstrapConfig(pkg *CompiledPackage, fee uint64) (*BootstrapConfig, error) {
+	config := &BootstrapConfig{
+		Package:    pkg,
+		Fee:        fee,
+		MinOctas:   defaultBootstrapMinOctas,
+		MintAmount: defaultBootstrapMintAmount,
+	}
+	if key := os.Getenv("FEE_ACCOUNT_PRIVATE_KEY"); key != "" {
+		feeAccount, err := createAccountFromPrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("创建费用账户失败: %v", err)
+		}
+		config.FeeAccount = feeAccount
+	}
+	envs := []struct {
+		name  string
+		value *uint64
+	}{
+		{"BOOTSTRAP_MIN_OCTAS", &config.MinOctas},
+		{"BOOTSTRAP_MINT_AMOUNT", &config.MintAmount},
+	}
+	for _, env := range envs {
+		valueStr := os.Getenv(env.name)
+		if valueStr == "" {
+			continue
+		}
+		value, err := strconv.ParseUint(valueStr, 10, 64)
+		if err != nil || value == 0 {
+			return nil, fmt.Errorf("无效的%s: %s", env.name, valueStr)
+		}
+		*env.value = value
+	}
+	config.RedeemAmount = config.MintAmount / 2
+	if config.MintAmount <= max(fee, twbtcTokenMintFee) || config.RedeemAmount <= fee {
+		return nil, fmt.Errorf("测试铸币金额 %d 太小：需要大于代币铸币手续费 %d，且一半需要大于桥费用 %d", config.MintAmount, twbtcTokenMintFee, fee)
+	}
+
+	switch value := os.Getenv("BOOTSTRAP_MAINNET_SMOKE_TEST"); value {
+	case "", "false":
+	case "true":
+		config.MainnetSmokeTest = true
+	default:
+		return nil, fmt.Errorf("无效的BOOTSTRAP_MAINNET_SMOKE_TEST: %s", value)
+	}
+
+	network, err := loadBtcNetwork()
+	if err != nil {
+		return nil, err
+	}
+	config.RedeemReceiver = os.Getenv("BOOTSTRAP_REDEEM_RECEIVER")
+	if config.RedeemReceiver == "" {
+		// 非测试网没有默认地址，执行测试赎回前再报错，主网上跳过冒烟测试时不需要
+		if network.Name == "testnet" {
+			config.RedeemReceiver = defaultBootstrapTestnetReceiver
+		}
+		return config, nil
+	}
+	_, err = validateRedeemReceiver(config.RedeemReceiver)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// bootstrapStep 检查清单中的一步，Done 根据链上状态判断是否已完成
+type bootstrapStep struct {
+	Name string
+	Done func() (bool, error)
+	Run  func() (string, error)
+}
+
+// 检查清单中一步的结果
+const (
+	BootstrapSkipped  = "已完成，跳过"
+	BootstrapRan      = "完成"
+	BootstrapFailed   = "失败"
+	BootstrapPending  = "未执行"
+	BootstrapDisabled = "主网未启用，跳过"
+)
+
+// BootstrapStepResult 一步的执行结果
+type BootstrapStepResult struct {
+	Name   string
+	Status string
+	TxHash string
+	Err    error
+}
+
+// BootstrapReport bootstrap 的冒烟测试报告
+type BootstrapReport struct {
+	Steps      []BootstrapStepResult
+	State      *BridgeState // 全部步骤完成后读取
+	Admin      aptos.AccountAddress
+	FeeAccount aptos.AccountAddress
+	Balances   map[aptos.AccountAddress]*TWBTCBalances
+}
+
+// Failed 是否有步骤失败
+func (r *BootstrapReport) Failed() bool {
+	for _, step := range r.Steps {
+		if step.Status == BootstrapFailed || step.Status == BootstrapPending {
+			return true
+		}
+	}
+	return false
+}
+
+// RunBootstrap 按检查清单搭建新环境：充值账户、发布合约、初始化桥、注册 TWBTC，最后测试铸币和赎回
+// 每一步先检查链上状态，已完成的跳过，执行后再次检查确认生效；某一步失败时后面的步骤不再执行，修复后重新运行即可继续。
+// 主网上除非 config.MainnetSmokeTest 为 true，否则不执行测试铸币和赎回
+func RunBootstrap(client *aptos.Client, admin aptos.TransactionSigner, moduleAddress string, config *BootstrapConfig) (*BootstrapReport, error) {
+	adminAddress := admin.AccountAddress()
+	err := checkBridgePackage(adminAddress, moduleAddress, config.Package)
+	if err != nil {
+		return nil, err
+	}
+	info, err := client.Info()
+	if err != nil {
+		return nil, fmt.Errorf("获取节点信息失败: %v", err)
+	}
+	mainnet := info.ChainId == aptosMainnetChainId
+	faucetAvailable := !mainnet
+	smokeTest := !mainnet || config.MainnetSmokeTest
+	if smokeTest && config.RedeemReceiver == "" {
+		return nil, fmt.Errorf("BTC_NETWORK 不是 testnet 时需要用 BOOTSTRAP_REDEEM_RECEIVER 指定测试赎回的接收地址")
+	}
+
+	report := &BootstrapReport{Admin: adminAddress, FeeAccount: adminAddress}
+	if config.FeeAccount != nil {
+		report.FeeAccount = config.FeeAccount.AccountAddress()
+	}
+
+	fundStep := func(name string, address aptos.AccountAddress) bootstrapStep {
+		return bootstrapStep{
+			Name: name,
+			Done: func() (bool, error) {
+				_, err := client.Account(address)
+				if isResourceNotFound(err) {
+					return false, nil
+				}
+				if err != nil {
+					return false, fmt.Errorf("获取账户信息失败: %v", err)
+				}
+				balance, err := client.AccountAPTBalance(address)
+				if err != nil {
+					return false, fmt.Errorf("获取APT余额失败: %v", err)
+				}
+				return balance >= config.MinOctas, nil
+			},
+			Run: func() (string, error) {
+				if !faucetAvailable {
+					return "", fmt.Errorf("主网没有水龙头，请向 %s 手动充值至少 %s APT", address.String(), formatOctas(config.MinOctas))
+				}
+				// 多领取一倍，后续步骤消耗gas后重新运行时余额仍不低于下限，不会再次领取
+				err := client.Fund(address, 2*config.MinOctas)
+				if err != nil {
+					return "", fmt.Errorf("从水龙头领取APT失败: %v", err)
+				}
+				return "", nil
+			},
+		}
+	}
+	registerStep := func(name string, address aptos.AccountAddress, run func() (string, error)) bootstrapStep {
+		return bootstrapStep{
+			Name: name,
+			Done: func() (bool, error) {
+				return IsTWBTCRegistered(client, address, moduleAddress)
+			},
+			Run: run,
+		}
+	}
+
+	steps := []bootstrapStep{fundStep("管理员账户充值", adminAddress)}
+	if config.FeeAccount != nil {
+		steps = append(steps, fundStep("费用账户充值", report.FeeAccount))
+	}
+	steps = append(steps,
+		bootstrapStep{
+			Name: "发布合约包 " + config.Package.Metadata.Name,
+			Done: func() (bool, error) {
+				return isPackagePublished(client, adminAddress, config.Package.Metadata)
+			},
+			Run: func() (string, error) {
+				txHash, _, err := PublishPackage(client, admin, config.Package)
+				return txHash, err
+			},
+		},
+		// initialize_module 最后调用 registerv2(admin, admin)，合约要求管理员在初始化前已注册
+		registerStep("管理员注册TWBTC", adminAddress, func() (string, error) {
+			return RegisterTWBTC(client, admin, moduleAddress)
+		}),
+		bootstrapStep{
+			Name: "初始化桥",
+			Done: func() (bool, error) {
+				initialized, err := moduleResourceExists(client, moduleAddress, BridgeConfigStructTag)
+				if err != nil || !initialized {
+					return false, err
+				}
+				// 已初始化的桥不能修改费用配置，与期望不一致时视为失败
+				bridgeConfig, err := GetBridgeConfig(client, moduleAddress)
+				if err != nil {
+					return false, err
+				}
+				feeAccount := aptos.AccountAddress{}
+				err = feeAccount.ParseStringRelaxed(bridgeConfig.FeeAccount)
+				if err != nil {
+					return false, fmt.Errorf("解析费用账户地址失败: %v", err)
+				}
+				if bridgeConfig.Fee != config.Fee || feeAccount != report.FeeAccount {
+					return false, fmt.Errorf("桥已初始化为费用 %d、费用账户 %s，与期望的 %d、%s 不一致",
+						bridgeConfig.Fee, bridgeConfig.FeeAccount, config.Fee, report.FeeAccount.String())
+				}
+				return true, nil
+			},
+			Run: func() (string, error) {
+				return ensureBridgeInitialized(client, admin, moduleAddress, report.FeeAccount, config.Fee)
+			},
+		},
+	)
+	if config.FeeAccount != nil {
+		steps = append(steps, registerStep("费用账户注册TWBTC", report.FeeAccount, func() (string, error) {
+			return RegisterTWBTC(client, config.FeeAccount, moduleAddress)
+		}))
+	}
+	smokeSteps := []bootstrapStep{
+		{
+			Name: fmt.Sprintf("测试铸币 %d satoshi (btc_tx_id %s)", config.MintAmount, bootstrapSmokeBtcTxId),
+			Done: func() (bool, error) {
+				return isBtcTxMinted(client, moduleAddress, bootstrapSmokeBtcTxId)
+			},
+			Run: func() (string, error) {
+				return mintTWBTC(client, admin, moduleAddress, adminAddress, config.MintAmount, bootstrapSmokeBtcTxId)
+			},
+		},
+		{
+			Name: fmt.Sprintf("测试赎回 %d satoshi 到 %s", config.RedeemAmount, config.RedeemReceiver),
+			Done: func() (bool, error) {
+				return hasRedeemRequest(client, moduleAddress, adminAddress, config.RedeemReceiver, config.RedeemAmount)
+			},
+			Run: func() (string, error) {
+				return redeemRequest(client, admin, moduleAddress, config.RedeemReceiver, config.RedeemAmount)
+			},
+		},
+	}
+	var disabled []BootstrapStepResult
+	if smokeTest {
+		steps = append(steps, smokeSteps...)
+	} else {
+		for _, step := range smokeSteps {
+			disabled = append(disabled, BootstrapStepResult{Name: step.Name, Status: BootstrapDisabled})
+		}
+	}
+
+	for i, step := range steps {
+		result := runBootstrapStep(step)
+		report.Steps = append(report.Steps, result)
+		if result.Status == BootstrapFailed {
+			for _, pending := range steps[i+1:] {
+				report.Steps = append(report.Steps, BootstrapStepResult{Name: pending.Name, Status: BootstrapPending})
+			}
+			report.Steps = append(report.Steps, disabled...)
+			return report, nil
+		}
+	}
+	report.Steps = append(report.Steps, disabled...)
+
+	report.State, err = ReadBridgeState(client, moduleAddress)
+	if err != nil {
+		return report, err
+	}
+	report.Balances = make(map[aptos.AccountAddress]*TWBTCBalances)
+	for _, address := range []aptos.AccountAddress{report.Admin, report.FeeAccount} {
+		report.Balances[address], err = GetTWBTCBalances(client, address, moduleAddress, report.State.LedgerVersion)
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// runBootstrapStep 已完成时跳过，否则执行并确认链上状态已更新
+func runBootstrapStep(step bootstrapStep) BootstrapStepResult {
+	result := BootstrapStepResult{Name: step.Name}
+	done, err := step.Done()
+	if err != nil {
+		result.Status, result.Err = BootstrapFailed, err
+		return result
+	}
+	if done {
+		result.Status = BootstrapSkipped
+		return result
+	}
+
+	logInfo(fmt.Sprintf("执行: %s", step.Name))
+	result.TxHash, err = step.Run()
+	if err != nil {
+		result.Status, result.Err = BootstrapFailed, err
+		return result
+	}
+	done, err = step.Done()
+	if err == nil && !done {
+		err = fmt.Errorf("执行后链上状态仍未完成")
+	}
+	if err != nil {
+		result.Status, result.Err = BootstrapFailed, err
+		return result
+	}
+	result.Status = BootstrapRan
+	return result
+}
+
+// hasRedeemRequest 最近的赎回请求事件中是否有 sender 向 receiver 赎回 amount 的请求
+func hasRedeemRequest(client *aptos.Client, moduleAddress string, sender aptos.AccountAddress, receiver string, amount uint64) (bool, error) {
+	events, err := GetRedeemRequestEvents(client, moduleAddress, 100)
+	if err != nil {
+		return false, err
+	}
+	for _, event := range events {
+		eventSender := aptos.AccountAddress{}
+		if eventSender.ParseStringRelaxed(event.Sender) != nil {
+			continue
+		}
+		if eventSender == sender && event.Receiver == receiver && event.Amount == amount {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// printBootstrapReport 打印检查清单和冒烟测试报告
+func printBootstrapReport(report *BootstrapReport) {
+	fmt.Println("===== bootstrap 检查清单 =====")
+	for i, step := range report.Steps {
+		line := fmt.Sprintf("%d. [%s] %s", i+1, step.Status, step.Name)
+		if step.TxHash != "" {
+			line += fmt.Sprintf(" (交易哈希 %s)", step.TxHash)
+		}
+		fmt.Println(line)
+		if step.Err != nil {
+			fmt.Printf("   错误: %v\n", step.Err)
+		}
+	}
+	if report.State == nil {
+		return
+	}
+
+	fmt.Println()
+	printBridgeState(report.State)
+	fmt.Println()
+	fmt.Println("===== 冒烟测试 =====")
+	fmt.Printf("测试铸币 %s 已记录: %v\n", bootstrapSmokeBtcTxId, slices.Contains(report.State.Minted, bootstrapSmokeBtcTxId))
+	printTWBTCBalances(report.Admin.String()+" (管理员)", report.Balances[report.Admin])
+	if report.FeeAccount != report.Admin {
+		printTWBTCBalances(report.FeeAccount.String()+" (费用账户)", report.Balances[report.FeeAccount])
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// newTestBootstrap 只安装桥参考模型、未发布合约的模拟节点，admin 尚未创建账户
+func newTestBootstrap(t *testing.T, chainId uint8) (*FakeFullnode, *aptos.Client, *aptos.Account, *BootstrapConfig) {
+	t.Helper()
+	node := NewFakeFullnode()
+	t.Cleanup(node.Close)
+	t.Setenv("APTOS_NODE_URL", node.URL())
+	node.mu.Lock()
+	node.chainId = chainId
+	node.mu.Unlock()
+	admin := newTestAccount(t, t.Name()+"-admin")
+	node.InstallBridgeModel(admin.Address)
+	client, err := node.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &BootstrapConfig{
+		Package:        testCompiledPackage("bridge", upgradePolicyCompatible, "V1", TokenModuleName, BridgeModuleName),
+		Fee:            defaultFakeNodeBridgeFee,
+		MinOctas:       defaultBootstrapMinOctas,
+		MintAmount:     defaultBootstrapMintAmount,
+		RedeemAmount:   defaultBootstrapMintAmount / 2,
+		RedeemReceiver: defaultBootstrapTestnetReceiver,
+	}
+	return node, client, admin, config
+}
+
+// committedCalls 按 模块::函数 统计 sender 已成功上链的入口函数调用
+func committedCalls(node *FakeFullnode, sender aptos.AccountAddress) map[string]int {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	calls := make(map[string]int)
+	for _, txn := range node.transactions {
+		if !txn.Committed || !txn.Success || txn.Signed.Transaction.Sender != sender {
+			continue
+		}
+		if entry, ok := txn.Signed.Transaction.Payload.Payload.(*aptos.EntryFunction); ok {
+			calls[entry.Module.Name+"::"+entry.Function]++
+		}
+	}
+	return calls
+}
+
+func expectBootstrapStatuses(t *testing.T, report *BootstrapReport, expected ...string) {
+	t.Helper()
+	var statuses []string
+	for _, step := range report.Steps {
+		statuses = append(statuses, step.Status)
+	}
+	if !slices.Equal(statuses, expected) {
+		t.Fatalf("各步骤结果应为 %v，实际 %v", expected, statuses)
+	}
+}
+
+func TestRunBootstrapResumesAfterInterruption(t *testing.T) {
+	node, client, admin, config := newTestBootstrap(t, fakeNodeChainId)
+	moduleAddress := admin.Address.String()
+
+	// 初始化桥时中断，之前完成的步骤已上链
+	node.HandleEntryFunction(moduleAddress+"::btc_bridgev3::initialize", func(ctx *FakeTxContext) error {
+		return errors.New("节点中断")
+	})
+	report, err := RunBootstrap(client, admin, moduleAddress, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectBootstrapStatuses(t, report, BootstrapRan, BootstrapRan, BootstrapRan, BootstrapFailed, BootstrapPending, BootstrapPending)
+	if !report.Failed() || report.State != nil {
+		t.Fatalf("中断后报告应为失败且不读取桥状态")
+	}
+
+	// 恢复后重新运行，从失败的步骤继续
+	node.InstallBridgeModel(admin.Address)
+	report, err = RunBootstrap(client, admin, moduleAddress, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectBootstrapStatuses(t, report, BootstrapSkipped, BootstrapSkipped, BootstrapSkipped, BootstrapRan, BootstrapRan, BootstrapRan)
+	if report.Failed() || report.State == nil || !slices.Contains(report.State.Minted, bootstrapSmokeBtcTxId) {
+		t.Fatalf("重新运行后应完成并记录测试铸币: %+v", report.State)
+	}
+
+	report, err = RunBootstrap(client, admin, moduleAddress, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectBootstrapStatuses(t, report, BootstrapSkipped, BootstrapSkipped, BootstrapSkipped, BootstrapSkipped, BootstrapSkipped, BootstrapSkipped)
+
+	calls := committedCalls(node, admin.Address)
+	expected := []string{"code::publish_package_txn", TokenModuleName + "::register", BridgeModuleName + "::initialize", BridgeModuleName + "::mint", BridgeModuleName + "::redeem_request"}
+	if len(calls) != len(expected) {
+		t.Fatalf("应只调用 %v，实际 %v", expected, calls)
+	}
+	for _, function := range expected {
+		if calls[function] != 1 {
+			t.Fatalf("%s 应只上链一次，实际 %v", function, calls)
+		}
+	}
+}
+
+func TestRunBootstrapSkipsSmokeTestOnMainnet(t *testing.T) {
+	node, client, admin, config := newTestBootstrap(t, aptosMainnetChainId)
+	moduleAddress := admin.Address.String()
+	// 主网没有水龙头，管理员需预先充值
+	err := node.CreateAccount(admin.Address, fakeNodeFundOctas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := RunBootstrap(client, admin, moduleAddress, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectBootstrapStatuses(t, report, BootstrapSkipped, BootstrapRan, BootstrapRan, BootstrapRan, BootstrapDisabled, BootstrapDisabled)
+	if report.Failed() || slices.Contains(report.State.Minted, bootstrapSmokeBtcTxId) {
+		t.Fatalf("主网默认不应执行测试铸币: %+v", report.State)
+	}
+	calls := committedCalls(node, admin.Address)
+	if calls[BridgeModuleName+"::mint"] != 0 || calls[BridgeModuleName+"::redeem_request"] != 0 {
+		t.Fatalf("主网默认不应提交测试铸币和赎回: %v", calls)
+	}
+
+	// 显式启用后执行冒烟测试，BTC 非测试网时需要指定赎回接收地址
+	config.MainnetSmokeTest = true
+	config.RedeemReceiver = ""
+	_, err = RunBootstrap(client, admin, moduleAddress, config)
+	if err == nil {
+		t.Fatalf("启用冒烟测试但没有赎回接收地址时应报错")
+	}
+	config.RedeemReceiver = defaultBootstrapTestnetReceiver
+	report, err = RunBootstrap(client, admin, moduleAddress, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectBootstrapStatuses(t, report, BootstrapSkipped, BootstrapSkipped, BootstrapSkipped, BootstrapSkipped, BootstrapRan, BootstrapRan)
+	calls = committedCalls(node, admin.Address)
+	if calls[BridgeModuleName+"::mint"] != 1 || calls[BridgeModuleName+"::redeem_request"] != 1 {
+		t.Fatalf("启用后应各提交一次测试铸币和赎回: %v", calls)
+	}
+}
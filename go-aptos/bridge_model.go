@@ -0,0 +1,560 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// btc_tokenv3::MAX_BTC_SUPPLY，2100万 BTC，8 位小数
+const twbtcMaxSupply uint64 = 21_000_000 * 100_000_000
+
+// btc_bridgev3 的中止原因
+const (
+	bridgeErrNotAuthorized          uint64 = 1
+	bridgeErrAlreadyInitialized     uint64 = 2
+	bridgeErrZeroAddress            uint64 = 3
+	bridgeErrZeroFee                uint64 = 4
+	bridgeErrAlreadyMinted          uint64 = 5
+	bridgeErrInsufficientAmount     uint64 = 6
+	bridgeErrAlreadyPrepared        uint64 = 8
+	bridgeErrZeroTxHash             uint64 = 9
+	bridgeErrEmptyString            uint64 = 10
+	bridgeErrZeroAmount             uint64 = 11
+	bridgeErrEmptyOutpointTxIds     uint64 = 12
+	bridgeErrEmptyOutpointIdxs      uint64 = 13
+	bridgeErrOutpointLengthMismatch uint64 = 14
+	bridgeErrZeroOutpointTxId       uint64 = 15
+	bridgeErrBtcTxIdAlreadyUsed     uint64 = 16
+)
+
+// btc_tokenv3 的中止原因
+const (
+	tokenErrNotAuthorized       uint64 = 1
+	tokenErrNotFound            uint64 = 2
+	tokenErrAlreadyInitialized  uint64 = 3
+	tokenErrInsufficientBalance uint64 = 4
+	tokenErrInvalidRecipient    uint64 = 6
+	tokenErrInsufficientAmount  uint64 = 6
+	tokenErrMaxSupplyExceeded   uint64 = 7
+)
+
+// BridgeModelEvent 模型执行时发出的事件，Data 按 REST 的 JSON 编码(u64 为字符串)
+type BridgeModelEvent struct {
+	Account   aptos.AccountAddress // 事件句柄所在账户
+	StructTag string               // 持有事件句柄的资源类型
+	Field     string               // 资源中事件句柄的字段名
+	Type      string               // 事件类型
+	Data      map[string]any
+}
+
+// BridgeModel btc_bridgev3/btc_tokenv3 的纯 Go 参考模型
+// 每个入口函数按合约中的顺序检查并返回相同的中止码，中止时状态不变；
+// 包括合约中的已知行为：burn_from 只检查余额而不扣减，redeem_prepare 不检查同一次调用内重复的 outpoint
+type BridgeModel struct {
+	Module aptos.AccountAddress // @my_address
+
+	// btc_bridgev3 的状态，BridgeConfig 等资源只可能在模块地址下发布成功
+	BridgeInitialized bool
+	Admin             aptos.AccountAddress
+	Fee               uint64
+	FeeAccount        aptos.AccountAddress
+	Minted            []string
+	Prepared          []string
+	Used              []string
+
+	// btc_tokenv3 和 CoinStore<BTC> 的状态
+	TokenInitialized bool
+	Registered       map[aptos.AccountAddress]bool
+	Balances         map[aptos.AccountAddress]uint64
+	Supply           uint64
+
+	// 累计 coin::mint 和 coin::burn 的数量，链上不保存，用于检查供应量不变式
+	TotalMinted uint64
+	TotalBurned uint64
+
+	events []BridgeModelEvent
+}
+
+// NewBridgeModel 创建模块尚未初始化的模型
+func NewBridgeModel(module aptos.AccountAddress) *BridgeModel {
+	return &BridgeModel{
+		Module:     module,
+		Registered: make(map[aptos.AccountAddress]bool),
+		Balances:   make(map[aptos.AccountAddress]uint64),
+	}
+}
+
+// Clone 深拷贝模型
+func (m *BridgeModel) Clone() *BridgeModel {
+	out := *m
+	out.Minted = append([]string(nil), m.Minted...)
+	out.Prepared = append([]string(nil), m.Prepared...)
+	out.Used = append([]string(nil), m.Used...)
+	out.Registered = make(map[aptos.AccountAddress]bool, len(m.Registered))
+	for address, registered := range m.Registered {
+		out.Registered[address] = registered
+	}
+	out.Balances = make(map[aptos.AccountAddress]uint64, len(m.Balances))
+	for address, balance := range m.Balances {
+		out.Balances[address] = balance
+	}
+	out.events = nil
+	return &out
+}
+
+// apply 在副本上执行入口函数，成功后替换状态并返回发出的事件，中止时状态不变
+func (m *BridgeModel) apply(fn func(next *BridgeModel) error) ([]BridgeModelEvent, error) {
+	next := m.Clone()
+	err := fn(next)
+	if err != nil {
+		return nil, err
+	}
+	events := next.events
+	next.events = nil
+	*m = *next
+	return events, nil
+}
+
+func (m *BridgeModel) bridgeModule() string {
+	return m.Module.String() + "::btc_bridgev3"
+}
+
+func (m *BridgeModel) tokenModule() string {
+	return m.Module.String() + "::btc_tokenv3"
+}
+
+func (m *BridgeModel) coinType() string {
+	return twbtcCoinType(m.Module)
+}
+
+func (m *BridgeModel) bridgeAbort(name string, category uint64, reason uint64) error {
+	return newFakeMoveAbort(m.bridgeModule(), name, category, reason, "")
+}
+
+func (m *BridgeModel) tokenAbort(name string, category uint64, reason uint64) error {
+	return newFakeMoveAbort(m.tokenModule(), name, category, reason, "")
+}
+
+// emit 记录 address 下 structTag 资源的 field 句柄发出的事件
+func (m *BridgeModel) emit(address aptos.AccountAddress, structTag string, field string, eventType string, data map[string]any) {
+	m.events = append(m.events, BridgeModelEvent{
+		Account:   address,
+		StructTag: canonicalTypeString(structTag),
+		Field:     field,
+		Type:      canonicalTypeString(eventType),
+		Data:      data,
+	})
+}
+
+func (m *BridgeModel) emitBridgeEvent(field string, name string, data map[string]any) {
+	m.emit(m.Module, m.bridgeModule()+"::BridgeEvents", field, m.bridgeModule()+"::"+name, data)
+}
+
+func (m *BridgeModel) emitTokenEvent(field string, name string, data map[string]any) {
+	m.emit(m.Module, m.tokenModule()+"::BridgeEvents", field, m.tokenModule()+"::"+name, data)
+}
+
+// requireBridge 对应 borrow_global<BridgeConfig>(@my_address)
+func (m *BridgeModel) requireBridge() error {
+	if !m.BridgeInitialized {
+		return fakeVmError("MISSING_DATA")
+	}
+	return nil
+}
+
+// coinRegister 对应 coin::register<BTC>，已注册时不做修改
+func (m *BridgeModel) coinRegister(address aptos.AccountAddress) error {
+	m.Registered[address] = true
+	return nil
+}
+
+// coinBalance 对应 coin::balance<BTC>，未注册时中止
+func (m *BridgeModel) coinBalance(address aptos.AccountAddress) (uint64, error) {
+	if !m.Registered[address] {
+		return 0, newFakeMoveAbort("0x1::coin", "ECOIN_STORE_NOT_PUBLISHED", moveErrNotFound, coinErrCoinStoreNotPublished, "Account hasn't registered `CoinStore` for `CoinType`")
+	}
+	return m.Balances[address], nil
+}
+
+// coinDeposit 对应 coin::deposit<BTC>
+func (m *BridgeModel) coinDeposit(address aptos.AccountAddress, amount uint64) error {
+	balance, err := m.coinBalance(address)
+	if err != nil {
+		return err
+	}
+	m.Balances[address] = balance + amount
+	m.emit(address, coinStoreType(m.coinType()), "deposit_events", "0x1::coin::DepositEvent", map[string]any{"amount": strconv.FormatUint(amount, 10)})
+	return nil
+}
+
+// coinWithdraw 对应 coin::withdraw<BTC>
+func (m *BridgeModel) coinWithdraw(address aptos.AccountAddress, amount uint64) error {
+	balance, err := m.coinBalance(address)
+	if err != nil {
+		return err
+	}
+	if balance < amount {
+		return newFakeMoveAbort("0x1::coin", "EINSUFFICIENT_BALANCE", moveErrInvalidArgument, coinErrInsufficientBalance, "Not enough coins to complete transaction")
+	}
+	m.Balances[address] = balance - amount
+	m.emit(address, coinStoreType(m.coinType()), "withdraw_events", "0x1::coin::WithdrawEvent", map[string]any{"amount": strconv.FormatUint(amount, 10)})
+	return nil
+}
+
+// coinTransfer 对应 coin::transfer<BTC>
+func (m *BridgeModel) coinTransfer(from aptos.AccountAddress, to aptos.AccountAddress, amount uint64) error {
+	err := m.coinWithdraw(from, amount)
+	if err != nil {
+		return err
+	}
+	return m.coinDeposit(to, amount)
+}
+
+// coinMint 对应 coin::mint<BTC> 后 coin::deposit
+func (m *BridgeModel) coinMint(address aptos.AccountAddress, amount uint64) error {
+	m.Supply += amount
+	m.TotalMinted += amount
+	return m.coinDeposit(address, amount)
+}
+
+// Initialize 对应 btc_bridgev3::initialize
+func (m *BridgeModel) Initialize(admin aptos.AccountAddress, feeAccount aptos.AccountAddress, fee uint64) ([]BridgeModelEvent, error) {
+	return m.apply(func(m *BridgeModel) error {
+		// BridgeConfig 发布在调用者地址下，只有模块地址能通过后面 initialize_module 的检查
+		if admin == m.Module && m.BridgeInitialized {
+			return m.bridgeAbort("E_ALREADY_INITIALIZED", moveErrAlreadyExists, bridgeErrAlreadyInitialized)
+		}
+		if feeAccount == (aptos.AccountAddress{}) {
+			return m.bridgeAbort("E_ZERO_ETH_ADDRESS", moveErrInvalidArgument, bridgeErrZeroAddress)
+		}
+		if fee == 0 {
+			return m.bridgeAbort("E_ZERO_FEE", moveErrInvalidArgument, bridgeErrZeroFee)
+		}
+		m.BridgeInitialized = true
+		m.Admin = admin
+		m.Fee = fee
+		m.FeeAccount = feeAccount
+		m.Minted = []string{}
+		m.Prepared = []string{}
+		m.Used = []string{}
+		return m.initializeModule(admin)
+	})
+}
+
+// InitializeModule 对应 btc_tokenv3::initialize_module
+func (m *BridgeModel) InitializeModule(account aptos.AccountAddress) ([]BridgeModelEvent, error) {
+	return m.apply(func(m *BridgeModel) error {
+		return m.initializeModule(account)
+	})
+}
+
+// initializeModule 最后调用 registerv2(account, account)，registerv2 要求账户已注册，
+// 因此模块账户必须在初始化前自行调用 register，否则 initialize 和 initialize_module 都会以 E_ALREADY_INITIALIZED 中止
+func (m *BridgeModel) initializeModule(account aptos.AccountAddress) error {
+	if account != m.Module {
+		return m.tokenAbort("E_NOT_AUTHORIZED", moveErrPermissionDenied, tokenErrNotAuthorized)
+	}
+	if m.TokenInitialized {
+		return m.tokenAbort("E_ALREADY_INITIALIZED", moveErrAlreadyExists, tokenErrAlreadyInitialized)
+	}
+	m.TokenInitialized = true
+	return m.registerV2(account, account)
+}
+
+// Register 对应 btc_tokenv3::register
+func (m *BridgeModel) Register(account aptos.AccountAddress) ([]BridgeModelEvent, error) {
+	return m.apply(func(m *BridgeModel) error {
+		return m.coinRegister(account)
+	})
+}
+
+// RegisterV2 对应 btc_tokenv3::registerv2，admin 为发送方，account 为次要签名方
+func (m *BridgeModel) RegisterV2(admin aptos.AccountAddress, account aptos.AccountAddress) ([]BridgeModelEvent, error) {
+	return m.apply(func(m *BridgeModel) error {
+		return m.registerV2(admin, account)
+	})
+}
+
+func (m *BridgeModel) registerV2(admin aptos.AccountAddress, account aptos.AccountAddress) error {
+	if admin != m.Module {
+		return m.tokenAbort("E_NOT_AUTHORIZED", moveErrPermissionDenied, tokenErrNotAuthorized)
+	}
+	if account == (aptos.AccountAddress{}) {
+		return m.tokenAbort("E_INVALID_RECIPIENT", moveErrInvalidArgument, tokenErrInvalidRecipient)
+	}
+	// 合约断言账户已注册(错误码沿用 E_ALREADY_INITIALIZED)，coin::register 对已注册账户不做任何事
+	if !m.Registered[account] {
+		return m.tokenAbort("E_ALREADY_INITIALIZED", moveErrInvalidArgument, tokenErrAlreadyInitialized)
+	}
+	return m.coinRegister(account)
+}
+
+// Mint 对应 btc_bridgev3::mint
+func (m *BridgeModel) Mint(admin aptos.AccountAddress, btcTxId string, receiver aptos.AccountAddress, amount uint64) ([]BridgeModelEvent, error) {
+	return m.apply(func(m *BridgeModel) error {
+		err := m.requireBridge()
+		if err != nil {
+			return err
+		}
+		if admin != m.Admin {
+			return m.bridgeAbort("E_NOT_AUTHORIZED", moveErrPermissionDenied, bridgeErrNotAuthorized)
+		}
+		if receiver == (aptos.AccountAddress{}) {
+			return m.bridgeAbort("E_ZERO_ETH_ADDRESS", moveErrInvalidArgument, bridgeErrZeroAddress)
+		}
+		if amount <= m.Fee {
+			return m.bridgeAbort("E_INSUFFICIENT_AMOUNT", moveErrInvalidArgument, bridgeErrInsufficientAmount)
+		}
+		for _, txId := range m.Minted {
+			if txId == btcTxId {
+				return m.bridgeAbort("E_ALREADY_MINTED", moveErrInvalidArgument, bridgeErrAlreadyMinted)
+			}
+		}
+		err = m.mintTokens(admin, receiver, amount, btcTxId)
+		if err != nil {
+			return err
+		}
+		m.Minted = append(m.Minted, btcTxId)
+		// 事件中的金额扣除的是桥费用，而接收方实际少收的是代币的固定铸币手续费
+		m.emitBridgeEvent("mint_events", "MintEvent", map[string]any{
+			"btc_tx_id": btcTxId,
+			"receiver":  receiver.String(),
+			"amount":    strconv.FormatUint(amount-m.Fee, 10),
+		})
+		return nil
+	})
+}
+
+// MintTokens 对应 btc_tokenv3::mint_tokens
+func (m *BridgeModel) MintTokens(admin aptos.AccountAddress, recipient aptos.AccountAddress, amount uint64, btcTxId string) ([]BridgeModelEvent, error) {
+	return m.apply(func(m *BridgeModel) error {
+		return m.mintTokens(admin, recipient, amount, btcTxId)
+	})
+}
+
+func (m *BridgeModel) mintTokens(admin aptos.AccountAddress, recipient aptos.AccountAddress, amount uint64, btcTxId string) error {
+	if admin != m.Module {
+		return m.tokenAbort("E_NOT_AUTHORIZED", moveErrPermissionDenied, tokenErrNotAuthorized)
+	}
+	if !m.TokenInitialized {
+		return m.tokenAbort("E_NOT_FOUND", moveErrNotFound, tokenErrNotFound)
+	}
+	if recipient == (aptos.AccountAddress{}) {
+		return m.tokenAbort("E_INVALID_RECIPIENT", moveErrInvalidArgument, tokenErrInvalidRecipient)
+	}
+	if amount <= twbtcTokenMintFee {
+		return m.tokenAbort("E_INSUFFICIENT_AMOUNT", moveErrInvalidArgument, tokenErrInsufficientAmount)
+	}
+	if m.Supply > twbtcMaxSupply || amount > twbtcMaxSupply-m.Supply {
+		return m.tokenAbort("E_MAX_SUPPLY_EXCEEDED", moveErrInvalidArgument, tokenErrMaxSupplyExceeded)
+	}
+	if !m.Registered[recipient] {
+		// 合约在接收方未注册时借用了 E_ALREADY_INITIALIZED 的原因值
+		return m.tokenAbort("E_ALREADY_INITIALIZED", moveErrInvalidArgument, tokenErrAlreadyInitialized)
+	}
+	recipientAmount := amount - twbtcTokenMintFee
+	err := m.coinMint(recipient, recipientAmount)
+	if err != nil {
+		return err
+	}
+	err = m.coinMint(admin, twbtcTokenMintFee)
+	if err != nil {
+		return err
+	}
+	m.emitTokenEvent("mint_events", "MintEvent", map[string]any{
+		"amount":    strconv.FormatUint(recipientAmount, 10),
+		"recipient": recipient.String(),
+		"btc_txid":  btcTxId,
+	})
+	return nil
+}
+
+// BurnFrom 对应 btc_tokenv3::burn_from(public fun，只能由 redeem_request 调用)
+// 合约检查余额后铸造等量的币再销毁，账户余额不变
+func (m *BridgeModel) BurnFrom(account aptos.AccountAddress, amount uint64, btcAddress string) ([]BridgeModelEvent, error) {
+	return m.apply(func(m *BridgeModel) error {
+		return m.burnFrom(account, amount, btcAddress)
+	})
+}
+
+func (m *BridgeModel) burnFrom(account aptos.AccountAddress, amount uint64, btcAddress string) error {
+	if !m.TokenInitialized {
+		return fakeVmError("MISSING_DATA")
+	}
+	balance, err := m.coinBalance(account)
+	if err != nil {
+		return err
+	}
+	if balance < amount {
+		return m.tokenAbort("E_INSUFFICIENT_BALANCE", moveErrInvalidArgument, tokenErrInsufficientBalance)
+	}
+	m.Supply += amount
+	m.TotalMinted += amount
+	m.Supply -= amount
+	m.TotalBurned += amount
+	m.emitTokenEvent("burn_events", "BurnEvent", map[string]any{
+		"amount":      strconv.FormatUint(amount, 10),
+		"burner":      account.String(),
+		"btc_address": btcAddress,
+	})
+	return nil
+}
+
+// Transfer 对应 btc_tokenv3::transfer
+func (m *BridgeModel) Transfer(from aptos.AccountAddress, to aptos.AccountAddress, amount uint64) ([]BridgeModelEvent, error) {
+	return m.apply(func(m *BridgeModel) error {
+		return m.coinTransfer(from, to, amount)
+	})
+}
+
+// RedeemRequest 对应 btc_bridgev3::redeem_request
+func (m *BridgeModel) RedeemRequest(user aptos.AccountAddress, amount uint64, receiver string) ([]BridgeModelEvent, error) {
+	return m.apply(func(m *BridgeModel) error {
+		err := m.requireBridge()
+		if err != nil {
+			return err
+		}
+		if amount <= m.Fee {
+			return m.bridgeAbort("E_INSUFFICIENT_AMOUNT", moveErrInvalidArgument, bridgeErrInsufficientAmount)
+		}
+		err = m.coinTransfer(user, m.FeeAccount, m.Fee)
+		if err != nil {
+			return err
+		}
+		err = m.burnFrom(user, amount-m.Fee, receiver)
+		if err != nil {
+			return err
+		}
+		m.emitBridgeEvent("redeem_request_events", "RedeemRequestEvent", map[string]any{
+			"sender":   user.String(),
+			"amount":   strconv.FormatUint(amount, 10),
+			"receiver": receiver,
+		})
+		return nil
+	})
+}
+
+// RedeemPrepare 对应 btc_bridgev3::redeem_prepare
+func (m *BridgeModel) RedeemPrepare(admin aptos.AccountAddress, redeemRequestTxHash string, requester aptos.AccountAddress, receiver string, amount uint64, outpointTxIds []string, outpointIdxs []uint64) ([]BridgeModelEvent, error) {
+	return m.apply(func(m *BridgeModel) error {
+		err := m.requireBridge()
+		if err != nil {
+			return err
+		}
+		if admin != m.Admin {
+			return m.bridgeAbort("E_NOT_AUTHORIZED", moveErrPermissionDenied, bridgeErrNotAuthorized)
+		}
+		if redeemRequestTxHash == "" {
+			return m.bridgeAbort("E_ZERO_ETH_TX_HASH", moveErrInvalidArgument, bridgeErrZeroTxHash)
+		}
+		for _, prepared := range m.Prepared {
+			if prepared == redeemRequestTxHash {
+				return m.bridgeAbort("E_ALREADY_PREPARED", moveErrInvalidArgument, bridgeErrAlreadyPrepared)
+			}
+		}
+		if requester == (aptos.AccountAddress{}) {
+			return m.bridgeAbort("E_ZERO_ETH_ADDRESS", moveErrInvalidArgument, bridgeErrZeroAddress)
+		}
+		if receiver == "" {
+			return m.bridgeAbort("E_EMPTY_STRING", moveErrInvalidArgument, bridgeErrEmptyString)
+		}
+		if amount == 0 {
+			return m.bridgeAbort("E_ZERO_AMOUNT", moveErrInvalidArgument, bridgeErrZeroAmount)
+		}
+		if len(outpointTxIds) == 0 {
+			return m.bridgeAbort("E_EMPTY_OUTPOINT_TX_IDS", moveErrInvalidArgument, bridgeErrEmptyOutpointTxIds)
+		}
+		if len(outpointIdxs) == 0 {
+			return m.bridgeAbort("E_EMPTY_OUTPOINT_IDXS", moveErrInvalidArgument, bridgeErrEmptyOutpointIdxs)
+		}
+		if len(outpointTxIds) != len(outpointIdxs) {
+			return m.bridgeAbort("E_OUTPOINT_TX_IDS_AND_OUTPOINT_IDXS_LENGTH_MISMATCH", moveErrInvalidArgument, bridgeErrOutpointLengthMismatch)
+		}
+		// 只与已使用的列表比较，同一次调用中重复的 txid 不会被拒绝
+		for _, txId := range outpointTxIds {
+			if txId == "" {
+				return m.bridgeAbort("E_ZERO_OUTPOINT_TX_ID", moveErrInvalidArgument, bridgeErrZeroOutpointTxId)
+			}
+			for _, used := range m.Used {
+				if used == txId {
+					return m.bridgeAbort("E_BTC_TX_ID_ALREADY_USED", moveErrInvalidArgument, bridgeErrBtcTxIdAlreadyUsed)
+				}
+			}
+		}
+		m.Prepared = append(m.Prepared, redeemRequestTxHash)
+		m.Used = append(m.Used, outpointTxIds...)
+		txIds := make([]any, len(outpointTxIds))
+		for i, txId := range outpointTxIds {
+			txIds[i] = txId
+		}
+		idxs := make([]any, len(outpointIdxs))
+		for i, idx := range outpointIdxs {
+			idxs[i] = strconv.FormatUint(idx, 10)
+		}
+		m.emitBridgeEvent("redeem_prepare_events", "RedeemPrepareEvent", map[string]any{
+			"eth_tx_hash":     redeemRequestTxHash,
+			"requester":       requester.String(),
+			"receiver":        receiver,
+			"amount":          strconv.FormatUint(amount, 10),
+			"outpoint_tx_ids": txIds,
+			"outpoint_idxs":   idxs,
+		})
+		return nil
+	})
+}
+
+// Balance 对应 btc_tokenv3::balance
+func (m *BridgeModel) Balance(address aptos.AccountAddress) (uint64, error) {
+	return m.coinBalance(address)
+}
+
+// CheckInvariants 检查模型状态的不变式
+func (m *BridgeModel) CheckInvariants() error {
+	if m.TotalMinted < m.TotalBurned || m.Supply != m.TotalMinted-m.TotalBurned {
+		return fmt.Errorf("供应量 %d 不等于累计铸造 %d 减累计销毁 %d", m.Supply, m.TotalMinted, m.TotalBurned)
+	}
+	if m.Supply > twbtcMaxSupply {
+		return fmt.Errorf("供应量 %d 超过上限 %d", m.Supply, twbtcMaxSupply)
+	}
+	var total uint64
+	for address, balance := range m.Balances {
+		if balance > 0 && !m.Registered[address] {
+			return fmt.Errorf("未注册的账户 %s 有余额 %d", address.String(), balance)
+		}
+		total += balance
+	}
+	if total != m.Supply {
+		return fmt.Errorf("余额之和 %d 不等于供应量 %d", total, m.Supply)
+	}
+	if !m.TokenInitialized && m.Supply != 0 {
+		return fmt.Errorf("代币未初始化时供应量应为 0")
+	}
+	for _, list := range []struct {
+		name   string
+		values []string
+	}{{"已铸币的交易ID", m.Minted}, {"已准备的赎回请求", m.Prepared}} {
+		seen := make(map[string]bool, len(list.values))
+		for _, value := range list.values {
+			if seen[value] {
+				return fmt.Errorf("%s %s 出现了两次", list.name, value)
+			}
+			seen[value] = true
+		}
+	}
+	return nil
+}
+
+// registeredAccounts 返回已注册 CoinStore<BTC> 的账户，按地址排序
+func (m *BridgeModel) registeredAccounts() []aptos.AccountAddress {
+	var out []aptos.AccountAddress
+	for address, registered := range m.Registered {
+		if registered {
+			out = append(out, address)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].String() < out[j].String() })
+	return out
+}
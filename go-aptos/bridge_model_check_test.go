@@ -0,0 +1,436 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// bridgeModelCheckOp 一次随机操作：predict 在模型上执行，submit 通过客户端在模拟节点上执行
+type bridgeModelCheckOp struct {
+	description string
+	predict     func(m *BridgeModel) ([]BridgeModelEvent, error)
+	submit      func() error
+}
+
+// bridgeModelChecker 用随机操作序列对比参考模型与客户端在模拟节点上的执行结果。
+// 模拟节点本身运行同一个 BridgeModel，这里只能发现客户端的负载编码、资源和事件解析与模型不一致，
+// 无法发现模型与合约的偏差；后者由 TestBridgeContractScenario 中按合约源码手写的预期覆盖
+type bridgeModelChecker struct {
+	rng      *rand.Rand
+	client   *aptos.Client
+	model    *BridgeModel
+	module   string
+	admin    *aptos.Account
+	accounts []*aptos.Account // 管理员、费用账户和普通用户
+	network  BtcNetwork
+
+	eventCounts map[string]uint64 // 结构体/字段 -> 事件句柄计数
+	succeeded   map[string]int
+	aborted     map[string]int
+	rejected    map[string]int // 客户端在提交前拒绝
+}
+
+// pickAccount 随机选择一个账户
+func (c *bridgeModelChecker) pickAccount() *aptos.Account {
+	return c.accounts[c.rng.Intn(len(c.accounts))]
+}
+
+// pickAddress 随机选择一个账户地址，偶尔返回零地址
+func (c *bridgeModelChecker) pickAddress() aptos.AccountAddress {
+	if c.rng.Intn(10) == 0 {
+		return aptos.AccountAddress{}
+	}
+	return c.pickAccount().Address
+}
+
+// pickAmount 随机选择金额，集中在桥费用和代币铸币手续费的边界附近
+func (c *bridgeModelChecker) pickAmount() uint64 {
+	fee := c.model.Fee
+	candidates := []uint64{0, fee, fee + 1, twbtcTokenMintFee, twbtcTokenMintFee + 1, uint64(c.rng.Int63n(200_000))}
+	return candidates[c.rng.Intn(len(candidates))]
+}
+
+// pickTxId 从小范围中选择BTC交易ID，使重复的情况经常出现
+func (c *bridgeModelChecker) pickTxId(prefix string) string {
+	if c.rng.Intn(12) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s-%d", prefix, c.rng.Intn(8))
+}
+
+// pickBtcReceiver 生成默认网络下有效的 P2WPKH 地址
+func (c *bridgeModelChecker) pickBtcReceiver() string {
+	address, _ := encodeSegwitAddress(c.network.Bech32HRP, 0, hash160([]byte{byte(c.rng.Intn(4))}))
+	return address
+}
+
+// nextOp 随机生成下一个操作
+func (c *bridgeModelChecker) nextOp() bridgeModelCheckOp {
+	sender := c.pickAccount()
+	switch c.rng.Intn(21) {
+	case 0, 20:
+		// 多数由管理员调用，使桥通常先于 initialize_module 完成初始化
+		if c.rng.Intn(4) != 0 {
+			sender = c.admin
+		}
+		feeAccount := c.pickAddress()
+		fee := []uint64{0, 1000, 5000}[c.rng.Intn(3)]
+		return bridgeModelCheckOp{
+			description: fmt.Sprintf("initialize(sender=%s, fee_account=%s, fee=%d)", sender.Address.String(), feeAccount.String(), fee),
+			predict: func(m *BridgeModel) ([]BridgeModelEvent, error) {
+				return m.Initialize(sender.Address, feeAccount, fee)
+			},
+			submit: func() error {
+				_, err := initBridge(c.client, sender, c.module, feeAccount, fee)
+				return err
+			},
+		}
+	case 1:
+		return bridgeModelCheckOp{
+			description: fmt.Sprintf("initialize_module(sender=%s)", sender.Address.String()),
+			predict: func(m *BridgeModel) ([]BridgeModelEvent, error) {
+				return m.InitializeModule(sender.Address)
+			},
+			submit: func() error {
+				_, err := initTWBTC(c.client, sender, c.module)
+				return err
+			},
+		}
+	case 2, 3:
+		// 模块账户需要先注册，initialize 和 initialize_module 才能成功
+		return bridgeModelCheckOp{
+			description: fmt.Sprintf("register(sender=%s)", sender.Address.String()),
+			predict: func(m *BridgeModel) ([]BridgeModelEvent, error) {
+				return m.Register(sender.Address)
+			},
+			submit: func() error {
+				_, err := RegisterTWBTC(c.client, sender, c.module)
+				return err
+			},
+		}
+	case 4, 5:
+		admin := c.admin
+		if c.rng.Intn(4) == 0 {
+			admin = c.pickAccount()
+		}
+		// 多签名方交易的签名方不能重复
+		for sender.Address == admin.Address {
+			sender = c.pickAccount()
+		}
+		return bridgeModelCheckOp{
+			description: fmt.Sprintf("registerv2(admin=%s, account=%s)", admin.Address.String(), sender.Address.String()),
+			predict: func(m *BridgeModel) ([]BridgeModelEvent, error) {
+				return m.RegisterV2(admin.Address, sender.Address)
+			},
+			submit: func() error {
+				_, err := registerTWBTC(c.client, admin, c.module, sender)
+				return err
+			},
+		}
+	case 6, 7, 8, 9:
+		admin := c.admin
+		if c.rng.Intn(6) == 0 {
+			admin = sender
+		}
+		btcTxId := c.pickTxId("deposit")
+		receiver := c.pickAddress()
+		amount := c.pickAmount()
+		return bridgeModelCheckOp{
+			description: fmt.Sprintf("mint(sender=%s, btc_tx_id=%q, receiver=%s, amount=%d)", admin.Address.String(), btcTxId, receiver.String(), amount),
+			predict: func(m *BridgeModel) ([]BridgeModelEvent, error) {
+				return m.Mint(admin.Address, btcTxId, receiver, amount)
+			},
+			submit: func() error {
+				_, err := mintTWBTC(c.client, admin, c.module, receiver, amount, btcTxId)
+				return err
+			},
+		}
+	case 10:
+		admin := c.admin
+		if c.rng.Intn(4) == 0 {
+			admin = sender
+		}
+		btcTxId := c.pickTxId("direct")
+		recipient := c.pickAddress()
+		amount := c.pickAmount()
+		return bridgeModelCheckOp{
+			description: fmt.Sprintf("mint_tokens(sender=%s, recipient=%s, amount=%d, btc_txid=%q)", admin.Address.String(), recipient.String(), amount, btcTxId),
+			predict: func(m *BridgeModel) ([]BridgeModelEvent, error) {
+				return m.MintTokens(admin.Address, recipient, amount, btcTxId)
+			},
+			submit: func() error {
+				payload, err := TokenMintTokensPayload(c.module, recipient, amount, btcTxId)
+				if err != nil {
+					return err
+				}
+				_, err = submitAndConfirm(c.client, admin, payload, nil)
+				return err
+			},
+		}
+	case 11, 12, 13:
+		to := c.pickAddress()
+		amount := uint64(c.rng.Int63n(int64(c.model.Balances[sender.Address] + 2000)))
+		return bridgeModelCheckOp{
+			description: fmt.Sprintf("transfer(sender=%s, to=%s, amount=%d)", sender.Address.String(), to.String(), amount),
+			predict: func(m *BridgeModel) ([]BridgeModelEvent, error) {
+				return m.Transfer(sender.Address, to, amount)
+			},
+			submit: func() error {
+				_, err := SendTWBTC(c.client, sender, to, amount, c.module)
+				return err
+			},
+		}
+	case 14, 15, 16:
+		receiver := c.pickBtcReceiver()
+		amount := c.pickAmount()
+		if c.rng.Intn(2) == 0 {
+			amount = uint64(c.rng.Int63n(int64(c.model.Balances[sender.Address] + 2)))
+		}
+		return bridgeModelCheckOp{
+			description: fmt.Sprintf("redeem_request(sender=%s, amount=%d, receiver=%s)", sender.Address.String(), amount, receiver),
+			predict: func(m *BridgeModel) ([]BridgeModelEvent, error) {
+				return m.RedeemRequest(sender.Address, amount, receiver)
+			},
+			submit: func() error {
+				_, err := redeemRequest(c.client, sender, c.module, receiver, amount)
+				return err
+			},
+		}
+	default:
+		admin := c.admin
+		if c.rng.Intn(6) == 0 {
+			admin = sender
+		}
+		txHash := c.pickTxId("redeem")
+		requester := c.pickAddress()
+		receiver := c.pickBtcReceiver()
+		if c.rng.Intn(10) == 0 {
+			receiver = ""
+		}
+		amount := c.pickAmount()
+		var txIds []string
+		var idxs []uint64
+		for i := c.rng.Intn(4); i > 0; i-- {
+			txIds = append(txIds, c.pickTxId("utxo"))
+			idxs = append(idxs, uint64(c.rng.Intn(3)))
+		}
+		if c.rng.Intn(10) == 0 {
+			idxs = append(idxs, 0)
+		}
+		return bridgeModelCheckOp{
+			description: fmt.Sprintf("redeem_prepare(sender=%s, tx_hash=%q, requester=%s, receiver=%q, amount=%d, outpoints=%v/%v)", admin.Address.String(), txHash, requester.String(), receiver, amount, txIds, idxs),
+			predict: func(m *BridgeModel) ([]BridgeModelEvent, error) {
+				return m.RedeemPrepare(admin.Address, txHash, requester, receiver, amount, txIds, idxs)
+			},
+			submit: func() error {
+				payload, err := buildRedeemPreparePayload(c.module, txHash, requester, receiver, amount, txIds, idxs)
+				if err != nil {
+					return err
+				}
+				_, err = submitAndConfirm(c.client, admin, payload, nil)
+				return err
+			},
+		}
+	}
+}
+
+// step 执行一次操作并比较模型预测与客户端结果
+func (c *bridgeModelChecker) step(op bridgeModelCheckOp) error {
+	name, _, _ := strings.Cut(op.description, "(")
+	events, predicted := op.predict(c.model)
+	err := op.submit()
+	switch {
+	case predicted == nil && err != nil:
+		return fmt.Errorf("%s: 模型预测成功，客户端返回: %v", op.description, err)
+	case predicted != nil && err == nil:
+		return fmt.Errorf("%s: 模型预测中止 %v，客户端执行成功", op.description, predicted)
+	case predicted != nil && strings.Contains(err.Error(), "交易执行失败"):
+		if !strings.Contains(err.Error(), predicted.Error()) {
+			return fmt.Errorf("%s: 模型预测中止 %v，链上为: %v", op.description, predicted, err)
+		}
+		c.aborted[name]++
+	case predicted != nil:
+		// 客户端在提交前拒绝了必然失败的交易
+		c.rejected[name]++
+	default:
+		c.succeeded[name]++
+	}
+	for _, event := range events {
+		c.eventCounts[event.StructTag+"/"+event.Field]++
+	}
+
+	err = c.model.CheckInvariants()
+	if err != nil {
+		return fmt.Errorf("%s 后不变式不成立: %v", op.description, err)
+	}
+	err = c.compare()
+	if err != nil {
+		return fmt.Errorf("%s 后链上状态与模型不一致: %v", op.description, err)
+	}
+	return nil
+}
+
+// compare 通过客户端读取模拟节点的状态并与模型比较
+func (c *bridgeModelChecker) compare() error {
+	m := c.model
+	exists, err := moduleResourceExists(c.client, c.module, "btc_bridgev3::BridgeConfig")
+	if err != nil {
+		return err
+	}
+	if exists != m.BridgeInitialized {
+		return fmt.Errorf("BridgeConfig 存在: %v，模型: %v", exists, m.BridgeInitialized)
+	}
+	if m.BridgeInitialized {
+		config, err := GetBridgeConfig(c.client, c.module)
+		if err != nil {
+			return err
+		}
+		if config.Fee != m.Fee || config.Admin != m.Admin.String() || config.FeeAccount != m.FeeAccount.String() {
+			return fmt.Errorf("BridgeConfig %+v 与模型不一致", *config)
+		}
+		minted, err := GetMintedTransactions(c.client, c.module)
+		if err != nil {
+			return err
+		}
+		prepared, err := GetPreparedRedeems(c.client, c.module)
+		if err != nil {
+			return err
+		}
+		used, err := GetUsedBtcTxIds(c.client, c.module)
+		if err != nil {
+			return err
+		}
+		if !slices.Equal(minted.Minted, m.Minted) || !slices.Equal(prepared, m.Prepared) || !slices.Equal(used, m.Used) {
+			return fmt.Errorf("已铸币/已准备/已使用列表 %v/%v/%v，模型: %v/%v/%v", minted.Minted, prepared, used, m.Minted, m.Prepared, m.Used)
+		}
+		events, err := GetBridgeEvents(c.client, c.module)
+		if err != nil {
+			return err
+		}
+		structTag := canonicalTypeString(c.module + "::btc_bridgev3::BridgeEvents")
+		for field, handle := range map[string]EventHandle{
+			"mint_events":           events.MintEvents,
+			"redeem_request_events": events.RedeemRequestEvents,
+			"redeem_prepare_events": events.RedeemPrepareEvents,
+		} {
+			if handle.Counter != c.eventCounts[structTag+"/"+field] {
+				return fmt.Errorf("%s 计数 %d，模型发出 %d 个", field, handle.Counter, c.eventCounts[structTag+"/"+field])
+			}
+		}
+	}
+
+	if m.TokenInitialized {
+		events, err := GetTokenBridgeEvents(c.client, c.module)
+		if err != nil {
+			return err
+		}
+		structTag := canonicalTypeString(c.module + "::btc_tokenv3::BridgeEvents")
+		if events.MintEvents.Counter != c.eventCounts[structTag+"/mint_events"] || events.BurnEvents.Counter != c.eventCounts[structTag+"/burn_events"] {
+			return fmt.Errorf("代币事件计数 %d/%d，模型发出 %d/%d 个", events.MintEvents.Counter, events.BurnEvents.Counter,
+				c.eventCounts[structTag+"/mint_events"], c.eventCounts[structTag+"/burn_events"])
+		}
+		supply, err := TWBTCSupply(c.client, c.module)
+		if err != nil {
+			return err
+		}
+		if !supply.IsUint64() || supply.Uint64() != m.Supply {
+			return fmt.Errorf("供应量 %s，模型: %d", supply.String(), m.Supply)
+		}
+	}
+
+	for _, account := range c.accounts {
+		registered, err := IsTWBTCRegistered(c.client, account.Address, c.module)
+		if err != nil {
+			return err
+		}
+		// 未注册的账户查询余额应返回 ErrTWBTCNotRegistered 而不是 0
+		balance, err := TWBTCBalance(c.client, account.Address, c.module)
+		if err != nil && (registered || !errors.Is(err, ErrTWBTCNotRegistered)) {
+			return err
+		}
+		if err == nil && !registered {
+			return fmt.Errorf("账户 %s 未注册，查询余额却返回 %d", account.Address.String(), balance)
+		}
+		if registered != m.Registered[account.Address] || balance != m.Balances[account.Address] {
+			return fmt.Errorf("账户 %s 注册 %v 余额 %d，模型: %v %d", account.Address.String(), registered, balance,
+				m.Registered[account.Address], m.Balances[account.Address])
+		}
+	}
+	return nil
+}
+
+// TestBridgeModelClientAgreement 在模拟节点上用固定种子的随机操作序列对比参考模型和客户端，并检查不变式
+func TestBridgeModelClientAgreement(t *testing.T) {
+	for _, seed := range []int64{1, 2, 3, 4} {
+		t.Run(fmt.Sprintf("seed-%d", seed), func(t *testing.T) {
+			c := newBridgeModelChecker(t, seed)
+			for i := range 150 {
+				err := c.step(c.nextOp())
+				if err != nil {
+					t.Fatalf("随机种子 %d 第 %d 步 %v", seed, i+1, err)
+				}
+			}
+
+			var names []string
+			for _, counts := range []map[string]int{c.succeeded, c.aborted, c.rejected} {
+				for name := range counts {
+					if !slices.Contains(names, name) {
+						names = append(names, name)
+					}
+				}
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				t.Logf("%-18s 成功 %d 中止 %d 本地拒绝 %d", name, c.succeeded[name], c.aborted[name], c.rejected[name])
+			}
+			if c.succeeded["mint"] == 0 || c.succeeded["redeem_request"] == 0 || c.succeeded["redeem_prepare"] == 0 {
+				t.Fatalf("随机序列应覆盖成功的 mint、redeem_request 和 redeem_prepare，实际 %v", c.succeeded)
+			}
+		})
+	}
+}
+
+// newBridgeModelChecker 启动只安装了桥参考模型的模拟节点，并创建管理员、费用账户和普通用户
+func newBridgeModelChecker(t *testing.T, seed int64) *bridgeModelChecker {
+	t.Helper()
+	node := NewFakeFullnode()
+	t.Cleanup(node.Close)
+	t.Setenv("APTOS_NODE_URL", node.URL())
+	client, err := node.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var accounts []*aptos.Account
+	for _, name := range []string{"admin", "fee", "alice", "bob", "carol"} {
+		account := newTestAccount(t, "bridge-model-"+name)
+		err = node.CreateAccount(account.Address, fakeNodeFundOctas)
+		if err != nil {
+			t.Fatal(err)
+		}
+		accounts = append(accounts, account)
+	}
+	admin := accounts[0]
+	node.InstallBridgeModel(admin.Address)
+
+	return &bridgeModelChecker{
+		rng:         rand.New(rand.NewSource(seed)),
+		client:      client,
+		model:       NewBridgeModel(admin.Address),
+		module:      admin.Address.String(),
+		admin:       admin,
+		accounts:    accounts,
+		network:     btcNetworks[defaultBtcNetwork],
+		eventCounts: make(map[string]uint64),
+		succeeded:   make(map[string]int),
+		aborted:     make(map[string]int),
+		rejected:    make(map[string]int),
+	}
+}
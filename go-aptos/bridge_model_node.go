@@ -0,0 +1,303 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// loadBridgeModel 从模拟节点的状态中读出模块地址下的桥和代币状态
+func loadBridgeModel(ctx *FakeTxContext, module aptos.AccountAddress) (*BridgeModel, error) {
+	m := NewBridgeModel(module)
+	prefix := module.String()
+	if data, ok := ctx.Resource(module, prefix+"::btc_bridgev3::BridgeConfig"); ok {
+		config := BridgeConfig{}
+		err := decodeMoveValue(data, &config)
+		if err != nil {
+			return nil, fmt.Errorf("解析BridgeConfig失败: %v", err)
+		}
+		err = m.Admin.ParseStringRelaxed(config.Admin)
+		if err != nil {
+			return nil, fmt.Errorf("解析管理员地址失败: %v", err)
+		}
+		err = m.FeeAccount.ParseStringRelaxed(config.FeeAccount)
+		if err != nil {
+			return nil, fmt.Errorf("解析费用账户地址失败: %v", err)
+		}
+		m.BridgeInitialized = true
+		m.Fee = config.Fee
+
+		lists := []struct {
+			structTag string
+			field     string
+			out       *[]string
+		}{
+			{"btc_bridgev3::MintedTransactions", "minted", &m.Minted},
+			{"btc_bridgev3::PreparedRedeems", "prepared", &m.Prepared},
+			{"btc_bridgev3::UsedBtcTxIds", "used", &m.Used},
+		}
+		for _, list := range lists {
+			data, err := ctx.BorrowGlobal(module, prefix+"::"+list.structTag)
+			if err != nil {
+				return nil, err
+			}
+			*list.out = []string{}
+			err = decodeMoveValue(data[list.field], list.out)
+			if err != nil {
+				return nil, fmt.Errorf("解析 %s 失败: %v", list.structTag, err)
+			}
+		}
+	}
+
+	coinType := twbtcCoinType(module)
+	m.TokenInitialized = ctx.Exists(module, prefix+"::btc_tokenv3::BTCCapabilities")
+	for address := range ctx.state.accounts {
+		balance, registered := ctx.CoinBalance(address, coinType)
+		if registered {
+			m.Registered[address] = true
+			m.Balances[address] = balance
+		}
+	}
+	if m.TokenInitialized {
+		supply, err := ctx.CoinSupply(coinType)
+		if err != nil {
+			return nil, err
+		}
+		m.Supply = supply
+		m.TotalMinted = supply
+	}
+	return m, nil
+}
+
+// stringsToMove 把字符串列表编码为 REST 的 vector<String>
+func stringsToMove(values []string) []any {
+	out := make([]any, len(values))
+	for i, value := range values {
+		out[i] = value
+	}
+	return out
+}
+
+// storeTo 把模型状态写回模拟节点，首次写入的资源按合约中 move_to 的顺序创建，以得到相同的事件句柄编号
+func (m *BridgeModel) storeTo(ctx *FakeTxContext) error {
+	prefix := m.Module.String()
+	coinType := m.coinType()
+
+	if m.BridgeInitialized {
+		configType := prefix + "::btc_bridgev3::BridgeConfig"
+		if !ctx.Exists(m.Module, configType) {
+			resources := []struct {
+				structTag string
+				data      func() map[string]any
+			}{
+				{"btc_bridgev3::BridgeConfig", func() map[string]any { return map[string]any{} }},
+				{"btc_bridgev3::MintedTransactions", func() map[string]any { return map[string]any{} }},
+				{"btc_bridgev3::PreparedRedeems", func() map[string]any { return map[string]any{} }},
+				{"btc_bridgev3::UsedBtcTxIds", func() map[string]any { return map[string]any{} }},
+				{"btc_bridgev3::BridgeEvents", func() map[string]any {
+					return map[string]any{
+						"mint_events":           ctx.NewEventHandle(m.Module),
+						"redeem_request_events": ctx.NewEventHandle(m.Module),
+						"redeem_prepare_events": ctx.NewEventHandle(m.Module),
+					}
+				}},
+			}
+			for _, resource := range resources {
+				err := ctx.MoveTo(m.Module, prefix+"::"+resource.structTag, resource.data())
+				if err != nil {
+					return fmt.Errorf("写入 %s 失败: %v", resource.structTag, err)
+				}
+			}
+		}
+		config, _ := ctx.Resource(m.Module, configType)
+		config["admin"] = m.Admin.String()
+		config["fee"] = strconv.FormatUint(m.Fee, 10)
+		config["fee_account"] = m.FeeAccount.String()
+		minted, _ := ctx.Resource(m.Module, prefix+"::btc_bridgev3::MintedTransactions")
+		minted["minted"] = stringsToMove(m.Minted)
+		prepared, _ := ctx.Resource(m.Module, prefix+"::btc_bridgev3::PreparedRedeems")
+		prepared["prepared"] = stringsToMove(m.Prepared)
+		used, _ := ctx.Resource(m.Module, prefix+"::btc_bridgev3::UsedBtcTxIds")
+		used["used"] = stringsToMove(m.Used)
+	}
+
+	capabilitiesType := prefix + "::btc_tokenv3::BTCCapabilities"
+	if m.TokenInitialized && !ctx.Exists(m.Module, capabilitiesType) {
+		err := ctx.CoinInitialize(coinType, "Bitcoin", "BTC", 8)
+		if err != nil {
+			return err
+		}
+		eventsType := prefix + "::btc_tokenv3::BridgeEvents"
+		if !ctx.Exists(m.Module, eventsType) {
+			err = ctx.MoveTo(m.Module, eventsType, map[string]any{
+				"mint_events": ctx.NewEventHandle(m.Module),
+				"burn_events": ctx.NewEventHandle(m.Module),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		err = ctx.MoveTo(m.Module, capabilitiesType, map[string]any{
+			"mint_cap":   map[string]any{"dummy_field": false},
+			"burn_cap":   map[string]any{"dummy_field": false},
+			"freeze_cap": map[string]any{"dummy_field": false},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, address := range m.registeredAccounts() {
+		err := ctx.CoinRegister(address, coinType)
+		if err != nil {
+			return err
+		}
+		ctx.setCoinValue(address, coinType, m.Balances[address])
+	}
+	if m.TokenInitialized {
+		integer, err := ctx.coinSupplyField(coinType)
+		if err != nil {
+			return err
+		}
+		integer["value"] = strconv.FormatUint(m.Supply, 10)
+	}
+	return nil
+}
+
+// applyBridgeModel 从模拟节点读出模型，执行 op 后写回状态并发出事件
+func applyBridgeModel(ctx *FakeTxContext, module aptos.AccountAddress, op func(m *BridgeModel) ([]BridgeModelEvent, error)) error {
+	m, err := loadBridgeModel(ctx, module)
+	if err != nil {
+		return err
+	}
+	events, err := op(m)
+	if err != nil {
+		return err
+	}
+	err = m.storeTo(ctx)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		err = ctx.EmitEvent(event.Account, event.StructTag, event.Field, event.Type, event.Data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InstallBridgeModel 用参考模型实现模块地址下 btc_bridgev3/btc_tokenv3 的入口函数
+func (n *FakeFullnode) InstallBridgeModel(module aptos.AccountAddress) {
+	handle := func(function string, op func(ctx *FakeTxContext, m *BridgeModel) ([]BridgeModelEvent, error)) {
+		n.HandleEntryFunction(module.String()+"::"+function, func(ctx *FakeTxContext) error {
+			return applyBridgeModel(ctx, module, func(m *BridgeModel) ([]BridgeModelEvent, error) {
+				return op(ctx, m)
+			})
+		})
+	}
+
+	handle("btc_bridgev3::initialize", func(ctx *FakeTxContext, m *BridgeModel) ([]BridgeModelEvent, error) {
+		feeAccount, err := ctx.AddressArg(0)
+		if err != nil {
+			return nil, err
+		}
+		fee, err := ctx.U64Arg(1)
+		if err != nil {
+			return nil, err
+		}
+		return m.Initialize(ctx.Sender, feeAccount, fee)
+	})
+	handle("btc_bridgev3::mint", func(ctx *FakeTxContext, m *BridgeModel) ([]BridgeModelEvent, error) {
+		btcTxId, err := ctx.StringArg(0)
+		if err != nil {
+			return nil, err
+		}
+		receiver, err := ctx.AddressArg(1)
+		if err != nil {
+			return nil, err
+		}
+		amount, err := ctx.U64Arg(2)
+		if err != nil {
+			return nil, err
+		}
+		return m.Mint(ctx.Sender, btcTxId, receiver, amount)
+	})
+	handle("btc_bridgev3::redeem_request", func(ctx *FakeTxContext, m *BridgeModel) ([]BridgeModelEvent, error) {
+		amount, err := ctx.U64Arg(0)
+		if err != nil {
+			return nil, err
+		}
+		receiver, err := ctx.StringArg(1)
+		if err != nil {
+			return nil, err
+		}
+		return m.RedeemRequest(ctx.Sender, amount, receiver)
+	})
+	handle("btc_bridgev3::redeem_prepare", func(ctx *FakeTxContext, m *BridgeModel) ([]BridgeModelEvent, error) {
+		txHash, err := ctx.StringArg(0)
+		if err != nil {
+			return nil, err
+		}
+		requester, err := ctx.AddressArg(1)
+		if err != nil {
+			return nil, err
+		}
+		receiver, err := ctx.StringArg(2)
+		if err != nil {
+			return nil, err
+		}
+		amount, err := ctx.U64Arg(3)
+		if err != nil {
+			return nil, err
+		}
+		txIds, err := ctx.StringVectorArg(4)
+		if err != nil {
+			return nil, err
+		}
+		idxs, err := ctx.U64VectorArg(5)
+		if err != nil {
+			return nil, err
+		}
+		return m.RedeemPrepare(ctx.Sender, txHash, requester, receiver, amount, txIds, idxs)
+	})
+	handle("btc_tokenv3::initialize_module", func(ctx *FakeTxContext, m *BridgeModel) ([]BridgeModelEvent, error) {
+		return m.InitializeModule(ctx.Sender)
+	})
+	handle("btc_tokenv3::register", func(ctx *FakeTxContext, m *BridgeModel) ([]BridgeModelEvent, error) {
+		return m.Register(ctx.Sender)
+	})
+	handle("btc_tokenv3::registerv2", func(ctx *FakeTxContext, m *BridgeModel) ([]BridgeModelEvent, error) {
+		if len(ctx.Signers) != 2 {
+			return nil, fakeVmError("NUMBER_OF_SIGNER_ARGUMENTS_MISMATCH")
+		}
+		return m.RegisterV2(ctx.Signers[0], ctx.Signers[1])
+	})
+	handle("btc_tokenv3::mint_tokens", func(ctx *FakeTxContext, m *BridgeModel) ([]BridgeModelEvent, error) {
+		recipient, err := ctx.AddressArg(0)
+		if err != nil {
+			return nil, err
+		}
+		amount, err := ctx.U64Arg(1)
+		if err != nil {
+			return nil, err
+		}
+		btcTxId, err := ctx.StringArg(2)
+		if err != nil {
+			return nil, err
+		}
+		return m.MintTokens(ctx.Sender, recipient, amount, btcTxId)
+	})
+	handle("btc_tokenv3::transfer", func(ctx *FakeTxContext, m *BridgeModel) ([]BridgeModelEvent, error) {
+		to, err := ctx.AddressArg(0)
+		if err != nil {
+			return nil, err
+		}
+		amount, err := ctx.U64Arg(1)
+		if err != nil {
+			return nil, err
+		}
+		return m.Transfer(ctx.Sender, to, amount)
+	})
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// bridgeScenarioStep 场景中的一笔交易，abort 为空时期望成功，否则为期望的中止位置、名称和中止码
+type bridgeScenarioStep struct {
+	description string
+	submit      func() error
+	abort       string
+}
+
+// TestBridgeContractScenario 按合约源码手工推算每一步的中止码和余额，不依赖参考模型的实现，
+// 用于发现参考模型(以及运行它的模拟节点)与 btc_bridgev3/btc_tokenv3 的偏差
+func TestBridgeContractScenario(t *testing.T) {
+	node := NewFakeFullnode()
+	defer node.Close()
+	t.Setenv("APTOS_NODE_URL", node.URL())
+	client, err := node.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := newTestAccount(t, "scenario-admin")
+	feeAccount := newTestAccount(t, "scenario-fee")
+	alice := newTestAccount(t, "scenario-alice")
+	bob := newTestAccount(t, "scenario-bob")
+	mallory := newTestAccount(t, "scenario-mallory")
+	for _, account := range []*aptos.Account{admin, feeAccount, alice, bob, mallory} {
+		err = node.CreateAccount(account.Address, fakeNodeFundOctas)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	node.InstallBridgeModel(admin.Address)
+	module := admin.Address.String()
+
+	// 中止码为 error 类别 << 16 | 原因：invalid_argument=0x1，not_found=0x6，permission_denied=0x5，already_exists=0x8
+	call := func(signer *aptos.Account, build func() (aptos.TransactionPayload, error)) func() error {
+		return func() error {
+			payload, err := build()
+			if err != nil {
+				return err
+			}
+			_, err = submitAndConfirm(client, signer, payload, nil)
+			return err
+		}
+	}
+	initialize := func(signer *aptos.Account, feeAccount aptos.AccountAddress, fee uint64) func() error {
+		return call(signer, func() (aptos.TransactionPayload, error) { return BridgeInitializePayload(module, feeAccount, fee) })
+	}
+	register := func(signer *aptos.Account) func() error {
+		return call(signer, func() (aptos.TransactionPayload, error) { return TokenRegisterPayload(module) })
+	}
+	mint := func(signer *aptos.Account, btcTxId string, receiver aptos.AccountAddress, amount uint64) func() error {
+		return call(signer, func() (aptos.TransactionPayload, error) { return BridgeMintPayload(module, btcTxId, receiver, amount) })
+	}
+	mintTokens := func(signer *aptos.Account, recipient aptos.AccountAddress, amount uint64, btcTxId string) func() error {
+		return call(signer, func() (aptos.TransactionPayload, error) {
+			return TokenMintTokensPayload(module, recipient, amount, btcTxId)
+		})
+	}
+	transfer := func(signer *aptos.Account, to aptos.AccountAddress, amount uint64) func() error {
+		return call(signer, func() (aptos.TransactionPayload, error) { return TokenTransferPayload(module, to, amount) })
+	}
+	redeemRequest := func(signer *aptos.Account, amount uint64) func() error {
+		return call(signer, func() (aptos.TransactionPayload, error) {
+			return BridgeRedeemRequestPayload(module, amount, "tb1qreceiver")
+		})
+	}
+	redeemPrepare := func(signer *aptos.Account, txHash string, requester aptos.AccountAddress, receiver string, amount uint64, txIds []string, idxs []uint64) func() error {
+		return call(signer, func() (aptos.TransactionPayload, error) {
+			return BridgeRedeemPreparePayload(module, txHash, requester, receiver, amount, txIds, idxs)
+		})
+	}
+	zero := aptos.AccountAddress{}
+
+	steps := []bridgeScenarioStep{
+		// initialize_module 最后调用 registerv2(admin, admin)，它要求账户已注册
+		{"管理员未注册时初始化", initialize(admin, feeAccount.Address, 1000), "btc_tokenv3: E_ALREADY_INITIALIZED(0x10003)"},
+		{"非模块账户初始化", initialize(alice, feeAccount.Address, 1000), "btc_tokenv3: E_NOT_AUTHORIZED(0x50001)"},
+		{"管理员注册", register(admin), ""},
+		{"费用账户为零地址", initialize(admin, zero, 1000), "btc_bridgev3: E_ZERO_ETH_ADDRESS(0x10003)"},
+		{"费用为零", initialize(admin, feeAccount.Address, 0), "btc_bridgev3: E_ZERO_FEE(0x10004)"},
+		{"初始化", initialize(admin, feeAccount.Address, 1000), ""},
+		{"重复初始化", initialize(admin, feeAccount.Address, 1000), "btc_bridgev3: E_ALREADY_INITIALIZED(0x80002)"},
+		{"重复初始化代币", call(admin, func() (aptos.TransactionPayload, error) { return TokenInitializeModulePayload(module) }), "btc_tokenv3: E_ALREADY_INITIALIZED(0x80003)"},
+
+		{"非管理员铸币", mint(alice, "tx-1", alice.Address, 50000), "btc_bridgev3: E_NOT_AUTHORIZED(0x50001)"},
+		{"铸给零地址", mint(admin, "tx-1", zero, 50000), "btc_bridgev3: E_ZERO_ETH_ADDRESS(0x10003)"},
+		{"铸给未注册账户", mint(admin, "tx-1", alice.Address, 50000), "btc_tokenv3: E_ALREADY_INITIALIZED(0x10003)"},
+		{"alice 注册", register(alice), ""},
+		{"bob 注册", register(bob), ""},
+		{"费用账户注册", register(feeAccount), ""},
+		{"金额等于桥费用", mint(admin, "tx-1", alice.Address, 1000), "btc_bridgev3: E_INSUFFICIENT_AMOUNT(0x10006)"},
+		{"金额不超过代币手续费", mint(admin, "tx-1", alice.Address, 10000), "btc_tokenv3: E_INSUFFICIENT_AMOUNT(0x10006)"},
+		// 代币合约扣除 10000 给管理员，桥的费用只影响事件中的金额
+		{"铸币 50000 给 alice", mint(admin, "tx-1", alice.Address, 50000), ""},
+		{"重复的 btc_tx_id", mint(admin, "tx-1", bob.Address, 30000), "btc_bridgev3: E_ALREADY_MINTED(0x10005)"},
+		{"铸币 30000 给 bob", mint(admin, "tx-2", bob.Address, 30000), ""},
+		{"非管理员直接调用 mint_tokens", mintTokens(alice, alice.Address, 20000, "direct"), "btc_tokenv3: E_NOT_AUTHORIZED(0x50001)"},
+		{"管理员直接铸币 20000 给 bob", mintTokens(admin, bob.Address, 20000, "direct"), ""},
+
+		{"alice 转给 bob 5000", transfer(alice, bob.Address, 5000), ""},
+		{"转账超过余额", transfer(alice, bob.Address, 1_000_000), "0x1::coin: EINSUFFICIENT_BALANCE(0x10006)"},
+		{"赎回金额等于桥费用", redeemRequest(alice, 1000), "btc_bridgev3: E_INSUFFICIENT_AMOUNT(0x10006)"},
+		{"未注册账户赎回", redeemRequest(mallory, 20000), "0x1::coin: ECOIN_STORE_NOT_PUBLISHED(0x60005)"},
+		// burn_from 只检查余额不扣减，alice 只付出桥费用
+		{"alice 赎回 20000", redeemRequest(alice, 20000), ""},
+		// bob 有 35000，费用转出后余额 34000 小于要销毁的 35000，整笔交易回滚
+		{"bob 赎回超过余额", redeemRequest(bob, 36000), "btc_tokenv3: E_INSUFFICIENT_BALANCE(0x10004)"},
+
+		{"非管理员准备赎回", redeemPrepare(alice, "redeem-1", alice.Address, "tb1q", 100, []string{"utxo-a"}, []uint64{0}), "btc_bridgev3: E_NOT_AUTHORIZED(0x50001)"},
+		{"交易哈希为空", redeemPrepare(admin, "", alice.Address, "tb1q", 100, []string{"utxo-a"}, []uint64{0}), "btc_bridgev3: E_ZERO_ETH_TX_HASH(0x10009)"},
+		{"请求者为零地址", redeemPrepare(admin, "redeem-1", zero, "tb1q", 100, []string{"utxo-a"}, []uint64{0}), "btc_bridgev3: E_ZERO_ETH_ADDRESS(0x10003)"},
+		{"接收地址为空", redeemPrepare(admin, "redeem-1", alice.Address, "", 100, []string{"utxo-a"}, []uint64{0}), "btc_bridgev3: E_EMPTY_STRING(0x1000a)"},
+		{"金额为零", redeemPrepare(admin, "redeem-1", alice.Address, "tb1q", 0, []string{"utxo-a"}, []uint64{0}), "btc_bridgev3: E_ZERO_AMOUNT(0x1000b)"},
+		{"没有 outpoint", redeemPrepare(admin, "redeem-1", alice.Address, "tb1q", 100, nil, nil), "btc_bridgev3: E_EMPTY_OUTPOINT_TX_IDS(0x1000c)"},
+		{"没有 outpoint 序号", redeemPrepare(admin, "redeem-1", alice.Address, "tb1q", 100, []string{"utxo-a"}, nil), "btc_bridgev3: E_EMPTY_OUTPOINT_IDXS(0x1000d)"},
+		{"outpoint 数量不一致", redeemPrepare(admin, "redeem-1", alice.Address, "tb1q", 100, []string{"utxo-a"}, []uint64{0, 1}), "btc_bridgev3: E_OUTPOINT_TX_IDS_AND_OUTPOINT_IDXS_LENGTH_MISMATCH(0x1000e)"},
+		{"outpoint 交易ID为空", redeemPrepare(admin, "redeem-1", alice.Address, "tb1q", 100, []string{"utxo-a", ""}, []uint64{0, 1}), "btc_bridgev3: E_ZERO_OUTPOINT_TX_ID(0x1000f)"},
+		// 同一次调用内重复的 outpoint 不会被发现
+		{"准备赎回", redeemPrepare(admin, "redeem-1", alice.Address, "tb1q", 100, []string{"utxo-a", "utxo-a"}, []uint64{0, 1}), ""},
+		{"重复准备", redeemPrepare(admin, "redeem-1", alice.Address, "tb1q", 100, []string{"utxo-b"}, []uint64{0}), "btc_bridgev3: E_ALREADY_PREPARED(0x10008)"},
+		{"outpoint 已使用", redeemPrepare(admin, "redeem-2", alice.Address, "tb1q", 100, []string{"utxo-b", "utxo-a"}, []uint64{0, 0}), "btc_bridgev3: E_BTC_TX_ID_ALREADY_USED(0x10010)"},
+	}
+	for _, step := range steps {
+		err := step.submit()
+		switch {
+		case step.abort == "" && err != nil:
+			t.Fatalf("%s: 应成功，实际: %v", step.description, err)
+		case step.abort != "" && (err == nil || !strings.Contains(err.Error(), "::"+step.abort) && !strings.Contains(err.Error(), "in "+step.abort)):
+			t.Fatalf("%s: 应以 %s 中止，实际: %v", step.description, step.abort, err)
+		}
+	}
+
+	// 余额: 每次铸币管理员得到 10000，接收者得到金额减 10000
+	for _, expected := range []struct {
+		name    string
+		account *aptos.Account
+		balance uint64
+	}{
+		{"admin", admin, 3 * twbtcTokenMintFee},
+		{"alice", alice, 50000 - twbtcTokenMintFee - 5000 - 1000},
+		{"bob", bob, 30000 - twbtcTokenMintFee + 20000 - twbtcTokenMintFee + 5000},
+		{"fee", feeAccount, 1000},
+	} {
+		balance, err := TWBTCBalance(client, expected.account.Address, module)
+		if err != nil || balance != expected.balance {
+			t.Fatalf("%s 余额应为 %d，实际 %d: %v", expected.name, expected.balance, balance, err)
+		}
+	}
+	if _, err := TWBTCBalance(client, mallory.Address, module); err == nil {
+		t.Fatalf("未注册账户查询余额应报错")
+	}
+	// burn_from 铸造后立即销毁，供应量只随铸币增加
+	supply, err := TWBTCSupply(client, module)
+	if err != nil || supply.Uint64() != 50000+30000+20000 {
+		t.Fatalf("供应量应为 100000，实际 %v: %v", supply, err)
+	}
+
+	minted, err := GetMintedTransactions(client, module)
+	if err != nil || strings.Join(minted.Minted, ",") != "tx-1,tx-2" {
+		t.Fatalf("已铸币列表应为 tx-1,tx-2，实际 %v: %v", minted, err)
+	}
+	prepared, err := GetPreparedRedeems(client, module)
+	if err != nil || strings.Join(prepared, ",") != "redeem-1" {
+		t.Fatalf("已准备列表应为 redeem-1，实际 %v: %v", prepared, err)
+	}
+	used, err := GetUsedBtcTxIds(client, module)
+	if err != nil || strings.Join(used, ",") != "utxo-a,utxo-a" {
+		t.Fatalf("已使用列表应为 utxo-a,utxo-a，实际 %v: %v", used, err)
+	}
+
+	mintEvents, err := GetBridgeMintEvents(client, module, 10)
+	if err != nil || len(mintEvents) != 2 || mintEvents[0].Amount != 50000-1000 || mintEvents[1].Amount != 30000-1000 {
+		t.Fatalf("桥铸币事件金额应扣除桥费用，实际 %+v: %v", mintEvents, err)
+	}
+	tokenMintEvents, err := GetTokenMintEvents(client, module, 10)
+	if err != nil || len(tokenMintEvents) != 3 || tokenMintEvents[2].Amount != 20000-twbtcTokenMintFee || tokenMintEvents[2].BtcTxId != "direct" {
+		t.Fatalf("代币铸币事件应有 3 个且金额扣除代币手续费，实际 %+v: %v", tokenMintEvents, err)
+	}
+	burnEvents, err := GetTokenBurnEvents(client, module, 10)
+	if err != nil || len(burnEvents) != 1 || burnEvents[0].Amount != 19000 {
+		t.Fatalf("应有 1 个销毁 19000 的事件，实际 %+v: %v", burnEvents, err)
+	}
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ripemd160"
+)
+
+// 比特币锁定脚本类型
+const (
+	BtcScriptP2PKH   = "p2pkh"
+	BtcScriptP2SH    = "p2sh"
+	BtcScriptP2WPKH  = "p2wpkh"
+	BtcScriptP2WSH   = "p2wsh"
+	BtcScriptP2TR    = "p2tr"
+	BtcScriptUnknown = "unknown"
+)
+
+const (
+	bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	base58Charset = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+	// BIP173 与 BIP350 的校验常量
+	bech32Const  uint32 = 1
+	bech32mConst uint32 = 0x2bc830a3
+)
+
+// doubleSha256 计算 SHA256(SHA256(data))
+func doubleSha256(data []byte) [32]byte {
+	first := sha256.Sum256(data)
+	return sha256.Sum256(first[:])
+}
+
+// hash160 计算 RIPEMD160(SHA256(data))
+func hash160(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	h := ripemd160.New()
+	h.Write(sum[:])
+	return h.Sum(nil)
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HrpExpand(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+// bech32Decode 解码 bech32/bech32m 字符串，返回前缀、去掉校验和的数据和校验常量
+func bech32Decode(s string) (string, []byte, uint32, error) {
+	if len(s) > 90 {
+		return "", nil, 0, fmt.Errorf("地址长度超过90个字符")
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, fmt.Errorf("地址不能混用大小写")
+	}
+	s = strings.ToLower(s)
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || pos+7 > len(s) {
+		return "", nil, 0, fmt.Errorf("无效的bech32分隔符位置")
+	}
+	hrp := s[:pos]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, 0, fmt.Errorf("前缀包含无效字符")
+		}
+	}
+	data := make([]byte, 0, len(s)-pos-1)
+	for i := pos + 1; i < len(s); i++ {
+		idx := strings.IndexByte(bech32Charset, s[i])
+		if idx < 0 {
+			return "", nil, 0, fmt.Errorf("包含无效的bech32字符 %q", s[i])
+		}
+		data = append(data, byte(idx))
+	}
+	constant := bech32Polymod(append(bech32HrpExpand(hrp), data...))
+	if constant != bech32Const && constant != bech32mConst {
+		return "", nil, 0, fmt.Errorf("bech32校验和错误")
+	}
+	return hrp, data[:len(data)-6], constant, nil
+}
+
+// bech32Encode 按指定校验常量编码 bech32/bech32m 字符串
+func bech32Encode(hrp string, data []byte, constant uint32) string {
+	values := append(bech32HrpExpand(hrp), data...)
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ constant
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, d := range data {
+		sb.WriteByte(bech32Charset[d])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+	return sb.String()
+}
+
+// convertBits 在不同位宽的分组之间转换
+func convertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	acc, bits := uint32(0), uint(0)
+	maxv := uint32(1)<<toBits - 1
+	var out []byte
+	for _, value := range data {
+		if uint32(value)>>fromBits != 0 {
+			return nil, fmt.Errorf("数据超出 %d 位", fromBits)
+		}
+		acc = acc<<fromBits | uint32(value)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(toBits-bits)&maxv))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxv != 0 {
+		return nil, fmt.Errorf("填充位无效")
+	}
+	return out, nil
+}
+
+// decodeSegwitAddress 解码隔离见证地址，返回前缀、见证版本和见证程序
+func decodeSegwitAddress(address string) (string, byte, []byte, error) {
+	hrp, data, constant, err := bech32Decode(address)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if len(data) < 1 || data[0] > 16 {
+		return "", 0, nil, fmt.Errorf("无效的见证版本")
+	}
+	version := data[0]
+	program, err := convertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return "", 0, nil, fmt.Errorf("见证程序长度 %d 无效", len(program))
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return "", 0, nil, fmt.Errorf("v0见证程序长度必须为20或32字节，实际为 %d", len(program))
+	}
+	if version == 0 && constant != bech32Const {
+		return "", 0, nil, fmt.Errorf("v0地址必须使用bech32编码")
+	}
+	if version != 0 && constant != bech32mConst {
+		return "", 0, nil, fmt.Errorf("v%d地址必须使用bech32m编码", version)
+	}
+	return hrp, version, program, nil
+}
+
+// encodeSegwitAddress 把见证版本和见证程序编码为地址
+func encodeSegwitAddress(hrp string, version byte, program []byte) (string, error) {
+	data, err := convertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	constant := bech32Const
+	if version != 0 {
+		constant = bech32mConst
+	}
+	return bech32Encode(hrp, append([]byte{version}, data...), constant), nil
+}
+
+// base58CheckDecode 解码 base58check 字符串，返回版本字节和负载
+func base58CheckDecode(s string) (byte, []byte, error) {
+	num := new(big.Int)
+	radix := big.NewInt(58)
+	for i := 0; i < len(s); i++ {
+		idx := strings.IndexByte(base58Charset, s[i])
+		if idx < 0 {
+			return 0, nil, fmt.Errorf("包含无效的base58字符 %q", s[i])
+		}
+		num.Mul(num, radix)
+		num.Add(num, big.NewInt(int64(idx)))
+	}
+	decoded := num.Bytes()
+	for i := 0; i < len(s) && s[i] == base58Charset[0]; i++ {
+		decoded = append([]byte{0}, decoded...)
+	}
+	if len(decoded) < 5 {
+		return 0, nil, fmt.Errorf("base58数据过短")
+	}
+	body, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	sum := doubleSha256(body)
+	if !bytes.Equal(sum[:4], checksum) {
+		return 0, nil, fmt.Errorf("base58check校验和错误")
+	}
+	return body[0], body[1:], nil
+}
+
+// witnessProgramScript 构建 OP_n <program> 形式的见证输出脚本
+func witnessProgramScript(version byte, program []byte) []byte {
+	op := byte(0x00)
+	if version > 0 {
+		op = 0x50 + version
+	}
+	return append([]byte{op, byte(len(program))}, program...)
+}
+
+// BtcNetwork 比特币网络的地址前缀
+type BtcNetwork struct {
+	Name             string
+	Bech32HRP        string
+	PubKeyHashAddrID byte
+	ScriptHashAddrID byte
+}
+
+// 支持的比特币网络，signet 与 testnet 使用相同的地址前缀
+var btcNetworks = map[string]BtcNetwork{
+	"mainnet": {Name: "mainnet", Bech32HRP: "bc", PubKeyHashAddrID: 0x00, ScriptHashAddrID: 0x05},
+	"testnet": {Name: "testnet", Bech32HRP: "tb", PubKeyHashAddrID: 0x6f, ScriptHashAddrID: 0xc4},
+	"signet":  {Name: "signet", Bech32HRP: "tb", PubKeyHashAddrID: 0x6f, ScriptHashAddrID: 0xc4},
+	"regtest": {Name: "regtest", Bech32HRP: "bcrt", PubKeyHashAddrID: 0x6f, ScriptHashAddrID: 0xc4},
+}
+
+// 默认的比特币网络，与默认的 Esplora 接口一致
+const defaultBtcNetwork = "testnet"
+
+// loadBtcNetwork 读取 BTC_NETWORK 配置的比特币网络 (mainnet/testnet/signet/regtest)
+func loadBtcNetwork() (BtcNetwork, error) {
+	name := os.Getenv("BTC_NETWORK")
+	if name == "" {
+		name = defaultBtcNetwork
+	}
+	network, ok := btcNetworks[strings.ToLower(name)]
+	if !ok {
+		return BtcNetwork{}, fmt.Errorf("无效的BTC_NETWORK: %s (应为 mainnet、testnet、signet 或 regtest)", name)
+	}
+	return network, nil
+}
+
+// btcNetworkForHRP 根据 bech32 前缀判断地址所属网络，用于错误提示
+func btcNetworkForHRP(hrp string) string {
+	switch hrp {
+	case "bc":
+		return "mainnet"
+	case "tb":
+		return "testnet/signet"
+	case "bcrt":
+		return "regtest"
+	}
+	return ""
+}
+
+// btcNetworkForAddrID 根据 base58 版本字节判断地址所属网络，用于错误提示
+func btcNetworkForAddrID(version byte) string {
+	switch version {
+	case 0x00, 0x05:
+		return "mainnet"
+	case 0x6f, 0xc4:
+		return "testnet/signet/regtest"
+	}
+	return ""
+}
+
+// BtcAddress 校验通过的比特币地址
+type BtcAddress struct {
+	Address  string
+	Type     string
+	PkScript []byte
+}
+
+// ParseBtcAddress 校验地址的编码、校验和、类型和网络前缀，只接受 P2PKH、P2SH、P2WPKH、P2WSH 和 P2TR
+func ParseBtcAddress(address string, network BtcNetwork) (*BtcAddress, error) {
+	if address == "" {
+		return nil, fmt.Errorf("BTC地址为空")
+	}
+	if strings.TrimSpace(address) != address {
+		return nil, fmt.Errorf("BTC地址 %q 包含首尾空白", address)
+	}
+
+	if pos := strings.LastIndexByte(address, '1'); pos > 0 && btcNetworkForHRP(strings.ToLower(address[:pos])) != "" {
+		hrp, version, program, err := decodeSegwitAddress(address)
+		if err != nil {
+			return nil, fmt.Errorf("无效的BTC地址 %s: %v", address, err)
+		}
+		if hrp != network.Bech32HRP {
+			return nil, fmt.Errorf("BTC地址 %s 属于 %s 网络，当前配置为 %s", address, btcNetworkForHRP(hrp), network.Name)
+		}
+		parsed := &BtcAddress{Address: address, PkScript: witnessProgramScript(version, program)}
+		switch {
+		case version == 0 && len(program) == 20:
+			parsed.Type = BtcScriptP2WPKH
+		case version == 0 && len(program) == 32:
+			parsed.Type = BtcScriptP2WSH
+		case version == 1 && len(program) == 32:
+			parsed.Type = BtcScriptP2TR
+		default:
+			return nil, fmt.Errorf("不支持的BTC地址 %s: 见证版本 %d、程序长度 %d", address, version, len(program))
+		}
+		return parsed, nil
+	}
+
+	version, payload, err := base58CheckDecode(address)
+	if err != nil {
+		return nil, fmt.Errorf("无效的BTC地址 %s: %v", address, err)
+	}
+	if len(payload) != 20 {
+		return nil, fmt.Errorf("无效的BTC地址 %s: 哈希长度 %d 无效", address, len(payload))
+	}
+	if name := btcNetworkForAddrID(version); name == "" {
+		return nil, fmt.Errorf("无效的BTC地址 %s: 未知的版本字节 0x%02x", address, version)
+	} else if version != network.PubKeyHashAddrID && version != network.ScriptHashAddrID {
+		return nil, fmt.Errorf("BTC地址 %s 属于 %s 网络，当前配置为 %s", address, name, network.Name)
+	}
+	if version == network.PubKeyHashAddrID {
+		script := append([]byte{0x76, 0xa9, 0x14}, payload...)
+		return &BtcAddress{Address: address, Type: BtcScriptP2PKH, PkScript: append(script, 0x88, 0xac)}, nil
+	}
+	script := append([]byte{0xa9, 0x14}, payload...)
+	return &BtcAddress{Address: address, Type: BtcScriptP2SH, PkScript: append(script, 0x87)}, nil
+}
+
+// btcAddressScript 把比特币地址转换为输出锁定脚本
+func btcAddressScript(address string, network BtcNetwork) ([]byte, error) {
+	parsed, err := ParseBtcAddress(address, network)
+	if err != nil {
+		return nil, err
+	}
+	return parsed.PkScript, nil
+}
+
+// validateRedeemReceiver 按 BTC_NETWORK 校验赎回接收地址，地址写错会导致燃烧的 TWBTC 无法找回
+func validateRedeemReceiver(receiver string) (*BtcAddress, error) {
+	network, err := loadBtcNetwork()
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := ParseBtcAddress(receiver, network)
+	if err != nil {
+		return nil, fmt.Errorf("赎回接收地址无效: %v", err)
+	}
+	return parsed, nil
+}
+
+// btcScriptType 识别标准输出脚本的类型
+func btcScriptType(script []byte) string {
+	switch {
+	case len(script) == 25 && script[0] == 0x76 && script[1] == 0xa9 && script[2] == 0x14 && script[23] == 0x88 && script[24] == 0xac:
+		return BtcScriptP2PKH
+	case len(script) == 23 && script[0] == 0xa9 && script[1] == 0x14 && script[22] == 0x87:
+		return BtcScriptP2SH
+	case len(script) == 22 && script[0] == 0x00 && script[1] == 0x14:
+		return BtcScriptP2WPKH
+	case len(script) == 34 && script[0] == 0x00 && script[1] == 0x20:
+		return BtcScriptP2WSH
+	case len(script) == 34 && script[0] == 0x51 && script[1] == 0x20:
+		return BtcScriptP2TR
+	}
+	return BtcScriptUnknown
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// testBase58CheckEncode 按 base58check 编码，用于构造版本字节或负载长度不合法的地址
+func testBase58CheckEncode(version byte, payload []byte) string {
+	body := append([]byte{version}, payload...)
+	sum := doubleSha256(body)
+	data := append(body, sum[:4]...)
+	num := new(big.Int).SetBytes(data)
+	radix, mod := big.NewInt(58), new(big.Int)
+	var encoded []byte
+	for num.Sign() > 0 {
+		num.DivMod(num, radix, mod)
+		encoded = append([]byte{base58Charset[mod.Int64()]}, encoded...)
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		encoded = append([]byte{base58Charset[0]}, encoded...)
+	}
+	return string(encoded)
+}
+
+// BIP173 和 BIP350 中与具体地址无关的 bech32/bech32m 校验和测试向量
+func TestBech32DecodeVectors(t *testing.T) {
+	valid := []struct {
+		s        string
+		constant uint32
+	}{
+		{"A12UEL5L", bech32Const},
+		{"a12uel5l", bech32Const},
+		{"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", bech32Const},
+		{"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w", bech32Const},
+		{"?1ezyfcl", bech32Const},
+		{"A1LQFN3A", bech32mConst},
+		{"a1lqfn3a", bech32mConst},
+		{"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx", bech32mConst},
+		{"split1checkupstagehandshakeupstreamerranterredcaperredlc445v", bech32mConst},
+		{"?1v759aa", bech32mConst},
+	}
+	for _, vector := range valid {
+		_, _, constant, err := bech32Decode(vector.s)
+		if err != nil || constant != vector.constant {
+			t.Fatalf("%s 应解码成功且校验常量为 %#x，实际 %#x: %v", vector.s, vector.constant, constant, err)
+		}
+	}
+
+	invalid := []struct {
+		s   string
+		err string
+	}{
+		{"\x201nwldj5", "前缀包含无效字符"},
+		{"\x7f1axkwrx", "前缀包含无效字符"},
+		{"an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx", "超过90个字符"},
+		{"pzry9x0s0muk", "分隔符"},
+		{"1pzry9x0s0muk", "分隔符"},
+		{"x1b4n0q5v", "无效的bech32字符"},
+		{"li1dgmt3", "分隔符"},
+		{"A1G7SGD8", "校验和错误"},
+		{"10a06t8", "分隔符"},
+		{"1qzzfhee", "分隔符"},
+	}
+	for _, vector := range invalid {
+		_, _, _, err := bech32Decode(vector.s)
+		if err == nil || !strings.Contains(err.Error(), vector.err) {
+			t.Fatalf("%q 应报错 %q，实际 %v", vector.s, vector.err, err)
+		}
+	}
+}
+
+// BIP173 和 BIP350 的隔离见证地址测试向量
+func TestDecodeSegwitAddressVectors(t *testing.T) {
+	valid := []struct {
+		address string
+		script  string
+	}{
+		{"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", "0014751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+		{"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y", "5128751e76e8199196d454941c45d1b3a323f1433bd6751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"BC1SW50QGDZ25J", "6002751e"},
+		{"bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs", "5210751e76e8199196d454941c45d1b3a323"},
+		{"tb1qqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesrxh6hy", "0020000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433"},
+		{"tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", "5120000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433"},
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+	}
+	for _, vector := range valid {
+		hrp, version, program, err := decodeSegwitAddress(vector.address)
+		if err != nil {
+			t.Fatalf("%s 应解码成功: %v", vector.address, err)
+		}
+		if script := hex.EncodeToString(witnessProgramScript(version, program)); script != vector.script {
+			t.Fatalf("%s 的输出脚本应为 %s，实际 %s", vector.address, vector.script, script)
+		}
+		encoded, err := encodeSegwitAddress(hrp, version, program)
+		if err != nil || encoded != strings.ToLower(vector.address) {
+			t.Fatalf("%s 重新编码应得到原地址，实际 %s: %v", vector.address, encoded, err)
+		}
+	}
+
+	invalid := []struct {
+		address string
+		err     string
+	}{
+		// BIP350: 见证版本与校验常量不匹配
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", "v1地址必须使用bech32m编码"},
+		{"tb1z0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqglt7rf", "v2地址必须使用bech32m编码"},
+		{"BC1S0XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ54WELL", "v16地址必须使用bech32m编码"},
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh", "v0地址必须使用bech32编码"},
+		{"tb1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq24jc47", "v0地址必须使用bech32编码"},
+		{"bc1p38j9r5y49hruaue7wxjce0updqjuyyx0kh56v8s25huc6995vvpql3jow4", "无效的bech32字符"},
+		{"BC130XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ7ZWS8R", "无效的见证版本"},
+		{"bc1pw5dgrnzv", "见证程序长度 1 无效"},
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v8n0nx0muaewav253zgeav", "见证程序长度 41 无效"},
+		{"BC1QR508D6QEJXTDG4Y5R3ZARVARYV98GJ9P", "v0见证程序长度必须为20或32字节"},
+		{"tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq47Zagq", "不能混用大小写"},
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v07qwwzcrf", "填充位无效"},
+		{"tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vpggkg4j", "填充位无效"},
+		{"bc1gmk9yu", "无效的见证版本"},
+		// BIP173
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", "校验和错误"},
+		{"bc1rw5uspcuh", "见证程序长度 1 无效"},
+		{"bc10w508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kw5rljs90", "见证程序长度 41 无效"},
+		{"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sL5k7", "不能混用大小写"},
+		{"bc1zw508d6qejxtdg4y5r3zarvaryvqyzf3du", "填充位无效"},
+		{"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3pjxtptv", "填充位无效"},
+	}
+	for _, vector := range invalid {
+		_, _, _, err := decodeSegwitAddress(vector.address)
+		if err == nil || !strings.Contains(err.Error(), vector.err) {
+			t.Fatalf("%s 应报错 %q，实际 %v", vector.address, vector.err, err)
+		}
+	}
+}
+
+func TestParseBtcAddress(t *testing.T) {
+	mainnet, testnet, signet, regtest := btcNetworks["mainnet"], btcNetworks["testnet"], btcNetworks["signet"], btcNetworks["regtest"]
+	hash := mustDecodeHex(t, "751e76e8199196d454941c45d1b3a323f1433bd6")
+	program32 := mustDecodeHex(t, "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	segwit := func(hrp string, version byte, program []byte) string {
+		address, err := encodeSegwitAddress(hrp, version, program)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return address
+	}
+
+	valid := []struct {
+		address string
+		network BtcNetwork
+		typ     string
+		script  string
+	}{
+		{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", mainnet, BtcScriptP2PKH, "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac"},
+		{testBase58CheckEncode(0x05, hash), mainnet, BtcScriptP2SH, "a914751e76e8199196d454941c45d1b3a323f1433bd687"},
+		{testBase58CheckEncode(0x6f, hash), testnet, BtcScriptP2PKH, "76a914751e76e8199196d454941c45d1b3a323f1433bd688ac"},
+		{testBase58CheckEncode(0xc4, hash), regtest, BtcScriptP2SH, "a914751e76e8199196d454941c45d1b3a323f1433bd687"},
+		{"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", mainnet, BtcScriptP2WPKH, "0014751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", signet, BtcScriptP2WSH, "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", mainnet, BtcScriptP2TR, "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+		{segwit("bcrt", 1, program32), regtest, BtcScriptP2TR, "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+	}
+	for _, vector := range valid {
+		parsed, err := ParseBtcAddress(vector.address, vector.network)
+		if err != nil {
+			t.Fatalf("%s 在 %s 上应有效: %v", vector.address, vector.network.Name, err)
+		}
+		if parsed.Type != vector.typ || hex.EncodeToString(parsed.PkScript) != vector.script || btcScriptType(parsed.PkScript) != vector.typ {
+			t.Fatalf("%s 应为 %s、脚本 %s，实际 %s、%x", vector.address, vector.typ, vector.script, parsed.Type, parsed.PkScript)
+		}
+	}
+
+	invalid := []struct {
+		name    string
+		address string
+		network BtcNetwork
+		err     string
+	}{
+		{"空地址", "", testnet, "为空"},
+		{"首尾空白", " tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", testnet, "首尾空白"},
+		// 网络前缀不匹配
+		{"主网地址配置为测试网", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", testnet, "属于 mainnet 网络，当前配置为 testnet"},
+		{"测试网地址配置为regtest", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", regtest, "属于 testnet/signet 网络，当前配置为 regtest"},
+		{"regtest地址配置为主网", segwit("bcrt", 1, program32), mainnet, "属于 regtest 网络，当前配置为 mainnet"},
+		{"测试网P2PKH配置为主网", testBase58CheckEncode(0x6f, hash), mainnet, "属于 testnet/signet/regtest 网络，当前配置为 mainnet"},
+		{"主网P2SH配置为signet", testBase58CheckEncode(0x05, hash), signet, "属于 mainnet 网络，当前配置为 signet"},
+		{"未知前缀", "tc1qw508d6qejxtdg4y5r3zarvary0c5xw7kg3g4ty", mainnet, "无效的base58字符"},
+		// base58check
+		{"base58校验和错误", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", mainnet, "base58check校验和错误"},
+		{"base58无效字符", "1A1zP1eP5QGefi2DMPTfTL5SLmv7Divf0a", mainnet, "无效的base58字符"},
+		{"base58过短", "1111", mainnet, "base58数据过短"},
+		{"哈希长度错误", testBase58CheckEncode(0x00, append(hash, 0)), mainnet, "哈希长度 21 无效"},
+		{"未知版本字节", testBase58CheckEncode(0x30, hash), mainnet, "未知的版本字节 0x30"},
+		// 见证程序长度
+		{"v0程序21字节", segwit("bc", 0, append(hash, 0)), mainnet, "v0见证程序长度必须为20或32字节"},
+		{"v1程序1字节", segwit("bc", 1, hash[:1]), mainnet, "见证程序长度 1 无效"},
+		{"v1程序41字节", segwit("bc", 1, append(append(program32, program32[:8]...), 0)), mainnet, "见证程序长度 41 无效"},
+		{"v1程序20字节", segwit("bc", 1, hash), mainnet, "不支持的BTC地址"},
+		{"v2程序32字节", segwit("bc", 2, program32), mainnet, "不支持的BTC地址"},
+		{"v1使用bech32", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", mainnet, "v1地址必须使用bech32m编码"},
+		{"v0使用bech32m", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh", mainnet, "v0地址必须使用bech32编码"},
+	}
+	for _, vector := range invalid {
+		t.Run(vector.name, func(t *testing.T) {
+			_, err := ParseBtcAddress(vector.address, vector.network)
+			if err == nil || !strings.Contains(err.Error(), vector.err) {
+				t.Fatalf("%s 应报错 %q，实际 %v", vector.address, vector.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 各网络 bitcoind 的默认 RPC 端口
+var defaultBitcoindPorts = map[string]int{
+	"mainnet": 8332,
+	"testnet": 18332,
+	"signet":  38332,
+	"regtest": 18443,
+}
+
+// bitcoind 的 RPC 错误码
+const (
+	bitcoindErrNotFound   = -5 // RPC_INVALID_ADDRESS_OR_KEY，交易不存在
+	bitcoindErrOutOfRange = -8 // RPC_INVALID_PARAMETER，区块高度超出范围
+)
+
+// bitcoindError bitcoind 返回的 RPC 错误
+type bitcoindError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *bitcoindError) Error() string {
+	return fmt.Sprintf("bitcoind错误 %d: %s", e.Code, e.Message)
+}
+
+// isBitcoindError 判断是否为指定错误码的 RPC 错误
+func isBitcoindError(err error, code int) bool {
+	var rpcErr *bitcoindError
+	return errors.As(err, &rpcErr) && rpcErr.Code == code
+}
+
+// bitcoindRPC 通过 JSON-RPC 读取 bitcoind 节点。查询任意交易需要节点开启 txindex
+type bitcoindRPC struct {
+	url        string
+	user       string
+	password   string
+	httpClient *http.Client
+	network    BtcNetwork
+	nextId     int
+}
+
+// newBitcoindRPC 按 BTC_RPC_URL、BTC_RPC_USER 和 BTC_RPC_PASSWORD 创建 bitcoind 客户端
+func newBitcoindRPC(network BtcNetwork) *bitcoindRPC {
+	url := os.Getenv("BTC_RPC_URL")
+	if url == "" {
+		url = fmt.Sprintf("http://127.0.0.1:%d", defaultBitcoindPorts[network.Name])
+	}
+	return &bitcoindRPC{
+		url:        url,
+		user:       os.Getenv("BTC_RPC_USER"),
+		password:   os.Getenv("BTC_RPC_PASSWORD"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		network:    network,
+	}
+}
+
+// call 调用 RPC 方法并把结果解析到 out
+func (c *bitcoindRPC) call(method string, params []any, out any) error {
+	c.nextId++
+	body, err := json.Marshal(map[string]any{"jsonrpc": "1.0", "id": c.nextId, "method": method, "params": params})
+	if err != nil {
+		return fmt.Errorf("序列化 %s 请求失败: %v", method, err)
+	}
+	req, err := http.NewRequest("POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建 %s 请求失败: %v", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.user != "" {
+		req.SetBasicAuth(c.user, c.password)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求 %s 失败: %v", method, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取 %s 响应失败: %v", method, err)
+	}
+	// bitcoind 出错时状态码为 404/500，但响应体中仍有 JSON-RPC 错误
+	var result struct {
+		Result json.RawMessage `json:"result"`
+		Error  *bitcoindError  `json:"error"`
+	}
+	err = json.Unmarshal(respBody, &result)
+	if err != nil {
+		return fmt.Errorf("请求 %s 失败: 状态码 %d, 响应体: %s", method, resp.StatusCode, string(respBody))
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if out == nil {
+		return nil
+	}
+	err = json.Unmarshal(result.Result, out)
+	if err != nil {
+		return fmt.Errorf("解析 %s 结果失败: %v", method, err)
+	}
+	return nil
+}
+
+// btcAmountToSats 把 bitcoind 以 BTC 为单位的十进制金额精确转换为 satoshi
+func btcAmountToSats(amount json.Number) (uint64, error) {
+	s := amount.String()
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > 8 {
+		return 0, fmt.Errorf("金额 %s 精度超过8位小数", s)
+	}
+	frac += strings.Repeat("0", 8-len(frac))
+	sats, err := strconv.ParseUint(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无效的金额 %s", s)
+	}
+	return sats, nil
+}
+
+func (c *bitcoindRPC) TipHeight() (int64, error) {
+	var height int64
+	err := c.call("getblockcount", []any{}, &height)
+	return height, err
+}
+
+func (c *bitcoindRPC) BlockHashAtHeight(height int64) (string, error) {
+	var hash string
+	err := c.call("getblockhash", []any{height}, &hash)
+	if isBitcoindError(err, bitcoindErrOutOfRange) {
+		return "", nil
+	}
+	return hash, err
+}
+
+// bitcoindHeader getblockheader 的结果
+type bitcoindHeader struct {
+	Hash              string `json:"hash"`
+	Height            int64  `json:"height"`
+	Time              int64  `json:"time"`
+	PreviousBlockHash string `json:"previousblockhash"`
+	Confirmations     int64  `json:"confirmations"` // 不在主链上时为 -1
+}
+
+func (c *bitcoindRPC) blockHeader(hash string) (*bitcoindHeader, error) {
+	header := &bitcoindHeader{}
+	err := c.call("getblockheader", []any{hash, true}, header)
+	if err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+func (c *bitcoindRPC) BlockHeader(height int64) (*BtcBlockHeader, error) {
+	hash, err := c.BlockHashAtHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	if hash == "" {
+		return nil, fmt.Errorf("高度 %d 没有区块", height)
+	}
+	header, err := c.blockHeader(hash)
+	if err != nil {
+		return nil, err
+	}
+	return &BtcBlockHeader{Hash: header.Hash, PrevHash: header.PreviousBlockHash, Height: header.Height, Time: header.Time}, nil
+}
+
+func (c *bitcoindRPC) Transaction(txid string) (*BtcTxInfo, error) {
+	var verbose struct {
+		Hex           string `json:"hex"`
+		BlockHash     string `json:"blockhash"`
+		Confirmations int64  `json:"confirmations"`
+	}
+	err := c.call("getrawtransaction", []any{txid, true}, &verbose)
+	if isBitcoindError(err, bitcoindErrNotFound) {
+		return &BtcTxInfo{TxId: txid}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw, err := hex.DecodeString(verbose.Hex)
+	if err != nil {
+		return nil, fmt.Errorf("解析原始交易失败: %v", err)
+	}
+	tx, err := DeserializeBtcTx(raw)
+	if err != nil {
+		return nil, err
+	}
+	info := &BtcTxInfo{TxId: txid, Found: true, Tx: tx}
+	// 所在区块被重组出主链时 confirmations 为 0，按未确认处理
+	if verbose.BlockHash != "" && verbose.Confirmations > 0 {
+		header, err := c.blockHeader(verbose.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+		info.BlockHash = verbose.BlockHash
+		info.BlockHeight = header.Height
+		info.Confirmations = verbose.Confirmations
+	}
+	return info, nil
+}
+
+func (c *bitcoindRPC) TxStatus(txid string) (*BtcTxStatus, error) {
+	info, err := c.Transaction(txid)
+	if err != nil {
+		return nil, err
+	}
+	return &BtcTxStatus{
+		Found:       info.Found,
+		Confirmed:   info.Confirmations > 0,
+		BlockHash:   info.BlockHash,
+		BlockHeight: info.BlockHeight,
+	}, nil
+}
+
+// AddressUtxos 用 scantxoutset 扫描 UTXO 集，只包含已确认的输出
+func (c *bitcoindRPC) AddressUtxos(address string) ([]BtcUtxo, error) {
+	script, err := btcAddressScript(address, c.network)
+	if err != nil {
+		return nil, err
+	}
+	var scan struct {
+		Height   int64 `json:"height"`
+		Unspents []struct {
+			TxId   string      `json:"txid"`
+			Vout   uint32      `json:"vout"`
+			Amount json.Number `json:"amount"`
+			Height int64       `json:"height"`
+		} `json:"unspents"`
+	}
+	err = c.call("scantxoutset", []any{"start", []string{"addr(" + address + ")"}}, &scan)
+	if err != nil {
+		return nil, err
+	}
+	utxos := make([]BtcUtxo, 0, len(scan.Unspents))
+	for _, unspent := range scan.Unspents {
+		value, err := btcAmountToSats(unspent.Amount)
+		if err != nil {
+			return nil, err
+		}
+		utxos = append(utxos, BtcUtxo{
+			TxId:          unspent.TxId,
+			Vout:          unspent.Vout,
+			Value:         value,
+			PkScript:      script,
+			Confirmations: confirmationsAt(scan.Height, unspent.Height),
+		})
+	}
+	return utxos, nil
+}
+
+func (c *bitcoindRPC) Broadcast(rawTx []byte) (string, error) {
+	var txid string
+	err := c.call("sendrawtransaction", []any{hex.EncodeToString(rawTx)}, &txid)
+	if err != nil {
+		return "", fmt.Errorf("广播交易失败: %v", err)
+	}
+	return txid, nil
+}
+
+func (c *bitcoindRPC) EstimateFeeRate(targetBlocks int64) (uint64, error) {
+	var estimate struct {
+		FeeRate json.Number `json:"feerate"` // BTC/kvB
+		Errors  []string    `json:"errors"`
+	}
+	err := c.call("estimatesmartfee", []any{targetBlocks}, &estimate)
+	if err != nil {
+		return 0, err
+	}
+	if estimate.FeeRate == "" {
+		return 0, fmt.Errorf("bitcoind没有费率估算: %s", strings.Join(estimate.Errors, "; "))
+	}
+	satsPerKvB, err := btcAmountToSats(estimate.FeeRate)
+	if err != nil {
+		return 0, err
+	}
+	feeRate := (satsPerKvB + 999) / 1000
+	if feeRate == 0 {
+		feeRate = 1
+	}
+	return feeRate, nil
+}
@@ -0,0 +1,599 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 默认的 Esplora 接口地址(比特币测试网)
+const defaultEsploraURL = "https://blockstream.info/testnet/api"
+
+// 比特币后端类型，通过 BTC_BACKEND 选择
+const (
+	BtcBackendEsplora  = "esplora"
+	BtcBackendBitcoind = "bitcoind"
+	BtcBackendFake     = "fake"
+)
+
+// BtcTxStatus 比特币交易在主链上的位置
+type BtcTxStatus struct {
+	Found       bool // 节点是否知道该交易(在内存池或区块中)
+	Confirmed   bool
+	BlockHash   string
+	BlockHeight int64
+}
+
+// BtcChain 确认策略需要的比特币链视图，只读取当前主链
+type BtcChain interface {
+	TipHeight() (int64, error)
+	BlockHashAtHeight(height int64) (string, error)
+	TxStatus(txid string) (*BtcTxStatus, error)
+}
+
+// BtcBlockHeader 区块头中桥关心的字段
+type BtcBlockHeader struct {
+	Hash     string
+	PrevHash string
+	Height   int64
+	Time     int64
+}
+
+// BtcTxInfo 交易内容及其确认数，Tx 为空表示后端没有原始交易
+type BtcTxInfo struct {
+	TxId          string
+	Found         bool
+	Confirmations int64 // 在内存池中为 0
+	BlockHash     string
+	BlockHeight   int64
+	Tx            *BtcTx
+}
+
+// BtcUtxo 地址上未花费的输出
+type BtcUtxo struct {
+	TxId          string
+	Vout          uint32
+	Value         uint64
+	PkScript      []byte
+	Confirmations int64 // 在内存池中为 0
+}
+
+// BitcoinBackend 存款跟踪、出账广播和审计需要的比特币链数据
+type BitcoinBackend interface {
+	BtcChain
+	BlockHeader(height int64) (*BtcBlockHeader, error)
+	Transaction(txid string) (*BtcTxInfo, error)
+	AddressUtxos(address string) ([]BtcUtxo, error)
+	Broadcast(rawTx []byte) (string, error)
+	EstimateFeeRate(targetBlocks int64) (uint64, error) // sat/vB
+}
+
+// BtcPrevOutSource 查询被花费输出的金额和锁定脚本
+type BtcPrevOutSource interface {
+	PrevOut(txid string, vout uint32) (*BtcPrevOut, error)
+}
+
+// BtcFeeRateSource 比特币手续费率来源 (sat/vB)
+type BtcFeeRateSource interface {
+	FeeRate() (uint64, error)
+}
+
+// fixedBtcFeeRate 固定的手续费率
+type fixedBtcFeeRate uint64
+
+func (r fixedBtcFeeRate) FeeRate() (uint64, error) {
+	return uint64(r), nil
+}
+
+// backendFeeRate 从后端读取指定目标区块数的费率估算
+type backendFeeRate struct {
+	backend      BitcoinBackend
+	targetBlocks int64
+}
+
+func (r *backendFeeRate) FeeRate() (uint64, error) {
+	return r.backend.EstimateFeeRate(r.targetBlocks)
+}
+
+// backendPrevOuts 通过后端查询交易得到被花费输出
+type backendPrevOuts struct {
+	backend BitcoinBackend
+}
+
+func (s *backendPrevOuts) PrevOut(txid string, vout uint32) (*BtcPrevOut, error) {
+	info, err := s.backend.Transaction(txid)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Found || info.Tx == nil {
+		return nil, fmt.Errorf("未找到BTC交易 %s", txid)
+	}
+	if int(vout) >= len(info.Tx.Outputs) {
+		return nil, fmt.Errorf("BTC交易 %s 没有第 %d 个输出", txid, vout)
+	}
+	out := info.Tx.Outputs[vout]
+	return &BtcPrevOut{Value: out.Value, PkScript: out.PkScript}, nil
+}
+
+// newBtcFeeRateSource 设置了 BTC_FEE_RATE 时使用固定费率，否则按 BTC_FEE_TARGET_BLOCKS 目标区块数读取后端的费率估算
+func newBtcFeeRateSource(backend BitcoinBackend) (BtcFeeRateSource, error) {
+	if os.Getenv("BTC_FEE_RATE") != "" {
+		feeRate, err := getBtcFeeRate()
+		if err != nil {
+			return nil, err
+		}
+		return fixedBtcFeeRate(feeRate), nil
+	}
+	target := int64(defaultBtcFeeTargetBlocks)
+	if targetStr := os.Getenv("BTC_FEE_TARGET_BLOCKS"); targetStr != "" {
+		parsed, err := strconv.ParseInt(targetStr, 10, 64)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("无效的BTC_FEE_TARGET_BLOCKS: %s", targetStr)
+		}
+		target = parsed
+	}
+	return &backendFeeRate{backend: backend, targetBlocks: target}, nil
+}
+
+// newBitcoinBackend 按 BTC_BACKEND 创建比特币后端:
+// esplora (默认, BTC_ESPLORA_URL)、bitcoind (BTC_RPC_URL/BTC_RPC_USER/BTC_RPC_PASSWORD) 或 fake (BTC_FAKE_STATE_FILE)
+func newBitcoinBackend() (BitcoinBackend, error) {
+	network, err := loadBtcNetwork()
+	if err != nil {
+		return nil, err
+	}
+	kind := os.Getenv("BTC_BACKEND")
+	if kind == "" {
+		kind = BtcBackendEsplora
+	}
+	switch kind {
+	case BtcBackendEsplora:
+		return newEsploraChain(network), nil
+	case BtcBackendBitcoind:
+		return newBitcoindRPC(network), nil
+	case BtcBackendFake:
+		path := os.Getenv("BTC_FAKE_STATE_FILE")
+		if path == "" {
+			path = defaultBtcFakeStateFile
+		}
+		chain, err := LoadFakeBtcChain(path)
+		if err != nil {
+			return nil, err
+		}
+		chain.network = network
+		return chain, nil
+	}
+	return nil, fmt.Errorf("无效的BTC_BACKEND: %s (应为 %s、%s 或 %s)", kind, BtcBackendEsplora, BtcBackendBitcoind, BtcBackendFake)
+}
+
+// saveBitcoinBackend 模拟链有状态文件时写回，其他后端无需保存
+func saveBitcoinBackend(backend BitcoinBackend) error {
+	if chain, ok := backend.(*FakeBtcChain); ok && chain.path != "" {
+		return chain.Save()
+	}
+	return nil
+}
+
+// confirmationsAt 根据链顶高度计算区块的确认数(含所在区块)
+func confirmationsAt(tip int64, height int64) int64 {
+	if height < 0 || height > tip {
+		return 0
+	}
+	return tip - height + 1
+}
+
+// newEsploraChain 创建 Esplora 客户端，BTC_ESPLORA_URL 可指定接口地址
+func newEsploraChain(network BtcNetwork) *esploraChain {
+	url := os.Getenv("BTC_ESPLORA_URL")
+	if url == "" {
+		url = defaultEsploraURL
+	}
+	return &esploraChain{baseURL: strings.TrimSuffix(url, "/"), httpClient: &http.Client{Timeout: 15 * time.Second}, network: network}
+}
+
+// esploraChain 通过 Esplora REST 接口读取比特币链
+type esploraChain struct {
+	baseURL    string
+	httpClient *http.Client
+	network    BtcNetwork
+}
+
+// get 请求接口并返回响应体，404 时返回 nil
+func (c *esploraChain) get(path string) ([]byte, error) {
+	resp, err := c.httpClient.Get(c.baseURL + path)
+	if err != nil {
+		return nil, fmt.Errorf("请求 %s 失败: %v", path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 响应失败: %v", path, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("请求 %s 失败: 状态码 %d, 响应体: %s", path, resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+func (c *esploraChain) TipHeight() (int64, error) {
+	body, err := c.get("/blocks/tip/height")
+	if err != nil {
+		return 0, err
+	}
+	height, err := strconv.ParseInt(strings.TrimSpace(string(body)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("解析区块高度失败: %v", err)
+	}
+	return height, nil
+}
+
+func (c *esploraChain) BlockHashAtHeight(height int64) (string, error) {
+	body, err := c.get(fmt.Sprintf("/block-height/%d", height))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+func (c *esploraChain) TxStatus(txid string) (*BtcTxStatus, error) {
+	body, err := c.get(fmt.Sprintf("/tx/%s/status", txid))
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		return &BtcTxStatus{}, nil
+	}
+	var status struct {
+		Confirmed   bool   `json:"confirmed"`
+		BlockHeight int64  `json:"block_height"`
+		BlockHash   string `json:"block_hash"`
+	}
+	err = json.Unmarshal(body, &status)
+	if err != nil {
+		return nil, fmt.Errorf("解析交易状态失败: %v", err)
+	}
+	return &BtcTxStatus{
+		Found:       true,
+		Confirmed:   status.Confirmed,
+		BlockHash:   status.BlockHash,
+		BlockHeight: status.BlockHeight,
+	}, nil
+}
+
+func (c *esploraChain) BlockHeader(height int64) (*BtcBlockHeader, error) {
+	hash, err := c.BlockHashAtHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	if hash == "" {
+		return nil, fmt.Errorf("高度 %d 没有区块", height)
+	}
+	body, err := c.get("/block/" + hash)
+	if err != nil {
+		return nil, err
+	}
+	var block struct {
+		Id                string `json:"id"`
+		Height            int64  `json:"height"`
+		PreviousBlockHash string `json:"previousblockhash"`
+		Timestamp         int64  `json:"timestamp"`
+	}
+	err = json.Unmarshal(body, &block)
+	if err != nil {
+		return nil, fmt.Errorf("解析区块头失败: %v", err)
+	}
+	return &BtcBlockHeader{Hash: block.Id, PrevHash: block.PreviousBlockHash, Height: block.Height, Time: block.Timestamp}, nil
+}
+
+func (c *esploraChain) Transaction(txid string) (*BtcTxInfo, error) {
+	status, err := c.TxStatus(txid)
+	if err != nil {
+		return nil, err
+	}
+	info := &BtcTxInfo{TxId: txid, Found: status.Found, BlockHash: status.BlockHash, BlockHeight: status.BlockHeight}
+	if !status.Found {
+		return info, nil
+	}
+	body, err := c.get(fmt.Sprintf("/tx/%s/hex", txid))
+	if err != nil {
+		return nil, err
+	}
+	raw, err := hex.DecodeString(strings.TrimSpace(string(body)))
+	if err != nil {
+		return nil, fmt.Errorf("解析原始交易失败: %v", err)
+	}
+	info.Tx, err = DeserializeBtcTx(raw)
+	if err != nil {
+		return nil, err
+	}
+	if status.Confirmed {
+		tip, err := c.TipHeight()
+		if err != nil {
+			return nil, err
+		}
+		info.Confirmations = confirmationsAt(tip, status.BlockHeight)
+	}
+	return info, nil
+}
+
+func (c *esploraChain) AddressUtxos(address string) ([]BtcUtxo, error) {
+	script, err := btcAddressScript(address, c.network)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.get(fmt.Sprintf("/address/%s/utxo", address))
+	if err != nil {
+		return nil, err
+	}
+	var entries []struct {
+		TxId   string `json:"txid"`
+		Vout   uint32 `json:"vout"`
+		Value  uint64 `json:"value"`
+		Status struct {
+			Confirmed   bool  `json:"confirmed"`
+			BlockHeight int64 `json:"block_height"`
+		} `json:"status"`
+	}
+	err = json.Unmarshal(body, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("解析地址UTXO失败: %v", err)
+	}
+	tip, err := c.TipHeight()
+	if err != nil {
+		return nil, err
+	}
+	utxos := make([]BtcUtxo, 0, len(entries))
+	for _, entry := range entries {
+		utxo := BtcUtxo{TxId: entry.TxId, Vout: entry.Vout, Value: entry.Value, PkScript: script}
+		if entry.Status.Confirmed {
+			utxo.Confirmations = confirmationsAt(tip, entry.Status.BlockHeight)
+		}
+		utxos = append(utxos, utxo)
+	}
+	return utxos, nil
+}
+
+func (c *esploraChain) Broadcast(rawTx []byte) (string, error) {
+	resp, err := c.httpClient.Post(c.baseURL+"/tx", "text/plain", strings.NewReader(hex.EncodeToString(rawTx)))
+	if err != nil {
+		return "", fmt.Errorf("广播交易失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("读取广播响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("广播交易失败: 状态码 %d, 响应体: %s", resp.StatusCode, string(body))
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+func (c *esploraChain) EstimateFeeRate(targetBlocks int64) (uint64, error) {
+	body, err := c.get("/fee-estimates")
+	if err != nil {
+		return 0, err
+	}
+	var estimates map[string]float64
+	err = json.Unmarshal(body, &estimates)
+	if err != nil {
+		return 0, fmt.Errorf("解析费率估算失败: %v", err)
+	}
+	// 选择不超过目标区块数的最大档位，没有时退回最快的档位
+	bestTarget, bestRate := int64(-1), 0.0
+	fastestTarget, fastestRate := int64(-1), 0.0
+	for key, rate := range estimates {
+		target, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			continue
+		}
+		if target <= targetBlocks && target > bestTarget {
+			bestTarget, bestRate = target, rate
+		}
+		if fastestTarget < 0 || target < fastestTarget {
+			fastestTarget, fastestRate = target, rate
+		}
+	}
+	if bestTarget < 0 {
+		if fastestTarget < 0 {
+			return 0, fmt.Errorf("Esplora没有返回费率估算")
+		}
+		bestRate = fastestRate
+	}
+	feeRate := uint64(math.Ceil(bestRate))
+	if feeRate == 0 {
+		feeRate = 1
+	}
+	return feeRate, nil
+}
+
+// printBtcUtxos 打印地址上的UTXO
+func printBtcUtxos(address string, utxos []BtcUtxo) {
+	var total uint64
+	fmt.Printf("地址 %s 的UTXO:\n", address)
+	for _, utxo := range utxos {
+		fmt.Printf("  %s:%d %d (确认数 %d)\n", utxo.TxId, utxo.Vout, utxo.Value, utxo.Confirmations)
+		total += utxo.Value
+	}
+	fmt.Printf("  合计: %d (satoshi)\n", total)
+}
+
+// printBtcChainUsage 打印比特币后端命令的帮助
+func printBtcChainUsage() {
+	fmt.Println("比特币后端用法(BTC_BACKEND=esplora|bitcoind|fake, BTC_NETWORK 比特币网络):")
+	fmt.Println("  链顶高度: ./main btc-chain tip")
+	fmt.Println("  区块头: ./main btc-chain header <高度>")
+	fmt.Println("  交易及确认数: ./main btc-chain tx <btc_tx_id>")
+	fmt.Println("  地址UTXO: ./main btc-chain utxos <地址>")
+	fmt.Println("  费率估算: ./main btc-chain fee [目标区块数]")
+	fmt.Println("  广播交易: ./main btc-chain broadcast <原始交易十六进制>")
+	fmt.Println("  模拟链转账(仅fake): ./main btc-chain fund <地址> <数量>")
+	fmt.Println("  模拟链出块(仅fake, 第一个区块打包内存池): ./main btc-chain mine [区块数]")
+}
+
+// runBtcChainCommand 处理 btc-chain 子命令，不需要 Aptos 私钥
+func runBtcChainCommand(args []string) {
+	if len(args) < 1 {
+		printBtcChainUsage()
+		os.Exit(1)
+	}
+
+	backend, err := newBitcoinBackend()
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+	fake, isFake := backend.(*FakeBtcChain)
+	requireArgs := func(n int) {
+		if len(args) < n {
+			printBtcChainUsage()
+			os.Exit(1)
+		}
+	}
+	requireFake := func() {
+		if !isFake {
+			logError(fmt.Sprintf("%s 只能在 BTC_BACKEND=%s 时使用", args[0], BtcBackendFake))
+			os.Exit(1)
+		}
+	}
+
+	switch args[0] {
+	case "tip":
+		height, err := backend.TipHeight()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		header, err := backend.BlockHeader(height)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("链顶高度: %d\n区块哈希: %s\n区块时间: %s\n", height, header.Hash, time.Unix(header.Time, 0).UTC().Format(time.RFC3339))
+
+	case "header":
+		requireArgs(2)
+		height, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			logError(fmt.Sprintf("错误: 无效的高度 %s", args[1]))
+			os.Exit(1)
+		}
+		header, err := backend.BlockHeader(height)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("高度: %d\n区块哈希: %s\n上一区块: %s\n区块时间: %s\n", header.Height, header.Hash, header.PrevHash, time.Unix(header.Time, 0).UTC().Format(time.RFC3339))
+
+	case "tx":
+		requireArgs(2)
+		info, err := backend.Transaction(args[1])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		if !info.Found {
+			logWarning(fmt.Sprintf("未找到交易 %s", args[1]))
+			return
+		}
+		fmt.Printf("交易ID: %s\n确认数: %d\n", info.TxId, info.Confirmations)
+		if info.Confirmations > 0 {
+			fmt.Printf("所在区块: %d %s\n", info.BlockHeight, info.BlockHash)
+		}
+		if info.Tx != nil {
+			for i, out := range info.Tx.Outputs {
+				fmt.Printf("输出 #%d: %d (%s) %s\n", i, out.Value, btcScriptType(out.PkScript), hex.EncodeToString(out.PkScript))
+			}
+		}
+
+	case "utxos":
+		requireArgs(2)
+		utxos, err := backend.AddressUtxos(args[1])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		printBtcUtxos(args[1], utxos)
+
+	case "fee":
+		target := int64(defaultBtcFeeTargetBlocks)
+		if len(args) > 1 {
+			target, err = strconv.ParseInt(args[1], 10, 64)
+			if err != nil || target <= 0 {
+				logError(fmt.Sprintf("错误: 无效的目标区块数 %s", args[1]))
+				os.Exit(1)
+			}
+		}
+		feeRate, err := backend.EstimateFeeRate(target)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("%d 个区块内确认的费率: %d sat/vB\n", target, feeRate)
+
+	case "broadcast":
+		requireArgs(2)
+		raw, err := hex.DecodeString(args[1])
+		if err != nil {
+			logError(fmt.Sprintf("解析原始交易失败: %v", err))
+			os.Exit(1)
+		}
+		txid, err := backend.Broadcast(raw)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("交易已广播: %s", txid))
+
+	case "fund":
+		requireArgs(3)
+		requireFake()
+		amount, err := strconv.ParseUint(args[2], 10, 64)
+		if err != nil {
+			logError(fmt.Sprintf("错误: 无效的金额 %s", args[2]))
+			os.Exit(1)
+		}
+		txid, err := fake.FundAddress(args[1], amount)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("已向 %s 转账 %d，交易ID: %s (在内存池中)", args[1], amount, txid))
+
+	case "mine":
+		requireFake()
+		count := 1
+		if len(args) > 1 {
+			count, err = strconv.Atoi(args[1])
+			if err != nil || count <= 0 {
+				logError(fmt.Sprintf("错误: 无效的区块数 %s", args[1]))
+				os.Exit(1)
+			}
+		}
+		fake.MineMempool()
+		fake.MineEmpty(count - 1)
+		height, _ := fake.TipHeight()
+		logSuccess(fmt.Sprintf("已出 %d 个区块，当前高度 %d", count, height))
+
+	default:
+		logError(fmt.Sprintf("未知的btc-chain子命令: %s", args[0]))
+		printBtcChainUsage()
+		os.Exit(1)
+	}
+
+	err = saveBitcoinBackend(backend)
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+}
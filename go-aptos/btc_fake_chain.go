@@ -0,0 +1,403 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+const (
+	// 模拟链的默认状态文件
+	defaultBtcFakeStateFile = "btc_fake_chain.json"
+
+	// 模拟链创世区块时间和出块间隔，保证区块头可复现
+	fakeBtcGenesisTime   int64 = 1700000000
+	fakeBtcBlockInterval int64 = 600
+)
+
+// fakeBtcBlock 模拟链上的区块
+type fakeBtcBlock struct {
+	Hash     string   `json:"hash"`
+	PrevHash string   `json:"prev_hash"`
+	Time     int64    `json:"time"`
+	TxIds    []string `json:"tx_ids"`
+}
+
+// FakeBtcChain 可编排的模拟比特币链，用于离线演练确认策略、重组处理和出账广播。
+// 交易ID可以是任意字符串(只跟踪确认)，也可以是通过 Fund/Broadcast 加入的真实交易
+type FakeBtcChain struct {
+	blocks  []fakeBtcBlock
+	mempool map[string]bool
+	txs     map[string]*BtcTx
+	nonce   int
+	feeRate uint64
+	network BtcNetwork
+	path    string // 为空时不保存
+}
+
+// fakeBtcChainState 模拟链状态文件的格式
+type fakeBtcChainState struct {
+	Blocks  []fakeBtcBlock    `json:"blocks"`
+	Mempool []string          `json:"mempool"`
+	Txs     map[string]string `json:"txs"` // 交易ID -> 原始交易十六进制
+	Nonce   int               `json:"nonce"`
+	FeeRate uint64            `json:"fee_rate"`
+}
+
+// NewFakeBtcChain 创建只有创世区块的模拟链
+func NewFakeBtcChain() *FakeBtcChain {
+	c := &FakeBtcChain{
+		mempool: make(map[string]bool),
+		txs:     make(map[string]*BtcTx),
+		feeRate: defaultBtcFeeRate,
+		network: btcNetworks[defaultBtcNetwork],
+	}
+	c.Mine()
+	return c
+}
+
+// LoadFakeBtcChain 读取模拟链状态文件，文件不存在时创建新链
+func LoadFakeBtcChain(path string) (*FakeBtcChain, error) {
+	c := NewFakeBtcChain()
+	c.path = path
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取模拟链状态文件失败: %v", err)
+	}
+	var state fakeBtcChainState
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return nil, fmt.Errorf("解析模拟链状态文件失败: %v", err)
+	}
+	if len(state.Blocks) == 0 {
+		return nil, fmt.Errorf("模拟链状态文件没有区块")
+	}
+	c.blocks = state.Blocks
+	c.nonce = state.Nonce
+	if state.FeeRate > 0 {
+		c.feeRate = state.FeeRate
+	}
+	for _, txid := range state.Mempool {
+		c.mempool[txid] = true
+	}
+	for txid, rawHex := range state.Txs {
+		raw, err := hex.DecodeString(rawHex)
+		if err != nil {
+			return nil, fmt.Errorf("解析模拟链交易 %s 失败: %v", txid, err)
+		}
+		tx, err := DeserializeBtcTx(raw)
+		if err != nil {
+			return nil, fmt.Errorf("解析模拟链交易 %s 失败: %v", txid, err)
+		}
+		c.txs[txid] = tx
+	}
+	return c, nil
+}
+
+// Save 写入模拟链状态文件
+func (c *FakeBtcChain) Save() error {
+	state := fakeBtcChainState{Blocks: c.blocks, Txs: make(map[string]string), Nonce: c.nonce, FeeRate: c.feeRate}
+	for txid := range c.mempool {
+		state.Mempool = append(state.Mempool, txid)
+	}
+	sort.Strings(state.Mempool)
+	for txid, tx := range c.txs {
+		state.Txs[txid] = hex.EncodeToString(tx.Serialize(true))
+	}
+	data, err := json.MarshalIndent(&state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化模拟链状态失败: %v", err)
+	}
+	err = os.WriteFile(c.path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("写入模拟链状态文件失败: %v", err)
+	}
+	return nil
+}
+
+// SetFeeRate 设置模拟链返回的费率估算 (sat/vB)
+func (c *FakeBtcChain) SetFeeRate(feeRate uint64) {
+	c.feeRate = feeRate
+}
+
+// AddToMempool 把只跟踪确认的交易ID放入内存池
+func (c *FakeBtcChain) AddToMempool(txids ...string) {
+	for _, txid := range txids {
+		c.mempool[txid] = true
+	}
+}
+
+// Mine 在链顶出一个包含指定交易的区块，返回区块哈希
+func (c *FakeBtcChain) Mine(txids ...string) string {
+	prev := ""
+	if len(c.blocks) > 0 {
+		prev = c.blocks[len(c.blocks)-1].Hash
+	}
+	c.nonce++
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%v|%d", prev, len(c.blocks), txids, c.nonce)))
+	block := fakeBtcBlock{
+		Hash:     hex.EncodeToString(sum[:]),
+		PrevHash: prev,
+		Time:     fakeBtcGenesisTime + int64(len(c.blocks))*fakeBtcBlockInterval,
+		TxIds:    txids,
+	}
+	c.blocks = append(c.blocks, block)
+	for _, txid := range txids {
+		delete(c.mempool, txid)
+	}
+	return block.Hash
+}
+
+// MineMempool 把内存池中的全部交易按交易ID排序打包进一个新区块
+func (c *FakeBtcChain) MineMempool() string {
+	txids := make([]string, 0, len(c.mempool))
+	for txid := range c.mempool {
+		txids = append(txids, txid)
+	}
+	sort.Strings(txids)
+	return c.Mine(txids...)
+}
+
+// MineEmpty 连续出 n 个空块
+func (c *FakeBtcChain) MineEmpty(n int) {
+	for i := 0; i < n; i++ {
+		c.Mine()
+	}
+}
+
+// Reorg 回滚最近 depth 个区块，被回滚区块中的交易回到内存池，再依次出 replacement 中的区块
+func (c *FakeBtcChain) Reorg(depth int, replacement ...[]string) {
+	if depth >= len(c.blocks) {
+		depth = len(c.blocks) - 1
+	}
+	for _, block := range c.blocks[len(c.blocks)-depth:] {
+		for _, txid := range block.TxIds {
+			c.mempool[txid] = true
+		}
+	}
+	c.blocks = c.blocks[:len(c.blocks)-depth]
+	for _, txids := range replacement {
+		c.Mine(txids...)
+	}
+}
+
+// Drop 从内存池中删除交易，模拟交易被双花替换
+func (c *FakeBtcChain) Drop(txid string) {
+	delete(c.mempool, txid)
+}
+
+// txHeights 返回主链和内存池中全部交易的所在高度，内存池中的为 -1
+func (c *FakeBtcChain) txHeights() map[string]int64 {
+	heights := make(map[string]int64)
+	for txid := range c.mempool {
+		heights[txid] = -1
+	}
+	for height, block := range c.blocks {
+		for _, txid := range block.TxIds {
+			heights[txid] = int64(height)
+		}
+	}
+	return heights
+}
+
+// spentOutPoints 主链和内存池中已被花费的输出及花费它的交易
+func (c *FakeBtcChain) spentOutPoints(heights map[string]int64) map[BtcOutPoint]string {
+	spent := make(map[BtcOutPoint]string)
+	for txid := range heights {
+		tx, ok := c.txs[txid]
+		if !ok {
+			continue
+		}
+		for _, in := range tx.Inputs {
+			spent[in.PrevOut] = txid
+		}
+	}
+	return spent
+}
+
+// Fund 凭空创建一笔向 pkScript 支付 value 的交易并放入内存池，模拟外部转账，返回交易ID
+func (c *FakeBtcChain) Fund(pkScript []byte, value uint64) string {
+	return c.FundOutputs(BtcTxOut{Value: value, PkScript: pkScript})
+}
+
+// FundOutputs 凭空创建一笔包含给定输出的交易并放入内存池，用于模拟带 OP_RETURN 或多个收款方的转账
+func (c *FakeBtcChain) FundOutputs(outputs ...BtcTxOut) string {
+	c.nonce++
+	seed := make([]byte, 8)
+	binary.LittleEndian.PutUint64(seed, uint64(c.nonce))
+	tx := &BtcTx{
+		Version: 2,
+		Inputs: []BtcTxIn{{
+			PrevOut:   BtcOutPoint{Hash: sha256.Sum256(append([]byte("fund|"), seed...)), Index: 0xffffffff},
+			ScriptSig: seed,
+			Sequence:  0xffffffff,
+		}},
+		Outputs: outputs,
+	}
+	txid := tx.TxId()
+	c.txs[txid] = tx
+	c.mempool[txid] = true
+	return txid
+}
+
+// FundAddress 向地址转账，地址按模拟链的网络校验
+func (c *FakeBtcChain) FundAddress(address string, value uint64) (string, error) {
+	script, err := btcAddressScript(address, c.network)
+	if err != nil {
+		return "", err
+	}
+	return c.Fund(script, value), nil
+}
+
+func (c *FakeBtcChain) TipHeight() (int64, error) {
+	return int64(len(c.blocks) - 1), nil
+}
+
+func (c *FakeBtcChain) BlockHashAtHeight(height int64) (string, error) {
+	if height < 0 || height >= int64(len(c.blocks)) {
+		return "", nil
+	}
+	return c.blocks[height].Hash, nil
+}
+
+func (c *FakeBtcChain) TxStatus(txid string) (*BtcTxStatus, error) {
+	for height, block := range c.blocks {
+		for _, id := range block.TxIds {
+			if id == txid {
+				return &BtcTxStatus{Found: true, Confirmed: true, BlockHash: block.Hash, BlockHeight: int64(height)}, nil
+			}
+		}
+	}
+	return &BtcTxStatus{Found: c.mempool[txid]}, nil
+}
+
+func (c *FakeBtcChain) BlockHeader(height int64) (*BtcBlockHeader, error) {
+	if height < 0 || height >= int64(len(c.blocks)) {
+		return nil, fmt.Errorf("高度 %d 没有区块", height)
+	}
+	block := c.blocks[height]
+	return &BtcBlockHeader{Hash: block.Hash, PrevHash: block.PrevHash, Height: height, Time: block.Time}, nil
+}
+
+func (c *FakeBtcChain) Transaction(txid string) (*BtcTxInfo, error) {
+	status, err := c.TxStatus(txid)
+	if err != nil {
+		return nil, err
+	}
+	info := &BtcTxInfo{TxId: txid, Found: status.Found, BlockHash: status.BlockHash, BlockHeight: status.BlockHeight}
+	if !status.Found {
+		return info, nil
+	}
+	info.Tx = c.txs[txid]
+	if status.Confirmed {
+		info.Confirmations = confirmationsAt(int64(len(c.blocks)-1), status.BlockHeight)
+	}
+	return info, nil
+}
+
+func (c *FakeBtcChain) AddressUtxos(address string) ([]BtcUtxo, error) {
+	script, err := btcAddressScript(address, c.network)
+	if err != nil {
+		return nil, err
+	}
+	heights := c.txHeights()
+	spent := c.spentOutPoints(heights)
+	txids := make([]string, 0, len(heights))
+	for txid := range heights {
+		if _, ok := c.txs[txid]; ok {
+			txids = append(txids, txid)
+		}
+	}
+	sort.Strings(txids)
+
+	tip := int64(len(c.blocks) - 1)
+	var utxos []BtcUtxo
+	for _, txid := range txids {
+		tx := c.txs[txid]
+		outPoint, _ := parseBtcOutPoint(txid, 0)
+		for vout, out := range tx.Outputs {
+			outPoint.Index = uint32(vout)
+			if string(out.PkScript) != string(script) || spent[outPoint] != "" {
+				continue
+			}
+			utxos = append(utxos, BtcUtxo{
+				TxId:          txid,
+				Vout:          uint32(vout),
+				Value:         out.Value,
+				PkScript:      out.PkScript,
+				Confirmations: confirmationsAt(tip, heights[txid]),
+			})
+		}
+	}
+	return utxos, nil
+}
+
+// Broadcast 校验输入存在、未被花费且见证签名有效后放入内存池，重复广播同一交易时直接返回交易ID
+func (c *FakeBtcChain) Broadcast(rawTx []byte) (string, error) {
+	tx, err := DeserializeBtcTx(rawTx)
+	if err != nil {
+		return "", err
+	}
+	txid := tx.TxId()
+	heights := c.txHeights()
+	if _, ok := heights[txid]; ok && c.txs[txid] != nil {
+		return txid, nil
+	}
+	if len(tx.Inputs) == 0 || len(tx.Outputs) == 0 {
+		return "", fmt.Errorf("交易没有输入或输出")
+	}
+
+	spent := c.spentOutPoints(heights)
+	prevOuts := make([]BtcPrevOut, len(tx.Inputs))
+	var inputTotal, outputTotal uint64
+	for i, in := range tx.Inputs {
+		prevTxId := in.PrevOut.TxId()
+		prevTx, ok := c.txs[prevTxId]
+		if _, active := heights[prevTxId]; !ok || !active {
+			return "", fmt.Errorf("第 %d 个输入引用的交易 %s 不存在", i, prevTxId)
+		}
+		if int(in.PrevOut.Index) >= len(prevTx.Outputs) {
+			return "", fmt.Errorf("第 %d 个输入引用的输出 %s:%d 不存在", i, prevTxId, in.PrevOut.Index)
+		}
+		if spender := spent[in.PrevOut]; spender != "" {
+			return "", fmt.Errorf("第 %d 个输入 %s:%d 已被交易 %s 花费", i, prevTxId, in.PrevOut.Index, spender)
+		}
+		prevOut := prevTx.Outputs[in.PrevOut.Index]
+		prevOuts[i] = BtcPrevOut{Value: prevOut.Value, PkScript: prevOut.PkScript}
+		inputTotal += prevOut.Value
+	}
+	for i := range tx.Inputs {
+		err = verifyBtcInputWitness(tx, i, prevOuts)
+		if err != nil {
+			return "", fmt.Errorf("第 %d 个输入的见证无效: %v", i, err)
+		}
+	}
+	for _, out := range tx.Outputs {
+		outputTotal += out.Value
+	}
+	if outputTotal > inputTotal {
+		return "", fmt.Errorf("输出合计 %d 大于输入合计 %d", outputTotal, inputTotal)
+	}
+
+	c.txs[txid] = tx
+	c.mempool[txid] = true
+	return txid, nil
+}
+
+func (c *FakeBtcChain) EstimateFeeRate(targetBlocks int64) (uint64, error) {
+	return c.feeRate, nil
+}
+
+// sha256Bytes 计算字符串的 SHA256，用于生成固定的模拟账户和测试密钥
+func sha256Bytes(s string) []byte {
+	sum := sha256.Sum256([]byte(s))
+	return sum[:]
+}
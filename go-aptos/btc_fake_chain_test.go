@@ -0,0 +1,148 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// newTestPayoutChain 创建模拟链和固定的桥密钥，返回桥的 P2TR 地址和接收方的 P2WPKH 地址
+func newTestPayoutChain(t *testing.T) (*FakeBtcChain, *btcec.PrivateKey, string, string) {
+	t.Helper()
+	chain := NewFakeBtcChain()
+	chain.SetFeeRate(5)
+	bridgeKey, _ := btcec.PrivKeyFromBytes(sha256Bytes("bridge"))
+	receiverKey, _ := btcec.PrivKeyFromBytes(sha256Bytes("receiver"))
+	bridgeAddress, err := encodeSegwitAddress(chain.network.Bech32HRP, 1, schnorr.SerializePubKey(taprootTweakPrivKey(bridgeKey).PubKey()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiverAddress, err := encodeSegwitAddress(chain.network.Bech32HRP, 0, hash160(receiverKey.PubKey().SerializeCompressed()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chain, bridgeKey, bridgeAddress, receiverAddress
+}
+
+// signTestPayout 按请求构建出账交易并用桥私钥签名
+func signTestPayout(t *testing.T, chain *FakeBtcChain, bridgeKey *btcec.PrivateKey, req RedeemPayoutRequest) (*RedeemPayout, *BtcTx) {
+	t.Helper()
+	payout, err := BuildRedeemPayout(req, &backendPrevOuts{backend: chain}, &backendFeeRate{backend: chain, targetBlocks: defaultBtcFeeTargetBlocks})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signatures, err := SignRedeemPayout(payout, bridgeKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := FinalizeRedeemPayout(payout, signatures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payout, tx
+}
+
+func TestFakeBtcChainDepositConfirmations(t *testing.T) {
+	chain, _, bridgeAddress, _ := newTestPayoutChain(t)
+	depositTxId, err := chain.FundAddress(bridgeAddress, 150000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := NewFinalityEngine(chain, FinalityPolicy{RequiredConfirmations: 6})
+	_, err = engine.Observe(depositTxId, "0xa", 150000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utxos, err := chain.AddressUtxos(bridgeAddress)
+	if err != nil || len(utxos) != 1 || utxos[0].Confirmations != 0 {
+		t.Fatalf("内存池中的存款应为 0 确认的 UTXO，实际 %+v: %v", utxos, err)
+	}
+
+	chain.MineMempool()
+	chain.MineEmpty(4)
+	if err := engine.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if ready := engine.Ready(); len(ready) != 0 {
+		t.Fatalf("5 个确认时不应可铸币")
+	}
+	chain.MineEmpty(1)
+	if err := engine.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if ready := engine.Ready(); len(ready) != 1 || ready[0].BtcTxId != depositTxId {
+		t.Fatalf("存款 %s 应达到 6 个确认", depositTxId)
+	}
+	utxos, err = chain.AddressUtxos(bridgeAddress)
+	if err != nil || len(utxos) != 1 || utxos[0].Confirmations != 6 || utxos[0].Value != 150000 {
+		t.Fatalf("桥地址应有 1 个 6 确认的 UTXO，实际 %+v: %v", utxos, err)
+	}
+}
+
+func TestFakeBtcChainRedeemPayout(t *testing.T) {
+	chain, bridgeKey, bridgeAddress, receiverAddress := newTestPayoutChain(t)
+	_, err := chain.FundAddress(bridgeAddress, 150000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.MineMempool()
+	utxos, err := chain.AddressUtxos(bridgeAddress)
+	if err != nil || len(utxos) != 1 {
+		t.Fatalf("桥地址应有 1 个 UTXO: %v", err)
+	}
+
+	req := RedeemPayoutRequest{
+		RedeemRequestTxHash: "0xredeem",
+		Requester:           "0xa",
+		Receiver:            receiverAddress,
+		Amount:              100000,
+		BridgeFee:           1000,
+		OutpointTxIds:       []string{utxos[0].TxId},
+		OutpointIdxs:        []uint64{uint64(utxos[0].Vout)},
+		BridgeAddress:       bridgeAddress,
+		Network:             chain.network,
+	}
+	payout, tx := signTestPayout(t, chain, bridgeKey, req)
+	if payout.PayoutAmount+payout.MinerFee != payout.BurnAmount || payout.PayoutAmount+payout.MinerFee+payout.ChangeAmount != utxos[0].Value {
+		t.Fatalf("出账金额与燃烧数量不一致: %+v", payout)
+	}
+
+	tampered, err := DeserializeBtcTx(tx.Serialize(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered.Inputs[0].Witness[0][0] ^= 0x01
+	if _, err := chain.Broadcast(tampered.Serialize(true)); err == nil {
+		t.Fatalf("篡改见证的交易不应被接受")
+	}
+
+	txid, err := chain.Broadcast(tx.Serialize(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txid != tx.TxId() {
+		t.Fatalf("广播返回的交易ID %s 与 %s 不一致", txid, tx.TxId())
+	}
+
+	// 花费同一输出的另一笔出账
+	req.Amount = 120000
+	_, conflictTx := signTestPayout(t, chain, bridgeKey, req)
+	if _, err := chain.Broadcast(conflictTx.Serialize(true)); err == nil {
+		t.Fatalf("双花交易不应被接受")
+	}
+
+	chain.MineMempool()
+	info, err := chain.Transaction(txid)
+	if err != nil || info.Confirmations != 1 {
+		t.Fatalf("出账交易应有 1 个确认: %+v %v", info, err)
+	}
+	bridgeUtxos, err := chain.AddressUtxos(bridgeAddress)
+	if err != nil || len(bridgeUtxos) != 1 || bridgeUtxos[0].Value != payout.ChangeAmount {
+		t.Fatalf("桥地址应只剩找零 %d，实际 %+v: %v", payout.ChangeAmount, bridgeUtxos, err)
+	}
+	receiverUtxos, err := chain.AddressUtxos(receiverAddress)
+	if err != nil || len(receiverUtxos) != 1 || receiverUtxos[0].Value != payout.PayoutAmount {
+		t.Fatalf("接收地址应收到 %d，实际 %+v: %v", payout.PayoutAmount, receiverUtxos, err)
+	}
+}
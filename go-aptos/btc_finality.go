@@ -0,0 +1,509 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// 存款状态
+const (
+	DepositPending = "pending" // 等待确认数
+	DepositPaused  = "paused"  // 受重组影响，需要人工核对后恢复
+	DepositMinted  = "minted"
+)
+
+// 告警级别
+const (
+	AlertWarning  = "warning"
+	AlertCritical = "critical"
+)
+
+// FinalityPolicy 比特币存款的确认策略
+type FinalityPolicy struct {
+	RequiredConfirmations int64  // 铸币前存款所在区块需要的确认数(含所在区块)
+	StateFile             string // 存款跟踪状态文件
+}
+
+// 默认确认策略
+var defaultFinalityPolicy = FinalityPolicy{
+	RequiredConfirmations: 6,
+	StateFile:             "btc_deposits.json",
+}
+
+// loadFinalityPolicy 读取确认策略，可通过 BTC_REQUIRED_CONFIRMATIONS 和 BTC_DEPOSIT_STATE_FILE 覆盖默认值
+func loadFinalityPolicy() (FinalityPolicy, error) {
+	policy := defaultFinalityPolicy
+	if valueStr := os.Getenv("BTC_REQUIRED_CONFIRMATIONS"); valueStr != "" {
+		value, err := strconv.ParseInt(valueStr, 10, 64)
+		if err != nil || value <= 0 {
+			return policy, fmt.Errorf("无效的BTC_REQUIRED_CONFIRMATIONS: %s", valueStr)
+		}
+		policy.RequiredConfirmations = value
+	}
+	if path := os.Getenv("BTC_DEPOSIT_STATE_FILE"); path != "" {
+		policy.StateFile = path
+	}
+	return policy, nil
+}
+
+// TrackedDeposit 被跟踪的比特币存款
+type TrackedDeposit struct {
+	BtcTxId       string `json:"btc_tx_id"`
+	Receiver      string `json:"receiver"`
+	Amount        uint64 `json:"amount"`
+	BlockHash     string `json:"block_hash"` // 未确认时为空
+	BlockHeight   int64  `json:"block_height"`
+	Confirmations int64  `json:"confirmations"`
+	Status        string `json:"status"`
+	PauseReason   string `json:"pause_reason,omitempty"`
+	MintTxHash    string `json:"mint_tx_hash,omitempty"`
+	Reorgs        int    `json:"reorgs"` // 所在区块被重组的次数
+}
+
+// FinalityAlert 重组告警
+type FinalityAlert struct {
+	Time    int64  `json:"time"`
+	Level   string `json:"level"`
+	BtcTxId string `json:"btc_tx_id"`
+	Message string `json:"message"`
+}
+
+// finalityState 存款跟踪状态文件的内容
+type finalityState struct {
+	Paused      bool              `json:"paused"` // 已铸币的存款被重组时暂停所有铸币
+	PauseReason string            `json:"pause_reason,omitempty"`
+	Deposits    []*TrackedDeposit `json:"deposits"`
+	Alerts      []FinalityAlert   `json:"alerts"`
+}
+
+// FinalityEngine 跟踪存款所在区块，确认数达到策略要求才允许铸币，发现重组时告警并暂停
+type FinalityEngine struct {
+	chain   BtcChain
+	policy  FinalityPolicy
+	state   finalityState
+	OnAlert func(FinalityAlert) // 为空时只记录到状态文件并打印
+}
+
+// NewFinalityEngine 创建确认策略引擎，不读取状态文件
+func NewFinalityEngine(chain BtcChain, policy FinalityPolicy) *FinalityEngine {
+	return &FinalityEngine{chain: chain, policy: policy}
+}
+
+// LoadFinalityEngine 创建确认策略引擎并读取状态文件
+func LoadFinalityEngine(chain BtcChain, policy FinalityPolicy) (*FinalityEngine, error) {
+	engine := NewFinalityEngine(chain, policy)
+	data, err := os.ReadFile(policy.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return engine, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取存款状态文件失败: %v", err)
+	}
+	err = json.Unmarshal(data, &engine.state)
+	if err != nil {
+		return nil, fmt.Errorf("解析存款状态文件失败: %v", err)
+	}
+	return engine, nil
+}
+
+// Save 写入状态文件
+func (e *FinalityEngine) Save() error {
+	data, err := json.MarshalIndent(&e.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化存款状态失败: %v", err)
+	}
+	err = os.WriteFile(e.policy.StateFile, data, 0644)
+	if err != nil {
+		return fmt.Errorf("写入存款状态文件失败: %v", err)
+	}
+	return nil
+}
+
+// Deposits 返回所有跟踪中的存款
+func (e *FinalityEngine) Deposits() []*TrackedDeposit {
+	return e.state.Deposits
+}
+
+// Deposit 按BTC交易ID查找存款
+func (e *FinalityEngine) Deposit(btcTxId string) *TrackedDeposit {
+	for _, deposit := range e.state.Deposits {
+		if deposit.BtcTxId == btcTxId {
+			return deposit
+		}
+	}
+	return nil
+}
+
+// Paused 是否因已铸币的存款被重组而暂停了所有铸币
+func (e *FinalityEngine) Paused() (bool, string) {
+	return e.state.Paused, e.state.PauseReason
+}
+
+func (e *FinalityEngine) alert(level string, btcTxId string, message string) {
+	alert := FinalityAlert{Time: time.Now().Unix(), Level: level, BtcTxId: btcTxId, Message: message}
+	e.state.Alerts = append(e.state.Alerts, alert)
+	if e.OnAlert != nil {
+		e.OnAlert(alert)
+		return
+	}
+	if level == AlertCritical {
+		logError(fmt.Sprintf("[告警] %s: %s", btcTxId, message))
+	} else {
+		logWarning(fmt.Sprintf("[告警] %s: %s", btcTxId, message))
+	}
+}
+
+// Observe 开始跟踪一笔存款，交易必须已被比特币节点知道
+func (e *FinalityEngine) Observe(btcTxId string, receiver string, amount uint64) (*TrackedDeposit, error) {
+	if e.Deposit(btcTxId) != nil {
+		return nil, fmt.Errorf("存款 %s 已在跟踪中", btcTxId)
+	}
+	if amount == 0 {
+		return nil, fmt.Errorf("存款金额必须大于0")
+	}
+	status, err := e.chain.TxStatus(btcTxId)
+	if err != nil {
+		return nil, fmt.Errorf("查询BTC交易失败: %v", err)
+	}
+	if !status.Found {
+		return nil, fmt.Errorf("比特币节点中找不到交易 %s", btcTxId)
+	}
+	deposit := &TrackedDeposit{BtcTxId: btcTxId, Receiver: receiver, Amount: amount, Status: DepositPending}
+	if status.Confirmed {
+		deposit.BlockHash, deposit.BlockHeight = status.BlockHash, status.BlockHeight
+	}
+	e.state.Deposits = append(e.state.Deposits, deposit)
+	return deposit, e.refreshConfirmations()
+}
+
+// Refresh 重新检查每笔存款所在区块是否仍在主链上并更新确认数，发现重组时告警并暂停
+func (e *FinalityEngine) Refresh() error {
+	for _, deposit := range e.state.Deposits {
+		if deposit.BlockHash == "" {
+			status, err := e.chain.TxStatus(deposit.BtcTxId)
+			if err != nil {
+				return fmt.Errorf("查询BTC交易 %s 失败: %v", deposit.BtcTxId, err)
+			}
+			if status.Confirmed {
+				deposit.BlockHash, deposit.BlockHeight = status.BlockHash, status.BlockHeight
+			}
+			continue
+		}
+
+		hash, err := e.chain.BlockHashAtHeight(deposit.BlockHeight)
+		if err != nil {
+			return fmt.Errorf("查询高度 %d 的区块失败: %v", deposit.BlockHeight, err)
+		}
+		if hash == deposit.BlockHash {
+			continue
+		}
+		err = e.handleReorg(deposit)
+		if err != nil {
+			return err
+		}
+	}
+	return e.refreshConfirmations()
+}
+
+// handleReorg 存款所在区块已不在主链上
+func (e *FinalityEngine) handleReorg(deposit *TrackedDeposit) error {
+	oldHash, oldHeight := deposit.BlockHash, deposit.BlockHeight
+	status, err := e.chain.TxStatus(deposit.BtcTxId)
+	if err != nil {
+		return fmt.Errorf("查询BTC交易 %s 失败: %v", deposit.BtcTxId, err)
+	}
+	deposit.Reorgs++
+	deposit.BlockHash, deposit.BlockHeight = "", 0
+	location := "交易已不在主链上"
+	if status.Confirmed {
+		deposit.BlockHash, deposit.BlockHeight = status.BlockHash, status.BlockHeight
+		location = fmt.Sprintf("交易重新打包在高度 %d 的区块 %s", status.BlockHeight, status.BlockHash)
+	} else if status.Found {
+		location = "交易回到内存池"
+	}
+	message := fmt.Sprintf("高度 %d 的区块 %s 被重组，%s", oldHeight, oldHash, location)
+
+	if deposit.Status == DepositMinted {
+		// 已铸币的存款被重组，链上的TWBTC可能没有BTC支撑，停止所有铸币等待人工处理
+		e.state.Paused = true
+		e.state.PauseReason = fmt.Sprintf("已铸币的存款 %s 被重组", deposit.BtcTxId)
+		e.alert(AlertCritical, deposit.BtcTxId, message+"，该存款已铸币，所有铸币已暂停")
+		return nil
+	}
+	deposit.Status = DepositPaused
+	deposit.PauseReason = message
+	e.alert(AlertWarning, deposit.BtcTxId, message+"，该存款已暂停")
+	return nil
+}
+
+// refreshConfirmations 按当前链高更新确认数
+func (e *FinalityEngine) refreshConfirmations() error {
+	tip, err := e.chain.TipHeight()
+	if err != nil {
+		return fmt.Errorf("查询BTC链高失败: %v", err)
+	}
+	for _, deposit := range e.state.Deposits {
+		deposit.Confirmations = 0
+		if deposit.BlockHash != "" && tip >= deposit.BlockHeight {
+			deposit.Confirmations = tip - deposit.BlockHeight + 1
+		}
+	}
+	return nil
+}
+
+// Ready 返回可以铸币的存款: 未暂停、未铸币且确认数达到要求
+func (e *FinalityEngine) Ready() []*TrackedDeposit {
+	if e.state.Paused {
+		return nil
+	}
+	var ready []*TrackedDeposit
+	for _, deposit := range e.state.Deposits {
+		if deposit.Status == DepositPending && deposit.Confirmations >= e.policy.RequiredConfirmations {
+			ready = append(ready, deposit)
+		}
+	}
+	return ready
+}
+
+// MarkMinted 记录存款已铸币
+func (e *FinalityEngine) MarkMinted(btcTxId string, txHash string) error {
+	deposit := e.Deposit(btcTxId)
+	if deposit == nil {
+		return fmt.Errorf("未跟踪的存款 %s", btcTxId)
+	}
+	deposit.Status = DepositMinted
+	deposit.MintTxHash = txHash
+	return nil
+}
+
+// Resume 人工核对后恢复暂停的存款，btcTxId 为 all 时同时解除全局暂停
+// 恢复后确认数按存款当前所在区块重新计算
+func (e *FinalityEngine) Resume(btcTxId string) error {
+	if btcTxId == "all" {
+		e.state.Paused = false
+		e.state.PauseReason = ""
+		for _, deposit := range e.state.Deposits {
+			if deposit.Status == DepositPaused {
+				deposit.Status = DepositPending
+				deposit.PauseReason = ""
+			}
+		}
+		return nil
+	}
+	deposit := e.Deposit(btcTxId)
+	if deposit == nil {
+		return fmt.Errorf("未跟踪的存款 %s", btcTxId)
+	}
+	if deposit.Status != DepositPaused {
+		return fmt.Errorf("存款 %s 未暂停", btcTxId)
+	}
+	deposit.Status = DepositPending
+	deposit.PauseReason = ""
+	return nil
+}
+
+// ProcessDeposits 刷新存款状态并为确认数足够的存款铸币，每笔铸币后保存状态
+func (e *FinalityEngine) ProcessDeposits(client *aptos.Client, account aptos.TransactionSigner, moduleAddress string) ([]*TrackedDeposit, error) {
+	err := e.Refresh()
+	if err != nil {
+		return nil, err
+	}
+	err = e.Save()
+	if err != nil {
+		return nil, err
+	}
+
+	var minted []*TrackedDeposit
+	for _, deposit := range e.Ready() {
+		receiver := aptos.AccountAddress{}
+		err := receiver.ParseStringRelaxed(deposit.Receiver)
+		if err != nil {
+			return minted, fmt.Errorf("存款 %s 的接收地址无效: %v", deposit.BtcTxId, err)
+		}
+		// 已由其他途径铸币的存款直接标记，避免重复提交被合约拒绝后阻塞后续存款
+		alreadyMinted, err := isBtcTxMinted(client, moduleAddress, deposit.BtcTxId)
+		if err != nil {
+			return minted, fmt.Errorf("查询存款 %s 的铸币状态失败: %v", deposit.BtcTxId, err)
+		}
+		txHash := ""
+		if alreadyMinted {
+			logWarning(fmt.Sprintf("存款 %s 已在链上铸币，跳过", deposit.BtcTxId))
+		} else {
+			txHash, err = mintTWBTC(client, account, moduleAddress, receiver, deposit.Amount, deposit.BtcTxId)
+			if errors.Is(err, ErrTransactionAlreadyApplied) || isAlreadyMintedAbort(err) {
+				logWarning(fmt.Sprintf("存款 %s 已在链上铸币，跳过", deposit.BtcTxId))
+				txHash, err = "", nil
+			}
+			if err != nil {
+				return minted, fmt.Errorf("存款 %s 铸币失败: %v", deposit.BtcTxId, err)
+			}
+		}
+		err = e.MarkMinted(deposit.BtcTxId, txHash)
+		if err != nil {
+			return minted, err
+		}
+		minted = append(minted, deposit)
+		err = e.Save()
+		if err != nil {
+			return minted, err
+		}
+	}
+	return minted, nil
+}
+
+// printFinalityStatus 打印存款跟踪状态
+func printFinalityStatus(e *FinalityEngine) {
+	if paused, reason := e.Paused(); paused {
+		logError(fmt.Sprintf("所有铸币已暂停: %s", reason))
+	}
+	fmt.Printf("需要确认数: %d\n", e.policy.RequiredConfirmations)
+	if len(e.Deposits()) == 0 {
+		fmt.Println("没有跟踪中的存款")
+	}
+	for _, deposit := range e.Deposits() {
+		fmt.Printf("%s  %s  接收者: %s  金额: %d  确认数: %d/%d",
+			deposit.BtcTxId, deposit.Status, deposit.Receiver, deposit.Amount, deposit.Confirmations, e.policy.RequiredConfirmations)
+		if deposit.Reorgs > 0 {
+			fmt.Printf("  重组次数: %d", deposit.Reorgs)
+		}
+		fmt.Println()
+		if deposit.PauseReason != "" {
+			fmt.Printf("  暂停原因: %s\n", deposit.PauseReason)
+		}
+		if deposit.MintTxHash != "" {
+			fmt.Printf("  铸币交易: %s\n", deposit.MintTxHash)
+		}
+	}
+}
+
+// printBtcDepositUsage 打印存款跟踪命令的帮助
+func printBtcDepositUsage() {
+	fmt.Println("BTC存款确认跟踪用法(BTC_REQUIRED_CONFIRMATIONS 确认数, BTC_DEPOSIT_STATE_FILE 状态文件, BTC_BACKEND 比特币后端):")
+	fmt.Println("  跟踪存款: ./main btc-deposit observe <btc_tx_id> <接收地址> <数量>")
+	fmt.Println("  查看状态: ./main btc-deposit status")
+	fmt.Println("  检查重组并为确认数足够的存款铸币(需PRIVATE_KEY): ./main btc-deposit process")
+	fmt.Println("  人工核对后恢复: ./main btc-deposit resume <btc_tx_id|all>")
+}
+
+// runBtcDepositCommand 处理 btc-deposit 子命令，只有 process 需要私钥
+func runBtcDepositCommand(args []string) {
+	if len(args) < 1 {
+		printBtcDepositUsage()
+		os.Exit(1)
+	}
+	policy, err := loadFinalityPolicy()
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+
+	backend, err := newBitcoinBackend()
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+	engine, err := LoadFinalityEngine(backend, policy)
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "observe":
+		if len(args) < 4 {
+			printBtcDepositUsage()
+			os.Exit(1)
+		}
+		receiver := aptos.AccountAddress{}
+		err := receiver.ParseStringRelaxed(args[2])
+		if err != nil {
+			logError(fmt.Sprintf("解析接收地址失败: %v", err))
+			os.Exit(1)
+		}
+		amount, err := strconv.ParseUint(args[3], 10, 64)
+		if err != nil {
+			logError(fmt.Sprintf("错误: 无效的金额 %s", args[3]))
+			os.Exit(1)
+		}
+		deposit, err := engine.Observe(args[1], receiver.String(), amount)
+		if err != nil {
+			logError(fmt.Sprintf("跟踪存款失败: %v", err))
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("开始跟踪存款 %s，当前确认数 %d/%d", deposit.BtcTxId, deposit.Confirmations, policy.RequiredConfirmations))
+
+	case "status":
+		err := engine.Refresh()
+		if err != nil {
+			logError(fmt.Sprintf("刷新存款状态失败: %v", err))
+			os.Exit(1)
+		}
+		printFinalityStatus(engine)
+
+	case "process":
+		privateKey := os.Getenv("PRIVATE_KEY")
+		if privateKey == "" {
+			logError("错误: 缺少私钥。请设置PRIVATE_KEY环境变量")
+			os.Exit(1)
+		}
+		account, err := createSigningAccount(privateKey)
+		if err != nil {
+			logError(fmt.Sprintf("创建账户失败: %v", err))
+			os.Exit(1)
+		}
+		moduleAddress, err := getModuleAddress()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		client, err := createClient()
+		if err != nil {
+			logError(fmt.Sprintf("创建客户端失败: %v", err))
+			os.Exit(1)
+		}
+		minted, err := engine.ProcessDeposits(client, account, moduleAddress)
+		for _, deposit := range minted {
+			logSuccess(fmt.Sprintf("存款 %s 已铸币 %d 给 %s，交易哈希: %s", deposit.BtcTxId, deposit.Amount, deposit.Receiver, deposit.MintTxHash))
+		}
+		printFinalityStatus(engine)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		return
+
+	case "resume":
+		if len(args) < 2 {
+			printBtcDepositUsage()
+			os.Exit(1)
+		}
+		err := engine.Resume(args[1])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		err = engine.Refresh()
+		if err != nil {
+			logError(fmt.Sprintf("刷新存款状态失败: %v", err))
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("已恢复 %s", args[1]))
+
+	default:
+		logError(fmt.Sprintf("未知的btc-deposit子命令: %s", args[0]))
+		printBtcDepositUsage()
+		os.Exit(1)
+	}
+
+	err = engine.Save()
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const testRequiredConfirmations = 3
+
+func newTestFinalityEngine(t *testing.T) (*FakeBtcChain, *FinalityEngine, *[]FinalityAlert) {
+	t.Helper()
+	chain := NewFakeBtcChain()
+	policy := FinalityPolicy{RequiredConfirmations: testRequiredConfirmations, StateFile: filepath.Join(t.TempDir(), "btc_deposits.json")}
+	engine := NewFinalityEngine(chain, policy)
+	alerts := &[]FinalityAlert{}
+	engine.OnAlert = func(alert FinalityAlert) {
+		*alerts = append(*alerts, alert)
+	}
+	return chain, engine, alerts
+}
+
+func expectDeposit(t *testing.T, engine *FinalityEngine, btcTxId string, status string, confirmations int64) {
+	t.Helper()
+	deposit := engine.Deposit(btcTxId)
+	if deposit == nil {
+		t.Fatalf("未跟踪存款 %s", btcTxId)
+	}
+	if deposit.Status != status || deposit.Confirmations != confirmations {
+		t.Fatalf("存款 %s 期望 %s/%d 确认，实际 %s/%d", btcTxId, status, confirmations, deposit.Status, deposit.Confirmations)
+	}
+}
+
+func expectReady(t *testing.T, engine *FinalityEngine, btcTxIds ...string) {
+	t.Helper()
+	ready := engine.Ready()
+	if len(ready) != len(btcTxIds) {
+		t.Fatalf("期望 %d 笔可铸币存款，实际 %d 笔", len(btcTxIds), len(ready))
+	}
+	for i, deposit := range ready {
+		if deposit.BtcTxId != btcTxIds[i] {
+			t.Fatalf("期望可铸币存款 %s，实际 %s", btcTxIds[i], deposit.BtcTxId)
+		}
+	}
+}
+
+func TestFinalityEngineWaitsForConfirmations(t *testing.T) {
+	chain, engine, _ := newTestFinalityEngine(t)
+	chain.AddToMempool("deposit-a", "deposit-b")
+	for _, btcTxId := range []string{"deposit-a", "deposit-b"} {
+		_, err := engine.Observe(btcTxId, "0xa", 100000)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectDeposit(t, engine, "deposit-a", DepositPending, 0)
+	expectReady(t, engine)
+
+	chain.Mine("deposit-a")
+	chain.Mine("deposit-b")
+	if err := engine.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	expectDeposit(t, engine, "deposit-a", DepositPending, 2)
+	expectDeposit(t, engine, "deposit-b", DepositPending, 1)
+	expectReady(t, engine)
+
+	chain.MineEmpty(testRequiredConfirmations - 2)
+	if err := engine.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	expectDeposit(t, engine, "deposit-a", DepositPending, testRequiredConfirmations)
+	expectReady(t, engine, "deposit-a")
+}
+
+func TestFinalityEngineReorgPausesUntilResumed(t *testing.T) {
+	chain, engine, alerts := newTestFinalityEngine(t)
+	chain.AddToMempool("deposit-a", "deposit-b")
+	for _, btcTxId := range []string{"deposit-a", "deposit-b"} {
+		_, err := engine.Observe(btcTxId, "0xa", 100000)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	chain.Mine("deposit-a")
+	chain.Mine("deposit-b")
+	chain.MineEmpty(testRequiredConfirmations - 2)
+	if err := engine.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.MarkMinted("deposit-a", "0xminted"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重组回滚 A 和 B 所在的区块，只有 B 被重新打包
+	chain.Reorg(testRequiredConfirmations, []string{"deposit-b"})
+	chain.Drop("deposit-a")
+	if err := engine.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	expectDeposit(t, engine, "deposit-a", DepositMinted, 0)
+	expectDeposit(t, engine, "deposit-b", DepositPaused, 1)
+	expectReady(t, engine)
+	if paused, _ := engine.Paused(); !paused || len(*alerts) != 2 || (*alerts)[0].Level != AlertCritical {
+		t.Fatalf("已铸币的存款被回滚应全局暂停并产生 2 条告警(首条为 critical)，实际暂停=%v 告警 %+v", paused, *alerts)
+	}
+
+	// 恢复后 B 需要在新区块上重新达到确认数
+	if err := engine.Resume("all"); err != nil {
+		t.Fatal(err)
+	}
+	chain.MineEmpty(testRequiredConfirmations - 2)
+	if err := engine.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	expectDeposit(t, engine, "deposit-b", DepositPending, testRequiredConfirmations-1)
+	expectReady(t, engine)
+	chain.Mine()
+	if err := engine.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	expectReady(t, engine, "deposit-b")
+}
+
+func TestProcessDepositsSkipsAlreadyMinted(t *testing.T) {
+	alice := newTestAccount(t, "finality-alice")
+	node, client, admin := newTestBridgeNode(t, alice)
+	moduleAddress := admin.Address.String()
+
+	chain, engine, _ := newTestFinalityEngine(t)
+	chain.AddToMempool("deposit-a", "deposit-b", "deposit-c")
+	for _, btcTxId := range []string{"deposit-a", "deposit-b", "deposit-c"} {
+		_, err := engine.Observe(btcTxId, alice.Address.String(), 50000)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	chain.MineMempool()
+	chain.MineEmpty(testRequiredConfirmations - 1)
+
+	// B 已由其他途径铸币，排在它后面的 C 不应被阻塞
+	_, err := mintTWBTC(client, admin, moduleAddress, alice.Address, 50000, "deposit-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	minted, err := engine.ProcessDeposits(client, admin, moduleAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(minted) != 3 {
+		t.Fatalf("3 笔存款都应标记为已铸币，实际 %d 笔", len(minted))
+	}
+	for _, btcTxId := range []string{"deposit-a", "deposit-b", "deposit-c"} {
+		expectDeposit(t, engine, btcTxId, DepositMinted, testRequiredConfirmations)
+	}
+	if engine.Deposit("deposit-b").MintTxHash != "" || engine.Deposit("deposit-c").MintTxHash == "" {
+		t.Fatalf("跳过的存款不应记录铸币交易，新铸币的存款应记录")
+	}
+	balance, _ := node.CoinBalance(alice.Address, twbtcCoinType(admin.Address))
+	if balance != 3*(50000-twbtcTokenMintFee) {
+		t.Fatalf("每笔存款只应铸币一次，余额应为 %d，实际 %d", 3*(50000-twbtcTokenMintFee), balance)
+	}
+}
+
+func TestIsAlreadyMintedAbort(t *testing.T) {
+	alice := newTestAccount(t, "finality-abort-alice")
+	_, client, admin := newTestBridgeNode(t, alice)
+	moduleAddress := admin.Address.String()
+
+	_, err := mintTWBTC(client, admin, moduleAddress, alice.Address, 50000, "deposit-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isAlreadyMintedAbort(err) {
+		t.Fatalf("成功的铸币不应判定为重复铸币")
+	}
+	_, err = mintTWBTC(client, admin, moduleAddress, alice.Address, 50000, "deposit-a")
+	if !isAlreadyMintedAbort(err) {
+		t.Fatalf("重复铸币应被合约以 E_ALREADY_MINTED 拒绝，实际: %v", err)
+	}
+}
@@ -0,0 +1,709 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+const (
+	// 出账交易版本和序列号，序列号允许 RBF 追加手续费
+	btcPayoutVersion  int32  = 2
+	btcPayoutSequence uint32 = 0xfffffffd
+
+	// Esplora 费率估算的默认目标确认区块数
+	defaultBtcFeeTargetBlocks = 6
+
+	// 查找赎回相关事件时读取的最大事件数
+	btcPayoutEventLimit uint64 = 100
+)
+
+// 门限签名器使用的签名算法
+const (
+	PayoutSigECDSA   = "ecdsa"   // P2WPKH 输入，BIP143 签名哈希
+	PayoutSigSchnorr = "schnorr" // P2TR 密钥路径输入，BIP341 签名哈希
+)
+
+// RedeemPayoutRequest 构建赎回出账交易需要的链上数据
+type RedeemPayoutRequest struct {
+	RedeemRequestTxHash string
+	Requester           string
+	Receiver            string   // BridgeRedeemRequestEvent.receiver
+	Amount              uint64   // BridgeRedeemRequestEvent.amount，含桥费用
+	BridgeFee           uint64   // BridgeConfig.fee
+	OutpointTxIds       []string // BridgeRedeemPrepareEvent 选定的输出
+	OutpointIdxs        []uint64
+	BridgeAddress       string // 桥的BTC地址，找零返回该地址
+	Network             BtcNetwork
+	DepositIntents      *DepositIntentStore // 非空时也可以花费已签发的专属存款地址上的输出
+}
+
+// PayoutInput 出账交易花费的桥输出
+type PayoutInput struct {
+	TxId     string `json:"txid"`
+	Vout     uint32 `json:"vout"`
+	Value    uint64 `json:"value"`
+	PkScript string `json:"pk_script"`
+	Type     string `json:"type"`
+	// 专属存款地址的输入: 所属接收者和签名前加到桥内部私钥上的调整值(十六进制)
+	DepositReceiver string `json:"deposit_receiver,omitempty"`
+	KeyTweak        string `json:"key_tweak,omitempty"`
+}
+
+// PayoutSighash 交给门限签名器的待签名哈希
+type PayoutSighash struct {
+	Index       int    `json:"index"`
+	Algorithm   string `json:"algorithm"`
+	SigHashType byte   `json:"sighash_type"`
+	Hash        string `json:"hash"`
+	KeyTweak    string `json:"key_tweak,omitempty"` // 非空时用桥内部私钥加上该调整值后的密钥签名
+}
+
+// PayoutSignature 门限签名器返回的签名，P2WPKH 输入需要同时给出压缩公钥
+type PayoutSignature struct {
+	Index     int    `json:"index"`
+	Signature string `json:"signature"`
+	PublicKey string `json:"public_key,omitempty"`
+}
+
+// RedeemPayout 待签名的赎回出账交易 (金额单位均为 satoshi)
+type RedeemPayout struct {
+	RedeemRequestTxHash string          `json:"redeem_request_tx_hash"`
+	Requester           string          `json:"requester"`
+	Receiver            string          `json:"receiver"`
+	RequestAmount       uint64          `json:"request_amount"`
+	BridgeFee           uint64          `json:"bridge_fee"`
+	BurnAmount          uint64          `json:"burn_amount"`
+	FeeRate             uint64          `json:"fee_rate"`
+	VSize               uint64          `json:"vsize"`
+	MinerFee            uint64          `json:"miner_fee"`
+	PayoutAmount        uint64          `json:"payout_amount"`
+	ChangeAddress       string          `json:"change_address"`
+	ChangeAmount        uint64          `json:"change_amount"`
+	Inputs              []PayoutInput   `json:"inputs"`
+	UnsignedTx          string          `json:"unsigned_tx"`
+	Sighashes           []PayoutSighash `json:"sighashes"`
+}
+
+// redeemBurnAmount 与 btc_bridgev3::redeem_request 相同的扣费逻辑，返回被燃烧的数量
+func redeemBurnAmount(amount uint64, bridgeFee uint64) (uint64, error) {
+	if amount <= bridgeFee {
+		return 0, fmt.Errorf("赎回金额 %d 必须大于桥费用 %d (E_INSUFFICIENT_AMOUNT)", amount, bridgeFee)
+	}
+	return amount - bridgeFee, nil
+}
+
+// dummyPayoutWitness 估算大小用的占位见证
+func dummyPayoutWitness(scriptType string) [][]byte {
+	if scriptType == BtcScriptP2TR {
+		return [][]byte{make([]byte, 64)}
+	}
+	return [][]byte{make([]byte, 72), make([]byte, 33)}
+}
+
+// BuildRedeemPayout 构建花费预备输出的出账交易。桥燃烧了 amount - fee，
+// 出账交易从桥地址恰好支出这个数量: 接收方得到燃烧数量减去矿工费，其余找零回桥地址。
+// 预备输出可以在桥地址上，也可以在 req.DepositIntents 中已签发的专属存款地址上，后者签名时按接收者调整桥密钥
+func BuildRedeemPayout(req RedeemPayoutRequest, prevOuts BtcPrevOutSource, feeRates BtcFeeRateSource) (*RedeemPayout, error) {
+	burnAmount, err := redeemBurnAmount(req.Amount, req.BridgeFee)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.OutpointTxIds) == 0 {
+		return nil, fmt.Errorf("赎回请求没有预备的输出")
+	}
+	if len(req.OutpointTxIds) != len(req.OutpointIdxs) {
+		return nil, fmt.Errorf("输出交易ID数量 %d 与输出序号数量 %d 不一致", len(req.OutpointTxIds), len(req.OutpointIdxs))
+	}
+	receiverScript, err := btcAddressScript(req.Receiver, req.Network)
+	if err != nil {
+		return nil, fmt.Errorf("接收地址无效: %v", err)
+	}
+	bridgeScript, err := btcAddressScript(req.BridgeAddress, req.Network)
+	if err != nil {
+		return nil, fmt.Errorf("桥地址无效: %v", err)
+	}
+	bridgeType := btcScriptType(bridgeScript)
+	if bridgeType != BtcScriptP2WPKH && bridgeType != BtcScriptP2TR {
+		return nil, fmt.Errorf("桥地址必须是P2WPKH或P2TR地址，实际为 %s", bridgeType)
+	}
+	if req.DepositIntents != nil && req.DepositIntents.BridgeAddress() != req.BridgeAddress {
+		return nil, fmt.Errorf("存款意图的桥地址 %s 与出账的桥地址 %s 不一致", req.DepositIntents.BridgeAddress(), req.BridgeAddress)
+	}
+
+	payout := &RedeemPayout{
+		RedeemRequestTxHash: req.RedeemRequestTxHash,
+		Requester:           req.Requester,
+		Receiver:            req.Receiver,
+		RequestAmount:       req.Amount,
+		BridgeFee:           req.BridgeFee,
+		BurnAmount:          burnAmount,
+		ChangeAddress:       req.BridgeAddress,
+	}
+	tx := &BtcTx{Version: btcPayoutVersion}
+	var total uint64
+	for i, txid := range req.OutpointTxIds {
+		if req.OutpointIdxs[i] > 0xffffffff {
+			return nil, fmt.Errorf("输出序号 %d 超出范围", req.OutpointIdxs[i])
+		}
+		vout := uint32(req.OutpointIdxs[i])
+		outPoint, err := parseBtcOutPoint(txid, vout)
+		if err != nil {
+			return nil, err
+		}
+		prevOut, err := prevOuts.PrevOut(txid, vout)
+		if err != nil {
+			return nil, fmt.Errorf("查询输出 %s:%d 失败: %v", txid, vout, err)
+		}
+		input := PayoutInput{
+			TxId:     txid,
+			Vout:     vout,
+			Value:    prevOut.Value,
+			PkScript: hex.EncodeToString(prevOut.PkScript),
+			Type:     bridgeType,
+		}
+		// 预备输出必须属于桥地址或专属存款地址，否则门限签名无法花费
+		if !bytes.Equal(prevOut.PkScript, bridgeScript) {
+			var intent *DepositIntent
+			var tweak [32]byte
+			if req.DepositIntents != nil {
+				intent, tweak = req.DepositIntents.spendTweak(prevOut.PkScript)
+			}
+			if intent == nil {
+				return nil, fmt.Errorf("输出 %s:%d 既不属于桥地址 %s，也不属于已签发的专属存款地址", txid, vout, req.BridgeAddress)
+			}
+			input.Type = BtcScriptP2TR
+			input.DepositReceiver = intent.Receiver
+			input.KeyTweak = hex.EncodeToString(tweak[:])
+		}
+		total += prevOut.Value
+		tx.Inputs = append(tx.Inputs, BtcTxIn{PrevOut: outPoint, Sequence: btcPayoutSequence})
+		payout.Inputs = append(payout.Inputs, input)
+	}
+	if total < burnAmount {
+		return nil, fmt.Errorf("预备输出合计 %d 小于燃烧数量 %d", total, burnAmount)
+	}
+
+	payout.FeeRate, err = feeRates.FeeRate()
+	if err != nil {
+		return nil, fmt.Errorf("获取BTC手续费率失败: %v", err)
+	}
+
+	// 先按带找零的两个输出估算，找零低于粉尘上限时去掉找零，零头归矿工
+	payout.ChangeAmount = total - burnAmount
+	tx.Outputs = []BtcTxOut{{PkScript: receiverScript}}
+	if payout.ChangeAmount >= btcDustLimit {
+		tx.Outputs = append(tx.Outputs, BtcTxOut{Value: payout.ChangeAmount, PkScript: bridgeScript})
+	} else {
+		payout.ChangeAmount = 0
+	}
+	for i := range tx.Inputs {
+		tx.Inputs[i].Witness = dummyPayoutWitness(payout.Inputs[i].Type)
+	}
+	payout.VSize = tx.VSize()
+	for i := range tx.Inputs {
+		tx.Inputs[i].Witness = nil
+	}
+	estimatedFee := payout.FeeRate * payout.VSize
+	if burnAmount < estimatedFee+btcDustLimit {
+		return nil, fmt.Errorf("燃烧数量 %d 不足以支付矿工费 %d 和粉尘上限 %d", burnAmount, estimatedFee, btcDustLimit)
+	}
+	payout.PayoutAmount = burnAmount - estimatedFee
+	payout.MinerFee = total - payout.PayoutAmount - payout.ChangeAmount
+	tx.Outputs[0].Value = payout.PayoutAmount
+
+	payout.UnsignedTx = hex.EncodeToString(tx.Serialize(false))
+	payout.Sighashes, err = payoutSighashes(tx, payout.Inputs)
+	if err != nil {
+		return nil, err
+	}
+	return payout, nil
+}
+
+// prevOuts 从出账文件中恢复被花费输出
+func (p *RedeemPayout) prevOuts() ([]BtcPrevOut, error) {
+	prevOuts := make([]BtcPrevOut, len(p.Inputs))
+	for i, in := range p.Inputs {
+		script, err := hex.DecodeString(in.PkScript)
+		if err != nil {
+			return nil, fmt.Errorf("解析第 %d 个输入的脚本失败: %v", i, err)
+		}
+		prevOuts[i] = BtcPrevOut{Value: in.Value, PkScript: script}
+	}
+	return prevOuts, nil
+}
+
+// payoutSighashes 为每个输入计算签名哈希
+func payoutSighashes(tx *BtcTx, inputs []PayoutInput) ([]PayoutSighash, error) {
+	p := &RedeemPayout{Inputs: inputs}
+	prevOuts, err := p.prevOuts()
+	if err != nil {
+		return nil, err
+	}
+	if len(prevOuts) != len(tx.Inputs) {
+		return nil, fmt.Errorf("交易有 %d 个输入，但记录了 %d 个被花费输出", len(tx.Inputs), len(prevOuts))
+	}
+	sighashes := make([]PayoutSighash, len(tx.Inputs))
+	for i := range tx.Inputs {
+		var hash [32]byte
+		switch btcScriptType(prevOuts[i].PkScript) {
+		case BtcScriptP2WPKH:
+			hash, err = tx.WitnessV0SigHash(i, prevOuts[i], btcSigHashAll)
+			sighashes[i] = PayoutSighash{Index: i, Algorithm: PayoutSigECDSA, SigHashType: btcSigHashAll}
+		case BtcScriptP2TR:
+			hash, err = tx.TaprootKeySpendSigHash(i, prevOuts, btcSigHashDefault)
+			sighashes[i] = PayoutSighash{Index: i, Algorithm: PayoutSigSchnorr, SigHashType: btcSigHashDefault}
+		default:
+			err = fmt.Errorf("不支持的输入类型 %s", btcScriptType(prevOuts[i].PkScript))
+		}
+		if err == nil && inputs[i].KeyTweak != "" {
+			if sighashes[i].Algorithm != PayoutSigSchnorr {
+				err = fmt.Errorf("只有P2TR输入可以带密钥调整值")
+			}
+			sighashes[i].KeyTweak = inputs[i].KeyTweak
+		}
+		if err != nil {
+			return nil, fmt.Errorf("计算第 %d 个输入的签名哈希失败: %v", i, err)
+		}
+		sighashes[i].Hash = hex.EncodeToString(hash[:])
+	}
+	return sighashes, nil
+}
+
+// unsignedTx 解析未签名交易，并重新计算签名哈希与文件中的记录核对，防止文件被篡改
+func (p *RedeemPayout) unsignedTx() (*BtcTx, error) {
+	raw, err := hex.DecodeString(p.UnsignedTx)
+	if err != nil {
+		return nil, fmt.Errorf("解析未签名交易失败: %v", err)
+	}
+	tx, err := DeserializeBtcTx(raw)
+	if err != nil {
+		return nil, err
+	}
+	sighashes, err := payoutSighashes(tx, p.Inputs)
+	if err != nil {
+		return nil, err
+	}
+	if len(sighashes) != len(p.Sighashes) {
+		return nil, fmt.Errorf("签名哈希数量 %d 与输入数量 %d 不一致", len(p.Sighashes), len(sighashes))
+	}
+	for i := range sighashes {
+		if sighashes[i] != p.Sighashes[i] {
+			return nil, fmt.Errorf("第 %d 个输入的签名哈希与交易内容不一致", i)
+		}
+	}
+	return tx, nil
+}
+
+// tweakXOnlyPrivKey 把私钥规范为 y 为偶数后加上调整值，与 tweakXOnlyPubKey 对应
+func tweakXOnlyPrivKey(privKey *btcec.PrivateKey, tweak [32]byte) *btcec.PrivateKey {
+	key := privKey.Key
+	if privKey.PubKey().SerializeCompressed()[0] == 0x03 {
+		key.Negate()
+	}
+	var t btcec.ModNScalar
+	t.SetBytes(&tweak)
+	key.Add(&t)
+	return btcec.PrivKeyFromScalar(&key)
+}
+
+// taprootTweakPrivKey 按 BIP86 (无脚本树) 调整私钥，得到 P2TR 输出密钥对应的私钥
+func taprootTweakPrivKey(privKey *btcec.PrivateKey) *btcec.PrivateKey {
+	return tweakXOnlyPrivKey(privKey, taggedHash("TapTweak", schnorr.SerializePubKey(privKey.PubKey())))
+}
+
+// SignRedeemPayout 用单个私钥签名，作为本地演示时门限签名器的替代
+func SignRedeemPayout(payout *RedeemPayout, privKey *btcec.PrivateKey) ([]PayoutSignature, error) {
+	_, err := payout.unsignedTx()
+	if err != nil {
+		return nil, err
+	}
+	var signatures []PayoutSignature
+	for _, sighash := range payout.Sighashes {
+		hash, err := hex.DecodeString(sighash.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("解析签名哈希失败: %v", err)
+		}
+		switch sighash.Algorithm {
+		case PayoutSigECDSA:
+			sig := ecdsa.Sign(privKey, hash)
+			signatures = append(signatures, PayoutSignature{
+				Index:     sighash.Index,
+				Signature: hex.EncodeToString(sig.Serialize()),
+				PublicKey: hex.EncodeToString(privKey.PubKey().SerializeCompressed()),
+			})
+		case PayoutSigSchnorr:
+			signingKey := privKey
+			if sighash.KeyTweak != "" {
+				tweak, err := hex.DecodeString(sighash.KeyTweak)
+				if err != nil || len(tweak) != 32 {
+					return nil, fmt.Errorf("第 %d 个输入的密钥调整值无效", sighash.Index)
+				}
+				signingKey = tweakXOnlyPrivKey(privKey, [32]byte(tweak))
+			}
+			sig, err := schnorr.Sign(taprootTweakPrivKey(signingKey), hash)
+			if err != nil {
+				return nil, fmt.Errorf("schnorr签名失败: %v", err)
+			}
+			signatures = append(signatures, PayoutSignature{Index: sighash.Index, Signature: hex.EncodeToString(sig.Serialize())})
+		default:
+			return nil, fmt.Errorf("未知的签名算法: %s", sighash.Algorithm)
+		}
+	}
+	return signatures, nil
+}
+
+// FinalizeRedeemPayout 校验门限签名并组装见证，返回可广播的交易
+func FinalizeRedeemPayout(payout *RedeemPayout, signatures []PayoutSignature) (*BtcTx, error) {
+	tx, err := payout.unsignedTx()
+	if err != nil {
+		return nil, err
+	}
+	prevOuts, err := payout.prevOuts()
+	if err != nil {
+		return nil, err
+	}
+	byIndex := make(map[int]PayoutSignature)
+	for _, sig := range signatures {
+		byIndex[sig.Index] = sig
+	}
+
+	for i, sighash := range payout.Sighashes {
+		sig, ok := byIndex[i]
+		if !ok {
+			return nil, fmt.Errorf("缺少第 %d 个输入的签名", i)
+		}
+		sigBytes, err := hex.DecodeString(sig.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("解析第 %d 个输入的签名失败: %v", i, err)
+		}
+		switch sighash.Algorithm {
+		case PayoutSigECDSA:
+			pubKeyBytes, err := hex.DecodeString(sig.PublicKey)
+			if err != nil {
+				return nil, fmt.Errorf("解析第 %d 个输入的公钥失败: %v", i, err)
+			}
+			tx.Inputs[i].Witness = [][]byte{append(sigBytes, sighash.SigHashType), pubKeyBytes}
+		case PayoutSigSchnorr:
+			// SIGHASH_DEFAULT 的签名不附加类型字节
+			tx.Inputs[i].Witness = [][]byte{sigBytes}
+		default:
+			return nil, fmt.Errorf("未知的签名算法: %s", sighash.Algorithm)
+		}
+		err = verifyBtcInputWitness(tx, i, prevOuts)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 个输入的签名无效: %v", i, err)
+		}
+	}
+	return tx, nil
+}
+
+// LoadRedeemPayoutRequest 根据赎回请求交易哈希从链上读取预备事件和桥费用，并核对燃烧事件
+func LoadRedeemPayoutRequest(client *aptos.Client, moduleAddress string, redeemRequestTxHash string, bridgeAddress string, network BtcNetwork) (*RedeemPayoutRequest, error) {
+	prepares, err := GetRedeemPrepareEvents(client, moduleAddress, btcPayoutEventLimit)
+	if err != nil {
+		return nil, err
+	}
+	var prepare *BridgeRedeemPrepareEvent
+	for i := range prepares {
+		if prepares[i].EthTxHash == redeemRequestTxHash {
+			prepare = &prepares[i]
+			break
+		}
+	}
+	if prepare == nil {
+		return nil, fmt.Errorf("赎回请求 %s 尚未执行redeem_prepare", redeemRequestTxHash)
+	}
+
+	bridgeFee, err := getBridgeFee(client, moduleAddress)
+	if err != nil {
+		return nil, err
+	}
+	burnAmount, err := redeemBurnAmount(prepare.Amount, bridgeFee)
+	if err != nil {
+		return nil, err
+	}
+
+	// 出账金额必须与链上实际燃烧的数量一致，桥费用在请求后变更时这里会发现不一致
+	requester := aptos.AccountAddress{}
+	err = requester.ParseStringRelaxed(prepare.Requester)
+	if err != nil {
+		return nil, fmt.Errorf("解析请求者地址失败: %v", err)
+	}
+	burns, err := GetTokenBurnEvents(client, moduleAddress, btcPayoutEventLimit)
+	if err != nil {
+		return nil, err
+	}
+	burned := false
+	for _, burn := range burns {
+		burner := aptos.AccountAddress{}
+		if burner.ParseStringRelaxed(burn.Burner) != nil {
+			continue
+		}
+		if burner == requester && burn.BtcAddress == prepare.Receiver && burn.Amount == burnAmount {
+			burned = true
+			break
+		}
+	}
+	if !burned {
+		return nil, fmt.Errorf("未找到 %s 燃烧 %d 到 %s 的燃烧事件，桥费用可能已变更", prepare.Requester, burnAmount, prepare.Receiver)
+	}
+
+	return &RedeemPayoutRequest{
+		RedeemRequestTxHash: redeemRequestTxHash,
+		Requester:           prepare.Requester,
+		Receiver:            prepare.Receiver,
+		Amount:              prepare.Amount,
+		BridgeFee:           bridgeFee,
+		OutpointTxIds:       prepare.OutpointTxIds,
+		OutpointIdxs:        prepare.OutpointIdxs,
+		BridgeAddress:       bridgeAddress,
+		Network:             network,
+	}, nil
+}
+
+// LoadRedeemPayout 读取出账文件
+func LoadRedeemPayout(path string) (*RedeemPayout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取出账文件失败: %v", err)
+	}
+	payout := &RedeemPayout{}
+	err = json.Unmarshal(data, payout)
+	if err != nil {
+		return nil, fmt.Errorf("解析出账文件失败: %v", err)
+	}
+	return payout, nil
+}
+
+// Save 写入出账文件
+func (p *RedeemPayout) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化出账交易失败: %v", err)
+	}
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("写入出账文件失败: %v", err)
+	}
+	return nil
+}
+
+// loadPayoutSignatures 读取签名文件
+func loadPayoutSignatures(path string) ([]PayoutSignature, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取签名文件失败: %v", err)
+	}
+	var signatures []PayoutSignature
+	err = json.Unmarshal(data, &signatures)
+	if err != nil {
+		return nil, fmt.Errorf("解析签名文件失败: %v", err)
+	}
+	return signatures, nil
+}
+
+// printRedeemPayout 打印出账交易摘要
+func printRedeemPayout(payout *RedeemPayout) {
+	fmt.Printf("===== 赎回出账 %s =====\n", payout.RedeemRequestTxHash)
+	fmt.Printf("请求者: %s\n", payout.Requester)
+	fmt.Printf("赎回金额: %d, 桥费用: %d, 燃烧数量: %d (satoshi)\n", payout.RequestAmount, payout.BridgeFee, payout.BurnAmount)
+	for _, in := range payout.Inputs {
+		if in.DepositReceiver != "" {
+			fmt.Printf("输入: %s:%d %d (%s, %s 的专属存款地址)\n", in.TxId, in.Vout, in.Value, in.Type, in.DepositReceiver)
+			continue
+		}
+		fmt.Printf("输入: %s:%d %d (%s)\n", in.TxId, in.Vout, in.Value, in.Type)
+	}
+	fmt.Printf("付款: %s %d\n", payout.Receiver, payout.PayoutAmount)
+	if payout.ChangeAmount > 0 {
+		fmt.Printf("找零: %s %d\n", payout.ChangeAddress, payout.ChangeAmount)
+	}
+	fmt.Printf("矿工费: %d (%d sat/vB, %d vB)\n", payout.MinerFee, payout.FeeRate, payout.VSize)
+	for _, sighash := range payout.Sighashes {
+		fmt.Printf("待签名 #%d (%s, 0x%02x): %s\n", sighash.Index, sighash.Algorithm, sighash.SigHashType, sighash.Hash)
+		if sighash.KeyTweak != "" {
+			fmt.Printf("  密钥调整值: %s\n", sighash.KeyTweak)
+		}
+	}
+}
+
+// printBtcPayoutUsage 打印赎回出账命令的帮助
+func printBtcPayoutUsage() {
+	fmt.Println("BTC赎回出账用法(BTC_BRIDGE_ADDRESS 桥BTC地址, BTC_NETWORK 比特币网络, BTC_FEE_RATE 固定费率或 BTC_FEE_TARGET_BLOCKS 估算目标, BTC_BACKEND 比特币后端, DEPOSIT_INTENT_FILE 存款意图映射文件):")
+	fmt.Println("  构建出账交易和签名哈希: ./main btc-payout build <赎回请求交易哈希> <出账文件>")
+	fmt.Println("  查看出账交易: ./main btc-payout show <出账文件>")
+	fmt.Println("  本地单密钥签名(需BTC_BRIDGE_PRIVATE_KEY): ./main btc-payout sign <出账文件> <签名文件>")
+	fmt.Println("  校验签名并组装交易: ./main btc-payout finalize <出账文件> <签名文件> [broadcast]")
+}
+
+// runBtcPayoutCommand 处理 btc-payout 子命令，不需要 Aptos 私钥
+func runBtcPayoutCommand(args []string) {
+	if len(args) < 2 {
+		printBtcPayoutUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "build":
+		if len(args) < 3 {
+			printBtcPayoutUsage()
+			os.Exit(1)
+		}
+		bridgeAddress := os.Getenv("BTC_BRIDGE_ADDRESS")
+		if bridgeAddress == "" {
+			logError("错误: 缺少桥BTC地址。请设置BTC_BRIDGE_ADDRESS环境变量")
+			os.Exit(1)
+		}
+		network, err := loadBtcNetwork()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		moduleAddress, err := getModuleAddress()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		client, err := createClient()
+		if err != nil {
+			logError(fmt.Sprintf("创建客户端失败: %v", err))
+			os.Exit(1)
+		}
+		req, err := LoadRedeemPayoutRequest(client, moduleAddress, args[1], bridgeAddress, network)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		backend, err := newBitcoinBackend()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		feeRates, err := newBtcFeeRateSource(backend)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		req.DepositIntents, err = loadPayoutDepositIntents(network)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		payout, err := BuildRedeemPayout(*req, &backendPrevOuts{backend: backend}, feeRates)
+		if err != nil {
+			logError(fmt.Sprintf("构建出账交易失败: %v", err))
+			os.Exit(1)
+		}
+		err = payout.Save(args[2])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		printRedeemPayout(payout)
+		logSuccess(fmt.Sprintf("出账交易已写入 %s，请将签名哈希交给门限签名器", args[2]))
+
+	case "show":
+		payout, err := LoadRedeemPayout(args[1])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		_, err = payout.unsignedTx()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		printRedeemPayout(payout)
+
+	case "sign":
+		if len(args) < 3 {
+			printBtcPayoutUsage()
+			os.Exit(1)
+		}
+		keyHex := os.Getenv("BTC_BRIDGE_PRIVATE_KEY")
+		keyBytes, err := hex.DecodeString(keyHex)
+		if keyHex == "" || err != nil || len(keyBytes) != 32 {
+			logError("错误: 请设置32字节十六进制的BTC_BRIDGE_PRIVATE_KEY环境变量")
+			os.Exit(1)
+		}
+		privKey, _ := btcec.PrivKeyFromBytes(keyBytes)
+		payout, err := LoadRedeemPayout(args[1])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		signatures, err := SignRedeemPayout(payout, privKey)
+		if err != nil {
+			logError(fmt.Sprintf("签名失败: %v", err))
+			os.Exit(1)
+		}
+		data, err := json.MarshalIndent(signatures, "", "  ")
+		if err != nil {
+			logError(fmt.Sprintf("序列化签名失败: %v", err))
+			os.Exit(1)
+		}
+		err = os.WriteFile(args[2], data, 0644)
+		if err != nil {
+			logError(fmt.Sprintf("写入签名文件失败: %v", err))
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("已签名 %d 个输入，签名写入 %s", len(signatures), args[2]))
+
+	case "finalize":
+		if len(args) < 3 {
+			printBtcPayoutUsage()
+			os.Exit(1)
+		}
+		payout, err := LoadRedeemPayout(args[1])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		signatures, err := loadPayoutSignatures(args[2])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		tx, err := FinalizeRedeemPayout(payout, signatures)
+		if err != nil {
+			logError(fmt.Sprintf("组装交易失败: %v", err))
+			os.Exit(1)
+		}
+		fmt.Printf("交易ID: %s\n", tx.TxId())
+		fmt.Printf("虚拟大小: %d vB\n", tx.VSize())
+		fmt.Printf("原始交易: %s\n", hex.EncodeToString(tx.Serialize(true)))
+		if len(args) < 4 || args[3] != "broadcast" {
+			logSuccess("出账交易已签名，可以广播")
+			return
+		}
+		backend, err := newBitcoinBackend()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		txid, err := backend.Broadcast(tx.Serialize(true))
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		err = saveBitcoinBackend(backend)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("出账交易已广播: %s", txid))
+
+	default:
+		logError(fmt.Sprintf("未知的btc-payout子命令: %s", args[0]))
+		printBtcPayoutUsage()
+		os.Exit(1)
+	}
+}
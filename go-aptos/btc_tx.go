@@ -0,0 +1,453 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// 签名哈希类型
+const (
+	btcSigHashDefault byte = 0x00 // 仅用于 taproot，等价于 SIGHASH_ALL
+	btcSigHashAll     byte = 0x01
+)
+
+// BtcOutPoint 交易输入引用的输出，Hash 为内部字节序
+type BtcOutPoint struct {
+	Hash  [32]byte
+	Index uint32
+}
+
+// parseBtcOutPoint 从区块浏览器显示的交易ID和输出序号构建 outpoint
+func parseBtcOutPoint(txid string, vout uint32) (BtcOutPoint, error) {
+	raw, err := hex.DecodeString(txid)
+	if err != nil || len(raw) != 32 {
+		return BtcOutPoint{}, fmt.Errorf("无效的BTC交易ID: %s", txid)
+	}
+	var outPoint BtcOutPoint
+	for i := range raw {
+		outPoint.Hash[i] = raw[31-i]
+	}
+	outPoint.Index = vout
+	return outPoint, nil
+}
+
+// TxId 返回区块浏览器显示的交易ID
+func (o BtcOutPoint) TxId() string {
+	var display [32]byte
+	for i := range o.Hash {
+		display[i] = o.Hash[31-i]
+	}
+	return hex.EncodeToString(display[:])
+}
+
+// BtcTxIn 交易输入
+type BtcTxIn struct {
+	PrevOut   BtcOutPoint
+	ScriptSig []byte
+	Sequence  uint32
+	Witness   [][]byte
+}
+
+// BtcTxOut 交易输出
+type BtcTxOut struct {
+	Value    uint64
+	PkScript []byte
+}
+
+// BtcPrevOut 被花费输出的金额和锁定脚本，签名哈希需要用到
+type BtcPrevOut struct {
+	Value    uint64
+	PkScript []byte
+}
+
+// BtcTx 比特币交易
+type BtcTx struct {
+	Version  int32
+	Inputs   []BtcTxIn
+	Outputs  []BtcTxOut
+	LockTime uint32
+}
+
+func writeVarInt(buf *bytes.Buffer, n uint64) {
+	switch {
+	case n < 0xfd:
+		buf.WriteByte(byte(n))
+	case n <= 0xffff:
+		buf.WriteByte(0xfd)
+		binary.Write(buf, binary.LittleEndian, uint16(n))
+	case n <= 0xffffffff:
+		buf.WriteByte(0xfe)
+		binary.Write(buf, binary.LittleEndian, uint32(n))
+	default:
+		buf.WriteByte(0xff)
+		binary.Write(buf, binary.LittleEndian, n)
+	}
+}
+
+func writeVarBytes(buf *bytes.Buffer, data []byte) {
+	writeVarInt(buf, uint64(len(data)))
+	buf.Write(data)
+}
+
+func readVarInt(r *bytes.Reader) (uint64, error) {
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	switch prefix {
+	case 0xfd:
+		var v uint16
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return uint64(v), err
+	case 0xfe:
+		var v uint32
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return uint64(v), err
+	case 0xff:
+		var v uint64
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return v, err
+	}
+	return uint64(prefix), nil
+}
+
+func readVarBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	data := make([]byte, n)
+	_, err = io.ReadFull(r, data)
+	return data, err
+}
+
+// hasWitness 是否有任何输入带见证数据
+func (tx *BtcTx) hasWitness() bool {
+	for _, in := range tx.Inputs {
+		if len(in.Witness) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Serialize 序列化交易，withWitness 为 false 时输出计算交易ID用的传统格式
+func (tx *BtcTx) Serialize(withWitness bool) []byte {
+	withWitness = withWitness && tx.hasWitness()
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, tx.Version)
+	if withWitness {
+		buf.Write([]byte{0x00, 0x01})
+	}
+	writeVarInt(&buf, uint64(len(tx.Inputs)))
+	for _, in := range tx.Inputs {
+		buf.Write(in.PrevOut.Hash[:])
+		binary.Write(&buf, binary.LittleEndian, in.PrevOut.Index)
+		writeVarBytes(&buf, in.ScriptSig)
+		binary.Write(&buf, binary.LittleEndian, in.Sequence)
+	}
+	writeVarInt(&buf, uint64(len(tx.Outputs)))
+	for _, out := range tx.Outputs {
+		binary.Write(&buf, binary.LittleEndian, out.Value)
+		writeVarBytes(&buf, out.PkScript)
+	}
+	if withWitness {
+		for _, in := range tx.Inputs {
+			writeVarInt(&buf, uint64(len(in.Witness)))
+			for _, item := range in.Witness {
+				writeVarBytes(&buf, item)
+			}
+		}
+	}
+	binary.Write(&buf, binary.LittleEndian, tx.LockTime)
+	return buf.Bytes()
+}
+
+// DeserializeBtcTx 解析序列化的交易，同时支持隔离见证格式
+func DeserializeBtcTx(raw []byte) (*BtcTx, error) {
+	r := bytes.NewReader(raw)
+	tx := &BtcTx{}
+	err := binary.Read(r, binary.LittleEndian, &tx.Version)
+	if err != nil {
+		return nil, fmt.Errorf("解析交易版本失败: %v", err)
+	}
+	inputCount, err := readVarInt(r)
+	if err != nil {
+		return nil, fmt.Errorf("解析输入数量失败: %v", err)
+	}
+	segwit := false
+	if inputCount == 0 {
+		flag, err := r.ReadByte()
+		if err != nil || flag != 0x01 {
+			return nil, fmt.Errorf("无效的隔离见证标记")
+		}
+		segwit = true
+		inputCount, err = readVarInt(r)
+		if err != nil {
+			return nil, fmt.Errorf("解析输入数量失败: %v", err)
+		}
+	}
+	if inputCount > uint64(r.Len())/41 {
+		return nil, fmt.Errorf("输入数量 %d 超出数据长度", inputCount)
+	}
+	tx.Inputs = make([]BtcTxIn, inputCount)
+	for i := range tx.Inputs {
+		in := &tx.Inputs[i]
+		_, err = io.ReadFull(r, in.PrevOut.Hash[:])
+		if err == nil {
+			err = binary.Read(r, binary.LittleEndian, &in.PrevOut.Index)
+		}
+		if err == nil {
+			in.ScriptSig, err = readVarBytes(r)
+		}
+		if err == nil {
+			err = binary.Read(r, binary.LittleEndian, &in.Sequence)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析第 %d 个输入失败: %v", i, err)
+		}
+	}
+	outputCount, err := readVarInt(r)
+	if err != nil {
+		return nil, fmt.Errorf("解析输出数量失败: %v", err)
+	}
+	if outputCount > uint64(r.Len())/9 {
+		return nil, fmt.Errorf("输出数量 %d 超出数据长度", outputCount)
+	}
+	tx.Outputs = make([]BtcTxOut, outputCount)
+	for i := range tx.Outputs {
+		out := &tx.Outputs[i]
+		err = binary.Read(r, binary.LittleEndian, &out.Value)
+		if err == nil {
+			out.PkScript, err = readVarBytes(r)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析第 %d 个输出失败: %v", i, err)
+		}
+	}
+	if segwit {
+		for i := range tx.Inputs {
+			count, err := readVarInt(r)
+			if err != nil || count > uint64(r.Len()) {
+				return nil, fmt.Errorf("解析第 %d 个输入的见证失败", i)
+			}
+			for j := uint64(0); j < count; j++ {
+				item, err := readVarBytes(r)
+				if err != nil {
+					return nil, fmt.Errorf("解析第 %d 个输入的见证失败: %v", i, err)
+				}
+				tx.Inputs[i].Witness = append(tx.Inputs[i].Witness, item)
+			}
+		}
+	}
+	err = binary.Read(r, binary.LittleEndian, &tx.LockTime)
+	if err != nil {
+		return nil, fmt.Errorf("解析锁定时间失败: %v", err)
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("交易末尾有 %d 字节多余数据", r.Len())
+	}
+	return tx, nil
+}
+
+// TxId 返回区块浏览器显示的交易ID
+func (tx *BtcTx) TxId() string {
+	return BtcOutPoint{Hash: doubleSha256(tx.Serialize(false))}.TxId()
+}
+
+// Weight 交易重量 (weight units)
+func (tx *BtcTx) Weight() uint64 {
+	base := uint64(len(tx.Serialize(false)))
+	total := uint64(len(tx.Serialize(true)))
+	return base*3 + total
+}
+
+// VSize 交易虚拟大小 (vB)
+func (tx *BtcTx) VSize() uint64 {
+	return (tx.Weight() + 3) / 4
+}
+
+// WitnessV0SigHash 计算 P2WPKH 输入的 BIP143 签名哈希
+func (tx *BtcTx) WitnessV0SigHash(index int, prevOut BtcPrevOut, hashType byte) ([32]byte, error) {
+	if index < 0 || index >= len(tx.Inputs) {
+		return [32]byte{}, fmt.Errorf("输入序号 %d 越界", index)
+	}
+	if btcScriptType(prevOut.PkScript) != BtcScriptP2WPKH {
+		return [32]byte{}, fmt.Errorf("第 %d 个输入不是P2WPKH输出", index)
+	}
+	if hashType != btcSigHashAll {
+		return [32]byte{}, fmt.Errorf("不支持的签名哈希类型 0x%02x", hashType)
+	}
+
+	var prevouts, sequences, outputs bytes.Buffer
+	for _, in := range tx.Inputs {
+		prevouts.Write(in.PrevOut.Hash[:])
+		binary.Write(&prevouts, binary.LittleEndian, in.PrevOut.Index)
+		binary.Write(&sequences, binary.LittleEndian, in.Sequence)
+	}
+	for _, out := range tx.Outputs {
+		binary.Write(&outputs, binary.LittleEndian, out.Value)
+		writeVarBytes(&outputs, out.PkScript)
+	}
+	hashPrevouts := doubleSha256(prevouts.Bytes())
+	hashSequence := doubleSha256(sequences.Bytes())
+	hashOutputs := doubleSha256(outputs.Bytes())
+
+	// P2WPKH 的 scriptCode 为对应的 P2PKH 脚本
+	scriptCode := append([]byte{0x76, 0xa9, 0x14}, prevOut.PkScript[2:]...)
+	scriptCode = append(scriptCode, 0x88, 0xac)
+
+	in := tx.Inputs[index]
+	var preimage bytes.Buffer
+	binary.Write(&preimage, binary.LittleEndian, tx.Version)
+	preimage.Write(hashPrevouts[:])
+	preimage.Write(hashSequence[:])
+	preimage.Write(in.PrevOut.Hash[:])
+	binary.Write(&preimage, binary.LittleEndian, in.PrevOut.Index)
+	writeVarBytes(&preimage, scriptCode)
+	binary.Write(&preimage, binary.LittleEndian, prevOut.Value)
+	binary.Write(&preimage, binary.LittleEndian, in.Sequence)
+	preimage.Write(hashOutputs[:])
+	binary.Write(&preimage, binary.LittleEndian, tx.LockTime)
+	binary.Write(&preimage, binary.LittleEndian, uint32(hashType))
+	return doubleSha256(preimage.Bytes()), nil
+}
+
+// taggedHash 计算 BIP340 标签哈希
+func taggedHash(tag string, msgs ...[]byte) [32]byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, msg := range msgs {
+		h.Write(msg)
+	}
+	var out [32]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// TaprootKeySpendSigHash 计算 taproot 密钥路径花费的 BIP341 签名哈希，prevOuts 需包含全部输入
+func (tx *BtcTx) TaprootKeySpendSigHash(index int, prevOuts []BtcPrevOut, hashType byte) ([32]byte, error) {
+	if index < 0 || index >= len(tx.Inputs) {
+		return [32]byte{}, fmt.Errorf("输入序号 %d 越界", index)
+	}
+	if len(prevOuts) != len(tx.Inputs) {
+		return [32]byte{}, fmt.Errorf("需要全部 %d 个输入的被花费输出，实际为 %d", len(tx.Inputs), len(prevOuts))
+	}
+	if btcScriptType(prevOuts[index].PkScript) != BtcScriptP2TR {
+		return [32]byte{}, fmt.Errorf("第 %d 个输入不是P2TR输出", index)
+	}
+	if hashType != btcSigHashDefault && hashType != btcSigHashAll {
+		return [32]byte{}, fmt.Errorf("不支持的签名哈希类型 0x%02x", hashType)
+	}
+
+	var prevouts, amounts, scripts, sequences, outputs bytes.Buffer
+	for i, in := range tx.Inputs {
+		prevouts.Write(in.PrevOut.Hash[:])
+		binary.Write(&prevouts, binary.LittleEndian, in.PrevOut.Index)
+		binary.Write(&amounts, binary.LittleEndian, prevOuts[i].Value)
+		writeVarBytes(&scripts, prevOuts[i].PkScript)
+		binary.Write(&sequences, binary.LittleEndian, in.Sequence)
+	}
+	for _, out := range tx.Outputs {
+		binary.Write(&outputs, binary.LittleEndian, out.Value)
+		writeVarBytes(&outputs, out.PkScript)
+	}
+	shaPrevouts := sha256.Sum256(prevouts.Bytes())
+	shaAmounts := sha256.Sum256(amounts.Bytes())
+	shaScripts := sha256.Sum256(scripts.Bytes())
+	shaSequences := sha256.Sum256(sequences.Bytes())
+	shaOutputs := sha256.Sum256(outputs.Bytes())
+
+	var msg bytes.Buffer
+	msg.WriteByte(0x00) // sighash epoch
+	msg.WriteByte(hashType)
+	binary.Write(&msg, binary.LittleEndian, tx.Version)
+	binary.Write(&msg, binary.LittleEndian, tx.LockTime)
+	msg.Write(shaPrevouts[:])
+	msg.Write(shaAmounts[:])
+	msg.Write(shaScripts[:])
+	msg.Write(shaSequences[:])
+	msg.Write(shaOutputs[:])
+	msg.WriteByte(0x00) // 密钥路径且没有 annex
+	binary.Write(&msg, binary.LittleEndian, uint32(index))
+	return taggedHash("TapSighash", msg.Bytes()), nil
+}
+
+// verifyBtcInputWitness 校验 P2WPKH 或 P2TR 密钥路径输入的见证签名，prevOuts 需包含全部输入
+func verifyBtcInputWitness(tx *BtcTx, index int, prevOuts []BtcPrevOut) error {
+	if index < 0 || index >= len(tx.Inputs) || len(prevOuts) != len(tx.Inputs) {
+		return fmt.Errorf("输入序号 %d 或被花费输出数量无效", index)
+	}
+	witness := tx.Inputs[index].Witness
+	prevOut := prevOuts[index]
+	switch btcScriptType(prevOut.PkScript) {
+	case BtcScriptP2WPKH:
+		if len(witness) != 2 || len(witness[0]) < 2 || len(witness[1]) != 33 {
+			return fmt.Errorf("P2WPKH见证需要签名和33字节压缩公钥")
+		}
+		if !bytes.Equal(hash160(witness[1]), prevOut.PkScript[2:]) {
+			return fmt.Errorf("公钥与被花费输出不匹配")
+		}
+		pubKey, err := btcec.ParsePubKey(witness[1])
+		if err != nil {
+			return fmt.Errorf("解析公钥失败: %v", err)
+		}
+		sig, hashType := witness[0][:len(witness[0])-1], witness[0][len(witness[0])-1]
+		hash, err := tx.WitnessV0SigHash(index, prevOut, hashType)
+		if err != nil {
+			return err
+		}
+		parsed, err := ecdsa.ParseDERSignature(sig)
+		if err != nil {
+			return fmt.Errorf("解析DER签名失败: %v", err)
+		}
+		if !parsed.Verify(hash[:], pubKey) {
+			return fmt.Errorf("签名验证失败")
+		}
+	case BtcScriptP2TR:
+		if len(witness) != 1 {
+			return fmt.Errorf("只支持taproot密钥路径花费")
+		}
+		sig, hashType := witness[0], btcSigHashDefault
+		if len(sig) == 65 {
+			sig, hashType = sig[:64], sig[64]
+			if hashType == btcSigHashDefault {
+				return fmt.Errorf("SIGHASH_DEFAULT签名不能附加类型字节")
+			}
+		}
+		if len(sig) != 64 {
+			return fmt.Errorf("schnorr签名长度 %d 无效", len(sig))
+		}
+		outputKey, err := schnorr.ParsePubKey(prevOut.PkScript[2:])
+		if err != nil {
+			return fmt.Errorf("解析输出密钥失败: %v", err)
+		}
+		hash, err := tx.TaprootKeySpendSigHash(index, prevOuts, hashType)
+		if err != nil {
+			return err
+		}
+		parsed, err := schnorr.ParseSignature(sig)
+		if err != nil {
+			return fmt.Errorf("解析schnorr签名失败: %v", err)
+		}
+		if !parsed.Verify(hash[:], outputKey) {
+			return fmt.Errorf("签名验证失败")
+		}
+	default:
+		return fmt.Errorf("不支持花费 %s 输出", btcScriptType(prevOut.PkScript))
+	}
+	return nil
+}
@@ -12,10 +12,28 @@ import (
 	"github.com/aptos-labs/aptos-go-sdk/crypto"
 )
 
+// loadNetworkConfig 默认连接devnet，APTOS_NODE_URL 可指定其他全节点(如本地节点或模拟节点)的 REST 地址，
+// APTOS_FAUCET_URL 指定水龙头地址
+func loadNetworkConfig() aptos.NetworkConfig {
+	networkConfig := aptos.DevnetConfig // TODO mainnet
+	if nodeUrl := os.Getenv("APTOS_NODE_URL"); nodeUrl != "" {
+		networkConfig = aptos.NetworkConfig{Name: "custom", NodeUrl: strings.TrimSuffix(nodeUrl, "/")}
+	}
+	if faucetUrl := os.Getenv("APTOS_FAUCET_URL"); faucetUrl != "" {
+		networkConfig.FaucetUrl = faucetUrl
+	}
+	return networkConfig
+}
+
+// getNodeBaseURL 全节点地址去掉 /v1 前缀，用于直接拼接 REST 路径
+func getNodeBaseURL() string {
+	return strings.TrimSuffix(loadNetworkConfig().NodeUrl, "/v1")
+}
+
 // 创建 Aptos 客户端
 func createClient() (*aptos.Client, error) {
 	// 从环境变量获取网络配置，默认为devnet
-	networkConfig := aptos.DevnetConfig // TODO mainnet
+	networkConfig := loadNetworkConfig()
 
 	// 创建客户端
 	client, err := aptos.NewClient(networkConfig)
@@ -49,6 +67,37 @@ func createAccountFromPrivateKey(privateKeyHex string) (*aptos.Account, error) {
 
 	return account, nil
 }
+
+// 创建签名账户，设置MULTISIG_CONFIG时PRIVATE_KEY为逗号分隔的多签成员私钥
+func createSigningAccount(privateKey string) (*aptos.Account, error) {
+	configPath := os.Getenv("MULTISIG_CONFIG")
+	if configPath == "" {
+		return createAccountFromPrivateKey(privateKey)
+	}
+
+	config, err := LoadMultiSigConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	var keys []*crypto.Ed25519PrivateKey
+	for _, keyHex := range strings.Split(privateKey, ",") {
+		key, err := parseEd25519PrivateKey(keyHex)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	signer, err := NewMultiSigSigner(config, keys)
+	if err != nil {
+		return nil, err
+	}
+	account, err := aptos.NewAccountFromSigner(signer)
+	if err != nil {
+		return nil, fmt.Errorf("从多签配置创建账户失败: %v", err)
+	}
+	return account, nil
+}
+
 // 构建并提交交易的通用函数
 func buildAndSubmitTransaction(
 	ctx context.Context,
@@ -131,7 +180,7 @@ func buildAndSubmitTransaction(
 	}
 
 	// 构建、签名并提交交易
-	resp, err := client.BuildSignAndSubmitTransaction(account, payload)
+	resp, err := buildSignAndSubmitTransaction(client, account, payload)
 	if err != nil {
 		return "", fmt.Errorf("构建、签名并提交交易失败: %v", err)
 	}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/api"
+)
+
+// ErrTransactionAlreadyApplied 重发前的幂等检查发现目标状态已在链上完成
+var ErrTransactionAlreadyApplied = errors.New("交易目标已在链上完成，不再重发")
+
+// ResubmitPolicy 交易确认与重发策略
+type ResubmitPolicy struct {
+	ExpirationSeconds uint64        // 每次提交的有效期
+	GasBumpPercent    uint64        // 每次重发提高的gas单价百分比
+	MaxGasUnitPrice   uint64        // gas单价上限
+	MaxAttempts       int           // 最多提交次数(含首次)
+	PollInterval      time.Duration // 查询交易状态的间隔
+}
+
+// 默认重发策略
+var defaultResubmitPolicy = ResubmitPolicy{
+	ExpirationSeconds: 60,
+	GasBumpPercent:    25,
+	MaxGasUnitPrice:   10000,
+	MaxAttempts:       5,
+	PollInterval:      time.Second,
+}
+
+// loadResubmitPolicy 读取重发策略，可通过环境变量覆盖默认值
+func loadResubmitPolicy() (ResubmitPolicy, error) {
+	policy := defaultResubmitPolicy
+	envs := []struct {
+		name  string
+		value *uint64
+	}{
+		{"APTOS_TXN_EXPIRATION_SECONDS", &policy.ExpirationSeconds},
+		{"APTOS_GAS_BUMP_PERCENT", &policy.GasBumpPercent},
+		{"APTOS_MAX_GAS_UNIT_PRICE", &policy.MaxGasUnitPrice},
+	}
+	for _, env := range envs {
+		valueStr := os.Getenv(env.name)
+		if valueStr == "" {
+			continue
+		}
+		value, err := strconv.ParseUint(valueStr, 10, 64)
+		if err != nil || value == 0 {
+			return policy, fmt.Errorf("无效的%s: %s", env.name, valueStr)
+		}
+		*env.value = value
+	}
+	if valueStr := os.Getenv("APTOS_MAX_SUBMIT_ATTEMPTS"); valueStr != "" {
+		value, err := strconv.Atoi(valueStr)
+		if err != nil || value <= 0 {
+			return policy, fmt.Errorf("无效的APTOS_MAX_SUBMIT_ATTEMPTS: %s", valueStr)
+		}
+		policy.MaxAttempts = value
+	}
+	return policy, nil
+}
+
+// TrackedTransaction 被跟踪的交易，所有重发都使用同一个序列号，因此最多只有一次能上链
+type TrackedTransaction struct {
+	Payload        aptos.TransactionPayload
+	SequenceNumber uint64
+	GasUnitPrice   uint64
+	Expiration     uint64
+	Hashes         []string
+	Attempts       int
+
+	// AlreadyApplied 重发前的幂等检查，返回true表示目标状态已在链上，不再重发
+	AlreadyApplied func() (bool, error)
+}
+
+// ConfirmationTracker 跟踪已提交交易直到确认或过期，过期后用相同序列号和更高gas单价重发
+type ConfirmationTracker struct {
+	client          *aptos.Client
+	signer          aptos.TransactionSigner
+	policy          ResubmitPolicy
+	sequenceManager *SequenceManager
+}
+
+// NewConfirmationTracker 创建交易确认跟踪器，sequenceManager为nil时每次从链上获取序列号
+func NewConfirmationTracker(client *aptos.Client, signer aptos.TransactionSigner, policy ResubmitPolicy, sequenceManager *SequenceManager) *ConfirmationTracker {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 1
+	}
+	if policy.PollInterval <= 0 {
+		policy.PollInterval = defaultResubmitPolicy.PollInterval
+	}
+	return &ConfirmationTracker{
+		client:          client,
+		signer:          signer,
+		policy:          policy,
+		sequenceManager: sequenceManager,
+	}
+}
+
+// buildAndSign 用跟踪交易当前的序列号和gas单价构建并签名
+func (t *ConfirmationTracker) buildAndSign(tracked *TrackedTransaction) (*aptos.SignedTransaction, error) {
+	rawTxn, err := t.client.BuildTransaction(t.signer.AccountAddress(), tracked.Payload,
+		aptos.SequenceNumber(tracked.SequenceNumber),
+		aptos.GasUnitPrice(tracked.GasUnitPrice),
+		aptos.ExpirationSeconds(t.policy.ExpirationSeconds),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("构建交易失败: %v", err)
+	}
+	signedTxn, err := signTransaction(rawTxn, t.signer)
+	if err != nil {
+		return nil, fmt.Errorf("签名交易失败: %v", err)
+	}
+	tracked.Expiration = rawTxn.ExpirationTimestampSeconds
+	return signedTxn, nil
+}
+
+// Submit 首次提交交易
+func (t *ConfirmationTracker) Submit(payload aptos.TransactionPayload, alreadyApplied func() (bool, error)) (*TrackedTransaction, error) {
+	gasInfo, err := t.client.EstimateGasPrice()
+	if err != nil {
+		return nil, fmt.Errorf("估算gas单价失败: %v", err)
+	}
+	tracked := &TrackedTransaction{
+		Payload:        payload,
+		GasUnitPrice:   min(gasInfo.GasEstimate, t.policy.MaxGasUnitPrice),
+		AlreadyApplied: alreadyApplied,
+	}
+
+	if t.sequenceManager != nil {
+		resp, sequenceNumber, err := t.sequenceManager.Submit(func(sequenceNumber uint64) (*aptos.SignedTransaction, error) {
+			tracked.SequenceNumber = sequenceNumber
+			return t.buildAndSign(tracked)
+		})
+		if err != nil {
+			return nil, err
+		}
+		tracked.SequenceNumber = sequenceNumber
+		tracked.Hashes = append(tracked.Hashes, resp.Hash)
+		tracked.Attempts = 1
+		return tracked, nil
+	}
+
+	info, err := t.client.Account(t.signer.AccountAddress())
+	if err != nil {
+		return nil, fmt.Errorf("获取账户信息失败: %v", err)
+	}
+	tracked.SequenceNumber, err = info.SequenceNumber()
+	if err != nil {
+		return nil, fmt.Errorf("获取序列号失败: %v", err)
+	}
+	err = t.submit(tracked)
+	if err != nil {
+		return nil, err
+	}
+	return tracked, nil
+}
+
+// submit 构建、签名并提交一次
+func (t *ConfirmationTracker) submit(tracked *TrackedTransaction) error {
+	signedTxn, err := t.buildAndSign(tracked)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.SubmitTransaction(signedTxn)
+	if err != nil {
+		return fmt.Errorf("提交交易失败: %v", err)
+	}
+	tracked.Hashes = append(tracked.Hashes, resp.Hash)
+	tracked.Attempts++
+	if t.sequenceManager != nil {
+		t.sequenceManager.Submitted(tracked.SequenceNumber, tracked.Expiration)
+	}
+	return nil
+}
+
+// findCommitted 查找该序列号已提交的各次尝试中是否有已上链的
+func (t *ConfirmationTracker) findCommitted(tracked *TrackedTransaction) (*api.UserTransaction, error) {
+	for _, hash := range tracked.Hashes {
+		txn, err := t.client.TransactionByHash(hash)
+		if err != nil || txn.Type != api.TransactionVariantUser {
+			continue
+		}
+		return txn.UserTransaction()
+	}
+	return nil, nil
+}
+
+// finish 交易结束时更新序列号管理器
+func (t *ConfirmationTracker) finish(tracked *TrackedTransaction, consumed bool) {
+	if t.sequenceManager == nil {
+		return
+	}
+	if consumed {
+		t.sequenceManager.Confirm(tracked.SequenceNumber)
+	} else {
+		t.sequenceManager.Release(tracked.SequenceNumber)
+	}
+}
+
+// Confirm 等待交易上链，过期未上链时提高gas单价后用同一序列号重发
+func (t *ConfirmationTracker) Confirm(tracked *TrackedTransaction) (*api.UserTransaction, error) {
+	for {
+		userTxn, err := t.findCommitted(tracked)
+		if err != nil {
+			t.finish(tracked, true)
+			return nil, fmt.Errorf("解析用户交易信息失败: %v", err)
+		}
+		if userTxn != nil {
+			t.finish(tracked, true)
+			return userTxn, nil
+		}
+
+		// 以链上时间判断是否过期，只有账本时间超过过期时间后交易才确定不会再上链
+		nodeInfo, err := t.client.Info()
+		if err != nil {
+			logWarning(fmt.Sprintf("获取节点信息失败: %v", err))
+			time.Sleep(t.policy.PollInterval)
+			continue
+		}
+		if nodeInfo.LedgerTimestamp()/1_000_000 <= tracked.Expiration {
+			time.Sleep(t.policy.PollInterval)
+			continue
+		}
+
+		// 已过期，确认该序列号是否被其他交易消耗。查询失败时与节点信息一样稍后重试，
+		// 不能直接返回，否则序列号管理器中的在途名额不会被归还
+		info, err := t.client.Account(t.signer.AccountAddress())
+		if err != nil {
+			logWarning(fmt.Sprintf("获取账户信息失败: %v", err))
+			time.Sleep(t.policy.PollInterval)
+			continue
+		}
+		onChain, err := info.SequenceNumber()
+		if err != nil {
+			logWarning(fmt.Sprintf("获取序列号失败: %v", err))
+			time.Sleep(t.policy.PollInterval)
+			continue
+		}
+		if onChain > tracked.SequenceNumber {
+			// 过期判断与上链可能有竞争，再查一次
+			userTxn, err := t.findCommitted(tracked)
+			t.finish(tracked, true)
+			if err != nil {
+				return nil, fmt.Errorf("解析用户交易信息失败: %v", err)
+			}
+			if userTxn != nil {
+				return userTxn, nil
+			}
+			return nil, fmt.Errorf("序列号 %d 已被其他交易使用", tracked.SequenceNumber)
+		}
+
+		if tracked.Attempts >= t.policy.MaxAttempts {
+			t.finish(tracked, false)
+			return nil, fmt.Errorf("交易 %d 次提交均已过期: %v", tracked.Attempts, tracked.Hashes)
+		}
+
+		// 重发前做幂等检查，例如btc_tx_id已被铸币时不再重发
+		if tracked.AlreadyApplied != nil {
+			applied, err := tracked.AlreadyApplied()
+			if err != nil {
+				logWarning(fmt.Sprintf("重发前幂等检查失败: %v", err))
+				time.Sleep(t.policy.PollInterval)
+				continue
+			}
+			if applied {
+				t.finish(tracked, false)
+				return nil, ErrTransactionAlreadyApplied
+			}
+		}
+
+		bumped := tracked.GasUnitPrice * (100 + t.policy.GasBumpPercent) / 100
+		if bumped <= tracked.GasUnitPrice {
+			bumped = tracked.GasUnitPrice + 1
+		}
+		tracked.GasUnitPrice = min(bumped, t.policy.MaxGasUnitPrice)
+		logWarning(fmt.Sprintf("交易已过期，使用序列号 %d 和gas单价 %d 重发 (第%d次)",
+			tracked.SequenceNumber, tracked.GasUnitPrice, tracked.Attempts+1))
+
+		err = t.submit(tracked)
+		if err != nil {
+			if isSequenceTooOld(err) {
+				// 序列号已被消耗，下一轮会查到结果或报告被占用
+				continue
+			}
+			t.finish(tracked, false)
+			return nil, err
+		}
+	}
+}
+
+// SubmitAndConfirm 提交并等待交易确认，交易执行失败时返回错误
+func (t *ConfirmationTracker) SubmitAndConfirm(payload aptos.TransactionPayload, alreadyApplied func() (bool, error)) (string, error) {
+	tracked, err := t.Submit(payload, alreadyApplied)
+	if err != nil {
+		return "", err
+	}
+	userTxn, err := t.Confirm(tracked)
+	if err != nil {
+		return "", err
+	}
+	if !userTxn.Success {
+		return "", fmt.Errorf("交易执行失败: %s", userTxn.VmStatus)
+	}
+	return userTxn.Hash, nil
+}
+
+// submitAndConfirm 使用默认重发策略提交交易并等待确认
+func submitAndConfirm(client *aptos.Client, account aptos.TransactionSigner, payload aptos.TransactionPayload, alreadyApplied func() (bool, error)) (string, error) {
+	policy, err := loadResubmitPolicy()
+	if err != nil {
+		return "", err
+	}
+	tracker := NewConfirmationTracker(client, account, policy, nil)
+	return tracker.SubmitAndConfirm(payload, alreadyApplied)
+}
+
+// isAlreadyMintedAbort 判断交易是否因btc_tx_id已铸币而被合约拒绝
+func isAlreadyMintedAbort(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "E_ALREADY_MINTED")
+}
+
+// isBtcTxMinted 检查btc_tx_id是否已在MintedTransactions中
+func isBtcTxMinted(client *aptos.Client, moduleAddress string, btcTxId string) (bool, error) {
+	minted, err := GetMintedTransactions(client, moduleAddress)
+	if err != nil {
+		return false, err
+	}
+	for _, txId := range minted.Minted {
+		if txId == btcTxId {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/api"
+)
+
+// flakyNodeProxy 模拟全节点前的代理，按 "方法 路径" 让请求失败指定次数
+type flakyNodeProxy struct {
+	node     *FakeFullnode
+	mu       sync.Mutex
+	failures map[string]int
+}
+
+func (p *flakyNodeProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Method + " " + r.URL.Path
+	p.mu.Lock()
+	fail := p.failures[key] > 0
+	if fail {
+		p.failures[key]--
+	}
+	p.mu.Unlock()
+	if fail {
+		http.Error(w, `{"message":"injected failure","error_code":"internal_error"}`, http.StatusServiceUnavailable)
+		return
+	}
+	p.node.server.Config.Handler.ServeHTTP(w, r)
+}
+
+// fail 让 "方法 路径" 的后续 n 次请求失败
+func (p *flakyNodeProxy) fail(key string, n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failures[key] += n
+}
+
+// remaining 尚未触发的失败次数
+func (p *flakyNodeProxy) remaining(key string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.failures[key]
+}
+
+// trackerTest 交易只进内存池不自动上链的模拟节点，跟踪器经过可注入失败的代理访问节点，
+// 序列号管理器只允许一笔在途交易，名额未归还时后续分配会阻塞
+type trackerTest struct {
+	node     *FakeFullnode
+	proxy    *flakyNodeProxy
+	client   *aptos.Client
+	sender   *aptos.Account
+	payload  aptos.TransactionPayload
+	sequence *SequenceManager
+	tracker  *ConfirmationTracker
+}
+
+// testResubmitPolicy 有效期 1 秒、轮询间隔很短的重发策略
+func testResubmitPolicy() ResubmitPolicy {
+	return ResubmitPolicy{
+		ExpirationSeconds: 1,
+		GasBumpPercent:    150,
+		MaxGasUnitPrice:   300,
+		MaxAttempts:       3,
+		PollInterval:      10 * time.Millisecond,
+	}
+}
+
+func newTrackerTest(t *testing.T, policy ResubmitPolicy) *trackerTest {
+	t.Helper()
+	node := NewFakeFullnode()
+	t.Cleanup(node.Close)
+	node.SetAutoCommit(false)
+	node.SetGasEstimate(100)
+	sender := newTestAccount(t, t.Name()+"-sender")
+	err := node.CreateAccount(sender.Address, fakeNodeFundOctas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := &flakyNodeProxy{node: node, failures: map[string]int{}}
+	server := httptest.NewServer(proxy)
+	t.Cleanup(server.Close)
+	client, err := aptos.NewClient(aptos.NetworkConfig{Name: "fake", ChainId: fakeNodeChainId, NodeUrl: server.URL + "/v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := aptos.CoinTransferPayload(nil, aptos.AccountOne, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequence := NewSequenceManager(client, sender.Address, 1)
+	return &trackerTest{
+		node:     node,
+		proxy:    proxy,
+		client:   client,
+		sender:   sender,
+		payload:  aptos.TransactionPayload{Payload: payload},
+		sequence: sequence,
+		tracker:  NewConfirmationTracker(client, sender, policy, sequence),
+	}
+}
+
+// accountPath 发送方账户接口的代理键
+func (tt *trackerTest) accountPath() string {
+	return "GET /v1/accounts/" + tt.sender.Address.String()
+}
+
+// pendingGasPrices 内存池中发送方交易的gas单价，升序
+func (tt *trackerTest) pendingGasPrices() []uint64 {
+	tt.node.mu.Lock()
+	defer tt.node.mu.Unlock()
+	var prices []uint64
+	for _, txn := range tt.node.transactions {
+		if !txn.Committed && txn.Signed.Transaction.Sender == tt.sender.Address {
+			prices = append(prices, txn.Signed.Transaction.GasUnitPrice)
+		}
+	}
+	slices.Sort(prices)
+	return prices
+}
+
+type confirmResult struct {
+	txn *api.UserTransaction
+	err error
+}
+
+// submit 提交并在后台等待确认
+func (tt *trackerTest) submit(t *testing.T, alreadyApplied func() (bool, error)) (*TrackedTransaction, chan confirmResult) {
+	t.Helper()
+	tracked, err := tt.tracker.Submit(tt.payload, alreadyApplied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan confirmResult, 1)
+	go func() {
+		txn, err := tt.tracker.Confirm(tracked)
+		done <- confirmResult{txn, err}
+	}()
+	return tracked, done
+}
+
+// commitWhenPending 等到内存池中出现gas单价为 gasUnitPrice 的重发后上链
+func (tt *trackerTest) commitWhenPending(t *testing.T, gasUnitPrice uint64) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !slices.Contains(tt.pendingGasPrices(), gasUnitPrice) {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待gas单价为 %d 的重发超时，内存池中为 %v", gasUnitPrice, tt.pendingGasPrices())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if tt.node.Commit() != 1 {
+		t.Fatalf("重发的交易应上链")
+	}
+}
+
+// expectReleased 跟踪结束后在途名额应已归还
+func (tt *trackerTest) expectReleased(t *testing.T) {
+	t.Helper()
+	if inFlight := tt.sequence.InFlight(); inFlight != 0 {
+		t.Fatalf("跟踪结束后在途名额应归还，实际仍有 %d 笔", inFlight)
+	}
+}
+
+func waitConfirm(t *testing.T, done chan confirmResult) confirmResult {
+	t.Helper()
+	select {
+	case result := <-done:
+		return result
+	case <-time.After(15 * time.Second):
+		t.Fatalf("等待确认超时")
+		return confirmResult{}
+	}
+}
+
+func TestConfirmationTrackerResubmitsWithBumpedGasUpToCap(t *testing.T) {
+	tt := newTrackerTest(t, testResubmitPolicy())
+	tracked, done := tt.submit(t, nil)
+	if tracked.GasUnitPrice != 100 || tracked.SequenceNumber != 0 {
+		t.Fatalf("首次提交应使用估算的gas单价 100 和序列号 0，实际 %d/%d", tracked.GasUnitPrice, tracked.SequenceNumber)
+	}
+	// 100 -> 250 -> 300 (上限，而不是 625)
+	tt.commitWhenPending(t, 300)
+	result := waitConfirm(t, done)
+	if result.err != nil || !result.txn.Success {
+		t.Fatalf("重发的交易应确认成功: %v", result.err)
+	}
+	if prices := tt.pendingGasPrices(); len(prices) != 0 {
+		t.Fatalf("上链后同一序列号的其他尝试应被丢弃，实际 %v", prices)
+	}
+	if tracked.Attempts != 3 || len(tracked.Hashes) != 3 || result.txn.Hash != tracked.Hashes[2] || result.txn.GasUnitPrice != 300 {
+		t.Fatalf("应在第 3 次以上限 300 提交后确认，实际 %d 次 %v，上链gas单价 %d", tracked.Attempts, tracked.Hashes, result.txn.GasUnitPrice)
+	}
+	tt.expectReleased(t)
+	if sequenceNumber, err := tt.sequence.Acquire(); err != nil || sequenceNumber != 1 {
+		t.Fatalf("序列号 0 已被消耗，下一个应为 1，实际 %d: %v", sequenceNumber, err)
+	}
+}
+
+func TestConfirmationTrackerSkipsResubmitWhenAlreadyApplied(t *testing.T) {
+	tt := newTrackerTest(t, testResubmitPolicy())
+	checks := 0
+	tracked, done := tt.submit(t, func() (bool, error) {
+		checks++
+		return true, nil
+	})
+	result := waitConfirm(t, done)
+	if !errors.Is(result.err, ErrTransactionAlreadyApplied) || checks != 1 {
+		t.Fatalf("目标已完成时应不再重发并返回 ErrTransactionAlreadyApplied，实际检查 %d 次: %v", checks, result.err)
+	}
+	if tracked.Attempts != 1 || len(tracked.Hashes) != 1 {
+		t.Fatalf("不应重发，实际提交 %d 次", tracked.Attempts)
+	}
+	tt.expectReleased(t)
+	if sequenceNumber, err := tt.sequence.Acquire(); err != nil || sequenceNumber != tracked.SequenceNumber {
+		t.Fatalf("未上链的序列号应回收复用，实际 %d: %v", sequenceNumber, err)
+	}
+}
+
+func TestConfirmationTrackerReleasesSlotOnErrors(t *testing.T) {
+	t.Run("查询失败后重试", func(t *testing.T) {
+		t.Parallel()
+		tt := newTrackerTest(t, testResubmitPolicy())
+		var mu sync.Mutex
+		checks := 0
+		_, done := tt.submit(t, func() (bool, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			checks++
+			if checks <= 2 {
+				return false, errors.New("查询铸币记录失败")
+			}
+			return false, nil
+		})
+		// 提交时分配序列号也会读取账户，提交之后再注入失败；过期前跟踪器不会读取账户
+		tt.proxy.fail(tt.accountPath(), 3)
+		tt.proxy.fail("GET /v1", 2)
+		tt.commitWhenPending(t, 250)
+		result := waitConfirm(t, done)
+		if result.err != nil || tt.proxy.remaining(tt.accountPath()) != 0 || tt.proxy.remaining("GET /v1") != 0 || checks != 3 {
+			t.Fatalf("账户、节点信息和幂等检查失败后应重试直到重发成功，实际检查 %d 次: %v", checks, result.err)
+		}
+		tt.expectReleased(t)
+	})
+
+	t.Run("提交次数用尽", func(t *testing.T) {
+		t.Parallel()
+		policy := testResubmitPolicy()
+		policy.MaxAttempts = 1
+		tt := newTrackerTest(t, policy)
+		_, done := tt.submit(t, nil)
+		result := waitConfirm(t, done)
+		if result.err == nil || !strings.Contains(result.err.Error(), "均已过期") {
+			t.Fatalf("提交次数用尽应报错: %v", result.err)
+		}
+		tt.expectReleased(t)
+	})
+
+	t.Run("重发被拒绝", func(t *testing.T) {
+		t.Parallel()
+		tt := newTrackerTest(t, testResubmitPolicy())
+		_, done := tt.submit(t, nil)
+		tt.proxy.fail("POST /v1/transactions", 1)
+		result := waitConfirm(t, done)
+		if result.err == nil || !strings.Contains(result.err.Error(), "提交交易失败") {
+			t.Fatalf("重发被节点拒绝时应报错: %v", result.err)
+		}
+		tt.expectReleased(t)
+	})
+
+	t.Run("序列号被其他交易使用", func(t *testing.T) {
+		t.Parallel()
+		tt := newTrackerTest(t, testResubmitPolicy())
+		tracked, done := tt.submit(t, nil)
+		rawTxn, err := tt.client.BuildTransaction(tt.sender.Address, tt.payload,
+			aptos.SequenceNumber(tracked.SequenceNumber), aptos.GasUnitPrice(200))
+		if err != nil {
+			t.Fatal(err)
+		}
+		signedTxn, err := rawTxn.SignedTransaction(tt.sender)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = tt.client.SubmitTransaction(signedTxn)
+		if err != nil || tt.node.Commit() != 1 {
+			t.Fatalf("其他交易应使用同一序列号上链: %v", err)
+		}
+		result := waitConfirm(t, done)
+		if result.err == nil || !strings.Contains(result.err.Error(), "已被其他交易使用") {
+			t.Fatalf("序列号被其他交易消耗时应报错: %v", result.err)
+		}
+		tt.expectReleased(t)
+	})
+}
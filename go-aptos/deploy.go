@@ -0,0 +1,521 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/bcs"
+)
+
+// 0x1::code::UpgradePolicy 的取值
+const (
+	upgradePolicyArbitrary  uint8 = 0
+	upgradePolicyCompatible uint8 = 1
+	upgradePolicyImmutable  uint8 = 2
+)
+
+// packageRegistryType 发布者账户下记录已发布包的资源
+const packageRegistryType = "0x1::code::PackageRegistry"
+
+// moveBytecodeMagic Move 字节码文件的魔数
+var moveBytecodeMagic = []byte{0xa1, 0x1c, 0xeb, 0x0b}
+
+// upgradePolicyName 返回升级策略的名称
+func upgradePolicyName(policy uint8) string {
+	switch policy {
+	case upgradePolicyArbitrary:
+		return "arbitrary"
+	case upgradePolicyCompatible:
+		return "compatible"
+	case upgradePolicyImmutable:
+		return "immutable"
+	}
+	return fmt.Sprintf("未知(%d)", policy)
+}
+
+// PackageDep 对应 0x1::code::PackageDep
+type PackageDep struct {
+	Account     aptos.AccountAddress
+	PackageName string
+}
+
+// PackageMetadata 对应 0x1::code::PackageMetadata 中部署时用到的字段
+type PackageMetadata struct {
+	Name          string
+	UpgradePolicy uint8
+	UpgradeNumber uint64
+	SourceDigest  string
+	Modules       []string // 按发布顺序排列的模块名
+	Deps          []PackageDep
+}
+
+// decodePackageMetadata 解码 BCS 编码的 PackageMetadata(package-metadata.bcs)
+func decodePackageMetadata(data []byte) (*PackageMetadata, error) {
+	des := bcs.NewDeserializer(data)
+	metadata := &PackageMetadata{}
+	metadata.Name = des.ReadString()
+	metadata.UpgradePolicy = des.U8()
+	metadata.UpgradeNumber = des.U64()
+	metadata.SourceDigest = des.ReadString()
+	des.ReadBytes() // manifest
+	moduleCount := des.Uleb128()
+	for i := uint32(0); i < moduleCount && des.Error() == nil; i++ {
+		metadata.Modules = append(metadata.Modules, des.ReadString())
+		des.ReadBytes() // source
+		des.ReadBytes() // source_map
+		skipMetadataExtension(des)
+	}
+	depCount := des.Uleb128()
+	for i := uint32(0); i < depCount && des.Error() == nil; i++ {
+		dep := PackageDep{}
+		dep.Account.UnmarshalBCS(des)
+		dep.PackageName = des.ReadString()
+		metadata.Deps = append(metadata.Deps, dep)
+	}
+	skipMetadataExtension(des)
+	if des.Error() != nil {
+		return nil, fmt.Errorf("解码包元数据失败: %v", des.Error())
+	}
+	if des.Remaining() != 0 {
+		return nil, fmt.Errorf("解码包元数据失败: 末尾有 %d 字节多余数据", des.Remaining())
+	}
+	return metadata, nil
+}
+
+// skipMetadataExtension 跳过 Option<0x1::copyable_any::Any> 类型的 extension 字段
+func skipMetadataExtension(des *bcs.Deserializer) {
+	if des.Uleb128() == 1 {
+		des.ReadString() // type_name
+		des.ReadBytes()  // data
+	}
+}
+
+// CompiledPackage 编译好的 Move 包：BCS 编码的元数据和按元数据中模块顺序排列的字节码
+type CompiledPackage struct {
+	Metadata    *PackageMetadata
+	MetadataBCS []byte
+	Code        [][]byte
+}
+
+// LoadCompiledPackage 读取编译好的 Move 包
+// path 可以是 aptos move compile --save-metadata 的包目录(含 build/)、build/<包名> 目录，
+// 或 aptos move build-publish-payload --json-output-file 输出的 JSON 文件
+func LoadCompiledPackage(path string) (*CompiledPackage, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取编译产物失败: %v", err)
+	}
+	var pkg *CompiledPackage
+	if info.IsDir() {
+		pkg, err = loadCompiledPackageDir(path)
+	} else {
+		pkg, err = loadPublishPayloadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pkg.Metadata, err = decodePackageMetadata(pkg.MetadataBCS)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkg.Code) != len(pkg.Metadata.Modules) {
+		return nil, fmt.Errorf("包 %s 的元数据中有 %d 个模块，字节码有 %d 个", pkg.Metadata.Name, len(pkg.Metadata.Modules), len(pkg.Code))
+	}
+	for i, code := range pkg.Code {
+		if !bytes.HasPrefix(code, moveBytecodeMagic) {
+			return nil, fmt.Errorf("模块 %s 不是有效的Move字节码", pkg.Metadata.Modules[i])
+		}
+	}
+	return pkg, nil
+}
+
+// loadCompiledPackageDir 从编译目录读取 package-metadata.bcs 和 bytecode_modules/<模块名>.mv
+func loadCompiledPackageDir(dir string) (*CompiledPackage, error) {
+	buildDir := dir
+	if _, err := os.Stat(filepath.Join(dir, "package-metadata.bcs")); err != nil {
+		matches, _ := filepath.Glob(filepath.Join(dir, "build", "*", "package-metadata.bcs"))
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("在 %s 中未找到 package-metadata.bcs，请先执行 aptos move compile --save-metadata", dir)
+		}
+		if len(matches) > 1 {
+			return nil, fmt.Errorf("%s 下有多个编译好的包，请指定 build/<包名> 目录", dir)
+		}
+		buildDir = filepath.Dir(matches[0])
+	}
+
+	pkg := &CompiledPackage{}
+	var err error
+	pkg.MetadataBCS, err = os.ReadFile(filepath.Join(buildDir, "package-metadata.bcs"))
+	if err != nil {
+		return nil, fmt.Errorf("读取包元数据失败: %v", err)
+	}
+	metadata, err := decodePackageMetadata(pkg.MetadataBCS)
+	if err != nil {
+		return nil, err
+	}
+	// 字节码按元数据中的模块顺序(依赖顺序)发布
+	for _, module := range metadata.Modules {
+		code, err := os.ReadFile(filepath.Join(buildDir, "bytecode_modules", module+".mv"))
+		if err != nil {
+			return nil, fmt.Errorf("读取模块 %s 的字节码失败: %v", module, err)
+		}
+		pkg.Code = append(pkg.Code, code)
+	}
+	return pkg, nil
+}
+
+// publishPayloadFile aptos move build-publish-payload 输出的 JSON
+type publishPayloadFile struct {
+	FunctionId string `json:"function_id"`
+	Args       []struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	} `json:"args"`
+}
+
+// loadPublishPayloadFile 从 build-publish-payload 的 JSON 中读取元数据和字节码
+func loadPublishPayloadFile(path string) (*CompiledPackage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取发布负载失败: %v", err)
+	}
+	payload := publishPayloadFile{}
+	err = json.Unmarshal(data, &payload)
+	if err != nil {
+		return nil, fmt.Errorf("解析发布负载失败: %v", err)
+	}
+	if canonicalFunctionId(payload.FunctionId) != canonicalFunctionId(CodeModuleAddress+"::"+CodeModuleName+"::publish_package_txn") {
+		return nil, fmt.Errorf("发布负载调用的是 %s，不是 0x1::code::publish_package_txn", payload.FunctionId)
+	}
+	if len(payload.Args) != 2 {
+		return nil, fmt.Errorf("发布负载应有 2 个参数，实际 %d 个", len(payload.Args))
+	}
+
+	pkg := &CompiledPackage{}
+	var metadataHex string
+	err = json.Unmarshal(payload.Args[0].Value, &metadataHex)
+	if err != nil {
+		return nil, fmt.Errorf("解析包元数据参数失败: %v", err)
+	}
+	pkg.MetadataBCS, err = decodeHexArg(metadataHex)
+	if err != nil {
+		return nil, fmt.Errorf("解析包元数据参数失败: %v", err)
+	}
+	var codeHex []string
+	err = json.Unmarshal(payload.Args[1].Value, &codeHex)
+	if err != nil {
+		return nil, fmt.Errorf("解析字节码参数失败: %v", err)
+	}
+	for i, value := range codeHex {
+		code, err := decodeHexArg(value)
+		if err != nil {
+			return nil, fmt.Errorf("解析第 %d 个模块的字节码失败: %v", i+1, err)
+		}
+		pkg.Code = append(pkg.Code, code)
+	}
+	return pkg, nil
+}
+
+// decodeHexArg 解码 0x 开头的十六进制参数
+func decodeHexArg(value string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(value, "0x"))
+}
+
+// publishedPackage PackageRegistry 中的包在 REST 中的 JSON 形式
+type publishedPackage struct {
+	Name          string `json:"name"`
+	UpgradePolicy struct {
+		Policy uint8 `json:"policy"`
+	} `json:"upgrade_policy"`
+	UpgradeNumber uint64 `json:"upgrade_number"`
+	SourceDigest  string `json:"source_digest"`
+	Modules       []struct {
+		Name string `json:"name"`
+	} `json:"modules"`
+	Deps []struct {
+		Account     string `json:"account"`
+		PackageName string `json:"package_name"`
+	} `json:"deps"`
+}
+
+// GetPublishedPackages 读取账户下 0x1::code::PackageRegistry 中已发布的包，账户未发布过包时返回空列表
+func GetPublishedPackages(client *aptos.Client, address aptos.AccountAddress, ledgerVersion ...uint64) ([]*PackageMetadata, error) {
+	resource, err := client.AccountResource(address, packageRegistryType, ledgerVersion...)
+	if err != nil {
+		if isResourceNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("获取PackageRegistry失败: %v", err)
+	}
+	registry := struct {
+		Packages []publishedPackage `json:"packages"`
+	}{}
+	err = decodeMoveValue(resource["data"], &registry)
+	if err != nil {
+		return nil, fmt.Errorf("解码PackageRegistry失败: %v", err)
+	}
+
+	var packages []*PackageMetadata
+	for _, published := range registry.Packages {
+		metadata := &PackageMetadata{
+			Name:          published.Name,
+			UpgradePolicy: published.UpgradePolicy.Policy,
+			UpgradeNumber: published.UpgradeNumber,
+			SourceDigest:  published.SourceDigest,
+		}
+		for _, module := range published.Modules {
+			metadata.Modules = append(metadata.Modules, module.Name)
+		}
+		for _, dep := range published.Deps {
+			account := aptos.AccountAddress{}
+			err = account.ParseStringRelaxed(dep.Account)
+			if err != nil {
+				return nil, fmt.Errorf("解析包 %s 的依赖地址失败: %v", published.Name, err)
+			}
+			metadata.Deps = append(metadata.Deps, PackageDep{Account: account, PackageName: dep.PackageName})
+		}
+		packages = append(packages, metadata)
+	}
+	return packages, nil
+}
+
+// findPackage 按包名查找
+func findPackage(packages []*PackageMetadata, name string) *PackageMetadata {
+	for _, pkg := range packages {
+		if pkg.Name == name {
+			return pkg
+		}
+	}
+	return nil
+}
+
+// CheckPackageUpgrade 按 0x1::code 发布时的规则检查新包能否发布到已有这些包的账户，返回同名的已发布包(首次发布时为 nil)
+// 检查升级策略不能放宽、不可变的包不能升级、不能删除模块以及模块不能与其他包重名；
+// 字节码层面的兼容性(结构体布局和公开函数签名)由 VM 检查，见 simulatePublish
+func CheckPackageUpgrade(published []*PackageMetadata, pkg *PackageMetadata) (*PackageMetadata, error) {
+	if pkg.UpgradePolicy == upgradePolicyArbitrary {
+		return nil, fmt.Errorf("包 %s 的升级策略为 arbitrary，链上已不允许发布", pkg.Name)
+	}
+	var existing *PackageMetadata
+	for _, other := range published {
+		if other.Name == pkg.Name {
+			existing = other
+			continue
+		}
+		for _, module := range pkg.Modules {
+			if slices.Contains(other.Modules, module) {
+				return nil, fmt.Errorf("模块 %s 已属于链上的包 %s", module, other.Name)
+			}
+		}
+	}
+	if existing == nil {
+		return nil, nil
+	}
+
+	if existing.UpgradePolicy == upgradePolicyImmutable {
+		return nil, fmt.Errorf("链上的包 %s 升级策略为 immutable，不能升级", existing.Name)
+	}
+	if pkg.UpgradePolicy < existing.UpgradePolicy {
+		return nil, fmt.Errorf("包 %s 的升级策略不能从 %s 放宽为 %s", pkg.Name,
+			upgradePolicyName(existing.UpgradePolicy), upgradePolicyName(pkg.UpgradePolicy))
+	}
+	for _, module := range existing.Modules {
+		if !slices.Contains(pkg.Modules, module) {
+			return nil, fmt.Errorf("升级不能删除链上已有的模块 %s", module)
+		}
+	}
+	return existing, nil
+}
+
+// checkPackageDeps 检查非框架地址上的依赖包已经发布，框架包总是存在
+func checkPackageDeps(client *aptos.Client, pkg *PackageMetadata) error {
+	for _, dep := range pkg.Deps {
+		if dep.Account.IsSpecial() {
+			continue
+		}
+		packages, err := GetPublishedPackages(client, dep.Account)
+		if err != nil {
+			return err
+		}
+		if findPackage(packages, dep.PackageName) == nil {
+			return fmt.Errorf("依赖的包 %s 未发布在 %s", dep.PackageName, dep.Account.String())
+		}
+	}
+	return nil
+}
+
+// simulatePublish 模拟发布交易，由 VM 检查字节码与链上版本的兼容性和模块地址是否与发布者一致
+func simulatePublish(client *aptos.Client, account aptos.TransactionSigner, payload aptos.TransactionPayload) error {
+	rawTxn, err := client.BuildTransaction(account.AccountAddress(), payload)
+	if err != nil {
+		return fmt.Errorf("构建发布交易失败: %v", err)
+	}
+	results, err := client.SimulateTransaction(rawTxn, account)
+	if err != nil {
+		return fmt.Errorf("模拟发布失败: %v", err)
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("模拟发布没有返回结果")
+	}
+	if !results[0].Success {
+		return fmt.Errorf("模拟发布失败: %s", results[0].VmStatus)
+	}
+	return nil
+}
+
+// isPackagePublished 账户下已发布了源码摘要相同的同名包
+func isPackagePublished(client *aptos.Client, address aptos.AccountAddress, pkg *PackageMetadata) (bool, error) {
+	packages, err := GetPublishedPackages(client, address)
+	if err != nil {
+		return false, err
+	}
+	existing := findPackage(packages, pkg.Name)
+	return existing != nil && existing.SourceDigest == pkg.SourceDigest, nil
+}
+
+// PublishPackage 检查升级兼容性后通过 0x1::code::publish_package_txn 发布包，
+// 链上已有源码摘要相同的同名包时跳过，返回的交易哈希为空
+func PublishPackage(client *aptos.Client, account aptos.TransactionSigner, pkg *CompiledPackage) (txHash string, existing *PackageMetadata, err error) {
+	address := account.AccountAddress()
+	published, err := GetPublishedPackages(client, address)
+	if err != nil {
+		return "", nil, err
+	}
+	existing, err = CheckPackageUpgrade(published, pkg.Metadata)
+	if err != nil {
+		return "", existing, err
+	}
+	if existing != nil && existing.SourceDigest == pkg.Metadata.SourceDigest {
+		return "", existing, nil
+	}
+	err = checkPackageDeps(client, pkg.Metadata)
+	if err != nil {
+		return "", existing, err
+	}
+
+	payload, err := CodePublishPackageTxnPayload(CodeModuleAddress, pkg.MetadataBCS, pkg.Code)
+	if err != nil {
+		return "", existing, err
+	}
+	err = simulatePublish(client, account, payload)
+	if err != nil {
+		return "", existing, err
+	}
+	txHash, err = submitAndConfirm(client, account, payload, func() (bool, error) {
+		return isPackagePublished(client, address, pkg.Metadata)
+	})
+	return txHash, existing, err
+}
+
+// DeployResult deploy 各步骤的结果，跳过的步骤交易哈希为空
+type DeployResult struct {
+	Package       *PackageMetadata
+	Existing      *PackageMetadata // 链上原有的同名包，首次发布时为 nil
+	PublishTxHash string
+	InitTxHash    string
+}
+
+// checkBridgePackage 检查包中有桥和代币模块，且发布账户就是模块地址(初始化函数要求由 @my_address 签名)
+func checkBridgePackage(publisher aptos.AccountAddress, moduleAddress string, pkg *CompiledPackage) error {
+	module := aptos.AccountAddress{}
+	err := module.ParseStringRelaxed(moduleAddress)
+	if err != nil {
+		return fmt.Errorf("解析模块地址失败: %v", err)
+	}
+	if module != publisher {
+		return fmt.Errorf("模块地址 %s 与发布账户 %s 不一致", module.String(), publisher.String())
+	}
+	for _, name := range []string{BridgeModuleName, TokenModuleName} {
+		if !slices.Contains(pkg.Metadata.Modules, name) {
+			return fmt.Errorf("包 %s 中没有模块 %s", pkg.Metadata.Name, name)
+		}
+	}
+	return nil
+}
+
+// DeployBridge 发布桥合约包并初始化桥，已完成的步骤会跳过，可以重复执行
+func DeployBridge(client *aptos.Client, account aptos.TransactionSigner, moduleAddress string, pkg *CompiledPackage, feeAccount aptos.AccountAddress, fee uint64) (*DeployResult, error) {
+	err := checkBridgePackage(account.AccountAddress(), moduleAddress, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DeployResult{Package: pkg.Metadata}
+	result.PublishTxHash, result.Existing, err = PublishPackage(client, account, pkg)
+	if err != nil {
+		return result, fmt.Errorf("发布包失败: %v", err)
+	}
+
+	result.InitTxHash, err = ensureBridgeInitialized(client, account, moduleAddress, feeAccount, fee)
+	return result, err
+}
+
+// ensureBridgeInitialized 桥尚未初始化时调用 btc_bridgev3::initialize，已初始化时返回空的交易哈希
+// btc_bridgev3::initialize 会同时初始化 TWBTC，因此只在 BridgeConfig 和 BTCCapabilities 都不存在时调用它；
+// 单独执行过 init-twbtc 的地址再调用会中止，这种情况直接报错；管理员未注册TWBTC时先注册，否则初始化会中止
+// fee 为 0 表示不提供初始化参数，桥尚未初始化时报错
+func ensureBridgeInitialized(client *aptos.Client, account aptos.TransactionSigner, moduleAddress string, feeAccount aptos.AccountAddress, fee uint64) (string, error) {
+	bridgeInitialized, err := moduleResourceExists(client, moduleAddress, BridgeConfigStructTag)
+	if err != nil {
+		return "", err
+	}
+	tokenInitialized, err := moduleResourceExists(client, moduleAddress, TokenBTCCapabilitiesStructTag)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case bridgeInitialized && tokenInitialized:
+		return "", nil
+	case bridgeInitialized:
+		return "", fmt.Errorf("BridgeConfig 已存在但 BTCCapabilities 不存在，链上状态不完整")
+	case tokenInitialized:
+		return "", fmt.Errorf("TWBTC已单独初始化(init-twbtc)，btc_bridgev3::initialize 会再次初始化TWBTC并中止，该地址无法再初始化桥")
+	}
+
+	if fee == 0 || feeAccount == (aptos.AccountAddress{}) {
+		return "", fmt.Errorf("桥尚未初始化，需要指定费用账户地址和非零费用")
+	}
+	_, err = ensureTWBTCRegistered(client, account, moduleAddress)
+	if err != nil {
+		return "", fmt.Errorf("初始化前注册管理员TWBTC失败: %v", err)
+	}
+	txHash, err := initBridge(client, account, moduleAddress, feeAccount, fee)
+	if err != nil {
+		return "", fmt.Errorf("初始化桥接失败: %v", err)
+	}
+	tokenInitialized, err = moduleResourceExists(client, moduleAddress, TokenBTCCapabilitiesStructTag)
+	if err != nil {
+		return txHash, err
+	}
+	if !tokenInitialized {
+		return txHash, fmt.Errorf("初始化桥后 BTCCapabilities 仍不存在")
+	}
+	return txHash, nil
+}
+
+// printDeployResult 打印部署结果
+func printDeployResult(result *DeployResult) {
+	pkg := result.Package
+	fmt.Printf("包: %s (升级策略 %s, 模块 %s)\n", pkg.Name, upgradePolicyName(pkg.UpgradePolicy), strings.Join(pkg.Modules, ", "))
+	switch {
+	case result.PublishTxHash != "" && result.Existing != nil:
+		fmt.Printf("升级: 第 %d 次升级, 交易哈希 %s\n", result.Existing.UpgradeNumber+1, result.PublishTxHash)
+	case result.PublishTxHash != "":
+		fmt.Printf("发布: 交易哈希 %s\n", result.PublishTxHash)
+	case result.Existing != nil:
+		fmt.Println("发布: 链上已是相同版本，跳过")
+	}
+	if result.InitTxHash != "" {
+		fmt.Printf("初始化桥: 交易哈希 %s\n", result.InitTxHash)
+	} else {
+		fmt.Println("初始化桥: 已初始化，跳过")
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/bcs"
+)
+
+// encodeTestPackageMetadata 按 package-metadata.bcs 的格式编码，源码、清单和 source_map 为空，
+// 第一个模块带一个 extension，用于覆盖解码时跳过 Some 的分支
+func encodeTestPackageMetadata(metadata *PackageMetadata) []byte {
+	ser := &bcs.Serializer{}
+	ser.WriteString(metadata.Name)
+	ser.U8(metadata.UpgradePolicy)
+	ser.U64(metadata.UpgradeNumber)
+	ser.WriteString(metadata.SourceDigest)
+	ser.WriteBytes(nil) // manifest
+	ser.Uleb128(uint32(len(metadata.Modules)))
+	for i, module := range metadata.Modules {
+		ser.WriteString(module)
+		ser.WriteBytes(nil) // source
+		ser.WriteBytes(nil) // source_map
+		if i == 0 {
+			ser.Uleb128(1)
+			ser.WriteString("0x1::string::String")
+			ser.WriteBytes([]byte{0})
+		} else {
+			ser.Uleb128(0)
+		}
+	}
+	ser.Uleb128(uint32(len(metadata.Deps)))
+	for _, dep := range metadata.Deps {
+		dep.Account.MarshalBCS(ser)
+		ser.WriteString(dep.PackageName)
+	}
+	ser.Uleb128(0) // extension
+	return ser.ToBytes()
+}
+
+// testCompiledPackage 每个模块的字节码只有魔数和模块名，模拟节点不校验字节码内容
+func testCompiledPackage(name string, policy uint8, digest string, modules ...string) *CompiledPackage {
+	metadata := &PackageMetadata{
+		Name:          name,
+		UpgradePolicy: policy,
+		SourceDigest:  digest,
+		Modules:       modules,
+		Deps:          []PackageDep{{Account: aptos.AccountOne, PackageName: "AptosFramework"}},
+	}
+	pkg := &CompiledPackage{Metadata: metadata, MetadataBCS: encodeTestPackageMetadata(metadata)}
+	for _, module := range modules {
+		pkg.Code = append(pkg.Code, append(slices.Clone(moveBytecodeMagic), module...))
+	}
+	return pkg
+}
+
+func TestDecodePackageMetadata(t *testing.T) {
+	pkg := testCompiledPackage("bridge", upgradePolicyCompatible, "DIGEST", TokenModuleName, BridgeModuleName)
+	pkg.Metadata.UpgradeNumber = 3
+	pkg.MetadataBCS = encodeTestPackageMetadata(pkg.Metadata)
+	metadata, err := decodePackageMetadata(pkg.MetadataBCS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Name != "bridge" || metadata.UpgradePolicy != upgradePolicyCompatible || metadata.UpgradeNumber != 3 || metadata.SourceDigest != "DIGEST" ||
+		!slices.Equal(metadata.Modules, []string{TokenModuleName, BridgeModuleName}) || !slices.Equal(metadata.Deps, pkg.Metadata.Deps) {
+		t.Fatalf("解码结果与编码前不一致: %+v", metadata)
+	}
+
+	malformed := []struct {
+		name string
+		data []byte
+	}{
+		{"空", nil},
+		{"截断", pkg.MetadataBCS[:len(pkg.MetadataBCS)-3]},
+		{"末尾多余数据", append(slices.Clone(pkg.MetadataBCS), 0)},
+		{"模块数过大", append(encodeTestPackageMetadata(&PackageMetadata{Name: "bridge"})[:len("bridge")+1+1+8+1+1], 0xff, 0xff, 0x03)},
+	}
+	for _, tc := range malformed {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := decodePackageMetadata(tc.data)
+			if err == nil || !strings.Contains(err.Error(), "解码包元数据失败") {
+				t.Fatalf("格式错误的元数据应解码失败: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckPackageUpgrade(t *testing.T) {
+	published := func(policy uint8, modules ...string) *PackageMetadata {
+		return &PackageMetadata{Name: "bridge", UpgradePolicy: policy, UpgradeNumber: 1, SourceDigest: "OLD", Modules: modules}
+	}
+	other := &PackageMetadata{Name: "other", UpgradePolicy: upgradePolicyCompatible, Modules: []string{"helper"}}
+	tests := []struct {
+		name      string
+		published []*PackageMetadata
+		policy    uint8
+		modules   []string
+		existing  bool
+		err       string
+	}{
+		{name: "首次发布", published: []*PackageMetadata{other}, policy: upgradePolicyCompatible, modules: []string{"token", "bridge"}},
+		{name: "兼容升级", published: []*PackageMetadata{published(upgradePolicyCompatible, "token", "bridge")}, policy: upgradePolicyCompatible, modules: []string{"token", "bridge", "extra"}, existing: true},
+		{name: "收紧为不可变", published: []*PackageMetadata{published(upgradePolicyCompatible, "token", "bridge")}, policy: upgradePolicyImmutable, modules: []string{"token", "bridge"}, existing: true},
+		{name: "不可变的包", published: []*PackageMetadata{published(upgradePolicyImmutable, "token", "bridge")}, policy: upgradePolicyImmutable, modules: []string{"token", "bridge"}, err: "immutable"},
+		{name: "arbitrary", published: nil, policy: upgradePolicyArbitrary, modules: []string{"token", "bridge"}, err: "arbitrary"},
+		{name: "删除模块", published: []*PackageMetadata{published(upgradePolicyCompatible, "token", "bridge")}, policy: upgradePolicyCompatible, modules: []string{"bridge"}, err: "不能删除"},
+		{name: "模块属于其他包", published: []*PackageMetadata{other}, policy: upgradePolicyCompatible, modules: []string{"bridge", "helper"}, err: "已属于链上的包 other"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pkg := &PackageMetadata{Name: "bridge", UpgradePolicy: tc.policy, SourceDigest: "NEW", Modules: tc.modules}
+			existing, err := CheckPackageUpgrade(tc.published, pkg)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("应报错 %q，实际 %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (existing != nil) != tc.existing {
+				t.Fatalf("返回的链上同名包应存在: %v，实际 %+v", tc.existing, existing)
+			}
+		})
+	}
+}
+
+func TestLoadCompiledPackage(t *testing.T) {
+	pkg := testCompiledPackage("bridge", upgradePolicyCompatible, "DIGEST", TokenModuleName, BridgeModuleName)
+	// writeBuildDir 按 aptos move compile --save-metadata 的目录结构写入
+	writeBuildDir := func(t *testing.T, metadataBCS []byte, code map[string][]byte) string {
+		dir := t.TempDir()
+		buildDir := filepath.Join(dir, "build", "bridge")
+		err := os.MkdirAll(filepath.Join(buildDir, "bytecode_modules"), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(buildDir, "package-metadata.bcs"), metadataBCS, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for module, bytecode := range code {
+			err = os.WriteFile(filepath.Join(buildDir, "bytecode_modules", module+".mv"), bytecode, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dir
+	}
+	// writePayload 按 aptos move build-publish-payload 的 JSON 格式写入
+	writePayload := func(t *testing.T, functionId string, metadataBCS []byte, code [][]byte) string {
+		var codeHex []string
+		for _, bytecode := range code {
+			codeHex = append(codeHex, "0x"+hex.EncodeToString(bytecode))
+		}
+		data, err := json.Marshal(map[string]any{
+			"function_id": functionId,
+			"type_args":   []any{},
+			"args": []any{
+				map[string]any{"type": "hex", "value": "0x" + hex.EncodeToString(metadataBCS)},
+				map[string]any{"type": "hex", "value": codeHex},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "publish.json")
+		err = os.WriteFile(path, data, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	code := map[string][]byte{TokenModuleName: pkg.Code[0], BridgeModuleName: pkg.Code[1]}
+
+	valid := map[string]string{
+		"编译目录":   writeBuildDir(t, pkg.MetadataBCS, code),
+		"发布负载文件": writePayload(t, "0x1::code::publish_package_txn", pkg.MetadataBCS, pkg.Code),
+	}
+	for name, path := range valid {
+		t.Run(name, func(t *testing.T) {
+			loaded, err := LoadCompiledPackage(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(loaded.Metadata.Modules, pkg.Metadata.Modules) || !slices.EqualFunc(loaded.Code, pkg.Code, slices.Equal) {
+				t.Fatalf("应按元数据中的模块顺序读取字节码: %+v", loaded.Metadata)
+			}
+		})
+	}
+
+	invalid := []struct {
+		name string
+		path string
+		err  string
+	}{
+		{"元数据格式错误", writeBuildDir(t, pkg.MetadataBCS[:10], code), "解码包元数据失败"},
+		{"缺少字节码", writeBuildDir(t, pkg.MetadataBCS, map[string][]byte{TokenModuleName: pkg.Code[0]}), "读取模块 " + BridgeModuleName},
+		{"不是Move字节码", writeBuildDir(t, pkg.MetadataBCS, map[string][]byte{TokenModuleName: pkg.Code[0], BridgeModuleName: []byte("not bytecode")}), "不是有效的Move字节码"},
+		{"负载调用其他函数", writePayload(t, "0x1::code::publish_package", pkg.MetadataBCS, pkg.Code), "不是 0x1::code::publish_package_txn"},
+		{"负载模块数量不一致", writePayload(t, "0x1::code::publish_package_txn", pkg.MetadataBCS, pkg.Code[:1]), "字节码有 1 个"},
+		{"负载元数据格式错误", writePayload(t, "0x1::code::publish_package_txn", []byte{0xff}, pkg.Code), "解码包元数据失败"},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadCompiledPackage(tc.path)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("应报错 %q，实际 %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestPublishPackageUpgradePolicies(t *testing.T) {
+	node := NewFakeFullnode()
+	t.Cleanup(node.Close)
+	publisher := newTestAccount(t, t.Name()+"-publisher")
+	err := node.CreateAccount(publisher.Address, fakeNodeFundOctas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := node.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publish := func(pkg *CompiledPackage) (string, *PackageMetadata, error) {
+		t.Helper()
+		txHash, existing, err := PublishPackage(client, publisher, pkg)
+		if err == nil && txHash != "" {
+			published, _ := isPackagePublished(client, publisher.Address, pkg.Metadata)
+			if !published {
+				t.Fatalf("发布后链上应有包 %s (%s)", pkg.Metadata.Name, pkg.Metadata.SourceDigest)
+			}
+		}
+		return txHash, existing, err
+	}
+
+	txHash, existing, err := publish(testCompiledPackage("bridge", upgradePolicyCompatible, "V1", TokenModuleName, BridgeModuleName))
+	if err != nil || txHash == "" || existing != nil {
+		t.Fatalf("首次发布应提交交易: %v", err)
+	}
+	txHash, existing, err = publish(testCompiledPackage("bridge", upgradePolicyCompatible, "V1", TokenModuleName, BridgeModuleName))
+	if err != nil || txHash != "" || existing == nil {
+		t.Fatalf("源码摘要相同应跳过发布: %v", err)
+	}
+	txHash, existing, err = publish(testCompiledPackage("bridge", upgradePolicyImmutable, "V2", TokenModuleName, BridgeModuleName))
+	if err != nil || txHash == "" || existing == nil || existing.UpgradeNumber != 0 {
+		t.Fatalf("兼容策略的包应能升级为不可变: %v", err)
+	}
+	packages, err := GetPublishedPackages(client, publisher.Address)
+	if err != nil || len(packages) != 1 || packages[0].UpgradeNumber != 1 || packages[0].UpgradePolicy != upgradePolicyImmutable {
+		t.Fatalf("升级后链上应为第 1 次升级的不可变包: %+v: %v", packages, err)
+	}
+	_, _, err = publish(testCompiledPackage("bridge", upgradePolicyImmutable, "V3", TokenModuleName, BridgeModuleName))
+	if err == nil || !strings.Contains(err.Error(), "immutable") {
+		t.Fatalf("不可变的包不应能升级: %v", err)
+	}
+	_, _, err = publish(testCompiledPackage("other", upgradePolicyCompatible, "V1", BridgeModuleName))
+	if err == nil || !strings.Contains(err.Error(), "已属于链上的包 bridge") {
+		t.Fatalf("模块名与已发布的包冲突时应拒绝: %v", err)
+	}
+}
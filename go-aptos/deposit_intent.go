@@ -0,0 +1,699 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+const (
+	// 存款意图映射的默认状态文件
+	defaultDepositIntentFile = "deposit_intents.json"
+
+	// 按 Aptos 地址派生存款密钥和备注标签的标签哈希前缀
+	depositIntentTweakTag = "TWBTC/DepositTweak"
+	depositIntentMemoTag  = "TWBTC/DepositMemo"
+
+	// OP_RETURN 备注的格式: "twbtc" + 8字节标签
+	depositMemoPrefix  = "twbtc"
+	depositMemoTagSize = 8
+)
+
+// 存款匹配到接收者的方式
+const (
+	DepositViaAddress = "address" // 支付到用户专属存款地址
+	DepositViaMemo    = "memo"    // 支付到桥地址并附带用户的 OP_RETURN 备注标签
+)
+
+// DepositIntentPolicy 存款意图的配置
+type DepositIntentPolicy struct {
+	BridgePubKey string // 桥密钥的公钥，33字节压缩格式或32字节 x-only，十六进制
+	StateFile    string // 存款地址和备注标签到接收者的映射
+}
+
+// loadDepositIntentPolicy 读取存款意图配置，桥公钥来自 BTC_BRIDGE_PUBKEY，未设置时由 BTC_BRIDGE_PRIVATE_KEY 计算；DEPOSIT_INTENT_FILE 可覆盖状态文件
+func loadDepositIntentPolicy() (DepositIntentPolicy, error) {
+	policy := DepositIntentPolicy{BridgePubKey: os.Getenv("BTC_BRIDGE_PUBKEY"), StateFile: defaultDepositIntentFile}
+	if path := os.Getenv("DEPOSIT_INTENT_FILE"); path != "" {
+		policy.StateFile = path
+	}
+	if policy.BridgePubKey != "" {
+		return policy, nil
+	}
+	keyHex := os.Getenv("BTC_BRIDGE_PRIVATE_KEY")
+	if keyHex == "" {
+		return policy, fmt.Errorf("错误: 缺少桥公钥。请设置BTC_BRIDGE_PUBKEY环境变量")
+	}
+	keyBytes, err := hex.DecodeString(keyHex)
+	if err != nil || len(keyBytes) != 32 {
+		return policy, fmt.Errorf("错误: 请设置32字节十六进制的BTC_BRIDGE_PRIVATE_KEY环境变量")
+	}
+	_, pubKey := btcec.PrivKeyFromBytes(keyBytes)
+	policy.BridgePubKey = hex.EncodeToString(schnorr.SerializePubKey(pubKey))
+	return policy, nil
+}
+
+// parseBridgePubKey 解析桥公钥并规范为 y 为偶数的内部密钥
+func parseBridgePubKey(keyHex string) (*btcec.PublicKey, error) {
+	keyBytes, err := hex.DecodeString(keyHex)
+	if err != nil {
+		return nil, fmt.Errorf("解析桥公钥失败: %v", err)
+	}
+	switch len(keyBytes) {
+	case 32:
+	case 33:
+		pubKey, err := btcec.ParsePubKey(keyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析桥公钥失败: %v", err)
+		}
+		keyBytes = schnorr.SerializePubKey(pubKey)
+	default:
+		return nil, fmt.Errorf("桥公钥长度应为32或33字节，实际 %d 字节", len(keyBytes))
+	}
+	pubKey, err := schnorr.ParsePubKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析桥公钥失败: %v", err)
+	}
+	return pubKey, nil
+}
+
+// tweakXOnlyPubKey 计算 lift_x(xonly) + tweak*G
+func tweakXOnlyPubKey(xonly []byte, tweak [32]byte) (*btcec.PublicKey, error) {
+	pubKey, err := schnorr.ParsePubKey(xonly)
+	if err != nil {
+		return nil, err
+	}
+	var t btcec.ModNScalar
+	if t.SetBytes(&tweak) != 0 {
+		return nil, fmt.Errorf("调整值超出曲线阶")
+	}
+	var point, tweakPoint, result btcec.JacobianPoint
+	pubKey.AsJacobian(&point)
+	btcec.ScalarBaseMultNonConst(&t, &tweakPoint)
+	btcec.AddNonConst(&point, &tweakPoint, &result)
+	if (result.X.IsZero() && result.Y.IsZero()) || result.Z.IsZero() {
+		return nil, fmt.Errorf("调整后的公钥为无穷远点")
+	}
+	result.ToAffine()
+	return btcec.NewPublicKey(&result.X, &result.Y), nil
+}
+
+// taprootOutputKey 按 BIP86 (无脚本树) 计算内部公钥对应的 P2TR 输出公钥，与 taprootTweakPrivKey 对应
+func taprootOutputKey(internalKey *btcec.PublicKey) (*btcec.PublicKey, error) {
+	xonly := schnorr.SerializePubKey(internalKey)
+	return tweakXOnlyPubKey(xonly, taggedHash("TapTweak", xonly))
+}
+
+// taprootKeyAddress 内部公钥对应的 P2TR 地址
+func taprootKeyAddress(internalKey *btcec.PublicKey, network BtcNetwork) (string, error) {
+	outputKey, err := taprootOutputKey(internalKey)
+	if err != nil {
+		return "", err
+	}
+	return encodeSegwitAddress(network.Bech32HRP, 1, schnorr.SerializePubKey(outputKey))
+}
+
+// depositIntentTweak 接收者在桥密钥上的调整值，只有知道桥公钥的一方才能把地址关联到接收者
+func depositIntentTweak(bridgeKey *btcec.PublicKey, receiver aptos.AccountAddress) [32]byte {
+	return taggedHash(depositIntentTweakTag, schnorr.SerializePubKey(bridgeKey), receiver[:])
+}
+
+// DeriveDepositAddress 派生接收者的专属存款地址: 内部公钥为 P + t*G，t 由桥公钥和 Aptos 地址的标签哈希得到，再按 BIP86 生成 P2TR 地址
+func DeriveDepositAddress(bridgeKey *btcec.PublicKey, receiver aptos.AccountAddress, network BtcNetwork) (string, error) {
+	userKey, err := tweakXOnlyPubKey(schnorr.SerializePubKey(bridgeKey), depositIntentTweak(bridgeKey, receiver))
+	if err != nil {
+		return "", fmt.Errorf("派生存款公钥失败: %v", err)
+	}
+	return taprootKeyAddress(userKey, network)
+}
+
+// DepositIntentPrivKey 由桥私钥派生专属存款地址的内部私钥。出账交易花费专属地址上的 UTXO 时，
+// BuildRedeemPayout 在输入上记录调整值，SignRedeemPayout 按同样的方式派生签名密钥
+func DepositIntentPrivKey(bridgeKey *btcec.PrivateKey, receiver aptos.AccountAddress) *btcec.PrivateKey {
+	return tweakXOnlyPrivKey(bridgeKey, depositIntentTweak(bridgeKey.PubKey(), receiver))
+}
+
+// DeriveDepositMemoTag 派生接收者的备注标签(十六进制)，向桥地址存款时放在 OP_RETURN 输出中
+func DeriveDepositMemoTag(bridgeKey *btcec.PublicKey, receiver aptos.AccountAddress) string {
+	hash := taggedHash(depositIntentMemoTag, schnorr.SerializePubKey(bridgeKey), receiver[:])
+	return hex.EncodeToString(hash[:depositMemoTagSize])
+}
+
+// depositMemoScript 备注标签对应的 OP_RETURN 输出脚本
+func depositMemoScript(memoTag string) ([]byte, error) {
+	tag, err := hex.DecodeString(memoTag)
+	if err != nil || len(tag) != depositMemoTagSize {
+		return nil, fmt.Errorf("无效的备注标签 %s: 应为 %d 字节十六进制", memoTag, depositMemoTagSize)
+	}
+	payload := append([]byte(depositMemoPrefix), tag...)
+	return append([]byte{0x6a, byte(len(payload))}, payload...), nil
+}
+
+// parseDepositMemo 从 OP_RETURN 输出脚本中读取备注标签，不是存款备注时返回 false
+func parseDepositMemo(script []byte) (string, bool) {
+	size := len(depositMemoPrefix) + depositMemoTagSize
+	if len(script) != 2+size || script[0] != 0x6a || int(script[1]) != size || !bytes.HasPrefix(script[2:], []byte(depositMemoPrefix)) {
+		return "", false
+	}
+	return hex.EncodeToString(script[2+len(depositMemoPrefix):]), true
+}
+
+// DepositIntent 一个接收者的存款方式，专属地址和备注标签都由桥公钥确定性派生
+type DepositIntent struct {
+	Receiver  string `json:"receiver"`
+	Address   string `json:"address"`
+	MemoTag   string `json:"memo_tag"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// depositIntentState 存款意图状态文件的内容，记录桥公钥和网络以防换钥后误用旧映射
+type depositIntentState struct {
+	BridgePubKey string           `json:"bridge_pubkey"`
+	Network      string           `json:"network"`
+	Intents      []*DepositIntent `json:"intents"`
+}
+
+// DepositIntentStore 存款地址和备注标签到 Aptos 接收者的映射
+type DepositIntentStore struct {
+	bridgeKey    *btcec.PublicKey
+	network      BtcNetwork
+	bridgeScript []byte
+	path         string // 为空时不保存
+	state        depositIntentState
+	byScript     map[string]*DepositIntent
+	byTag        map[string]*DepositIntent
+}
+
+// NewDepositIntentStore 创建空的存款意图映射，不读取状态文件
+func NewDepositIntentStore(bridgeKey *btcec.PublicKey, network BtcNetwork, path string) (*DepositIntentStore, error) {
+	bridgeAddress, err := taprootKeyAddress(bridgeKey, network)
+	if err != nil {
+		return nil, err
+	}
+	bridgeScript, err := btcAddressScript(bridgeAddress, network)
+	if err != nil {
+		return nil, err
+	}
+	return &DepositIntentStore{
+		bridgeKey:    bridgeKey,
+		network:      network,
+		bridgeScript: bridgeScript,
+		path:         path,
+		state:        depositIntentState{BridgePubKey: hex.EncodeToString(schnorr.SerializePubKey(bridgeKey)), Network: network.Name},
+		byScript:     make(map[string]*DepositIntent),
+		byTag:        make(map[string]*DepositIntent),
+	}, nil
+}
+
+// LoadDepositIntentStore 读取存款意图映射，文件记录的桥公钥或网络与当前配置不同时报错
+func LoadDepositIntentStore(bridgeKey *btcec.PublicKey, network BtcNetwork, path string) (*DepositIntentStore, error) {
+	s, err := NewDepositIntentStore(bridgeKey, network, path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取存款意图文件失败: %v", err)
+	}
+	state := depositIntentState{}
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return nil, fmt.Errorf("解析存款意图文件失败: %v", err)
+	}
+	if state.BridgePubKey != s.state.BridgePubKey || state.Network != s.state.Network {
+		return nil, fmt.Errorf("存款意图文件 %s 属于桥公钥 %s (%s 网络)，当前配置为 %s (%s 网络)",
+			path, state.BridgePubKey, state.Network, s.state.BridgePubKey, s.state.Network)
+	}
+	for _, intent := range state.Intents {
+		err = s.add(intent)
+		if err != nil {
+			return nil, fmt.Errorf("存款意图文件 %s 无效: %v", path, err)
+		}
+	}
+	return s, nil
+}
+
+// add 校验意图与桥公钥派生的结果一致后加入索引
+func (s *DepositIntentStore) add(intent *DepositIntent) error {
+	receiver := aptos.AccountAddress{}
+	err := receiver.ParseStringRelaxed(intent.Receiver)
+	if err != nil {
+		return fmt.Errorf("接收地址 %s 无效: %v", intent.Receiver, err)
+	}
+	address, err := DeriveDepositAddress(s.bridgeKey, receiver, s.network)
+	if err != nil {
+		return err
+	}
+	if address != intent.Address || DeriveDepositMemoTag(s.bridgeKey, receiver) != intent.MemoTag {
+		return fmt.Errorf("接收者 %s 的存款地址或备注标签与桥公钥派生的结果不一致", intent.Receiver)
+	}
+	script, err := btcAddressScript(intent.Address, s.network)
+	if err != nil {
+		return err
+	}
+	if s.byScript[string(script)] != nil || s.byTag[intent.MemoTag] != nil {
+		return fmt.Errorf("接收者 %s 重复", intent.Receiver)
+	}
+	s.byScript[string(script)] = intent
+	s.byTag[intent.MemoTag] = intent
+	s.state.Intents = append(s.state.Intents, intent)
+	return nil
+}
+
+// Save 写入状态文件
+func (s *DepositIntentStore) Save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(&s.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化存款意图失败: %v", err)
+	}
+	err = writeFileAtomic(s.path, data)
+	if err != nil {
+		return fmt.Errorf("写入存款意图文件失败: %v", err)
+	}
+	return nil
+}
+
+// BridgeAddress 桥公钥对应的 BIP86 P2TR 地址，备注标签方式的存款支付到这里
+func (s *DepositIntentStore) BridgeAddress() string {
+	address, _ := taprootKeyAddress(s.bridgeKey, s.network)
+	return address
+}
+
+// Intents 返回所有存款意图
+func (s *DepositIntentStore) Intents() []*DepositIntent {
+	return s.state.Intents
+}
+
+// spendTweak 输出脚本属于已签发的专属存款地址时，返回该意图和花费时加到桥内部私钥上的调整值，否则返回 nil
+func (s *DepositIntentStore) spendTweak(script []byte) (*DepositIntent, [32]byte) {
+	intent := s.byScript[string(script)]
+	if intent == nil {
+		return nil, [32]byte{}
+	}
+	receiver := aptos.AccountAddress{}
+	if receiver.ParseStringRelaxed(intent.Receiver) != nil {
+		return nil, [32]byte{}
+	}
+	return intent, depositIntentTweak(s.bridgeKey, receiver)
+}
+
+// Intent 按接收者查找存款意图
+func (s *DepositIntentStore) Intent(receiver aptos.AccountAddress) *DepositIntent {
+	for _, intent := range s.state.Intents {
+		if intent.Receiver == receiver.String() {
+			return intent
+		}
+	}
+	return nil
+}
+
+// Issue 为接收者签发存款地址和备注标签并保存，已签发过时返回原来的意图，created 为 false
+func (s *DepositIntentStore) Issue(receiver aptos.AccountAddress) (intent *DepositIntent, created bool, err error) {
+	if intent := s.Intent(receiver); intent != nil {
+		return intent, false, nil
+	}
+	address, err := DeriveDepositAddress(s.bridgeKey, receiver, s.network)
+	if err != nil {
+		return nil, false, err
+	}
+	intent = &DepositIntent{
+		Receiver:  receiver.String(),
+		Address:   address,
+		MemoTag:   DeriveDepositMemoTag(s.bridgeKey, receiver),
+		CreatedAt: time.Now().Unix(),
+	}
+	err = s.add(intent)
+	if err != nil {
+		return nil, false, err
+	}
+	return intent, true, s.Save()
+}
+
+// DepositResolution 一笔比特币交易解析出的存款
+type DepositResolution struct {
+	BtcTxId  string
+	Receiver string
+	Amount   uint64 // 支付到专属地址和带备注支付到桥地址的金额之和
+	Via      []string
+}
+
+// Resolve 按输出脚本和 OP_RETURN 备注把交易解析为存款。与任何意图无关的交易(如出账找零)返回 nil；
+// 无法自动铸币的交易返回错误: 向多个接收者存款、备注标签未知或重复、金额不超过桥费用或铸币手续费
+func (s *DepositIntentStore) Resolve(tx *BtcTx, bridgeFee uint64) (*DepositResolution, error) {
+	amounts := map[string]uint64{}
+	var receivers, via, memoTags []string
+	credit := func(intent *DepositIntent, value uint64, method string) {
+		if _, ok := amounts[intent.Receiver]; !ok {
+			receivers = append(receivers, intent.Receiver)
+		}
+		amounts[intent.Receiver] += value
+		if len(via) == 0 || via[len(via)-1] != method {
+			via = append(via, method)
+		}
+	}
+	var bridgeAmount uint64
+	for _, out := range tx.Outputs {
+		if intent := s.byScript[string(out.PkScript)]; intent != nil {
+			credit(intent, out.Value, DepositViaAddress)
+		} else if bytes.Equal(out.PkScript, s.bridgeScript) {
+			bridgeAmount += out.Value
+		} else if tag, ok := parseDepositMemo(out.PkScript); ok {
+			memoTags = append(memoTags, tag)
+		}
+	}
+
+	switch {
+	case len(memoTags) > 1:
+		return nil, fmt.Errorf("交易包含 %d 个备注标签", len(memoTags))
+	case len(memoTags) == 1:
+		intent := s.byTag[memoTags[0]]
+		if intent == nil {
+			return nil, fmt.Errorf("未知的备注标签 %s", memoTags[0])
+		}
+		if bridgeAmount == 0 {
+			return nil, fmt.Errorf("交易带有备注标签 %s 但没有支付到桥地址", memoTags[0])
+		}
+		credit(intent, bridgeAmount, DepositViaMemo)
+	}
+
+	if len(receivers) == 0 {
+		return nil, nil
+	}
+	if len(receivers) > 1 {
+		return nil, fmt.Errorf("交易同时向 %d 个接收者存款，需要人工处理", len(receivers))
+	}
+	resolution := &DepositResolution{BtcTxId: tx.TxId(), Receiver: receivers[0], Amount: amounts[receivers[0]], Via: via}
+	// 桥的 mint 要求金额大于桥费用，代币合约再从中扣除铸币手续费
+	if resolution.Amount <= max(bridgeFee, twbtcTokenMintFee) {
+		return nil, fmt.Errorf("存款金额 %d 不超过桥费用 %d 或铸币手续费 %d，无法铸币", resolution.Amount, bridgeFee, twbtcTokenMintFee)
+	}
+	return resolution, nil
+}
+
+// DepositScanSkip 扫描时无法自动处理的交易
+type DepositScanSkip struct {
+	BtcTxId string
+	Reason  string
+}
+
+// DepositScanResult 一次扫描的结果
+type DepositScanResult struct {
+	Observed []*DepositResolution
+	Skipped  []DepositScanSkip
+}
+
+// Scan 查询所有专属地址和桥地址上的 UTXO，把解析出的新存款交给确认策略引擎跟踪，之后由 ProcessDeposits 铸币。
+// 只能看到未花费的输出，扫描间隔应短于把存款归集走的间隔。bridgeFee 为链上当前的桥费用
+func (s *DepositIntentStore) Scan(backend BitcoinBackend, engine *FinalityEngine, bridgeFee uint64) (*DepositScanResult, error) {
+	result := &DepositScanResult{}
+	if len(s.state.Intents) == 0 {
+		return result, nil
+	}
+	addresses := []string{s.BridgeAddress()}
+	for _, intent := range s.state.Intents {
+		addresses = append(addresses, intent.Address)
+	}
+	seen := map[string]bool{}
+	var txids []string
+	for _, address := range addresses {
+		utxos, err := backend.AddressUtxos(address)
+		if err != nil {
+			return nil, fmt.Errorf("查询地址 %s 的UTXO失败: %v", address, err)
+		}
+		for _, utxo := range utxos {
+			if !seen[utxo.TxId] {
+				seen[utxo.TxId] = true
+				txids = append(txids, utxo.TxId)
+			}
+		}
+	}
+
+	for _, txid := range txids {
+		if engine.Deposit(txid) != nil {
+			continue
+		}
+		info, err := backend.Transaction(txid)
+		if err != nil {
+			return nil, fmt.Errorf("查询BTC交易 %s 失败: %v", txid, err)
+		}
+		if info.Tx == nil {
+			result.Skipped = append(result.Skipped, DepositScanSkip{BtcTxId: txid, Reason: "比特币后端没有返回原始交易"})
+			continue
+		}
+		resolution, err := s.Resolve(info.Tx, bridgeFee)
+		if err != nil {
+			result.Skipped = append(result.Skipped, DepositScanSkip{BtcTxId: txid, Reason: err.Error()})
+			continue
+		}
+		if resolution == nil {
+			continue
+		}
+		_, err = engine.Observe(txid, resolution.Receiver, resolution.Amount)
+		if err != nil {
+			return result, fmt.Errorf("跟踪存款 %s 失败: %v", txid, err)
+		}
+		result.Observed = append(result.Observed, resolution)
+	}
+	return result, nil
+}
+
+// loadPayoutDepositIntents 存款意图文件存在时读取，供出账交易花费专属存款地址上的输出；没有文件时返回 nil
+func loadPayoutDepositIntents(network BtcNetwork) (*DepositIntentStore, error) {
+	path := defaultDepositIntentFile
+	if env := os.Getenv("DEPOSIT_INTENT_FILE"); env != "" {
+		path = env
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	policy, err := loadDepositIntentPolicy()
+	if err != nil {
+		return nil, err
+	}
+	bridgeKey, err := parseBridgePubKey(policy.BridgePubKey)
+	if err != nil {
+		return nil, err
+	}
+	return LoadDepositIntentStore(bridgeKey, network, policy.StateFile)
+}
+
+// loadDepositBridgeClient 创建只读客户端并读取链上当前的桥费用，解析存款时用于判断金额能否铸币
+func loadDepositBridgeClient() (*aptos.Client, string, uint64, error) {
+	moduleAddress, err := getModuleAddress()
+	if err != nil {
+		return nil, "", 0, err
+	}
+	client, err := createClient()
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("创建客户端失败: %v", err)
+	}
+	bridgeFee, err := getBridgeFee(client, moduleAddress)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return client, moduleAddress, bridgeFee, nil
+}
+
+// printDepositIntent 打印一个存款意图
+func printDepositIntent(intent *DepositIntent, bridgeAddress string) {
+	fmt.Printf("接收者: %s\n", intent.Receiver)
+	fmt.Printf("  专属存款地址: %s\n", intent.Address)
+	fmt.Printf("  备注标签: %s (向 %s 存款时在 OP_RETURN 中写入 %q + 标签字节)\n", intent.MemoTag, bridgeAddress, depositMemoPrefix)
+}
+
+// printDepositScanResult 打印扫描结果
+func printDepositScanResult(result *DepositScanResult) {
+	for _, resolution := range result.Observed {
+		logSuccess(fmt.Sprintf("存款 %s 解析为 %s 的 %d satoshi (%v)，开始跟踪确认数", resolution.BtcTxId, resolution.Receiver, resolution.Amount, resolution.Via))
+	}
+	for _, skip := range result.Skipped {
+		logWarning(fmt.Sprintf("跳过 %s: %s", skip.BtcTxId, skip.Reason))
+	}
+	if len(result.Observed) == 0 && len(result.Skipped) == 0 {
+		fmt.Println("没有新的存款")
+	}
+}
+
+// printDepositIntentUsage 打印存款意图命令的帮助
+func printDepositIntentUsage() {
+	fmt.Println("存款意图用法(BTC_BRIDGE_PUBKEY 桥公钥或 BTC_BRIDGE_PRIVATE_KEY 桥私钥, DEPOSIT_INTENT_FILE 映射文件, BTC_NETWORK 比特币网络, BTC_BACKEND 比特币后端):")
+	fmt.Println("  为接收者签发专属存款地址和备注标签: ./main deposit-intent new <Aptos地址>")
+	fmt.Println("  查看已签发的存款意图: ./main deposit-intent list")
+	fmt.Println("  解析一笔BTC交易的接收者: ./main deposit-intent resolve <btc_tx_id>")
+	fmt.Println("  扫描新存款并交给 btc-deposit 跟踪(加 process 时同时为确认数足够的存款铸币，需PRIVATE_KEY): ./main deposit-intent scan [process]")
+}
+
+// runDepositIntentCommand 处理 deposit-intent 子命令，resolve 和 scan 从链上读取桥费用，只有 scan process 需要 Aptos 私钥
+func runDepositIntentCommand(args []string) {
+	if len(args) < 1 {
+		printDepositIntentUsage()
+		os.Exit(1)
+	}
+
+	policy, err := loadDepositIntentPolicy()
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+	bridgeKey, err := parseBridgePubKey(policy.BridgePubKey)
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+	network, err := loadBtcNetwork()
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+	store, err := LoadDepositIntentStore(bridgeKey, network, policy.StateFile)
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "new":
+		if len(args) < 2 {
+			printDepositIntentUsage()
+			os.Exit(1)
+		}
+		receiver := aptos.AccountAddress{}
+		err := receiver.ParseStringRelaxed(args[1])
+		if err != nil {
+			logError(fmt.Sprintf("解析接收地址失败: %v", err))
+			os.Exit(1)
+		}
+		intent, created, err := store.Issue(receiver)
+		if err != nil {
+			logError(fmt.Sprintf("签发存款意图失败: %v", err))
+			os.Exit(1)
+		}
+		printDepositIntent(intent, store.BridgeAddress())
+		if created {
+			logSuccess(fmt.Sprintf("已签发并写入 %s", policy.StateFile))
+		} else {
+			logInfo("该接收者已签发过，返回原有的存款地址和备注标签")
+		}
+
+	case "list":
+		fmt.Printf("桥地址: %s\n", store.BridgeAddress())
+		if len(store.Intents()) == 0 {
+			fmt.Println("没有已签发的存款意图")
+		}
+		for _, intent := range store.Intents() {
+			printDepositIntent(intent, store.BridgeAddress())
+		}
+
+	case "resolve":
+		if len(args) < 2 {
+			printDepositIntentUsage()
+			os.Exit(1)
+		}
+		backend, err := newBitcoinBackend()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		info, err := backend.Transaction(args[1])
+		if err != nil {
+			logError(fmt.Sprintf("查询BTC交易失败: %v", err))
+			os.Exit(1)
+		}
+		if info.Tx == nil {
+			logError(fmt.Sprintf("比特币后端找不到交易 %s 的原始数据", args[1]))
+			os.Exit(1)
+		}
+		_, _, bridgeFee, err := loadDepositBridgeClient()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		resolution, err := store.Resolve(info.Tx, bridgeFee)
+		if err != nil {
+			logError(fmt.Sprintf("无法自动解析: %v", err))
+			os.Exit(1)
+		}
+		if resolution == nil {
+			logWarning("交易没有支付到任何专属存款地址，也没有带备注支付到桥地址")
+			return
+		}
+		fmt.Printf("接收者: %s\n金额: %d\n匹配方式: %v\n确认数: %d\n", resolution.Receiver, resolution.Amount, resolution.Via, info.Confirmations)
+
+	case "scan":
+		backend, err := newBitcoinBackend()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		finalityPolicy, err := loadFinalityPolicy()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		engine, err := LoadFinalityEngine(backend, finalityPolicy)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		client, moduleAddress, bridgeFee, err := loadDepositBridgeClient()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		result, err := store.Scan(backend, engine, bridgeFee)
+		if result != nil {
+			printDepositScanResult(result)
+		}
+		if saveErr := engine.Save(); saveErr != nil {
+			logError(saveErr.Error())
+			os.Exit(1)
+		}
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		if len(args) < 2 || args[1] != "process" {
+			printFinalityStatus(engine)
+			return
+		}
+
+		privateKey := os.Getenv("PRIVATE_KEY")
+		if privateKey == "" {
+			logError("错误: 缺少私钥。请设置PRIVATE_KEY环境变量")
+			os.Exit(1)
+		}
+		account, err := createSigningAccount(privateKey)
+		if err != nil {
+			logError(fmt.Sprintf("创建账户失败: %v", err))
+			os.Exit(1)
+		}
+		minted, err := engine.ProcessDeposits(client, account, moduleAddress)
+		for _, deposit := range minted {
+			logSuccess(fmt.Sprintf("存款 %s 已铸币 %d 给 %s，交易哈希: %s", deposit.BtcTxId, deposit.Amount, deposit.Receiver, deposit.MintTxHash))
+		}
+		printFinalityStatus(engine)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+
+	default:
+		logError(fmt.Sprintf("未知的deposit-intent子命令: %s", args[0]))
+		printDepositIntentUsage()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/hex"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// newTestDepositIntents 用固定的桥密钥创建模拟链上的存款意图映射，并为 receivers 签发存款意图
+func newTestDepositIntents(t *testing.T, chain *FakeBtcChain, receivers ...*aptos.Account) (*btcec.PrivateKey, *DepositIntentStore, string) {
+	t.Helper()
+	bridgeKey, _ := btcec.PrivKeyFromBytes(sha256Bytes("bridge"))
+	bridgePubKey, err := parseBridgePubKey(hex.EncodeToString(bridgeKey.PubKey().SerializeCompressed()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "deposit_intents.json")
+	store, err := LoadDepositIntentStore(bridgePubKey, chain.network, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, receiver := range receivers {
+		_, created, err := store.Issue(receiver.Address)
+		if err != nil || !created {
+			t.Fatalf("应为 %s 签发新的存款意图: %v", receiver.Address.String(), err)
+		}
+	}
+	return bridgeKey, store, path
+}
+
+// testIntentScript 接收者专属存款地址的输出脚本
+func testIntentScript(t *testing.T, store *DepositIntentStore, receiver *aptos.Account) []byte {
+	t.Helper()
+	script, err := btcAddressScript(store.Intent(receiver.Address).Address, store.network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return script
+}
+
+func TestDepositIntentIssueAndReload(t *testing.T) {
+	chain := NewFakeBtcChain()
+	alice := newTestAccount(t, "deposit-intent-alice")
+	bob := newTestAccount(t, "deposit-intent-bob")
+	bridgeKey, store, path := newTestDepositIntents(t, chain, alice, bob)
+	aliceIntent, bobIntent := store.Intent(alice.Address), store.Intent(bob.Address)
+
+	again, created, err := store.Issue(alice.Address)
+	if err != nil || created || again != aliceIntent || len(store.Intents()) != 2 {
+		t.Fatalf("重复签发应返回原有的存款意图")
+	}
+	if aliceIntent.Address == bobIntent.Address || aliceIntent.MemoTag == bobIntent.MemoTag || aliceIntent.Address == store.BridgeAddress() {
+		t.Fatalf("不同接收者的存款地址和备注标签应互不相同")
+	}
+	parsed, err := ParseBtcAddress(aliceIntent.Address, chain.network)
+	if err != nil || parsed.Type != BtcScriptP2TR {
+		t.Fatalf("专属存款地址应为 P2TR: %v", err)
+	}
+	for _, account := range []*aptos.Account{alice, bob} {
+		outputKey := taprootTweakPrivKey(DepositIntentPrivKey(bridgeKey, account.Address)).PubKey()
+		address, err := encodeSegwitAddress(chain.network.Bech32HRP, 1, schnorr.SerializePubKey(outputKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if address != store.Intent(account.Address).Address {
+			t.Fatalf("由桥私钥派生的地址 %s 与签发的地址 %s 不一致", address, store.Intent(account.Address).Address)
+		}
+	}
+
+	reloaded, err := LoadDepositIntentStore(store.bridgeKey, chain.network, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intent := reloaded.Intent(bob.Address); intent == nil || intent.Address != bobIntent.Address || intent.MemoTag != bobIntent.MemoTag {
+		t.Fatalf("重新读取后应保留 bob 的存款意图")
+	}
+	otherKey, _ := btcec.PrivKeyFromBytes(sha256Bytes("deposit-intent-other"))
+	if _, err := LoadDepositIntentStore(otherKey.PubKey(), chain.network, path); err == nil {
+		t.Fatalf("换用其他桥公钥读取映射文件应报错")
+	}
+	if _, err := LoadDepositIntentStore(store.bridgeKey, btcNetworks["mainnet"], path); err == nil {
+		t.Fatalf("换用其他网络读取映射文件应报错")
+	}
+}
+
+func TestDepositIntentResolve(t *testing.T) {
+	chain := NewFakeBtcChain()
+	alice := newTestAccount(t, "deposit-intent-alice")
+	bob := newTestAccount(t, "deposit-intent-bob")
+	_, store, _ := newTestDepositIntents(t, chain, alice, bob)
+	bridgeScript, err := btcAddressScript(store.BridgeAddress(), chain.network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliceScript, bobScript := testIntentScript(t, store, alice), testIntentScript(t, store, bob)
+	bobMemo, err := depositMemoScript(store.Intent(bob.Address).MemoTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, _ := btcec.PrivKeyFromBytes(sha256Bytes("deposit-intent-other"))
+	unknownMemo, err := depositMemoScript(DeriveDepositMemoTag(otherKey.PubKey(), bob.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 桥费用高于铸币手续费时，金额介于两者之间的存款也无法铸币
+	const bridgeFee = 2 * twbtcTokenMintFee
+	for _, expected := range []struct {
+		name     string
+		txid     string
+		receiver string
+		amount   uint64
+		via      []string
+		err      string
+	}{
+		{name: "专属地址", txid: chain.Fund(aliceScript, 50000), receiver: alice.Address.String(), amount: 50000, via: []string{DepositViaAddress}},
+		{name: "备注标签", txid: chain.FundOutputs(BtcTxOut{Value: 40000, PkScript: bridgeScript}, BtcTxOut{PkScript: bobMemo}), receiver: bob.Address.String(), amount: 40000, via: []string{DepositViaMemo}},
+		{name: "专属地址加备注", txid: chain.FundOutputs(BtcTxOut{Value: 30000, PkScript: bobScript}, BtcTxOut{Value: 20000, PkScript: bridgeScript}, BtcTxOut{PkScript: bobMemo}), receiver: bob.Address.String(), amount: 50000, via: []string{DepositViaAddress, DepositViaMemo}},
+		{name: "同时存给两人", txid: chain.FundOutputs(BtcTxOut{Value: 30000, PkScript: aliceScript}, BtcTxOut{Value: 30000, PkScript: bobScript}), err: "2 个接收者"},
+		{name: "两个备注", txid: chain.FundOutputs(BtcTxOut{Value: 30000, PkScript: bridgeScript}, BtcTxOut{PkScript: bobMemo}, BtcTxOut{PkScript: bobMemo}), err: "2 个备注标签"},
+		{name: "不超过铸币手续费", txid: chain.Fund(aliceScript, twbtcTokenMintFee), err: "手续费"},
+		{name: "不超过桥费用", txid: chain.Fund(aliceScript, bridgeFee), err: "桥费用"},
+		{name: "未知备注", txid: chain.FundOutputs(BtcTxOut{Value: 20000, PkScript: bridgeScript}, BtcTxOut{PkScript: unknownMemo}), err: "未知的备注标签"},
+		{name: "备注没有支付到桥地址", txid: chain.FundOutputs(BtcTxOut{Value: 20000, PkScript: aliceScript}, BtcTxOut{PkScript: bobMemo}), err: "没有支付到桥地址"},
+		{name: "无备注(找零)", txid: chain.Fund(bridgeScript, 70000)},
+	} {
+		info, err := chain.Transaction(expected.txid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resolution, err := store.Resolve(info.Tx, bridgeFee)
+		switch {
+		case expected.err != "":
+			if err == nil || !strings.Contains(err.Error(), expected.err) {
+				t.Fatalf("%s: 应无法解析(%s)，实际: %v", expected.name, expected.err, err)
+			}
+		case err != nil:
+			t.Fatalf("%s: 解析失败: %v", expected.name, err)
+		case expected.receiver == "":
+			if resolution != nil {
+				t.Fatalf("%s: 与存款意图无关，不应解析出接收者", expected.name)
+			}
+		case resolution == nil || resolution.BtcTxId != expected.txid || resolution.Receiver != expected.receiver || resolution.Amount != expected.amount || !slices.Equal(resolution.Via, expected.via):
+			t.Fatalf("%s: 应解析为 %s 的 %d (%v)，实际 %+v", expected.name, expected.receiver, expected.amount, expected.via, resolution)
+		}
+	}
+}
+
+func TestDepositIntentScanAndMint(t *testing.T) {
+	alice := newTestAccount(t, "deposit-intent-alice")
+	bob := newTestAccount(t, "deposit-intent-bob")
+	node, client, admin := newTestBridgeNode(t, alice, bob)
+	moduleAddress := admin.Address.String()
+	chain := NewFakeBtcChain()
+	_, store, _ := newTestDepositIntents(t, chain, alice, bob)
+	bridgeScript, err := btcAddressScript(store.BridgeAddress(), chain.network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobMemo, err := depositMemoScript(store.Intent(bob.Address).MemoTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliceTxId := chain.Fund(testIntentScript(t, store, alice), 50000)
+	bobTxId := chain.FundOutputs(BtcTxOut{Value: 40000, PkScript: bridgeScript}, BtcTxOut{PkScript: bobMemo})
+	dustTxId := chain.Fund(testIntentScript(t, store, alice), twbtcTokenMintFee)
+	chain.Fund(bridgeScript, 70000)
+
+	policy := FinalityPolicy{RequiredConfirmations: 3, StateFile: filepath.Join(t.TempDir(), "btc_deposits.json")}
+	engine := NewFinalityEngine(chain, policy)
+	result, err := store.Scan(chain, engine, defaultFakeNodeBridgeFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var observed []string
+	for _, resolution := range result.Observed {
+		observed = append(observed, resolution.BtcTxId)
+	}
+	slices.Sort(observed)
+	expectedObserved := []string{aliceTxId, bobTxId}
+	slices.Sort(expectedObserved)
+	if !slices.Equal(observed, expectedObserved) || len(result.Skipped) != 1 || result.Skipped[0].BtcTxId != dustTxId {
+		t.Fatalf("应跟踪 %v 并跳过 %s，实际 %+v", expectedObserved, dustTxId, result)
+	}
+	minted, err := engine.ProcessDeposits(client, admin, moduleAddress)
+	if err != nil || len(minted) != 0 {
+		t.Fatalf("存款在内存池中时不应铸币，实际 %d 笔: %v", len(minted), err)
+	}
+
+	chain.MineMempool()
+	chain.MineEmpty(int(policy.RequiredConfirmations) - 1)
+	result, err = store.Scan(chain, engine, defaultFakeNodeBridgeFee)
+	if err != nil || len(result.Observed) != 0 {
+		t.Fatalf("已跟踪的存款不应重复跟踪: %v", err)
+	}
+	minted, err = engine.ProcessDeposits(client, admin, moduleAddress)
+	if err != nil || len(minted) != 2 {
+		t.Fatalf("应为 2 笔存款铸币，实际 %d 笔: %v", len(minted), err)
+	}
+	// 代币合约从每笔铸币中扣除 twbtcTokenMintFee
+	coinType := twbtcCoinType(admin.Address)
+	for account, expected := range map[*aptos.Account]uint64{alice: 50000 - twbtcTokenMintFee, bob: 40000 - twbtcTokenMintFee} {
+		balance, _ := node.CoinBalance(account.Address, coinType)
+		if balance != expected {
+			t.Fatalf("%s 余额应为 %d，实际 %d", account.Address.String(), expected, balance)
+		}
+	}
+}
+
+func TestDepositIntentOutputsFundRedeemPayout(t *testing.T) {
+	alice := newTestAccount(t, "deposit-intent-alice")
+	chain, bridgeKey, bridgeAddress, receiverAddress := newTestPayoutChain(t)
+	_, store, _ := newTestDepositIntents(t, chain, alice)
+	if store.BridgeAddress() != bridgeAddress {
+		t.Fatalf("存款意图的桥地址 %s 应与出账桥地址 %s 相同", store.BridgeAddress(), bridgeAddress)
+	}
+	intentTxId := chain.Fund(testIntentScript(t, store, alice), 60000)
+	bridgeTxId, err := chain.FundAddress(bridgeAddress, 60000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.MineMempool()
+
+	req := RedeemPayoutRequest{
+		RedeemRequestTxHash: "0xredeem",
+		Requester:           "0xa",
+		Receiver:            receiverAddress,
+		Amount:              100000,
+		BridgeFee:           1000,
+		OutpointTxIds:       []string{intentTxId, bridgeTxId},
+		OutpointIdxs:        []uint64{0, 0},
+		BridgeAddress:       bridgeAddress,
+		Network:             chain.network,
+	}
+	_, err = BuildRedeemPayout(req, &backendPrevOuts{backend: chain}, &backendFeeRate{backend: chain, targetBlocks: defaultBtcFeeTargetBlocks})
+	if err == nil || !strings.Contains(err.Error(), "专属存款地址") {
+		t.Fatalf("未提供存款意图时不应花费专属存款地址上的输出: %v", err)
+	}
+
+	req.DepositIntents = store
+	payout, tx := signTestPayout(t, chain, bridgeKey, req)
+	if payout.Inputs[0].DepositReceiver != alice.Address.String() || payout.Sighashes[0].KeyTweak == "" || payout.Inputs[1].KeyTweak != "" {
+		t.Fatalf("只有专属存款地址的输入应记录接收者和密钥调整值: %+v", payout.Inputs)
+	}
+	if _, err := chain.Broadcast(tx.Serialize(true)); err != nil {
+		t.Fatalf("花费专属存款地址和桥地址的出账交易应被接受: %v", err)
+	}
+	chain.MineMempool()
+	utxos, err := chain.AddressUtxos(store.Intent(alice.Address).Address)
+	if err != nil || len(utxos) != 0 {
+		t.Fatalf("专属存款地址上的输出应已花费，实际 %+v: %v", utxos, err)
+	}
+
+	otherKey, _ := btcec.PrivKeyFromBytes(sha256Bytes("deposit-intent-other"))
+	otherStore, err := NewDepositIntentStore(otherKey.PubKey(), chain.network, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.DepositIntents = otherStore
+	_, err = BuildRedeemPayout(req, &backendPrevOuts{backend: chain}, &backendFeeRate{backend: chain, targetBlocks: defaultBtcFeeTargetBlocks})
+	if err == nil || !strings.Contains(err.Error(), "不一致") {
+		t.Fatalf("存款意图属于其他桥密钥时应报错: %v", err)
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// 按序列号分页读取事件时每页的最大数量，与全节点的上限一致
+const maxEventPageLimit = 100
+
+// eventHTTPClient 读取事件分页的 HTTP 客户端，全节点无响应时不会一直阻塞
+var eventHTTPClient = &http.Client{Timeout: 15 * time.Second}
+
+// BridgeEventStream 桥合约的一个事件句柄
+type BridgeEventStream struct {
+	Name         string // HTTP API 和命令行中使用的名称
+	HandleStruct string // 持有事件句柄的资源，module::struct
+	Field        string // 事件句柄字段
+	EventStruct  string // 事件类型，module::struct
+}
+
+// bridgeEventStreams 桥合约和代币合约的所有事件句柄
+var bridgeEventStreams = []BridgeEventStream{
+	{"mint", BridgeEventsStructTag, "mint_events", BridgeMintEventStructTag},
+	{"redeem_request", BridgeEventsStructTag, "redeem_request_events", BridgeRedeemRequestEventStructTag},
+	{"redeem_prepare", BridgeEventsStructTag, "redeem_prepare_events", BridgeRedeemPrepareEventStructTag},
+	{"token_mint", TokenBridgeEventsStructTag, "mint_events", TokenMintEventStructTag},
+	{"token_burn", TokenBridgeEventsStructTag, "burn_events", TokenBurnEventStructTag},
+}
+
+// findBridgeEventStream 按名称查找事件句柄
+func findBridgeEventStream(name string) (BridgeEventStream, error) {
+	for _, stream := range bridgeEventStreams {
+		if stream.Name == name {
+			return stream, nil
+		}
+	}
+	names := make([]string, 0, len(bridgeEventStreams))
+	for _, stream := range bridgeEventStreams {
+		names = append(names, stream.Name)
+	}
+	return BridgeEventStream{}, fmt.Errorf("未知的事件类型 %s，可选 %v", name, names)
+}
+
+// SequencedBridgeEvent 带句柄内序列号和交易版本的桥事件
+type SequencedBridgeEvent struct {
+	Stream         string `json:"stream"`
+	SequenceNumber uint64 `json:"sequence_number"`
+	Version        uint64 `json:"version"`
+	Type           string `json:"type"` // module::struct
+	Data           any    `json:"data"` // *BridgeMintEvent 等，与 BridgeEventRecord.Event 相同
+}
+
+// BridgeEventPage 一个事件句柄中从 Start 开始的一页事件
+type BridgeEventPage struct {
+	Stream string                 `json:"stream"`
+	Start  uint64                 `json:"start"`
+	Total  uint64                 `json:"total"` // 句柄的事件总数，序列号为 0..Total-1
+	Events []SequencedBridgeEvent `json:"events"`
+	Next   *uint64                `json:"next,omitempty"` // 下一页的 start，没有更多事件时为空
+}
+
+// eventStreamHandle 读取事件所在的句柄，Counter 为句柄中的事件总数
+func eventStreamHandle(client *aptos.Client, moduleAddress string, stream BridgeEventStream) (*EventHandle, error) {
+	handles := map[string]*EventHandle{}
+	switch stream.HandleStruct {
+	case BridgeEventsStructTag:
+		events, err := GetBridgeEvents(client, moduleAddress)
+		if err != nil {
+			return nil, err
+		}
+		handles["mint_events"] = &events.MintEvents
+		handles["redeem_request_events"] = &events.RedeemRequestEvents
+		handles["redeem_prepare_events"] = &events.RedeemPrepareEvents
+	case TokenBridgeEventsStructTag:
+		events, err := GetTokenBridgeEvents(client, moduleAddress)
+		if err != nil {
+			return nil, err
+		}
+		handles["mint_events"] = &events.MintEvents
+		handles["burn_events"] = &events.BurnEvents
+	}
+	handle, ok := handles[stream.Field]
+	if !ok {
+		return nil, fmt.Errorf("%s 没有事件句柄 %s", stream.HandleStruct, stream.Field)
+	}
+	return handle, nil
+}
+
+// GetBridgeEventPage 按序列号读取事件句柄中 [start, start+limit) 的事件
+func GetBridgeEventPage(client *aptos.Client, moduleAddress string, streamName string, start uint64, limit uint64) (*BridgeEventPage, error) {
+	stream, err := findBridgeEventStream(streamName)
+	if err != nil {
+		return nil, err
+	}
+	if limit == 0 || limit > maxEventPageLimit {
+		return nil, fmt.Errorf("每页数量必须在 1 到 %d 之间", maxEventPageLimit)
+	}
+	address := aptos.AccountAddress{}
+	err = address.ParseStringRelaxed(moduleAddress)
+	if err != nil {
+		return nil, fmt.Errorf("解析模块地址失败: %v", err)
+	}
+	handle, err := eventStreamHandle(client, moduleAddress, stream)
+	if err != nil {
+		return nil, err
+	}
+	page := &BridgeEventPage{Stream: stream.Name, Start: start, Total: handle.Counter, Events: []SequencedBridgeEvent{}}
+	if start >= handle.Counter {
+		return page, nil
+	}
+
+	handleAccount := aptos.AccountAddress{}
+	err = handleAccount.ParseStringRelaxed(handle.Guid.Id.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("解析账户地址失败: %v", err)
+	}
+	fullURL := fmt.Sprintf("%s/v1/accounts/%s/events/%s/%s?%s",
+		getNodeBaseURL(),
+		handleAccount.String(),
+		url.PathEscape(address.String()+"::"+stream.HandleStruct),
+		stream.Field,
+		url.Values{"start": {strconv.FormatUint(start, 10)}, "limit": {strconv.FormatUint(limit, 10)}}.Encode())
+	resp, err := eventHTTPClient.Get(fullURL)
+	if err != nil {
+		return nil, fmt.Errorf("发送HTTP请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API请求失败: 状态码 %d, 响应体: %s", resp.StatusCode, string(body))
+	}
+
+	var events []struct {
+		Version        string `json:"version"`
+		SequenceNumber string `json:"sequence_number"`
+		Data           any    `json:"data"`
+	}
+	err = json.Unmarshal(body, &events)
+	if err != nil {
+		return nil, fmt.Errorf("解析响应失败: %v", err)
+	}
+	for _, event := range events {
+		sequenceNumber, err := strconv.ParseUint(event.SequenceNumber, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("解析事件序列号失败: %v", err)
+		}
+		version, err := strconv.ParseUint(event.Version, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("解析事件版本失败: %v", err)
+		}
+		data, err := decodeBridgeEvent(stream.EventStruct, event.Data)
+		if err != nil {
+			return nil, err
+		}
+		page.Events = append(page.Events, SequencedBridgeEvent{
+			Stream:         stream.Name,
+			SequenceNumber: sequenceNumber,
+			Version:        version,
+			Type:           stream.EventStruct,
+			Data:           data,
+		})
+	}
+	if len(page.Events) > 0 {
+		next := page.Events[len(page.Events)-1].SequenceNumber + 1
+		if next < handle.Counter {
+			page.Next = &next
+		}
+	}
+	return page, nil
+}
+
+// EventFollower 按序列号轮询每个事件句柄，把新事件按句柄内的顺序交给 handler，
+// Cursors 为每个句柄下一个要处理的序列号，由调用方持久化
+type EventFollower struct {
+	source  EventSource
+	Streams []string
+	Cursors map[string]uint64
+}
+
+// NewEventFollower 创建事件跟随器，cursors 中没有的句柄从当前事件总数开始，即只处理之后的新事件
+func NewEventFollower(source EventSource, cursors map[string]uint64) (*EventFollower, error) {
+	follower := &EventFollower{source: source, Cursors: map[string]uint64{}}
+	for _, stream := range bridgeEventStreams {
+		follower.Streams = append(follower.Streams, stream.Name)
+		if cursor, ok := cursors[stream.Name]; ok {
+			follower.Cursors[stream.Name] = cursor
+			continue
+		}
+		page, err := source.EventPage(stream.Name, ^uint64(0), 1)
+		if err != nil {
+			return nil, err
+		}
+		follower.Cursors[stream.Name] = page.Total
+	}
+	return follower, nil
+}
+
+// PollOnce 读取各句柄游标之后的所有事件并交给 handler，handler 返回错误时游标停在该事件并返回错误
+func (f *EventFollower) PollOnce(handler func(SequencedBridgeEvent) error) (int, error) {
+	delivered := 0
+	for _, stream := range f.Streams {
+		for {
+			page, err := f.source.EventPage(stream, f.Cursors[stream], maxEventPageLimit)
+			if err != nil {
+				return delivered, err
+			}
+			for _, event := range page.Events {
+				err = handler(event)
+				if err != nil {
+					return delivered, err
+				}
+				f.Cursors[stream] = event.SequenceNumber + 1
+				delivered++
+			}
+			if page.Next == nil {
+				break
+			}
+		}
+	}
+	return delivered, nil
+}
+
+// Run 每隔 interval 调用一次 PollOnce，直到 ctx 取消；读取事件失败时只打印警告，afterPoll 在每轮之后调用，用于保存游标
+func (f *EventFollower) Run(ctx context.Context, interval time.Duration, handler func(SequencedBridgeEvent) error, afterPoll func() error) error {
+	for {
+		_, err := f.PollOnce(handler)
+		if err != nil {
+			logWarning(fmt.Sprintf("跟随桥事件失败: %v", err))
+		}
+		if afterPoll != nil {
+			err = afterPoll()
+			if err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
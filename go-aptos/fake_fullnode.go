@@ -0,0 +1,1624 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/bcs"
+	"github.com/aptos-labs/aptos-go-sdk/crypto"
+)
+
+const (
+	// 模拟节点的链ID，与本地测试网相同
+	fakeNodeChainId uint8 = 4
+
+	// 每笔交易固定消耗的gas单位和默认gas单价估算
+	fakeNodeGasUsed     uint64 = 10
+	fakeNodeGasEstimate uint64 = 100
+
+	// 按句柄查询事件时的默认和最大条数，与全节点一致
+	fakeNodeDefaultEventLimit uint64 = 25
+	fakeNodeMaxEventLimit     uint64 = 100
+
+	aptosCoinType = "0x1::aptos_coin::AptosCoin"
+)
+
+// Move 错误码的类别，错误码为 类别<<16 | 原因
+const (
+	moveErrInvalidArgument  uint64 = 0x1
+	moveErrInvalidState     uint64 = 0x3
+	moveErrPermissionDenied uint64 = 0x5
+	moveErrNotFound         uint64 = 0x6
+	moveErrAlreadyExists    uint64 = 0x8
+)
+
+// 0x1::coin 的中止原因
+const (
+	coinErrCoinInfoNotPublished  uint64 = 3
+	coinErrCoinStoreNotPublished uint64 = 5
+	coinErrInsufficientBalance   uint64 = 6
+)
+
+// FakeMoveAbort 入口函数或视图函数中止，VM 状态与链上格式相同
+type FakeMoveAbort struct {
+	Module      string // 例如 0x1::coin
+	Name        string // 例如 EINSUFFICIENT_BALANCE
+	Code        uint64 // 含类别的完整错误码
+	Description string
+}
+
+func (e *FakeMoveAbort) Error() string {
+	return fmt.Sprintf("Move abort in %s: %s(0x%x): %s", e.Module, e.Name, e.Code, e.Description)
+}
+
+// newFakeMoveAbort 按类别和原因构造中止
+func newFakeMoveAbort(module string, name string, category uint64, reason uint64, description string) *FakeMoveAbort {
+	return &FakeMoveAbort{Module: module, Name: name, Code: category<<16 | reason, Description: description}
+}
+
+// FakeEntryFunction 入口函数的模拟实现，返回错误时交易失败且写入全部回滚
+type FakeEntryFunction func(ctx *FakeTxContext) error
+
+// FakeViewFunction 视图函数的模拟实现，返回值按 REST 的 JSON 编码(u64 为字符串)
+type FakeViewFunction func(ctx *FakeTxContext) ([]any, error)
+
+// fakeAccount 模拟节点上的账户
+type fakeAccount struct {
+	SequenceNumber  uint64
+	AuthKey         aptos.AccountAddress
+	GuidCreationNum uint64
+	Resources       map[string]map[string]any // 资源类型 -> data
+}
+
+// fakeState 某个账本版本的全部账户状态
+type fakeState struct {
+	accounts map[aptos.AccountAddress]*fakeAccount
+}
+
+// clone 深拷贝状态，交易在副本上执行，成功后才替换
+func (s *fakeState) clone() *fakeState {
+	out := &fakeState{accounts: make(map[aptos.AccountAddress]*fakeAccount, len(s.accounts))}
+	for address, account := range s.accounts {
+		copied := *account
+		copied.Resources = make(map[string]map[string]any, len(account.Resources))
+		for resourceType, data := range account.Resources {
+			copied.Resources[resourceType] = cloneMoveValue(data).(map[string]any)
+		}
+		out.accounts[address] = &copied
+	}
+	return out
+}
+
+// cloneMoveValue 深拷贝 REST 编码的 Move 值
+func cloneMoveValue(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(value))
+		for k, item := range value {
+			out[k] = cloneMoveValue(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(value))
+		for i, item := range value {
+			out[i] = cloneMoveValue(item)
+		}
+		return out
+	case []string:
+		return append([]string(nil), value...)
+	}
+	return v
+}
+
+// fakeEvent 按句柄存储的事件
+type fakeEvent struct {
+	Account        aptos.AccountAddress
+	CreationNumber uint64
+	SequenceNumber uint64
+	Type           string
+	Data           map[string]any
+	Version        uint64
+}
+
+func (e *fakeEvent) handleKey() string {
+	return fmt.Sprintf("%s/%d", e.Account.String(), e.CreationNumber)
+}
+
+func (e *fakeEvent) toJSON(withVersion bool) map[string]any {
+	out := map[string]any{
+		"guid": map[string]any{
+			"creation_number": strconv.FormatUint(e.CreationNumber, 10),
+			"account_address": e.Account.String(),
+		},
+		"sequence_number": strconv.FormatUint(e.SequenceNumber, 10),
+		"type":            e.Type,
+		"data":            e.Data,
+	}
+	if withVersion {
+		out["version"] = strconv.FormatUint(e.Version, 10)
+	}
+	return out
+}
+
+// fakeTxn 提交到模拟节点的交易
+type fakeTxn struct {
+	Hash      string
+	Signed    *aptos.SignedTransaction
+	Committed bool
+	Version   uint64
+	Success   bool
+	VmStatus  string
+	GasUsed   uint64
+	Events    []*fakeEvent
+	Timestamp uint64 // 微秒
+}
+
+// FakeFullnode 进程内的模拟 Aptos 全节点，实现 CLI 用到的 REST 接口，
+// 状态可编程设置，入口函数和视图函数通过 HandleEntryFunction/HandleView 注册实现
+type FakeFullnode struct {
+	mu             sync.Mutex
+	server         *httptest.Server
+	chainId        uint8
+	gasEstimate    uint64
+	autoCommit     bool
+	timeOffset     time.Duration
+	history        []*fakeState // history[v] 为账本版本 v 之后的状态
+	events         map[string][]*fakeEvent
+	transactions   map[string]*fakeTxn
+	entryFunctions map[string]FakeEntryFunction
+	viewFunctions  map[string]FakeViewFunction
+}
+
+// NewFakeFullnode 启动模拟全节点，只有 0x1 账户，默认提交后立即上链
+func NewFakeFullnode() *FakeFullnode {
+	n := newFakeFullnodeState()
+	n.server = httptest.NewServer(n.routes())
+	return n
+}
+
+// newFakeFullnodeState 创建未启动 HTTP 服务的模拟节点
+func newFakeFullnodeState() *FakeFullnode {
+	genesis := &fakeState{accounts: make(map[aptos.AccountAddress]*fakeAccount)}
+	genesis.createAccount(aptos.AccountOne)
+	n := &FakeFullnode{
+		chainId:        fakeNodeChainId,
+		gasEstimate:    fakeNodeGasEstimate,
+		autoCommit:     true,
+		history:        []*fakeState{genesis},
+		events:         make(map[string][]*fakeEvent),
+		transactions:   make(map[string]*fakeTxn),
+		entryFunctions: make(map[string]FakeEntryFunction),
+		viewFunctions:  make(map[string]FakeViewFunction),
+	}
+	n.registerFrameworkFunctions()
+	n.registerFungibleAssetFunctions()
+	n.registerCodeFunctions()
+	n.registerMultisigAccountFunctions()
+	return n
+}
+
+// URL 返回 REST 接口地址(含 /v1)，可作为 APTOS_NODE_URL
+func (n *FakeFullnode) URL() string {
+	return n.server.URL + "/v1"
+}
+
+// FaucetURL 返回模拟水龙头地址，可作为 APTOS_FAUCET_URL
+func (n *FakeFullnode) FaucetURL() string {
+	return n.server.URL
+}
+
+// Close 关闭 HTTP 服务
+func (n *FakeFullnode) Close() {
+	n.server.Close()
+}
+
+// Client 创建连接到模拟节点的客户端
+func (n *FakeFullnode) Client() (*aptos.Client, error) {
+	client, err := aptos.NewClient(aptos.NetworkConfig{Name: "fake", ChainId: n.chainId, NodeUrl: n.URL(), FaucetUrl: n.FaucetURL()})
+	if err != nil {
+		return nil, fmt.Errorf("创建客户端失败: %v", err)
+	}
+	return client, nil
+}
+
+// SetAutoCommit 关闭后提交的交易停留在内存池，直到调用 Commit
+func (n *FakeFullnode) SetAutoCommit(autoCommit bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.autoCommit = autoCommit
+}
+
+// SetGasEstimate 设置 estimate_gas_price 返回的gas单价
+func (n *FakeFullnode) SetGasEstimate(gasUnitPrice uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.gasEstimate = gasUnitPrice
+}
+
+// AdvanceTime 推进账本时间，用于让内存池中的交易过期
+func (n *FakeFullnode) AdvanceTime(d time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.timeOffset += d
+	n.dropExpired()
+}
+
+// Now 返回账本时间，包含 AdvanceTime 推进的部分
+func (n *FakeFullnode) Now() time.Time {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.now()
+}
+
+// Commit 执行内存池中序列号连续且未过期的交易，返回上链的交易数
+func (n *FakeFullnode) Commit() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.commitReady()
+}
+
+// Pending 返回内存池中的交易数
+func (n *FakeFullnode) Pending() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	count := 0
+	for _, txn := range n.transactions {
+		if !txn.Committed {
+			count++
+		}
+	}
+	return count
+}
+
+// LedgerVersion 返回当前账本版本
+func (n *FakeFullnode) LedgerVersion() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.ledgerVersion()
+}
+
+// HandleEntryFunction 注册入口函数实现，function 形如 0x42::btc_bridgev3::mint
+func (n *FakeFullnode) HandleEntryFunction(function string, fn FakeEntryFunction) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.entryFunctions[canonicalFunctionId(function)] = fn
+}
+
+// HandleView 注册视图函数实现
+func (n *FakeFullnode) HandleView(function string, fn FakeViewFunction) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.viewFunctions[canonicalFunctionId(function)] = fn
+}
+
+// Update 直接在当前状态上执行 fn 来编排状态(不产生交易，不增加账本版本)，fn 返回错误时不做修改
+func (n *FakeFullnode) Update(fn func(ctx *FakeTxContext) error) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	work := n.current().clone()
+	ctx := &FakeTxContext{state: work, version: n.ledgerVersion()}
+	err := fn(ctx)
+	if err != nil {
+		return err
+	}
+	n.history[len(n.history)-1] = work
+	n.appendEvents(ctx.events)
+	return nil
+}
+
+// View 在当前状态上只读执行 fn
+func (n *FakeFullnode) View(fn func(ctx *FakeTxContext) error) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return fn(&FakeTxContext{state: n.current().clone(), version: n.ledgerVersion()})
+}
+
+// CreateAccount 创建账户并存入 octas 个 APT，认证密钥与地址相同
+func (n *FakeFullnode) CreateAccount(address aptos.AccountAddress, octas uint64) error {
+	return n.Update(func(ctx *FakeTxContext) error {
+		ctx.state.createAccount(address)
+		err := ctx.CoinRegister(address, aptosCoinType)
+		if err != nil {
+			return err
+		}
+		return ctx.CoinDeposit(address, aptosCoinType, octas)
+	})
+}
+
+// SetAuthKey 设置账户的认证密钥，用于多签或轮换过密钥的账户
+func (n *FakeFullnode) SetAuthKey(address aptos.AccountAddress, authKey aptos.AccountAddress) error {
+	return n.Update(func(ctx *FakeTxContext) error {
+		ctx.state.createAccount(address).AuthKey = authKey
+		return nil
+	})
+}
+
+// SetCoinBalance 设置账户某种币的余额，未注册时先注册
+func (n *FakeFullnode) SetCoinBalance(address aptos.AccountAddress, coinType string, value uint64) error {
+	return n.Update(func(ctx *FakeTxContext) error {
+		ctx.state.createAccount(address)
+		err := ctx.CoinRegister(address, coinType)
+		if err != nil {
+			return err
+		}
+		store, _ := ctx.Resource(address, coinStoreType(coinType))
+		store["coin"] = map[string]any{"value": strconv.FormatUint(value, 10)}
+		return nil
+	})
+}
+
+// CoinBalance 读取账户某种币的余额，未注册时 registered 为 false
+func (n *FakeFullnode) CoinBalance(address aptos.AccountAddress, coinType string) (balance uint64, registered bool) {
+	_ = n.View(func(ctx *FakeTxContext) error {
+		balance, registered = ctx.CoinBalance(address, coinType)
+		return nil
+	})
+	return balance, registered
+}
+
+// current 返回最新状态
+func (n *FakeFullnode) current() *fakeState {
+	return n.history[len(n.history)-1]
+}
+
+func (n *FakeFullnode) ledgerVersion() uint64 {
+	return uint64(len(n.history) - 1)
+}
+
+// now 返回账本时间
+func (n *FakeFullnode) now() time.Time {
+	return time.Now().Add(n.timeOffset)
+}
+
+func (n *FakeFullnode) appendEvents(events []*fakeEvent) {
+	for _, event := range events {
+		n.events[event.handleKey()] = append(n.events[event.handleKey()], event)
+	}
+}
+
+// canonicalFunctionId 把函数ID中的地址规范化
+func canonicalFunctionId(function string) string {
+	parts := strings.Split(function, "::")
+	if len(parts) != 3 {
+		return function
+	}
+	address := aptos.AccountAddress{}
+	err := address.ParseStringRelaxed(parts[0])
+	if err != nil {
+		return function
+	}
+	return fmt.Sprintf("%s::%s::%s", address.String(), parts[1], parts[2])
+}
+
+// canonicalTypeString 把类型字符串中的地址规范化，无法解析时原样返回
+func canonicalTypeString(typeString string) string {
+	typeTag, err := aptos.ParseTypeTag(typeString)
+	if err != nil {
+		return typeString
+	}
+	return typeTag.String()
+}
+
+// coinStoreType 返回 0x1::coin::CoinStore<coinType>
+func coinStoreType(coinType string) string {
+	return canonicalTypeString(fmt.Sprintf("0x1::coin::CoinStore<%s>", coinType))
+}
+
+// coinInfoType 返回 0x1::coin::CoinInfo<coinType>，存放在币类型的模块地址下
+func coinInfoType(coinType string) string {
+	return canonicalTypeString(fmt.Sprintf("0x1::coin::CoinInfo<%s>", coinType))
+}
+
+// coinTypeAddress 币类型所在的模块地址
+func coinTypeAddress(coinType string) (aptos.AccountAddress, error) {
+	address := aptos.AccountAddress{}
+	err := address.ParseStringRelaxed(strings.Split(coinType, "::")[0])
+	if err != nil {
+		return address, fmt.Errorf("无效的币类型 %s", coinType)
+	}
+	return address, nil
+}
+
+// createAccount 账户不存在时创建，返回账户
+func (s *fakeState) createAccount(address aptos.AccountAddress) *fakeAccount {
+	account, ok := s.accounts[address]
+	if !ok {
+		account = &fakeAccount{AuthKey: address, Resources: make(map[string]map[string]any)}
+		s.accounts[address] = account
+	}
+	return account
+}
+
+// accountResource 0x1::account::Account 资源，由账户字段生成
+func (a *fakeAccount) accountResource() map[string]any {
+	return map[string]any{
+		"authentication_key": a.AuthKey.String(),
+		"sequence_number":    strconv.FormatUint(a.SequenceNumber, 10),
+		"guid_creation_num":  strconv.FormatUint(a.GuidCreationNum, 10),
+	}
+}
+
+// resources 账户的全部资源，按类型排序
+func (a *fakeAccount) resources() []aptos.AccountResourceInfo {
+	out := []aptos.AccountResourceInfo{{Type: "0x1::account::Account", Data: a.accountResource()}}
+	for resourceType, data := range a.Resources {
+		out = append(out, aptos.AccountResourceInfo{Type: resourceType, Data: data})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Type < out[j].Type })
+	return out
+}
+
+// FakeTxContext 模拟实现读写链上状态的上下文，交易中的写入在成功后才生效
+type FakeTxContext struct {
+	Sender   aptos.AccountAddress
+	Signers  []aptos.AccountAddress // 发送方和次要签名方，对应入口函数的 &signer 参数
+	Function string
+	TypeArgs []string
+	Args     [][]byte
+
+	state   *fakeState
+	events  []*fakeEvent
+	version uint64
+}
+
+// fakeVmError 非中止的执行错误，VM 状态为 status
+type fakeVmError string
+
+func (e fakeVmError) Error() string {
+	return string(e)
+}
+
+// AccountExists 账户是否存在
+func (ctx *FakeTxContext) AccountExists(address aptos.AccountAddress) bool {
+	_, ok := ctx.state.accounts[address]
+	return ok
+}
+
+// CreateAccount 账户不存在时创建
+func (ctx *FakeTxContext) CreateAccount(address aptos.AccountAddress) {
+	ctx.state.createAccount(address)
+}
+
+// Exists 对应 Move 的 exists<T>(address)
+func (ctx *FakeTxContext) Exists(address aptos.AccountAddress, resourceType string) bool {
+	_, ok := ctx.Resource(address, resourceType)
+	return ok
+}
+
+// Resource 返回资源的 data，修改返回值即修改状态
+func (ctx *FakeTxContext) Resource(address aptos.AccountAddress, resourceType string) (map[string]any, bool) {
+	account, ok := ctx.state.accounts[address]
+	if !ok {
+		return nil, false
+	}
+	data, ok := account.Resources[canonicalTypeString(resourceType)]
+	return data, ok
+}
+
+// BorrowGlobal 对应 Move 的 borrow_global，资源不存在时返回 MISSING_DATA
+func (ctx *FakeTxContext) BorrowGlobal(address aptos.AccountAddress, resourceType string) (map[string]any, error) {
+	data, ok := ctx.Resource(address, resourceType)
+	if !ok {
+		return nil, fakeVmError("MISSING_DATA")
+	}
+	return data, nil
+}
+
+// MoveTo 对应 Move 的 move_to，资源已存在时返回 RESOURCE_ALREADY_EXISTS
+func (ctx *FakeTxContext) MoveTo(address aptos.AccountAddress, resourceType string, data map[string]any) error {
+	if ctx.Exists(address, resourceType) {
+		return fakeVmError("RESOURCE_ALREADY_EXISTS")
+	}
+	ctx.state.createAccount(address).Resources[canonicalTypeString(resourceType)] = data
+	return nil
+}
+
+// NewEventHandle 对应 account::new_event_handle，在账户下分配新的 GUID
+func (ctx *FakeTxContext) NewEventHandle(address aptos.AccountAddress) map[string]any {
+	account := ctx.state.createAccount(address)
+	creationNum := account.GuidCreationNum
+	account.GuidCreationNum++
+	return map[string]any{
+		"counter": "0",
+		"guid": map[string]any{
+			"id": map[string]any{
+				"addr":         address.String(),
+				"creation_num": strconv.FormatUint(creationNum, 10),
+			},
+		},
+	}
+}
+
+// EmitEvent 对应 event::emit_event，通过 address 下 structTag 资源的 field 句柄发出事件
+func (ctx *FakeTxContext) EmitEvent(address aptos.AccountAddress, structTag string, field string, eventType string, data map[string]any) error {
+	resource, err := ctx.BorrowGlobal(address, structTag)
+	if err != nil {
+		return err
+	}
+	handle := &EventHandle{}
+	err = decodeMoveValue(resource[field], handle)
+	if err != nil {
+		return fmt.Errorf("资源 %s 的 %s 不是事件句柄: %v", structTag, field, err)
+	}
+	handleAddress := aptos.AccountAddress{}
+	err = handleAddress.ParseStringRelaxed(handle.Guid.Id.Addr)
+	if err != nil {
+		return fmt.Errorf("解析事件句柄地址失败: %v", err)
+	}
+	ctx.events = append(ctx.events, &fakeEvent{
+		Account:        handleAddress,
+		CreationNumber: handle.Guid.Id.CreationNum,
+		SequenceNumber: handle.Counter,
+		Type:           canonicalTypeString(eventType),
+		Data:           data,
+		Version:        ctx.version,
+	})
+	resource[field].(map[string]any)["counter"] = strconv.FormatUint(handle.Counter+1, 10)
+	return nil
+}
+
+// argDeserializer 返回第 i 个参数的反序列化器
+func (ctx *FakeTxContext) argDeserializer(i int) (*bcs.Deserializer, error) {
+	if i >= len(ctx.Args) {
+		return nil, fakeVmError("NUMBER_OF_ARGUMENTS_MISMATCH")
+	}
+	return bcs.NewDeserializer(ctx.Args[i]), nil
+}
+
+// finishArg 检查参数是否完整读取
+func finishArg(des *bcs.Deserializer) error {
+	if des.Error() != nil || des.Remaining() != 0 {
+		return fakeVmError("FAILED_TO_DESERIALIZE_ARGUMENT")
+	}
+	return nil
+}
+
+// AddressArg 读取 address 参数
+func (ctx *FakeTxContext) AddressArg(i int) (aptos.AccountAddress, error) {
+	address := aptos.AccountAddress{}
+	des, err := ctx.argDeserializer(i)
+	if err != nil {
+		return address, err
+	}
+	address.UnmarshalBCS(des)
+	return address, finishArg(des)
+}
+
+// U64Arg 读取 u64 参数
+func (ctx *FakeTxContext) U64Arg(i int) (uint64, error) {
+	des, err := ctx.argDeserializer(i)
+	if err != nil {
+		return 0, err
+	}
+	value := des.U64()
+	return value, finishArg(des)
+}
+
+// StringArg 读取 String 参数
+func (ctx *FakeTxContext) StringArg(i int) (string, error) {
+	des, err := ctx.argDeserializer(i)
+	if err != nil {
+		return "", err
+	}
+	value := des.ReadString()
+	return value, finishArg(des)
+}
+
+// StringVectorArg 读取 vector<String> 参数
+func (ctx *FakeTxContext) StringVectorArg(i int) ([]string, error) {
+	des, err := ctx.argDeserializer(i)
+	if err != nil {
+		return nil, err
+	}
+	length := des.Uleb128()
+	values := make([]string, 0, length)
+	for j := uint32(0); j < length && des.Error() == nil; j++ {
+		values = append(values, des.ReadString())
+	}
+	return values, finishArg(des)
+}
+
+// U64VectorArg 读取 vector<u64> 参数
+func (ctx *FakeTxContext) U64VectorArg(i int) ([]uint64, error) {
+	des, err := ctx.argDeserializer(i)
+	if err != nil {
+		return nil, err
+	}
+	length := des.Uleb128()
+	values := make([]uint64, 0, length)
+	for j := uint32(0); j < length && des.Error() == nil; j++ {
+		values = append(values, des.U64())
+	}
+	return values, finishArg(des)
+}
+
+// TypeArg 读取第 i 个类型参数
+func (ctx *FakeTxContext) TypeArg(i int) (string, error) {
+	if i >= len(ctx.TypeArgs) {
+		return "", fakeVmError("NUMBER_OF_TYPE_ARGUMENTS_MISMATCH")
+	}
+	return ctx.TypeArgs[i], nil
+}
+
+// CoinRegister 对应 coin::register，已注册时不做修改
+func (ctx *FakeTxContext) CoinRegister(address aptos.AccountAddress, coinType string) error {
+	if ctx.Exists(address, coinStoreType(coinType)) {
+		return nil
+	}
+	return ctx.MoveTo(address, coinStoreType(coinType), map[string]any{
+		"coin":            map[string]any{"value": "0"},
+		"frozen":          false,
+		"deposit_events":  ctx.NewEventHandle(address),
+		"withdraw_events": ctx.NewEventHandle(address),
+	})
+}
+
+// CoinBalance 对应 coin::balance，未注册时 registered 为 false
+func (ctx *FakeTxContext) CoinBalance(address aptos.AccountAddress, coinType string) (balance uint64, registered bool) {
+	store, ok := ctx.Resource(address, coinStoreType(coinType))
+	if !ok {
+		return 0, false
+	}
+	value := struct {
+		Coin struct {
+			Value uint64 `json:"value"`
+		} `json:"coin"`
+	}{}
+	_ = decodeMoveValue(store, &value)
+	return value.Coin.Value, true
+}
+
+// setCoinValue 设置 CoinStore 中的余额
+func (ctx *FakeTxContext) setCoinValue(address aptos.AccountAddress, coinType string, value uint64) {
+	store, _ := ctx.Resource(address, coinStoreType(coinType))
+	store["coin"] = map[string]any{"value": strconv.FormatUint(value, 10)}
+}
+
+// CoinDeposit 对应 coin::deposit，没有 CoinStore 时存入已迁移账户的 FA 主存储，两者都没有时中止
+func (ctx *FakeTxContext) CoinDeposit(address aptos.AccountAddress, coinType string, amount uint64) error {
+	balance, registered := ctx.CoinBalance(address, coinType)
+	if !registered {
+		if _, exists := ctx.pairedFABalance(address, coinType); exists {
+			metadata, _ := ctx.PairedMetadata(coinType)
+			return ctx.FADeposit(address, metadata, amount)
+		}
+		return newFakeMoveAbort("0x1::coin", "ECOIN_STORE_NOT_PUBLISHED", moveErrNotFound, coinErrCoinStoreNotPublished, "Account hasn't registered `CoinStore` for `CoinType`")
+	}
+	ctx.setCoinValue(address, coinType, balance+amount)
+	return ctx.EmitEvent(address, coinStoreType(coinType), "deposit_events", "0x1::coin::DepositEvent", map[string]any{"amount": strconv.FormatUint(amount, 10)})
+}
+
+// CoinWithdraw 对应 coin::withdraw，先从 CoinStore 扣除，不足部分从配对 FA 的主存储扣除，余额不足或未注册时中止
+func (ctx *FakeTxContext) CoinWithdraw(address aptos.AccountAddress, coinType string, amount uint64) error {
+	balance, registered := ctx.CoinBalance(address, coinType)
+	faBalance, faExists := ctx.pairedFABalance(address, coinType)
+	if !registered && !faExists {
+		return newFakeMoveAbort("0x1::coin", "ECOIN_STORE_NOT_PUBLISHED", moveErrNotFound, coinErrCoinStoreNotPublished, "Account hasn't registered `CoinStore` for `CoinType`")
+	}
+	if balance+faBalance < amount {
+		return newFakeMoveAbort("0x1::coin", "EINSUFFICIENT_BALANCE", moveErrInvalidArgument, coinErrInsufficientBalance, "Not enough coins to complete transaction")
+	}
+	if balance < amount {
+		metadata, _ := ctx.PairedMetadata(coinType)
+		err := ctx.FAWithdraw(address, metadata, amount-balance)
+		if err != nil {
+			return err
+		}
+		amount = balance
+	}
+	if !registered {
+		return nil
+	}
+	ctx.setCoinValue(address, coinType, balance-amount)
+	return ctx.EmitEvent(address, coinStoreType(coinType), "withdraw_events", "0x1::coin::WithdrawEvent", map[string]any{"amount": strconv.FormatUint(amount, 10)})
+}
+
+// CoinInitialize 对应 coin::initialize，在币类型的模块地址下创建 CoinInfo，供应量从 0 开始
+func (ctx *FakeTxContext) CoinInitialize(coinType string, name string, symbol string, decimals uint8) error {
+	address, err := coinTypeAddress(coinType)
+	if err != nil {
+		return err
+	}
+	return ctx.MoveTo(address, coinInfoType(coinType), map[string]any{
+		"name":     name,
+		"symbol":   symbol,
+		"decimals": float64(decimals),
+		"supply": map[string]any{"vec": []any{map[string]any{
+			"aggregator": map[string]any{"vec": []any{}},
+			"integer": map[string]any{"vec": []any{map[string]any{
+				"limit": "340282366920938463463374607431768211455",
+				"value": "0",
+			}}},
+		}}},
+	})
+}
+
+// coinSupplyField 返回 CoinInfo 中保存供应量的 integer 值
+func (ctx *FakeTxContext) coinSupplyField(coinType string) (map[string]any, error) {
+	address, err := coinTypeAddress(coinType)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := ctx.Resource(address, coinInfoType(coinType))
+	if !ok {
+		return nil, newFakeMoveAbort("0x1::coin", "ECOIN_INFO_NOT_PUBLISHED", moveErrNotFound, coinErrCoinInfoNotPublished, "`CoinType` is not registered as a coin")
+	}
+	integer, ok := jsonPath(info, "supply", "vec", 0, "integer", "vec", 0).(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("CoinInfo<%s> 的供应量格式无效", coinType)
+	}
+	return integer, nil
+}
+
+// CoinSupply 对应 coin::supply
+func (ctx *FakeTxContext) CoinSupply(coinType string) (uint64, error) {
+	integer, err := ctx.coinSupplyField(coinType)
+	if err != nil {
+		return 0, err
+	}
+	var supply uint64
+	err = decodeMoveValue(integer["value"], &supply)
+	return supply, err
+}
+
+// CoinMint 对应 coin::mint 后 coin::deposit，同时增加供应量
+func (ctx *FakeTxContext) CoinMint(address aptos.AccountAddress, coinType string, amount uint64) error {
+	supply, err := ctx.CoinSupply(coinType)
+	if err != nil {
+		return err
+	}
+	integer, _ := ctx.coinSupplyField(coinType)
+	integer["value"] = strconv.FormatUint(supply+amount, 10)
+	return ctx.CoinDeposit(address, coinType, amount)
+}
+
+// CoinBurnSupply 对应 coin::burn，减少供应量
+func (ctx *FakeTxContext) CoinBurnSupply(coinType string, amount uint64) error {
+	supply, err := ctx.CoinSupply(coinType)
+	if err != nil {
+		return err
+	}
+	if supply < amount {
+		return fakeVmError("ARITHMETIC_ERROR")
+	}
+	integer, _ := ctx.coinSupplyField(coinType)
+	integer["value"] = strconv.FormatUint(supply-amount, 10)
+	return nil
+}
+
+// jsonPath 按键或下标读取嵌套的 JSON 值，路径不存在时返回 nil
+func jsonPath(v any, path ...any) any {
+	for _, key := range path {
+		switch k := key.(type) {
+		case string:
+			m, ok := v.(map[string]any)
+			if !ok {
+				return nil
+			}
+			v = m[k]
+		case int:
+			s, ok := v.([]any)
+			if !ok || k >= len(s) {
+				return nil
+			}
+			v = s[k]
+		}
+	}
+	return v
+}
+
+// registerFrameworkFunctions 注册 CLI 用到的 0x1 框架函数
+func (n *FakeFullnode) registerFrameworkFunctions() {
+	n.entryFunctions["0x1::aptos_account::transfer"] = func(ctx *FakeTxContext) error {
+		to, err := ctx.AddressArg(0)
+		if err != nil {
+			return err
+		}
+		amount, err := ctx.U64Arg(1)
+		if err != nil {
+			return err
+		}
+		// 接收方不存在时创建账户并注册 APT
+		ctx.CreateAccount(to)
+		err = ctx.CoinRegister(to, aptosCoinType)
+		if err != nil {
+			return err
+		}
+		err = ctx.CoinWithdraw(ctx.Sender, aptosCoinType, amount)
+		if err != nil {
+			return err
+		}
+		return ctx.CoinDeposit(to, aptosCoinType, amount)
+	}
+	n.entryFunctions["0x1::coin::transfer"] = func(ctx *FakeTxContext) error {
+		coinType, err := ctx.TypeArg(0)
+		if err != nil {
+			return err
+		}
+		to, err := ctx.AddressArg(0)
+		if err != nil {
+			return err
+		}
+		amount, err := ctx.U64Arg(1)
+		if err != nil {
+			return err
+		}
+		err = ctx.CoinWithdraw(ctx.Sender, coinType, amount)
+		if err != nil {
+			return err
+		}
+		return ctx.CoinDeposit(to, coinType, amount)
+	}
+	n.entryFunctions["0x1::managed_coin::register"] = func(ctx *FakeTxContext) error {
+		coinType, err := ctx.TypeArg(0)
+		if err != nil {
+			return err
+		}
+		return ctx.CoinRegister(ctx.Sender, coinType)
+	}
+	n.viewFunctions["0x1::coin::balance"] = func(ctx *FakeTxContext) ([]any, error) {
+		coinType, err := ctx.TypeArg(0)
+		if err != nil {
+			return nil, err
+		}
+		owner, err := ctx.AddressArg(0)
+		if err != nil {
+			return nil, err
+		}
+		// 与链上一致，余额包含迁移到配对 FA 主存储中的部分
+		balance, registered := ctx.CoinBalance(owner, coinType)
+		faBalance, faExists := ctx.pairedFABalance(owner, coinType)
+		if !registered && !faExists {
+			return nil, newFakeMoveAbort("0x1::coin", "ECOIN_STORE_NOT_PUBLISHED", moveErrNotFound, coinErrCoinStoreNotPublished, "Account hasn't registered `CoinStore` for `CoinType`")
+		}
+		return []any{strconv.FormatUint(balance+faBalance, 10)}, nil
+	}
+	n.viewFunctions["0x1::coin::is_account_registered"] = func(ctx *FakeTxContext) ([]any, error) {
+		coinType, err := ctx.TypeArg(0)
+		if err != nil {
+			return nil, err
+		}
+		owner, err := ctx.AddressArg(0)
+		if err != nil {
+			return nil, err
+		}
+		_, registered := ctx.CoinBalance(owner, coinType)
+		_, faExists := ctx.pairedFABalance(owner, coinType)
+		return []any{registered || faExists}, nil
+	}
+	n.viewFunctions["0x1::coin::supply"] = func(ctx *FakeTxContext) ([]any, error) {
+		coinType, err := ctx.TypeArg(0)
+		if err != nil {
+			return nil, err
+		}
+		supply, err := ctx.CoinSupply(coinType)
+		if err != nil {
+			return nil, err
+		}
+		return []any{map[string]any{"vec": []any{strconv.FormatUint(supply, 10)}}}, nil
+	}
+}
+
+// fakeSigner 交易中需要校验认证密钥的签名方
+type fakeSigner struct {
+	address aptos.AccountAddress
+	auth    *crypto.AccountAuthenticator
+}
+
+// fakeTxnSigners 解析交易的签名方、次要签名方和gas支付方
+func fakeTxnSigners(signed *aptos.SignedTransaction) (signers []fakeSigner, secondary []aptos.AccountAddress, payer aptos.AccountAddress) {
+	sender := signed.Transaction.Sender
+	payer = sender
+	switch auth := signed.Authenticator.Auth.(type) {
+	case *aptos.Ed25519TransactionAuthenticator:
+		signers = []fakeSigner{{sender, auth.Sender}}
+	case *aptos.MultiEd25519TransactionAuthenticator:
+		signers = []fakeSigner{{sender, auth.Sender}}
+	case *aptos.SingleSenderTransactionAuthenticator:
+		signers = []fakeSigner{{sender, auth.Sender}}
+	case *aptos.MultiAgentTransactionAuthenticator:
+		signers = []fakeSigner{{sender, auth.Sender}}
+		for i := range auth.SecondarySigners {
+			signers = append(signers, fakeSigner{auth.SecondarySignerAddresses[i], &auth.SecondarySigners[i]})
+		}
+		secondary = auth.SecondarySignerAddresses
+	case *aptos.FeePayerTransactionAuthenticator:
+		signers = []fakeSigner{{sender, auth.Sender}}
+		for i := range auth.SecondarySigners {
+			signers = append(signers, fakeSigner{auth.SecondarySignerAddresses[i], &auth.SecondarySigners[i]})
+		}
+		signers = append(signers, fakeSigner{*auth.FeePayer, auth.FeePayerAuthenticator})
+		secondary = auth.SecondarySignerAddresses
+		payer = *auth.FeePayer
+	}
+	return signers, secondary, payer
+}
+
+// fakeTxnSignaturesValid 校验所有签名方的签名
+// 多签名方和代付交易签名的是 RawTransactionWithData；多签账户的签名不能用 SignedTransaction.Verify，
+// SDK 按公钥位置取签名，位图不连续时会越界
+// 与链上一致，代付交易中代付方以外的签名方可以在代付方地址为 0x0 时签名
+func fakeTxnSignaturesValid(signed *aptos.SignedTransaction) bool {
+	var signingMessage interface{ SigningMessage() ([]byte, error) } = signed.Transaction
+	var unsetFeePayerMessage []byte
+	switch auth := signed.Authenticator.Auth.(type) {
+	case *aptos.MultiAgentTransactionAuthenticator:
+		signingMessage = &aptos.RawTransactionWithData{
+			Variant: aptos.MultiAgentRawTransactionWithDataVariant,
+			Inner: &aptos.MultiAgentRawTransactionWithData{
+				RawTxn:           signed.Transaction,
+				SecondarySigners: auth.SecondarySignerAddresses,
+			},
+		}
+	case *aptos.FeePayerTransactionAuthenticator:
+		signingMessage = &aptos.RawTransactionWithData{
+			Variant: aptos.MultiAgentWithFeePayerRawTransactionWithDataVariant,
+			Inner: &aptos.MultiAgentWithFeePayerRawTransactionWithData{
+				RawTxn:           signed.Transaction,
+				SecondarySigners: auth.SecondarySignerAddresses,
+				FeePayer:         auth.FeePayer,
+			},
+		}
+		unsetFeePayer := &aptos.RawTransactionWithData{
+			Variant: aptos.MultiAgentWithFeePayerRawTransactionWithDataVariant,
+			Inner: &aptos.MultiAgentWithFeePayerRawTransactionWithData{
+				RawTxn:           signed.Transaction,
+				SecondarySigners: auth.SecondarySignerAddresses,
+				FeePayer:         &aptos.AccountZero,
+			},
+		}
+		message, err := unsetFeePayer.SigningMessage()
+		if err != nil {
+			return false
+		}
+		unsetFeePayerMessage = message
+	}
+	message, err := signingMessage.SigningMessage()
+	if err != nil {
+		return false
+	}
+	signers, _, _ := fakeTxnSigners(signed)
+	for i, signer := range signers {
+		if verifyAccountAuthenticator(signer.auth, message) {
+			continue
+		}
+		// 代付方是最后一个签名方，必须对自己的地址签名
+		if unsetFeePayerMessage == nil || i == len(signers)-1 || !verifyAccountAuthenticator(signer.auth, unsetFeePayerMessage) {
+			return false
+		}
+	}
+	return true
+}
+
+// validate 提交时的校验，与全节点的 VM 校验错误码相同
+func (n *FakeFullnode) validate(signed *aptos.SignedTransaction, simulate bool) string {
+	raw := signed.Transaction
+	if raw.ChainId != n.chainId {
+		return "BAD_CHAIN_ID"
+	}
+	if !simulate && !fakeTxnSignaturesValid(signed) {
+		return "INVALID_SIGNATURE"
+	}
+	state := n.current()
+	signers, _, payer := fakeTxnSigners(signed)
+	for _, signer := range signers {
+		account, ok := state.accounts[signer.address]
+		if !ok {
+			return "SENDING_ACCOUNT_DOES_NOT_EXIST"
+		}
+		if !simulate && signer.auth.PubKey() != nil && aptos.AccountAddress(*signer.auth.PubKey().AuthKey()) != account.AuthKey {
+			return "INVALID_AUTH_KEY"
+		}
+	}
+	if raw.SequenceNumber < state.accounts[raw.Sender].SequenceNumber {
+		return "SEQUENCE_NUMBER_TOO_OLD"
+	}
+	if raw.ExpirationTimestampSeconds <= uint64(n.now().Unix()) {
+		return "TRANSACTION_EXPIRED"
+	}
+	ctx := &FakeTxContext{state: state}
+	balance, _ := ctx.CoinBalance(payer, aptosCoinType)
+	if !simulate && balance < raw.MaxGasAmount*raw.GasUnitPrice {
+		return "INSUFFICIENT_BALANCE_FOR_TRANSACTION_FEE"
+	}
+	return ""
+}
+
+// fakeExecution 交易执行结果
+type fakeExecution struct {
+	state    *fakeState
+	success  bool
+	vmStatus string
+	events   []*fakeEvent
+}
+
+// execute 在 base 的副本上执行交易：序列号加一并扣除gas，入口函数失败时只保留这两项修改
+func (n *FakeFullnode) execute(base *fakeState, signed *aptos.SignedTransaction, version uint64) *fakeExecution {
+	raw := signed.Transaction
+	_, secondary, payer := fakeTxnSigners(signed)
+
+	state := base.clone()
+	state.createAccount(raw.Sender).SequenceNumber++
+	gasCtx := &FakeTxContext{state: state}
+	balance, _ := gasCtx.CoinBalance(payer, aptosCoinType)
+	if balance > 0 {
+		gasCtx.setCoinValue(payer, aptosCoinType, balance-min(balance, fakeNodeGasUsed*raw.GasUnitPrice))
+	}
+
+	switch payload := raw.Payload.Payload.(type) {
+	case *aptos.EntryFunction:
+		return n.executeEntryFunction(state, payload, append([]aptos.AccountAddress{raw.Sender}, secondary...), version)
+	case *aptos.Multisig:
+		return n.executeMultisig(state, payload, raw.Sender, version)
+	}
+	return &fakeExecution{state: state, vmStatus: fmt.Sprintf("FEATURE_UNDER_GATING: fake node does not support %T payload", raw.Payload.Payload)}
+}
+
+// executeEntryFunction 在 state 的副本上执行入口函数，signers[0] 为发送方，失败时保留 state
+func (n *FakeFullnode) executeEntryFunction(state *fakeState, entryFunction *aptos.EntryFunction, signers []aptos.AccountAddress, version uint64) *fakeExecution {
+	function := fmt.Sprintf("%s::%s::%s", entryFunction.Module.Address.String(), entryFunction.Module.Name, entryFunction.Function)
+	handler, ok := n.entryFunctions[function]
+	if !ok {
+		return &fakeExecution{state: state, vmStatus: "FUNCTION_RESOLUTION_FAILURE"}
+	}
+	ctx := &FakeTxContext{
+		Sender:   signers[0],
+		Signers:  signers,
+		Function: function,
+		Args:     entryFunction.Args,
+		state:    state.clone(),
+		version:  version,
+	}
+	for _, typeArg := range entryFunction.ArgTypes {
+		ctx.TypeArgs = append(ctx.TypeArgs, typeArg.String())
+	}
+	err := handler(ctx)
+	if err != nil {
+		return &fakeExecution{state: state, vmStatus: err.Error()}
+	}
+	return &fakeExecution{state: ctx.state, success: true, vmStatus: "Executed successfully", events: ctx.events}
+}
+
+// commitReady 依次执行序列号与链上一致的交易，直到没有可执行的
+func (n *FakeFullnode) commitReady() int {
+	n.dropExpired()
+	committed := 0
+	for {
+		var ready *fakeTxn
+		for _, txn := range n.transactions {
+			if txn.Committed {
+				continue
+			}
+			account := n.current().accounts[txn.Signed.Transaction.Sender]
+			if account != nil && account.SequenceNumber == txn.Signed.Transaction.SequenceNumber {
+				// 同一序列号有多笔时取gas单价最高的，其余在下一轮因序列号过旧被丢弃
+				if ready == nil || txn.Signed.Transaction.GasUnitPrice > ready.Signed.Transaction.GasUnitPrice {
+					ready = txn
+				}
+			}
+		}
+		if ready == nil {
+			return committed
+		}
+		version := n.ledgerVersion() + 1
+		result := n.execute(n.current(), ready.Signed, version)
+		n.history = append(n.history, result.state)
+		n.appendEvents(result.events)
+		ready.Committed = true
+		ready.Version = version
+		ready.Success = result.success
+		ready.VmStatus = result.vmStatus
+		ready.GasUsed = fakeNodeGasUsed
+		ready.Events = result.events
+		ready.Timestamp = uint64(n.now().UnixMicro())
+		committed++
+		n.dropStale()
+	}
+}
+
+// dropExpired 丢弃内存池中已过期的交易
+func (n *FakeFullnode) dropExpired() {
+	now := uint64(n.now().Unix())
+	for hash, txn := range n.transactions {
+		if !txn.Committed && txn.Signed.Transaction.ExpirationTimestampSeconds <= now {
+			delete(n.transactions, hash)
+		}
+	}
+}
+
+// dropStale 丢弃内存池中序列号已被消耗的交易
+func (n *FakeFullnode) dropStale() {
+	for hash, txn := range n.transactions {
+		if txn.Committed {
+			continue
+		}
+		account := n.current().accounts[txn.Signed.Transaction.Sender]
+		if account != nil && txn.Signed.Transaction.SequenceNumber < account.SequenceNumber {
+			delete(n.transactions, hash)
+		}
+	}
+}
+
+// routes 注册 REST 接口
+func (n *FakeFullnode) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1", n.handleLedgerInfo)
+	mux.HandleFunc("GET /v1/{$}", n.handleLedgerInfo)
+	mux.HandleFunc("GET /v1/estimate_gas_price", n.handleEstimateGasPrice)
+	mux.HandleFunc("GET /v1/accounts/{address}", n.handleAccount)
+	mux.HandleFunc("GET /v1/accounts/{address}/resources", n.handleAccountResources)
+	mux.HandleFunc("GET /v1/accounts/{address}/resource/{type...}", n.handleAccountResource)
+	mux.HandleFunc("GET /v1/accounts/{address}/events/{handle...}", n.handleEventsByHandle)
+	mux.HandleFunc("POST /v1/transactions", n.handleSubmitTransaction)
+	mux.HandleFunc("POST /v1/transactions/simulate", n.handleSimulateTransaction)
+	mux.HandleFunc("GET /v1/transactions/by_hash/{hash}", n.handleTransactionByHash)
+	mux.HandleFunc("GET /v1/transactions/wait_by_hash/{hash}", n.handleTransactionByHash)
+	mux.HandleFunc("POST /v1/view", n.handleView)
+	mux.HandleFunc("POST /mint", n.handleFaucetMint)
+	return mux
+}
+
+// writeJSON 写入 JSON 响应和账本信息头
+func (n *FakeFullnode) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Aptos-Chain-Id", strconv.Itoa(int(n.chainId)))
+	w.Header().Set("X-Aptos-Ledger-Version", strconv.FormatUint(n.ledgerVersion(), 10))
+	w.Header().Set("X-Aptos-Ledger-TimestampUsec", strconv.FormatInt(n.now().UnixMicro(), 10))
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError 写入与全节点格式相同的错误
+func (n *FakeFullnode) writeError(w http.ResponseWriter, status int, errorCode string, message string) {
+	n.writeJSON(w, status, map[string]any{"message": message, "error_code": errorCode, "vm_error_code": nil})
+}
+
+// pathAddress 解析路径中的账户地址
+func (n *FakeFullnode) pathAddress(w http.ResponseWriter, r *http.Request) (aptos.AccountAddress, bool) {
+	address := aptos.AccountAddress{}
+	err := address.ParseStringRelaxed(r.PathValue("address"))
+	if err != nil {
+		n.writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("invalid address %s", r.PathValue("address")))
+		return address, false
+	}
+	return address, true
+}
+
+// stateAt 按 ledger_version 参数返回历史状态，未指定时返回最新状态
+func (n *FakeFullnode) stateAt(w http.ResponseWriter, r *http.Request) (*fakeState, bool) {
+	versionStr := r.URL.Query().Get("ledger_version")
+	if versionStr == "" {
+		return n.current(), true
+	}
+	version, err := strconv.ParseUint(versionStr, 10, 64)
+	if err != nil {
+		n.writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("invalid ledger_version %s", versionStr))
+		return nil, false
+	}
+	if version > n.ledgerVersion() {
+		n.writeError(w, http.StatusNotFound, "version_not_found", fmt.Sprintf("Ledger version(%d) not found, latest version: %d", version, n.ledgerVersion()))
+		return nil, false
+	}
+	return n.history[version], true
+}
+
+// accountAt 返回路径中账户在请求版本的状态，不存在时写入 404
+func (n *FakeFullnode) accountAt(w http.ResponseWriter, r *http.Request) (*fakeAccount, bool) {
+	address, ok := n.pathAddress(w, r)
+	if !ok {
+		return nil, false
+	}
+	state, ok := n.stateAt(w, r)
+	if !ok {
+		return nil, false
+	}
+	account, ok := state.accounts[address]
+	if !ok {
+		n.writeError(w, http.StatusNotFound, "account_not_found", fmt.Sprintf("Account not found by Address(%s)", address.String()))
+		return nil, false
+	}
+	return account, true
+}
+
+func (n *FakeFullnode) handleLedgerInfo(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	version := strconv.FormatUint(n.ledgerVersion(), 10)
+	n.writeJSON(w, http.StatusOK, map[string]any{
+		"chain_id":              n.chainId,
+		"epoch":                 "1",
+		"ledger_version":        version,
+		"oldest_ledger_version": "0",
+		"ledger_timestamp":      strconv.FormatInt(n.now().UnixMicro(), 10),
+		"node_role":             "full_node",
+		"oldest_block_height":   "0",
+		"block_height":          version,
+		"git_hash":              "fake",
+	})
+}
+
+func (n *FakeFullnode) handleEstimateGasPrice(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.writeJSON(w, http.StatusOK, map[string]any{
+		"deprioritized_gas_estimate": n.gasEstimate,
+		"gas_estimate":               n.gasEstimate,
+		"prioritized_gas_estimate":   n.gasEstimate * 3 / 2,
+	})
+}
+
+func (n *FakeFullnode) handleAccount(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	account, ok := n.accountAt(w, r)
+	if !ok {
+		return
+	}
+	n.writeJSON(w, http.StatusOK, map[string]any{
+		"sequence_number":    strconv.FormatUint(account.SequenceNumber, 10),
+		"authentication_key": fullAddressHex(account.AuthKey),
+	})
+}
+
+// fullAddressHex 认证密钥总是输出完整的 32 字节
+func fullAddressHex(address aptos.AccountAddress) string {
+	return "0x" + hex.EncodeToString(address[:])
+}
+
+func (n *FakeFullnode) handleAccountResources(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	account, ok := n.accountAt(w, r)
+	if !ok {
+		return
+	}
+	n.writeJSON(w, http.StatusOK, account.resources())
+}
+
+func (n *FakeFullnode) handleAccountResource(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	account, ok := n.accountAt(w, r)
+	if !ok {
+		return
+	}
+	resourceType := canonicalTypeString(r.PathValue("type"))
+	data, ok := account.Resources[resourceType]
+	if resourceType == "0x1::account::Account" {
+		data, ok = account.accountResource(), true
+	}
+	if !ok {
+		n.writeError(w, http.StatusNotFound, "resource_not_found", fmt.Sprintf("Resource not found by Address(%s), Struct tag(%s)", r.PathValue("address"), resourceType))
+		return
+	}
+	n.writeJSON(w, http.StatusOK, map[string]any{"type": resourceType, "data": data})
+}
+
+// handleEventsByHandle 处理 /accounts/{address}/events/{struct_tag}/{field_name}
+func (n *FakeFullnode) handleEventsByHandle(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	account, ok := n.accountAt(w, r)
+	if !ok {
+		return
+	}
+	handlePath := r.PathValue("handle")
+	slash := strings.LastIndex(handlePath, "/")
+	if slash < 0 {
+		n.writeError(w, http.StatusBadRequest, "invalid_input", "event handle must be struct_tag/field_name")
+		return
+	}
+	structTag := canonicalTypeString(handlePath[:slash])
+	field := handlePath[slash+1:]
+	resource, ok := account.Resources[structTag]
+	if !ok {
+		n.writeError(w, http.StatusNotFound, "resource_not_found", fmt.Sprintf("Resource not found by Address(%s), Struct tag(%s)", r.PathValue("address"), structTag))
+		return
+	}
+	handle := &EventHandle{}
+	err := decodeMoveValue(resource[field], handle)
+	if err != nil {
+		n.writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("field %s of %s is not an event handle", field, structTag))
+		return
+	}
+	handleAddress := aptos.AccountAddress{}
+	_ = handleAddress.ParseStringRelaxed(handle.Guid.Id.Addr)
+	events := n.events[fmt.Sprintf("%s/%d", handleAddress.String(), handle.Guid.Id.CreationNum)]
+
+	query := r.URL.Query()
+	limit := fakeNodeDefaultEventLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err = strconv.ParseUint(limitStr, 10, 64)
+		if err != nil || limit == 0 {
+			n.writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("invalid limit %s", limitStr))
+			return
+		}
+	}
+	limit = min(limit, fakeNodeMaxEventLimit)
+	// 未指定 start 时返回最近的 limit 个事件
+	start := uint64(0)
+	if uint64(len(events)) > limit {
+		start = uint64(len(events)) - limit
+	}
+	if startStr := query.Get("start"); startStr != "" {
+		start, err = strconv.ParseUint(startStr, 10, 64)
+		if err != nil {
+			n.writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("invalid start %s", startStr))
+			return
+		}
+	}
+	out := []map[string]any{}
+	for _, event := range events {
+		if event.SequenceNumber >= start && uint64(len(out)) < limit {
+			out = append(out, event.toJSON(true))
+		}
+	}
+	n.writeJSON(w, http.StatusOK, out)
+}
+
+// readSignedTransaction 读取 BCS 编码的签名交易
+func (n *FakeFullnode) readSignedTransaction(w http.ResponseWriter, r *http.Request) (*aptos.SignedTransaction, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		n.writeError(w, http.StatusBadRequest, "invalid_input", err.Error())
+		return nil, false
+	}
+	signed := &aptos.SignedTransaction{}
+	err = bcs.Deserialize(signed, body)
+	if err != nil {
+		n.writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("failed to deserialize signed transaction: %v", err))
+		return nil, false
+	}
+	return signed, true
+}
+
+// writeVmError 写入提交校验失败的错误，消息格式与全节点相同
+func (n *FakeFullnode) writeVmError(w http.ResponseWriter, status string) {
+	n.writeError(w, http.StatusBadRequest, "vm_error", fmt.Sprintf("Invalid transaction: Type: Validation Code: %s", status))
+}
+
+func (n *FakeFullnode) handleSubmitTransaction(w http.ResponseWriter, r *http.Request) {
+	signed, ok := n.readSignedTransaction(w, r)
+	if !ok {
+		return
+	}
+	hash, err := signed.Hash()
+	if err != nil {
+		n.writeError(w, http.StatusBadRequest, "invalid_input", err.Error())
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if existing, ok := n.transactions[hash]; ok {
+		// 重复提交同一笔交易是幂等的
+		n.writeJSON(w, http.StatusAccepted, n.pendingJSON(existing))
+		return
+	}
+	status := n.validate(signed, false)
+	if status != "" {
+		n.writeVmError(w, status)
+		return
+	}
+	raw := signed.Transaction
+	for _, txn := range n.transactions {
+		other := txn.Signed.Transaction
+		if !txn.Committed && other.Sender == raw.Sender && other.SequenceNumber == raw.SequenceNumber && other.GasUnitPrice >= raw.GasUnitPrice {
+			n.writeError(w, http.StatusBadRequest, "invalid_transaction_update", "Transaction already in mempool with a different payload")
+			return
+		}
+	}
+	txn := &fakeTxn{Hash: hash, Signed: signed}
+	n.transactions[hash] = txn
+	if n.autoCommit {
+		n.commitReady()
+	}
+	n.writeJSON(w, http.StatusAccepted, n.pendingJSON(txn))
+}
+
+func (n *FakeFullnode) handleSimulateTransaction(w http.ResponseWriter, r *http.Request) {
+	signed, ok := n.readSignedTransaction(w, r)
+	if !ok {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if r.URL.Query().Get("estimate_gas_unit_price") == "true" {
+		signed.Transaction.GasUnitPrice = n.gasEstimate
+	}
+	status := n.validate(signed, true)
+	if status != "" {
+		n.writeVmError(w, status)
+		return
+	}
+	hash, _ := signed.Hash()
+	version := n.ledgerVersion() + 1
+	result := n.execute(n.current(), signed, version)
+	txn := &fakeTxn{
+		Hash:      hash,
+		Signed:    signed,
+		Committed: true,
+		Version:   version,
+		Success:   result.success,
+		VmStatus:  result.vmStatus,
+		GasUsed:   fakeNodeGasUsed,
+		Events:    result.events,
+		Timestamp: uint64(n.now().UnixMicro()),
+	}
+	n.writeJSON(w, http.StatusOK, []map[string]any{n.userTxnJSON(txn)})
+}
+
+// handleFaucetMint 模拟水龙头的 /mint：直接修改状态为账户充值，不产生交易，返回空的交易哈希列表
+func (n *FakeFullnode) handleFaucetMint(w http.ResponseWriter, r *http.Request) {
+	address := aptos.AccountAddress{}
+	err := address.ParseStringRelaxed(r.URL.Query().Get("address"))
+	if err != nil {
+		n.writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("invalid address: %v", err))
+		return
+	}
+	amount, err := strconv.ParseUint(r.URL.Query().Get("amount"), 10, 64)
+	if err != nil {
+		n.writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("invalid amount: %v", err))
+		return
+	}
+	err = n.CreateAccount(address, amount)
+	if err != nil {
+		n.writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	n.writeJSON(w, http.StatusOK, []string{})
+}
+
+func (n *FakeFullnode) handleTransactionByHash(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.dropExpired()
+	txn, ok := n.transactions[r.PathValue("hash")]
+	if !ok {
+		n.writeError(w, http.StatusNotFound, "transaction_not_found", fmt.Sprintf("Transaction not found by Transaction hash(%s)", r.PathValue("hash")))
+		return
+	}
+	if !txn.Committed {
+		n.writeJSON(w, http.StatusOK, n.pendingJSON(txn))
+		return
+	}
+	n.writeJSON(w, http.StatusOK, n.userTxnJSON(txn))
+}
+
+// handleView 处理 BCS 编码的视图函数请求
+func (n *FakeFullnode) handleView(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		n.writeError(w, http.StatusBadRequest, "invalid_input", err.Error())
+		return
+	}
+	des := bcs.NewDeserializer(body)
+	module := aptos.ModuleId{}
+	module.UnmarshalBCS(des)
+	functionName := des.ReadString()
+	typeArgs := bcs.DeserializeSequence[aptos.TypeTag](des)
+	argCount := des.Uleb128()
+	args := make([][]byte, 0, argCount)
+	for i := uint32(0); i < argCount && des.Error() == nil; i++ {
+		args = append(args, des.ReadBytes())
+	}
+	if des.Error() != nil {
+		n.writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("failed to deserialize view request: %v", des.Error()))
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	state, ok := n.stateAt(w, r)
+	if !ok {
+		return
+	}
+	function := fmt.Sprintf("%s::%s::%s", module.Address.String(), module.Name, functionName)
+	handler, ok := n.viewFunctions[function]
+	if !ok {
+		n.writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("FUNCTION_RESOLUTION_FAILURE: %s", function))
+		return
+	}
+	ctx := &FakeTxContext{Function: function, Args: args, state: state.clone(), version: n.ledgerVersion()}
+	for _, typeArg := range typeArgs {
+		ctx.TypeArgs = append(ctx.TypeArgs, typeArg.String())
+	}
+	values, err := handler(ctx)
+	if err != nil {
+		var abort *FakeMoveAbort
+		if errors.As(err, &abort) {
+			n.writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("Error: %s", abort.Error()))
+			return
+		}
+		n.writeError(w, http.StatusBadRequest, "invalid_input", err.Error())
+		return
+	}
+	n.writeJSON(w, http.StatusOK, values)
+}
+
+// payloadJSON 入口函数负载，模拟节点没有 ABI，参数按 BCS 十六进制输出，调用方需持有锁
+func (n *FakeFullnode) payloadJSON(raw *aptos.RawTransaction) map[string]any {
+	switch payload := raw.Payload.Payload.(type) {
+	case *aptos.EntryFunction:
+		return n.entryFunctionJSON(payload)
+	case *aptos.Multisig:
+		out := map[string]any{"type": "multisig_payload", "multisig_address": payload.MultisigAddress.String()}
+		if payload.Payload != nil {
+			if entryFunction, ok := payload.Payload.Payload.(*aptos.EntryFunction); ok {
+				out["transaction_payload"] = n.entryFunctionJSON(entryFunction)
+			}
+		}
+		return out
+	}
+	return map[string]any{"type": "unknown"}
+}
+
+// entryFunctionJSON 入口函数负载的 JSON，调用方需持有锁
+func (n *FakeFullnode) entryFunctionJSON(entryFunction *aptos.EntryFunction) map[string]any {
+	typeArgs := []string{}
+	for _, typeArg := range entryFunction.ArgTypes {
+		typeArgs = append(typeArgs, typeArg.String())
+	}
+	// 已安装的桥合约函数的参数按 REST 的格式输出，其他函数没有参数表，输出 BCS 的十六进制
+	function := fmt.Sprintf("%s::%s::%s", entryFunction.Module.Address.String(), entryFunction.Module.Name, entryFunction.Function)
+	args, ok := []any(nil), false
+	if n.entryFunctions[canonicalFunctionId(function)] != nil {
+		args, ok = entryFunctionArgsJSON(entryFunction, entryFunction.Module.Address)
+	}
+	if !ok {
+		args = []any{}
+		for _, arg := range entryFunction.Args {
+			args = append(args, "0x"+hex.EncodeToString(arg))
+		}
+	}
+	return map[string]any{
+		"type":           "entry_function_payload",
+		"function":       function,
+		"type_arguments": typeArgs,
+		"arguments":      args,
+	}
+}
+
+// pendingJSON 内存池中交易的 JSON，也是提交接口的响应
+func (n *FakeFullnode) pendingJSON(txn *fakeTxn) map[string]any {
+	raw := txn.Signed.Transaction
+	return map[string]any{
+		"type":                      "pending_transaction",
+		"hash":                      txn.Hash,
+		"sender":                    raw.Sender.String(),
+		"sequence_number":           strconv.FormatUint(raw.SequenceNumber, 10),
+		"max_gas_amount":            strconv.FormatUint(raw.MaxGasAmount, 10),
+		"gas_unit_price":            strconv.FormatUint(raw.GasUnitPrice, 10),
+		"expiration_timestamp_secs": strconv.FormatUint(raw.ExpirationTimestampSeconds, 10),
+		"payload":                   n.payloadJSON(raw),
+	}
+}
+
+// userTxnJSON 已上链交易的 JSON
+func (n *FakeFullnode) userTxnJSON(txn *fakeTxn) map[string]any {
+	out := n.pendingJSON(txn)
+	events := []map[string]any{}
+	for _, event := range txn.Events {
+		events = append(events, event.toJSON(false))
+	}
+	out["type"] = "user_transaction"
+	out["version"] = strconv.FormatUint(txn.Version, 10)
+	out["success"] = txn.Success
+	out["vm_status"] = txn.VmStatus
+	out["gas_used"] = strconv.FormatUint(txn.GasUsed, 10)
+	out["events"] = events
+	out["changes"] = []any{}
+	out["timestamp"] = strconv.FormatUint(txn.Timestamp, 10)
+	return out
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strconv"
+
+	"github.com/aptos-labs/aptos-go-sdk/bcs"
+)
+
+// 0x1::code 的中止原因
+const (
+	codeErrModuleNameClash            uint64 = 1
+	codeErrUpgradeImmutable           uint64 = 2
+	codeErrUpgradeWeakerPolicy        uint64 = 3
+	codeErrModuleMissing              uint64 = 4
+	codeErrIncompatiblePolicyDisabled uint64 = 8
+)
+
+// BytesArg 读取 vector<u8> 参数
+func (ctx *FakeTxContext) BytesArg(i int) ([]byte, error) {
+	des, err := ctx.argDeserializer(i)
+	if err != nil {
+		return nil, err
+	}
+	value := des.ReadBytes()
+	return value, finishArg(des)
+}
+
+// BytesVectorArg 读取 vector<vector<u8>> 参数
+func (ctx *FakeTxContext) BytesVectorArg(i int) ([][]byte, error) {
+	des, err := ctx.argDeserializer(i)
+	if err != nil {
+		return nil, err
+	}
+	values := bcs.DeserializeSequenceWithFunction(des, func(des *bcs.Deserializer, out *[]byte) {
+		*out = des.ReadBytes()
+	})
+	return values, finishArg(des)
+}
+
+// publishedPackageJSON 按 REST 的格式编码 PackageRegistry 中的一个包，源码和清单不保存
+func publishedPackageJSON(metadata *PackageMetadata) map[string]any {
+	modules := []any{}
+	for _, module := range metadata.Modules {
+		modules = append(modules, map[string]any{
+			"name":       module,
+			"source":     "0x",
+			"source_map": "0x",
+			"extension":  map[string]any{"vec": []any{}},
+		})
+	}
+	deps := []any{}
+	for _, dep := range metadata.Deps {
+		deps = append(deps, map[string]any{
+			"account":      dep.Account.String(),
+			"package_name": dep.PackageName,
+		})
+	}
+	return map[string]any{
+		"name":           metadata.Name,
+		"upgrade_policy": map[string]any{"policy": float64(metadata.UpgradePolicy)},
+		"upgrade_number": strconv.FormatUint(metadata.UpgradeNumber, 10),
+		"source_digest":  metadata.SourceDigest,
+		"manifest":       "0x",
+		"modules":        modules,
+		"deps":           deps,
+		"extension":      map[string]any{"vec": []any{}},
+	}
+}
+
+// registerCodeFunctions 注册 0x1::code::publish_package_txn
+// 只检查 0x1::code 中的包级规则并记录 PackageRegistry，不校验字节码，模块的入口函数仍由 HandleEntryFunction 注册
+func (n *FakeFullnode) registerCodeFunctions() {
+	n.entryFunctions["0x1::code::publish_package_txn"] = func(ctx *FakeTxContext) error {
+		metadataBCS, err := ctx.BytesArg(0)
+		if err != nil {
+			return err
+		}
+		code, err := ctx.BytesVectorArg(1)
+		if err != nil {
+			return err
+		}
+		metadata, err := decodePackageMetadata(metadataBCS)
+		if err != nil || len(code) != len(metadata.Modules) {
+			return fakeVmError("FAILED_TO_DESERIALIZE_ARGUMENT")
+		}
+		for _, module := range code {
+			if !bytes.HasPrefix(module, moveBytecodeMagic) {
+				return fakeVmError("CODE_DESERIALIZATION_ERROR")
+			}
+		}
+		if metadata.UpgradePolicy == upgradePolicyArbitrary {
+			return newFakeMoveAbort("0x1::code", "EINCOMPATIBLE_POLICY_DISABLED", moveErrInvalidArgument, codeErrIncompatiblePolicyDisabled, "Cannot publish package with arbitrary upgrade policy")
+		}
+
+		registry, ok := ctx.Resource(ctx.Sender, packageRegistryType)
+		if !ok {
+			registry = map[string]any{"packages": []any{}}
+			err = ctx.MoveTo(ctx.Sender, packageRegistryType, registry)
+			if err != nil {
+				return err
+			}
+		}
+		packages := registry["packages"].([]any)
+		index := len(packages)
+		for i, raw := range packages {
+			published := publishedPackage{}
+			_ = decodeMoveValue(raw, &published)
+			if published.Name != metadata.Name {
+				for _, module := range published.Modules {
+					if slices.Contains(metadata.Modules, module.Name) {
+						return newFakeMoveAbort("0x1::code", "EMODULE_NAME_CLASH", moveErrAlreadyExists, codeErrModuleNameClash, "Package contains duplicate module names with existing modules publised in other packages on this address")
+					}
+				}
+				continue
+			}
+			if published.UpgradePolicy.Policy == upgradePolicyImmutable {
+				return newFakeMoveAbort("0x1::code", "EUPGRADE_IMMUTABLE", moveErrInvalidArgument, codeErrUpgradeImmutable, "Cannot upgrade an immutable package")
+			}
+			if metadata.UpgradePolicy < published.UpgradePolicy.Policy {
+				return newFakeMoveAbort("0x1::code", "EUPGRADE_WEAKER_POLICY", moveErrInvalidArgument, codeErrUpgradeWeakerPolicy, "Cannot downgrade a package's upgradability policy")
+			}
+			for _, module := range published.Modules {
+				if !slices.Contains(metadata.Modules, module.Name) {
+					return newFakeMoveAbort("0x1::code", "EMODULE_MISSING", moveErrInvalidArgument, codeErrModuleMissing, "Cannot delete a module that was published in the same package")
+				}
+			}
+			index = i
+			metadata.UpgradeNumber = published.UpgradeNumber + 1
+		}
+		if index == len(packages) {
+			metadata.UpgradeNumber = 0
+			packages = append(packages, nil)
+		}
+		packages[index] = publishedPackageJSON(metadata)
+		registry["packages"] = packages
+		return nil
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+const (
+	fungibleStoreType = "0x1::fungible_asset::FungibleStore"
+	objectCoreType    = "0x1::object::ObjectCore"
+)
+
+// 0x1::fungible_asset 的中止原因
+const (
+	faErrInsufficientBalance uint64 = 4
+)
+
+// pairedMetadataAddress 模拟节点中币类型配对的 FA 元数据对象地址
+// 链上除 APT 外的元数据对象地址由 GUID 生成，无法预先计算，这里用 0xa 下以币类型为种子的命名对象代替
+func pairedMetadataAddress(coinType string) aptos.AccountAddress {
+	fungibleAsset := aptos.AccountAddress{}
+	fungibleAsset[31] = 0xa
+	return fungibleAsset.NamedObjectAddress([]byte(canonicalTypeString(coinType)))
+}
+
+// primaryStoreAddress 对应 primary_fungible_store::primary_store_address，与链上的推导方式相同
+func primaryStoreAddress(owner aptos.AccountAddress, metadata aptos.AccountAddress) aptos.AccountAddress {
+	return owner.ObjectAddressFromObject(&metadata)
+}
+
+// PairedMetadata 对应 coin::paired_metadata，币类型尚未配对时 ok 为 false
+func (ctx *FakeTxContext) PairedMetadata(coinType string) (metadata aptos.AccountAddress, ok bool) {
+	metadata = pairedMetadataAddress(coinType)
+	return metadata, ctx.Exists(metadata, faMetadataType)
+}
+
+// ensurePairedMetadata 币类型尚未配对时按 CoinInfo 创建 FA 元数据对象
+func (ctx *FakeTxContext) ensurePairedMetadata(coinType string) (aptos.AccountAddress, error) {
+	metadata, ok := ctx.PairedMetadata(coinType)
+	if ok {
+		return metadata, nil
+	}
+	address, err := coinTypeAddress(coinType)
+	if err != nil {
+		return metadata, err
+	}
+	info, ok := ctx.Resource(address, coinInfoType(coinType))
+	if !ok {
+		return metadata, newFakeMoveAbort("0x1::coin", "ECOIN_INFO_NOT_PUBLISHED", moveErrNotFound, coinErrCoinInfoNotPublished, "`CoinType` is not registered as a coin")
+	}
+	err = ctx.MoveTo(metadata, objectCoreType, map[string]any{
+		"owner":                  "0xa",
+		"allow_ungated_transfer": false,
+		"guid_creation_num":      "0",
+		"transfer_events":        ctx.NewEventHandle(metadata),
+	})
+	if err != nil {
+		return metadata, err
+	}
+	return metadata, ctx.MoveTo(metadata, faMetadataType, map[string]any{
+		"name":        info["name"],
+		"symbol":      info["symbol"],
+		"decimals":    info["decimals"],
+		"icon_uri":    "",
+		"project_uri": "",
+	})
+}
+
+// FABalance 对应 primary_fungible_store::balance，主存储不存在时 exists 为 false
+func (ctx *FakeTxContext) FABalance(owner aptos.AccountAddress, metadata aptos.AccountAddress) (balance uint64, exists bool) {
+	store, ok := ctx.Resource(primaryStoreAddress(owner, metadata), fungibleStoreType)
+	if !ok {
+		return 0, false
+	}
+	value := struct {
+		Balance uint64 `json:"balance"`
+	}{}
+	_ = decodeMoveValue(store, &value)
+	return value.Balance, true
+}
+
+// ensurePrimaryStore 对应 primary_fungible_store::ensure_primary_store_exists
+func (ctx *FakeTxContext) ensurePrimaryStore(owner aptos.AccountAddress, metadata aptos.AccountAddress) (map[string]any, error) {
+	storeAddress := primaryStoreAddress(owner, metadata)
+	store, ok := ctx.Resource(storeAddress, fungibleStoreType)
+	if ok {
+		return store, nil
+	}
+	err := ctx.MoveTo(storeAddress, objectCoreType, map[string]any{
+		"owner":                  owner.String(),
+		"allow_ungated_transfer": false,
+		"guid_creation_num":      "0",
+		"transfer_events":        ctx.NewEventHandle(storeAddress),
+	})
+	if err != nil {
+		return nil, err
+	}
+	store = map[string]any{
+		"metadata": map[string]any{"inner": metadata.String()},
+		"balance":  "0",
+		"frozen":   false,
+	}
+	return store, ctx.MoveTo(storeAddress, fungibleStoreType, store)
+}
+
+// FADeposit 对应 primary_fungible_store::deposit，主存储不存在时先创建
+func (ctx *FakeTxContext) FADeposit(owner aptos.AccountAddress, metadata aptos.AccountAddress, amount uint64) error {
+	balance, _ := ctx.FABalance(owner, metadata)
+	store, err := ctx.ensurePrimaryStore(owner, metadata)
+	if err != nil {
+		return err
+	}
+	store["balance"] = strconv.FormatUint(balance+amount, 10)
+	return nil
+}
+
+// FAWithdraw 对应 primary_fungible_store::withdraw，余额不足或主存储不存在时中止
+func (ctx *FakeTxContext) FAWithdraw(owner aptos.AccountAddress, metadata aptos.AccountAddress, amount uint64) error {
+	balance, _ := ctx.FABalance(owner, metadata)
+	if balance < amount {
+		return newFakeMoveAbort("0x1::fungible_asset", "EINSUFFICIENT_BALANCE", moveErrInvalidArgument, faErrInsufficientBalance, "Insufficient balance in the fungible store.")
+	}
+	if amount == 0 {
+		return nil
+	}
+	store, _ := ctx.Resource(primaryStoreAddress(owner, metadata), fungibleStoreType)
+	store["balance"] = strconv.FormatUint(balance-amount, 10)
+	return nil
+}
+
+// pairedFABalance 账户在币类型配对的 FA 主存储中的余额
+func (ctx *FakeTxContext) pairedFABalance(owner aptos.AccountAddress, coinType string) (balance uint64, exists bool) {
+	metadata, ok := ctx.PairedMetadata(coinType)
+	if !ok {
+		return 0, false
+	}
+	return ctx.FABalance(owner, metadata)
+}
+
+// CoinMigrate 对应 coin::migrate_to_fungible_store：把 CoinStore 中的余额转入配对 FA 的主存储并删除 CoinStore，
+// 已迁移的账户不做修改
+// 桥参考模型只跟踪 CoinStore，迁移后的账户不再参与模型中的桥操作
+func (ctx *FakeTxContext) CoinMigrate(owner aptos.AccountAddress, coinType string) error {
+	balance, registered := ctx.CoinBalance(owner, coinType)
+	if !registered {
+		return nil
+	}
+	metadata, err := ctx.ensurePairedMetadata(coinType)
+	if err != nil {
+		return err
+	}
+	err = ctx.FADeposit(owner, metadata, balance)
+	if err != nil {
+		return err
+	}
+	delete(ctx.state.accounts[owner].Resources, coinStoreType(coinType))
+	return nil
+}
+
+// registerFungibleAssetFunctions 注册 FA 迁移相关的 0x1 框架函数
+func (n *FakeFullnode) registerFungibleAssetFunctions() {
+	n.entryFunctions["0x1::coin::migrate_to_fungible_store"] = func(ctx *FakeTxContext) error {
+		coinType, err := ctx.TypeArg(0)
+		if err != nil {
+			return err
+		}
+		return ctx.CoinMigrate(ctx.Sender, coinType)
+	}
+	n.entryFunctions["0x1::primary_fungible_store::transfer"] = func(ctx *FakeTxContext) error {
+		metadata, err := ctx.AddressArg(0)
+		if err != nil {
+			return err
+		}
+		recipient, err := ctx.AddressArg(1)
+		if err != nil {
+			return err
+		}
+		amount, err := ctx.U64Arg(2)
+		if err != nil {
+			return err
+		}
+		if !ctx.Exists(metadata, faMetadataType) {
+			return fakeVmError("MISSING_DATA")
+		}
+		err = ctx.FAWithdraw(ctx.Sender, metadata, amount)
+		if err != nil {
+			return err
+		}
+		return ctx.FADeposit(recipient, metadata, amount)
+	}
+	n.viewFunctions["0x1::coin::paired_metadata"] = func(ctx *FakeTxContext) ([]any, error) {
+		coinType, err := ctx.TypeArg(0)
+		if err != nil {
+			return nil, err
+		}
+		metadata, ok := ctx.PairedMetadata(coinType)
+		if !ok {
+			return []any{map[string]any{"vec": []any{}}}, nil
+		}
+		return []any{map[string]any{"vec": []any{map[string]any{"inner": metadata.String()}}}}, nil
+	}
+	n.viewFunctions["0x1::primary_fungible_store::balance"] = func(ctx *FakeTxContext) ([]any, error) {
+		owner, err := ctx.AddressArg(0)
+		if err != nil {
+			return nil, err
+		}
+		metadata, err := ctx.AddressArg(1)
+		if err != nil {
+			return nil, err
+		}
+		balance, _ := ctx.FABalance(owner, metadata)
+		return []any{strconv.FormatUint(balance, 10)}, nil
+	}
+	n.viewFunctions["0x1::primary_fungible_store::primary_store_exists"] = func(ctx *FakeTxContext) ([]any, error) {
+		owner, err := ctx.AddressArg(0)
+		if err != nil {
+			return nil, err
+		}
+		metadata, err := ctx.AddressArg(1)
+		if err != nil {
+			return nil, err
+		}
+		_, exists := ctx.FABalance(owner, metadata)
+		return []any{exists}, nil
+	}
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/bcs"
+)
+
+const multisigAccountType = "0x1::multisig_account::MultisigAccount"
+
+// multisigAccountDomainSeparator 多签账户地址种子的前缀，与 0x1::multisig_account 中的 DOMAIN_SEPARATOR 相同
+const multisigAccountDomainSeparator = "aptos_framework::multisig_account"
+
+// 0x1::multisig_account 的中止原因
+const (
+	multisigErrDuplicateOwner             uint64 = 1
+	multisigErrPayloadCannotBeEmpty       uint64 = 4
+	multisigErrInvalidSignaturesRequired  uint64 = 11
+	multisigErrNotEnoughRejections        uint64 = 10
+	multisigErrAccountNotMultisig         uint64 = 2002
+	multisigErrNotOwner                   uint64 = 2003
+	multisigErrTransactionNotFound        uint64 = 2006
+	multisigErrNotEnoughApprovals         uint64 = 2009
+	multisigErrPayloadDoesNotMatch        uint64 = 2010
+	multisigErrTransactionPayloadNotFound uint64 = 2011
+)
+
+// nextMultisigAccountAddress 对应 multisig_account::get_next_multisig_account_address，
+// 由创建者地址和创建交易执行前的序列号推导
+func nextMultisigAccountAddress(creator aptos.AccountAddress, sequenceNumber uint64) aptos.AccountAddress {
+	nonce, _ := bcs.SerializeU64(sequenceNumber)
+	return creator.ResourceAccount(append([]byte(multisigAccountDomainSeparator), nonce...))
+}
+
+// multisigAccount 读取多签账户资源，地址不是多签账户时中止
+func (ctx *FakeTxContext) multisigAccount(address aptos.AccountAddress) (map[string]any, error) {
+	account, ok := ctx.Resource(address, multisigAccountType)
+	if !ok {
+		return nil, newFakeMoveAbort("0x1::multisig_account", "EACCOUNT_NOT_MULTISIG", moveErrInvalidState, multisigErrAccountNotMultisig, "Specified account is not a multisig account.")
+	}
+	return account, nil
+}
+
+// multisigOwnerAccount 读取多签账户资源并检查 owner 是所有者
+func (ctx *FakeTxContext) multisigOwnerAccount(address aptos.AccountAddress, owner aptos.AccountAddress) (map[string]any, error) {
+	account, err := ctx.multisigAccount(address)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range account["owners"].([]any) {
+		if raw.(string) == owner.String() {
+			return account, nil
+		}
+	}
+	return nil, newFakeMoveAbort("0x1::multisig_account", "ENOT_OWNER", moveErrPermissionDenied, multisigErrNotOwner, "Account executing this operation is not an owner of the multisig account.")
+}
+
+// multisigU64 读取多签账户资源中的 u64 字段
+func multisigU64(account map[string]any, field string) uint64 {
+	value, _ := strconv.ParseUint(account[field].(string), 10, 64)
+	return value
+}
+
+// multisigTransaction 读取待执行的提案，序号不在待执行范围内时中止
+// 链上提案存在 Table 中，模拟节点直接以序号为键存在资源的 transactions 字段里
+func multisigTransaction(account map[string]any, sequenceNumber uint64) (map[string]any, error) {
+	if sequenceNumber <= multisigU64(account, "last_executed_sequence_number") || sequenceNumber >= multisigU64(account, "next_sequence_number") {
+		return nil, newFakeMoveAbort("0x1::multisig_account", "ETRANSACTION_NOT_FOUND", moveErrNotFound, multisigErrTransactionNotFound, "Transaction with specified id cannot be found.")
+	}
+	return account["transactions"].(map[string]any)[strconv.FormatUint(sequenceNumber, 10)].(map[string]any), nil
+}
+
+// multisigVotes 统计提案的赞成和反对票数
+func multisigVotes(txn map[string]any) (approvals uint64, rejections uint64) {
+	for _, raw := range txn["votes"].(map[string]any)["data"].([]any) {
+		if raw.(map[string]any)["value"].(bool) {
+			approvals++
+		} else {
+			rejections++
+		}
+	}
+	return approvals, rejections
+}
+
+// multisigVote 记录或改投 owner 的票
+func multisigVote(txn map[string]any, owner aptos.AccountAddress, approve bool) {
+	votes := txn["votes"].(map[string]any)
+	data := votes["data"].([]any)
+	for _, raw := range data {
+		vote := raw.(map[string]any)
+		if vote["key"] == owner.String() {
+			vote["value"] = approve
+			return
+		}
+	}
+	votes["data"] = append(data, map[string]any{"key": owner.String(), "value": approve})
+}
+
+// resolveMultisigTransaction 移除序号最小的待执行提案
+func resolveMultisigTransaction(account map[string]any) {
+	sequenceNumber := multisigU64(account, "last_executed_sequence_number") + 1
+	delete(account["transactions"].(map[string]any), strconv.FormatUint(sequenceNumber, 10))
+	account["last_executed_sequence_number"] = strconv.FormatUint(sequenceNumber, 10)
+}
+
+// registerMultisigAccountFunctions 注册 0x1::multisig_account 中创建账户、提案、投票的函数和查询提案的视图函数
+// 不支持修改所有者、门限和元数据，也不发出事件
+func (n *FakeFullnode) registerMultisigAccountFunctions() {
+	n.entryFunctions["0x1::multisig_account::create_with_owners"] = func(ctx *FakeTxContext) error {
+		des, err := ctx.argDeserializer(0)
+		if err != nil {
+			return err
+		}
+		additionalOwners := bcs.DeserializeSequence[aptos.AccountAddress](des)
+		err = finishArg(des)
+		if err != nil {
+			return err
+		}
+		signaturesRequired, err := ctx.U64Arg(1)
+		if err != nil {
+			return err
+		}
+		owners := []any{ctx.Sender.String()}
+		seen := map[aptos.AccountAddress]bool{ctx.Sender: true}
+		for _, owner := range additionalOwners {
+			if seen[owner] {
+				return newFakeMoveAbort("0x1::multisig_account", "EDUPLICATE_OWNER", moveErrInvalidArgument, multisigErrDuplicateOwner, "Owner list cannot contain the same address more than once.")
+			}
+			seen[owner] = true
+			owners = append(owners, owner.String())
+		}
+		if signaturesRequired == 0 || signaturesRequired > uint64(len(owners)) {
+			return newFakeMoveAbort("0x1::multisig_account", "EINVALID_SIGNATURES_REQUIRED", moveErrInvalidArgument, multisigErrInvalidSignaturesRequired, "Number of signatures required must be more than zero and at most the total number of owners.")
+		}
+		// 执行时发送方的序列号已经加一
+		address := nextMultisigAccountAddress(ctx.Sender, ctx.state.accounts[ctx.Sender].SequenceNumber-1)
+		ctx.CreateAccount(address)
+		return ctx.MoveTo(address, multisigAccountType, map[string]any{
+			"owners":                        owners,
+			"num_signatures_required":       strconv.FormatUint(signaturesRequired, 10),
+			"transactions":                  map[string]any{},
+			"last_executed_sequence_number": "0",
+			"next_sequence_number":          "1",
+		})
+	}
+	n.entryFunctions["0x1::multisig_account::create_transaction"] = func(ctx *FakeTxContext) error {
+		multisigAddress, err := ctx.AddressArg(0)
+		if err != nil {
+			return err
+		}
+		payload, err := ctx.BytesArg(1)
+		if err != nil {
+			return err
+		}
+		if len(payload) == 0 {
+			return newFakeMoveAbort("0x1::multisig_account", "EPAYLOAD_CANNOT_BE_EMPTY", moveErrInvalidArgument, multisigErrPayloadCannotBeEmpty, "Transaction payload cannot be empty.")
+		}
+		account, err := ctx.multisigOwnerAccount(multisigAddress, ctx.Sender)
+		if err != nil {
+			return err
+		}
+		// 创建者自动投赞成票
+		sequenceNumber := multisigU64(account, "next_sequence_number")
+		account["transactions"].(map[string]any)[strconv.FormatUint(sequenceNumber, 10)] = map[string]any{
+			"payload":            map[string]any{"vec": []any{"0x" + hex.EncodeToString(payload)}},
+			"payload_hash":       map[string]any{"vec": []any{}},
+			"votes":              map[string]any{"data": []any{map[string]any{"key": ctx.Sender.String(), "value": true}}},
+			"creator":            ctx.Sender.String(),
+			"creation_time_secs": strconv.FormatInt(n.now().Unix(), 10),
+		}
+		account["next_sequence_number"] = strconv.FormatUint(sequenceNumber+1, 10)
+		return nil
+	}
+	vote := func(approve bool) FakeEntryFunction {
+		return func(ctx *FakeTxContext) error {
+			multisigAddress, err := ctx.AddressArg(0)
+			if err != nil {
+				return err
+			}
+			sequenceNumber, err := ctx.U64Arg(1)
+			if err != nil {
+				return err
+			}
+			account, err := ctx.multisigOwnerAccount(multisigAddress, ctx.Sender)
+			if err != nil {
+				return err
+			}
+			txn, err := multisigTransaction(account, sequenceNumber)
+			if err != nil {
+				return err
+			}
+			multisigVote(txn, ctx.Sender, approve)
+			return nil
+		}
+	}
+	n.entryFunctions["0x1::multisig_account::approve_transaction"] = vote(true)
+	n.entryFunctions["0x1::multisig_account::reject_transaction"] = vote(false)
+	n.entryFunctions["0x1::multisig_account::execute_rejected_transaction"] = func(ctx *FakeTxContext) error {
+		multisigAddress, err := ctx.AddressArg(0)
+		if err != nil {
+			return err
+		}
+		account, err := ctx.multisigOwnerAccount(multisigAddress, ctx.Sender)
+		if err != nil {
+			return err
+		}
+		txn, err := multisigTransaction(account, multisigU64(account, "last_executed_sequence_number")+1)
+		if err != nil {
+			return err
+		}
+		_, rejections := multisigVotes(txn)
+		if rejections < multisigU64(account, "num_signatures_required") {
+			return newFakeMoveAbort("0x1::multisig_account", "ENOT_ENOUGH_REJECTIONS", moveErrInvalidState, multisigErrNotEnoughRejections, "Transaction has not received enough rejections to be officially rejected.")
+		}
+		resolveMultisigTransaction(account)
+		return nil
+	}
+
+	n.viewFunctions["0x1::multisig_account::get_next_multisig_account_address"] = func(ctx *FakeTxContext) ([]any, error) {
+		creator, err := ctx.AddressArg(0)
+		if err != nil {
+			return nil, err
+		}
+		var sequenceNumber uint64
+		if account, ok := ctx.state.accounts[creator]; ok {
+			sequenceNumber = account.SequenceNumber
+		}
+		address := nextMultisigAccountAddress(creator, sequenceNumber)
+		return []any{address.String()}, nil
+	}
+	multisigView := func(fn func(account map[string]any) any) FakeViewFunction {
+		return func(ctx *FakeTxContext) ([]any, error) {
+			multisigAddress, err := ctx.AddressArg(0)
+			if err != nil {
+				return nil, err
+			}
+			account, err := ctx.multisigAccount(multisigAddress)
+			if err != nil {
+				return nil, err
+			}
+			return []any{fn(account)}, nil
+		}
+	}
+	n.viewFunctions["0x1::multisig_account::owners"] = multisigView(func(account map[string]any) any {
+		return account["owners"]
+	})
+	n.viewFunctions["0x1::multisig_account::num_signatures_required"] = multisigView(func(account map[string]any) any {
+		return account["num_signatures_required"]
+	})
+	n.viewFunctions["0x1::multisig_account::last_resolved_sequence_number"] = multisigView(func(account map[string]any) any {
+		return account["last_executed_sequence_number"]
+	})
+	n.viewFunctions["0x1::multisig_account::get_pending_transactions"] = multisigView(func(account map[string]any) any {
+		pending := []any{}
+		for i := multisigU64(account, "last_executed_sequence_number") + 1; i < multisigU64(account, "next_sequence_number"); i++ {
+			txn, _ := multisigTransaction(account, i)
+			pending = append(pending, txn)
+		}
+		return pending
+	})
+}
+
+// executeMultisig 以多签账户身份执行序号最小的待执行提案
+// 发送方必须是所有者，赞成票需达到门限，交易中的负载为空时执行提案存储的负载，否则必须与之相同；
+// 执行成功后移除提案，执行失败时提案保留
+func (n *FakeFullnode) executeMultisig(state *fakeState, payload *aptos.Multisig, sender aptos.AccountAddress, version uint64) *fakeExecution {
+	ctx := &FakeTxContext{Sender: sender, state: state.clone()}
+	entryFunction, err := ctx.multisigExecutablePayload(payload)
+	if err != nil {
+		return &fakeExecution{state: state, vmStatus: err.Error()}
+	}
+	execution := n.executeEntryFunction(ctx.state, entryFunction, []aptos.AccountAddress{payload.MultisigAddress}, version)
+	if !execution.success {
+		return &fakeExecution{state: state, vmStatus: execution.vmStatus}
+	}
+	account, _ := (&FakeTxContext{state: execution.state}).multisigAccount(payload.MultisigAddress)
+	resolveMultisigTransaction(account)
+	return execution
+}
+
+// multisigExecutablePayload 检查提案可以执行并返回要执行的入口函数
+func (ctx *FakeTxContext) multisigExecutablePayload(payload *aptos.Multisig) (*aptos.EntryFunction, error) {
+	account, err := ctx.multisigOwnerAccount(payload.MultisigAddress, ctx.Sender)
+	if err != nil {
+		return nil, err
+	}
+	txn, err := multisigTransaction(account, multisigU64(account, "last_executed_sequence_number")+1)
+	if err != nil {
+		return nil, err
+	}
+	approvals, _ := multisigVotes(txn)
+	if approvals < multisigU64(account, "num_signatures_required") {
+		return nil, newFakeMoveAbort("0x1::multisig_account", "ENOT_ENOUGH_APPROVALS", moveErrInvalidArgument, multisigErrNotEnoughApprovals, "Transaction has not received enough approvals to be executed.")
+	}
+	stored := txn["payload"].(map[string]any)["vec"].([]any)
+	if len(stored) == 0 {
+		return nil, newFakeMoveAbort("0x1::multisig_account", "ETRANSACTION_PAYLOAD_NOT_FOUND", moveErrInvalidArgument, multisigErrTransactionPayloadNotFound, "Transaction payload must be provided when only its hash is stored on chain.")
+	}
+	storedBytes, _ := hex.DecodeString(strings.TrimPrefix(stored[0].(string), "0x"))
+	if payload.Payload != nil {
+		payloadBytes, err := bcs.Serialize(payload.Payload)
+		if err != nil || !bytes.Equal(payloadBytes, storedBytes) {
+			return nil, newFakeMoveAbort("0x1::multisig_account", "EPAYLOAD_DOES_NOT_MATCH", moveErrInvalidArgument, multisigErrPayloadDoesNotMatch, "Provided target function does not match the payload stored in the on-chain transaction.")
+		}
+	}
+	storedPayload := &aptos.MultisigTransactionPayload{}
+	err = bcs.Deserialize(storedPayload, storedBytes)
+	if err != nil {
+		return nil, fakeVmError("FAILED_TO_DESERIALIZE_ARGUMENT")
+	}
+	entryFunction, ok := storedPayload.Payload.(*aptos.EntryFunction)
+	if !ok {
+		return nil, fakeVmError("FEATURE_UNDER_GATING")
+	}
+	return entryFunction, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"strconv"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+const (
+	// fake-node serve 的默认监听地址
+	defaultFakeNodeListenAddr = "127.0.0.1:8090"
+
+	// 模拟节点上桥的默认费用和预充值的 APT 数量
+	defaultFakeNodeBridgeFee uint64 = 1000
+	fakeNodeFundOctas        uint64 = 10_0000_0000
+)
+
+// NewFakeFullnodeOn 在指定地址启动模拟全节点，供其他进程通过 APTOS_NODE_URL 连接
+func NewFakeFullnodeOn(addr string) (*FakeFullnode, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("监听 %s 失败: %v", addr, err)
+	}
+	n := newFakeFullnodeState()
+	n.server = httptest.NewUnstartedServer(n.routes())
+	n.server.Listener.Close()
+	n.server.Listener = listener
+	n.server.Start()
+	return n, nil
+}
+
+// twbtcCoinType 返回 module::btc_tokenv3::BTC
+func twbtcCoinType(moduleAddress aptos.AccountAddress) string {
+	return fmt.Sprintf("%s::btc_tokenv3::BTC", moduleAddress.String())
+}
+
+// SeedBridge 用参考模型实现模块地址下的桥合约，并写入 btc_bridgev3::initialize 执行后的状态
+func (n *FakeFullnode) SeedBridge(moduleAddress aptos.AccountAddress, feeAccount aptos.AccountAddress, fee uint64) error {
+	n.InstallBridgeModel(moduleAddress)
+	return n.Update(func(ctx *FakeTxContext) error {
+		// initialize_module 要求模块账户已注册
+		err := applyBridgeModel(ctx, moduleAddress, func(m *BridgeModel) ([]BridgeModelEvent, error) {
+			return m.Register(moduleAddress)
+		})
+		if err != nil {
+			return err
+		}
+		return applyBridgeModel(ctx, moduleAddress, func(m *BridgeModel) ([]BridgeModelEvent, error) {
+			return m.Initialize(moduleAddress, feeAccount, fee)
+		})
+	})
+}
+
+// fakeNodeAccount 由固定种子生成模拟节点上的账户
+func fakeNodeAccount(seed string) (*aptos.Account, error) {
+	return createAccountFromPrivateKey(hex.EncodeToString(sha256Bytes(seed)))
+}
+
+// printFakeNodeUsage 打印模拟全节点命令的帮助
+func printFakeNodeUsage() {
+	fmt.Println("模拟全节点用法:")
+	fmt.Println("  启动模拟节点: ./main fake-node serve [监听地址] [预充值地址...]")
+	fmt.Println("    在 MODULE_PUBLISHER_ACCOUNT_ADDRESS 下写入初始化后的桥状态(FAKE_NODE_BRIDGE_FEE 指定桥费用)，")
+	fmt.Println("    FAKE_NODE_BRIDGE=none 时不写入桥状态，只安装桥参考模型，用于演练 deploy")
+	fmt.Println("    其他命令设置 APTOS_NODE_URL=http://<监听地址>/v1 后即连接到模拟节点")
+}
+
+// runFakeNodeCommand 处理 fake-node 子命令，不需要私钥
+func runFakeNodeCommand(args []string) {
+	if len(args) < 1 {
+		printFakeNodeUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "serve":
+		listenAddr := defaultFakeNodeListenAddr
+		if len(args) > 1 {
+			listenAddr = args[1]
+		}
+		moduleAddressStr, err := getModuleAddress()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		fee := defaultFakeNodeBridgeFee
+		if feeStr := os.Getenv("FAKE_NODE_BRIDGE_FEE"); feeStr != "" {
+			fee, err = strconv.ParseUint(feeStr, 10, 64)
+			if err != nil || fee == 0 {
+				logError(fmt.Sprintf("无效的FAKE_NODE_BRIDGE_FEE: %s", feeStr))
+				os.Exit(1)
+			}
+		}
+		var funded []aptos.AccountAddress
+		for _, addressStr := range append([]string{moduleAddressStr}, args[min(len(args), 2):]...) {
+			address := aptos.AccountAddress{}
+			err = address.ParseStringRelaxed(addressStr)
+			if err != nil {
+				logError(fmt.Sprintf("解析地址 %s 失败: %v", addressStr, err))
+				os.Exit(1)
+			}
+			funded = append(funded, address)
+		}
+
+		node, err := NewFakeFullnodeOn(listenAddr)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		defer node.Close()
+		for _, address := range funded {
+			err = node.CreateAccount(address, fakeNodeFundOctas)
+			if err != nil {
+				logError(err.Error())
+				os.Exit(1)
+			}
+		}
+		switch os.Getenv("FAKE_NODE_BRIDGE") {
+		case "", "initialized":
+			err = node.SeedBridge(funded[0], funded[0], fee)
+			if err != nil {
+				logError(err.Error())
+				os.Exit(1)
+			}
+		case "none":
+			// 只安装参考模型，由 deploy 发布并初始化
+			node.InstallBridgeModel(funded[0])
+		default:
+			logError(fmt.Sprintf("无效的FAKE_NODE_BRIDGE: %s", os.Getenv("FAKE_NODE_BRIDGE")))
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("模拟全节点已启动: APTOS_NODE_URL=%s APTOS_FAUCET_URL=%s", node.URL(), node.FaucetURL()))
+		logInfo(fmt.Sprintf("已为 %d 个账户各充值 %d octas，按 Ctrl+C 退出", len(funded), fakeNodeFundOctas))
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		<-ctx.Done()
+
+	default:
+		logError(fmt.Sprintf("未知的fake-node子命令: %s", args[0]))
+		printFakeNodeUsage()
+		os.Exit(1)
+	}
+}
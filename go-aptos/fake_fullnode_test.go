@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// newTestBridgeNode 启动已初始化桥的模拟全节点，receivers 已注册 TWBTC，直接拼接 REST 路径的查询也指向该节点
+func newTestBridgeNode(t *testing.T, receivers ...*aptos.Account) (*FakeFullnode, *aptos.Client, *aptos.Account) {
+	t.Helper()
+	node := NewFakeFullnode()
+	t.Cleanup(node.Close)
+	t.Setenv("APTOS_NODE_URL", node.URL())
+	admin, err := fakeNodeAccount(t.Name() + "-admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, account := range append([]*aptos.Account{admin}, receivers...) {
+		err = node.CreateAccount(account.Address, fakeNodeFundOctas)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = node.SeedBridge(admin.Address, admin.Address, defaultFakeNodeBridgeFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := node.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, account := range receivers {
+		_, err = RegisterTWBTC(client, account, admin.Address.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return node, client, admin
+}
+
+func newTestAccount(t *testing.T, seed string) *aptos.Account {
+	t.Helper()
+	account, err := fakeNodeAccount(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return account
+}
+
+func TestFakeFullnodeBridgeConfig(t *testing.T) {
+	_, client, admin := newTestBridgeNode(t)
+	config, err := GetBridgeConfig(client, admin.Address.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Fee != defaultFakeNodeBridgeFee || config.Admin != admin.Address.String() || config.FeeAccount != admin.Address.String() {
+		t.Fatalf("桥配置与写入的不一致: %+v", config)
+	}
+}
+
+func TestFakeFullnodeCoinBalance(t *testing.T) {
+	node, client, admin := newTestBridgeNode(t)
+	moduleAddress := admin.Address.String()
+	user := newTestAccount(t, "fake-node-user")
+	err := node.CreateAccount(user.Address, fakeNodeFundOctas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registered, err := IsTWBTCRegistered(client, user.Address, moduleAddress)
+	if err != nil || registered {
+		t.Fatalf("新账户不应已注册TWBTC: %v", err)
+	}
+	err = node.SetCoinBalance(user.Address, twbtcCoinType(admin.Address), 50000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, err := CheckTWBTCBalance(client, user.Address, moduleAddress)
+	if err != nil || balance.Uint64() != 50000 {
+		t.Fatalf("TWBTC余额应为 50000，实际 %v: %v", balance, err)
+	}
+}
+
+func TestFakeFullnodeEventPaging(t *testing.T) {
+	node, client, admin := newTestBridgeNode(t)
+	moduleAddress := admin.Address.String()
+	err := node.Update(func(ctx *FakeTxContext) error {
+		for i := 1; i <= 3; i++ {
+			err := ctx.EmitEvent(admin.Address, moduleAddress+"::btc_bridgev3::BridgeEvents", "mint_events", moduleAddress+"::btc_bridgev3::MintEvent", map[string]any{
+				"btc_tx_id": fmt.Sprintf("seeded-%d", i),
+				"receiver":  admin.Address.String(),
+				"amount":    strconv.Itoa(i * 1000),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mintEvents, err := GetBridgeMintEvents(client, moduleAddress, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mintEvents) != 2 || mintEvents[0].BtcTxId != "seeded-2" || mintEvents[1].BtcTxId != "seeded-3" || mintEvents[1].Amount != 3000 {
+		t.Fatalf("应按顺序返回最近 2 个铸币事件，实际 %+v", mintEvents)
+	}
+	requestEvents, err := GetRedeemRequestEvents(client, moduleAddress, 10)
+	if err != nil || len(requestEvents) != 0 {
+		t.Fatalf("不应有赎回请求事件，实际 %+v: %v", requestEvents, err)
+	}
+}
+
+func TestFakeFullnodeAPTTransfer(t *testing.T) {
+	user := newTestAccount(t, "fake-node-user")
+	node, client, _ := newTestBridgeNode(t, user)
+	versionBefore := node.LedgerVersion()
+	pinnedBefore, err := client.AccountAPTBalance(user.Address, versionBefore)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = sendAPT(context.Background(), client, user, "0xbeef", big.NewInt(12345))
+	if err != nil {
+		t.Fatal(err)
+	}
+	received, err := checkAPTBalance(context.Background(), client, "0xbeef")
+	if err != nil || received.Uint64() != 12345 {
+		t.Fatalf("接收方APT余额应为 12345，实际 %v: %v", received, err)
+	}
+	pinned, err := client.AccountAPTBalance(user.Address, versionBefore)
+	if err != nil || pinned != pinnedBefore {
+		t.Fatalf("按版本 %d 读取的余额应为转账前的 %d，实际 %d: %v", versionBefore, pinnedBefore, pinned, err)
+	}
+}
+
+func TestFakeFullnodeBridgeMint(t *testing.T) {
+	user := newTestAccount(t, "fake-node-user")
+	_, client, admin := newTestBridgeNode(t, user)
+	moduleAddress := admin.Address.String()
+
+	_, err := mintTWBTC(client, admin, "0xcafe", user.Address, 15000, "btc-tx-1")
+	if err == nil || !strings.Contains(err.Error(), "FUNCTION_RESOLUTION_FAILURE") {
+		t.Fatalf("未注册的入口函数应执行失败，实际: %v", err)
+	}
+	_, err = mintTWBTC(client, admin, moduleAddress, user.Address, twbtcTokenMintFee/2, "btc-tx-1")
+	if err == nil || !strings.Contains(err.Error(), "btc_tokenv3: E_INSUFFICIENT_AMOUNT") {
+		t.Fatalf("金额不超过代币铸币手续费时应中止，实际: %v", err)
+	}
+
+	_, err = mintTWBTC(client, admin, moduleAddress, user.Address, 15000, "btc-tx-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	minted, err := isBtcTxMinted(client, moduleAddress, "btc-tx-1")
+	if err != nil || !minted {
+		t.Fatalf("铸币后应记录 btc-tx-1: %v", err)
+	}
+	balance, err := CheckTWBTCBalance(client, user.Address, moduleAddress)
+	if err != nil || balance.Uint64() != 15000-twbtcTokenMintFee {
+		t.Fatalf("铸币后余额应为 %d，实际 %v: %v", 15000-twbtcTokenMintFee, balance, err)
+	}
+
+	_, err = mintTWBTC(client, admin, moduleAddress, user.Address, 15000, "btc-tx-1")
+	if err == nil || !strings.Contains(err.Error(), "E_ALREADY_MINTED") {
+		t.Fatalf("重复铸币应中止，实际: %v", err)
+	}
+	balance, err = CheckTWBTCBalance(client, user.Address, moduleAddress)
+	if err != nil || balance.Uint64() != 15000-twbtcTokenMintFee {
+		t.Fatalf("中止的交易不应修改余额，实际 %v: %v", balance, err)
+	}
+}
+
+func TestFakeFullnodeFungibleAssetTransfer(t *testing.T) {
+	user := newTestAccount(t, "fake-node-user")
+	recipient := newTestAccount(t, "fake-node-recipient")
+	node, client, admin := newTestBridgeNode(t, user)
+	moduleAddress := admin.Address.String()
+	err := node.SetCoinBalance(user.Address, twbtcCoinType(admin.Address), 55000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = MigrateTWBTC(client, user, moduleAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balances, err := GetTWBTCBalances(client, user.Address, moduleAddress)
+	if err != nil || !balances.Migrated() || balances.Total() != 55000 {
+		t.Fatalf("迁移后余额应全部在FA主存储中，实际 %+v: %v", balances, err)
+	}
+	_, err = SendTWBTC(client, user, recipient.Address, 5000, moduleAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, err := CheckTWBTCBalance(client, user.Address, moduleAddress)
+	if err != nil || balance.Uint64() != 50000 {
+		t.Fatalf("FA转账后用户余额应为 50000，实际 %v: %v", balance, err)
+	}
+	received, err := CheckTWBTCBalance(client, recipient.Address, moduleAddress)
+	if err != nil || received.Uint64() != 5000 {
+		t.Fatalf("FA转账后接收方余额应为 5000，实际 %v: %v", received, err)
+	}
+}
+
+func TestFakeFullnodeRejectsOldSequenceNumber(t *testing.T) {
+	user := newTestAccount(t, "fake-node-user")
+	_, client, admin := newTestBridgeNode(t, user)
+	// 注册 TWBTC 已用掉序列号 0
+	payload, err := aptos.CoinTransferPayload(nil, admin.Address, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawTxn, err := client.BuildTransaction(user.Address, aptos.TransactionPayload{Payload: payload}, aptos.SequenceNumber(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedTxn, err := rawTxn.SignedTransaction(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.SubmitTransaction(signedTxn)
+	if !isSequenceTooOld(err) {
+		t.Fatalf("应返回 SEQUENCE_NUMBER_TOO_OLD，实际: %v", err)
+	}
+}
+
+func TestFakeFullnodeExpiresPendingTransactions(t *testing.T) {
+	user := newTestAccount(t, "fake-node-user")
+	node, client, admin := newTestBridgeNode(t, user)
+	payload, err := aptos.CoinTransferPayload(nil, admin.Address, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node.SetAutoCommit(false)
+	resp, err := buildSignAndSubmitTransaction(client, user, aptos.TransactionPayload{Payload: payload})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn, err := client.TransactionByHash(resp.Hash)
+	if err != nil || node.Pending() != 1 {
+		t.Fatalf("交易应在内存池中，实际 %+v: %v", txn, err)
+	}
+	node.AdvanceTime(time.Duration(aptos.DefaultExpirationSeconds+1) * time.Second)
+	_, err = client.TransactionByHash(resp.Hash)
+	if !isResourceNotFound(err) || node.Pending() != 0 {
+		t.Fatalf("过期交易应被丢弃，实际: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// faMetadataType FA 元数据对象的资源类型
+const faMetadataType = "0x1::fungible_asset::Metadata"
+
+// faMetadataTypeArgs primary_fungible_store 函数的类型参数 <0x1::fungible_asset::Metadata>
+func faMetadataTypeArgs() ([]aptos.TypeTag, error) {
+	metadataType, err := aptos.ParseTypeTag(faMetadataType)
+	if err != nil {
+		return nil, fmt.Errorf("解析FA元数据类型失败: %v", err)
+	}
+	return []aptos.TypeTag{*metadataType}, nil
+}
+
+// TWBTCPairedMetadata 通过 coin::paired_metadata 查询 TWBTC 配对的 FA 元数据对象地址，
+// 尚未配对时 paired 为 false，可以指定账本版本
+func TWBTCPairedMetadata(client *aptos.Client, moduleAddress string, ledgerVersion ...uint64) (metadata aptos.AccountAddress, paired bool, err error) {
+	typeArgs, err := twbtcTypeArgs(moduleAddress)
+	if err != nil {
+		return metadata, false, err
+	}
+	result, err := CoinPairedMetadataView(client, CoinModuleAddress, typeArgs, ledgerVersion...)
+	if err != nil {
+		return metadata, false, fmt.Errorf("查询TWBTC配对的FA元数据失败: %v", err)
+	}
+	if len(result.Vec) == 0 {
+		return metadata, false, nil
+	}
+	err = metadata.ParseStringRelaxed(result.Vec[0].Inner)
+	if err != nil {
+		return metadata, false, fmt.Errorf("解析FA元数据地址失败: %v", err)
+	}
+	return metadata, true, nil
+}
+
+// TWBTCBalances 账户的 TWBTC 分别在 CoinStore 和配对 FA 主存储中的余额
+type TWBTCBalances struct {
+	LedgerVersion   uint64
+	HasCoinStore    bool
+	Coin            uint64
+	Metadata        *aptos.AccountAddress // 为 nil 表示 TWBTC 尚未配对 FA
+	HasPrimaryStore bool
+	FungibleAsset   uint64
+}
+
+// Total 两部分余额之和，与 coin::balance 相同
+func (b *TWBTCBalances) Total() uint64 {
+	return b.Coin + b.FungibleAsset
+}
+
+// Registered 有 CoinStore 或主存储时可以接收 TWBTC
+func (b *TWBTCBalances) Registered() bool {
+	return b.HasCoinStore || b.HasPrimaryStore
+}
+
+// Migrated 已迁移到 FA：没有 CoinStore，余额全部在主存储中
+func (b *TWBTCBalances) Migrated() bool {
+	return !b.HasCoinStore && b.HasPrimaryStore
+}
+
+// GetTWBTCBalances 在同一账本版本上读取账户 CoinStore 和配对 FA 主存储中的 TWBTC 余额，
+// 未指定版本时固定为当前最新版本
+func GetTWBTCBalances(client *aptos.Client, address aptos.AccountAddress, moduleAddress string, ledgerVersion ...uint64) (*TWBTCBalances, error) {
+	version, err := pinLedgerVersion(client, ledgerVersion...)
+	if err != nil {
+		return nil, err
+	}
+	balances := &TWBTCBalances{LedgerVersion: version}
+
+	typeArgs, err := twbtcTypeArgs(moduleAddress)
+	if err != nil {
+		return nil, err
+	}
+	resourceType := fmt.Sprintf("0x1::coin::CoinStore<%s>", typeArgs[0].String())
+	resource, err := client.AccountResource(address, resourceType, version)
+	switch {
+	case err == nil:
+		store := struct {
+			Coin struct {
+				Value uint64 `json:"value"`
+			} `json:"coin"`
+		}{}
+		err = decodeMoveValue(resource["data"], &store)
+		if err != nil {
+			return nil, fmt.Errorf("解码CoinStore失败: %v", err)
+		}
+		balances.HasCoinStore = true
+		balances.Coin = store.Coin.Value
+	case !isResourceNotFound(err):
+		return nil, fmt.Errorf("获取CoinStore失败: %v", err)
+	}
+
+	metadata, paired, err := TWBTCPairedMetadata(client, moduleAddress, version)
+	if err != nil {
+		return nil, err
+	}
+	if !paired {
+		return balances, nil
+	}
+	balances.Metadata = &metadata
+	metadataTypeArgs, err := faMetadataTypeArgs()
+	if err != nil {
+		return nil, err
+	}
+	balances.HasPrimaryStore, err = PrimaryStoreExistsView(client, PrimaryStoreModuleAddress, metadataTypeArgs, address, metadata, version)
+	if err != nil {
+		return nil, fmt.Errorf("查询FA主存储失败: %v", err)
+	}
+	if balances.HasPrimaryStore {
+		balances.FungibleAsset, err = PrimaryStoreBalanceView(client, PrimaryStoreModuleAddress, metadataTypeArgs, address, metadata, version)
+		if err != nil {
+			return nil, fmt.Errorf("查询FA余额失败: %v", err)
+		}
+	}
+	return balances, nil
+}
+
+// printTWBTCBalances 打印余额及其在 CoinStore 和 FA 主存储中的分布
+func printTWBTCBalances(address string, balances *TWBTCBalances) {
+	fmt.Printf("地址 %s 的TWBTC余额: %d Satoshis (账本版本 %d)\n", address, balances.Total(), balances.LedgerVersion)
+	if balances.HasCoinStore {
+		fmt.Printf("  CoinStore: %d\n", balances.Coin)
+	} else {
+		fmt.Println("  CoinStore: 无")
+	}
+	if balances.Metadata == nil {
+		fmt.Println("  FA主存储: TWBTC尚未配对FA")
+		return
+	}
+	if balances.HasPrimaryStore {
+		fmt.Printf("  FA主存储: %d (元数据 %s)\n", balances.FungibleAsset, balances.Metadata.String())
+	} else {
+		fmt.Printf("  FA主存储: 无 (元数据 %s)\n", balances.Metadata.String())
+	}
+}
+
+// buildTWBTCTransferPayload 构建发送 TWBTC 的交易负载：发送方已迁移到 FA 时通过
+// primary_fungible_store::transfer 从主存储发送，否则使用 btc_tokenv3::transfer
+func buildTWBTCTransferPayload(client *aptos.Client, sender aptos.AccountAddress, receiver aptos.AccountAddress, amount uint64, moduleAddress string) (aptos.TransactionPayload, error) {
+	balances, err := GetTWBTCBalances(client, sender, moduleAddress)
+	if err != nil {
+		return aptos.TransactionPayload{}, err
+	}
+	if !balances.Migrated() {
+		return TokenTransferPayload(moduleAddress, receiver, amount)
+	}
+	typeArgs, err := faMetadataTypeArgs()
+	if err != nil {
+		return aptos.TransactionPayload{}, err
+	}
+	return PrimaryStoreTransferPayload(PrimaryStoreModuleAddress, typeArgs, *balances.Metadata, receiver, amount)
+}
+
+// MigrateTWBTC 调用 coin::migrate_to_fungible_store 把账户的 TWBTC 从 CoinStore 迁移到配对 FA 的主存储，
+// 链上会在 TWBTC 尚未配对时创建元数据对象
+func MigrateTWBTC(client *aptos.Client, account aptos.TransactionSigner, moduleAddress string) (string, error) {
+	address := account.AccountAddress()
+	balances, err := GetTWBTCBalances(client, address, moduleAddress)
+	if err != nil {
+		return "", err
+	}
+	if !balances.HasCoinStore {
+		if balances.HasPrimaryStore {
+			return "", fmt.Errorf("账户 %s 已迁移到FA", address.String())
+		}
+		return "", fmt.Errorf("%w: %s", ErrTWBTCNotRegistered, address.String())
+	}
+
+	typeArgs, err := twbtcTypeArgs(moduleAddress)
+	if err != nil {
+		return "", err
+	}
+	payload, err := CoinMigrateToFungibleStorePayload(CoinModuleAddress, typeArgs)
+	if err != nil {
+		return "", err
+	}
+	return submitAndConfirm(client, account, payload, func() (bool, error) {
+		balances, err := GetTWBTCBalances(client, address, moduleAddress)
+		if err != nil {
+			return false, err
+		}
+		return balances.Migrated(), nil
+	})
+}
@@ -4,15 +4,20 @@ go 1.22
 
 toolchain go1.24.1
 
-require github.com/aptos-labs/aptos-go-sdk v1.6.2
+require (
+	github.com/aptos-labs/aptos-go-sdk v1.6.2
+	github.com/btcsuite/btcd/btcec/v2 v2.3.4
+	github.com/coder/websocket v1.8.12
+	github.com/hasura/go-graphql-client v0.13.1
+	golang.org/x/crypto v0.32.0
+)
 
 require (
 	filippo.io/edwards25519 v1.1.0 // indirect
-	github.com/coder/websocket v1.8.12 // indirect
+	github.com/btcsuite/btcd/chaincfg/chainhash v1.0.1 // indirect
+	github.com/decred/dcrd/crypto/blake256 v1.0.1 // indirect
 	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.3.0 // indirect
 	github.com/google/uuid v1.6.0 // indirect
-	github.com/hasura/go-graphql-client v0.13.1 // indirect
 	github.com/hdevalence/ed25519consensus v0.2.0 // indirect
-	golang.org/x/crypto v0.32.0 // indirect
 	golang.org/x/sys v0.29.0 // indirect
 )
@@ -0,0 +1,498 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/hasura/go-graphql-client"
+)
+
+// 事件来源后端，由 EVENT_SOURCE 选择
+const (
+	EventSourceREST    = "rest"
+	EventSourceIndexer = "indexer"
+)
+
+// 每次从索引器拉取的最大事件数
+const indexerEventBatchSize = 100
+
+// EventSource 桥事件来源，REST 和索引器两种后端返回相同的类型，limit 为最近的事件数，按发生顺序排列
+type EventSource interface {
+	BridgeMintEvents(limit uint64) ([]BridgeMintEvent, error)
+	RedeemRequestEvents(limit uint64) ([]BridgeRedeemRequestEvent, error)
+	RedeemPrepareEvents(limit uint64) ([]BridgeRedeemPrepareEvent, error)
+	TokenMintEvents(limit uint64) ([]TokenMintEvent, error)
+	TokenBurnEvents(limit uint64) ([]TokenBurnEvent, error)
+	// EventPage 按句柄内的序列号读取 [start, start+limit) 的事件，start 超出范围时只返回事件总数
+	EventPage(streamName string, start uint64, limit uint64) (*BridgeEventPage, error)
+}
+
+// BridgeEventRecord 从索引器实时收到的一个桥事件
+type BridgeEventRecord struct {
+	Type       string // module::struct，例如 btc_bridgev3::MintEvent
+	Version    uint64
+	EventIndex uint64
+	Event      any // *BridgeMintEvent、*BridgeRedeemRequestEvent、*BridgeRedeemPrepareEvent、*TokenMintEvent 或 *TokenBurnEvent
+}
+
+// bridgeEventTypes 桥合约发出的事件类型
+var bridgeEventTypes = []string{
+	BridgeMintEventStructTag,
+	BridgeRedeemRequestEventStructTag,
+	BridgeRedeemPrepareEventStructTag,
+	TokenMintEventStructTag,
+	TokenBurnEventStructTag,
+}
+
+// newEventSource 按 EVENT_SOURCE 创建事件来源，默认使用 REST
+func newEventSource(client *aptos.Client, moduleAddress string) (EventSource, error) {
+	backend := os.Getenv("EVENT_SOURCE")
+	switch backend {
+	case "", EventSourceREST:
+		return &restEventSource{client: client, moduleAddress: moduleAddress}, nil
+	case EventSourceIndexer:
+		url := os.Getenv("INDEXER_URL")
+		if url == "" {
+			url = aptos.DevnetConfig.IndexerUrl
+		}
+		return NewIndexerEventSource(url, os.Getenv("INDEXER_WS_URL"), moduleAddress)
+	}
+	return nil, fmt.Errorf("未知的事件来源 %s，可选 %s 或 %s", backend, EventSourceREST, EventSourceIndexer)
+}
+
+// restEventSource 通过全节点 REST 接口按事件句柄读取事件
+type restEventSource struct {
+	client        *aptos.Client
+	moduleAddress string
+}
+
+func (s *restEventSource) BridgeMintEvents(limit uint64) ([]BridgeMintEvent, error) {
+	return GetBridgeMintEvents(s.client, s.moduleAddress, limit)
+}
+
+func (s *restEventSource) RedeemRequestEvents(limit uint64) ([]BridgeRedeemRequestEvent, error) {
+	return GetRedeemRequestEvents(s.client, s.moduleAddress, limit)
+}
+
+func (s *restEventSource) RedeemPrepareEvents(limit uint64) ([]BridgeRedeemPrepareEvent, error) {
+	return GetRedeemPrepareEvents(s.client, s.moduleAddress, limit)
+}
+
+func (s *restEventSource) TokenMintEvents(limit uint64) ([]TokenMintEvent, error) {
+	return GetTokenMintEvents(s.client, s.moduleAddress, limit)
+}
+
+func (s *restEventSource) TokenBurnEvents(limit uint64) ([]TokenBurnEvent, error) {
+	return GetTokenBurnEvents(s.client, s.moduleAddress, limit)
+}
+
+func (s *restEventSource) EventPage(streamName string, start uint64, limit uint64) (*BridgeEventPage, error) {
+	return GetBridgeEventPage(s.client, s.moduleAddress, streamName, start, limit)
+}
+
+// IndexerEventSource 通过索引器 GraphQL 接口查询和订阅 events 表
+type IndexerEventSource struct {
+	client        *graphql.Client
+	wsURL         string
+	moduleAddress aptos.AccountAddress
+	retryTimeout  time.Duration // 订阅断开后重连的最长时间
+}
+
+// indexerEvent events 表中的一行
+type indexerEvent struct {
+	Type               string `json:"type"`
+	TransactionVersion uint64 `json:"transaction_version"`
+	EventIndex         uint64 `json:"event_index"`
+	SequenceNumber     uint64 `json:"sequence_number"` // 事件句柄内的序列号
+	Data               any    `json:"data"`
+}
+
+// NewIndexerEventSource 创建索引器事件来源，wsURL 为空时由 url 的 http(s) 换成 ws(s) 得到
+func NewIndexerEventSource(url string, wsURL string, moduleAddress string) (*IndexerEventSource, error) {
+	address := aptos.AccountAddress{}
+	err := address.ParseStringRelaxed(moduleAddress)
+	if err != nil {
+		return nil, fmt.Errorf("解析模块地址失败: %v", err)
+	}
+	if url == "" {
+		return nil, fmt.Errorf("未配置索引器地址")
+	}
+	if wsURL == "" {
+		wsURL = "ws" + strings.TrimPrefix(url, "http")
+	}
+	return &IndexerEventSource{
+		client:        graphql.NewClient(url, http.DefaultClient),
+		wsURL:         wsURL,
+		moduleAddress: address,
+		retryTimeout:  10 * time.Second,
+	}, nil
+}
+
+// eventTypeNames 事件类型在索引器中可能的写法，地址有补零和不补零两种形式
+func (s *IndexerEventSource) eventTypeNames(structTags ...string) []string {
+	long := s.moduleAddress.StringLong()
+	short := "0x" + strings.TrimLeft(strings.TrimPrefix(long, "0x"), "0")
+	var names []string
+	for _, structTag := range structTags {
+		names = append(names, long+"::"+structTag)
+		if short != long {
+			names = append(names, short+"::"+structTag)
+		}
+	}
+	return names
+}
+
+// structTag 去掉事件类型中的地址，返回 module::struct
+func (s *IndexerEventSource) structTag(eventType string) string {
+	parts := strings.SplitN(eventType, "::", 2)
+	if len(parts) != 2 {
+		return eventType
+	}
+	return parts[1]
+}
+
+const indexerLatestEventsQuery = `query BridgeLatestEvents($types: [String!], $limit: Int) {
+  events(where: {indexed_type: {_in: $types}}, order_by: [{transaction_version: desc}, {event_index: desc}], limit: $limit) {
+    type
+    transaction_version
+    event_index
+    sequence_number
+    data
+  }
+}`
+
+const indexerEventsAfterQuery = `query BridgeEventsAfter($types: [String!], $after: bigint, $limit: Int) {
+  events(where: {indexed_type: {_in: $types}, transaction_version: {_gt: $after}}, order_by: [{transaction_version: asc}, {event_index: asc}], limit: $limit) {
+    type
+    transaction_version
+    event_index
+    sequence_number
+    data
+  }
+}`
+
+const indexerEventsSubscription = `subscription BridgeEventStream($types: [String!], $after: bigint) {
+  events_stream(batch_size: 100, cursor: {initial_value: {transaction_version: $after}, ordering: ASC}, where: {indexed_type: {_in: $types}}) {
+    type
+    transaction_version
+    event_index
+    sequence_number
+    data
+  }
+}`
+
+const indexerEventPageQuery = `query BridgeEventPage($types: [String!], $start: bigint, $limit: Int) {
+  events(where: {indexed_type: {_in: $types}, sequence_number: {_gte: $start}}, order_by: [{sequence_number: asc}], limit: $limit) {
+    type
+    transaction_version
+    event_index
+    sequence_number
+    data
+  }
+}`
+
+// queryEvents 执行事件查询
+func (s *IndexerEventSource) queryEvents(query string, variables map[string]any) ([]indexerEvent, error) {
+	data, err := s.client.ExecRaw(context.Background(), query, variables)
+	if err != nil {
+		return nil, fmt.Errorf("查询索引器失败: %v", err)
+	}
+	var result struct {
+		Events []indexerEvent `json:"events"`
+	}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, fmt.Errorf("解析索引器响应失败: %v", err)
+	}
+	return result.Events, nil
+}
+
+// latestEvents 查询某个类型最近的 limit 个事件，按发生顺序解码到 out 指向的切片
+func (s *IndexerEventSource) latestEvents(structTag string, limit uint64, out any) error {
+	events, err := s.queryEvents(indexerLatestEventsQuery, map[string]any{
+		"types": s.eventTypeNames(structTag),
+		"limit": limit,
+	})
+	if err != nil {
+		return err
+	}
+	// 查询按版本倒序，与 REST 一致改为正序
+	data := make([]any, len(events))
+	for i, event := range events {
+		data[len(events)-1-i] = event.Data
+	}
+	err = decodeMoveValue(data, out)
+	if err != nil {
+		return fmt.Errorf("解析事件数据失败: %v", err)
+	}
+	return nil
+}
+
+func (s *IndexerEventSource) BridgeMintEvents(limit uint64) ([]BridgeMintEvent, error) {
+	var events []BridgeMintEvent
+	err := s.latestEvents(BridgeMintEventStructTag, limit, &events)
+	return events, err
+}
+
+func (s *IndexerEventSource) RedeemRequestEvents(limit uint64) ([]BridgeRedeemRequestEvent, error) {
+	var events []BridgeRedeemRequestEvent
+	err := s.latestEvents(BridgeRedeemRequestEventStructTag, limit, &events)
+	return events, err
+}
+
+func (s *IndexerEventSource) RedeemPrepareEvents(limit uint64) ([]BridgeRedeemPrepareEvent, error) {
+	var events []BridgeRedeemPrepareEvent
+	err := s.latestEvents(BridgeRedeemPrepareEventStructTag, limit, &events)
+	return events, err
+}
+
+func (s *IndexerEventSource) TokenMintEvents(limit uint64) ([]TokenMintEvent, error) {
+	var events []TokenMintEvent
+	err := s.latestEvents(TokenMintEventStructTag, limit, &events)
+	return events, err
+}
+
+func (s *IndexerEventSource) TokenBurnEvents(limit uint64) ([]TokenBurnEvent, error) {
+	var events []TokenBurnEvent
+	err := s.latestEvents(TokenBurnEventStructTag, limit, &events)
+	return events, err
+}
+
+// EventPage 按序列号分页读取事件，事件总数由最新事件的序列号得到，索引器落后于全节点时总数也会偏小
+func (s *IndexerEventSource) EventPage(streamName string, start uint64, limit uint64) (*BridgeEventPage, error) {
+	stream, err := findBridgeEventStream(streamName)
+	if err != nil {
+		return nil, err
+	}
+	if limit == 0 || limit > maxEventPageLimit {
+		return nil, fmt.Errorf("每页数量必须在 1 到 %d 之间", maxEventPageLimit)
+	}
+	types := s.eventTypeNames(stream.EventStruct)
+	latest, err := s.queryEvents(indexerLatestEventsQuery, map[string]any{"types": types, "limit": 1})
+	if err != nil {
+		return nil, err
+	}
+	page := &BridgeEventPage{Stream: stream.Name, Start: start, Events: []SequencedBridgeEvent{}}
+	if len(latest) > 0 {
+		page.Total = latest[0].SequenceNumber + 1
+	}
+	if start >= page.Total {
+		return page, nil
+	}
+
+	events, err := s.queryEvents(indexerEventPageQuery, map[string]any{"types": types, "start": start, "limit": limit})
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		data, err := decodeBridgeEvent(stream.EventStruct, event.Data)
+		if err != nil {
+			return nil, err
+		}
+		page.Events = append(page.Events, SequencedBridgeEvent{
+			Stream:         stream.Name,
+			SequenceNumber: event.SequenceNumber,
+			Version:        event.TransactionVersion,
+			Type:           stream.EventStruct,
+			Data:           data,
+		})
+	}
+	if len(page.Events) > 0 {
+		next := page.Events[len(page.Events)-1].SequenceNumber + 1
+		if next < page.Total {
+			page.Next = &next
+		}
+	}
+	return page, nil
+}
+
+// decodeBridgeEvent 按事件类型解码为对应的事件结构体
+func decodeBridgeEvent(structTag string, data any) (any, error) {
+	var event any
+	switch structTag {
+	case BridgeMintEventStructTag:
+		event = &BridgeMintEvent{}
+	case BridgeRedeemRequestEventStructTag:
+		event = &BridgeRedeemRequestEvent{}
+	case BridgeRedeemPrepareEventStructTag:
+		event = &BridgeRedeemPrepareEvent{}
+	case TokenMintEventStructTag:
+		event = &TokenMintEvent{}
+	case TokenBurnEventStructTag:
+		event = &TokenBurnEvent{}
+	default:
+		return nil, fmt.Errorf("不是桥合约事件: %s", structTag)
+	}
+	err := decodeMoveValue(data, event)
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %v", structTag, err)
+	}
+	return event, nil
+}
+
+// eventCursor 已处理到的事件位置，用于去重
+type eventCursor struct {
+	version    uint64
+	eventIndex uint64
+	started    bool
+}
+
+// advance 事件在游标之后时前移游标并返回 true
+func (c *eventCursor) advance(event indexerEvent) bool {
+	if c.started && (event.TransactionVersion < c.version ||
+		(event.TransactionVersion == c.version && event.EventIndex <= c.eventIndex)) {
+		return false
+	}
+	c.version, c.eventIndex, c.started = event.TransactionVersion, event.EventIndex, true
+	return true
+}
+
+// deliver 解码新事件并交给 handler，已处理过的事件跳过
+func (s *IndexerEventSource) deliver(cursor *eventCursor, events []indexerEvent, handler func(BridgeEventRecord)) error {
+	for _, event := range events {
+		if !cursor.advance(event) {
+			continue
+		}
+		structTag := s.structTag(event.Type)
+		decoded, err := decodeBridgeEvent(structTag, event.Data)
+		if err != nil {
+			return err
+		}
+		handler(BridgeEventRecord{
+			Type:       structTag,
+			Version:    event.TransactionVersion,
+			EventIndex: event.EventIndex,
+			Event:      decoded,
+		})
+	}
+	return nil
+}
+
+// Subscribe 通过 GraphQL 订阅接收版本 afterVersion 之后的桥事件，直到 ctx 取消或连接出错
+// 消息按到达顺序逐条处理，handler 不会被并发调用
+func (s *IndexerEventSource) Subscribe(ctx context.Context, afterVersion uint64, handler func(BridgeEventRecord)) error {
+	cursor := &eventCursor{version: afterVersion, eventIndex: ^uint64(0), started: true}
+	subscription := graphql.NewSubscriptionClient(s.wsURL).
+		WithRetryTimeout(s.retryTimeout).
+		WithExitWhenNoSubscription(false).
+		WithSyncMode(true)
+	defer subscription.Close()
+
+	var deliverErr error
+	_, err := subscription.Exec(indexerEventsSubscription, map[string]any{
+		"types": s.eventTypeNames(bridgeEventTypes...),
+		"after": afterVersion,
+	}, func(message []byte, err error) error {
+		if err != nil {
+			return err
+		}
+		var result struct {
+			Events []indexerEvent `json:"events_stream"`
+		}
+		err = json.Unmarshal(message, &result)
+		if err != nil {
+			return fmt.Errorf("解析订阅消息失败: %v", err)
+		}
+		deliverErr = s.deliver(cursor, result.Events, handler)
+		if deliverErr != nil {
+			return graphql.ErrSubscriptionStopped
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("创建订阅失败: %v", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		subscription.Close()
+	}()
+	err = subscription.Run()
+	if deliverErr != nil {
+		return deliverErr
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("订阅中断: %v", err)
+	}
+	// 服务端正常关闭连接时 Run 也会返回，此时订阅并未按调用方的要求结束
+	return fmt.Errorf("订阅被索引器关闭")
+}
+
+// Poll 不支持订阅时按 interval 轮询版本 afterVersion 之后的桥事件，直到 ctx 取消
+func (s *IndexerEventSource) Poll(ctx context.Context, afterVersion uint64, interval time.Duration, handler func(BridgeEventRecord)) error {
+	return s.poll(ctx, &eventCursor{version: afterVersion, eventIndex: ^uint64(0), started: true}, interval, handler)
+}
+
+// poll 轮询游标之后的桥事件
+func (s *IndexerEventSource) poll(ctx context.Context, cursor *eventCursor, interval time.Duration, handler func(BridgeEventRecord)) error {
+	for {
+		// 从游标所在版本查起，同一版本中已处理的事件由游标去重
+		after := cursor.version
+		if after > 0 {
+			after--
+		}
+		events, err := s.queryEvents(indexerEventsAfterQuery, map[string]any{
+			"types": s.eventTypeNames(bridgeEventTypes...),
+			"after": after,
+			"limit": indexerEventBatchSize,
+		})
+		if err != nil {
+			logWarning(err.Error())
+		} else {
+			err = s.deliver(cursor, events, handler)
+			if err != nil {
+				return err
+			}
+			// 一批拉满说明还有积压，立即继续
+			if len(events) == indexerEventBatchSize {
+				continue
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Watch 订阅桥事件，订阅失败时退回轮询
+// 订阅中途断开时从已交付的最后一个事件之后继续轮询，已交付的事件不会重复交付
+func (s *IndexerEventSource) Watch(ctx context.Context, afterVersion uint64, interval time.Duration, handler func(BridgeEventRecord)) error {
+	cursor := &eventCursor{version: afterVersion, eventIndex: ^uint64(0), started: true}
+	err := s.Subscribe(ctx, afterVersion, func(record BridgeEventRecord) {
+		cursor.version, cursor.eventIndex = record.Version, record.EventIndex
+		handler(record)
+	})
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	logWarning(fmt.Sprintf("索引器订阅不可用，改为每 %s 轮询: %v", interval, err))
+	return s.poll(ctx, cursor, interval, handler)
+}
+
+// printBridgeEventRecord 打印一个实时收到的桥事件
+func printBridgeEventRecord(record BridgeEventRecord) {
+	prefix := fmt.Sprintf("[版本 %d #%d] %s", record.Version, record.EventIndex, record.Type)
+	switch event := record.Event.(type) {
+	case *BridgeMintEvent:
+		fmt.Printf("%s 交易ID: %s, 接收者: %s, 金额: %d\n", prefix, event.BtcTxId, event.Receiver, event.Amount)
+	case *BridgeRedeemRequestEvent:
+		fmt.Printf("%s 发送者: %s, 接收者: %s, 金额: %d\n", prefix, event.Sender, event.Receiver, event.Amount)
+	case *BridgeRedeemPrepareEvent:
+		fmt.Printf("%s 请求者: %s, 接收者: %s, 金额: %d, outpoints: %v/%v\n", prefix, event.Requester, event.Receiver, event.Amount, event.OutpointTxIds, event.OutpointIdxs)
+	case *TokenMintEvent:
+		fmt.Printf("%s 接收者: %s, 金额: %d, BTC交易ID: %s\n", prefix, event.Recipient, event.Amount, event.BtcTxId)
+	case *TokenBurnEvent:
+		fmt.Printf("%s 销毁者: %s, 金额: %d, BTC地址: %s\n", prefix, event.Burner, event.Amount, event.BtcAddress)
+	}
+}
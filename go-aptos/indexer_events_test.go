@@ -0,0 +1,363 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+// stubIndexer 用内存中的 events 表响应 GraphQL 查询的索引器，
+// streamLimit 大于 0 时接受一次订阅，推送 streamLimit 个事件后断开，之后的订阅都被拒绝
+type stubIndexer struct {
+	mu          sync.Mutex
+	events      []indexerEvent // 按版本和序号正序
+	queries     []map[string]any
+	streamLimit int
+	streamed    bool
+}
+
+func (s *stubIndexer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		// 订阅的 websocket 握手走 GET
+		s.mu.Lock()
+		accept := s.streamLimit > 0 && !s.streamed
+		s.streamed = true
+		s.mu.Unlock()
+		if !accept {
+			http.Error(w, "subscriptions not supported", http.StatusBadRequest)
+			return
+		}
+		s.serveSubscription(w, r)
+		return
+	}
+	var request struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries = append(s.queries, request.Variables)
+
+	types := map[string]bool{}
+	for _, t := range request.Variables["types"].([]any) {
+		types[t.(string)] = true
+	}
+	limit := int(request.Variables["limit"].(float64))
+	var matched []indexerEvent
+	switch {
+	case strings.Contains(request.Query, "BridgeEventPage"):
+		start := uint64(request.Variables["start"].(float64))
+		for _, event := range s.events {
+			if types[event.Type] && event.SequenceNumber >= start {
+				matched = append(matched, event)
+			}
+		}
+	case strings.Contains(request.Query, "BridgeEventsAfter"):
+		after := uint64(request.Variables["after"].(float64))
+		for _, event := range s.events {
+			if types[event.Type] && event.TransactionVersion > after {
+				matched = append(matched, event)
+			}
+		}
+	default:
+		for i := len(s.events) - 1; i >= 0; i-- {
+			if types[s.events[i].Type] {
+				matched = append(matched, s.events[i])
+			}
+		}
+	}
+	if len(matched) > limit {
+		matched = matched[:limit]
+	}
+	json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"events": matched}})
+}
+
+// serveSubscription 按 subscriptions-transport-ws 协议应答一次订阅，
+// 在一条 data 消息中推送 after 之后的前 streamLimit 个事件，然后不发关闭帧直接断开连接
+func (s *stubIndexer) serveSubscription(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{Subprotocols: []string{"graphql-ws"}})
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+	ctx := r.Context()
+	var message struct {
+		ID      string `json:"id"`
+		Type    string `json:"type"`
+		Payload struct {
+			Variables map[string]any `json:"variables"`
+		} `json:"payload"`
+	}
+	for message.Type != "start" {
+		err = wsjson.Read(ctx, conn, &message)
+		if err != nil {
+			return
+		}
+		if message.Type == "connection_init" {
+			err = wsjson.Write(ctx, conn, map[string]any{"type": "connection_ack"})
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	after := uint64(message.Payload.Variables["after"].(float64))
+	s.mu.Lock()
+	var streamed []indexerEvent
+	for _, event := range s.events {
+		if event.TransactionVersion > after && len(streamed) < s.streamLimit {
+			streamed = append(streamed, event)
+		}
+	}
+	s.mu.Unlock()
+	wsjson.Write(ctx, conn, map[string]any{
+		"id":      message.ID,
+		"type":    "data",
+		"payload": map[string]any{"data": map[string]any{"events_stream": streamed}},
+	})
+}
+
+// add 追加一个事件，序列号按同一类型写法已有的事件数分配
+func (s *stubIndexer) add(eventType string, version uint64, eventIndex uint64, data map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sequenceNumber := uint64(0)
+	for _, event := range s.events {
+		if event.Type == eventType {
+			sequenceNumber++
+		}
+	}
+	s.events = append(s.events, indexerEvent{Type: eventType, TransactionVersion: version, EventIndex: eventIndex, SequenceNumber: sequenceNumber, Data: data})
+}
+
+func (s *stubIndexer) queryCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queries)
+}
+
+func newStubIndexerSource(t *testing.T) (*stubIndexer, *IndexerEventSource) {
+	t.Helper()
+	indexer := &stubIndexer{}
+	server := httptest.NewServer(indexer)
+	t.Cleanup(server.Close)
+	source, err := NewIndexerEventSource(server.URL, "", "0x42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return indexer, source
+}
+
+func mintEventData(btcTxId string, amount uint64) map[string]any {
+	return map[string]any{"btc_tx_id": btcTxId, "receiver": "0xa", "amount": strconv.FormatUint(amount, 10)}
+}
+
+func TestIndexerEventSourceLatestEvents(t *testing.T) {
+	indexer, source := newStubIndexerSource(t)
+	mintType := source.moduleAddress.StringLong() + "::" + BridgeMintEventStructTag
+	// 索引器中的类型地址可能不补零
+	shortMintType := "0x42::" + BridgeMintEventStructTag
+	indexer.add(mintType, 10, 0, mintEventData("a", 20000))
+	indexer.add(shortMintType, 11, 0, mintEventData("b", 30000))
+	indexer.add("0x42::"+TokenBurnEventStructTag, 11, 1, map[string]any{"burner": "0xa", "amount": "1", "btc_address": "tb1q"})
+	indexer.add("0x66::"+BridgeMintEventStructTag, 12, 0, mintEventData("forged", 40000))
+	indexer.add(mintType, 13, 0, mintEventData("c", 50000))
+
+	events, err := source.BridgeMintEvents(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].BtcTxId != "b" || events[1].BtcTxId != "c" || events[1].Amount != 50000 {
+		t.Fatalf("应按发生顺序返回最近 2 个 MintEvent，实际 %+v", events)
+	}
+	events, err = source.BridgeMintEvents(10)
+	if err != nil || len(events) != 3 {
+		t.Fatalf("其他地址下的同名事件不应返回，实际 %+v: %v", events, err)
+	}
+}
+
+func TestIndexerEventSourcePollPaging(t *testing.T) {
+	indexer, source := newStubIndexerSource(t)
+	mintType := source.moduleAddress.StringLong() + "::" + BridgeMintEventStructTag
+	// 超过一批的积压，其中同一版本内有多个事件
+	total := indexerEventBatchSize*2 + 5
+	for i := range total {
+		indexer.add(mintType, uint64(100+i/2), uint64(i%2), mintEventData(strconv.Itoa(i), 20000))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var received []BridgeEventRecord
+	done := make(chan error)
+	go func() {
+		done <- source.Poll(ctx, 99, time.Hour, func(record BridgeEventRecord) {
+			received = append(received, record)
+			if len(received) == total {
+				cancel()
+			}
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("积压的事件应连续拉取，不等待轮询间隔，已收到 %d 个", len(received))
+	}
+	if len(received) != total {
+		t.Fatalf("应收到 %d 个事件，实际 %d", total, len(received))
+	}
+	for i, record := range received {
+		event, ok := record.Event.(*BridgeMintEvent)
+		if !ok || event.BtcTxId != strconv.Itoa(i) || record.Version != uint64(100+i/2) || record.EventIndex != uint64(i%2) {
+			t.Fatalf("第 %d 个事件应按顺序且不重复，实际 %+v", i, record)
+		}
+	}
+	if indexer.queryCount() < 3 {
+		t.Fatalf("%d 个事件应分多批拉取，实际查询 %d 次", total, indexer.queryCount())
+	}
+}
+
+func TestIndexerEventSourceWatchFallsBackToPolling(t *testing.T) {
+	indexer, source := newStubIndexerSource(t)
+	// 订阅握手被拒绝后尽快放弃重连
+	source.retryTimeout = time.Millisecond
+	mintType := source.moduleAddress.StringLong() + "::" + BridgeMintEventStructTag
+	indexer.add(mintType, 5, 0, mintEventData("old", 20000))
+	indexer.add(mintType, 7, 0, mintEventData("new", 30000))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var received []BridgeEventRecord
+	done := make(chan error)
+	go func() {
+		done <- source.Watch(ctx, 5, 10*time.Millisecond, func(record BridgeEventRecord) {
+			received = append(received, record)
+			cancel()
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("订阅不可用时应改为轮询")
+	}
+	if len(received) != 1 || received[0].Version != 7 || received[0].Event.(*BridgeMintEvent).BtcTxId != "new" {
+		t.Fatalf("应只收到版本 5 之后的事件，实际 %+v", received)
+	}
+}
+
+func TestIndexerEventSourceWatchResumesAfterSubscriptionDrops(t *testing.T) {
+	indexer, source := newStubIndexerSource(t)
+	source.retryTimeout = time.Millisecond
+	mintType := source.moduleAddress.StringLong() + "::" + BridgeMintEventStructTag
+	indexer.add(mintType, 3, 0, mintEventData("before", 20000))
+	indexer.add(mintType, 5, 0, mintEventData("a", 20000))
+	indexer.add(mintType, 6, 0, mintEventData("b", 20000))
+	indexer.add(mintType, 6, 1, mintEventData("c", 20000))
+	indexer.add(mintType, 8, 0, mintEventData("d", 20000))
+	// 订阅在版本 6 的第一个事件之后断开，同一版本的后一个事件需要由轮询补上
+	indexer.streamLimit = 2
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var received []string
+	done := make(chan error)
+	go func() {
+		done <- source.Watch(ctx, 4, 10*time.Millisecond, func(record BridgeEventRecord) {
+			received = append(received, record.Event.(*BridgeMintEvent).BtcTxId)
+			if len(received) == 4 {
+				cancel()
+			}
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("订阅断开后应改为轮询，已收到 %v", received)
+	}
+	if !indexer.streamed || strings.Join(received, ",") != "a,b,c,d" {
+		t.Fatalf("订阅断开后应从已交付的最后一个事件之后继续，不重复也不遗漏，实际 %v", received)
+	}
+	// 轮询从版本 6 查起，由游标跳过已交付的 b
+	indexer.mu.Lock()
+	defer indexer.mu.Unlock()
+	if len(indexer.queries) == 0 || indexer.queries[0]["after"].(float64) != 5 {
+		t.Fatalf("轮询应从版本 6 开始查询，实际 %v", indexer.queries)
+	}
+}
+
+func TestIndexerEventSourceEventPage(t *testing.T) {
+	indexer, source := newStubIndexerSource(t)
+	mintType := source.moduleAddress.StringLong() + "::" + BridgeMintEventStructTag
+	page, err := source.EventPage("mint", 0, 10)
+	if err != nil || page.Total != 0 || len(page.Events) != 0 {
+		t.Fatalf("没有事件时总数应为 0，实际 %+v: %v", page, err)
+	}
+	for i := range 5 {
+		indexer.add(mintType, uint64(10+i), 0, mintEventData(strconv.Itoa(i), 20000))
+	}
+	indexer.add("0x66::"+BridgeMintEventStructTag, 20, 0, mintEventData("forged", 40000))
+
+	page, err = source.EventPage("mint", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 5 || len(page.Events) != 2 || page.Events[0].SequenceNumber != 1 || page.Events[1].Version != 12 || page.Next == nil || *page.Next != 3 {
+		t.Fatalf("应返回序列号 1、2，下一页从 3 开始: %+v", page)
+	}
+	page, err = source.EventPage("mint", 3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Events) != 2 || page.Next != nil || page.Events[1].Data.(*BridgeMintEvent).BtcTxId != "4" {
+		t.Fatalf("最后一页应为序列号 3、4 且没有下一页: %+v", page)
+	}
+	if _, err := source.EventPage("unknown", 0, 10); err == nil {
+		t.Fatalf("未知的事件句柄应返回错误")
+	}
+}
+
+func TestEventFollowerWithIndexerSource(t *testing.T) {
+	indexer, source := newStubIndexerSource(t)
+	mintType := source.moduleAddress.StringLong() + "::" + BridgeMintEventStructTag
+	burnType := source.moduleAddress.StringLong() + "::" + TokenBurnEventStructTag
+	indexer.add(mintType, 10, 0, mintEventData("old", 20000))
+
+	follower, err := NewEventFollower(source, map[string]uint64{"token_burn": 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if follower.Cursors["mint"] != 1 || follower.Cursors["token_burn"] != 0 || follower.Cursors["redeem_request"] != 0 {
+		t.Fatalf("没有保存游标的句柄应从当前事件总数开始: %v", follower.Cursors)
+	}
+	indexer.add(mintType, 11, 0, mintEventData("new", 30000))
+	indexer.add(burnType, 11, 1, map[string]any{"burner": "0xa", "amount": "1", "btc_address": "tb1q"})
+
+	var received []string
+	delivered, err := follower.PollOnce(func(event SequencedBridgeEvent) error {
+		received = append(received, fmt.Sprintf("%s:%d", event.Stream, event.SequenceNumber))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivered != 2 || strings.Join(received, ",") != "mint:1,token_burn:0" {
+		t.Fatalf("应只跟随到新的铸币事件和销毁事件，实际 %v", received)
+	}
+	if follower.Cursors["mint"] != 2 || follower.Cursors["token_burn"] != 1 {
+		t.Fatalf("游标应移到已处理事件之后: %v", follower.Cursors)
+	}
+}
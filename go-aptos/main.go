@@ -2,11 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"os"
 	"strconv"
-	"strings"
 
 	"github.com/aptos-labs/aptos-go-sdk"
 )
@@ -16,19 +16,78 @@ func printUsage() {
 	fmt.Println("用法:")
 	fmt.Println("  检查APT余额: ./main check-apt [地址]")
 	fmt.Println("  发送APT: ./main send-apt <接收地址> <数量>")
-	fmt.Println("  检查TWBTC余额: ./main check-twbtc [地址]")
+	fmt.Println("  检查TWBTC余额: ./main check-twbtc [地址] [账本版本]")
+	fmt.Println("  查询桥状态: ./main bridge-state [账本版本] (配置、交易列表和供应量取自同一账本版本)")
 	fmt.Println("  注册TWBTC: ./main register-twbtc")
+	fmt.Println("  迁移TWBTC到FA: ./main migrate-twbtc (coin::migrate_to_fungible_store，余额转入FA主存储)")
 	fmt.Println("  发送TWBTC: ./main send-twbtc <接收地址> <数量>")
 	fmt.Println("  初始化桥接: ./main init-bridge <费用账户地址> <费用>")
-	fmt.Println("  redeem-request: ./main redeem-request <接收地址> <数量>")
+	fmt.Println("  redeem-request: ./main redeem-request <接收地址> <数量> (按BTC_NETWORK校验接收地址，默认testnet)")
 	fmt.Println("  mint: ./main mint <btc_tx_id> <接收地址> <数量>")
-	fmt.Println("  注册TWBTC: ./main registerTWBTC <接收地址>")
+	fmt.Println("  为用户注册TWBTC: ./main registerTWBTC <接收地址> [部分签名文件] (本地签名需设置RECEIVER_PRIVATE_KEY；合约的 registerv2 要求账户已通过 register 注册)")
+	fmt.Println("  追加签名: ./main partial-sign <部分签名文件>")
+	fmt.Println("  提交多方签名交易: ./main partial-submit <部分签名文件>")
 	fmt.Println("  初始化TWBTC: ./main init-twbtc")
-	fmt.Println("  查询事件: ./main query-events [查询时间秒]")
+	fmt.Println("  发布并初始化合约: ./main deploy <编译目录|发布负载.json> [费用账户地址] [费用] (已完成的步骤会跳过)")
+	fmt.Println("  搭建新环境: ./main bootstrap <编译目录|发布负载.json> <费用> (充值、发布、初始化、注册并测试铸币和赎回，已完成的步骤会跳过；FEE_ACCOUNT_PRIVATE_KEY 指定费用账户，主网默认不测试铸币和赎回，BOOTSTRAP_MAINNET_SMOKE_TEST=true 时执行)")
+	fmt.Println("  查询事件: ./main query-events [查询时间秒] (EVENT_SOURCE=indexer 时通过索引器实时订阅，INDEXER_URL/INDEXER_WS_URL 可指定索引器地址)")
+	fmt.Println("  费用报价: ./main quote <mint|redeem> <数量(satoshi)>")
+	fmt.Println("  批量铸币: ./main mint-batch <文件.csv|文件.json> [结果文件] [并发数]")
+	fmt.Println("  生成代付请求: ./main sponsor-request redeem <接收地址> <数量> <输出文件>")
+	fmt.Println("  生成代付请求: ./main sponsor-request register <输出文件>")
+	fmt.Println("  代付签名并提交: ./main sponsor-sign <请求文件>")
+	fmt.Println("  离线签名: ./main tx <build|sign|sign-partial|assemble|submit> ... (./main tx 查看详细用法)")
+	fmt.Println("  K-of-N多签账户: ./main multisig <new|address|pubkey> ... (./main multisig 查看详细用法)")
+	fmt.Println("  BTC存款确认跟踪: ./main btc-deposit <observe|status|process|resume> ... (./main btc-deposit 查看详细用法)")
+	fmt.Println("  比特币链查询: ./main btc-chain <tip|header|tx|utxos|fee|broadcast|fund|mine> ... (./main btc-chain 查看详细用法)")
+	fmt.Println("  BTC赎回出账: ./main btc-payout <build|show|sign|finalize> ... (./main btc-payout 查看详细用法)")
+	fmt.Println("  模拟全节点: ./main fake-node serve ... (./main fake-node 查看详细用法)")
+	fmt.Println("  HTTP JSON接口: ./main serve [监听地址] (./main serve help 查看详细用法)")
+	fmt.Println("  桥事件webhook: ./main webhook <run|replay|dead-letters|retry-dead> ... (./main webhook 查看详细用法)")
+	fmt.Println("  BTC存款意图: ./main deposit-intent <new|list|resolve|scan> ... (./main deposit-intent 查看详细用法)")
+	fmt.Println("  链上多签账户提案: ./main multisig-account <create|propose|list|approve|reject|execute> ... (./main multisig-account 查看详细用法)")
 }
 
 // 主函数
 func main() {
+	// 离线签名流程中只有 tx sign 需要私钥，单独处理
+	if len(os.Args) > 1 && os.Args[1] == "tx" {
+		runTxCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "multisig" {
+		runMultiSigCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "btc-deposit" {
+		runBtcDepositCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "btc-chain" {
+		runBtcChainCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "fake-node" {
+		runFakeNodeCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "btc-payout" {
+		runBtcPayoutCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		runServeCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "webhook" {
+		runWebhookCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "deposit-intent" {
+		runDepositIntentCommand(os.Args[2:])
+		return
+	}
+
 	// 从环境变量获取私钥
 	privateKey := os.Getenv("PRIVATE_KEY")
 	
@@ -60,7 +119,7 @@ func main() {
 	}
 
 	// 创建账户
-	account, err := createAccountFromPrivateKey(privateKey)
+	account, err := createSigningAccount(privateKey)
 	if err != nil {
 		logError(fmt.Sprintf("创建账户失败: %v", err))
 		os.Exit(1)
@@ -139,13 +198,58 @@ func main() {
 			os.Exit(1)
 		}
 		
-		balance, err := CheckTWBTCBalance(client, address, moduleAddress)
+		// 可选的账本版本，查询历史余额
+		ledgerVersion := []uint64{}
+		if len(os.Args) > 3 {
+			version, err := strconv.ParseUint(os.Args[3], 10, 64)
+			if err != nil {
+				logError(fmt.Sprintf("无效的账本版本: %s", os.Args[3]))
+				os.Exit(1)
+			}
+			ledgerVersion = append(ledgerVersion, version)
+		}
+
+		// 分别读取 CoinStore 和迁移后 FA 主存储中的余额
+		balances, err := GetTWBTCBalances(client, address, moduleAddress, ledgerVersion...)
 		if err != nil {
 			logError(fmt.Sprintf("检查TWBTC余额失败: %v", err))
 			os.Exit(1)
 		}
+		if !balances.Registered() {
+			logWarning(fmt.Sprintf("地址 %s 尚未注册TWBTC，需要先执行 register-twbtc", addressStr))
+			os.Exit(1)
+		}
+
+		printTWBTCBalances(addressStr, balances)
+
+	case "migrate-twbtc":
+		// 把TWBTC从CoinStore迁移到FA主存储
+		txHash, err := MigrateTWBTC(client, account, moduleAddress)
+		if err != nil {
+			logError(fmt.Sprintf("迁移TWBTC到FA失败: %v", err))
+			os.Exit(1)
+		}
+
+		logSuccess("成功把TWBTC迁移到FA主存储")
+		logSuccess(fmt.Sprintf("交易哈希: %s", txHash))
 
-		fmt.Printf("地址 %s 的TWBTC余额: %s Satoshis\n", addressStr, balance.String())
+	case "bridge-state":
+		// 在同一账本版本上读取桥状态
+		ledgerVersion := []uint64{}
+		if len(os.Args) > 2 {
+			version, err := strconv.ParseUint(os.Args[2], 10, 64)
+			if err != nil {
+				logError(fmt.Sprintf("无效的账本版本: %s", os.Args[2]))
+				os.Exit(1)
+			}
+			ledgerVersion = append(ledgerVersion, version)
+		}
+		state, err := ReadBridgeState(client, moduleAddress, ledgerVersion...)
+		if err != nil {
+			logError(fmt.Sprintf("读取桥状态失败: %v", err))
+			os.Exit(1)
+		}
+		printBridgeState(state)
 
 	case "register-twbtc":
 		// 注册TWBTC代币
@@ -175,12 +279,20 @@ func main() {
 			os.Exit(1)
 		}
 
+		// 发送方已迁移到FA时从主存储发送，接收方无需注册
+		senderBalances, err := GetTWBTCBalances(client, account.Address, moduleAddress)
+		if err == nil && senderBalances.Migrated() {
+			logInfo("发送方已迁移到FA，通过 primary_fungible_store::transfer 发送")
+		}
+
 		// 检查接收方是否已注册TWBTC
 		recipientBalance, err := CheckTWBTCBalance(client, recipient, moduleAddress)
 		if err != nil {
-			// 如果是资源不存在的错误，提示用户需要先注册
-			if strings.Contains(err.Error(), "resource not found") {
-				logWarning("接收方还未注册TWBTC代币，发送前需要先注册")
+			// 接收方未注册时提示用户需要先注册
+			if errors.Is(err, ErrTWBTCNotRegistered) {
+				if senderBalances == nil || !senderBalances.Migrated() {
+					logWarning("接收方还未注册TWBTC代币，发送前需要先注册")
+				}
 			} else {
 				logWarning(fmt.Sprintf("检查接收方TWBTC余额失败: %v", err))
 				// 但不退出，继续尝试发送
@@ -209,7 +321,12 @@ func main() {
 		logSuccess(fmt.Sprintf("交易哈希: %s", txHash))
 
 	case "init-twbtc":
-		// 初始化TWBTC
+		// 初始化TWBTC，initialize_module 要求管理员已注册
+		_, err := ensureTWBTCRegistered(client, account, moduleAddress)
+		if err != nil {
+			logError(fmt.Sprintf("注册TWBTC失败: %v", err))
+			os.Exit(1)
+		}
 		txHash, err := initTWBTC(client, account, moduleAddress)
 		if err != nil {
 			logError(fmt.Sprintf("初始化TWBTC失败: %v", err))
@@ -235,6 +352,11 @@ func main() {
 		}
 		fee, err := strconv.ParseUint(fee_str, 10, 64)
 
+		_, err = ensureTWBTCRegistered(client, account, moduleAddress)
+		if err != nil {
+			logError(fmt.Sprintf("注册TWBTC失败: %v", err))
+			os.Exit(1)
+		}
 		txHash, err := initBridge(client, account, moduleAddress, feeAccountAddress, fee)
 		if err != nil {
 			logError(fmt.Sprintf("初始化桥接失败: %v", err))
@@ -243,6 +365,85 @@ func main() {
 		logSuccess(fmt.Sprintf("成功初始化桥接"))
 		logSuccess(fmt.Sprintf("交易哈希: %s", txHash))
 
+	case "deploy":
+		// 发布合约包，检查升级兼容性后按需初始化桥
+		if len(os.Args) < 3 {
+			logError("错误: 部署需要指定编译好的包")
+			fmt.Println("用法: ./main deploy <编译目录|发布负载.json> [费用账户地址] [费用]")
+			os.Exit(1)
+		}
+		pkg, err := LoadCompiledPackage(os.Args[2])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		feeAccount := aptos.AccountAddress{}
+		var fee uint64
+		if len(os.Args) > 3 {
+			if len(os.Args) < 5 {
+				logError("错误: 费用账户地址和费用需要同时指定")
+				os.Exit(1)
+			}
+			err = feeAccount.ParseStringRelaxed(os.Args[3])
+			if err != nil {
+				logError(fmt.Sprintf("解析费用账户地址失败: %v", err))
+				os.Exit(1)
+			}
+			fee, err = strconv.ParseUint(os.Args[4], 10, 64)
+			if err != nil || fee == 0 {
+				logError(fmt.Sprintf("无效的费用: %s", os.Args[4]))
+				os.Exit(1)
+			}
+		}
+
+		result, err := DeployBridge(client, account, moduleAddress, pkg, feeAccount, fee)
+		if err != nil {
+			logError(fmt.Sprintf("部署失败: %v", err))
+			if result != nil && result.PublishTxHash != "" {
+				logInfo(fmt.Sprintf("包已发布(交易哈希 %s)，重新执行 deploy 会跳过发布", result.PublishTxHash))
+			}
+			os.Exit(1)
+		}
+		printDeployResult(result)
+		logSuccess("合约已发布并初始化")
+
+	case "bootstrap":
+		// 按检查清单搭建并验证新环境
+		if len(os.Args) < 4 {
+			logError("错误: bootstrap需要指定编译好的包和桥费用")
+			fmt.Println("用法: ./main bootstrap <编译目录|发布负载.json> <费用>")
+			os.Exit(1)
+		}
+		pkg, err := LoadCompiledPackage(os.Args[2])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		fee, err := strconv.ParseUint(os.Args[3], 10, 64)
+		if err != nil || fee == 0 {
+			logError(fmt.Sprintf("无效的费用: %s", os.Args[3]))
+			os.Exit(1)
+		}
+		config, err := loadBootstrapConfig(pkg, fee)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+
+		report, err := RunBootstrap(client, account, moduleAddress, config)
+		if report != nil {
+			printBootstrapReport(report)
+		}
+		if err != nil {
+			logError(fmt.Sprintf("bootstrap失败: %v", err))
+			os.Exit(1)
+		}
+		if report.Failed() {
+			logError("bootstrap未完成，修复后重新运行会从失败的步骤继续")
+			os.Exit(1)
+		}
+		logSuccess("环境已就绪，冒烟测试通过")
+
 	case "redeem-request":
 		// 赎回请求
 		if len(os.Args) < 4 {
@@ -253,6 +454,13 @@ func main() {
 		recipientStr := os.Args[2]
 		amountStr := os.Args[3]
 
+		receiver, err := validateRedeemReceiver(recipientStr)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		logInfo(fmt.Sprintf("接收地址类型: %s", receiver.Type))
+
 		amount, err := strconv.ParseUint(amountStr, 10, 64)
 		if err != nil {
 			logError(fmt.Sprintf("错误: 无效的金额 %s", amountStr))
@@ -267,21 +475,111 @@ func main() {
 		logSuccess(fmt.Sprintf("交易哈希: %s", txHash))
 
 	case "registerTWBTC":
+		// 管理员为用户注册TWBTC，管理员和用户共同签名
 		if len(os.Args) < 3 {
 			logError("错误: 注册TWBTC需要指定接收地址")
-			fmt.Println("用法: ./main registerTWBTC <接收地址>")
+			fmt.Println("用法: ./main registerTWBTC <接收地址> [部分签名文件]")
 			os.Exit(1)
 		}
 		receiverAddressStr := os.Args[2]
 		receiverAddress := aptos.AccountAddress{}
 		err := receiverAddress.ParseStringRelaxed(receiverAddressStr)
-		txHash, err := registerTWBTC(client, account, moduleAddress, receiverAddress)
+		if err != nil {
+			logError(fmt.Sprintf("解析接收地址失败: %v", err))
+			os.Exit(1)
+		}
+
+		if len(os.Args) > 3 {
+			// 用户私钥不在本地，生成部分签名文件交给用户签名
+			request, err := RegisterTWBTCRequest(client, account, moduleAddress, receiverAddress)
+			if err != nil {
+				logError(fmt.Sprintf("注册TWBTC失败: %v", err))
+				os.Exit(1)
+			}
+			err = WritePartialTransaction(os.Args[3], request)
+			if err != nil {
+				logError(err.Error())
+				os.Exit(1)
+			}
+			logSuccess(fmt.Sprintf("已生成部分签名文件 %s，请用户执行 partial-sign 后再 partial-submit", os.Args[3]))
+			break
+		}
+
+		receiverKey := os.Getenv("RECEIVER_PRIVATE_KEY")
+		if receiverKey == "" {
+			logError("错误: 缺少用户私钥。请设置RECEIVER_PRIVATE_KEY环境变量，或指定部分签名文件")
+			os.Exit(1)
+		}
+		receiver, err := createAccountFromPrivateKey(receiverKey)
+		if err != nil {
+			logError(fmt.Sprintf("创建用户账户失败: %v", err))
+			os.Exit(1)
+		}
+		if receiver.Address != receiverAddress {
+			logError(fmt.Sprintf("错误: RECEIVER_PRIVATE_KEY 对应地址 %s 与接收地址不一致", receiver.Address.String()))
+			os.Exit(1)
+		}
+		txHash, err := registerTWBTC(client, account, moduleAddress, receiver)
 		if err != nil {
 			logError(fmt.Sprintf("注册TWBTC失败: %v", err))
 			os.Exit(1)
 		}
 		logSuccess(fmt.Sprintf("成功注册TWBTC"))
 		logSuccess(fmt.Sprintf("交易哈希: %s", txHash))
+
+	case "partial-sign":
+		// 为部分签名文件中的交易追加本地签名
+		if len(os.Args) < 3 {
+			logError("错误: 需要指定部分签名文件")
+			fmt.Println("用法: ./main partial-sign <部分签名文件>")
+			os.Exit(1)
+		}
+		request, err := ReadPartialTransaction(os.Args[2])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("交易类型: %s\n调用函数: %s\n发送方: %s\n", request.Type, request.Function, request.Sender)
+		err = request.Sign(account)
+		if err != nil {
+			logError(fmt.Sprintf("签名失败: %v", err))
+			os.Exit(1)
+		}
+		err = WritePartialTransaction(os.Args[2], request)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		missing, err := request.MissingSigners()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("已签名 %s", account.Address.String()))
+		for _, signer := range missing {
+			logWarning(fmt.Sprintf("仍缺少签名: %s", signer.String()))
+		}
+
+	case "partial-submit":
+		// 签名收齐后提交部分签名文件中的交易
+		if len(os.Args) < 3 {
+			logError("错误: 需要指定部分签名文件")
+			fmt.Println("用法: ./main partial-submit <部分签名文件>")
+			os.Exit(1)
+		}
+		request, err := ReadPartialTransaction(os.Args[2])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		txHash, err := SubmitPartialTransaction(client, request)
+		if err != nil {
+			logError(fmt.Sprintf("提交交易失败: %v", err))
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("成功提交 %s", request.Function))
+		logSuccess(fmt.Sprintf("交易哈希: %s", txHash))
+
 	case "mint":
 		// 赎回确认
 		if len(os.Args) < 4 {
@@ -311,6 +609,109 @@ func main() {
 		logSuccess(fmt.Sprintf("成功发送 %s BTC 到地址 %s", amountStr, recipientStr))
 		logSuccess(fmt.Sprintf("交易哈希: %s", txHash))
 		
+	case "mint-batch":
+		// 批量铸币
+		if len(os.Args) < 3 {
+			logError("错误: 批量铸币需要指定输入文件")
+			fmt.Println("用法: ./main mint-batch <文件.csv|文件.json> [结果文件] [并发数]")
+			os.Exit(1)
+		}
+		inputPath := os.Args[2]
+		resultsPath := inputPath + ".results.csv"
+		if len(os.Args) > 3 {
+			resultsPath = os.Args[3]
+		}
+		pipeline := defaultMintBatchPipeline
+		if len(os.Args) > 4 {
+			pipeline, err = strconv.Atoi(os.Args[4])
+			if err != nil || pipeline <= 0 {
+				logError(fmt.Sprintf("错误: 无效的并发数 %s", os.Args[4]))
+				os.Exit(1)
+			}
+		}
+		rows, err := LoadMintBatchRows(inputPath)
+		if err != nil {
+			logError(fmt.Sprintf("读取批量文件失败: %v", err))
+			os.Exit(1)
+		}
+		results, err := MintBatch(client, account, moduleAddress, rows, resultsPath, pipeline)
+		printMintBatchSummary(results, resultsPath)
+		if err != nil {
+			logError(fmt.Sprintf("批量铸币中断: %v", err))
+			os.Exit(1)
+		}
+
+	case "sponsor-request":
+		// 用户侧: 签名交易并生成代付请求文件
+		if len(os.Args) < 4 {
+			logError("错误: 生成代付请求需要指定操作类型和输出文件")
+			fmt.Println("用法: ./main sponsor-request redeem <接收地址> <数量> <输出文件>")
+			fmt.Println("      ./main sponsor-request register <输出文件>")
+			os.Exit(1)
+		}
+		var request *PartialTransaction
+		var outputPath string
+		switch os.Args[2] {
+		case "redeem":
+			if len(os.Args) < 6 {
+				logError("错误: 代付赎回请求需要指定接收地址、数量和输出文件")
+				fmt.Println("用法: ./main sponsor-request redeem <接收地址> <数量> <输出文件>")
+				os.Exit(1)
+			}
+			amount, err := strconv.ParseUint(os.Args[4], 10, 64)
+			if err != nil {
+				logError(fmt.Sprintf("错误: 无效的金额 %s", os.Args[4]))
+				os.Exit(1)
+			}
+			outputPath = os.Args[5]
+			request, err = SponsorRedeemRequest(client, account, moduleAddress, os.Args[3], amount)
+		case "register":
+			outputPath = os.Args[3]
+			request, err = SponsorRegisterRequest(client, account, moduleAddress)
+		default:
+			logError(fmt.Sprintf("错误: 不支持代付的操作 %s", os.Args[2]))
+			os.Exit(1)
+		}
+		if err != nil {
+			logError(fmt.Sprintf("生成代付请求失败: %v", err))
+			os.Exit(1)
+		}
+		err = WritePartialTransaction(outputPath, request)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("已生成代付请求 %s，请交给代付方执行 sponsor-sign", outputPath))
+
+	case "sponsor-sign":
+		// 代付方: 校验请求并作为fee payer签名提交
+		if len(os.Args) < 3 {
+			logError("错误: 代付签名需要指定请求文件")
+			fmt.Println("用法: ./main sponsor-sign <请求文件>")
+			os.Exit(1)
+		}
+		request, err := ReadPartialTransaction(os.Args[2])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		policy, err := loadSponsorPolicy()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		txHash, err := SponsorSignAndSubmit(client, account, moduleAddress, request, policy)
+		if err != nil {
+			logError(fmt.Sprintf("代付交易失败: %v", err))
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("成功代付 %s 的交易", request.Sender))
+		logSuccess(fmt.Sprintf("交易哈希: %s", txHash))
+
+	case "multisig-account":
+		// 链上多签账户的提案流程
+		runMultisigAccountCommand(client, account, moduleAddress, os.Args[2:])
+
 	case "query-events":
 		// 查询事件
 
@@ -337,9 +738,30 @@ func main() {
 		// 获取查询时间参数
 
 
+	case "quote":
+		// 铸币/赎回费用报价
+		if len(os.Args) < 4 {
+			logError("错误: 报价需要指定操作类型和数量")
+			fmt.Println("用法: ./main quote <mint|redeem> <数量(satoshi)>")
+			os.Exit(1)
+		}
+		operation := os.Args[2]
+		amountStr := os.Args[3]
+		amount, err := strconv.ParseUint(amountStr, 10, 64)
+		if err != nil {
+			logError(fmt.Sprintf("错误: 无效的金额 %s", amountStr))
+			os.Exit(1)
+		}
+		quote, err := QuoteBridgeOperation(client, moduleAddress, operation, amount)
+		if err != nil {
+			logError(fmt.Sprintf("获取报价失败: %v", err))
+			os.Exit(1)
+		}
+		printQuote(quote)
+
 	default:
 		logError(fmt.Sprintf("未知命令: %s", command))
-		fmt.Println("可用命令: check-apt, send-apt, check-twbtc, register-twbtc, send-twbtc")
+		fmt.Println("可用命令: check-apt, send-apt, check-twbtc, register-twbtc, migrate-twbtc, send-twbtc, deploy, bootstrap")
 		printUsage()
 		os.Exit(1)
 	}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// 批量铸币每行的处理状态
+const (
+	MintBatchSuccess = "success"
+	MintBatchFailed  = "failed"
+	MintBatchSkipped = "skipped"
+	// 文件内重复的行，结果以同一btc_tx_id被提交的那一行为准，续跑读取结果文件时忽略
+	MintBatchDuplicate = "duplicate"
+)
+
+// 默认同时在途的交易数量
+const defaultMintBatchPipeline = 8
+
+// MintBatchRow 批量铸币输入文件中的一行
+type MintBatchRow struct {
+	BtcTxId  string `json:"btc_tx_id"`
+	Receiver string `json:"receiver"`
+	Amount   uint64 `json:"amount"`
+}
+
+// MintBatchResult 批量铸币结果文件中的一行
+type MintBatchResult struct {
+	BtcTxId  string
+	Receiver string
+	Amount   uint64
+	Status   string
+	TxHash   string
+	Error    string
+}
+
+var mintBatchResultHeader = []string{"btc_tx_id", "receiver", "amount", "status", "tx_hash", "error"}
+
+// LoadMintBatchRows 从CSV或JSON文件读取批量铸币数据
+// CSV 列顺序为 btc_tx_id,receiver,amount，首行表头可选；JSON 为对象数组
+func LoadMintBatchRows(path string) ([]MintBatchRow, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开批量文件失败: %v", err)
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var rows []MintBatchRow
+		err = json.NewDecoder(file).Decode(&rows)
+		if err != nil {
+			return nil, fmt.Errorf("解析JSON批量文件失败: %v", err)
+		}
+		return rows, nil
+	}
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("解析CSV批量文件失败: %v", err)
+	}
+
+	var rows []MintBatchRow
+	for i, record := range records {
+		if i == 0 && record[0] == "btc_tx_id" {
+			continue
+		}
+		amount, err := strconv.ParseUint(record[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("第%d行金额无效: %s", i+1, record[2])
+		}
+		rows = append(rows, MintBatchRow{
+			BtcTxId:  record[0],
+			Receiver: record[1],
+			Amount:   amount,
+		})
+	}
+	return rows, nil
+}
+
+// loadMintBatchResults 读取已有的结果文件，用于中断后续跑
+func loadMintBatchResults(path string) (map[string]MintBatchResult, error) {
+	results := make(map[string]MintBatchResult)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return results, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开结果文件失败: %v", err)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(completeMintBatchLines(data)))
+	reader.FieldsPerRecord = len(mintBatchResultHeader)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析结果文件失败: %v", err)
+		}
+		if record[0] == mintBatchResultHeader[0] || record[3] == MintBatchDuplicate {
+			continue
+		}
+		amount, _ := strconv.ParseUint(record[2], 10, 64)
+		// 同一个btc_tx_id以最后一条记录为准，重复行的记录不会覆盖被处理那一行的结果
+		results[record[0]] = MintBatchResult{
+			BtcTxId:  record[0],
+			Receiver: record[1],
+			Amount:   amount,
+			Status:   record[3],
+			TxHash:   record[4],
+			Error:    record[5],
+		}
+	}
+	return results, nil
+}
+
+// completeMintBatchLines 去掉结果文件末尾没有换行的不完整记录
+// 每条记录写完才会写换行，中断时最后一行可能只写了一半
+func completeMintBatchLines(data []byte) []byte {
+	return data[:bytes.LastIndexByte(data, '\n')+1]
+}
+
+// mintBatchWriter 逐行追加写入结果文件，保证中断时已完成的行不会丢失
+type mintBatchWriter struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+func newMintBatchWriter(path string) (*mintBatchWriter, error) {
+	existing, readErr := os.ReadFile(path)
+	if complete := completeMintBatchLines(existing); len(complete) < len(existing) {
+		// 丢弃上次中断时写了一半的最后一行，该行会在本次重新处理
+		err := os.Truncate(path, int64(len(complete)))
+		if err != nil {
+			return nil, fmt.Errorf("截断结果文件失败: %v", err)
+		}
+		existing = complete
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开结果文件失败: %v", err)
+	}
+	w := &mintBatchWriter{file: file, writer: csv.NewWriter(file)}
+	if errors.Is(readErr, os.ErrNotExist) || len(existing) == 0 {
+		w.writer.Write(mintBatchResultHeader)
+		w.writer.Flush()
+	}
+	return w, nil
+}
+
+func (w *mintBatchWriter) Write(result MintBatchResult) error {
+	w.writer.Write([]string{
+		result.BtcTxId,
+		result.Receiver,
+		strconv.FormatUint(result.Amount, 10),
+		result.Status,
+		result.TxHash,
+		result.Error,
+	})
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		return fmt.Errorf("写入结果文件失败: %v", err)
+	}
+	return w.file.Sync()
+}
+
+func (w *mintBatchWriter) Close() error {
+	return w.file.Close()
+}
+
+// mintBatchJob 一条待提交的铸币
+type mintBatchJob struct {
+	row      MintBatchRow
+	receiver aptos.AccountAddress
+}
+
+// MintBatch 批量铸币
+// 先过滤已在链上铸币、文件内重复、金额不足和接收方未注册的行，再按流水线序列号提交，
+// 每行结果立即写入resultsPath，重跑时跳过结果文件中已成功或已跳过的行。
+// 同一btc_tx_id只有被提交的那一行算数，前面未通过检查的行不影响后面的行，之后的行记为重复
+func MintBatch(client *aptos.Client, account aptos.TransactionSigner, moduleAddress string, rows []MintBatchRow, resultsPath string, pipeline int) ([]MintBatchResult, error) {
+	if pipeline <= 0 {
+		pipeline = defaultMintBatchPipeline
+	}
+
+	previous, err := loadMintBatchResults(resultsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	minted, err := GetMintedTransactions(client, moduleAddress)
+	if err != nil {
+		return nil, fmt.Errorf("获取已铸币交易列表失败: %v", err)
+	}
+	mintedSet := make(map[string]bool, len(minted.Minted))
+	for _, txId := range minted.Minted {
+		mintedSet[txId] = true
+	}
+
+	config, err := GetBridgeConfig(client, moduleAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := newMintBatchWriter(resultsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer writer.Close()
+
+	var results []MintBatchResult
+	record := func(result MintBatchResult) error {
+		results = append(results, result)
+		return writer.Write(result)
+	}
+
+	// 预检查，过滤掉不会成功的行
+	var jobs []mintBatchJob
+	seen := make(map[string]bool)
+	registered := make(map[string]bool)
+	for _, row := range rows {
+		if prev, ok := previous[row.BtcTxId]; ok && prev.Status != MintBatchFailed {
+			logInfo(fmt.Sprintf("跳过已处理的交易 %s (%s)", row.BtcTxId, prev.Status))
+			continue
+		}
+
+		result := MintBatchResult{
+			BtcTxId:  row.BtcTxId,
+			Receiver: row.Receiver,
+			Amount:   row.Amount,
+			Status:   MintBatchSkipped,
+		}
+		if row.BtcTxId == "" {
+			result.Status = MintBatchFailed
+			result.Error = "btc_tx_id为空"
+		} else if seen[row.BtcTxId] {
+			result.Status = MintBatchDuplicate
+			result.Error = "文件内重复的btc_tx_id"
+		} else if mintedSet[row.BtcTxId] {
+			result.Error = "链上已铸币"
+		}
+		if result.Error != "" {
+			if err := record(result); err != nil {
+				return results, err
+			}
+			continue
+		}
+
+		result.Status = MintBatchFailed
+		receiver := aptos.AccountAddress{}
+		err := receiver.ParseStringRelaxed(row.Receiver)
+		if err != nil {
+			result.Error = fmt.Sprintf("解析接收地址失败: %v", err)
+		} else if quote, _ := calculateQuote(QuoteMint, row.Amount, config.Fee, 0); quote.WillAbort {
+			result.Error = quote.AbortReason
+		} else if ok, known := registered[receiver.String()]; known && !ok {
+			result.Error = "接收方未注册TWBTC"
+		} else if !known {
+			ok, err := IsTWBTCRegistered(client, receiver, moduleAddress)
+			if err != nil {
+				result.Error = fmt.Sprintf("检查接收方注册状态失败: %v", err)
+			} else {
+				registered[receiver.String()] = ok
+				if !ok {
+					result.Error = "接收方未注册TWBTC"
+				}
+			}
+		}
+		if result.Error != "" {
+			if err := record(result); err != nil {
+				return results, err
+			}
+			continue
+		}
+
+		seen[row.BtcTxId] = true
+		jobs = append(jobs, mintBatchJob{row: row, receiver: receiver})
+	}
+
+	logInfo(fmt.Sprintf("共 %d 行，待提交 %d 笔铸币交易", len(rows), len(jobs)))
+
+	// 按窗口流水线提交，序列号由本地管理器分配，过期的交易用更高gas单价重发
+	policy, err := loadResubmitPolicy()
+	if err != nil {
+		return results, err
+	}
+	sequenceManager := NewSequenceManager(client, account.AccountAddress(), pipeline)
+	tracker := NewConfirmationTracker(client, account, policy, sequenceManager)
+	for start := 0; start < len(jobs); start += pipeline {
+		end := min(start+pipeline, len(jobs))
+		window := jobs[start:end]
+
+		pending := make([]MintBatchResult, len(window))
+		tracked := make([]*TrackedTransaction, len(window))
+		for i, job := range window {
+			pending[i] = MintBatchResult{
+				BtcTxId:  job.row.BtcTxId,
+				Receiver: job.row.Receiver,
+				Amount:   job.row.Amount,
+				Status:   MintBatchFailed,
+			}
+			payload, err := BridgeMintPayload(moduleAddress, job.row.BtcTxId, job.receiver, job.row.Amount)
+			if err != nil {
+				pending[i].Error = err.Error()
+				continue
+			}
+			btcTxId := job.row.BtcTxId
+			tracked[i], err = tracker.Submit(payload, func() (bool, error) {
+				return isBtcTxMinted(client, moduleAddress, btcTxId)
+			})
+			if err != nil {
+				pending[i].Error = err.Error()
+				continue
+			}
+			pending[i].TxHash = tracked[i].Hashes[0]
+		}
+
+		for i := range pending {
+			if tracked[i] != nil {
+				userTxn, err := tracker.Confirm(tracked[i])
+				if errors.Is(err, ErrTransactionAlreadyApplied) {
+					pending[i].Status = MintBatchSkipped
+					pending[i].Error = "链上已铸币"
+				} else if err != nil {
+					pending[i].Error = err.Error()
+				} else {
+					pending[i].TxHash = userTxn.Hash
+					if userTxn.Success {
+						pending[i].Status = MintBatchSuccess
+					} else {
+						pending[i].Error = fmt.Sprintf("交易执行失败: %s", userTxn.VmStatus)
+					}
+				}
+			}
+			if pending[i].Status == MintBatchSuccess {
+				logSuccess(fmt.Sprintf("铸币成功 %s -> %s: %s", pending[i].BtcTxId, pending[i].Receiver, pending[i].TxHash))
+			} else if pending[i].Status == MintBatchSkipped {
+				logWarning(fmt.Sprintf("跳过 %s: %s", pending[i].BtcTxId, pending[i].Error))
+			} else {
+				logError(fmt.Sprintf("铸币失败 %s: %s", pending[i].BtcTxId, pending[i].Error))
+			}
+			if err := record(pending[i]); err != nil {
+				return results, err
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// printMintBatchSummary 打印批量铸币汇总
+func printMintBatchSummary(results []MintBatchResult, resultsPath string) {
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Status]++
+	}
+	fmt.Println("===== 批量铸币结果 =====")
+	fmt.Printf("成功: %d, 失败: %d, 跳过: %d, 重复: %d\n", counts[MintBatchSuccess], counts[MintBatchFailed], counts[MintBatchSkipped], counts[MintBatchDuplicate])
+	fmt.Printf("结果文件: %s\n", resultsPath)
+}
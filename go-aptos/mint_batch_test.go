@@ -0,0 +1,150 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// mintBatchStatuses 按行顺序列出一次运行的结果状态
+func mintBatchStatuses(results []MintBatchResult) []string {
+	var statuses []string
+	for _, result := range results {
+		statuses = append(statuses, result.BtcTxId+":"+result.Status)
+	}
+	return statuses
+}
+
+func expectMintBatchStatuses(t *testing.T, results []MintBatchResult, expected ...string) {
+	t.Helper()
+	statuses := mintBatchStatuses(results)
+	if len(statuses) != len(expected) {
+		t.Fatalf("结果应为 %v，实际 %v", expected, statuses)
+	}
+	for i := range expected {
+		if statuses[i] != expected[i] {
+			t.Fatalf("结果应为 %v，实际 %v", expected, statuses)
+		}
+	}
+}
+
+// expectTWBTCBalance 代币合约从每笔铸币中扣除 twbtcTokenMintFee
+func expectTWBTCBalance(t *testing.T, node *FakeFullnode, admin *aptos.Account, account *aptos.Account, mints int, amount uint64) {
+	t.Helper()
+	balance, _ := node.CoinBalance(account.Address, twbtcCoinType(admin.Address))
+	if expected := uint64(mints) * (amount - twbtcTokenMintFee); balance != expected {
+		t.Fatalf("%s 应被铸币 %d 次，余额应为 %d，实际 %d", account.Address.String(), mints, expected, balance)
+	}
+}
+
+func TestMintBatchRetriesFailedRowOnResume(t *testing.T) {
+	alice := newTestAccount(t, "mint-batch-alice")
+	bob := newTestAccount(t, "mint-batch-bob")
+	node, client, admin := newTestBridgeNode(t, alice)
+	err := node.CreateAccount(bob.Address, fakeNodeFundOctas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moduleAddress := admin.Address.String()
+	resultsPath := filepath.Join(t.TempDir(), "results.csv")
+	rows := []MintBatchRow{
+		{BtcTxId: "deposit-a", Receiver: bob.Address.String(), Amount: 20000},
+		{BtcTxId: "deposit-a", Receiver: bob.Address.String(), Amount: 20000},
+		{BtcTxId: "deposit-b", Receiver: alice.Address.String(), Amount: 20000},
+		{BtcTxId: "deposit-b", Receiver: alice.Address.String(), Amount: 20000},
+	}
+
+	// bob 未注册，两行 deposit-a 都没有被提交，后一行不应被当作重复
+	results, err := MintBatch(client, admin, moduleAddress, rows, resultsPath, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectMintBatchStatuses(t, results, "deposit-a:failed", "deposit-a:failed", "deposit-b:duplicate", "deposit-b:success")
+	if results[2].Error != "文件内重复的btc_tx_id" {
+		t.Fatalf("提交过的 btc_tx_id 之后的行应记为重复: %+v", results[2])
+	}
+
+	// 重复行的记录写在成功记录之前，读取结果时不应覆盖
+	previous, err := loadMintBatchResults(resultsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if previous["deposit-a"].Status != MintBatchFailed || previous["deposit-b"].Status != MintBatchSuccess {
+		t.Fatalf("结果文件应记录 deposit-a 失败、deposit-b 成功，实际 %+v", previous)
+	}
+
+	// bob 注册后续跑，失败的 deposit-a 应被重试
+	_, err = RegisterTWBTC(client, bob, moduleAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err = MintBatch(client, admin, moduleAddress, rows, resultsPath, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectMintBatchStatuses(t, results, "deposit-a:duplicate", "deposit-a:success")
+	previous, err = loadMintBatchResults(resultsPath)
+	if err != nil || previous["deposit-a"].Status != MintBatchSuccess {
+		t.Fatalf("续跑后 deposit-a 应记为成功，实际 %+v: %v", previous["deposit-a"], err)
+	}
+
+	results, err = MintBatch(client, admin, moduleAddress, rows, resultsPath, 2)
+	if err != nil || len(results) != 0 {
+		t.Fatalf("全部处理完后再次续跑不应产生结果，实际 %v: %v", mintBatchStatuses(results), err)
+	}
+	expectTWBTCBalance(t, node, admin, alice, 1, 20000)
+	expectTWBTCBalance(t, node, admin, bob, 1, 20000)
+}
+
+func TestMintBatchResumesAfterCrash(t *testing.T) {
+	alice := newTestAccount(t, "mint-batch-alice")
+	node, client, admin := newTestBridgeNode(t, alice)
+	moduleAddress := admin.Address.String()
+	resultsPath := filepath.Join(t.TempDir(), "results.csv")
+	var rows []MintBatchRow
+	for _, btcTxId := range []string{"deposit-0", "deposit-1", "deposit-2"} {
+		rows = append(rows, MintBatchRow{BtcTxId: btcTxId, Receiver: alice.Address.String(), Amount: 20000})
+	}
+
+	// 上次运行铸了 deposit-0 并写入结果；deposit-1 已上链，但写结果时中断，只留下半行
+	results, err := MintBatch(client, admin, moduleAddress, rows[:1], resultsPath, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectMintBatchStatuses(t, results, "deposit-0:success")
+	_, err = mintTWBTC(client, admin, moduleAddress, alice.Address, 20000, "deposit-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.OpenFile(resultsPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = file.WriteString("deposit-1," + alice.Address.String())
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err = MintBatch(client, admin, moduleAddress, rows, resultsPath, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectMintBatchStatuses(t, results, "deposit-1:skipped", "deposit-2:success")
+	if results[0].Error != "链上已铸币" {
+		t.Fatalf("链上已铸币的行应被跳过: %+v", results[0])
+	}
+
+	previous, err := loadMintBatchResults(resultsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for btcTxId, status := range map[string]string{"deposit-0": MintBatchSuccess, "deposit-1": MintBatchSkipped, "deposit-2": MintBatchSuccess} {
+		if previous[btcTxId].Status != status {
+			t.Fatalf("续跑后的结果文件中 %s 应为 %s，实际 %+v", btcTxId, status, previous)
+		}
+	}
+	expectTWBTCBalance(t, node, admin, alice, 3, 20000)
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/bcs"
+)
+
+// multisigAccountModule 链上多签账户模块 0x1::multisig_account
+var multisigAccountModule = aptos.ModuleId{Address: aptos.AccountOne, Name: "multisig_account"}
+
+// MultisigTransaction 对应 0x1::multisig_account::MultisigTransaction
+type MultisigTransaction struct {
+	Payload struct {
+		Vec []string `json:"vec"`
+	} `json:"payload"`
+	PayloadHash struct {
+		Vec []string `json:"vec"`
+	} `json:"payload_hash"`
+	Votes struct {
+		Data []struct {
+			Key   string `json:"key"`
+			Value bool   `json:"value"`
+		} `json:"data"`
+	} `json:"votes"`
+	Creator          string `json:"creator"`
+	CreationTimeSecs uint64 `json:"creation_time_secs"`
+}
+
+// MultisigProposal 待执行的多签提案
+type MultisigProposal struct {
+	ID          uint64
+	Creator     string
+	CreatedAt   time.Time
+	Payload     *aptos.MultisigTransactionPayload // 只存哈希的提案为 nil
+	PayloadHash string
+	Approvals   []string
+	Rejections  []string
+}
+
+// MultisigAccountInfo 多签账户的所有者、门限和待执行提案
+type MultisigAccountInfo struct {
+	Address            aptos.AccountAddress
+	Owners             []string
+	SignaturesRequired uint64
+	Proposals          []MultisigProposal
+}
+
+// viewMultisigAccount 调用 multisig_account 的view函数，args 为BCS编码的参数
+func viewMultisigAccount(client *aptos.Client, function string, args ...[]byte) ([]any, error) {
+	result, err := client.View(&aptos.ViewPayload{
+		Module:   multisigAccountModule,
+		Function: function,
+		ArgTypes: []aptos.TypeTag{},
+		Args:     args,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("调用 multisig_account::%s 失败: %v", function, err)
+	}
+	if len(result) != 1 {
+		return nil, fmt.Errorf("multisig_account::%s 返回 %d 个值，期望 1 个", function, len(result))
+	}
+	return result, nil
+}
+
+// CreateMultisigAccount 创建链上多签账户，发送方自动成为所有者之一，返回多签账户地址
+func CreateMultisigAccount(client *aptos.Client, account aptos.TransactionSigner, otherOwners []aptos.AccountAddress, signaturesRequired uint64) (aptos.AccountAddress, string, error) {
+	if signaturesRequired == 0 || signaturesRequired > uint64(len(otherOwners)+1) {
+		return aptos.AccountAddress{}, "", fmt.Errorf("门限 %d 必须在 1 到所有者数量 %d 之间", signaturesRequired, len(otherOwners)+1)
+	}
+	multisigAddress, err := client.FetchNextMultisigAddress(account.AccountAddress())
+	if err != nil {
+		return aptos.AccountAddress{}, "", fmt.Errorf("获取多签账户地址失败: %v", err)
+	}
+	// metadata_values 为空的 vector<vector<u8>>
+	entryFunction, err := aptos.MultisigCreateAccountPayload(signaturesRequired, otherOwners, []string{}, []byte{0})
+	if err != nil {
+		return aptos.AccountAddress{}, "", fmt.Errorf("构建创建多签账户负载失败: %v", err)
+	}
+	txHash, err := submitAndConfirm(client, account, aptos.TransactionPayload{Payload: entryFunction}, nil)
+	if err != nil {
+		return aptos.AccountAddress{}, "", err
+	}
+	return *multisigAddress, txHash, nil
+}
+
+// ProposeMultisigTransaction 在多签账户上创建提案，负载完整存储在链上以便其他所有者解码核对
+// 提案者的赞成票由链上自动记录
+func ProposeMultisigTransaction(client *aptos.Client, account aptos.TransactionSigner, multisigAddress aptos.AccountAddress, payload aptos.TransactionPayload) (string, error) {
+	entryFunction, ok := payload.Payload.(*aptos.EntryFunction)
+	if !ok {
+		return "", fmt.Errorf("多签提案只支持入口函数调用")
+	}
+	proposal, err := aptos.MultisigCreateTransactionPayload(multisigAddress, &aptos.MultisigTransactionPayload{
+		Variant: aptos.MultisigTransactionPayloadVariantEntryFunction,
+		Payload: entryFunction,
+	})
+	if err != nil {
+		return "", fmt.Errorf("构建提案负载失败: %v", err)
+	}
+	return submitAndConfirm(client, account, aptos.TransactionPayload{Payload: proposal}, nil)
+}
+
+// GetMultisigAccountInfo 读取多签账户的所有者、门限和所有待执行提案
+func GetMultisigAccountInfo(client *aptos.Client, multisigAddress aptos.AccountAddress) (*MultisigAccountInfo, error) {
+	info := &MultisigAccountInfo{Address: multisigAddress}
+
+	result, err := viewMultisigAccount(client, "owners", multisigAddress[:])
+	if err != nil {
+		return nil, err
+	}
+	err = decodeMoveValue(result[0], &info.Owners)
+	if err != nil {
+		return nil, fmt.Errorf("解码所有者失败: %v", err)
+	}
+
+	result, err = viewMultisigAccount(client, "num_signatures_required", multisigAddress[:])
+	if err != nil {
+		return nil, err
+	}
+	err = decodeMoveValue(result[0], &info.SignaturesRequired)
+	if err != nil {
+		return nil, fmt.Errorf("解码门限失败: %v", err)
+	}
+
+	result, err = viewMultisigAccount(client, "last_resolved_sequence_number", multisigAddress[:])
+	if err != nil {
+		return nil, err
+	}
+	var lastResolved uint64
+	err = decodeMoveValue(result[0], &lastResolved)
+	if err != nil {
+		return nil, fmt.Errorf("解码已处理序号失败: %v", err)
+	}
+
+	result, err = viewMultisigAccount(client, "get_pending_transactions", multisigAddress[:])
+	if err != nil {
+		return nil, err
+	}
+	var pending []MultisigTransaction
+	err = decodeMoveValue(result[0], &pending)
+	if err != nil {
+		return nil, fmt.Errorf("解码待执行提案失败: %v", err)
+	}
+
+	// 待执行提案按序号排列，从上一个已处理的序号之后开始
+	for i, txn := range pending {
+		proposal, err := newMultisigProposal(lastResolved+1+uint64(i), txn)
+		if err != nil {
+			return nil, err
+		}
+		info.Proposals = append(info.Proposals, *proposal)
+	}
+	return info, nil
+}
+
+// newMultisigProposal 解码链上的提案
+func newMultisigProposal(id uint64, txn MultisigTransaction) (*MultisigProposal, error) {
+	proposal := &MultisigProposal{
+		ID:        id,
+		Creator:   txn.Creator,
+		CreatedAt: time.Unix(int64(txn.CreationTimeSecs), 0),
+	}
+	if len(txn.Payload.Vec) > 0 {
+		payloadBytes, err := hex.DecodeString(strings.TrimPrefix(txn.Payload.Vec[0], "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("解析提案 %d 的负载失败: %v", id, err)
+		}
+		proposal.Payload = &aptos.MultisigTransactionPayload{}
+		err = bcs.Deserialize(proposal.Payload, payloadBytes)
+		if err != nil {
+			return nil, fmt.Errorf("反序列化提案 %d 的负载失败: %v", id, err)
+		}
+	}
+	if len(txn.PayloadHash.Vec) > 0 {
+		proposal.PayloadHash = txn.PayloadHash.Vec[0]
+	}
+	for _, vote := range txn.Votes.Data {
+		if vote.Value {
+			proposal.Approvals = append(proposal.Approvals, vote.Key)
+		} else {
+			proposal.Rejections = append(proposal.Rejections, vote.Key)
+		}
+	}
+	return proposal, nil
+}
+
+// findMultisigProposal 在待执行提案中查找指定序号
+func (info *MultisigAccountInfo) findMultisigProposal(id uint64) (*MultisigProposal, error) {
+	for i := range info.Proposals {
+		if info.Proposals[i].ID == id {
+			return &info.Proposals[i], nil
+		}
+	}
+	return nil, fmt.Errorf("多签账户 %s 没有待执行的提案 %d", info.Address.String(), id)
+}
+
+// isOwner 检查地址是否为多签账户所有者
+func (info *MultisigAccountInfo) isOwner(address aptos.AccountAddress) bool {
+	for _, owner := range info.Owners {
+		ownerAddress := aptos.AccountAddress{}
+		if ownerAddress.ParseStringRelaxed(owner) == nil && ownerAddress == address {
+			return true
+		}
+	}
+	return false
+}
+
+// VoteMultisigTransaction 对提案投赞成或反对票
+func VoteMultisigTransaction(client *aptos.Client, account aptos.TransactionSigner, multisigAddress aptos.AccountAddress, id uint64, approve bool) (string, error) {
+	info, err := GetMultisigAccountInfo(client, multisigAddress)
+	if err != nil {
+		return "", err
+	}
+	voter := account.AccountAddress()
+	if !info.isOwner(voter) {
+		return "", fmt.Errorf("%s 不是多签账户 %s 的所有者", voter.String(), multisigAddress.String())
+	}
+	_, err = info.findMultisigProposal(id)
+	if err != nil {
+		return "", err
+	}
+
+	var entryFunction *aptos.EntryFunction
+	if approve {
+		entryFunction, err = aptos.MultisigApprovePayload(multisigAddress, id)
+	} else {
+		entryFunction, err = aptos.MultisigRejectPayload(multisigAddress, id)
+	}
+	if err != nil {
+		return "", fmt.Errorf("构建投票负载失败: %v", err)
+	}
+	return submitAndConfirm(client, account, aptos.TransactionPayload{Payload: entryFunction}, nil)
+}
+
+// ExecuteMultisigTransaction 执行赞成票已达门限的提案，反对票已达门限时将其移除
+// 只能处理序号最小的待执行提案
+func ExecuteMultisigTransaction(client *aptos.Client, account aptos.TransactionSigner, multisigAddress aptos.AccountAddress, id uint64) (string, error) {
+	info, err := GetMultisigAccountInfo(client, multisigAddress)
+	if err != nil {
+		return "", err
+	}
+	proposal, err := info.findMultisigProposal(id)
+	if err != nil {
+		return "", err
+	}
+	if info.Proposals[0].ID != id {
+		return "", fmt.Errorf("需要先执行或拒绝之前的提案 %d", info.Proposals[0].ID)
+	}
+	// 反对票达到门限的提案需要执行一次移除，否则会阻塞之后的提案
+	if uint64(len(proposal.Rejections)) >= info.SignaturesRequired {
+		removePayload := aptos.TransactionPayload{
+			Payload: &aptos.EntryFunction{
+				Module:   multisigAccountModule,
+				Function: "execute_rejected_transaction",
+				ArgTypes: []aptos.TypeTag{},
+				Args:     [][]byte{multisigAddress[:]},
+			},
+		}
+		return submitAndConfirm(client, account, removePayload, nil)
+	}
+	if uint64(len(proposal.Approvals)) < info.SignaturesRequired {
+		return "", fmt.Errorf("提案 %d 赞成票 %d 未达到门限 %d", id, len(proposal.Approvals), info.SignaturesRequired)
+	}
+	if proposal.Payload == nil {
+		return "", fmt.Errorf("提案 %d 只存储了负载哈希，无法执行", id)
+	}
+
+	payload := aptos.TransactionPayload{
+		Payload: &aptos.Multisig{
+			MultisigAddress: multisigAddress,
+			Payload:         proposal.Payload,
+		},
+	}
+	return submitAndConfirm(client, account, payload, nil)
+}
+
+// printMultisigAccountInfo 打印多签账户和待执行提案，moduleAddress 下的桥合约调用按参数表解码
+func printMultisigAccountInfo(info *MultisigAccountInfo, moduleAddress aptos.AccountAddress) {
+	fmt.Printf("多签账户: %s\n", info.Address.String())
+	fmt.Printf("门限: %d/%d\n", info.SignaturesRequired, len(info.Owners))
+	for _, owner := range info.Owners {
+		fmt.Printf("  所有者: %s\n", owner)
+	}
+	if len(info.Proposals) == 0 {
+		fmt.Println("没有待执行的提案")
+		return
+	}
+	for _, proposal := range info.Proposals {
+		fmt.Printf("\n提案 #%d  创建者 %s  创建于 %s\n", proposal.ID, proposal.Creator, proposal.CreatedAt.Format(time.RFC3339))
+		fmt.Printf("赞成 %d/%d  反对 %d\n", len(proposal.Approvals), info.SignaturesRequired, len(proposal.Rejections))
+		for _, approval := range proposal.Approvals {
+			fmt.Printf("  赞成: %s\n", approval)
+		}
+		for _, rejection := range proposal.Rejections {
+			fmt.Printf("  反对: %s\n", rejection)
+		}
+		if proposal.Payload == nil {
+			fmt.Printf("负载哈希: %s\n", proposal.PayloadHash)
+			continue
+		}
+		entryFunction, ok := proposal.Payload.Payload.(*aptos.EntryFunction)
+		if !ok {
+			fmt.Println("不支持的负载类型")
+			continue
+		}
+		decoded, err := DecodeEntryFunction(entryFunction, moduleAddress)
+		if err != nil {
+			fmt.Printf("解码负载失败: %v\n", err)
+			continue
+		}
+		fmt.Print(decoded.String())
+	}
+}
+
+// printMultisigAccountUsage 打印链上多签账户命令的帮助
+func printMultisigAccountUsage() {
+	fmt.Println("链上多签账户(0x1::multisig_account)用法:")
+	fmt.Println("  创建多签账户: ./main multisig-account create <门限> <其他所有者地址,...>")
+	fmt.Println("  发起提案: ./main multisig-account propose <多签账户地址> <mint|init-bridge|init-twbtc|redeem-request|redeem-prepare> <参数...>")
+	fmt.Println("  查看待执行提案: ./main multisig-account list <多签账户地址>")
+	fmt.Println("  赞成/反对提案: ./main multisig-account <approve|reject> <多签账户地址> <提案序号>")
+	fmt.Println("  执行提案(反对票达到门限时移除提案): ./main multisig-account execute <多签账户地址> <提案序号>")
+	fmt.Println("桥合约的管理员需要是多签账户地址，提案才能执行成功")
+}
+
+// runMultisigAccountCommand 处理 multisig-account 子命令
+func runMultisigAccountCommand(client *aptos.Client, account aptos.TransactionSigner, moduleAddress string, args []string) {
+	if len(args) < 2 {
+		printMultisigAccountUsage()
+		os.Exit(1)
+	}
+
+	if args[0] == "create" {
+		if len(args) < 3 {
+			printMultisigAccountUsage()
+			os.Exit(1)
+		}
+		threshold, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			logError(fmt.Sprintf("错误: 无效的门限 %s", args[1]))
+			os.Exit(1)
+		}
+		var owners []aptos.AccountAddress
+		for _, owner := range strings.Split(args[2], ",") {
+			address := aptos.AccountAddress{}
+			err := address.ParseStringRelaxed(strings.TrimSpace(owner))
+			if err != nil {
+				logError(fmt.Sprintf("解析所有者地址 %s 失败: %v", owner, err))
+				os.Exit(1)
+			}
+			owners = append(owners, address)
+		}
+		multisigAddress, txHash, err := CreateMultisigAccount(client, account, owners, threshold)
+		if err != nil {
+			logError(fmt.Sprintf("创建多签账户失败: %v", err))
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("多签账户地址: %s", multisigAddress.String()))
+		logSuccess(fmt.Sprintf("交易哈希: %s", txHash))
+		return
+	}
+
+	multisigAddress := aptos.AccountAddress{}
+	err := multisigAddress.ParseStringRelaxed(args[1])
+	if err != nil {
+		logError(fmt.Sprintf("解析多签账户地址失败: %v", err))
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "propose":
+		if len(args) < 3 {
+			printMultisigAccountUsage()
+			os.Exit(1)
+		}
+		payload, _, err := buildOfflinePayload(moduleAddress, args[2:])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		decoded, err := DecodeEntryFunction(payload.Payload.(*aptos.EntryFunction), optionalModuleAddress())
+		if err != nil {
+			logError(fmt.Sprintf("解码负载失败: %v", err))
+			os.Exit(1)
+		}
+		fmt.Print(decoded.String())
+		txHash, err := ProposeMultisigTransaction(client, account, multisigAddress, payload)
+		if err != nil {
+			logError(fmt.Sprintf("发起提案失败: %v", err))
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("已发起提案，交易哈希: %s", txHash))
+
+	case "list":
+		info, err := GetMultisigAccountInfo(client, multisigAddress)
+		if err != nil {
+			logError(fmt.Sprintf("查询多签账户失败: %v", err))
+			os.Exit(1)
+		}
+		printMultisigAccountInfo(info, optionalModuleAddress())
+
+	case "approve", "reject", "execute":
+		if len(args) < 3 {
+			printMultisigAccountUsage()
+			os.Exit(1)
+		}
+		id, err := strconv.ParseUint(args[2], 10, 64)
+		if err != nil {
+			logError(fmt.Sprintf("错误: 无效的提案序号 %s", args[2]))
+			os.Exit(1)
+		}
+		var txHash string
+		if args[0] == "execute" {
+			txHash, err = ExecuteMultisigTransaction(client, account, multisigAddress, id)
+		} else {
+			txHash, err = VoteMultisigTransaction(client, account, multisigAddress, id, args[0] == "approve")
+		}
+		if err != nil {
+			logError(fmt.Sprintf("提案 %d %s 失败: %v", id, args[0], err))
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("提案 %d %s 成功，交易哈希: %s", id, args[0], txHash))
+
+	default:
+		logError(fmt.Sprintf("未知的multisig-account子命令: %s", args[0]))
+		printMultisigAccountUsage()
+		os.Exit(1)
+	}
+}
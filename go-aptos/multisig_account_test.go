@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/bcs"
+)
+
+// testMultisigTransaction 构造 get_pending_transactions 返回的一个提案
+func testMultisigTransaction(payload []byte, votes map[string]bool) MultisigTransaction {
+	txn := MultisigTransaction{Creator: "0xa11ce", CreationTimeSecs: 1700000000}
+	if payload != nil {
+		txn.Payload.Vec = []string{"0x" + hex.EncodeToString(payload)}
+	}
+	for key, value := range votes {
+		txn.Votes.Data = append(txn.Votes.Data, struct {
+			Key   string `json:"key"`
+			Value bool   `json:"value"`
+		}{key, value})
+	}
+	return txn
+}
+
+func TestNewMultisigProposalDecodesPayload(t *testing.T) {
+	entryFunction, err := aptos.CoinTransferPayload(nil, aptos.AccountOne, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payloadBytes, err := bcs.Serialize(&aptos.MultisigTransactionPayload{
+		Variant: aptos.MultisigTransactionPayloadVariantEntryFunction,
+		Payload: entryFunction,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proposal, err := newMultisigProposal(3, testMultisigTransaction(payloadBytes, map[string]bool{"0xa11ce": true, "0xb0b": false}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proposal.ID != 3 || proposal.Creator != "0xa11ce" || proposal.CreatedAt.Unix() != 1700000000 {
+		t.Fatalf("提案序号、创建者或创建时间解码错误: %+v", proposal)
+	}
+	if len(proposal.Approvals) != 1 || proposal.Approvals[0] != "0xa11ce" || len(proposal.Rejections) != 1 || proposal.Rejections[0] != "0xb0b" {
+		t.Fatalf("应有 0xa11ce 赞成、0xb0b 反对，实际赞成 %v 反对 %v", proposal.Approvals, proposal.Rejections)
+	}
+	decoded, ok := proposal.Payload.Payload.(*aptos.EntryFunction)
+	if !ok {
+		t.Fatalf("负载应解码为入口函数，实际 %T", proposal.Payload.Payload)
+	}
+	reencoded, err := bcs.Serialize(proposal.Payload)
+	if err != nil || hex.EncodeToString(reencoded) != hex.EncodeToString(payloadBytes) {
+		t.Fatalf("解码后的负载 %s::%s 重新编码应与链上相同: %v", decoded.Module.Name, decoded.Function, err)
+	}
+
+	// 只存哈希的提案没有负载
+	hashOnly := testMultisigTransaction(nil, nil)
+	hashOnly.PayloadHash.Vec = []string{"0xabcd"}
+	proposal, err = newMultisigProposal(4, hashOnly)
+	if err != nil || proposal.Payload != nil || proposal.PayloadHash != "0xabcd" {
+		t.Fatalf("只存哈希的提案负载应为空并保留哈希，实际 %+v: %v", proposal, err)
+	}
+
+	for name, txn := range map[string]MultisigTransaction{
+		"十六进制无效": {Payload: struct {
+			Vec []string `json:"vec"`
+		}{[]string{"0xzz"}}},
+		"BCS截断":  testMultisigTransaction(payloadBytes[:len(payloadBytes)-1], nil),
+		"未知负载类型": testMultisigTransaction([]byte{0x07}, nil),
+	} {
+		_, err := newMultisigProposal(5, txn)
+		if err == nil || !strings.Contains(err.Error(), "提案 5 的负载失败") {
+			t.Fatalf("%s 的负载应解码失败: %v", name, err)
+		}
+	}
+}
+
+func TestMultisigAccountProposeApproveExecute(t *testing.T) {
+	alice := newTestAccount(t, "multisig-alice")
+	bob := newTestAccount(t, "multisig-bob")
+	carol := newTestAccount(t, "multisig-carol")
+	outsider := newTestAccount(t, "multisig-outsider")
+	node := NewFakeFullnode()
+	t.Cleanup(node.Close)
+	client, err := node.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, account := range []*aptos.Account{alice, bob, carol, outsider} {
+		err = node.CreateAccount(account.Address, fakeNodeFundOctas)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	multisigAddress, _, err := CreateMultisigAccount(client, alice, []aptos.AccountAddress{bob.Address, carol.Address}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sendAPT(context.Background(), client, alice, multisigAddress.String(), big.NewInt(100000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := aptos.AccountAddress{}
+	recipient[31] = 0xbe
+	transfer, err := aptos.CoinTransferPayload(nil, recipient, 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ProposeMultisigTransaction(client, alice, multisigAddress, aptos.TransactionPayload{Payload: transfer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := GetMultisigAccountInfo(client, multisigAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Owners) != 3 || info.SignaturesRequired != 2 || len(info.Proposals) != 1 {
+		t.Fatalf("应为 2-of-3 且有 1 个待执行提案，实际 %+v", info)
+	}
+	proposal := info.Proposals[0]
+	if proposal.ID != 1 || len(proposal.Approvals) != 1 || proposal.Approvals[0] != alice.Address.String() || proposal.Payload == nil {
+		t.Fatalf("提案 1 应带完整负载并记录提案者的赞成票，实际 %+v", proposal)
+	}
+	if decoded, ok := proposal.Payload.Payload.(*aptos.EntryFunction); !ok || decoded.Function != transfer.Function {
+		t.Fatalf("链上读回的负载应为转账，实际 %+v", proposal.Payload.Payload)
+	}
+
+	_, err = ExecuteMultisigTransaction(client, carol, multisigAddress, 1)
+	if err == nil || !strings.Contains(err.Error(), "未达到门限") {
+		t.Fatalf("赞成票不足时不应执行: %v", err)
+	}
+	_, err = VoteMultisigTransaction(client, outsider, multisigAddress, 1, true)
+	if err == nil || !strings.Contains(err.Error(), "不是多签账户") {
+		t.Fatalf("非所有者不应能投票: %v", err)
+	}
+	_, err = VoteMultisigTransaction(client, bob, multisigAddress, 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 执行者不必投过票，只需是所有者
+	_, err = ExecuteMultisigTransaction(client, carol, multisigAddress, 1)
+	if err != nil {
+		t.Fatalf("赞成票达到门限后应能执行: %v", err)
+	}
+	received, err := checkAPTBalance(context.Background(), client, recipient.String())
+	if err != nil || received.Uint64() != 12345 {
+		t.Fatalf("多签账户应转出 12345，实际 %v: %v", received, err)
+	}
+
+	// 反对票达到门限的提案被移除，不会阻塞后面的提案
+	for i := 0; i < 2; i++ {
+		_, err = ProposeMultisigTransaction(client, alice, multisigAddress, aptos.TransactionPayload{Payload: transfer})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = ExecuteMultisigTransaction(client, alice, multisigAddress, 3)
+	if err == nil || !strings.Contains(err.Error(), "需要先执行或拒绝之前的提案 2") {
+		t.Fatalf("应先处理提案 2: %v", err)
+	}
+	for _, owner := range []*aptos.Account{bob, carol} {
+		_, err = VoteMultisigTransaction(client, owner, multisigAddress, 2, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = ExecuteMultisigTransaction(client, alice, multisigAddress, 2)
+	if err != nil {
+		t.Fatalf("反对票达到门限的提案应被移除: %v", err)
+	}
+	info, err = GetMultisigAccountInfo(client, multisigAddress)
+	if err != nil || len(info.Proposals) != 1 || info.Proposals[0].ID != 3 {
+		t.Fatalf("移除提案 2 后应只剩提案 3，实际 %+v: %v", info, err)
+	}
+	received, err = checkAPTBalance(context.Background(), client, recipient.String())
+	if err != nil || received.Uint64() != 12345 {
+		t.Fatalf("被拒绝的提案不应执行转账，实际 %v: %v", received, err)
+	}
+
+	// 绕过本地检查直接提交执行，链上同样拒绝赞成票不足的提案
+	_, err = submitAndConfirm(client, alice, aptos.TransactionPayload{Payload: &aptos.Multisig{MultisigAddress: multisigAddress, Payload: info.Proposals[0].Payload}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "ENOT_ENOUGH_APPROVALS") {
+		t.Fatalf("赞成票不足的提案应在链上执行失败: %v", err)
+	}
+}
@@ -0,0 +1,526 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/api"
+	"github.com/aptos-labs/aptos-go-sdk/bcs"
+	"github.com/aptos-labs/aptos-go-sdk/crypto"
+)
+
+// K-of-N 多签账户的签名方案
+const (
+	MultiSigSchemeMultiEd25519 = "multi_ed25519" // 旧的 MultiEd25519 账户
+	MultiSigSchemeMultiKey     = "multi_key"     // 新的 MultiKey 账户
+)
+
+// MultiSigConfig K-of-N 多签账户配置，公钥顺序决定签名位图，创建后不能调整
+type MultiSigConfig struct {
+	Scheme     string   `json:"scheme"`
+	Threshold  uint8    `json:"threshold"`
+	PublicKeys []string `json:"public_keys"` // Ed25519 公钥十六进制
+}
+
+// NewMultiSigConfig 创建并校验多签账户配置
+func NewMultiSigConfig(scheme string, threshold uint8, publicKeys []string) (*MultiSigConfig, error) {
+	config := &MultiSigConfig{Scheme: scheme, Threshold: threshold, PublicKeys: publicKeys}
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// LoadMultiSigConfig 读取多签账户配置文件
+func LoadMultiSigConfig(path string) (*MultiSigConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取多签配置文件失败: %v", err)
+	}
+	config := &MultiSigConfig{}
+	err = json.Unmarshal(data, config)
+	if err != nil {
+		return nil, fmt.Errorf("解析多签配置文件失败: %v", err)
+	}
+	err = config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Save 写入多签账户配置文件
+func (c *MultiSigConfig) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化多签配置失败: %v", err)
+	}
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("写入多签配置文件失败: %v", err)
+	}
+	return nil
+}
+
+// Validate 校验方案、门限和公钥
+func (c *MultiSigConfig) Validate() error {
+	if c.Scheme != MultiSigSchemeMultiEd25519 && c.Scheme != MultiSigSchemeMultiKey {
+		return fmt.Errorf("未知的多签方案: %s (应为 %s 或 %s)", c.Scheme, MultiSigSchemeMultiEd25519, MultiSigSchemeMultiKey)
+	}
+	n := len(c.PublicKeys)
+	if n < 1 || n > int(crypto.MaxMultiKeySignatures) {
+		return fmt.Errorf("公钥数量必须在 1 到 %d 之间, 实际为 %d", crypto.MaxMultiKeySignatures, n)
+	}
+	if c.Threshold < 1 || int(c.Threshold) > n {
+		return fmt.Errorf("门限必须在 1 到 %d 之间, 实际为 %d", n, c.Threshold)
+	}
+	keys, err := c.ed25519PublicKeys()
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if seen[key.ToHex()] {
+			return fmt.Errorf("重复的公钥: %s", key.ToHex())
+		}
+		seen[key.ToHex()] = true
+	}
+	return nil
+}
+
+// ed25519PublicKeys 解析配置中的公钥
+func (c *MultiSigConfig) ed25519PublicKeys() ([]*crypto.Ed25519PublicKey, error) {
+	keys := make([]*crypto.Ed25519PublicKey, 0, len(c.PublicKeys))
+	for i, keyHex := range c.PublicKeys {
+		key := &crypto.Ed25519PublicKey{}
+		err := key.FromHex(keyHex)
+		if err != nil {
+			return nil, fmt.Errorf("解析第%d个公钥失败: %v", i+1, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// PublicKey 返回多签账户的公钥
+func (c *MultiSigConfig) PublicKey() (crypto.PublicKey, error) {
+	keys, err := c.ed25519PublicKeys()
+	if err != nil {
+		return nil, err
+	}
+	if c.Scheme == MultiSigSchemeMultiEd25519 {
+		return &crypto.MultiEd25519PublicKey{PubKeys: keys, SignaturesRequired: c.Threshold}, nil
+	}
+	anyKeys := make([]*crypto.AnyPublicKey, 0, len(keys))
+	for _, key := range keys {
+		anyKey, err := crypto.ToAnyPublicKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("转换公钥失败: %v", err)
+		}
+		anyKeys = append(anyKeys, anyKey)
+	}
+	return &crypto.MultiKey{PubKeys: anyKeys, SignaturesRequired: c.Threshold}, nil
+}
+
+// Address 由N个公钥和门限K推导多签账户地址
+func (c *MultiSigConfig) Address() (aptos.AccountAddress, error) {
+	publicKey, err := c.PublicKey()
+	if err != nil {
+		return aptos.AccountAddress{}, err
+	}
+	address := aptos.AccountAddress{}
+	copy(address[:], publicKey.AuthKey()[:])
+	return address, nil
+}
+
+// keyIndex 返回公钥在配置中的位置
+func (c *MultiSigConfig) keyIndex(publicKey *crypto.Ed25519PublicKey) (int, error) {
+	keys, err := c.ed25519PublicKeys()
+	if err != nil {
+		return 0, err
+	}
+	for i, key := range keys {
+		if key.ToHex() == publicKey.ToHex() {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("公钥 %s 不在多签配置中", publicKey.ToHex())
+}
+
+// PartialSignature 一个签名方对签名消息的部分签名
+type PartialSignature struct {
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// SignPartial 用单个成员私钥对签名消息签名
+func SignPartial(message []byte, key *crypto.Ed25519PrivateKey) (*PartialSignature, error) {
+	signature, err := key.SignMessage(message)
+	if err != nil {
+		return nil, fmt.Errorf("签名失败: %v", err)
+	}
+	return &PartialSignature{
+		PublicKey: key.PubKey().ToHex(),
+		Signature: signature.ToHex(),
+	}, nil
+}
+
+// WritePartialSignature 写入部分签名文件
+func WritePartialSignature(path string, partial *PartialSignature) error {
+	data, err := json.MarshalIndent(partial, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化部分签名失败: %v", err)
+	}
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("写入部分签名文件失败: %v", err)
+	}
+	return nil
+}
+
+// ReadPartialSignature 读取部分签名文件
+func ReadPartialSignature(path string) (*PartialSignature, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取部分签名文件失败: %v", err)
+	}
+	partial := &PartialSignature{}
+	err = json.Unmarshal(data, partial)
+	if err != nil {
+		return nil, fmt.Errorf("解析部分签名文件 %s 失败: %v", path, err)
+	}
+	return partial, nil
+}
+
+// indexedSignature 按公钥位置排列的成员签名
+type indexedSignature struct {
+	index     int
+	signature *crypto.Ed25519Signature
+}
+
+// AssembleAuthenticator 校验各成员的部分签名并组装为多签验证器
+// 无效、重复或不属于该账户的签名会被拒绝，有效签名不足K个时返回错误，超过K个时只取前K个
+func (c *MultiSigConfig) AssembleAuthenticator(message []byte, partials []PartialSignature) (*crypto.AccountAuthenticator, error) {
+	var signatures []indexedSignature
+	used := make(map[int]bool)
+	for _, partial := range partials {
+		publicKey := &crypto.Ed25519PublicKey{}
+		err := publicKey.FromHex(partial.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("解析部分签名公钥失败: %v", err)
+		}
+		index, err := c.keyIndex(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		if used[index] {
+			return nil, fmt.Errorf("公钥 %s 重复签名", partial.PublicKey)
+		}
+		signature := &crypto.Ed25519Signature{}
+		err = signature.FromHex(partial.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 的签名失败: %v", partial.PublicKey, err)
+		}
+		if !publicKey.Verify(message, signature) {
+			return nil, fmt.Errorf("%s 的签名无效", partial.PublicKey)
+		}
+		used[index] = true
+		signatures = append(signatures, indexedSignature{index: index, signature: signature})
+	}
+	if len(signatures) < int(c.Threshold) {
+		return nil, fmt.Errorf("有效签名 %d 个，不足门限 %d", len(signatures), c.Threshold)
+	}
+
+	// 位图和签名都按公钥顺序排列
+	sort.Slice(signatures, func(i, j int) bool { return signatures[i].index < signatures[j].index })
+	signatures = signatures[:c.Threshold]
+
+	publicKey, err := c.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	switch key := publicKey.(type) {
+	case *crypto.MultiEd25519PublicKey:
+		sig := &crypto.MultiEd25519Signature{}
+		for _, s := range signatures {
+			sig.Signatures = append(sig.Signatures, s.signature)
+			sig.Bitmap[s.index/8] |= 128 >> (s.index % 8)
+		}
+		return &crypto.AccountAuthenticator{
+			Variant: crypto.AccountAuthenticatorMultiEd25519,
+			Auth:    &crypto.MultiEd25519Authenticator{PubKey: key, Sig: sig},
+		}, nil
+
+	case *crypto.MultiKey:
+		indexed := make([]crypto.IndexedAnySignature, 0, len(signatures))
+		for _, s := range signatures {
+			indexed = append(indexed, crypto.IndexedAnySignature{
+				Index:     uint8(s.index),
+				Signature: &crypto.AnySignature{Variant: crypto.AnySignatureVariantEd25519, Signature: s.signature},
+			})
+		}
+		sig, err := crypto.NewMultiKeySignature(indexed)
+		if err != nil {
+			return nil, fmt.Errorf("组装多签签名失败: %v", err)
+		}
+		return &crypto.AccountAuthenticator{
+			Variant: crypto.AccountAuthenticatorMultiKey,
+			Auth:    &crypto.MultiKeyAuthenticator{PubKey: key, Sig: sig},
+		}, nil
+	}
+	return nil, fmt.Errorf("不支持的多签公钥类型: %T", publicKey)
+}
+
+// verifyAccountAuthenticator 校验账户签名
+// SDK 对 MultiEd25519/MultiKey 的校验没有按位图对应公钥，这两种按位图逐个校验成员签名
+func verifyAccountAuthenticator(auth *crypto.AccountAuthenticator, message []byte) bool {
+	switch a := auth.Auth.(type) {
+	case *crypto.MultiEd25519Authenticator:
+		var indices []int
+		for i := range a.PubKey.PubKeys {
+			if a.Sig.Bitmap[i/8]&(128>>(i%8)) != 0 {
+				indices = append(indices, i)
+			}
+		}
+		return verifyIndexedSignatures(len(indices), int(a.PubKey.SignaturesRequired), len(a.Sig.Signatures), func(i int) bool {
+			return a.PubKey.PubKeys[indices[i]].Verify(message, a.Sig.Signatures[i])
+		})
+
+	case *crypto.MultiKeyAuthenticator:
+		bitmapBytes, err := bcs.Serialize(&a.Sig.Bitmap)
+		if err != nil {
+			return false
+		}
+		bitmap := bcs.NewDeserializer(bitmapBytes).ReadBytes()
+		var indices []int
+		for i := 0; i < len(a.PubKey.PubKeys) && i/8 < len(bitmap); i++ {
+			if bitmap[i/8]&(128>>(i%8)) != 0 {
+				indices = append(indices, i)
+			}
+		}
+		return verifyIndexedSignatures(len(indices), int(a.PubKey.SignaturesRequired), len(a.Sig.Signatures), func(i int) bool {
+			return a.PubKey.PubKeys[indices[i]].Verify(message, a.Sig.Signatures[i])
+		})
+	}
+	return auth.Verify(message)
+}
+
+// verifyIndexedSignatures 位图中的签名数必须与签名数一致且不少于门限，并且每个签名都有效
+func verifyIndexedSignatures(signed int, required int, count int, verify func(i int) bool) bool {
+	if signed != count || count < required {
+		return false
+	}
+	for i := 0; i < count; i++ {
+		if !verify(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// multiEd25519TransactionAuthenticator 序列化正确的MultiEd25519交易验证器
+// SDK 序列化时多写了一个 AccountAuthenticator 类型字节，与链上格式和它自己的反序列化都不一致
+type multiEd25519TransactionAuthenticator struct {
+	aptos.MultiEd25519TransactionAuthenticator
+}
+
+func (ea *multiEd25519TransactionAuthenticator) MarshalBCS(ser *bcs.Serializer) {
+	ea.Sender.Auth.MarshalBCS(ser)
+}
+
+// fixTransactionAuthenticator 替换交易中SDK的MultiEd25519交易验证器，签名后和反序列化后都需要调用
+func fixTransactionAuthenticator(signedTxn *aptos.SignedTransaction) {
+	if signedTxn.Authenticator == nil {
+		return
+	}
+	if auth, ok := signedTxn.Authenticator.Auth.(*aptos.MultiEd25519TransactionAuthenticator); ok {
+		signedTxn.Authenticator.Auth = &multiEd25519TransactionAuthenticator{*auth}
+	}
+}
+
+// signTransaction 签名交易，MultiEd25519多签账户签出的交易也能正确序列化
+func signTransaction(rawTxn *aptos.RawTransaction, signer aptos.TransactionSigner) (*aptos.SignedTransaction, error) {
+	signedTxn, err := rawTxn.SignedTransaction(signer)
+	if err != nil {
+		return nil, err
+	}
+	fixTransactionAuthenticator(signedTxn)
+	return signedTxn, nil
+}
+
+// buildSignAndSubmitTransaction 代替 client.BuildSignAndSubmitTransaction，签名经过 signTransaction
+func buildSignAndSubmitTransaction(client *aptos.Client, signer aptos.TransactionSigner, payload aptos.TransactionPayload) (*api.SubmitTransactionResponse, error) {
+	rawTxn, err := client.BuildTransaction(signer.AccountAddress(), payload)
+	if err != nil {
+		return nil, err
+	}
+	signedTxn, err := signTransaction(rawTxn, signer)
+	if err != nil {
+		return nil, err
+	}
+	return client.SubmitTransaction(signedTxn)
+}
+
+// MultiSigSigner 本地持有至少K个成员私钥时的多签签名者，实现 crypto.Signer，
+// 可以像单签账户一样用于所有交易函数
+type MultiSigSigner struct {
+	config    *MultiSigConfig
+	publicKey crypto.PublicKey
+	keys      []*crypto.Ed25519PrivateKey
+}
+
+// NewMultiSigSigner 创建本地多签签名者，私钥必须都属于该多签账户
+func NewMultiSigSigner(config *MultiSigConfig, keys []*crypto.Ed25519PrivateKey) (*MultiSigSigner, error) {
+	for _, key := range keys {
+		_, err := config.keyIndex(key.PubKey().(*crypto.Ed25519PublicKey))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(keys) < int(config.Threshold) {
+		return nil, fmt.Errorf("本地私钥 %d 个，不足门限 %d", len(keys), config.Threshold)
+	}
+	publicKey, err := config.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return &MultiSigSigner{config: config, publicKey: publicKey, keys: keys}, nil
+}
+
+// Sign 用本地成员私钥签名并组装多签验证器
+func (s *MultiSigSigner) Sign(msg []byte) (*crypto.AccountAuthenticator, error) {
+	partials := make([]PartialSignature, 0, len(s.keys))
+	for _, key := range s.keys {
+		partial, err := SignPartial(msg, key)
+		if err != nil {
+			return nil, err
+		}
+		partials = append(partials, *partial)
+	}
+	return s.config.AssembleAuthenticator(msg, partials)
+}
+
+// SignMessage 返回组装后的多签签名
+func (s *MultiSigSigner) SignMessage(msg []byte) (crypto.Signature, error) {
+	auth, err := s.Sign(msg)
+	if err != nil {
+		return nil, err
+	}
+	return auth.Signature(), nil
+}
+
+// SimulationAuthenticator 模拟交易时使用不校验签名的验证器
+func (s *MultiSigSigner) SimulationAuthenticator() *crypto.AccountAuthenticator {
+	return crypto.NoAccountAuthenticator()
+}
+
+// AuthKey 多签账户的认证密钥
+func (s *MultiSigSigner) AuthKey() *crypto.AuthenticationKey {
+	return s.publicKey.AuthKey()
+}
+
+// PubKey 多签账户的公钥
+func (s *MultiSigSigner) PubKey() crypto.PublicKey {
+	return s.publicKey
+}
+
+// parseEd25519PrivateKey 解析十六进制的Ed25519私钥
+func parseEd25519PrivateKey(privateKeyHex string) (*crypto.Ed25519PrivateKey, error) {
+	privateKeyBytes, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(privateKeyHex), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("解析私钥失败: %v", err)
+	}
+	key := &crypto.Ed25519PrivateKey{}
+	err = key.FromBytes(privateKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("创建Ed25519私钥失败: %v", err)
+	}
+	return key, nil
+}
+
+// printMultiSigUsage 打印多签命令的帮助
+func printMultiSigUsage() {
+	fmt.Println("多签账户用法:")
+	fmt.Println("  创建配置: ./main multisig new <multi_ed25519|multi_key> <门限K> <公钥1,公钥2,...> <输出配置文件>")
+	fmt.Println("  查看地址: ./main multisig address <配置文件>")
+	fmt.Println("  查看本人公钥(需PRIVATE_KEY): ./main multisig pubkey")
+	fmt.Println("  成员签名(离线, 需PRIVATE_KEY): ./main tx sign-partial <未签名文件> <输出签名文件>")
+	fmt.Println("  组装签名: ./main tx assemble <未签名文件> <配置文件> <输出文件> <签名文件...>")
+	fmt.Println("  本地持有K个私钥时设置MULTISIG_CONFIG，PRIVATE_KEY用逗号分隔多个私钥即可直接执行其他命令")
+}
+
+// runMultiSigCommand 处理 multisig 子命令，不需要连接节点
+func runMultiSigCommand(args []string) {
+	if len(args) < 1 {
+		printMultiSigUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "new":
+		if len(args) < 5 {
+			printMultiSigUsage()
+			os.Exit(1)
+		}
+		var threshold uint8
+		_, err := fmt.Sscanf(args[2], "%d", &threshold)
+		if err != nil {
+			logError(fmt.Sprintf("错误: 无效的门限 %s", args[2]))
+			os.Exit(1)
+		}
+		config, err := NewMultiSigConfig(args[1], threshold, strings.Split(args[3], ","))
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		address, err := config.Address()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		err = config.Save(args[4])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("已创建 %d-of-%d 多签配置 %s", config.Threshold, len(config.PublicKeys), args[4]))
+		logSuccess(fmt.Sprintf("多签账户地址: %s", address.String()))
+
+	case "address":
+		if len(args) < 2 {
+			printMultiSigUsage()
+			os.Exit(1)
+		}
+		config, err := LoadMultiSigConfig(args[1])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		address, err := config.Address()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("方案: %s, 门限: %d-of-%d\n", config.Scheme, config.Threshold, len(config.PublicKeys))
+		fmt.Printf("多签账户地址: %s\n", address.String())
+
+	case "pubkey":
+		key, err := parseEd25519PrivateKey(os.Getenv("PRIVATE_KEY"))
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("公钥: %s\n", key.PubKey().ToHex())
+
+	default:
+		logError(fmt.Sprintf("未知的multisig子命令: %s", args[0]))
+		printMultiSigUsage()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/crypto"
+	"golang.org/x/crypto/sha3"
+)
+
+// testMultiSigKeys 私钥分别为 32 个 0x01、0x02、0x03 字节的三个成员
+func testMultiSigKeys(t *testing.T) []*crypto.Ed25519PrivateKey {
+	t.Helper()
+	var keys []*crypto.Ed25519PrivateKey
+	for i := byte(1); i <= 3; i++ {
+		key, err := parseEd25519PrivateKey(hex.EncodeToString(bytes.Repeat([]byte{i}, 32)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func newTestMultiSigConfig(t *testing.T, scheme string, threshold uint8, keys []*crypto.Ed25519PrivateKey) *MultiSigConfig {
+	t.Helper()
+	var publicKeys []string
+	for _, key := range keys {
+		publicKeys = append(publicKeys, key.PubKey().ToHex())
+	}
+	config, err := NewMultiSigConfig(scheme, threshold, publicKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestMultiSigConfigAddress(t *testing.T) {
+	keys := testMultiSigKeys(t)
+	var multiEd25519Preimage, multiKeyPreimage []byte
+	// MultiKey 按 BCS 序列化: 公钥个数 || (AnyPublicKey 类型 0x00 || 长度 || 公钥)... || 门限
+	multiKeyPreimage = append(multiKeyPreimage, byte(len(keys)))
+	for _, key := range keys {
+		multiEd25519Preimage = append(multiEd25519Preimage, key.PubKey().Bytes()...)
+		multiKeyPreimage = append(append(multiKeyPreimage, 0x00, 32), key.PubKey().Bytes()...)
+	}
+	// 认证密钥为 sha3-256(公钥序列化 || 门限 || 方案标识)，MultiEd25519 标识为 0x01，MultiKey 为 0x03
+	multiEd25519Preimage = append(multiEd25519Preimage, 2, 0x01)
+	multiKeyPreimage = append(multiKeyPreimage, 2, 0x03)
+
+	for _, vector := range []struct {
+		scheme   string
+		preimage []byte
+		address  string
+	}{
+		{MultiSigSchemeMultiEd25519, multiEd25519Preimage, "0xe103d0e6e67b017524bebf94ae151df6a70c6f354178a88a9a3865bcafabfdb4"},
+		{MultiSigSchemeMultiKey, multiKeyPreimage, "0x337ff552951704cefa06a5381d377c6d50c68e9de1e56307b563463657bcc4ba"},
+	} {
+		config := newTestMultiSigConfig(t, vector.scheme, 2, keys)
+		address, err := config.Address()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := sha3.Sum256(vector.preimage)
+		if address.String() != vector.address || !bytes.Equal(address[:], expected[:]) {
+			t.Fatalf("%s 2-of-3 地址应为 %s (%x)，实际 %s", vector.scheme, vector.address, expected, address.String())
+		}
+
+		// 公钥顺序或门限不同则地址不同
+		reordered := newTestMultiSigConfig(t, vector.scheme, 2, []*crypto.Ed25519PrivateKey{keys[1], keys[0], keys[2]})
+		other, err := reordered.Address()
+		if err != nil || other == address {
+			t.Fatalf("%s 调换公钥顺序后地址应改变: %v", vector.scheme, err)
+		}
+		other, err = newTestMultiSigConfig(t, vector.scheme, 3, keys).Address()
+		if err != nil || other == address {
+			t.Fatalf("%s 改变门限后地址应改变: %v", vector.scheme, err)
+		}
+	}
+}
+
+// newTestMultiSigNode 在模拟节点上创建多签账户并充值
+func newTestMultiSigNode(t *testing.T, config *MultiSigConfig) (*FakeFullnode, *aptos.Client, aptos.AccountAddress) {
+	t.Helper()
+	node := NewFakeFullnode()
+	t.Cleanup(node.Close)
+	client, err := node.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := config.Address()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = node.CreateAccount(address, fakeNodeFundOctas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return node, client, address
+}
+
+func TestMultiSigSignerSubmitsKOfNTransaction(t *testing.T) {
+	keys := testMultiSigKeys(t)
+	for _, scheme := range []string{MultiSigSchemeMultiEd25519, MultiSigSchemeMultiKey} {
+		t.Run(scheme, func(t *testing.T) {
+			config := newTestMultiSigConfig(t, scheme, 2, keys)
+			_, client, address := newTestMultiSigNode(t, config)
+
+			// 只持有第 1 和第 3 个成员的私钥，位图不连续
+			signer, err := NewMultiSigSigner(config, []*crypto.Ed25519PrivateKey{keys[2], keys[0]})
+			if err != nil {
+				t.Fatal(err)
+			}
+			account, err := aptos.NewAccountFromSigner(signer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if account.Address != address {
+				t.Fatalf("签名者地址应为 %s，实际 %s", address.String(), account.Address.String())
+			}
+			_, err = sendAPT(context.Background(), client, account, "0xbeef", big.NewInt(12345))
+			if err != nil {
+				t.Fatalf("2-of-3 多签交易应通过模拟节点的签名校验: %v", err)
+			}
+			received, err := checkAPTBalance(context.Background(), client, "0xbeef")
+			if err != nil || received.Uint64() != 12345 {
+				t.Fatalf("接收方APT余额应为 12345，实际 %v: %v", received, err)
+			}
+
+			_, err = NewMultiSigSigner(config, keys[:1])
+			if err == nil || !strings.Contains(err.Error(), "不足门限") {
+				t.Fatalf("私钥不足门限时应拒绝创建签名者: %v", err)
+			}
+		})
+	}
+}
+
+func TestMultiSigBelowThresholdRejected(t *testing.T) {
+	keys := testMultiSigKeys(t)
+	for _, scheme := range []string{MultiSigSchemeMultiEd25519, MultiSigSchemeMultiKey} {
+		t.Run(scheme, func(t *testing.T) {
+			config := newTestMultiSigConfig(t, scheme, 2, keys)
+			_, client, address := newTestMultiSigNode(t, config)
+			payload, err := aptos.CoinTransferPayload(nil, aptos.AccountOne, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rawTxn, err := client.BuildTransaction(address, aptos.TransactionPayload{Payload: payload})
+			if err != nil {
+				t.Fatal(err)
+			}
+			message, err := rawTxn.SigningMessage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			partial, err := SignPartial(message, keys[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = config.AssembleAuthenticator(message, []PartialSignature{*partial})
+			if err == nil || !strings.Contains(err.Error(), "不足门限") {
+				t.Fatalf("签名不足门限时应拒绝组装: %v", err)
+			}
+
+			// 绕过组装检查: 用 1-of-3 配置组装只有一个签名的位图，再换成账户的 2-of-3 公钥
+			auth, err := newTestMultiSigConfig(t, scheme, 1, keys).AssembleAuthenticator(message, []PartialSignature{*partial})
+			if err != nil {
+				t.Fatal(err)
+			}
+			publicKey, err := config.PublicKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch a := auth.Auth.(type) {
+			case *crypto.MultiEd25519Authenticator:
+				a.PubKey = publicKey.(*crypto.MultiEd25519PublicKey)
+			case *crypto.MultiKeyAuthenticator:
+				a.PubKey = publicKey.(*crypto.MultiKey)
+			}
+			if verifyAccountAuthenticator(auth, message) {
+				t.Fatalf("只有一个签名的 2-of-3 验证器不应通过校验")
+			}
+			signedTxn, err := rawTxn.SignedTransactionWithAuthenticator(auth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fixTransactionAuthenticator(signedTxn)
+			_, err = client.SubmitTransaction(signedTxn)
+			if err == nil || !strings.Contains(err.Error(), "INVALID_SIGNATURE") {
+				t.Fatalf("模拟节点应以 INVALID_SIGNATURE 拒绝不足门限的交易: %v", err)
+			}
+		})
+	}
+}
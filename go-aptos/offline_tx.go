@@ -0,0 +1,545 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/bcs"
+	"github.com/aptos-labs/aptos-go-sdk/crypto"
+)
+
+// 离线签名交易的默认有效期，需要留出把文件带到离线机器签名再带回来的时间
+const defaultOfflineTxnExpirationSeconds = 3600
+
+// 链ID对应的网络名称
+var chainNames = map[uint8]string{
+	1: "mainnet",
+	2: "testnet",
+}
+
+// BuildOfflineTransaction 在联网机器上构建未签名交易，序列号、gas单价和链ID从节点获取
+func BuildOfflineTransaction(client *aptos.Client, sender aptos.AccountAddress, payload aptos.TransactionPayload, expirationSeconds uint64) (*aptos.RawTransaction, error) {
+	rawTxn, err := client.BuildTransaction(sender, payload, aptos.ExpirationSeconds(expirationSeconds))
+	if err != nil {
+		return nil, fmt.Errorf("构建交易失败: %v", err)
+	}
+	return rawTxn, nil
+}
+
+// SignOfflineTransaction 在离线机器上签名，只需要私钥，不访问网络
+func SignOfflineTransaction(rawTxn *aptos.RawTransaction, signer aptos.TransactionSigner) (*aptos.SignedTransaction, error) {
+	address := signer.AccountAddress()
+	if rawTxn.Sender != address {
+		return nil, fmt.Errorf("私钥对应地址 %s 不是交易发送方 %s", address.String(), rawTxn.Sender.String())
+	}
+	signedTxn, err := signTransaction(rawTxn, signer)
+	if err != nil {
+		return nil, fmt.Errorf("签名交易失败: %v", err)
+	}
+	return signedTxn, nil
+}
+
+// SubmitOfflineTransaction 广播已签名的交易并等待确认
+func SubmitOfflineTransaction(client *aptos.Client, signedTxn *aptos.SignedTransaction) (string, error) {
+	err := verifySignedTransaction(signedTxn)
+	if err != nil {
+		return "", err
+	}
+	if uint64(time.Now().Unix()) >= signedTxn.Transaction.ExpirationTimestampSeconds {
+		return "", fmt.Errorf("交易已于 %s 过期，需要重新构建", time.Unix(int64(signedTxn.Transaction.ExpirationTimestampSeconds), 0).Format(time.RFC3339))
+	}
+	return submitSignedAndWait(client, signedTxn)
+}
+
+// verifySignedTransaction 校验单发送方交易的签名，支持单签和多签账户
+func verifySignedTransaction(signedTxn *aptos.SignedTransaction) error {
+	message, err := signedTxn.Transaction.SigningMessage()
+	if err != nil {
+		return fmt.Errorf("生成签名消息失败: %v", err)
+	}
+	var sender *crypto.AccountAuthenticator
+	switch auth := signedTxn.Authenticator.Auth.(type) {
+	case *aptos.Ed25519TransactionAuthenticator:
+		sender = auth.Sender
+	case *aptos.MultiEd25519TransactionAuthenticator:
+		sender = auth.Sender
+	case *multiEd25519TransactionAuthenticator:
+		sender = auth.Sender
+	case *aptos.SingleSenderTransactionAuthenticator:
+		sender = auth.Sender
+	default:
+		return fmt.Errorf("不支持的交易验证器类型: %d", signedTxn.Authenticator.Variant)
+	}
+	if !verifyAccountAuthenticator(sender, message) {
+		return fmt.Errorf("签名校验失败")
+	}
+	return nil
+}
+
+// AssembleOfflineTransaction 用多签成员的部分签名组装已签名交易
+func AssembleOfflineTransaction(rawTxn *aptos.RawTransaction, config *MultiSigConfig, partials []PartialSignature) (*aptos.SignedTransaction, error) {
+	address, err := config.Address()
+	if err != nil {
+		return nil, err
+	}
+	if rawTxn.Sender != address {
+		return nil, fmt.Errorf("交易发送方 %s 不是多签账户 %s", rawTxn.Sender.String(), address.String())
+	}
+	message, err := rawTxn.SigningMessage()
+	if err != nil {
+		return nil, fmt.Errorf("生成签名消息失败: %v", err)
+	}
+	auth, err := config.AssembleAuthenticator(message, partials)
+	if err != nil {
+		return nil, err
+	}
+	signedTxn, err := rawTxn.SignedTransactionWithAuthenticator(auth)
+	if err != nil {
+		return nil, fmt.Errorf("组装交易失败: %v", err)
+	}
+	fixTransactionAuthenticator(signedTxn)
+	return signedTxn, nil
+}
+
+// SummarizeRawTransaction 生成交易的可读摘要，moduleAddress 下的桥合约调用按参数表解码
+func SummarizeRawTransaction(rawTxn *aptos.RawTransaction, moduleAddress aptos.AccountAddress) (string, error) {
+	var b strings.Builder
+	chainName, ok := chainNames[rawTxn.ChainId]
+	if !ok {
+		chainName = "devnet/本地网络"
+	}
+	fmt.Fprintf(&b, "链ID: %d (%s)\n", rawTxn.ChainId, chainName)
+	fmt.Fprintf(&b, "发送方: %s\n", rawTxn.Sender.String())
+	fmt.Fprintf(&b, "序列号: %d\n", rawTxn.SequenceNumber)
+	fmt.Fprintf(&b, "最大gas: %d, gas单价: %d (最多消耗 %s APT)\n",
+		rawTxn.MaxGasAmount, rawTxn.GasUnitPrice, formatOctas(rawTxn.MaxGasAmount*rawTxn.GasUnitPrice))
+	fmt.Fprintf(&b, "过期时间: %s\n", time.Unix(int64(rawTxn.ExpirationTimestampSeconds), 0).Format(time.RFC3339))
+
+	entryFunction, ok := rawTxn.Payload.Payload.(*aptos.EntryFunction)
+	if !ok {
+		fmt.Fprintf(&b, "负载: %T (非入口函数调用)\n", rawTxn.Payload.Payload)
+		return b.String(), nil
+	}
+	decoded, err := DecodeEntryFunction(entryFunction, moduleAddress)
+	if err != nil {
+		return "", err
+	}
+	b.WriteString("调用: " + decoded.String())
+	return b.String(), nil
+}
+
+// formatOctas 将Octas格式化为APT
+func formatOctas(octas uint64) string {
+	return strconv.FormatFloat(float64(octas)/100000000, 'f', -1, 64)
+}
+
+// writeHexFile 以十六进制文本写入BCS数据，便于在离线机器之间拷贝
+func writeHexFile(path string, value bcs.Marshaler) error {
+	data, err := bcs.Serialize(value)
+	if err != nil {
+		return fmt.Errorf("序列化交易失败: %v", err)
+	}
+	err = os.WriteFile(path, []byte(hex.EncodeToString(data)+"\n"), 0644)
+	if err != nil {
+		return fmt.Errorf("写入文件失败: %v", err)
+	}
+	return nil
+}
+
+// readHexFile 读取十六进制文本并反序列化
+func readHexFile(path string, value bcs.Unmarshaler) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %v", err)
+	}
+	data, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(content)), "0x"))
+	if err != nil {
+		return fmt.Errorf("解析十六进制失败: %v", err)
+	}
+	err = bcs.Deserialize(value, data)
+	if err != nil {
+		return fmt.Errorf("反序列化交易失败: %v", err)
+	}
+	return nil
+}
+
+// buildOfflinePayload 根据命令行参数构建离线交易的负载，返回负载和剩余参数
+func buildOfflinePayload(moduleAddress string, args []string) (aptos.TransactionPayload, []string, error) {
+	operation := args[0]
+	args = args[1:]
+	need := func(n int, usage string) error {
+		if len(args) < n {
+			return fmt.Errorf("参数不足，%s 的参数为: %s", operation, usage)
+		}
+		return nil
+	}
+
+	switch operation {
+	case "mint":
+		if err := need(3, "<btc_tx_id> <接收地址> <数量>"); err != nil {
+			return aptos.TransactionPayload{}, nil, err
+		}
+		receiver := aptos.AccountAddress{}
+		err := receiver.ParseStringRelaxed(args[1])
+		if err != nil {
+			return aptos.TransactionPayload{}, nil, fmt.Errorf("解析接收地址失败: %v", err)
+		}
+		amount, err := strconv.ParseUint(args[2], 10, 64)
+		if err != nil {
+			return aptos.TransactionPayload{}, nil, fmt.Errorf("无效的金额 %s", args[2])
+		}
+		payload, err := BridgeMintPayload(moduleAddress, args[0], receiver, amount)
+		return payload, args[3:], err
+
+	case "init-bridge":
+		if err := need(2, "<费用账户地址> <费用>"); err != nil {
+			return aptos.TransactionPayload{}, nil, err
+		}
+		feeAccount := aptos.AccountAddress{}
+		err := feeAccount.ParseStringRelaxed(args[0])
+		if err != nil {
+			return aptos.TransactionPayload{}, nil, fmt.Errorf("解析费用账户地址失败: %v", err)
+		}
+		fee, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return aptos.TransactionPayload{}, nil, fmt.Errorf("无效的费用 %s", args[1])
+		}
+		payload, err := BridgeInitializePayload(moduleAddress, feeAccount, fee)
+		return payload, args[2:], err
+
+	case "init-twbtc":
+		payload, err := TokenInitializeModulePayload(moduleAddress)
+		return payload, args, err
+
+	case "redeem-request":
+		if err := need(2, "<接收地址> <数量>"); err != nil {
+			return aptos.TransactionPayload{}, nil, err
+		}
+		amount, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return aptos.TransactionPayload{}, nil, fmt.Errorf("无效的金额 %s", args[1])
+		}
+		payload, err := buildRedeemRequestPayload(moduleAddress, args[0], amount)
+		return payload, args[2:], err
+
+	case "redeem-prepare":
+		if err := need(5, "<赎回请求交易哈希> <请求地址> <BTC接收地址> <数量> <txid:vout,...>"); err != nil {
+			return aptos.TransactionPayload{}, nil, err
+		}
+		requester := aptos.AccountAddress{}
+		err := requester.ParseStringRelaxed(args[1])
+		if err != nil {
+			return aptos.TransactionPayload{}, nil, fmt.Errorf("解析请求地址失败: %v", err)
+		}
+		amount, err := strconv.ParseUint(args[3], 10, 64)
+		if err != nil {
+			return aptos.TransactionPayload{}, nil, fmt.Errorf("无效的金额 %s", args[3])
+		}
+		var txIds []string
+		var idxs []uint64
+		for _, outpoint := range strings.Split(args[4], ",") {
+			txId, idxStr, ok := strings.Cut(outpoint, ":")
+			if !ok {
+				return aptos.TransactionPayload{}, nil, fmt.Errorf("无效的outpoint %s，格式应为 txid:vout", outpoint)
+			}
+			idx, err := strconv.ParseUint(idxStr, 10, 64)
+			if err != nil {
+				return aptos.TransactionPayload{}, nil, fmt.Errorf("无效的outpoint索引 %s", idxStr)
+			}
+			txIds = append(txIds, txId)
+			idxs = append(idxs, idx)
+		}
+		payload, err := buildRedeemPreparePayload(moduleAddress, args[0], requester, args[2], amount, txIds, idxs)
+		return payload, args[5:], err
+	}
+	return aptos.TransactionPayload{}, nil, fmt.Errorf("不支持离线构建的操作: %s", operation)
+}
+
+// confirmPrompt 在终端上请求确认
+func confirmPrompt(question string) bool {
+	fmt.Printf("%s (y/N): ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// printTxUsage 打印离线签名命令的帮助
+func printTxUsage() {
+	fmt.Println("离线签名用法:")
+	fmt.Println("  构建未签名交易(联网, 需SENDER_ADDRESS): ./main tx build <mint|init-bridge|init-twbtc|redeem-request|redeem-prepare> <参数...> <输出文件>")
+	fmt.Println("  签名(离线, 需PRIVATE_KEY): ./main tx sign <未签名文件> <输出文件>")
+	fmt.Println("  多签成员签名(离线, 需PRIVATE_KEY): ./main tx sign-partial <未签名文件> <输出签名文件>")
+	fmt.Println("  组装多签交易: ./main tx assemble <未签名文件> <多签配置文件> <输出文件> <签名文件...>")
+	fmt.Println("  广播已签名交易(联网): ./main tx submit <已签名文件>")
+}
+
+// runTxCommand 处理离线签名流程的 tx 子命令，只有 tx sign 需要私钥
+func runTxCommand(args []string) {
+	if len(args) < 1 {
+		printTxUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "build":
+		if len(args) < 3 {
+			printTxUsage()
+			os.Exit(1)
+		}
+		moduleAddress, err := getModuleAddress()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		senderStr := os.Getenv("SENDER_ADDRESS")
+		if senderStr == "" {
+			logError("错误: 缺少发送方地址。请设置SENDER_ADDRESS环境变量")
+			os.Exit(1)
+		}
+		sender := aptos.AccountAddress{}
+		err = sender.ParseStringRelaxed(senderStr)
+		if err != nil {
+			logError(fmt.Sprintf("解析发送方地址失败: %v", err))
+			os.Exit(1)
+		}
+		expirationSeconds := uint64(defaultOfflineTxnExpirationSeconds)
+		if valueStr := os.Getenv("OFFLINE_TXN_EXPIRATION_SECONDS"); valueStr != "" {
+			expirationSeconds, err = strconv.ParseUint(valueStr, 10, 64)
+			if err != nil || expirationSeconds == 0 {
+				logError(fmt.Sprintf("无效的OFFLINE_TXN_EXPIRATION_SECONDS: %s", valueStr))
+				os.Exit(1)
+			}
+		}
+
+		payload, rest, err := buildOfflinePayload(moduleAddress, args[1:])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		if len(rest) < 1 {
+			logError("错误: 需要指定输出文件")
+			printTxUsage()
+			os.Exit(1)
+		}
+		outputPath := rest[0]
+
+		client, err := createClient()
+		if err != nil {
+			logError(fmt.Sprintf("创建客户端失败: %v", err))
+			os.Exit(1)
+		}
+		rawTxn, err := BuildOfflineTransaction(client, sender, payload, expirationSeconds)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		summary, err := SummarizeRawTransaction(rawTxn, optionalModuleAddress())
+		if err != nil {
+			logError(fmt.Sprintf("生成交易摘要失败: %v", err))
+			os.Exit(1)
+		}
+		err = writeHexFile(outputPath, rawTxn)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		err = os.WriteFile(outputPath+".summary.txt", []byte(summary), 0644)
+		if err != nil {
+			logError(fmt.Sprintf("写入摘要文件失败: %v", err))
+			os.Exit(1)
+		}
+		fmt.Print(summary)
+		logSuccess(fmt.Sprintf("已生成未签名交易 %s 和摘要 %s.summary.txt", outputPath, outputPath))
+
+	case "sign":
+		if len(args) < 3 {
+			printTxUsage()
+			os.Exit(1)
+		}
+		privateKey := os.Getenv("PRIVATE_KEY")
+		if privateKey == "" {
+			logError("错误: 缺少私钥。请设置PRIVATE_KEY环境变量")
+			os.Exit(1)
+		}
+		account, err := createAccountFromPrivateKey(privateKey)
+		if err != nil {
+			logError(fmt.Sprintf("创建账户失败: %v", err))
+			os.Exit(1)
+		}
+		rawTxn := &aptos.RawTransaction{}
+		err = readHexFile(args[1], rawTxn)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+
+		// 摘要从交易本身解码，不信任构建时附带的摘要文件
+		summary, err := SummarizeRawTransaction(rawTxn, optionalModuleAddress())
+		if err != nil {
+			logError(fmt.Sprintf("解码交易失败: %v", err))
+			os.Exit(1)
+		}
+		fmt.Print(summary)
+		if !confirmPrompt("确认签名以上交易?") {
+			logWarning("已取消签名")
+			os.Exit(1)
+		}
+
+		signedTxn, err := SignOfflineTransaction(rawTxn, account)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		err = writeHexFile(args[2], signedTxn)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("已签名，输出到 %s", args[2]))
+
+	case "sign-partial":
+		if len(args) < 3 {
+			printTxUsage()
+			os.Exit(1)
+		}
+		key, err := parseEd25519PrivateKey(os.Getenv("PRIVATE_KEY"))
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		rawTxn := &aptos.RawTransaction{}
+		err = readHexFile(args[1], rawTxn)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		// 设置了多签配置时提前检查成员身份和发送方，避免签了无用的签名
+		if configPath := os.Getenv("MULTISIG_CONFIG"); configPath != "" {
+			config, err := LoadMultiSigConfig(configPath)
+			if err != nil {
+				logError(err.Error())
+				os.Exit(1)
+			}
+			_, err = config.keyIndex(key.PubKey().(*crypto.Ed25519PublicKey))
+			if err != nil {
+				logError(err.Error())
+				os.Exit(1)
+			}
+			address, err := config.Address()
+			if err != nil {
+				logError(err.Error())
+				os.Exit(1)
+			}
+			if rawTxn.Sender != address {
+				logError(fmt.Sprintf("错误: 交易发送方 %s 不是多签账户 %s", rawTxn.Sender.String(), address.String()))
+				os.Exit(1)
+			}
+		}
+
+		summary, err := SummarizeRawTransaction(rawTxn, optionalModuleAddress())
+		if err != nil {
+			logError(fmt.Sprintf("解码交易失败: %v", err))
+			os.Exit(1)
+		}
+		fmt.Print(summary)
+		if !confirmPrompt("确认签名以上交易?") {
+			logWarning("已取消签名")
+			os.Exit(1)
+		}
+		message, err := rawTxn.SigningMessage()
+		if err != nil {
+			logError(fmt.Sprintf("生成签名消息失败: %v", err))
+			os.Exit(1)
+		}
+		partial, err := SignPartial(message, key)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		err = WritePartialSignature(args[2], partial)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("已签名，部分签名输出到 %s", args[2]))
+
+	case "assemble":
+		if len(args) < 5 {
+			printTxUsage()
+			os.Exit(1)
+		}
+		rawTxn := &aptos.RawTransaction{}
+		err := readHexFile(args[1], rawTxn)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		config, err := LoadMultiSigConfig(args[2])
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		var partials []PartialSignature
+		for _, path := range args[4:] {
+			partial, err := ReadPartialSignature(path)
+			if err != nil {
+				logError(err.Error())
+				os.Exit(1)
+			}
+			partials = append(partials, *partial)
+		}
+		signedTxn, err := AssembleOfflineTransaction(rawTxn, config, partials)
+		if err != nil {
+			logError(fmt.Sprintf("组装多签交易失败: %v", err))
+			os.Exit(1)
+		}
+		err = writeHexFile(args[3], signedTxn)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("已组装 %d 个签名，输出到 %s", len(partials), args[3]))
+
+	case "submit":
+		if len(args) < 2 {
+			printTxUsage()
+			os.Exit(1)
+		}
+		signedTxn := &aptos.SignedTransaction{}
+		err := readHexFile(args[1], signedTxn)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		fixTransactionAuthenticator(signedTxn)
+		summary, err := SummarizeRawTransaction(signedTxn.Transaction, optionalModuleAddress())
+		if err != nil {
+			logError(fmt.Sprintf("解码交易失败: %v", err))
+			os.Exit(1)
+		}
+		fmt.Print(summary)
+
+		client, err := createClient()
+		if err != nil {
+			logError(fmt.Sprintf("创建客户端失败: %v", err))
+			os.Exit(1)
+		}
+		txHash, err := SubmitOfflineTransaction(client, signedTxn)
+		if err != nil {
+			logError(fmt.Sprintf("广播交易失败: %v", err))
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("交易哈希: %s", txHash))
+
+	default:
+		logError(fmt.Sprintf("未知的tx子命令: %s", args[0]))
+		printTxUsage()
+		os.Exit(1)
+	}
+}
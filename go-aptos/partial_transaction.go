@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/bcs"
+	"github.com/aptos-labs/aptos-go-sdk/crypto"
+)
+
+// 部分签名交易文件的类型
+const (
+	PartialTxnFeePayer   = "fee_payer"
+	PartialTxnMultiAgent = "multi_agent"
+)
+
+// 需要在多方之间传递签名的交易的有效期，留出交换文件的时间
+const partialTxnExpirationSeconds = 600
+
+// PartialTransaction 在多个签名方之间传递的部分签名交易文件
+// 交易和各方签名都以BCS十六进制保存，Function 仅用于人工核对
+type PartialTransaction struct {
+	Type           string            `json:"type"`
+	Function       string            `json:"function"`
+	Sender         string            `json:"sender"`
+	RawTransaction string            `json:"raw_transaction"`
+	Signatures     map[string]string `json:"signatures"` // 签名方地址 -> AccountAuthenticator
+}
+
+// NewPartialTransaction 由待签名的交易创建部分签名交易文件
+func NewPartialTransaction(txnType string, rawTxn *aptos.RawTransactionWithData) (*PartialTransaction, error) {
+	txnBytes, err := bcs.Serialize(rawTxn)
+	if err != nil {
+		return nil, fmt.Errorf("序列化交易失败: %v", err)
+	}
+	inner := innerRawTransaction(rawTxn)
+	if inner == nil {
+		return nil, fmt.Errorf("不支持的交易类型: %d", rawTxn.Variant)
+	}
+	return &PartialTransaction{
+		Type:           txnType,
+		Function:       entryFunctionName(inner.Payload),
+		Sender:         inner.Sender.String(),
+		RawTransaction: hex.EncodeToString(txnBytes),
+		Signatures:     make(map[string]string),
+	}, nil
+}
+
+// RawTransactionWithData 解码文件中的交易
+func (p *PartialTransaction) RawTransactionWithData() (*aptos.RawTransactionWithData, error) {
+	txnBytes, err := hex.DecodeString(strings.TrimPrefix(p.RawTransaction, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("解析交易十六进制失败: %v", err)
+	}
+	rawTxn := &aptos.RawTransactionWithData{}
+	err = bcs.Deserialize(rawTxn, txnBytes)
+	if err != nil {
+		return nil, fmt.Errorf("反序列化交易失败: %v", err)
+	}
+	return rawTxn, nil
+}
+
+// AddSignature 记录一个签名方的签名
+func (p *PartialTransaction) AddSignature(address aptos.AccountAddress, auth *crypto.AccountAuthenticator) error {
+	authBytes, err := bcs.Serialize(auth)
+	if err != nil {
+		return fmt.Errorf("序列化签名失败: %v", err)
+	}
+	if p.Signatures == nil {
+		p.Signatures = make(map[string]string)
+	}
+	p.Signatures[address.String()] = hex.EncodeToString(authBytes)
+	return nil
+}
+
+// Signature 读取并校验一个签名方的签名，签名必须对文件中的交易有效，且公钥的认证密钥等于签名方地址(不支持轮换过密钥的账户)
+func (p *PartialTransaction) Signature(address aptos.AccountAddress) (*crypto.AccountAuthenticator, error) {
+	authHex, ok := p.Signatures[address.String()]
+	if !ok {
+		return nil, fmt.Errorf("缺少 %s 的签名", address.String())
+	}
+	authBytes, err := hex.DecodeString(authHex)
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 的签名失败: %v", address.String(), err)
+	}
+	auth := &crypto.AccountAuthenticator{}
+	err = bcs.Deserialize(auth, authBytes)
+	if err != nil {
+		return nil, fmt.Errorf("反序列化 %s 的签名失败: %v", address.String(), err)
+	}
+
+	rawTxn, err := p.RawTransactionWithData()
+	if err != nil {
+		return nil, err
+	}
+	message, err := rawTxn.SigningMessage()
+	if err != nil {
+		return nil, fmt.Errorf("生成签名消息失败: %v", err)
+	}
+	if !verifyAccountAuthenticator(auth, message) {
+		return nil, fmt.Errorf("%s 的签名无效", address.String())
+	}
+	// 公钥必须属于签名方，否则任何人都能用自己的密钥冒充签名方
+	authKey := auth.PubKey().AuthKey()
+	if aptos.AccountAddress(*authKey) != address {
+		return nil, fmt.Errorf("%s 的签名公钥对应的认证密钥 %s 与签名方地址不一致", address.String(), authKey.ToHex())
+	}
+	return auth, nil
+}
+
+// Signers 返回需要签名的地址，第一个为发送方，其后为次要签名方
+func (p *PartialTransaction) Signers() ([]aptos.AccountAddress, error) {
+	rawTxn, err := p.RawTransactionWithData()
+	if err != nil {
+		return nil, err
+	}
+	switch inner := rawTxn.Inner.(type) {
+	case *aptos.MultiAgentRawTransactionWithData:
+		return append([]aptos.AccountAddress{inner.RawTxn.Sender}, inner.SecondarySigners...), nil
+	case *aptos.MultiAgentWithFeePayerRawTransactionWithData:
+		return append([]aptos.AccountAddress{inner.RawTxn.Sender}, inner.SecondarySigners...), nil
+	}
+	return nil, fmt.Errorf("不支持的交易类型: %d", rawTxn.Variant)
+}
+
+// MissingSigners 返回还未签名的地址
+func (p *PartialTransaction) MissingSigners() ([]aptos.AccountAddress, error) {
+	signers, err := p.Signers()
+	if err != nil {
+		return nil, err
+	}
+	var missing []aptos.AccountAddress
+	for _, signer := range signers {
+		if _, ok := p.Signatures[signer.String()]; !ok {
+			missing = append(missing, signer)
+		}
+	}
+	return missing, nil
+}
+
+// Sign 用本地私钥为文件中的交易签名，签名者必须是发送方或次要签名方之一
+func (p *PartialTransaction) Sign(signer aptos.TransactionSigner) error {
+	signers, err := p.Signers()
+	if err != nil {
+		return err
+	}
+	address := signer.AccountAddress()
+	isSigner := false
+	for _, s := range signers {
+		if s == address {
+			isSigner = true
+			break
+		}
+	}
+	if !isSigner {
+		return fmt.Errorf("%s 不是该交易的签名方", address.String())
+	}
+
+	rawTxn, err := p.RawTransactionWithData()
+	if err != nil {
+		return err
+	}
+	auth, err := rawTxn.Sign(signer)
+	if err != nil {
+		return fmt.Errorf("签名交易失败: %v", err)
+	}
+	return p.AddSignature(address, auth)
+}
+
+// BuildMultiAgentTransaction 构建需要多个签名方的交易，secondarySigners 按入口函数signer参数的顺序排列
+func BuildMultiAgentTransaction(client *aptos.Client, sender aptos.AccountAddress, payload aptos.TransactionPayload, secondarySigners []aptos.AccountAddress) (*PartialTransaction, error) {
+	rawTxn, err := client.BuildTransactionMultiAgent(sender, payload,
+		aptos.AdditionalSigners(secondarySigners),
+		aptos.ExpirationSeconds(partialTxnExpirationSeconds),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("构建多签名方交易失败: %v", err)
+	}
+	return NewPartialTransaction(PartialTxnMultiAgent, rawTxn)
+}
+
+// AssembleMultiAgentTransaction 校验所有签名并组装为可提交的交易
+func AssembleMultiAgentTransaction(p *PartialTransaction) (*aptos.SignedTransaction, error) {
+	if p.Type != PartialTxnMultiAgent {
+		return nil, fmt.Errorf("不是多签名方交易: %s", p.Type)
+	}
+	signers, err := p.Signers()
+	if err != nil {
+		return nil, err
+	}
+	senderAuth, err := p.Signature(signers[0])
+	if err != nil {
+		return nil, err
+	}
+	secondaryAuths := make([]crypto.AccountAuthenticator, 0, len(signers)-1)
+	for _, signer := range signers[1:] {
+		auth, err := p.Signature(signer)
+		if err != nil {
+			return nil, err
+		}
+		secondaryAuths = append(secondaryAuths, *auth)
+	}
+
+	rawTxn, err := p.RawTransactionWithData()
+	if err != nil {
+		return nil, err
+	}
+	signedTxn, ok := rawTxn.ToMultiAgentSignedTransaction(senderAuth, secondaryAuths)
+	if !ok {
+		return nil, fmt.Errorf("组装多签名方交易失败")
+	}
+	return signedTxn, nil
+}
+
+// SubmitPartialTransaction 在签名收齐后提交多签名方交易并等待确认
+func SubmitPartialTransaction(client *aptos.Client, p *PartialTransaction) (string, error) {
+	signedTxn, err := AssembleMultiAgentTransaction(p)
+	if err != nil {
+		return "", err
+	}
+	return submitSignedAndWait(client, signedTxn)
+}
+
+// submitSignedAndWait 提交已签名的交易并等待确认，交易执行失败时返回错误
+func submitSignedAndWait(client *aptos.Client, signedTxn *aptos.SignedTransaction) (string, error) {
+	resp, err := client.SubmitTransaction(signedTxn)
+	if err != nil {
+		return "", fmt.Errorf("提交交易失败: %v", err)
+	}
+	return waitForSuccess(client, resp.Hash)
+}
+
+// waitForSuccess 等待交易确认，交易执行失败时返回错误
+func waitForSuccess(client *aptos.Client, hash string) (string, error) {
+	txn, err := client.WaitForTransaction(hash)
+	if err != nil {
+		return "", fmt.Errorf("等待交易确认失败: %v", err)
+	}
+	if !txn.Success {
+		return "", fmt.Errorf("交易执行失败: %s", txn.VmStatus)
+	}
+	return hash, nil
+}
+
+// WritePartialTransaction 写入部分签名交易文件
+func WritePartialTransaction(path string, p *PartialTransaction) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化交易文件失败: %v", err)
+	}
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("写入交易文件失败: %v", err)
+	}
+	return nil
+}
+
+// ReadPartialTransaction 读取部分签名交易文件
+func ReadPartialTransaction(path string) (*PartialTransaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取交易文件失败: %v", err)
+	}
+	p := &PartialTransaction{}
+	err = json.Unmarshal(data, p)
+	if err != nil {
+		return nil, fmt.Errorf("解析交易文件失败: %v", err)
+	}
+	return p, nil
+}
+
+// innerRawTransaction 取出RawTransactionWithData中的RawTransaction
+func innerRawTransaction(rawTxn *aptos.RawTransactionWithData) *aptos.RawTransaction {
+	switch inner := rawTxn.Inner.(type) {
+	case *aptos.MultiAgentRawTransactionWithData:
+		return inner.RawTxn
+	case *aptos.MultiAgentWithFeePayerRawTransactionWithData:
+		return inner.RawTxn
+	}
+	return nil
+}
+
+// entryFunctionName 返回交易负载调用的函数全名，非入口函数时返回空字符串
+func entryFunctionName(payload aptos.TransactionPayload) string {
+	entryFunction, ok := payload.Payload.(*aptos.EntryFunction)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s::%s::%s", entryFunction.Module.Address.String(), entryFunction.Module.Name, entryFunction.Function)
+}
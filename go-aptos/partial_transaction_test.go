@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// newTestMultiAgentTransaction 不经过节点构建一笔双签名方交易
+func newTestMultiAgentTransaction(t *testing.T, sender aptos.AccountAddress, secondary aptos.AccountAddress) *PartialTransaction {
+	t.Helper()
+	payload, err := TokenRegisterPayload(sender.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawTxn := &aptos.RawTransactionWithData{
+		Variant: aptos.MultiAgentRawTransactionWithDataVariant,
+		Inner: &aptos.MultiAgentRawTransactionWithData{
+			RawTxn: &aptos.RawTransaction{
+				Sender:                     sender,
+				Payload:                    payload,
+				MaxGasAmount:               10000,
+				GasUnitPrice:               100,
+				ExpirationTimestampSeconds: uint64(time.Now().Unix()) + partialTxnExpirationSeconds,
+				ChainId:                    4,
+			},
+			SecondarySigners: []aptos.AccountAddress{secondary},
+		},
+	}
+	p, err := NewPartialTransaction(PartialTxnMultiAgent, rawTxn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPartialTransactionSignature(t *testing.T) {
+	sender, _ := aptos.NewEd25519Account()
+	secondary, _ := aptos.NewEd25519Account()
+	p := newTestMultiAgentTransaction(t, sender.Address, secondary.Address)
+
+	for _, signer := range []*aptos.Account{sender, secondary} {
+		if err := p.Sign(signer); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Signature(signer.Address); err != nil {
+			t.Fatalf("%s 的签名应通过校验: %v", signer.Address.String(), err)
+		}
+	}
+	if missing, err := p.MissingSigners(); err != nil || len(missing) != 0 {
+		t.Fatalf("两方签名后不应缺少签名方，实际 %v: %v", missing, err)
+	}
+	if _, err := AssembleMultiAgentTransaction(p); err != nil {
+		t.Fatalf("组装交易失败: %v", err)
+	}
+}
+
+func TestPartialTransactionRejectsForeignKey(t *testing.T) {
+	sender, _ := aptos.NewEd25519Account()
+	secondary, _ := aptos.NewEd25519Account()
+	attacker, _ := aptos.NewEd25519Account()
+	p := newTestMultiAgentTransaction(t, sender.Address, secondary.Address)
+
+	// 攻击者用自己的密钥对交易签出有效签名，再记在次要签名方名下
+	rawTxn, err := p.RawTransactionWithData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := rawTxn.Sign(attacker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddSignature(secondary.Address, auth); err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Signature(secondary.Address)
+	if err == nil || !strings.Contains(err.Error(), "认证密钥") {
+		t.Fatalf("其他账户的公钥应被拒绝，实际: %v", err)
+	}
+	if err := p.Sign(sender); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AssembleMultiAgentTransaction(p); err == nil {
+		t.Fatalf("包含冒充签名的交易不应被组装")
+	}
+}
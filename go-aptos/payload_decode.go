@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/bcs"
+)
+
+// MoveParam 入口函数的一个非signer参数
+type MoveParam struct {
+	Name string
+	Type string
+}
+
+// DecodedArg 解码后的参数
+type DecodedArg struct {
+	Name  string
+	Type  string
+	Value string
+}
+
+// DecodedEntryFunction 解码后的入口函数调用
+type DecodedEntryFunction struct {
+	Function string // 完整函数名 address::module::function
+	Known    bool   // 是否为已知的桥合约函数，未知函数的参数以十六进制显示
+	TypeArgs []string
+	Args     []DecodedArg
+}
+
+// bridgeEntryFunctionParams 返回桥合约入口函数的参数表，模块地址不是 moduleAddress 时返回 false，
+// 防止其他地址下同名的模块被当作桥合约调用解码
+func bridgeEntryFunctionParams(entryFunction *aptos.EntryFunction, moduleAddress aptos.AccountAddress) ([]MoveParam, bool) {
+	if entryFunction.Module.Address != moduleAddress {
+		return nil, false
+	}
+	params, ok := bindingEntryFunctions[entryFunction.Module.Name+"::"+entryFunction.Function]
+	if !ok || len(params) != len(entryFunction.Args) {
+		return nil, false
+	}
+	return params, true
+}
+
+// optionalModuleAddress 解析 MODULE_PUBLISHER_ACCOUNT_ADDRESS，未设置或无效时返回零地址，此时任何调用都不按桥合约函数解码
+func optionalModuleAddress() aptos.AccountAddress {
+	moduleAddress := aptos.AccountAddress{}
+	if value := os.Getenv("MODULE_PUBLISHER_ACCOUNT_ADDRESS"); value != "" && moduleAddress.ParseStringRelaxed(value) != nil {
+		return aptos.AccountAddress{}
+	}
+	return moduleAddress
+}
+
+// DecodeEntryFunction 按桥合约的参数表解码入口函数的BCS参数，只有 moduleAddress 下的模块才视为桥合约
+func DecodeEntryFunction(entryFunction *aptos.EntryFunction, moduleAddress aptos.AccountAddress) (*DecodedEntryFunction, error) {
+	decoded := &DecodedEntryFunction{
+		Function: fmt.Sprintf("%s::%s::%s", entryFunction.Module.Address.String(), entryFunction.Module.Name, entryFunction.Function),
+	}
+	for _, typeArg := range entryFunction.ArgTypes {
+		decoded.TypeArgs = append(decoded.TypeArgs, typeArg.String())
+	}
+
+	params, ok := bridgeEntryFunctionParams(entryFunction, moduleAddress)
+	if !ok {
+		for i, arg := range entryFunction.Args {
+			decoded.Args = append(decoded.Args, DecodedArg{
+				Name:  fmt.Sprintf("arg%d", i),
+				Type:  "bytes",
+				Value: "0x" + fmt.Sprintf("%x", arg),
+			})
+		}
+		return decoded, nil
+	}
+
+	decoded.Known = true
+	for i, param := range params {
+		value, err := decodeMoveArg(param.Type, entryFunction.Args[i])
+		if err != nil {
+			return nil, fmt.Errorf("解码参数 %s 失败: %v", param.Name, err)
+		}
+		decoded.Args = append(decoded.Args, DecodedArg{Name: param.Name, Type: param.Type, Value: value})
+	}
+	return decoded, nil
+}
+
+// decodeMoveArg 将一个BCS编码的参数解码为可读字符串
+func decodeMoveArg(moveType string, arg []byte) (string, error) {
+	des := bcs.NewDeserializer(arg)
+	value := decodeMoveArgValue(moveType, des)
+	if des.Error() != nil {
+		return "", des.Error()
+	}
+	if des.Remaining() != 0 {
+		return "", fmt.Errorf("参数末尾有 %d 字节多余数据", des.Remaining())
+	}
+	return value, nil
+}
+
+func decodeMoveArgValue(moveType string, des *bcs.Deserializer) string {
+	switch moveType {
+	case "address":
+		address := aptos.AccountAddress{}
+		des.Struct(&address)
+		return address.String()
+	case "u64":
+		return strconv.FormatUint(des.U64(), 10)
+	case "bool":
+		return strconv.FormatBool(des.Bool())
+	case "0x1::string::String":
+		return strconv.Quote(des.ReadString())
+	}
+
+	// Object<T> 以对象地址编码
+	if strings.HasPrefix(moveType, "0x1::object::Object<") {
+		return decodeMoveArgValue("address", des)
+	}
+	if inner, ok := strings.CutPrefix(moveType, "vector<"); ok {
+		inner = strings.TrimSuffix(inner, ">")
+		if inner == "u8" {
+			return fmt.Sprintf("0x%x", des.ReadBytes())
+		}
+		// 长度来自输入，每个元素至少占 1 字节，容量不超过剩余字节数
+		length := des.Uleb128()
+		values := make([]string, 0, min(int(length), des.Remaining()))
+		for i := uint32(0); i < length && des.Error() == nil; i++ {
+			values = append(values, decodeMoveArgValue(inner, des))
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	}
+
+	des.SetError(fmt.Errorf("不支持的参数类型: %s", moveType))
+	return ""
+}
+
+// entryFunctionArgsJSON 按全节点 REST 接口的写法把已知桥合约函数的参数解码为 JSON 值:
+// u64 为十进制字符串，vector<u8> 为十六进制字符串，不是 moduleAddress 下的桥合约函数或解码失败时返回 false
+func entryFunctionArgsJSON(entryFunction *aptos.EntryFunction, moduleAddress aptos.AccountAddress) ([]any, bool) {
+	params, ok := bridgeEntryFunctionParams(entryFunction, moduleAddress)
+	if !ok {
+		return nil, false
+	}
+	args := make([]any, 0, len(params))
+	for i, param := range params {
+		des := bcs.NewDeserializer(entryFunction.Args[i])
+		value := decodeMoveArgJSON(param.Type, des)
+		if des.Error() != nil || des.Remaining() != 0 {
+			return nil, false
+		}
+		args = append(args, value)
+	}
+	return args, true
+}
+
+func decodeMoveArgJSON(moveType string, des *bcs.Deserializer) any {
+	switch moveType {
+	case "bool":
+		return des.Bool()
+	case "0x1::string::String":
+		return des.ReadString()
+	}
+	if inner, ok := strings.CutPrefix(moveType, "vector<"); ok {
+		inner = strings.TrimSuffix(inner, ">")
+		if inner == "u8" {
+			return fmt.Sprintf("0x%x", des.ReadBytes())
+		}
+		length := des.Uleb128()
+		values := make([]any, 0, min(int(length), des.Remaining()))
+		for i := uint32(0); i < length && des.Error() == nil; i++ {
+			values = append(values, decodeMoveArgJSON(inner, des))
+		}
+		return values
+	}
+	// 地址、Object<T> 和 u64 与可读形式相同
+	return decodeMoveArgValue(moveType, des)
+}
+
+// String 返回可读的调用描述
+func (d *DecodedEntryFunction) String() string {
+	var b strings.Builder
+	b.WriteString(d.Function)
+	if len(d.TypeArgs) > 0 {
+		b.WriteString("<" + strings.Join(d.TypeArgs, ", ") + ">")
+	}
+	b.WriteString("\n")
+	if !d.Known {
+		b.WriteString("  (非桥合约函数或不在桥模块地址下，参数未解码)\n")
+	}
+	for _, arg := range d.Args {
+		fmt.Fprintf(&b, "  %s: %s = %s\n", arg.Name, arg.Type, arg.Value)
+	}
+	return b.String()
+}
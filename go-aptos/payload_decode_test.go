@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+func TestDecodeEntryFunctionBridgeModule(t *testing.T) {
+	module := aptos.AccountAddress{}
+	module[31] = 0x42
+	receiver := aptos.AccountAddress{}
+	receiver[31] = 0xa
+	payload, err := BridgeMintPayload(module.String(), "btc-tx", receiver, 20000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entryFunction := payload.Payload.(*aptos.EntryFunction)
+
+	decoded, err := DecodeEntryFunction(entryFunction, module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Known || len(decoded.Args) != 3 || decoded.Args[0].Value != `"btc-tx"` || decoded.Args[1].Value != receiver.String() || decoded.Args[2].Value != "20000" {
+		t.Fatalf("桥模块地址下的 mint 应按参数表解码，实际 %+v", decoded)
+	}
+	args, ok := entryFunctionArgsJSON(entryFunction, module)
+	if !ok || len(args) != 3 || args[2] != "20000" {
+		t.Fatalf("桥模块地址下的 mint 应解码为 REST 参数，实际 %v", args)
+	}
+}
+
+func TestDecodeEntryFunctionLookalikeModule(t *testing.T) {
+	module := aptos.AccountAddress{}
+	module[31] = 0x42
+	attacker := aptos.AccountAddress{}
+	attacker[31] = 0x66
+	payload, err := BridgeMintPayload(attacker.String(), "btc-tx", attacker, 20000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entryFunction := payload.Payload.(*aptos.EntryFunction)
+
+	decoded, err := DecodeEntryFunction(entryFunction, module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Known || decoded.Args[0].Type != "bytes" {
+		t.Fatalf("其他地址下同名的模块不应按桥合约解码，实际 %+v", decoded)
+	}
+	if _, ok := entryFunctionArgsJSON(entryFunction, module); ok {
+		t.Fatalf("其他地址下同名的模块不应解码为 REST 参数")
+	}
+	if decoded, _ := DecodeEntryFunction(entryFunction, aptos.AccountAddress{}); decoded.Known {
+		t.Fatalf("未配置模块地址时不应按桥合约解码")
+	}
+}
+
+func TestDecodeEntryFunctionHostileVectorLength(t *testing.T) {
+	module := aptos.AccountAddress{}
+	module[31] = 0x42
+	payload, err := BridgeRedeemPreparePayload(module.String(), "0xabc", module, "tb1q", 20000, []string{"tx"}, []uint64{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entryFunction := payload.Payload.(*aptos.EntryFunction)
+	// 向量长度声明为 0xffffffff 但没有元素，不应按声明的长度分配内存
+	hostile := []byte{0xff, 0xff, 0xff, 0xff, 0x0f}
+	entryFunction.Args[4] = hostile
+	entryFunction.Args[5] = hostile
+
+	if _, err := DecodeEntryFunction(entryFunction, module); err == nil {
+		t.Fatalf("长度与内容不符的向量应解码失败")
+	}
+	if _, ok := entryFunctionArgsJSON(entryFunction, module); ok {
+		t.Fatalf("长度与内容不符的向量不应解码为 REST 参数")
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+const (
+	// btc_tokenv3::get_fee 固定收取的铸币手续费 (satoshi)
+	twbtcTokenMintFee uint64 = 10000
+
+	// BTC 粉尘上限，低于该值的输出无法被广播
+	btcDustLimit uint64 = 546
+
+	// 默认的 BTC 手续费率 (sat/vB)，可通过 BTC_FEE_RATE 环境变量覆盖
+	defaultBtcFeeRate uint64 = 10
+
+	// 典型赎回出账交易的虚拟大小: 1 个 P2WPKH 输入 + 收款和找零 2 个输出
+	btcPayoutTxVSize uint64 = 141
+
+	// 典型充值交易的虚拟大小: 1 个 P2WPKH 输入 + 充值和找零 2 个输出
+	btcDepositTxVSize uint64 = 141
+)
+
+// 报价操作类型
+const (
+	QuoteMint   = "mint"
+	QuoteRedeem = "redeem"
+)
+
+// BridgeQuote 一次铸币或赎回的费用报价 (单位均为 satoshi)
+type BridgeQuote struct {
+	Operation   string
+	GrossAmount uint64
+	BridgeFee   uint64
+	Fee         uint64 // 实际从本次金额中扣除的费用
+	NetReceived uint64 // Aptos 侧 (铸币) 或 BTC 侧 (赎回，未扣矿工费) 实际到账
+	BtcFeeRate  uint64
+	BtcMinerFee uint64
+	MinAmount   uint64 // 合约不会 abort 的最小金额
+	MinViable   uint64 // 扣除所有费用后仍可到账的最小金额
+	WillAbort   bool
+	AbortReason string
+}
+
+// getBtcFeeRate 获取 BTC 手续费率 (sat/vB)
+func getBtcFeeRate() (uint64, error) {
+	feeRateStr := os.Getenv("BTC_FEE_RATE")
+	if feeRateStr == "" {
+		return defaultBtcFeeRate, nil
+	}
+	feeRate, err := strconv.ParseUint(feeRateStr, 10, 64)
+	if err != nil || feeRate == 0 {
+		return 0, fmt.Errorf("无效的BTC_FEE_RATE: %s", feeRateStr)
+	}
+	return feeRate, nil
+}
+
+// getBridgeFee 从链上BridgeConfig读取当前桥费用
+func getBridgeFee(client *aptos.Client, moduleAddress string) (uint64, error) {
+	config, err := GetBridgeConfig(client, moduleAddress)
+	if err != nil {
+		return 0, err
+	}
+	return config.Fee, nil
+}
+
+// QuoteBridgeOperation 根据链上实时费用计算铸币或赎回的报价
+func QuoteBridgeOperation(client *aptos.Client, moduleAddress string, operation string, amount uint64) (*BridgeQuote, error) {
+	bridgeFee, err := getBridgeFee(client, moduleAddress)
+	if err != nil {
+		return nil, err
+	}
+	feeRate, err := getBtcFeeRate()
+	if err != nil {
+		return nil, err
+	}
+	return calculateQuote(operation, amount, bridgeFee, feeRate)
+}
+
+// calculateQuote 按照btc_bridgev3/btc_tokenv3的扣费逻辑计算报价
+func calculateQuote(operation string, amount uint64, bridgeFee uint64, feeRate uint64) (*BridgeQuote, error) {
+	quote := &BridgeQuote{
+		Operation:   operation,
+		GrossAmount: amount,
+		BridgeFee:   bridgeFee,
+		BtcFeeRate:  feeRate,
+	}
+
+	switch operation {
+	case QuoteMint:
+		// btc_bridgev3::mint 要求 amount > BridgeConfig.fee，
+		// btc_tokenv3::mint_tokens 要求 amount > 固定手续费，并把固定手续费铸给管理员
+		quote.Fee = twbtcTokenMintFee
+		quote.BtcMinerFee = feeRate * btcDepositTxVSize
+		quote.MinAmount = max(bridgeFee, twbtcTokenMintFee) + 1
+		quote.MinViable = quote.MinAmount
+		if amount <= bridgeFee {
+			quote.WillAbort = true
+			quote.AbortReason = fmt.Sprintf("金额必须大于桥费用 %d (E_INSUFFICIENT_AMOUNT)", bridgeFee)
+		} else if amount <= twbtcTokenMintFee {
+			quote.WillAbort = true
+			quote.AbortReason = fmt.Sprintf("金额必须大于代币铸币手续费 %d (E_INSUFFICIENT_AMOUNT)", twbtcTokenMintFee)
+		} else {
+			quote.NetReceived = amount - twbtcTokenMintFee
+		}
+
+	case QuoteRedeem:
+		// btc_bridgev3::redeem_request 要求 amount > BridgeConfig.fee，
+		// 费用转给fee_account，剩余部分燃烧并在BTC侧支付
+		quote.Fee = bridgeFee
+		quote.BtcMinerFee = feeRate * btcPayoutTxVSize
+		quote.MinAmount = bridgeFee + 1
+		quote.MinViable = bridgeFee + quote.BtcMinerFee + btcDustLimit
+		if amount <= bridgeFee {
+			quote.WillAbort = true
+			quote.AbortReason = fmt.Sprintf("金额必须大于桥费用 %d (E_INSUFFICIENT_AMOUNT)", bridgeFee)
+		} else {
+			quote.NetReceived = amount - bridgeFee
+		}
+
+	default:
+		return nil, fmt.Errorf("未知的报价操作: %s (应为 %s 或 %s)", operation, QuoteMint, QuoteRedeem)
+	}
+
+	return quote, nil
+}
+
+// checkRedeemAmount 在提交赎回请求前检查金额和余额，避免交易在链上abort
+func checkRedeemAmount(client *aptos.Client, account aptos.AccountAddress, moduleAddress string, amount uint64) error {
+	config, err := GetBridgeConfig(client, moduleAddress)
+	if err != nil {
+		return err
+	}
+	bridgeFee := config.Fee
+	if amount <= bridgeFee {
+		return fmt.Errorf("赎回金额 %d 必须大于桥费用 %d，否则交易会以E_INSUFFICIENT_AMOUNT失败", amount, bridgeFee)
+	}
+
+	balance, err := CheckTWBTCBalance(client, account, moduleAddress)
+	if err != nil {
+		return fmt.Errorf("检查TWBTC余额失败: %v", err)
+	}
+	// 合约先把费用转给费用账户再检查余额，赎回方就是费用账户时费用会转回自己
+	required := amount
+	feeAccount := aptos.AccountAddress{}
+	if feeAccount.ParseStringRelaxed(config.FeeAccount) == nil && feeAccount == account {
+		required = amount - bridgeFee
+	}
+	if balance.Cmp(new(big.Int).SetUint64(required)) < 0 {
+		return fmt.Errorf("TWBTC余额不足: 当前 %s，需要 %d", balance.String(), required)
+	}
+	return nil
+}
+
+// printQuote 打印报价的可读格式
+func printQuote(quote *BridgeQuote) {
+	fmt.Printf("===== %s 报价 =====\n", quote.Operation)
+	fmt.Printf("总金额: %d (satoshi)\n", quote.GrossAmount)
+	fmt.Printf("桥费用: %d (satoshi)\n", quote.BridgeFee)
+	fmt.Printf("扣除费用: %d (satoshi)\n", quote.Fee)
+	fmt.Printf("实际到账: %d (satoshi)\n", quote.NetReceived)
+	fmt.Printf("BTC矿工费估算: %d (satoshi, %d sat/vB)\n", quote.BtcMinerFee, quote.BtcFeeRate)
+	if quote.Operation == QuoteRedeem && quote.NetReceived > quote.BtcMinerFee {
+		fmt.Printf("BTC侧扣除矿工费后到账: %d (satoshi)\n", quote.NetReceived-quote.BtcMinerFee)
+	}
+	fmt.Printf("合约最小金额: %d (satoshi)\n", quote.MinAmount)
+	fmt.Printf("最小可行金额: %d (satoshi)\n", quote.MinViable)
+	if quote.WillAbort {
+		logWarning(fmt.Sprintf("该金额会导致交易失败: %s", quote.AbortReason))
+	} else if quote.GrossAmount < quote.MinViable {
+		logWarning("该金额低于最小可行金额，扣除费用后可能无法到账")
+	}
+}
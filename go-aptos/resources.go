@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// EventHandleID 对应 0x1::guid::ID
+type EventHandleID struct {
+	Addr        string `json:"addr"`
+	CreationNum uint64 `json:"creation_num"`
+}
+
+// EventHandle 对应 0x1::event::EventHandle<T>
+type EventHandle struct {
+	Counter uint64 `json:"counter"`
+	Guid    struct {
+		Id EventHandleID `json:"id"`
+	} `json:"guid"`
+}
+
+// CoinCapability 对应 0x1::coin 中的 Mint/Burn/FreezeCapability<T>，空结构体在 REST 中编码为 dummy_field
+type CoinCapability struct {
+	DummyField bool `json:"dummy_field"`
+}
+
+// decodeMoveValue 将 REST 返回的 Move 值解码到 out 指向的结构体
+// REST 把 u64/u128/u256 编码为字符串、u8/u16/u32 编码为数字，两种形式都能处理；
+// 字段缺失或类型不符时返回错误而不是 panic
+func decodeMoveValue(raw any, out any) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("解码目标必须是非空指针")
+	}
+	return decodeMoveField(raw, v.Elem(), "data")
+}
+
+func decodeMoveField(raw any, v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		var err error
+		switch r := raw.(type) {
+		case string:
+			n, err = strconv.ParseUint(r, 10, v.Type().Bits())
+		case float64:
+			if r < 0 || r != float64(uint64(r)) {
+				err = fmt.Errorf("不是无符号整数: %v", r)
+			}
+			n = uint64(r)
+			if v.OverflowUint(n) {
+				err = fmt.Errorf("数值溢出: %v", r)
+			}
+		default:
+			err = fmt.Errorf("期望整数, 实际为 %T", raw)
+		}
+		if err != nil {
+			return fmt.Errorf("字段 %s 解析失败: %v", path, err)
+		}
+		v.SetUint(n)
+
+	case reflect.String:
+		s, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("字段 %s 解析失败: 期望字符串, 实际为 %T", path, raw)
+		}
+		v.SetString(s)
+
+	case reflect.Bool:
+		b, ok := raw.(bool)
+		if !ok {
+			return fmt.Errorf("字段 %s 解析失败: 期望布尔值, 实际为 %T", path, raw)
+		}
+		v.SetBool(b)
+
+	case reflect.Slice:
+		list, ok := raw.([]any)
+		if !ok {
+			return fmt.Errorf("字段 %s 解析失败: 期望数组, 实际为 %T", path, raw)
+		}
+		slice := reflect.MakeSlice(v.Type(), len(list), len(list))
+		for i, item := range list {
+			err := decodeMoveField(item, slice.Index(i), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+
+	case reflect.Struct:
+		m, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("字段 %s 解析失败: 期望对象, 实际为 %T", path, raw)
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			value, ok := m[name]
+			if !ok {
+				return fmt.Errorf("字段 %s 缺少 %s", path, name)
+			}
+			err := decodeMoveField(value, v.Field(i), path+"."+name)
+			if err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("字段 %s 不支持的类型: %s", path, v.Type())
+	}
+	return nil
+}
+
+// getModuleResource 读取模块地址下的资源并解码其 data 字段，可以指定账本版本
+func getModuleResource(client *aptos.Client, moduleAddress string, structTag string, out any, ledgerVersion ...uint64) error {
+	address := aptos.AccountAddress{}
+	err := address.ParseStringRelaxed(moduleAddress)
+	if err != nil {
+		return fmt.Errorf("解析模块地址失败: %v", err)
+	}
+
+	resourceType := fmt.Sprintf("%s::%s", address.String(), structTag)
+	resource, err := client.AccountResource(address, resourceType, ledgerVersion...)
+	if err != nil {
+		return fmt.Errorf("获取资源 %s 失败: %v", structTag, err)
+	}
+
+	data, ok := resource["data"]
+	if !ok {
+		return fmt.Errorf("资源 %s 中未找到data字段", structTag)
+	}
+	err = decodeMoveValue(data, out)
+	if err != nil {
+		return fmt.Errorf("解码资源 %s 失败: %v", structTag, err)
+	}
+	return nil
+}
+
+// GetMintedTransactions 获取已铸币的BTC交易ID列表
+func GetMintedTransactions(client *aptos.Client, moduleAddress string, ledgerVersion ...uint64) (*BridgeMintedTransactions, error) {
+	minted := &BridgeMintedTransactions{}
+	err := getModuleResource(client, moduleAddress, BridgeMintedTransactionsStructTag, minted, ledgerVersion...)
+	if err != nil {
+		return nil, err
+	}
+	return minted, nil
+}
+
+// GetBridgeEvents 获取桥事件句柄
+func GetBridgeEvents(client *aptos.Client, moduleAddress string, ledgerVersion ...uint64) (*BridgeEvents, error) {
+	events := &BridgeEvents{}
+	err := getModuleResource(client, moduleAddress, BridgeEventsStructTag, events, ledgerVersion...)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetTokenBridgeEvents 获取代币事件句柄
+func GetTokenBridgeEvents(client *aptos.Client, moduleAddress string, ledgerVersion ...uint64) (*TokenBridgeEvents, error) {
+	events := &TokenBridgeEvents{}
+	err := getModuleResource(client, moduleAddress, TokenBridgeEventsStructTag, events, ledgerVersion...)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetBTCCapabilities 获取代币能力资源
+func GetBTCCapabilities(client *aptos.Client, moduleAddress string, ledgerVersion ...uint64) (*TokenBTCCapabilities, error) {
+	capabilities := &TokenBTCCapabilities{}
+	err := getModuleResource(client, moduleAddress, TokenBTCCapabilitiesStructTag, capabilities, ledgerVersion...)
+	if err != nil {
+		return nil, err
+	}
+	return capabilities, nil
+}
+
+// isResourceNotFound 判断节点返回的错误是否为资源不存在
+func isResourceNotFound(err error) bool {
+	var httpErr *aptos.HttpError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound
+}
+
+// moduleResourceExists 检查模块地址下是否存在指定资源
+func moduleResourceExists(client *aptos.Client, moduleAddress string, structTag string) (bool, error) {
+	address := aptos.AccountAddress{}
+	err := address.ParseStringRelaxed(moduleAddress)
+	if err != nil {
+		return false, fmt.Errorf("解析模块地址失败: %v", err)
+	}
+
+	resourceType := fmt.Sprintf("%s::%s", address.String(), structTag)
+	_, err = client.AccountResource(address, resourceType)
+	if err != nil {
+		if isResourceNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("获取资源 %s 失败: %v", structTag, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/api"
+)
+
+// 提交失败时最多重新同步序列号的次数
+const sequenceSubmitRetries = 3
+
+// sequenceNumberClient 序列号管理器依赖的节点接口，*aptos.Client 实现了该接口
+type sequenceNumberClient interface {
+	Account(address aptos.AccountAddress, ledgerVersion ...uint64) (aptos.AccountInfo, error)
+	SubmitTransaction(signedTransaction *aptos.SignedTransaction) (*api.SubmitTransactionResponse, error)
+}
+
+// SequenceManager 单个账户的本地序列号管理器
+// 在本地分配序列号以便同一账户同时有多笔交易在途，遇到 SEQUENCE_NUMBER_TOO_OLD/TOO_NEW
+// 时与链上重新同步，过期未上链的序列号会被回收并优先重新分配以填补空洞
+type SequenceManager struct {
+	client      sequenceNumberClient
+	address     aptos.AccountAddress
+	maxInFlight int
+	now         func() time.Time // 与交易过期时间比较的时钟，应与账本时间一致
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	synced   bool
+	syncing  bool // 有调用方正在读取链上序列号
+	next     uint64
+	inFlight map[uint64]uint64 // 序列号 -> 过期时间戳(秒)，0 表示已分配但尚未提交
+	released []uint64          // 已回收待复用的序列号，升序
+}
+
+// NewSequenceManager 创建序列号管理器，maxInFlight 为同时在途交易数上限
+func NewSequenceManager(client sequenceNumberClient, address aptos.AccountAddress, maxInFlight int) *SequenceManager {
+	if maxInFlight <= 0 {
+		maxInFlight = 1
+	}
+	m := &SequenceManager{
+		client:      client,
+		address:     address,
+		maxInFlight: maxInFlight,
+		now:         time.Now,
+		inFlight:    make(map[uint64]uint64),
+	}
+	m.cond = sync.NewCond(&m.mu)
+	return m
+}
+
+// fetchOnChain 获取链上当前序列号
+func (m *SequenceManager) fetchOnChain() (uint64, error) {
+	info, err := m.client.Account(m.address)
+	if err != nil {
+		return 0, fmt.Errorf("获取账户信息失败: %v", err)
+	}
+	sequenceNumber, err := info.SequenceNumber()
+	if err != nil {
+		return 0, fmt.Errorf("获取序列号失败: %v", err)
+	}
+	return sequenceNumber, nil
+}
+
+// Acquire 分配一个序列号，在途交易达到上限时阻塞等待
+// 首次分配前需要读取链上序列号，读取期间不持有锁，其他调用方等待同步完成而不是重复请求
+func (m *SequenceManager) Acquire() (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for {
+		for len(m.inFlight) >= m.maxInFlight || m.syncing {
+			m.cond.Wait()
+		}
+		if m.synced {
+			break
+		}
+
+		m.syncing = true
+		m.mu.Unlock()
+		onChain, err := m.fetchOnChain()
+		m.mu.Lock()
+		m.syncing = false
+		m.cond.Broadcast()
+		if err != nil {
+			return 0, err
+		}
+		// 读取期间 Resync 可能已经同步过，以已同步的状态为准
+		if !m.synced {
+			m.applyOnChain(onChain)
+		}
+	}
+
+	var sequenceNumber uint64
+	if len(m.released) > 0 {
+		sequenceNumber = m.released[0]
+		m.released = m.released[1:]
+	} else {
+		sequenceNumber = m.next
+		m.next++
+	}
+	m.inFlight[sequenceNumber] = 0
+	return sequenceNumber, nil
+}
+
+// Submitted 记录已提交交易的过期时间
+func (m *SequenceManager) Submitted(sequenceNumber uint64, expiration uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.inFlight[sequenceNumber]; ok {
+		m.inFlight[sequenceNumber] = expiration
+	}
+}
+
+// Confirm 交易已上链(无论执行成功与否)，序列号被消耗
+func (m *SequenceManager) Confirm(sequenceNumber uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.inFlight, sequenceNumber)
+	m.cond.Broadcast()
+}
+
+// Release 交易未提交或已过期，序列号回收以便复用
+func (m *SequenceManager) Release(sequenceNumber uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.release(sequenceNumber)
+	m.cond.Broadcast()
+}
+
+func (m *SequenceManager) release(sequenceNumber uint64) {
+	delete(m.inFlight, sequenceNumber)
+	if sequenceNumber >= m.next {
+		return
+	}
+	i := sort.Search(len(m.released), func(i int) bool { return m.released[i] >= sequenceNumber })
+	if i < len(m.released) && m.released[i] == sequenceNumber {
+		return
+	}
+	m.released = append(m.released, 0)
+	copy(m.released[i+1:], m.released[i:])
+	m.released[i] = sequenceNumber
+}
+
+// Resync 与链上序列号重新同步
+func (m *SequenceManager) Resync() error {
+	onChain, err := m.fetchOnChain()
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.applyOnChain(onChain)
+	m.cond.Broadcast()
+	return nil
+}
+
+// applyOnChain 根据链上序列号修正本地状态，调用方需持有锁
+func (m *SequenceManager) applyOnChain(onChain uint64) {
+	m.synced = true
+
+	// 低于链上序列号的都已被消耗
+	for sequenceNumber := range m.inFlight {
+		if sequenceNumber < onChain {
+			delete(m.inFlight, sequenceNumber)
+		}
+	}
+	released := m.released[:0]
+	for _, sequenceNumber := range m.released {
+		if sequenceNumber >= onChain {
+			released = append(released, sequenceNumber)
+		}
+	}
+	m.released = released
+
+	if m.next < onChain {
+		m.next = onChain
+	}
+
+	// 没有在途交易时，本地多分配的序列号不可能再上链，回退到链上值
+	if len(m.inFlight) == 0 {
+		m.next = onChain
+		m.released = m.released[:0]
+	}
+}
+
+// ReclaimExpired 回收已过期且未上链的序列号，返回被回收的序列号
+func (m *SequenceManager) ReclaimExpired() ([]uint64, error) {
+	onChain, err := m.fetchOnChain()
+	if err != nil {
+		return nil, err
+	}
+	now := uint64(m.now().Unix())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var reclaimed []uint64
+	for sequenceNumber, expiration := range m.inFlight {
+		if expiration != 0 && expiration < now && sequenceNumber >= onChain {
+			reclaimed = append(reclaimed, sequenceNumber)
+		}
+	}
+	for _, sequenceNumber := range reclaimed {
+		m.release(sequenceNumber)
+	}
+	m.applyOnChain(onChain)
+	m.cond.Broadcast()
+	sort.Slice(reclaimed, func(i, j int) bool { return reclaimed[i] < reclaimed[j] })
+	return reclaimed, nil
+}
+
+// InFlight 返回当前在途交易数
+func (m *SequenceManager) InFlight() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.inFlight)
+}
+
+// isSequenceTooOld 判断提交错误是否为序列号过旧
+func isSequenceTooOld(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "SEQUENCE_NUMBER_TOO_OLD")
+}
+
+// isSequenceTooNew 判断提交错误是否为序列号过新
+func isSequenceTooNew(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "SEQUENCE_NUMBER_TOO_NEW")
+}
+
+// Submit 分配序列号、调用build构建签名交易并提交
+// 序列号过旧或过新时重新同步后用新的序列号重建，其他错误会回收序列号并返回
+func (m *SequenceManager) Submit(build func(sequenceNumber uint64) (*aptos.SignedTransaction, error)) (*api.SubmitTransactionResponse, uint64, error) {
+	var lastErr error
+	for attempt := 0; attempt <= sequenceSubmitRetries; attempt++ {
+		sequenceNumber, err := m.Acquire()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		signedTxn, err := build(sequenceNumber)
+		if err != nil {
+			m.Release(sequenceNumber)
+			return nil, 0, err
+		}
+
+		resp, err := m.client.SubmitTransaction(signedTxn)
+		if err == nil {
+			m.Submitted(sequenceNumber, signedTxn.Transaction.ExpirationTimestampSeconds)
+			return resp, sequenceNumber, nil
+		}
+		lastErr = err
+
+		switch {
+		case isSequenceTooOld(err):
+			// 序列号已被其他交易消耗，不回收
+			m.Confirm(sequenceNumber)
+		case isSequenceTooNew(err):
+			m.Release(sequenceNumber)
+		default:
+			m.Release(sequenceNumber)
+			return nil, 0, fmt.Errorf("提交交易失败: %v", err)
+		}
+
+		logWarning(fmt.Sprintf("序列号 %d 不可用，重新同步: %v", sequenceNumber, err))
+		if err := m.Resync(); err != nil {
+			return nil, 0, err
+		}
+	}
+	return nil, 0, fmt.Errorf("多次重新同步序列号后仍提交失败: %v", lastErr)
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/api"
+)
+
+// stubSequenceClient 按脚本返回链上序列号的节点
+type stubSequenceClient struct {
+	mu        sync.Mutex
+	onChain   []uint64      // 依次返回，最后一个值重复使用
+	fetches   int           // Account 被调用的次数
+	fetching  chan struct{} // 非空时每次读取开始前写入一次
+	gate      chan struct{} // 非空时读取阻塞到关闭
+	submitted []uint64
+	chainNext uint64 // 低于该值的序列号提交时返回 SEQUENCE_NUMBER_TOO_OLD
+}
+
+func (c *stubSequenceClient) Account(address aptos.AccountAddress, ledgerVersion ...uint64) (aptos.AccountInfo, error) {
+	if c.fetching != nil {
+		c.fetching <- struct{}{}
+	}
+	if c.gate != nil {
+		<-c.gate
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value := c.onChain[min(c.fetches, len(c.onChain)-1)]
+	c.fetches++
+	return aptos.AccountInfo{SequenceNumberStr: strconv.FormatUint(value, 10)}, nil
+}
+
+func (c *stubSequenceClient) SubmitTransaction(signedTransaction *aptos.SignedTransaction) (*api.SubmitTransactionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sequenceNumber := signedTransaction.Transaction.SequenceNumber
+	if sequenceNumber < c.chainNext {
+		return nil, fmt.Errorf("vm_error_code SEQUENCE_NUMBER_TOO_OLD")
+	}
+	c.submitted = append(c.submitted, sequenceNumber)
+	return &api.SubmitTransactionResponse{}, nil
+}
+
+func stubSignedTransaction(sequenceNumber uint64) (*aptos.SignedTransaction, error) {
+	raw := &aptos.RawTransaction{SequenceNumber: sequenceNumber, ExpirationTimestampSeconds: uint64(time.Now().Unix()) + 60}
+	return &aptos.SignedTransaction{Transaction: raw}, nil
+}
+
+func TestSequenceManagerParallelAcquire(t *testing.T) {
+	client := &stubSequenceClient{onChain: []uint64{5}, fetching: make(chan struct{}, 1), gate: make(chan struct{})}
+	m := NewSequenceManager(client, aptos.AccountOne, 20)
+
+	results := make(chan uint64, 20)
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sequenceNumber, err := m.Acquire()
+			if err != nil {
+				t.Errorf("分配序列号失败: %v", err)
+				return
+			}
+			results <- sequenceNumber
+		}()
+	}
+
+	// 读取链上序列号期间不应持有锁
+	<-client.fetching
+	done := make(chan int)
+	go func() { done <- m.InFlight() }()
+	select {
+	case inFlight := <-done:
+		if inFlight != 0 {
+			t.Fatalf("同步完成前不应有在途序列号，实际 %d", inFlight)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("读取链上序列号期间 InFlight 被阻塞")
+	}
+	close(client.gate)
+	wg.Wait()
+	close(results)
+
+	var allocated []uint64
+	for sequenceNumber := range results {
+		allocated = append(allocated, sequenceNumber)
+	}
+	slices.Sort(allocated)
+	for i, sequenceNumber := range allocated {
+		if sequenceNumber != uint64(5+i) {
+			t.Fatalf("应分配 5..24 且不重复，实际 %v", allocated)
+		}
+	}
+	if len(allocated) != 20 || client.fetches != 1 {
+		t.Fatalf("应分配 20 个序列号且只读取一次链上序列号，实际 %d 个、读取 %d 次", len(allocated), client.fetches)
+	}
+}
+
+func TestSequenceManagerAcquireWaitsForCapacity(t *testing.T) {
+	client := &stubSequenceClient{onChain: []uint64{0}}
+	m := NewSequenceManager(client, aptos.AccountOne, 2)
+	for want := uint64(0); want < 2; want++ {
+		sequenceNumber, err := m.Acquire()
+		if err != nil || sequenceNumber != want {
+			t.Fatalf("应分配 %d，实际 %d: %v", want, sequenceNumber, err)
+		}
+	}
+
+	acquired := make(chan uint64)
+	go func() {
+		sequenceNumber, _ := m.Acquire()
+		acquired <- sequenceNumber
+	}()
+	select {
+	case sequenceNumber := <-acquired:
+		t.Fatalf("在途交易达到上限时不应分配，实际分配 %d", sequenceNumber)
+	case <-time.After(50 * time.Millisecond):
+	}
+	m.Confirm(0)
+	select {
+	case sequenceNumber := <-acquired:
+		if sequenceNumber != 2 {
+			t.Fatalf("确认后应分配 2，实际 %d", sequenceNumber)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("确认后等待的分配没有继续")
+	}
+}
+
+func TestSequenceManagerResyncAfterGap(t *testing.T) {
+	client := &stubSequenceClient{onChain: []uint64{0, 1, 10}}
+	m := NewSequenceManager(client, aptos.AccountOne, 10)
+	for want := uint64(0); want < 4; want++ {
+		sequenceNumber, err := m.Acquire()
+		if err != nil || sequenceNumber != want {
+			t.Fatalf("应分配 %d，实际 %d: %v", want, sequenceNumber, err)
+		}
+	}
+	past := uint64(time.Now().Unix()) - 10
+	m.Submitted(0, past)
+	m.Submitted(1, past)
+	m.Submitted(2, past)
+	m.Submitted(3, past+3600)
+
+	// 链上为 1: 0 已上链，1、2 过期未上链，3 未过期
+	reclaimed, err := m.ReclaimExpired()
+	if err != nil || !slices.Equal(reclaimed, []uint64{1, 2}) {
+		t.Fatalf("应回收 1、2，实际 %v: %v", reclaimed, err)
+	}
+	for _, want := range []uint64{1, 2, 4} {
+		sequenceNumber, err := m.Acquire()
+		if err != nil || sequenceNumber != want {
+			t.Fatalf("应先填补空洞再分配新序列号，期望 %d 实际 %d: %v", want, sequenceNumber, err)
+		}
+	}
+
+	// 其他进程把链上序列号推进到 10，本地在途的都已失效
+	err = m.Resync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.InFlight() != 0 {
+		t.Fatalf("重新同步后低于链上值的在途序列号应被清除，实际 %d", m.InFlight())
+	}
+	sequenceNumber, err := m.Acquire()
+	if err != nil || sequenceNumber != 10 {
+		t.Fatalf("重新同步后应从 10 开始分配，实际 %d: %v", sequenceNumber, err)
+	}
+}
+
+func TestSequenceManagerSubmitResyncsTooOld(t *testing.T) {
+	// 首次读取为 0，但 0..2 已被其他进程使用
+	client := &stubSequenceClient{onChain: []uint64{0, 3}, chainNext: 3}
+	m := NewSequenceManager(client, aptos.AccountOne, 4)
+	_, sequenceNumber, err := m.Submit(stubSignedTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sequenceNumber != 3 || !slices.Equal(client.submitted, []uint64{3}) {
+		t.Fatalf("序列号过旧后应重新同步并用 3 提交，实际 %d，已提交 %v", sequenceNumber, client.submitted)
+	}
+	if m.InFlight() != 1 {
+		t.Fatalf("应只有一笔在途交易，实际 %d", m.InFlight())
+	}
+}
+
+// waitFor 轮询直到 cond 成立
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待%s超时", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSequenceManagerOnFakeFullnode(t *testing.T) {
+	node := NewFakeFullnode()
+	t.Cleanup(node.Close)
+	node.SetAutoCommit(false)
+	sender := newTestAccount(t, "sequence-sender")
+	err := node.CreateAccount(sender.Address, fakeNodeFundOctas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := node.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer, err := aptos.CoinTransferPayload(nil, aptos.AccountOne, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 序列号 1 按账本时间 5 秒后过期，其他 60 秒
+	build := func(sequenceNumber uint64) (*aptos.SignedTransaction, error) {
+		expiresIn := 60 * time.Second
+		if sequenceNumber == 1 {
+			expiresIn = 5 * time.Second
+		}
+		raw := &aptos.RawTransaction{
+			Sender:                     sender.Address,
+			SequenceNumber:             sequenceNumber,
+			Payload:                    aptos.TransactionPayload{Payload: transfer},
+			MaxGasAmount:               10000,
+			GasUnitPrice:               100,
+			ExpirationTimestampSeconds: uint64(node.Now().Add(expiresIn).Unix()),
+			ChainId:                    fakeNodeChainId,
+		}
+		return raw.SignedTransaction(sender)
+	}
+
+	m := NewSequenceManager(client, sender.Address, 4)
+	results := make(chan uint64, 6)
+	for i := 0; i < 6; i++ {
+		go func() {
+			_, sequenceNumber, err := m.Submit(build)
+			if err != nil {
+				t.Errorf("提交失败: %v", err)
+			}
+			results <- sequenceNumber
+		}()
+	}
+	var submitted []uint64
+	receive := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			select {
+			case sequenceNumber := <-results:
+				submitted = append(submitted, sequenceNumber)
+			case <-time.After(10 * time.Second):
+				t.Fatalf("等待提交超时，已提交 %v", submitted)
+			}
+		}
+		slices.Sort(submitted)
+	}
+
+	// 在途上限为 4，并发分配的序列号不重复，其余两笔等待名额
+	receive(4)
+	if !slices.Equal(submitted, []uint64{0, 1, 2, 3}) || node.Pending() != 4 {
+		t.Fatalf("应并发提交 0..3，实际 %v，内存池中 %d 笔", submitted, node.Pending())
+	}
+
+	// 序列号 1 在内存池中过期，0 上链后 2、3 卡在空洞之后
+	node.AdvanceTime(10 * time.Second)
+	if node.Commit() != 1 {
+		t.Fatalf("只有序列号 0 应上链")
+	}
+	m.Confirm(0)
+	receive(1)
+	if submitted[len(submitted)-1] != 4 || m.InFlight() != 4 {
+		t.Fatalf("确认 0 后等待的提交应分配 4，实际 %v，在途 %d", submitted, m.InFlight())
+	}
+
+	// 本机时钟还没到序列号 1 的过期时间，按账本时间才能回收
+	reclaimed, err := m.ReclaimExpired()
+	if err != nil || len(reclaimed) != 0 {
+		t.Fatalf("按本机时钟不应回收，实际 %v: %v", reclaimed, err)
+	}
+	m.now = node.Now
+	reclaimed, err = m.ReclaimExpired()
+	if err != nil || !slices.Equal(reclaimed, []uint64{1}) {
+		t.Fatalf("按账本时间应回收过期的 1，实际 %v: %v", reclaimed, err)
+	}
+
+	// 最后一笔复用 1 填补空洞，之后 1..4 依次上链
+	receive(1)
+	if !slices.Equal(submitted, []uint64{0, 1, 1, 2, 3, 4}) {
+		t.Fatalf("回收后应先复用 1，实际 %v", submitted)
+	}
+	waitFor(t, "重发的序列号 1 进入内存池", func() bool { return node.Pending() == 4 })
+	if committed := node.Commit(); committed != 4 {
+		t.Fatalf("填补空洞后 1..4 应上链，实际 %d 笔", committed)
+	}
+	for sequenceNumber := uint64(1); sequenceNumber <= 4; sequenceNumber++ {
+		m.Confirm(sequenceNumber)
+	}
+	info, err := client.Account(sender.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	onChain, err := info.SequenceNumber()
+	if err != nil || onChain != 5 {
+		t.Fatalf("链上序列号应为 5，实际 %d: %v", onChain, err)
+	}
+	sequenceNumber, err := m.Acquire()
+	if err != nil || sequenceNumber != 5 || m.InFlight() != 1 {
+		t.Fatalf("全部确认后应分配 5，实际 %d，在途 %d: %v", sequenceNumber, m.InFlight(), err)
+	}
+}
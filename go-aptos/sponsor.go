@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/crypto"
+)
+
+// SponsorPolicy 代付方的代付策略
+type SponsorPolicy struct {
+	AllowedFunctions  []string `json:"allowed_functions"` // 相对模块地址的 module::function
+	MaxGasAmount      uint64   `json:"max_gas_amount"`
+	MaxGasUnitPrice   uint64   `json:"max_gas_unit_price"`
+	MaxTxnsPerAddress int      `json:"max_txns_per_address"` // 每个地址在窗口内最多代付的次数，0 表示不限
+	QuotaWindowHours  int      `json:"quota_window_hours"`
+	QuotaFile         string   `json:"quota_file"`
+}
+
+// 默认代付策略，只代付赎回请求和注册
+var defaultSponsorPolicy = SponsorPolicy{
+	AllowedFunctions:  []string{BridgeModuleName + "::redeem_request", TokenModuleName + "::register"},
+	MaxGasAmount:      20000,
+	MaxGasUnitPrice:   1000,
+	MaxTxnsPerAddress: 5,
+	QuotaWindowHours:  24,
+	QuotaFile:         "sponsor_quota.json",
+}
+
+// loadSponsorPolicy 读取代付策略，SPONSOR_POLICY_FILE 未设置时使用默认策略
+// 文件中未出现的字段保留默认值
+func loadSponsorPolicy() (SponsorPolicy, error) {
+	policy := defaultSponsorPolicy
+	path := os.Getenv("SPONSOR_POLICY_FILE")
+	if path == "" {
+		return policy, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return policy, fmt.Errorf("读取代付策略文件失败: %v", err)
+	}
+	err = json.Unmarshal(data, &policy)
+	if err != nil {
+		return policy, fmt.Errorf("解析代付策略文件失败: %v", err)
+	}
+	return policy, nil
+}
+
+// sponsorQuota 已代付记录，地址 -> 代付时间(unix秒)
+type sponsorQuota map[string][]int64
+
+func loadSponsorQuota(path string) (sponsorQuota, error) {
+	quota := make(sponsorQuota)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return quota, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取代付额度文件失败: %v", err)
+	}
+	err = json.Unmarshal(data, &quota)
+	if err != nil {
+		return nil, fmt.Errorf("解析代付额度文件失败: %v", err)
+	}
+	return quota, nil
+}
+
+func (q sponsorQuota) save(path string) error {
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化代付额度失败: %v", err)
+	}
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("写入代付额度文件失败: %v", err)
+	}
+	return nil
+}
+
+// used 返回地址在窗口内的代付次数，并清理窗口外的记录
+func (q sponsorQuota) used(address string, window time.Duration, now time.Time) int {
+	var kept []int64
+	for _, ts := range q[address] {
+		if now.Sub(time.Unix(ts, 0)) < window {
+			kept = append(kept, ts)
+		}
+	}
+	q[address] = kept
+	return len(kept)
+}
+
+// CheckSponsorPolicy 检查交易是否符合代付策略
+func CheckSponsorPolicy(policy SponsorPolicy, moduleAddress string, rawTxn *aptos.RawTransaction) error {
+	module := aptos.AccountAddress{}
+	err := module.ParseStringRelaxed(moduleAddress)
+	if err != nil {
+		return fmt.Errorf("解析模块地址失败: %v", err)
+	}
+
+	entryFunction, ok := rawTxn.Payload.Payload.(*aptos.EntryFunction)
+	if !ok {
+		return fmt.Errorf("只代付入口函数调用")
+	}
+	if entryFunction.Module.Address != module {
+		return fmt.Errorf("不代付其他模块的调用: %s", entryFunctionName(rawTxn.Payload))
+	}
+	allowed := false
+	name := entryFunction.Module.Name + "::" + entryFunction.Function
+	for _, function := range policy.AllowedFunctions {
+		if function == name {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("函数 %s 不在代付范围内", name)
+	}
+
+	if policy.MaxGasAmount > 0 && rawTxn.MaxGasAmount > policy.MaxGasAmount {
+		return fmt.Errorf("最大gas %d 超过代付上限 %d", rawTxn.MaxGasAmount, policy.MaxGasAmount)
+	}
+	if policy.MaxGasUnitPrice > 0 && rawTxn.GasUnitPrice > policy.MaxGasUnitPrice {
+		return fmt.Errorf("gas单价 %d 超过代付上限 %d", rawTxn.GasUnitPrice, policy.MaxGasUnitPrice)
+	}
+
+	if policy.MaxTxnsPerAddress > 0 {
+		quota, err := loadSponsorQuota(policy.QuotaFile)
+		if err != nil {
+			return err
+		}
+		window := time.Duration(policy.QuotaWindowHours) * time.Hour
+		used := quota.used(rawTxn.Sender.String(), window, time.Now())
+		if used >= policy.MaxTxnsPerAddress {
+			return fmt.Errorf("地址 %s 在 %d 小时内已代付 %d 次，达到上限", rawTxn.Sender.String(), policy.QuotaWindowHours, used)
+		}
+	}
+	return nil
+}
+
+// recordSponsorUsage 记录一次代付
+func recordSponsorUsage(policy SponsorPolicy, sender aptos.AccountAddress) error {
+	if policy.MaxTxnsPerAddress <= 0 {
+		return nil
+	}
+	quota, err := loadSponsorQuota(policy.QuotaFile)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	quota.used(sender.String(), time.Duration(policy.QuotaWindowHours)*time.Hour, now)
+	quota[sender.String()] = append(quota[sender.String()], now.Unix())
+	return quota.save(policy.QuotaFile)
+}
+
+// BuildSponsoredRequest 用户侧: 构建由代付方支付gas的交易并签名
+// 用户签名时代付方地址为0x0，代付方签名时再填入自己的地址
+func BuildSponsoredRequest(client *aptos.Client, account aptos.TransactionSigner, payload aptos.TransactionPayload) (*PartialTransaction, error) {
+	rawTxn, err := client.BuildTransactionMultiAgent(account.AccountAddress(), payload,
+		aptos.FeePayer(&aptos.AccountZero),
+		aptos.MaxGasAmount(defaultSponsorPolicy.MaxGasAmount),
+		aptos.ExpirationSeconds(partialTxnExpirationSeconds),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("构建代付交易失败: %v", err)
+	}
+	auth, err := rawTxn.Sign(account)
+	if err != nil {
+		return nil, fmt.Errorf("签名交易失败: %v", err)
+	}
+
+	request, err := NewPartialTransaction(PartialTxnFeePayer, rawTxn)
+	if err != nil {
+		return nil, err
+	}
+	err = request.AddSignature(account.AccountAddress(), auth)
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// SponsorRedeemRequest 用户侧: 生成待代付的赎回请求
+func SponsorRedeemRequest(client *aptos.Client, account aptos.TransactionSigner, moduleAddress string, receiverAddress string, amount uint64) (*PartialTransaction, error) {
+	payload, err := buildRedeemRequestPayload(moduleAddress, receiverAddress, amount)
+	if err != nil {
+		return nil, err
+	}
+	err = checkRedeemAmount(client, account.AccountAddress(), moduleAddress, amount)
+	if err != nil {
+		return nil, err
+	}
+	return BuildSponsoredRequest(client, account, payload)
+}
+
+// SponsorRegisterRequest 用户侧: 生成待代付的TWBTC注册请求
+func SponsorRegisterRequest(client *aptos.Client, account aptos.TransactionSigner, moduleAddress string) (*PartialTransaction, error) {
+	registered, err := IsTWBTCRegistered(client, account.AccountAddress(), moduleAddress)
+	if err != nil {
+		return nil, err
+	}
+	if registered {
+		return nil, fmt.Errorf("账户已注册TWBTC，无需代付")
+	}
+	payload, err := TokenRegisterPayload(moduleAddress)
+	if err != nil {
+		return nil, err
+	}
+	return BuildSponsoredRequest(client, account, payload)
+}
+
+// SponsorSignAndSubmit 代付方: 校验用户签名和代付策略后作为fee payer签名并提交
+func SponsorSignAndSubmit(client *aptos.Client, sponsor aptos.TransactionSigner, moduleAddress string, request *PartialTransaction, policy SponsorPolicy) (string, error) {
+	if request.Type != PartialTxnFeePayer {
+		return "", fmt.Errorf("不是代付交易请求: %s", request.Type)
+	}
+	rawTxn, err := request.RawTransactionWithData()
+	if err != nil {
+		return "", err
+	}
+	feePayerTxn, ok := rawTxn.Inner.(*aptos.MultiAgentWithFeePayerRawTransactionWithData)
+	if !ok {
+		return "", fmt.Errorf("交易未设置fee payer")
+	}
+	if *feePayerTxn.FeePayer != aptos.AccountZero {
+		return "", fmt.Errorf("交易已指定其他代付方: %s", feePayerTxn.FeePayer.String())
+	}
+	if len(feePayerTxn.SecondarySigners) > 0 {
+		return "", fmt.Errorf("不代付多签名方交易")
+	}
+	sender := feePayerTxn.RawTxn.Sender
+
+	senderAuth, err := request.Signature(sender)
+	if err != nil {
+		return "", err
+	}
+	err = CheckSponsorPolicy(policy, moduleAddress, feePayerTxn.RawTxn)
+	if err != nil {
+		return "", fmt.Errorf("不符合代付策略: %v", err)
+	}
+	logInfo(fmt.Sprintf("代付 %s 调用 %s，最大gas %d，gas单价 %d",
+		sender.String(), entryFunctionName(feePayerTxn.RawTxn.Payload), feePayerTxn.RawTxn.MaxGasAmount, feePayerTxn.RawTxn.GasUnitPrice))
+
+	rawTxn.SetFeePayer(sponsor.AccountAddress())
+	sponsorAuth, err := rawTxn.Sign(sponsor)
+	if err != nil {
+		return "", fmt.Errorf("代付方签名失败: %v", err)
+	}
+	signedTxn, ok := rawTxn.ToFeePayerSignedTransaction(senderAuth, sponsorAuth, []crypto.AccountAuthenticator{})
+	if !ok {
+		return "", fmt.Errorf("组装代付交易失败")
+	}
+
+	resp, err := client.SubmitTransaction(signedTxn)
+	if err != nil {
+		return "", fmt.Errorf("提交交易失败: %v", err)
+	}
+	// 提交后即计入额度，交易在链上失败同样消耗了代付方的gas
+	err = recordSponsorUsage(policy, sender)
+	if err != nil {
+		logWarning(fmt.Sprintf("记录代付额度失败: %v", err))
+	}
+	return waitForSuccess(client, resp.Hash)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// sponsorTest 桥已初始化的模拟节点、代付方和把额度记在临时文件中的默认策略
+type sponsorTest struct {
+	node    *FakeFullnode
+	client  *aptos.Client
+	module  string
+	sponsor *aptos.Account
+	policy  SponsorPolicy
+}
+
+func newSponsorTest(t *testing.T, users ...*aptos.Account) *sponsorTest {
+	t.Helper()
+	node, client, admin := newTestBridgeNode(t)
+	sponsor := newTestAccount(t, t.Name()+"-sponsor")
+	for _, account := range append([]*aptos.Account{sponsor}, users...) {
+		err := node.CreateAccount(account.Address, fakeNodeFundOctas)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	policy := defaultSponsorPolicy
+	policy.QuotaFile = filepath.Join(t.TempDir(), "sponsor_quota.json")
+	return &sponsorTest{node: node, client: client, module: admin.Address.String(), sponsor: sponsor, policy: policy}
+}
+
+// request 由 user 签名一笔待代付的交易
+func (st *sponsorTest) request(t *testing.T, user *aptos.Account, payload aptos.TransactionPayload) *PartialTransaction {
+	t.Helper()
+	request, err := BuildSponsoredRequest(st.client, user, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return request
+}
+
+// register 待代付的 TWBTC 注册，链上重复注册不会失败
+func (st *sponsorTest) register(t *testing.T, user *aptos.Account) *PartialTransaction {
+	t.Helper()
+	payload, err := TokenRegisterPayload(st.module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st.request(t, user, payload)
+}
+
+// expectRejected 不符合策略的请求不应提交，代付方和用户都不消耗gas
+func (st *sponsorTest) expectRejected(t *testing.T, user *aptos.Account, request *PartialTransaction, policy SponsorPolicy, reason string) {
+	t.Helper()
+	sponsorBefore, _ := st.node.CoinBalance(st.sponsor.Address, aptosCoinType)
+	userBefore, _ := st.node.CoinBalance(user.Address, aptosCoinType)
+	_, err := SponsorSignAndSubmit(st.client, st.sponsor, st.module, request, policy)
+	if err == nil || !strings.Contains(err.Error(), "不符合代付策略") || !strings.Contains(err.Error(), reason) {
+		t.Fatalf("应以 %q 拒绝代付，实际 %v", reason, err)
+	}
+	sponsorAfter, _ := st.node.CoinBalance(st.sponsor.Address, aptosCoinType)
+	userAfter, _ := st.node.CoinBalance(user.Address, aptosCoinType)
+	if sponsorAfter != sponsorBefore || userAfter != userBefore {
+		t.Fatalf("被拒绝的请求不应提交，代付方余额 %d -> %d，用户余额 %d -> %d", sponsorBefore, sponsorAfter, userBefore, userAfter)
+	}
+}
+
+func TestSponsorRejectsDisallowedFunctions(t *testing.T) {
+	user := newTestAccount(t, "sponsor-user")
+	st := newSponsorTest(t, user)
+
+	transfer, err := TokenTransferPayload(st.module, st.sponsor.Address, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.expectRejected(t, user, st.request(t, user, transfer), st.policy, "函数 "+TokenModuleName+"::transfer 不在代付范围内")
+
+	aptTransfer, err := aptos.CoinTransferPayload(nil, st.sponsor.Address, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.expectRejected(t, user, st.request(t, user, aptos.TransactionPayload{Payload: aptTransfer}), st.policy, "不代付其他模块的调用")
+
+	// 同名函数但策略只允许注册
+	policy := st.policy
+	policy.AllowedFunctions = []string{TokenModuleName + "::register"}
+	redeem, err := BridgeRedeemRequestPayload(st.module, 10000, "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.expectRejected(t, user, st.request(t, user, redeem), policy, BridgeModuleName+"::redeem_request 不在代付范围内")
+	if _, err := os.Stat(st.policy.QuotaFile); !os.IsNotExist(err) {
+		t.Fatalf("被拒绝的请求不应计入额度: %v", err)
+	}
+}
+
+func TestSponsorRejectsGasAboveCap(t *testing.T) {
+	user := newTestAccount(t, "sponsor-user")
+	st := newSponsorTest(t, user)
+	request := st.register(t, user)
+
+	policy := st.policy
+	policy.MaxGasAmount = defaultSponsorPolicy.MaxGasAmount - 1
+	st.expectRejected(t, user, request, policy, "超过代付上限")
+
+	policy = st.policy
+	policy.MaxGasUnitPrice = fakeNodeGasEstimate - 1
+	st.expectRejected(t, user, request, policy, "gas单价")
+
+	// 在上限之内的同一请求可以代付，gas由代付方支付
+	sponsorBefore, _ := st.node.CoinBalance(st.sponsor.Address, aptosCoinType)
+	userBefore, _ := st.node.CoinBalance(user.Address, aptosCoinType)
+	_, err := SponsorSignAndSubmit(st.client, st.sponsor, st.module, request, st.policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sponsorAfter, _ := st.node.CoinBalance(st.sponsor.Address, aptosCoinType)
+	userAfter, _ := st.node.CoinBalance(user.Address, aptosCoinType)
+	if sponsorAfter >= sponsorBefore || userAfter != userBefore {
+		t.Fatalf("gas应由代付方支付，代付方余额 %d -> %d，用户余额 %d -> %d", sponsorBefore, sponsorAfter, userBefore, userAfter)
+	}
+	registered, err := IsTWBTCRegistered(st.client, user.Address, st.module)
+	if err != nil || !registered {
+		t.Fatalf("代付的注册应上链: %v", err)
+	}
+}
+
+func TestSponsorQuotaExhaustedAndPersisted(t *testing.T) {
+	alice := newTestAccount(t, "sponsor-alice")
+	bob := newTestAccount(t, "sponsor-bob")
+	st := newSponsorTest(t, alice, bob)
+
+	// 策略从文件读取，每次检查和记录都重新读取额度文件，模拟代付服务多次重启
+	policyPath := filepath.Join(t.TempDir(), "sponsor_policy.json")
+	err := os.WriteFile(policyPath, []byte(`{"max_txns_per_address": 2, "quota_file": "`+st.policy.QuotaFile+`"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SPONSOR_POLICY_FILE", policyPath)
+	loadPolicy := func() SponsorPolicy {
+		policy, err := loadSponsorPolicy()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return policy
+	}
+	if policy := loadPolicy(); policy.MaxTxnsPerAddress != 2 || policy.MaxGasAmount != defaultSponsorPolicy.MaxGasAmount {
+		t.Fatalf("文件中未出现的字段应保留默认值: %+v", policy)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err = SponsorSignAndSubmit(st.client, st.sponsor, st.module, st.register(t, alice), loadPolicy())
+		if err != nil {
+			t.Fatalf("第 %d 次代付应成功: %v", i+1, err)
+		}
+	}
+	st.expectRejected(t, alice, st.register(t, alice), loadPolicy(), "已代付 2 次，达到上限")
+
+	quota, err := loadSponsorQuota(st.policy.QuotaFile)
+	if err != nil || len(quota[alice.Address.String()]) != 2 {
+		t.Fatalf("额度文件中应记录 alice 的 2 次代付，实际 %v: %v", quota, err)
+	}
+	// 额度按地址计算
+	_, err = SponsorSignAndSubmit(st.client, st.sponsor, st.module, st.register(t, bob), loadPolicy())
+	if err != nil {
+		t.Fatalf("bob 的额度不受 alice 影响: %v", err)
+	}
+
+	// 窗口外的记录不再计入，记录新的代付时被清理
+	stale := time.Now().Add(-time.Duration(defaultSponsorPolicy.QuotaWindowHours) * time.Hour).Unix()
+	quota, err = loadSponsorQuota(st.policy.QuotaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quota[alice.Address.String()] = []int64{stale - 60, stale - 1}
+	err = quota.save(st.policy.QuotaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = SponsorSignAndSubmit(st.client, st.sponsor, st.module, st.register(t, alice), loadPolicy())
+	if err != nil {
+		t.Fatalf("窗口外的代付不应计入额度: %v", err)
+	}
+	data, err := os.ReadFile(st.policy.QuotaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	persisted := sponsorQuota{}
+	err = json.Unmarshal(data, &persisted)
+	if err != nil || len(persisted[alice.Address.String()]) != 1 || persisted[alice.Address.String()][0] <= stale || len(persisted[bob.Address.String()]) != 1 {
+		t.Fatalf("额度文件应只保留 alice 窗口内的 1 次和 bob 的 1 次，实际 %s: %v", data, err)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// moduleABI 与节点 /accounts/{addr}/module/{name} 返回的 abi 字段格式相同
+type moduleABI struct {
+	Address          string        `json:"address"`
+	Name             string        `json:"name"`
+	Friends          []string      `json:"friends"`
+	ExposedFunctions []functionABI `json:"exposed_functions"`
+	Structs          []structABI   `json:"structs"`
+}
+
+// functionABI 模块对外可见的函数
+type functionABI struct {
+	Name              string             `json:"name"`
+	Visibility        string             `json:"visibility"`
+	IsEntry           bool               `json:"is_entry"`
+	IsView            bool               `json:"is_view"`
+	GenericTypeParams []genericTypeParam `json:"generic_type_params"`
+	Params            []string           `json:"params"`
+	Return            []string           `json:"return"`
+}
+
+// genericTypeParam 泛型参数的能力约束
+type genericTypeParam struct {
+	Constraints []string `json:"constraints"`
+}
+
+// structABI 模块中定义的结构体
+type structABI struct {
+	Name              string             `json:"name"`
+	IsNative          bool               `json:"is_native"`
+	IsEvent           bool               `json:"is_event"`
+	Abilities         []string           `json:"abilities"`
+	GenericTypeParams []genericTypeParam `json:"generic_type_params"`
+	Fields            []fieldABI         `json:"fields"`
+}
+
+// fieldABI 结构体字段
+type fieldABI struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// hasAbility 判断结构体是否具有指定能力
+func (s *structABI) hasAbility(ability string) bool {
+	for _, a := range s.Abilities {
+		if a == ability {
+			return true
+		}
+	}
+	return false
+}
+
+// parseModuleJSON 解析 ABI JSON，既接受节点返回的 {bytecode, abi} 也接受只有 abi 的快照
+func parseModuleJSON(data []byte) (*moduleABI, error) {
+	var wrapper struct {
+		Abi *moduleABI `json:"abi"`
+	}
+	err := json.Unmarshal(data, &wrapper)
+	if err != nil {
+		return nil, fmt.Errorf("解析ABI失败: %v", err)
+	}
+	if wrapper.Abi != nil {
+		return wrapper.Abi, nil
+	}
+	abi := &moduleABI{}
+	err = json.Unmarshal(data, abi)
+	if err != nil {
+		return nil, fmt.Errorf("解析ABI失败: %v", err)
+	}
+	if abi.Name == "" {
+		return nil, fmt.Errorf("ABI中缺少模块名")
+	}
+	return abi, nil
+}
+
+// loadABIFile 从快照目录读取 {dir}/{module}.json
+func loadABIFile(dir string, module string) (*moduleABI, error) {
+	path := filepath.Join(dir, module+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取ABI文件失败: %v", err)
+	}
+	abi, err := parseModuleJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if abi.Name != module {
+		return nil, fmt.Errorf("%s: 模块名为 %s, 期望 %s", path, abi.Name, module)
+	}
+	return abi, nil
+}
+
+// fetchABI 从节点的 /accounts/{addr}/module/{name} 读取模块 ABI
+func fetchABI(nodeURL string, address string, module string) (*moduleABI, error) {
+	base := strings.TrimRight(nodeURL, "/")
+	if !strings.HasSuffix(base, "/v1") {
+		base += "/v1"
+	}
+	endpoint := fmt.Sprintf("%s/accounts/%s/module/%s", base, url.PathEscape(address), url.PathEscape(module))
+
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	resp, err := httpClient.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("请求模块 %s 失败: %v", module, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取模块 %s 失败: %v", module, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("请求模块 %s 失败: HTTP %d: %s", module, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	abi, err := parseModuleJSON(body)
+	if err != nil {
+		return nil, fmt.Errorf("模块 %s: %v", module, err)
+	}
+	return abi, nil
+}
+
+// loadPackageABI 从编译好的 Move 包中读取模块字节码并还原 ABI
+// dir 可以是包目录(含 build/)、build/<包名> 目录或 bytecode_modules 目录本身
+func loadPackageABI(dir string, module string) (*moduleABI, error) {
+	candidates := []string{
+		filepath.Join(dir, module+".mv"),
+		filepath.Join(dir, "bytecode_modules", module+".mv"),
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "build", "*", "bytecode_modules", module+".mv"))
+	candidates = append(candidates, matches...)
+
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		abi, err := decodeModuleBytecode(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if abi.Name != module {
+			return nil, fmt.Errorf("%s: 模块名为 %s, 期望 %s", path, abi.Name, module)
+		}
+		return abi, nil
+	}
+	return nil, fmt.Errorf("在 %s 中未找到模块 %s 的字节码，请先执行 aptos move compile", dir, module)
+}
+
+// saveABIFile 把 ABI 写入快照目录，供离线重新生成
+func saveABIFile(dir string, abi *moduleABI) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+	// 类型中的 & 和 <> 保持原样，不转义为 \u0026
+	data := bytes.Buffer{}
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(abi)
+	if err != nil {
+		return fmt.Errorf("序列化ABI失败: %v", err)
+	}
+	path := filepath.Join(dir, abi.Name+".json")
+	err = os.WriteFile(path, data.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("写入ABI文件失败: %v", err)
+	}
+	return nil
+}
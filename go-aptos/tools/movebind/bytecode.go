@@ -0,0 +1,980 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Move 字节码中用到的表类型
+const (
+	tableModuleHandles       = 0x1
+	tableStructHandles       = 0x2
+	tableFunctionHandles     = 0x3
+	tableSignatures          = 0x5
+	tableIdentifiers         = 0x7
+	tableAddressIdentifiers  = 0x8
+	tableStructDefs          = 0xA
+	tableFunctionDefs        = 0xC
+	tableFriendDecls         = 0xF
+	tableMetadata            = 0x10
+	bytecodeMagic            = "\xa1\x1c\xeb\x0b"
+	bytecodeMinVersion       = 5
+	bytecodeMaxVersion       = 7
+	bytecodeVersionMask      = 0x00FF_FFFF
+	aptosMetadataKeyV1       = "aptos::metadata_v1"
+	attributeViewFunction    = 1
+	attributeEvent           = 4
+	functionDefFlagNative    = 0x2
+	functionDefFlagEntry     = 0x4
+	structFieldsNative       = 0x1
+	structFieldsDeclared     = 0x2
+	visibilityPrivate        = 0
+	visibilityPublic         = 1
+	visibilityFriend         = 3
+	accountAddressLength     = 32
+	maxSignatureTokenNesting = 256
+)
+
+// bytecodeReader 顺序读取字节码，越界时返回错误而不是 panic
+type bytecodeReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bytecodeReader) done() bool {
+	return r.pos >= len(r.data)
+}
+
+func (r *bytecodeReader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, fmt.Errorf("字节码在偏移 %d 处意外结束", r.pos)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *bytecodeReader) u8() (uint8, error) {
+	b, err := r.bytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *bytecodeReader) uleb() (uint64, error) {
+	var value uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		b, err := r.u8()
+		if err != nil {
+			return 0, err
+		}
+		value |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return value, nil
+		}
+	}
+	return 0, fmt.Errorf("偏移 %d 处的ULEB128编码过长", r.pos)
+}
+
+// index 读取 ULEB128 编码的表索引，并检查是否越界
+func (r *bytecodeReader) index(limit int, what string) (int, error) {
+	value, err := r.uleb()
+	if err != nil {
+		return 0, err
+	}
+	if limit >= 0 && value >= uint64(limit) {
+		return 0, fmt.Errorf("%s索引 %d 越界(共 %d 项)", what, value, limit)
+	}
+	return int(value), nil
+}
+
+// moveSignatureToken Move 类型签名
+type moveSignatureToken struct {
+	kind     uint8
+	index    int // 结构体句柄或泛型参数的索引
+	children []*moveSignatureToken
+}
+
+const (
+	tokenBool          = 0x1
+	tokenU8            = 0x2
+	tokenU64           = 0x3
+	tokenU128          = 0x4
+	tokenAddress       = 0x5
+	tokenReference     = 0x6
+	tokenMutReference  = 0x7
+	tokenStruct        = 0x8
+	tokenTypeParameter = 0x9
+	tokenVector        = 0xA
+	tokenStructInst    = 0xB
+	tokenSigner        = 0xC
+	tokenU16           = 0xD
+	tokenU32           = 0xE
+	tokenU256          = 0xF
+)
+
+func (r *bytecodeReader) signatureToken(depth int) (*moveSignatureToken, error) {
+	if depth > maxSignatureTokenNesting {
+		return nil, fmt.Errorf("类型签名嵌套过深")
+	}
+	kind, err := r.u8()
+	if err != nil {
+		return nil, err
+	}
+	token := &moveSignatureToken{kind: kind}
+	switch kind {
+	case tokenBool, tokenU8, tokenU16, tokenU32, tokenU64, tokenU128, tokenU256, tokenAddress, tokenSigner:
+	case tokenReference, tokenMutReference, tokenVector:
+		child, err := r.signatureToken(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		token.children = []*moveSignatureToken{child}
+	case tokenStruct, tokenTypeParameter:
+		token.index, err = r.index(-1, "类型")
+		if err != nil {
+			return nil, err
+		}
+	case tokenStructInst:
+		token.index, err = r.index(-1, "结构体句柄")
+		if err != nil {
+			return nil, err
+		}
+		count, err := r.uleb()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < count; i++ {
+			child, err := r.signatureToken(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			token.children = append(token.children, child)
+		}
+	default:
+		return nil, fmt.Errorf("不支持的类型签名 0x%02x", kind)
+	}
+	return token, nil
+}
+
+// bytecodeOperand 字节码指令操作数的编码方式
+type bytecodeOperand int
+
+const (
+	operandNone  bytecodeOperand = iota
+	operandIndex                 // ULEB128 索引或跳转偏移
+	operandByte                  // 局部变量编号或 u8 常量
+	operandU16
+	operandU32
+	operandU64
+	operandU128
+	operandU256
+	operandIndexU64 // 向量签名索引加元素个数
+)
+
+// bytecodeOperands 各条指令的操作数，只用于跳过函数体
+var bytecodeOperands = map[uint8]bytecodeOperand{
+	0x01: operandNone,     // Pop
+	0x02: operandNone,     // Ret
+	0x03: operandIndex,    // BrTrue
+	0x04: operandIndex,    // BrFalse
+	0x05: operandIndex,    // Branch
+	0x06: operandU64,      // LdU64
+	0x07: operandIndex,    // LdConst
+	0x08: operandNone,     // LdTrue
+	0x09: operandNone,     // LdFalse
+	0x0A: operandByte,     // CopyLoc
+	0x0B: operandByte,     // MoveLoc
+	0x0C: operandByte,     // StLoc
+	0x0D: operandByte,     // MutBorrowLoc
+	0x0E: operandByte,     // ImmBorrowLoc
+	0x0F: operandIndex,    // MutBorrowField
+	0x10: operandIndex,    // ImmBorrowField
+	0x11: operandIndex,    // Call
+	0x12: operandIndex,    // Pack
+	0x13: operandIndex,    // Unpack
+	0x14: operandNone,     // ReadRef
+	0x15: operandNone,     // WriteRef
+	0x16: operandNone,     // Add
+	0x17: operandNone,     // Sub
+	0x18: operandNone,     // Mul
+	0x19: operandNone,     // Mod
+	0x1A: operandNone,     // Div
+	0x1B: operandNone,     // BitOr
+	0x1C: operandNone,     // BitAnd
+	0x1D: operandNone,     // Xor
+	0x1E: operandNone,     // Or
+	0x1F: operandNone,     // And
+	0x20: operandNone,     // Not
+	0x21: operandNone,     // Eq
+	0x22: operandNone,     // Neq
+	0x23: operandNone,     // Lt
+	0x24: operandNone,     // Gt
+	0x25: operandNone,     // Le
+	0x26: operandNone,     // Ge
+	0x27: operandNone,     // Abort
+	0x28: operandNone,     // Nop
+	0x29: operandIndex,    // Exists
+	0x2A: operandIndex,    // MutBorrowGlobal
+	0x2B: operandIndex,    // ImmBorrowGlobal
+	0x2C: operandIndex,    // MoveFrom
+	0x2D: operandIndex,    // MoveTo
+	0x2E: operandNone,     // FreezeRef
+	0x2F: operandNone,     // Shl
+	0x30: operandNone,     // Shr
+	0x31: operandByte,     // LdU8
+	0x32: operandU128,     // LdU128
+	0x33: operandNone,     // CastU8
+	0x34: operandNone,     // CastU64
+	0x35: operandNone,     // CastU128
+	0x36: operandIndex,    // MutBorrowFieldGeneric
+	0x37: operandIndex,    // ImmBorrowFieldGeneric
+	0x38: operandIndex,    // CallGeneric
+	0x39: operandIndex,    // PackGeneric
+	0x3A: operandIndex,    // UnpackGeneric
+	0x3B: operandIndex,    // ExistsGeneric
+	0x3C: operandIndex,    // MutBorrowGlobalGeneric
+	0x3D: operandIndex,    // ImmBorrowGlobalGeneric
+	0x3E: operandIndex,    // MoveFromGeneric
+	0x3F: operandIndex,    // MoveToGeneric
+	0x40: operandIndexU64, // VecPack
+	0x41: operandIndex,    // VecLen
+	0x42: operandIndex,    // VecImmBorrow
+	0x43: operandIndex,    // VecMutBorrow
+	0x44: operandIndex,    // VecPushBack
+	0x45: operandIndex,    // VecPopBack
+	0x46: operandIndexU64, // VecUnpack
+	0x47: operandIndex,    // VecSwap
+	0x48: operandU16,      // LdU16
+	0x49: operandU32,      // LdU32
+	0x4A: operandU256,     // LdU256
+	0x4B: operandNone,     // CastU16
+	0x4C: operandNone,     // CastU32
+	0x4D: operandNone,     // CastU256
+	0x4E: operandIndex,    // ImmBorrowVariantField
+	0x4F: operandIndex,    // MutBorrowVariantField
+	0x50: operandIndex,    // ImmBorrowVariantFieldGeneric
+	0x51: operandIndex,    // MutBorrowVariantFieldGeneric
+	0x52: operandIndex,    // PackVariant
+	0x53: operandIndex,    // PackVariantGeneric
+	0x54: operandIndex,    // UnpackVariant
+	0x55: operandIndex,    // UnpackVariantGeneric
+	0x56: operandIndex,    // TestVariant
+	0x57: operandIndex,    // TestVariantGeneric
+}
+
+// skipCode 跳过函数体中的指令，生成绑定不需要函数体
+func (r *bytecodeReader) skipCode() error {
+	count, err := r.uleb()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < count; i++ {
+		opcode, err := r.u8()
+		if err != nil {
+			return err
+		}
+		operand, ok := bytecodeOperands[opcode]
+		if !ok {
+			return fmt.Errorf("不支持的字节码指令 0x%02x", opcode)
+		}
+		switch operand {
+		case operandIndex:
+			_, err = r.uleb()
+		case operandByte:
+			_, err = r.bytes(1)
+		case operandU16:
+			_, err = r.bytes(2)
+		case operandU32:
+			_, err = r.bytes(4)
+		case operandU64:
+			_, err = r.bytes(8)
+		case operandU128:
+			_, err = r.bytes(16)
+		case operandU256:
+			_, err = r.bytes(32)
+		case operandIndexU64:
+			_, err = r.uleb()
+			if err == nil {
+				_, err = r.bytes(8)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type moduleHandle struct {
+	address int
+	name    int
+}
+
+type structHandle struct {
+	module     int
+	name       int
+	abilities  uint8
+	typeParams []uint8
+}
+
+type functionHandle struct {
+	module     int
+	name       int
+	params     int
+	returns    int
+	typeParams []uint8
+}
+
+// compiledModule 解析 ABI 需要的字节码表
+type compiledModule struct {
+	version         uint32
+	moduleHandles   []moduleHandle
+	structHandles   []structHandle
+	functionHandles []functionHandle
+	signatures      [][]*moveSignatureToken
+	identifiers     []string
+	addresses       [][]byte
+	friends         []moduleHandle
+	structDefs      []compiledStruct
+	functionDefs    []compiledFunction
+	metadata        map[string][]byte
+	self            int
+}
+
+type compiledStruct struct {
+	handle int
+	native bool
+	fields []compiledField
+}
+
+type compiledField struct {
+	name  int
+	token *moveSignatureToken
+}
+
+type compiledFunction struct {
+	handle     int
+	visibility uint8
+	entry      bool
+}
+
+// decodeModuleBytecode 解析编译后的 Move 模块(.mv)，按节点 REST 接口的格式还原 ABI
+func decodeModuleBytecode(data []byte) (*moduleABI, error) {
+	module, err := parseCompiledModule(data)
+	if err != nil {
+		return nil, err
+	}
+	return module.abi()
+}
+
+func parseCompiledModule(data []byte) (*compiledModule, error) {
+	r := &bytecodeReader{data: data}
+	magic, err := r.bytes(4)
+	if err != nil || string(magic) != bytecodeMagic {
+		return nil, fmt.Errorf("不是Move模块字节码")
+	}
+	versionBytes, err := r.bytes(4)
+	if err != nil {
+		return nil, err
+	}
+	m := &compiledModule{
+		version:  binary.LittleEndian.Uint32(versionBytes) & bytecodeVersionMask,
+		metadata: map[string][]byte{},
+	}
+	if m.version < bytecodeMinVersion || m.version > bytecodeMaxVersion {
+		return nil, fmt.Errorf("不支持的字节码版本 %d", m.version)
+	}
+
+	tableCount, err := r.uleb()
+	if err != nil {
+		return nil, err
+	}
+	type tableHeader struct {
+		kind   uint8
+		offset uint64
+		length uint64
+	}
+	tables := []tableHeader{}
+	for i := uint64(0); i < tableCount; i++ {
+		kind, err := r.u8()
+		if err != nil {
+			return nil, err
+		}
+		offset, err := r.uleb()
+		if err != nil {
+			return nil, err
+		}
+		length, err := r.uleb()
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, tableHeader{kind, offset, length})
+	}
+
+	contentStart := r.pos
+	contentLength := uint64(0)
+	for _, table := range tables {
+		end := table.offset + table.length
+		if uint64(contentStart)+end > uint64(len(data)) {
+			return nil, fmt.Errorf("表 0x%02x 超出字节码范围", table.kind)
+		}
+		if end > contentLength {
+			contentLength = end
+		}
+	}
+	tableReader := func(kind uint8) *bytecodeReader {
+		for _, table := range tables {
+			if table.kind == kind {
+				start := contentStart + int(table.offset)
+				return &bytecodeReader{data: data[start : start+int(table.length)]}
+			}
+		}
+		return &bytecodeReader{}
+	}
+
+	// 索引之间存在依赖，按被引用的顺序解析
+	steps := []struct {
+		kind  uint8
+		parse func(r *bytecodeReader) error
+	}{
+		{tableIdentifiers, m.parseIdentifier},
+		{tableAddressIdentifiers, m.parseAddress},
+		{tableModuleHandles, m.parseModuleHandle},
+		{tableStructHandles, m.parseStructHandle},
+		{tableSignatures, m.parseSignature},
+		{tableFunctionHandles, m.parseFunctionHandle},
+		{tableFriendDecls, m.parseFriend},
+		{tableStructDefs, m.parseStructDef},
+		{tableFunctionDefs, m.parseFunctionDef},
+		{tableMetadata, m.parseMetadata},
+	}
+	for _, step := range steps {
+		tr := tableReader(step.kind)
+		for !tr.done() {
+			err := step.parse(tr)
+			if err != nil {
+				return nil, fmt.Errorf("解析表 0x%02x 失败: %v", step.kind, err)
+			}
+		}
+	}
+
+	r.pos = contentStart + int(contentLength)
+	m.self, err = r.index(len(m.moduleHandles), "模块句柄")
+	if err != nil {
+		return nil, fmt.Errorf("读取模块自身句柄失败: %v", err)
+	}
+	return m, nil
+}
+
+func (m *compiledModule) parseIdentifier(r *bytecodeReader) error {
+	length, err := r.uleb()
+	if err != nil {
+		return err
+	}
+	b, err := r.bytes(int(length))
+	if err != nil {
+		return err
+	}
+	m.identifiers = append(m.identifiers, string(b))
+	return nil
+}
+
+func (m *compiledModule) parseAddress(r *bytecodeReader) error {
+	b, err := r.bytes(accountAddressLength)
+	if err != nil {
+		return err
+	}
+	m.addresses = append(m.addresses, b)
+	return nil
+}
+
+func (m *compiledModule) readModuleHandle(r *bytecodeReader) (moduleHandle, error) {
+	address, err := r.index(len(m.addresses), "地址")
+	if err != nil {
+		return moduleHandle{}, err
+	}
+	name, err := r.index(len(m.identifiers), "标识符")
+	if err != nil {
+		return moduleHandle{}, err
+	}
+	return moduleHandle{address, name}, nil
+}
+
+func (m *compiledModule) parseModuleHandle(r *bytecodeReader) error {
+	handle, err := m.readModuleHandle(r)
+	if err != nil {
+		return err
+	}
+	m.moduleHandles = append(m.moduleHandles, handle)
+	return nil
+}
+
+func (m *compiledModule) parseFriend(r *bytecodeReader) error {
+	handle, err := m.readModuleHandle(r)
+	if err != nil {
+		return err
+	}
+	m.friends = append(m.friends, handle)
+	return nil
+}
+
+func (m *compiledModule) parseStructHandle(r *bytecodeReader) error {
+	module, err := r.index(len(m.moduleHandles), "模块句柄")
+	if err != nil {
+		return err
+	}
+	name, err := r.index(len(m.identifiers), "标识符")
+	if err != nil {
+		return err
+	}
+	abilities, err := r.u8()
+	if err != nil {
+		return err
+	}
+	count, err := r.uleb()
+	if err != nil {
+		return err
+	}
+	handle := structHandle{module: module, name: name, abilities: abilities}
+	for i := uint64(0); i < count; i++ {
+		// 能力约束后面是 phantom 标记
+		b, err := r.bytes(2)
+		if err != nil {
+			return err
+		}
+		handle.typeParams = append(handle.typeParams, b[0])
+	}
+	m.structHandles = append(m.structHandles, handle)
+	return nil
+}
+
+func (m *compiledModule) parseSignature(r *bytecodeReader) error {
+	count, err := r.uleb()
+	if err != nil {
+		return err
+	}
+	signature := []*moveSignatureToken{}
+	for i := uint64(0); i < count; i++ {
+		token, err := r.signatureToken(0)
+		if err != nil {
+			return err
+		}
+		signature = append(signature, token)
+	}
+	m.signatures = append(m.signatures, signature)
+	return nil
+}
+
+func (m *compiledModule) parseFunctionHandle(r *bytecodeReader) error {
+	handle := functionHandle{}
+	var err error
+	handle.module, err = r.index(len(m.moduleHandles), "模块句柄")
+	if err != nil {
+		return err
+	}
+	handle.name, err = r.index(len(m.identifiers), "标识符")
+	if err != nil {
+		return err
+	}
+	handle.params, err = r.index(len(m.signatures), "签名")
+	if err != nil {
+		return err
+	}
+	handle.returns, err = r.index(len(m.signatures), "签名")
+	if err != nil {
+		return err
+	}
+	count, err := r.uleb()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < count; i++ {
+		constraint, err := r.u8()
+		if err != nil {
+			return err
+		}
+		handle.typeParams = append(handle.typeParams, constraint)
+	}
+	if m.version >= 7 {
+		// 访问说明符只在实验特性中出现，生成绑定时不需要
+		present, err := r.u8()
+		if err != nil {
+			return err
+		}
+		if present != 0 {
+			return fmt.Errorf("函数 %s 带有访问说明符，暂不支持", m.identifiers[handle.name])
+		}
+	}
+	m.functionHandles = append(m.functionHandles, handle)
+	return nil
+}
+
+func (m *compiledModule) parseStructDef(r *bytecodeReader) error {
+	def := compiledStruct{}
+	var err error
+	def.handle, err = r.index(len(m.structHandles), "结构体句柄")
+	if err != nil {
+		return err
+	}
+	kind, err := r.u8()
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case structFieldsNative:
+		def.native = true
+	case structFieldsDeclared:
+		count, err := r.uleb()
+		if err != nil {
+			return err
+		}
+		for i := uint64(0); i < count; i++ {
+			name, err := r.index(len(m.identifiers), "标识符")
+			if err != nil {
+				return err
+			}
+			token, err := r.signatureToken(0)
+			if err != nil {
+				return err
+			}
+			def.fields = append(def.fields, compiledField{name, token})
+		}
+	default:
+		return fmt.Errorf("结构体 %s 使用了不支持的定义方式 0x%02x(enum?)", m.identifiers[m.structHandles[def.handle].name], kind)
+	}
+	m.structDefs = append(m.structDefs, def)
+	return nil
+}
+
+func (m *compiledModule) parseFunctionDef(r *bytecodeReader) error {
+	def := compiledFunction{}
+	var err error
+	def.handle, err = r.index(len(m.functionHandles), "函数句柄")
+	if err != nil {
+		return err
+	}
+	def.visibility, err = r.u8()
+	if err != nil {
+		return err
+	}
+	flags, err := r.u8()
+	if err != nil {
+		return err
+	}
+	def.entry = flags&functionDefFlagEntry != 0
+
+	acquires, err := r.uleb()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < acquires; i++ {
+		_, err = r.uleb()
+		if err != nil {
+			return err
+		}
+	}
+	if flags&functionDefFlagNative == 0 {
+		_, err = r.uleb() // 局部变量签名
+		if err != nil {
+			return err
+		}
+		err = r.skipCode()
+		if err != nil {
+			return fmt.Errorf("函数 %s: %v", m.identifiers[m.functionHandles[def.handle].name], err)
+		}
+	}
+	m.functionDefs = append(m.functionDefs, def)
+	return nil
+}
+
+func (m *compiledModule) parseMetadata(r *bytecodeReader) error {
+	keyLength, err := r.uleb()
+	if err != nil {
+		return err
+	}
+	key, err := r.bytes(int(keyLength))
+	if err != nil {
+		return err
+	}
+	valueLength, err := r.uleb()
+	if err != nil {
+		return err
+	}
+	value, err := r.bytes(int(valueLength))
+	if err != nil {
+		return err
+	}
+	m.metadata[string(key)] = value
+	return nil
+}
+
+// attributes 读取 aptos::metadata_v1 中结构体和函数的属性(#[view]、#[event] 等)
+func (m *compiledModule) attributes() (structs map[string][]uint8, functions map[string][]uint8, err error) {
+	structs = map[string][]uint8{}
+	functions = map[string][]uint8{}
+	value, ok := m.metadata[aptosMetadataKeyV1]
+	if !ok {
+		return structs, functions, nil
+	}
+	r := &bytecodeReader{data: value}
+
+	// error_map: BTreeMap<u64, ErrorDescription{code_name, code_description}>
+	count, err := r.uleb()
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := uint64(0); i < count; i++ {
+		_, err = r.bytes(8)
+		if err != nil {
+			return nil, nil, err
+		}
+		for j := 0; j < 2; j++ {
+			err = r.skipBytes()
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	for _, out := range []map[string][]uint8{structs, functions} {
+		count, err := r.uleb()
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := uint64(0); i < count; i++ {
+			nameLength, err := r.uleb()
+			if err != nil {
+				return nil, nil, err
+			}
+			name, err := r.bytes(int(nameLength))
+			if err != nil {
+				return nil, nil, err
+			}
+			attributes, err := r.uleb()
+			if err != nil {
+				return nil, nil, err
+			}
+			for j := uint64(0); j < attributes; j++ {
+				kind, err := r.u8()
+				if err != nil {
+					return nil, nil, err
+				}
+				args, err := r.uleb()
+				if err != nil {
+					return nil, nil, err
+				}
+				for k := uint64(0); k < args; k++ {
+					err = r.skipBytes()
+					if err != nil {
+						return nil, nil, err
+					}
+				}
+				out[string(name)] = append(out[string(name)], kind)
+			}
+		}
+	}
+	return structs, functions, nil
+}
+
+// skipBytes 跳过一个 ULEB128 长度前缀的字节串
+func (r *bytecodeReader) skipBytes() error {
+	length, err := r.uleb()
+	if err != nil {
+		return err
+	}
+	_, err = r.bytes(int(length))
+	return err
+}
+
+// addressString 按节点的格式显示地址：0x0-0xf 的特殊地址用短格式，其余用完整的 64 位十六进制
+func addressString(address []byte) string {
+	special := true
+	for _, b := range address[:len(address)-1] {
+		if b != 0 {
+			special = false
+			break
+		}
+	}
+	if special && address[len(address)-1] < 0x10 {
+		return fmt.Sprintf("0x%x", address[len(address)-1])
+	}
+	return "0x" + hex.EncodeToString(address)
+}
+
+func (m *compiledModule) moduleString(handle moduleHandle) string {
+	return addressString(m.addresses[handle.address]) + "::" + m.identifiers[handle.name]
+}
+
+// typeString 按节点 ABI 的格式显示类型，如 vector<0x1::string::String>、&signer、T0
+func (m *compiledModule) typeString(token *moveSignatureToken) (string, error) {
+	switch token.kind {
+	case tokenBool:
+		return "bool", nil
+	case tokenU8:
+		return "u8", nil
+	case tokenU16:
+		return "u16", nil
+	case tokenU32:
+		return "u32", nil
+	case tokenU64:
+		return "u64", nil
+	case tokenU128:
+		return "u128", nil
+	case tokenU256:
+		return "u256", nil
+	case tokenAddress:
+		return "address", nil
+	case tokenSigner:
+		return "signer", nil
+	case tokenTypeParameter:
+		return fmt.Sprintf("T%d", token.index), nil
+	}
+
+	children := []string{}
+	for _, child := range token.children {
+		s, err := m.typeString(child)
+		if err != nil {
+			return "", err
+		}
+		children = append(children, s)
+	}
+	switch token.kind {
+	case tokenReference:
+		return "&" + children[0], nil
+	case tokenMutReference:
+		return "&mut " + children[0], nil
+	case tokenVector:
+		return "vector<" + children[0] + ">", nil
+	case tokenStruct, tokenStructInst:
+		if token.index >= len(m.structHandles) {
+			return "", fmt.Errorf("结构体句柄索引 %d 越界", token.index)
+		}
+		handle := m.structHandles[token.index]
+		name := m.moduleString(m.moduleHandles[handle.module]) + "::" + m.identifiers[handle.name]
+		if len(children) > 0 {
+			name += "<" + strings.Join(children, ", ") + ">"
+		}
+		return name, nil
+	}
+	return "", fmt.Errorf("不支持的类型签名 0x%02x", token.kind)
+}
+
+func (m *compiledModule) signatureStrings(index int) ([]string, error) {
+	out := []string{}
+	for _, token := range m.signatures[index] {
+		s, err := m.typeString(token)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// abilityStrings 按 copy、drop、store、key 的顺序列出能力
+func abilityStrings(abilities uint8) []string {
+	out := []string{}
+	for i, name := range []string{"copy", "drop", "store", "key"} {
+		if abilities&(1<<i) != 0 {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func hasAttribute(attributes []uint8, kind uint8) bool {
+	for _, a := range attributes {
+		if a == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// abi 生成与节点 REST 接口相同的 ABI：public/friend 函数和入口函数对外可见，结构体全部列出
+func (m *compiledModule) abi() (*moduleABI, error) {
+	structAttributes, functionAttributes, err := m.attributes()
+	if err != nil {
+		return nil, fmt.Errorf("解析模块元数据失败: %v", err)
+	}
+	self := m.moduleHandles[m.self]
+	abi := &moduleABI{
+		Address:          addressString(m.addresses[self.address]),
+		Name:             m.identifiers[self.name],
+		Friends:          []string{},
+		ExposedFunctions: []functionABI{},
+		Structs:          []structABI{},
+	}
+	for _, friend := range m.friends {
+		abi.Friends = append(abi.Friends, m.moduleString(friend))
+	}
+
+	for _, def := range m.functionDefs {
+		visibility := ""
+		switch def.visibility {
+		case visibilityPublic:
+			visibility = "public"
+		case visibilityFriend:
+			visibility = "friend"
+		case visibilityPrivate:
+			visibility = "private"
+			if !def.entry {
+				continue
+			}
+		default:
+			return nil, fmt.Errorf("未知的函数可见性 %d", def.visibility)
+		}
+		handle := m.functionHandles[def.handle]
+		name := m.identifiers[handle.name]
+		params, err := m.signatureStrings(handle.params)
+		if err != nil {
+			return nil, fmt.Errorf("函数 %s: %v", name, err)
+		}
+		returns, err := m.signatureStrings(handle.returns)
+		if err != nil {
+			return nil, fmt.Errorf("函数 %s: %v", name, err)
+		}
+		typeParams := []genericTypeParam{}
+		for _, constraint := range handle.typeParams {
+			typeParams = append(typeParams, genericTypeParam{Constraints: abilityStrings(constraint)})
+		}
+		abi.ExposedFunctions = append(abi.ExposedFunctions, functionABI{
+			Name:              name,
+			Visibility:        visibility,
+			IsEntry:           def.entry,
+			IsView:            hasAttribute(functionAttributes[name], attributeViewFunction),
+			GenericTypeParams: typeParams,
+			Params:            params,
+			Return:            returns,
+		})
+	}
+
+	for _, def := range m.structDefs {
+		handle := m.structHandles[def.handle]
+		name := m.identifiers[handle.name]
+		s := structABI{
+			Name:              name,
+			IsNative:          def.native,
+			IsEvent:           hasAttribute(structAttributes[name], attributeEvent),
+			Abilities:         abilityStrings(handle.abilities),
+			GenericTypeParams: []genericTypeParam{},
+			Fields:            []fieldABI{},
+		}
+		for _, constraint := range handle.typeParams {
+			s.GenericTypeParams = append(s.GenericTypeParams, genericTypeParam{Constraints: abilityStrings(constraint)})
+		}
+		for _, field := range def.fields {
+			t, err := m.typeString(field.token)
+			if err != nil {
+				return nil, fmt.Errorf("结构体 %s: %v", name, err)
+			}
+			s.Fields = append(s.Fields, fieldABI{Name: m.identifiers[field.name], Type: t})
+		}
+		abi.Structs = append(abi.Structs, s)
+	}
+	return abi, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testdata/btc_bridgev3.mv 是按编译器输出布局(字节码版本 6)手工汇编的 btc_bridgev3 模块：
+// 除了 ABI 用到的表，还带有函数实例化、常量池、字段句柄等解析时会跳过的表，
+// 函数体使用真实的指令编码，元数据中带有合约的错误码表。
+const (
+	bridgeFixture   = "testdata/btc_bridgev3.mv"
+	bindingsPackage = "../.."
+)
+
+func TestDecodeModuleBytecodeMatchesABISnapshot(t *testing.T) {
+	data, err := os.ReadFile(bridgeFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeModuleBytecode(data)
+	if err != nil {
+		t.Fatalf("解析字节码失败: %v", err)
+	}
+	snapshot, err := loadABIFile(filepath.Join(bindingsPackage, "abi"), "btc_bridgev3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, snapshot) {
+		t.Fatalf("字节码解析出的ABI与节点快照不一致:\n字节码 %+v\n快照 %+v", decoded, snapshot)
+	}
+}
+
+// TestCompiledBindingsMatchGenerated 用字节码解析出的 ABI 替换快照，按 bindings.go 中
+// go:generate 的参数重新生成绑定，结果应与提交的 bindings_gen.go 相同(来源注释除外)
+func TestCompiledBindingsMatchGenerated(t *testing.T) {
+	source, err := os.ReadFile(filepath.Join(bindingsPackage, "bindings.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args []string
+	for _, line := range strings.Split(string(source), "\n") {
+		if rest, ok := strings.CutPrefix(line, "//go:generate go run ./tools/movebind "); ok {
+			args = strings.Fields(rest)
+		}
+	}
+	if len(args) == 0 {
+		t.Fatalf("bindings.go 中没有 movebind 的 go:generate 指令")
+	}
+	flags := map[string]string{}
+	for len(args) >= 2 && strings.HasPrefix(args[0], "-") {
+		flags[args[0]] = args[1]
+		args = args[2:]
+	}
+	sources := []string{}
+	for _, dir := range strings.Split(flags["-sources"], ",") {
+		sources = append(sources, filepath.Join(bindingsPackage, dir))
+	}
+
+	abiDir := t.TempDir()
+	for _, arg := range args {
+		spec, err := parseModuleSpec(arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var abi *moduleABI
+		if spec.Module == "btc_bridgev3" {
+			abi, err = loadPackageABI("testdata", spec.Module)
+		} else {
+			abi, err = loadABIFile(filepath.Join(bindingsPackage, flags["-abi"]), spec.Module)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = saveABIFile(abiDir, abi)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := filepath.Join(t.TempDir(), "bindings_gen.go")
+	err = run(abiDir, "", "", "", strings.Join(sources, ","), "", out, "main", args)
+	if err != nil {
+		t.Fatalf("生成绑定失败: %v", err)
+	}
+	generated, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := os.ReadFile(filepath.Join(bindingsPackage, flags["-out"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, generated, _ = bytes.Cut(generated, []byte("\n"))
+	_, committed, _ = bytes.Cut(committed, []byte("\n"))
+	if !bytes.Equal(generated, committed) {
+		t.Fatalf("由字节码生成的绑定与 %s 不一致，请检查字节码解析或重新生成", flags["-out"])
+	}
+}
+
+func TestDecodeModuleBytecodeRejectsTruncated(t *testing.T) {
+	data, err := os.ReadFile(bridgeFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := range len(data) {
+		_, err := decodeModuleBytecode(data[:n])
+		if err == nil {
+			t.Fatalf("截断到 %d 字节的字节码应解析失败", n)
+		}
+	}
+}
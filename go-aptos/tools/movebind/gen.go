@@ -0,0 +1,601 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// boundModule 一个要生成绑定的模块，Prefix 是生成的 Go 标识符的前缀
+type boundModule struct {
+	ABI    *moduleABI
+	Prefix string
+}
+
+// externTypes 其他模块中的结构体在 REST JSON 中对应的 Go 类型，这些类型由目标包提供
+var externTypes = map[string]string{
+	"0x1::string::String":           "string",
+	"0x1::event::EventHandle":       "EventHandle",
+	"0x1::coin::MintCapability":     "CoinCapability",
+	"0x1::coin::BurnCapability":     "CoinCapability",
+	"0x1::coin::FreezeCapability":   "CoinCapability",
+	"0x1::object::Object":           "struct {\nInner string `json:\"inner\"`\n}",
+	"0x1::fungible_asset::Metadata": "struct {\nDummyField bool `json:\"dummy_field\"`\n}",
+}
+
+// wordOverrides 按 Go 的习惯改写蛇形命名中的个别单词
+var wordOverrides = map[string]string{
+	"txid": "TxId",
+}
+
+// exportedName 把蛇形命名转为导出的驼峰命名，如 redeem_request -> RedeemRequest
+func exportedName(s string) string {
+	out := strings.Builder{}
+	for _, word := range strings.Split(s, "_") {
+		if word == "" {
+			continue
+		}
+		if override, ok := wordOverrides[strings.ToLower(word)]; ok {
+			out.WriteString(override)
+			continue
+		}
+		out.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return out.String()
+}
+
+// paramName 把 Move 参数名转为不冲突的 Go 局部变量名
+func paramName(s string) string {
+	name := exportedName(s)
+	if name == "" {
+		return "arg"
+	}
+	name = strings.ToLower(name[:1]) + name[1:]
+	if token.IsKeyword(name) {
+		return name + "Arg"
+	}
+	switch name {
+	case "client", "moduleAddress", "typeArgs", "ledgerVersion", "module", "args", "values", "err", "ser", "result":
+		return name + "Arg"
+	}
+	return name
+}
+
+// generator 根据模块 ABI 生成 Go 代码
+type generator struct {
+	pkg        string
+	sources    []string
+	modules    []boundModule
+	paramNames map[string][]string
+
+	buf      bytes.Buffer
+	usesBCS  bool
+	usesBig  bool
+	typeName map[string]string // module::Struct -> Go 类型名
+	events   map[string]bool   // 作为 EventHandle 类型参数出现的结构体
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// structKey 用于在绑定的模块中查找结构体
+func structKey(module string, name string) string {
+	return module + "::" + name
+}
+
+// boundStruct 判断类型是否为本次生成的模块中定义的结构体
+func (g *generator) boundStruct(t *moveType) (string, bool) {
+	for _, m := range g.modules {
+		if t.isStruct(m.ABI.Address, m.ABI.Name, t.Name) {
+			name, ok := g.typeName[structKey(m.ABI.Name, t.Name)]
+			return name, ok
+		}
+	}
+	return "", false
+}
+
+// externKey 返回 0x1::module::Name 形式的键
+func externKey(t *moveType) string {
+	return t.Address.String() + "::" + t.Module + "::" + t.Name
+}
+
+// valueType 返回 REST JSON 中的值对应的 Go 类型，用于结构体字段和视图函数返回值
+func (g *generator) valueType(t *moveType) (string, error) {
+	switch t.Kind {
+	case "bool":
+		return "bool", nil
+	case "u8":
+		return "uint8", nil
+	case "u16":
+		return "uint16", nil
+	case "u32":
+		return "uint32", nil
+	case "u64":
+		return "uint64", nil
+	case "u128", "u256":
+		// REST 以十进制字符串返回，超出 uint64 的范围
+		return "string", nil
+	case "address":
+		return "string", nil
+	case "param":
+		return "any", nil
+	case "vector":
+		if t.Elem.Kind == "u8" {
+			// REST 以 0x 开头的十六进制字符串返回 vector<u8>
+			return "string", nil
+		}
+		elem, err := g.valueType(t.Elem)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	case "struct":
+		if name, ok := g.boundStruct(t); ok {
+			return name, nil
+		}
+		if t.Address.String() == "0x1" && t.Module == "option" && t.Name == "Option" && len(t.TypeArgs) == 1 {
+			elem, err := g.valueType(t.TypeArgs[0])
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("struct {\nVec []%s `json:\"vec\"`\n}", elem), nil
+		}
+		if name, ok := externTypes[externKey(t)]; ok {
+			return name, nil
+		}
+		return "any", nil
+	}
+	return "", fmt.Errorf("类型 %s 不能出现在结构体字段或返回值中", t)
+}
+
+// argType 返回入口函数和视图函数参数在 Go 中的类型
+func (g *generator) argType(t *moveType) (string, error) {
+	switch t.Kind {
+	case "bool":
+		return "bool", nil
+	case "u8":
+		return "uint8", nil
+	case "u16":
+		return "uint16", nil
+	case "u32":
+		return "uint32", nil
+	case "u64":
+		return "uint64", nil
+	case "u128", "u256":
+		g.usesBig = true
+		return "*big.Int", nil
+	case "address":
+		return "aptos.AccountAddress", nil
+	case "vector":
+		if t.Elem.Kind == "u8" {
+			return "[]byte", nil
+		}
+		elem, err := g.argType(t.Elem)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	case "struct":
+		switch externKey(t) {
+		case "0x1::string::String":
+			return "string", nil
+		case "0x1::object::Object":
+			return "aptos.AccountAddress", nil
+		case "0x1::option::Option":
+			elem, err := g.argType(t.TypeArgs[0])
+			if err != nil {
+				return "", err
+			}
+			return "*" + elem, nil
+		}
+	}
+	return "", fmt.Errorf("类型 %s 不能作为交易参数", t)
+}
+
+// encodeArg 生成把 value 写入 ser 的 BCS 编码语句
+func (g *generator) encodeArg(t *moveType, value string, depth int) (string, error) {
+	item := fmt.Sprintf("item%d", depth)
+	switch t.Kind {
+	case "bool":
+		return fmt.Sprintf("ser.Bool(%s)", value), nil
+	case "u8", "u16", "u32", "u64":
+		return fmt.Sprintf("ser.%s(%s)", strings.ToUpper(t.Kind), value), nil
+	case "u128", "u256":
+		return fmt.Sprintf("ser.%s(*%s)", strings.ToUpper(t.Kind), value), nil
+	case "address":
+		return fmt.Sprintf("%s.MarshalBCS(ser)", value), nil
+	case "vector":
+		if t.Elem.Kind == "u8" {
+			return fmt.Sprintf("ser.WriteBytes(%s)", value), nil
+		}
+		elemType, err := g.argType(t.Elem)
+		if err != nil {
+			return "", err
+		}
+		inner, err := g.encodeArg(t.Elem, item, depth+1)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("bcs.SerializeSequenceWithFunction(%s, ser, func(ser *bcs.Serializer, %s %s) {\n%s\n})", value, item, elemType, inner), nil
+	case "struct":
+		switch externKey(t) {
+		case "0x1::string::String":
+			return fmt.Sprintf("ser.WriteString(%s)", value), nil
+		case "0x1::object::Object":
+			return fmt.Sprintf("%s.MarshalBCS(ser)", value), nil
+		case "0x1::option::Option":
+			elemType, err := g.argType(t.TypeArgs[0])
+			if err != nil {
+				return "", err
+			}
+			inner, err := g.encodeArg(t.TypeArgs[0], item, depth+1)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("bcs.SerializeOption(ser, %s, func(ser *bcs.Serializer, %s %s) {\n%s\n})", value, item, elemType, inner), nil
+		}
+	}
+	return "", fmt.Errorf("类型 %s 不能作为交易参数", t)
+}
+
+// functionParam 入口函数或视图函数的一个非 signer 参数
+type functionParam struct {
+	MoveName string
+	GoName   string
+	Type     *moveType
+}
+
+// fallbackParamName 没有源码时的参数名：基本类型在参数中只出现一次时用类型名(如 address)，否则为 argN
+func fallbackParamName(params []string, i int) string {
+	count := 0
+	for _, param := range params {
+		if param == params[i] {
+			count++
+		}
+	}
+	switch params[i] {
+	case "bool", "u8", "u16", "u32", "u64", "u128", "u256", "address":
+		if count == 1 {
+			return params[i]
+		}
+	}
+	return fmt.Sprintf("arg%d", i)
+}
+
+// functionName 生成的函数名，函数名已以前缀开头时不重复前缀，与结构体的规则相同
+func functionName(prefix string, fn string, suffix string) string {
+	name := exportedName(fn)
+	if !strings.HasPrefix(name, prefix) {
+		name = prefix + name
+	}
+	return name + suffix
+}
+
+// functionParams 解析函数参数，去掉开头的 signer，返回签名方和普通参数
+func (g *generator) functionParams(module string, fn *functionABI) (signers []string, params []functionParam, err error) {
+	names := g.paramNames[module+"::"+fn.Name]
+	if names != nil && len(names) != len(fn.Params) {
+		return nil, nil, fmt.Errorf("%s::%s 源码中有 %d 个参数，ABI中有 %d 个", module, fn.Name, len(names), len(fn.Params))
+	}
+	for i, raw := range fn.Params {
+		t, err := parseMoveType(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s::%s: %v", module, fn.Name, err)
+		}
+		name := fallbackParamName(fn.Params, i)
+		if names != nil {
+			name = names[i]
+		}
+		if t.isSigner() {
+			if len(params) > 0 {
+				return nil, nil, fmt.Errorf("%s::%s: signer 参数必须在最前面", module, fn.Name)
+			}
+			signers = append(signers, name)
+			continue
+		}
+		params = append(params, functionParam{MoveName: name, GoName: paramName(name), Type: t})
+	}
+	return signers, params, nil
+}
+
+// generate 生成完整的 Go 源文件
+func (g *generator) generate() ([]byte, error) {
+	g.typeName = map[string]string{}
+	g.events = map[string]bool{}
+	for _, m := range g.modules {
+		for _, s := range m.ABI.Structs {
+			name := s.Name
+			if !strings.HasPrefix(name, m.Prefix) {
+				name = m.Prefix + name
+			}
+			g.typeName[structKey(m.ABI.Name, s.Name)] = name
+		}
+	}
+	for _, m := range g.modules {
+		for _, s := range m.ABI.Structs {
+			for _, field := range s.Fields {
+				t, err := parseMoveType(field.Type)
+				if err != nil {
+					return nil, fmt.Errorf("%s::%s: %v", m.ABI.Name, s.Name, err)
+				}
+				if externKey(t) == "0x1::event::EventHandle" && len(t.TypeArgs) == 1 {
+					for _, other := range g.modules {
+						event := t.TypeArgs[0]
+						if event.isStruct(other.ABI.Address, other.ABI.Name, event.Name) {
+							g.events[structKey(other.ABI.Name, event.Name)] = true
+						}
+					}
+				}
+			}
+		}
+	}
+
+	body := bytes.Buffer{}
+	g.buf = bytes.Buffer{}
+	for _, m := range g.modules {
+		err := g.generateModule(m)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := g.generateParamTable()
+	if err != nil {
+		return nil, err
+	}
+	body, g.buf = g.buf, body
+
+	g.printf("// Code generated by movebind from %s. DO NOT EDIT.\n\n", strings.Join(g.sources, ", "))
+	g.printf("package %s\n\n", g.pkg)
+	g.printf("import (\n\"fmt\"\n")
+	if g.usesBig {
+		g.printf("\"math/big\"\n")
+	}
+	g.printf("\n\"github.com/aptos-labs/aptos-go-sdk\"\n")
+	if g.usesBCS {
+		g.printf("\"github.com/aptos-labs/aptos-go-sdk/bcs\"\n")
+	}
+	g.printf(")\n\n")
+	g.printf("// bindingModuleId 解析模块地址并与模块名组成模块 ID\n")
+	g.printf("func bindingModuleId(moduleAddress string, name string) (aptos.ModuleId, error) {\n")
+	g.printf("address := aptos.AccountAddress{}\n")
+	g.printf("err := address.ParseStringRelaxed(moduleAddress)\n")
+	g.printf("if err != nil {\nreturn aptos.ModuleId{}, fmt.Errorf(\"解析地址失败: %%v\", err)\n}\n")
+	g.printf("return aptos.ModuleId{Address: address, Name: name}, nil\n}\n\n")
+	g.buf.Write(body.Bytes())
+
+	out, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("格式化生成的代码失败: %v\n%s", err, g.buf.String())
+	}
+	return out, nil
+}
+
+// isFrameworkAddress 判断是否为 0x1、0x3、0x4 等框架保留地址
+func isFrameworkAddress(address string) bool {
+	parsed := aptos.AccountAddress{}
+	return parsed.ParseStringRelaxed(address) == nil && parsed.IsSpecial()
+}
+
+func (g *generator) generateModule(m boundModule) error {
+	abi := m.ABI
+	g.printf("// %sModuleName %s 模块的名称\n", m.Prefix, abi.Name)
+	g.printf("const %sModuleName = %q\n\n", m.Prefix, abi.Name)
+	if isFrameworkAddress(abi.Address) {
+		g.printf("// %sModuleAddress %s 模块所在的框架地址\n", m.Prefix, abi.Name)
+	} else {
+		g.printf("// %sModuleAddress 生成绑定时 %s 模块的发布地址，运行时以配置的模块地址为准\n", m.Prefix, abi.Name)
+	}
+	g.printf("const %sModuleAddress = %q\n\n", m.Prefix, abi.Address)
+
+	for i := range abi.Structs {
+		err := g.generateStruct(m, &abi.Structs[i])
+		if err != nil {
+			return err
+		}
+	}
+	for i := range abi.ExposedFunctions {
+		fn := &abi.ExposedFunctions[i]
+		if fn.IsEntry {
+			err := g.generateEntry(m, fn)
+			if err != nil {
+				return err
+			}
+		}
+		if fn.IsView {
+			err := g.generateView(m, fn)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (g *generator) generateStruct(m boundModule, s *structABI) error {
+	if s.IsNative {
+		return nil
+	}
+	name := g.typeName[structKey(m.ABI.Name, s.Name)]
+	kind := ""
+	switch {
+	case s.hasAbility("key"):
+		kind = "资源"
+	case s.IsEvent || g.events[structKey(m.ABI.Name, s.Name)]:
+		kind = "事件"
+	}
+	g.printf("// %s 对应 %s::%s%s\n", name, m.ABI.Name, s.Name, strings.TrimRight(" "+kind, " "))
+	g.printf("type %s struct {\n", name)
+	for _, field := range s.Fields {
+		t, err := parseMoveType(field.Type)
+		if err != nil {
+			return fmt.Errorf("%s::%s: %v", m.ABI.Name, s.Name, err)
+		}
+		goType, err := g.valueType(t)
+		if err != nil {
+			return fmt.Errorf("%s::%s.%s: %v", m.ABI.Name, s.Name, field.Name, err)
+		}
+		g.printf("%s %s `json:%q`\n", exportedName(field.Name), goType, field.Name)
+	}
+	g.printf("}\n\n")
+	if kind != "" {
+		g.printf("// %sStructTag %s::%s 不含地址的结构体标签\n", name, m.ABI.Name, s.Name)
+		g.printf("const %sStructTag = %sModuleName + \"::%s\"\n\n", name, m.Prefix, s.Name)
+	}
+	return nil
+}
+
+// writeArgs 生成类型参数检查和参数的 BCS 编码，fail 返回出错时的 return 语句
+func (g *generator) writeArgs(m boundModule, fn *functionABI, params []functionParam, fail func(err string) string) error {
+	g.printf("module, err := bindingModuleId(moduleAddress, %sModuleName)\n", m.Prefix)
+	g.printf("if err != nil {\n%s\n}\n", fail("err"))
+	if len(fn.GenericTypeParams) > 0 {
+		g.printf("if len(typeArgs) != %d {\n", len(fn.GenericTypeParams))
+		g.printf("%s\n}\n", fail(fmt.Sprintf("fmt.Errorf(\"%%s::%s 需要 %d 个类型参数, 实际为 %%d\", %sModuleName, len(typeArgs))", fn.Name, len(fn.GenericTypeParams), m.Prefix)))
+	}
+	g.printf("args := make([][]byte, %d)\n", len(params))
+	for i, param := range params {
+		encode, err := g.encodeArg(param.Type, param.GoName, 0)
+		if err != nil {
+			return fmt.Errorf("%s::%s 参数 %s: %v", m.ABI.Name, fn.Name, param.MoveName, err)
+		}
+		g.usesBCS = true
+		g.printf("args[%d], err = bcs.SerializeSingle(func(ser *bcs.Serializer) {\n%s\n})\n", i, encode)
+		g.printf("if err != nil {\n%s\n}\n", fail(fmt.Sprintf("fmt.Errorf(\"序列化参数 %s 失败: %%v\", err)", param.MoveName)))
+	}
+	return nil
+}
+
+// signatureParams 生成函数签名中的参数列表
+func (g *generator) signatureParams(fn *functionABI, params []functionParam) (string, error) {
+	list := []string{"moduleAddress string"}
+	if len(fn.GenericTypeParams) > 0 {
+		list = append(list, "typeArgs []aptos.TypeTag")
+	}
+	for _, param := range params {
+		goType, err := g.argType(param.Type)
+		if err != nil {
+			return "", fmt.Errorf("%s 参数 %s: %v", fn.Name, param.MoveName, err)
+		}
+		list = append(list, param.GoName+" "+goType)
+	}
+	return strings.Join(list, ", "), nil
+}
+
+func typeArgsExpr(fn *functionABI) string {
+	if len(fn.GenericTypeParams) > 0 {
+		return "typeArgs"
+	}
+	return "[]aptos.TypeTag{}"
+}
+
+func (g *generator) generateEntry(m boundModule, fn *functionABI) error {
+	signers, params, err := g.functionParams(m.ABI.Name, fn)
+	if err != nil {
+		return err
+	}
+	signature, err := g.signatureParams(fn, params)
+	if err != nil {
+		return fmt.Errorf("%s::%v", m.ABI.Name, err)
+	}
+	name := functionName(m.Prefix, fn.Name, "Payload")
+	g.printf("// %s 构建 %s::%s 的交易负载\n", name, m.ABI.Name, fn.Name)
+	switch {
+	case len(signers) > 1:
+		g.printf("// 签名方依次为 %s，需要以多代理交易提交\n", strings.Join(signers, "、"))
+	case len(signers) == 1:
+		g.printf("// 签名方为 %s\n", signers[0])
+	}
+	g.printf("func %s(%s) (aptos.TransactionPayload, error) {\n", name, signature)
+	err = g.writeArgs(m, fn, params, func(err string) string {
+		return "return aptos.TransactionPayload{}, " + err
+	})
+	if err != nil {
+		return err
+	}
+	g.printf("return aptos.TransactionPayload{\nPayload: &aptos.EntryFunction{\n")
+	g.printf("Module: module,\nFunction: %q,\nArgTypes: %s,\nArgs: args,\n},\n}, nil\n}\n\n", fn.Name, typeArgsExpr(fn))
+	return nil
+}
+
+func (g *generator) generateView(m boundModule, fn *functionABI) error {
+	_, params, err := g.functionParams(m.ABI.Name, fn)
+	if err != nil {
+		return err
+	}
+	signature, err := g.signatureParams(fn, params)
+	if err != nil {
+		return fmt.Errorf("%s::%v", m.ABI.Name, err)
+	}
+	results := []string{}
+	returns := []string{}
+	for i, raw := range fn.Return {
+		t, err := parseMoveType(raw)
+		if err != nil {
+			return fmt.Errorf("%s::%s: %v", m.ABI.Name, fn.Name, err)
+		}
+		goType, err := g.valueType(t)
+		if err != nil {
+			return fmt.Errorf("%s::%s 返回值: %v", m.ABI.Name, fn.Name, err)
+		}
+		result := fmt.Sprintf("result%d", i)
+		if len(fn.Return) == 1 {
+			result = "result"
+		}
+		results = append(results, result)
+		returns = append(returns, result+" "+goType)
+	}
+	returns = append(returns, "err error")
+
+	name := functionName(m.Prefix, fn.Name, "View")
+	g.printf("// %s 调用 %s::%s 视图函数，可以指定账本版本\n", name, m.ABI.Name, fn.Name)
+	g.printf("func %s(client *aptos.Client, %s, ledgerVersion ...uint64) (%s) {\n", name, signature, strings.Join(returns, ", "))
+	err = g.writeArgs(m, fn, params, func(err string) string {
+		if err == "err" {
+			return "return"
+		}
+		return "err = " + err + "\nreturn"
+	})
+	if err != nil {
+		return err
+	}
+	g.printf("values, err := client.View(&aptos.ViewPayload{\n")
+	g.printf("Module: module,\nFunction: %q,\nArgTypes: %s,\nArgs: args,\n}, ledgerVersion...)\n", fn.Name, typeArgsExpr(fn))
+	g.printf("if err != nil {\nerr = fmt.Errorf(\"调用 %%s::%s 失败: %%v\", %sModuleName, err)\nreturn\n}\n", fn.Name, m.Prefix)
+	g.printf("if len(values) != %d {\nerr = fmt.Errorf(\"%%s::%s 返回 %%d 个值, 期望 %d 个\", %sModuleName, len(values))\nreturn\n}\n", len(fn.Return), fn.Name, len(fn.Return), m.Prefix)
+	for i, result := range results {
+		g.printf("err = decodeMoveValue(values[%d], &%s)\n", i, result)
+		g.printf("if err != nil {\nerr = fmt.Errorf(\"解析 %%s::%s 的返回值失败: %%v\", %sModuleName, err)\nreturn\n}\n", fn.Name, m.Prefix)
+	}
+	g.printf("return\n}\n\n")
+	return nil
+}
+
+// generateParamTable 生成入口函数的参数表，用于解码交易负载
+func (g *generator) generateParamTable() error {
+	g.printf("// bindingEntryFunctions 入口函数的非 signer 参数，按 module::function 索引\n")
+	g.printf("var bindingEntryFunctions = map[string][]MoveParam{\n")
+	for _, m := range g.modules {
+		for i := range m.ABI.ExposedFunctions {
+			fn := &m.ABI.ExposedFunctions[i]
+			if !fn.IsEntry {
+				continue
+			}
+			_, params, err := g.functionParams(m.ABI.Name, fn)
+			if err != nil {
+				return err
+			}
+			g.printf("%sModuleName + \"::%s\": {\n", m.Prefix, fn.Name)
+			for _, param := range params {
+				g.printf("{%q, %q},\n", param.MoveName, param.Type.String())
+			}
+			g.printf("},\n")
+		}
+	}
+	g.printf("}\n")
+	return nil
+}
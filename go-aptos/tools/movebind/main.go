@@ -0,0 +1,196 @@
+// movebind 根据 Move 模块的 ABI 生成 Go 绑定：入口函数的交易负载构建函数、视图函数、
+// 资源和事件结构体，以及解码交易负载用的参数表。
+//
+// ABI 的来源三选一：
+//
+//	-abi DIR                 读取 DIR/<模块名>.json 快照(默认，离线可用)
+//	-node URL -address ADDR  从节点的 /accounts/{addr}/module/{name} 读取
+//	-compiled DIR            从 aptos move compile 的输出中读取 .mv 字节码
+//
+// 配合 -save DIR 可以把读到的 ABI 写成快照，例如模块迁移到新地址后：
+//
+//	go run ./tools/movebind -node https://api.devnet.aptoslabs.com -address 0x... -save abi \
+//	    -sources ../my-aptos-dapp/contract/sources -out bindings_gen.go btc_bridgev3=Bridge btc_tokenv3=Token
+//
+// 位置参数为 模块名=Go前缀，前缀决定生成的标识符，模块改名时只需修改模块名并重新生成。
+// 模块名前可以带地址(如 0x1::coin)，使用 -node 时覆盖 -address；前缀后可以跟 :函数名,函数名…，
+// 此时只生成列出的函数，不生成结构体，用于绑定框架模块中的少数视图函数:
+//
+//	0x1::coin=Coin:balance,is_account_registered,supply
+//
+// 生成的代码依赖目标包中的 decodeMoveValue、MoveParam、EventHandle 和 CoinCapability。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+func main() {
+	abiDir := flag.String("abi", "", "ABI 快照目录，读取 <目录>/<模块名>.json")
+	nodeURL := flag.String("node", "", "节点地址，从 /accounts/{addr}/module/{name} 读取 ABI")
+	address := flag.String("address", "", "模块的发布地址，配合 -node 使用")
+	compiled := flag.String("compiled", "", "编译后的 Move 包目录，读取 bytecode_modules/<模块名>.mv")
+	sources := flag.String("sources", "", "Move 源码目录，多个目录用逗号分隔，用于恢复参数名(可选)")
+	save := flag.String("save", "", "把读到的 ABI 写入该目录作为快照(可选)")
+	out := flag.String("out", "", "生成的 Go 文件，为空时输出到标准输出")
+	pkg := flag.String("pkg", "main", "生成代码的 Go 包名")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: movebind [-abi DIR | -node URL -address ADDR | -compiled DIR] [选项] [地址::]模块名=Go前缀[:函数名,...]...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	err := run(*abiDir, *nodeURL, *address, *compiled, *sources, *save, *out, *pkg, flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "movebind: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(abiDir, nodeURL, address, compiled, sources, save, out, pkg string, specs []string) error {
+	selected := 0
+	for _, source := range []string{abiDir, nodeURL, compiled} {
+		if source != "" {
+			selected++
+		}
+	}
+	if selected != 1 {
+		return fmt.Errorf("必须且只能指定 -abi、-node、-compiled 中的一个")
+	}
+	if len(specs) == 0 {
+		return fmt.Errorf("缺少要生成的模块，格式为 模块名=Go前缀")
+	}
+
+	g := &generator{pkg: pkg}
+	for _, spec := range specs {
+		m, err := parseModuleSpec(spec)
+		if err != nil {
+			return err
+		}
+		module := m.Module
+		moduleAddress := address
+		if m.Address != "" {
+			moduleAddress = m.Address
+		}
+		if nodeURL != "" && moduleAddress == "" {
+			return fmt.Errorf("使用 -node 时必须指定 -address 或在模块参数中带上地址")
+		}
+
+		var abi *moduleABI
+		var from string
+		switch {
+		case abiDir != "":
+			abi, err = loadABIFile(abiDir, module)
+			from = filepath.ToSlash(filepath.Join(abiDir, module+".json"))
+		case nodeURL != "":
+			abi, err = fetchABI(nodeURL, moduleAddress, module)
+			from = fmt.Sprintf("%s::%s", moduleAddress, module)
+		default:
+			abi, err = loadPackageABI(compiled, module)
+			from = module + ".mv"
+		}
+		if err != nil {
+			return err
+		}
+		if save != "" {
+			err = saveABIFile(save, abi)
+			if err != nil {
+				return err
+			}
+			from = filepath.ToSlash(filepath.Join(save, module+".json"))
+		}
+		if m.Address != "" && !sameAddress(m.Address, abi.Address) {
+			return fmt.Errorf("%s: 模块地址为 %s, 期望 %s", from, abi.Address, m.Address)
+		}
+		if m.Functions != nil {
+			err = m.filter(abi)
+			if err != nil {
+				return fmt.Errorf("%s: %v", from, err)
+			}
+		}
+		g.sources = append(g.sources, from)
+		g.modules = append(g.modules, boundModule{ABI: abi, Prefix: m.Prefix})
+	}
+
+	if sources != "" {
+		names, err := loadSourceParamNames(sources)
+		if err != nil {
+			return err
+		}
+		g.paramNames = names
+	}
+
+	code, err := g.generate()
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(out, code, 0644)
+}
+
+// moduleSpec 解析后的位置参数 [地址::]模块名=Go前缀[:函数名,...]
+type moduleSpec struct {
+	Address   string
+	Module    string
+	Prefix    string
+	Functions []string // 为空表示生成整个模块
+}
+
+func parseModuleSpec(spec string) (*moduleSpec, error) {
+	invalid := fmt.Errorf("无效的模块参数 %q，格式为 [地址::]模块名=Go前缀[:函数名,...]", spec)
+	module, prefix, ok := strings.Cut(spec, "=")
+	if !ok {
+		return nil, invalid
+	}
+	m := &moduleSpec{Module: module, Prefix: prefix}
+	if address, name, ok := strings.Cut(module, "::"); ok {
+		m.Address, m.Module = address, name
+	}
+	if prefix, functions, ok := strings.Cut(prefix, ":"); ok {
+		m.Prefix = prefix
+		m.Functions = strings.Split(functions, ",")
+	}
+	if m.Module == "" || m.Prefix == "" || m.Address == "" && strings.Contains(module, "::") {
+		return nil, invalid
+	}
+	for _, fn := range m.Functions {
+		if fn == "" {
+			return nil, invalid
+		}
+	}
+	return m, nil
+}
+
+// filter 只保留列出的函数并去掉结构体，列出的函数不存在时报错
+func (m *moduleSpec) filter(abi *moduleABI) error {
+	exposed := map[string]functionABI{}
+	for _, fn := range abi.ExposedFunctions {
+		exposed[fn.Name] = fn
+	}
+	functions := []functionABI{}
+	for _, name := range m.Functions {
+		fn, ok := exposed[name]
+		if !ok {
+			return fmt.Errorf("模块 %s 中没有对外可见的函数 %s", abi.Name, name)
+		}
+		functions = append(functions, fn)
+	}
+	abi.ExposedFunctions = functions
+	abi.Structs = nil
+	return nil
+}
+
+// sameAddress 按宽松格式比较两个地址
+func sameAddress(a string, b string) bool {
+	var x, y aptos.AccountAddress
+	return x.ParseStringRelaxed(a) == nil && y.ParseStringRelaxed(b) == nil && x == y
+}
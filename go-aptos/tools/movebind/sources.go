@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	moveModulePattern   = regexp.MustCompile(`\bmodule\s+([A-Za-z0-9_]+)::([A-Za-z0-9_]+)\s*\{`)
+	moveFunctionPattern = regexp.MustCompile(`\bfun\s+([A-Za-z0-9_]+)\s*(<[^>(]*>)?\s*\(`)
+	moveBlockComment    = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	moveLineComment     = regexp.MustCompile(`//[^\n]*`)
+)
+
+// loadSourceParamNames 从 Move 源码中读取函数的参数名，按 module::function 索引
+// 节点和字节码中的 ABI 只有参数类型，没有参数名；dirs 为逗号分隔的多个源码目录
+func loadSourceParamNames(dirs string) (map[string][]string, error) {
+	files := []string{}
+	for _, dir := range strings.Split(dirs, ",") {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.move"))
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("目录 %s 中没有 .move 文件", dir)
+		}
+		files = append(files, matches...)
+	}
+
+	names := map[string][]string{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("读取源码失败: %v", err)
+		}
+		source := moveBlockComment.ReplaceAllString(string(data), "")
+		source = moveLineComment.ReplaceAllString(source, "")
+
+		modules := moveModulePattern.FindAllStringSubmatchIndex(source, -1)
+		for i, module := range modules {
+			end := len(source)
+			if i+1 < len(modules) {
+				end = modules[i+1][0]
+			}
+			moduleName := source[module[4]:module[5]]
+			body := source[module[1]:end]
+			for _, fn := range moveFunctionPattern.FindAllStringSubmatchIndex(body, -1) {
+				params, ok := splitMoveParams(body[fn[1]:])
+				if !ok {
+					return nil, fmt.Errorf("%s: 无法解析函数 %s 的参数", file, body[fn[2]:fn[3]])
+				}
+				names[moduleName+"::"+body[fn[2]:fn[3]]] = params
+			}
+		}
+	}
+	return names, nil
+}
+
+// splitMoveParams 读取到匹配的右括号为止，返回每个 name: type 中的 name
+func splitMoveParams(s string) ([]string, bool) {
+	depth := 0
+	current := strings.Builder{}
+	params := []string{}
+	flush := func() {
+		param := strings.TrimSpace(current.String())
+		current.Reset()
+		if param == "" {
+			return
+		}
+		name, _, _ := strings.Cut(param, ":")
+		params = append(params, strings.TrimSpace(name))
+	}
+	for _, c := range s {
+		switch c {
+		case '<', '(':
+			depth++
+		case '>':
+			depth--
+		case ')':
+			if depth == 0 {
+				flush()
+				return params, true
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				flush()
+				continue
+			}
+		}
+		current.WriteRune(c)
+	}
+	return nil, false
+}
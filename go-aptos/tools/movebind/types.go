@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// moveType 解析后的 Move 类型
+type moveType struct {
+	Kind      string // bool、u8…u256、address、signer、vector、struct、param、ref
+	Mutable   bool   // ref 是否为 &mut
+	Elem      *moveType
+	Address   aptos.AccountAddress
+	Module    string
+	Name      string
+	TypeArgs  []*moveType
+	ParamIdx  int
+	rawString string
+}
+
+func (t *moveType) String() string {
+	return t.rawString
+}
+
+// isStruct 判断是否为指定的结构体，address 使用宽松格式
+func (t *moveType) isStruct(address string, module string, name string) bool {
+	if t.Kind != "struct" || t.Module != module || t.Name != name {
+		return false
+	}
+	expected := aptos.AccountAddress{}
+	err := expected.ParseStringRelaxed(address)
+	return err == nil && expected == t.Address
+}
+
+// isSigner 判断参数是否为 signer 或 &signer，这类参数由交易签名方提供
+func (t *moveType) isSigner() bool {
+	if t.Kind == "ref" {
+		return t.Elem.Kind == "signer"
+	}
+	return t.Kind == "signer"
+}
+
+// parseMoveType 解析 ABI 中的类型字符串，如 vector<0x1::string::String>、&mut T0
+func parseMoveType(s string) (*moveType, error) {
+	p := &typeParser{input: s}
+	t, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("解析类型 %q 失败: %v", s, err)
+	}
+	p.skipSpaces()
+	if p.pos != len(p.input) {
+		return nil, fmt.Errorf("解析类型 %q 失败: 多余的内容 %q", s, p.input[p.pos:])
+	}
+	return t, nil
+}
+
+type typeParser struct {
+	input string
+	pos   int
+}
+
+func (p *typeParser) skipSpaces() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *typeParser) consume(prefix string) bool {
+	p.skipSpaces()
+	if strings.HasPrefix(p.input[p.pos:], prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+// ident 读取标识符或地址
+func (p *typeParser) ident() string {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		if c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.input[start:p.pos]
+}
+
+func (p *typeParser) parse() (*moveType, error) {
+	start := p.pos
+	t, err := p.parseInner()
+	if err != nil {
+		return nil, err
+	}
+	t.rawString = strings.TrimSpace(p.input[start:p.pos])
+	return t, nil
+}
+
+func (p *typeParser) parseInner() (*moveType, error) {
+	if p.consume("&") {
+		t := &moveType{Kind: "ref"}
+		if p.consume("mut ") {
+			t.Mutable = true
+		}
+		elem, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		t.Elem = elem
+		return t, nil
+	}
+
+	word := p.ident()
+	switch word {
+	case "":
+		return nil, fmt.Errorf("位置 %d 处缺少类型", p.pos)
+	case "bool", "u8", "u16", "u32", "u64", "u128", "u256", "address", "signer":
+		return &moveType{Kind: word}, nil
+	case "vector":
+		if !p.consume("<") {
+			return nil, fmt.Errorf("vector 缺少 <")
+		}
+		elem, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(">") {
+			return nil, fmt.Errorf("vector 缺少 >")
+		}
+		return &moveType{Kind: "vector", Elem: elem}, nil
+	}
+
+	if !p.consume("::") {
+		// 泛型参数 T0、T1…
+		if strings.HasPrefix(word, "T") {
+			idx, err := strconv.Atoi(word[1:])
+			if err == nil {
+				return &moveType{Kind: "param", ParamIdx: idx}, nil
+			}
+		}
+		return nil, fmt.Errorf("未知类型 %s", word)
+	}
+
+	t := &moveType{Kind: "struct"}
+	err := t.Address.ParseStringRelaxed(word)
+	if err != nil {
+		return nil, fmt.Errorf("解析地址 %s 失败: %v", word, err)
+	}
+	t.Module = p.ident()
+	if !p.consume("::") {
+		return nil, fmt.Errorf("结构体类型缺少 ::")
+	}
+	t.Name = p.ident()
+	if t.Module == "" || t.Name == "" {
+		return nil, fmt.Errorf("结构体类型不完整")
+	}
+	if p.consume("<") {
+		for {
+			arg, err := p.parse()
+			if err != nil {
+				return nil, err
+			}
+			t.TypeArgs = append(t.TypeArgs, arg)
+			if p.consume(">") {
+				break
+			}
+			if !p.consume(",") {
+				return nil, fmt.Errorf("类型参数之间缺少 ,")
+			}
+		}
+	}
+	return t, nil
+}
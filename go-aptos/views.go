@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// ErrTWBTCNotRegistered 账户既没有 TWBTC 的 CoinStore，也没有迁移后的 FA 主存储
+// 与余额为 0 区分：未注册的账户不能接收转账和铸币
+var ErrTWBTCNotRegistered = errors.New("账户未注册TWBTC")
+
+// twbtcTypeArgs 返回 0x1::coin 视图函数的类型参数 <模块地址::btc_tokenv3::BTC>
+func twbtcTypeArgs(moduleAddress string) ([]aptos.TypeTag, error) {
+	address := aptos.AccountAddress{}
+	err := address.ParseStringRelaxed(moduleAddress)
+	if err != nil {
+		return nil, fmt.Errorf("解析模块地址失败: %v", err)
+	}
+	coinType, err := aptos.ParseTypeTag(fmt.Sprintf("%s::%s::BTC", address.String(), TokenModuleName))
+	if err != nil {
+		return nil, fmt.Errorf("解析TWBTC类型失败: %v", err)
+	}
+	return []aptos.TypeTag{*coinType}, nil
+}
+
+// IsTWBTCRegistered 通过 0x1::coin::is_account_registered 判断账户能否接收 TWBTC，
+// 迁移到 FA 后只有主存储的账户同样视为已注册，可以指定账本版本
+func IsTWBTCRegistered(client *aptos.Client, address aptos.AccountAddress, moduleAddress string, ledgerVersion ...uint64) (bool, error) {
+	typeArgs, err := twbtcTypeArgs(moduleAddress)
+	if err != nil {
+		return false, err
+	}
+	registered, err := CoinIsAccountRegisteredView(client, CoinModuleAddress, typeArgs, address, ledgerVersion...)
+	if err != nil {
+		return false, fmt.Errorf("查询TWBTC注册状态失败: %v", err)
+	}
+	return registered, nil
+}
+
+// TWBTCBalance 通过 0x1::coin::balance 读取 TWBTC 余额，包含迁移到 FA 后主存储中的部分，可以指定账本版本
+// 账户未注册时返回 ErrTWBTCNotRegistered，已注册但余额为 0 时返回 0
+func TWBTCBalance(client *aptos.Client, address aptos.AccountAddress, moduleAddress string, ledgerVersion ...uint64) (uint64, error) {
+	// 注册状态只会从未注册变为已注册，未固定版本时先查注册再查余额也不会误报
+	registered, err := IsTWBTCRegistered(client, address, moduleAddress, ledgerVersion...)
+	if err != nil {
+		return 0, err
+	}
+	if !registered {
+		return 0, fmt.Errorf("%w: %s", ErrTWBTCNotRegistered, address.String())
+	}
+	typeArgs, err := twbtcTypeArgs(moduleAddress)
+	if err != nil {
+		return 0, err
+	}
+	balance, err := CoinBalanceView(client, CoinModuleAddress, typeArgs, address, ledgerVersion...)
+	if err != nil {
+		return 0, fmt.Errorf("查询TWBTC余额失败: %v", err)
+	}
+	return balance, nil
+}
+
+// TWBTCSupply 通过 0x1::coin::supply 读取 TWBTC 总供应量，可以指定账本版本
+func TWBTCSupply(client *aptos.Client, moduleAddress string, ledgerVersion ...uint64) (*big.Int, error) {
+	typeArgs, err := twbtcTypeArgs(moduleAddress)
+	if err != nil {
+		return nil, err
+	}
+	supply, err := CoinSupplyView(client, CoinModuleAddress, typeArgs, ledgerVersion...)
+	if err != nil {
+		return nil, fmt.Errorf("查询TWBTC供应量失败: %v", err)
+	}
+	if len(supply.Vec) != 1 {
+		return nil, fmt.Errorf("TWBTC未追踪供应量")
+	}
+	value, ok := new(big.Int).SetString(supply.Vec[0], 10)
+	if !ok {
+		return nil, fmt.Errorf("解析TWBTC供应量失败: %s", supply.Vec[0])
+	}
+	return value, nil
+}
+
+// pinLedgerVersion 返回指定的账本版本，未指定时取节点当前的最新版本
+func pinLedgerVersion(client *aptos.Client, ledgerVersion ...uint64) (uint64, error) {
+	if len(ledgerVersion) > 0 {
+		return ledgerVersion[0], nil
+	}
+	info, err := client.Info()
+	if err != nil {
+		return 0, fmt.Errorf("获取账本版本失败: %v", err)
+	}
+	return info.LedgerVersion(), nil
+}
+
+// BridgeState 在同一账本版本上读取的桥状态
+type BridgeState struct {
+	LedgerVersion uint64
+	Config        *BridgeConfig
+	Minted        []string
+	Prepared      []string
+	Used          []string
+	Supply        *big.Int
+	Metadata      *aptos.AccountAddress // TWBTC 配对的 FA 元数据，为 nil 表示尚未配对
+}
+
+// ReadBridgeState 在同一账本版本上读取桥配置、各交易列表和 TWBTC 供应量，
+// 未指定版本时固定为当前最新版本，避免读取过程中有新交易导致各项不一致
+func ReadBridgeState(client *aptos.Client, moduleAddress string, ledgerVersion ...uint64) (*BridgeState, error) {
+	version, err := pinLedgerVersion(client, ledgerVersion...)
+	if err != nil {
+		return nil, err
+	}
+	state := &BridgeState{LedgerVersion: version}
+	state.Config, err = GetBridgeConfig(client, moduleAddress, version)
+	if err != nil {
+		return nil, err
+	}
+	minted, err := GetMintedTransactions(client, moduleAddress, version)
+	if err != nil {
+		return nil, err
+	}
+	state.Minted = minted.Minted
+	state.Prepared, err = GetPreparedRedeems(client, moduleAddress, version)
+	if err != nil {
+		return nil, err
+	}
+	state.Used, err = GetUsedBtcTxIds(client, moduleAddress, version)
+	if err != nil {
+		return nil, err
+	}
+	state.Supply, err = TWBTCSupply(client, moduleAddress, version)
+	if err != nil {
+		return nil, err
+	}
+	metadata, paired, err := TWBTCPairedMetadata(client, moduleAddress, version)
+	if err != nil {
+		return nil, err
+	}
+	if paired {
+		state.Metadata = &metadata
+	}
+	return state, nil
+}
+
+// printBridgeState 打印桥状态
+func printBridgeState(state *BridgeState) {
+	fmt.Printf("===== 桥状态 (账本版本 %d) =====\n", state.LedgerVersion)
+	fmt.Printf("管理员地址: %s\n", state.Config.Admin)
+	fmt.Printf("交易费用: %d (satoshi)\n", state.Config.Fee)
+	fmt.Printf("费用接收地址: %s\n", state.Config.FeeAccount)
+	fmt.Printf("TWBTC总供应量: %s Satoshis\n", state.Supply.String())
+	if state.Metadata != nil {
+		fmt.Printf("配对的FA元数据: %s\n", state.Metadata.String())
+	} else {
+		fmt.Println("配对的FA元数据: 尚未配对")
+	}
+	fmt.Printf("已铸币BTC交易: %d 笔\n", len(state.Minted))
+	fmt.Printf("已准备赎回: %d 笔\n", len(state.Prepared))
+	fmt.Printf("已使用BTC交易ID: %d 个\n", len(state.Used))
+}
@@ -0,0 +1,857 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+)
+
+// webhook 请求头
+const (
+	webhookSignatureHeader = "X-Bridge-Signature" // sha256=<hex(HMAC-SHA256(secret, timestamp + "." + body))>
+	webhookTimestampHeader = "X-Bridge-Timestamp"
+	webhookEventIdHeader   = "X-Bridge-Event-Id"
+)
+
+// WebhookPolicy webhook 投递的重试策略和文件位置
+type WebhookPolicy struct {
+	ConfigFile     string        // 订阅者配置
+	StateFile      string        // 事件跟随游标
+	DeadLetterFile string        // 重试耗尽的投递
+	MaxAttempts    int           // 每次投递最多尝试次数(含首次)
+	InitialBackoff time.Duration // 第一次重试前的等待，之后每次翻倍
+	MaxBackoff     time.Duration
+	Timeout        time.Duration // 单次请求超时
+	PollInterval   time.Duration // 跟随事件的轮询间隔
+}
+
+// 默认 webhook 策略
+var defaultWebhookPolicy = WebhookPolicy{
+	ConfigFile:     "webhooks.json",
+	StateFile:      "webhook_state.json",
+	DeadLetterFile: "webhook_dead_letters.json",
+	MaxAttempts:    5,
+	InitialBackoff: time.Second,
+	MaxBackoff:     time.Minute,
+	Timeout:        10 * time.Second,
+	PollInterval:   5 * time.Second,
+}
+
+// loadWebhookPolicy 读取 webhook 策略，可通过环境变量覆盖默认值
+func loadWebhookPolicy() (WebhookPolicy, error) {
+	policy := defaultWebhookPolicy
+	files := []struct {
+		name  string
+		value *string
+	}{
+		{"WEBHOOK_CONFIG", &policy.ConfigFile},
+		{"WEBHOOK_STATE_FILE", &policy.StateFile},
+		{"WEBHOOK_DEAD_LETTER_FILE", &policy.DeadLetterFile},
+	}
+	for _, file := range files {
+		if path := os.Getenv(file.name); path != "" {
+			*file.value = path
+		}
+	}
+	durations := []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"WEBHOOK_INITIAL_BACKOFF_MS", &policy.InitialBackoff},
+		{"WEBHOOK_MAX_BACKOFF_MS", &policy.MaxBackoff},
+		{"WEBHOOK_TIMEOUT_MS", &policy.Timeout},
+		{"WEBHOOK_POLL_INTERVAL_MS", &policy.PollInterval},
+	}
+	for _, env := range durations {
+		valueStr := os.Getenv(env.name)
+		if valueStr == "" {
+			continue
+		}
+		value, err := strconv.ParseUint(valueStr, 10, 64)
+		if err != nil || value == 0 {
+			return policy, fmt.Errorf("无效的%s: %s", env.name, valueStr)
+		}
+		*env.value = time.Duration(value) * time.Millisecond
+	}
+	if valueStr := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); valueStr != "" {
+		value, err := strconv.Atoi(valueStr)
+		if err != nil || value <= 0 {
+			return policy, fmt.Errorf("无效的WEBHOOK_MAX_ATTEMPTS: %s", valueStr)
+		}
+		policy.MaxAttempts = value
+	}
+	return policy, nil
+}
+
+// WebhookSubscriber 一个 webhook 订阅者，各过滤条件为空表示不限制，非空时事件需要命中其中之一
+type WebhookSubscriber struct {
+	Name           string   `json:"name"`
+	URL            string   `json:"url"`
+	Secret         string   `json:"secret"`
+	EventTypes     []string `json:"event_types,omitempty"`     // 事件句柄名称，如 mint、redeem_request
+	AptosAddresses []string `json:"aptos_addresses,omitempty"` // 与事件中的接收者、发送者等 Aptos 地址比较
+	BtcAddresses   []string `json:"btc_addresses,omitempty"`   // 与赎回接收地址、销毁时的 BTC 地址比较
+
+	aptosAddresses []aptos.AccountAddress
+}
+
+// webhookConfig 订阅者配置文件的内容
+type webhookConfig struct {
+	Subscribers []*WebhookSubscriber `json:"subscribers"`
+}
+
+// loadWebhookSubscribers 读取并校验订阅者配置
+func loadWebhookSubscribers(path string) ([]*WebhookSubscriber, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取webhook配置失败: %v", err)
+	}
+	config := &webhookConfig{}
+	err = json.Unmarshal(data, config)
+	if err != nil {
+		return nil, fmt.Errorf("解析webhook配置失败: %v", err)
+	}
+	names := map[string]bool{}
+	for _, subscriber := range config.Subscribers {
+		err = subscriber.validate()
+		if err != nil {
+			return nil, err
+		}
+		if names[subscriber.Name] {
+			return nil, fmt.Errorf("订阅者名称 %s 重复", subscriber.Name)
+		}
+		names[subscriber.Name] = true
+	}
+	return config.Subscribers, nil
+}
+
+// validate 检查订阅者配置并解析地址过滤条件
+func (s *WebhookSubscriber) validate() error {
+	if s.Name == "" || s.URL == "" || s.Secret == "" {
+		return fmt.Errorf("订阅者需要 name、url 和 secret")
+	}
+	for _, eventType := range s.EventTypes {
+		_, err := findBridgeEventStream(eventType)
+		if err != nil {
+			return fmt.Errorf("订阅者 %s: %v", s.Name, err)
+		}
+	}
+	s.aptosAddresses = nil
+	for _, addressStr := range s.AptosAddresses {
+		address := aptos.AccountAddress{}
+		err := address.ParseStringRelaxed(addressStr)
+		if err != nil {
+			return fmt.Errorf("订阅者 %s 的Aptos地址 %s 无效: %v", s.Name, addressStr, err)
+		}
+		s.aptosAddresses = append(s.aptosAddresses, address)
+	}
+	return nil
+}
+
+// eventAddresses 返回事件中涉及的 Aptos 地址和 BTC 地址
+func eventAddresses(event SequencedBridgeEvent) (aptosAddresses []string, btcAddresses []string) {
+	switch data := event.Data.(type) {
+	case *BridgeMintEvent:
+		return []string{data.Receiver}, nil
+	case *BridgeRedeemRequestEvent:
+		return []string{data.Sender}, []string{data.Receiver}
+	case *BridgeRedeemPrepareEvent:
+		return []string{data.Requester}, []string{data.Receiver}
+	case *TokenMintEvent:
+		return []string{data.Recipient}, nil
+	case *TokenBurnEvent:
+		return []string{data.Burner}, []string{data.BtcAddress}
+	}
+	return nil, nil
+}
+
+// Matches 事件是否满足订阅者的所有过滤条件
+func (s *WebhookSubscriber) Matches(event SequencedBridgeEvent) bool {
+	if len(s.EventTypes) > 0 && !slices.Contains(s.EventTypes, event.Stream) {
+		return false
+	}
+	aptosAddresses, btcAddresses := eventAddresses(event)
+	if len(s.aptosAddresses) > 0 && !slices.ContainsFunc(aptosAddresses, func(addressStr string) bool {
+		address := aptos.AccountAddress{}
+		return address.ParseStringRelaxed(addressStr) == nil && slices.Contains(s.aptosAddresses, address)
+	}) {
+		return false
+	}
+	// bech32 地址不区分大小写
+	if len(s.BtcAddresses) > 0 && !slices.ContainsFunc(btcAddresses, func(address string) bool {
+		return slices.ContainsFunc(s.BtcAddresses, func(filter string) bool {
+			return strings.EqualFold(filter, address)
+		})
+	}) {
+		return false
+	}
+	return true
+}
+
+// WebhookPayload webhook 请求体
+type WebhookPayload struct {
+	Id             string `json:"id"` // stream:sequence_number，同一事件重试和重放时不变，接收方可以据此去重
+	Stream         string `json:"stream"`
+	SequenceNumber uint64 `json:"sequence_number"`
+	Version        uint64 `json:"version"`
+	Type           string `json:"type"`
+	Data           any    `json:"data"`
+	Replay         bool   `json:"replay"` // 由 webhook replay 重新发送
+}
+
+// newWebhookPayload 由事件生成请求体
+func newWebhookPayload(event SequencedBridgeEvent, replay bool) *WebhookPayload {
+	return &WebhookPayload{
+		Id:             fmt.Sprintf("%s:%d", event.Stream, event.SequenceNumber),
+		Stream:         event.Stream,
+		SequenceNumber: event.SequenceNumber,
+		Version:        event.Version,
+		Type:           event.Type,
+		Data:           event.Data,
+		Replay:         replay,
+	}
+}
+
+// signWebhookPayload 计算签名头的值，接收方用相同的 secret 对时间戳和原始请求体重新计算后比较
+func signWebhookPayload(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature 校验签名头，供接收方参考
+func VerifyWebhookSignature(secret string, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(signWebhookPayload(secret, timestamp, body)), []byte(signature))
+}
+
+// WebhookDeadLetter 重试耗尽的一次投递，Body 为原始请求体，重新投递时重新签名
+type WebhookDeadLetter struct {
+	Subscriber string          `json:"subscriber"`
+	EventId    string          `json:"event_id"`
+	Body       json.RawMessage `json:"body"`
+	Attempts   int             `json:"attempts"`
+	LastError  string          `json:"last_error"`
+	FailedAt   int64           `json:"failed_at"`
+}
+
+// loadWebhookDeadLetters 读取死信文件，文件不存在时返回空列表
+func loadWebhookDeadLetters(path string) ([]*WebhookDeadLetter, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取死信文件失败: %v", err)
+	}
+	var letters []*WebhookDeadLetter
+	err = json.Unmarshal(data, &letters)
+	if err != nil {
+		return nil, fmt.Errorf("解析死信文件失败: %v", err)
+	}
+	return letters, nil
+}
+
+// saveWebhookDeadLetters 写入死信文件
+func saveWebhookDeadLetters(path string, letters []*WebhookDeadLetter) error {
+	data, err := json.MarshalIndent(letters, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化死信失败: %v", err)
+	}
+	err = writeFileAtomic(path, data)
+	if err != nil {
+		return fmt.Errorf("写入死信文件失败: %v", err)
+	}
+	return nil
+}
+
+// writeFileAtomic 先写入同目录的临时文件再重命名，进程中途退出时不会留下写了一半的文件
+func writeFileAtomic(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// webhookDelivery 一次待投递的请求
+type webhookDelivery struct {
+	eventId  string
+	body     []byte
+	stream   string // 计入跟随游标的事件所在句柄，重放和死信重投时为空
+	sequence uint64
+	attempts int
+	due      time.Time                     // 下一次尝试的时间
+	done     func(attempts int, err error) // 投递成功或重试耗尽后调用
+}
+
+// webhookQueue 一个订阅者的投递队列，由单独的 goroutine 处理，
+// 失败的请求按退避时间重新排期，不阻塞事件跟随、其他订阅者和队列中已到期的请求
+type webhookQueue struct {
+	subscriber *WebhookSubscriber
+	mu         sync.Mutex
+	items      []*webhookDelivery
+	wake       chan struct{}
+}
+
+// push 加入队列并唤醒处理 goroutine
+func (q *webhookQueue) push(delivery *webhookDelivery) {
+	q.mu.Lock()
+	q.items = append(q.items, delivery)
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next 返回最早到期的请求，同时到期的按入队顺序，队列为空时返回 nil
+func (q *webhookQueue) next() *webhookDelivery {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var next *webhookDelivery
+	for _, delivery := range q.items {
+		if next == nil || delivery.due.Before(next.due) {
+			next = delivery
+		}
+	}
+	return next
+}
+
+func (q *webhookQueue) remove(delivery *webhookDelivery) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.items = slices.DeleteFunc(q.items, func(item *webhookDelivery) bool {
+		return item == delivery
+	})
+}
+
+// WebhookDispatcher 把桥事件放入匹配订阅者的投递队列，失败时按指数退避排期重试，重试耗尽后写入死信文件；
+// 使用完毕后需要调用 Close
+type WebhookDispatcher struct {
+	subscribers []*WebhookSubscriber
+	policy      WebhookPolicy
+	httpClient  *http.Client
+	now         func() time.Time
+
+	queues      map[string]*webhookQueue
+	stop        chan struct{}
+	workers     sync.WaitGroup
+	outstanding sync.WaitGroup // 尚未投递成功或写入死信的请求
+
+	mu           sync.Mutex
+	pending      map[string]map[uint64]int // 句柄名 -> 序列号 -> 尚未完成的投递数
+	deadLetterMu sync.Mutex
+}
+
+// NewWebhookDispatcher 创建投递器，为每个订阅者启动投递队列
+func NewWebhookDispatcher(subscribers []*WebhookSubscriber, policy WebhookPolicy) *WebhookDispatcher {
+	d := &WebhookDispatcher{
+		subscribers: subscribers,
+		policy:      policy,
+		httpClient:  &http.Client{Timeout: policy.Timeout},
+		now:         time.Now,
+		queues:      map[string]*webhookQueue{},
+		stop:        make(chan struct{}),
+		pending:     map[string]map[uint64]int{},
+	}
+	for _, subscriber := range subscribers {
+		queue := &webhookQueue{subscriber: subscriber, wake: make(chan struct{}, 1)}
+		d.queues[subscriber.Name] = queue
+		d.workers.Add(1)
+		go d.runQueue(queue)
+	}
+	return d
+}
+
+// Close 停止投递队列，队列中尚未完成的事件不计入 Cursors，下次运行时重新投递
+func (d *WebhookDispatcher) Close() {
+	close(d.stop)
+	d.workers.Wait()
+}
+
+// Wait 等待所有已入队的请求投递成功或写入死信
+func (d *WebhookDispatcher) Wait() {
+	d.outstanding.Wait()
+}
+
+// subscriber 按名称查找订阅者
+func (d *WebhookDispatcher) subscriber(name string) *WebhookSubscriber {
+	for _, subscriber := range d.subscribers {
+		if subscriber.Name == name {
+			return subscriber
+		}
+	}
+	return nil
+}
+
+// backoff 第 attempt 次失败后重试前的等待时间
+func (d *WebhookDispatcher) backoff(attempt int) time.Duration {
+	delay := d.policy.InitialBackoff
+	for i := 1; i < attempt && delay < d.policy.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.policy.MaxBackoff)
+}
+
+// post 发送一次签名请求，2xx 视为成功
+func (d *WebhookDispatcher) post(subscriber *WebhookSubscriber, eventId string, body []byte) error {
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+	request, err := http.NewRequest(http.MethodPost, subscriber.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建HTTP请求失败: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(webhookEventIdHeader, eventId)
+	request.Header.Set(webhookTimestampHeader, timestamp)
+	request.Header.Set(webhookSignatureHeader, signWebhookPayload(subscriber.Secret, timestamp, body))
+	resp, err := d.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("发送HTTP请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("状态码 %d, 响应体: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// enqueue 把请求放入订阅者的队列，立即到期
+func (d *WebhookDispatcher) enqueue(subscriber *WebhookSubscriber, delivery *webhookDelivery) {
+	if delivery.stream != "" {
+		d.mu.Lock()
+		if d.pending[delivery.stream] == nil {
+			d.pending[delivery.stream] = map[uint64]int{}
+		}
+		d.pending[delivery.stream][delivery.sequence]++
+		d.mu.Unlock()
+	}
+	delivery.due = d.now()
+	d.outstanding.Add(1)
+	d.queues[subscriber.Name].push(delivery)
+}
+
+// runQueue 依次处理到期的请求，直到 Close
+func (d *WebhookDispatcher) runQueue(queue *webhookQueue) {
+	defer d.workers.Done()
+	for {
+		var timer *time.Timer
+		var wait <-chan time.Time
+		if delivery := queue.next(); delivery != nil {
+			delay := delivery.due.Sub(d.now())
+			if delay <= 0 {
+				d.attempt(queue, delivery)
+				continue
+			}
+			timer = time.NewTimer(delay)
+			wait = timer.C
+		}
+		select {
+		case <-d.stop:
+		case <-queue.wake:
+		case <-wait:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+	}
+}
+
+// attempt 尝试投递一次，失败且未用完次数时按退避时间重新排期
+func (d *WebhookDispatcher) attempt(queue *webhookQueue, delivery *webhookDelivery) {
+	err := d.post(queue.subscriber, delivery.eventId, delivery.body)
+	delivery.attempts++
+	if err != nil && delivery.attempts < d.policy.MaxAttempts {
+		logWarning(fmt.Sprintf("投递 %s 给 %s 第 %d 次失败: %v", delivery.eventId, queue.subscriber.Name, delivery.attempts, err))
+		delivery.due = d.now().Add(d.backoff(delivery.attempts))
+		return
+	}
+	queue.remove(delivery)
+	delivery.done(delivery.attempts, err)
+	d.outstanding.Done()
+}
+
+// complete 事件的一次投递已完成，不再阻止游标前移
+func (d *WebhookDispatcher) complete(delivery *webhookDelivery) {
+	if delivery.stream == "" {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending[delivery.stream][delivery.sequence]--
+	if d.pending[delivery.stream][delivery.sequence] == 0 {
+		delete(d.pending[delivery.stream], delivery.sequence)
+	}
+}
+
+// Cursors 可以安全保存的游标：不超过各句柄中仍在队列里的最早事件，重启后从该事件重新投递
+func (d *WebhookDispatcher) Cursors(followed map[string]uint64) map[string]uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	cursors := map[string]uint64{}
+	for stream, cursor := range followed {
+		for sequence := range d.pending[stream] {
+			cursor = min(cursor, sequence)
+		}
+		cursors[stream] = cursor
+	}
+	return cursors
+}
+
+// Dispatch 把事件放入所有匹配订阅者的投递队列后立即返回，only 不为空时只投递给该订阅者；
+// 重试耗尽的写入死信文件，死信写入失败时该事件留在 Cursors 之后，下次运行时重新投递
+func (d *WebhookDispatcher) Dispatch(event SequencedBridgeEvent, replay bool, only string) error {
+	payload := newWebhookPayload(event, replay)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化webhook请求体失败: %v", err)
+	}
+	for _, subscriber := range d.subscribers {
+		if (only != "" && subscriber.Name != only) || !subscriber.Matches(event) {
+			continue
+		}
+		delivery := &webhookDelivery{eventId: payload.Id, body: body}
+		if !replay {
+			delivery.stream, delivery.sequence = event.Stream, event.SequenceNumber
+		}
+		delivery.done = func(attempts int, err error) {
+			if err == nil {
+				logSuccess(fmt.Sprintf("已投递 %s 给 %s", payload.Id, subscriber.Name))
+				d.complete(delivery)
+				return
+			}
+			saveErr := d.addDeadLetter(&WebhookDeadLetter{
+				Subscriber: subscriber.Name,
+				EventId:    payload.Id,
+				Body:       body,
+				Attempts:   attempts,
+				LastError:  err.Error(),
+				FailedAt:   d.now().Unix(),
+			})
+			if saveErr != nil {
+				logError(fmt.Sprintf("投递 %s 给 %s 失败且写入死信失败，下次运行时重新投递: %v", payload.Id, subscriber.Name, saveErr))
+				return
+			}
+			logError(fmt.Sprintf("投递 %s 给 %s 重试 %d 次后仍失败，已写入死信", payload.Id, subscriber.Name, attempts))
+			d.complete(delivery)
+		}
+		d.enqueue(subscriber, delivery)
+	}
+	return nil
+}
+
+// addDeadLetter 追加一条死信，多个订阅者的队列可能同时写入
+func (d *WebhookDispatcher) addDeadLetter(letter *WebhookDeadLetter) error {
+	d.deadLetterMu.Lock()
+	defer d.deadLetterMu.Unlock()
+	letters, err := loadWebhookDeadLetters(d.policy.DeadLetterFile)
+	if err != nil {
+		return err
+	}
+	return saveWebhookDeadLetters(d.policy.DeadLetterFile, append(letters, letter))
+}
+
+// RetryDeadLetters 重新投递死信并等待结果，成功的从死信文件中移除，返回成功数
+func (d *WebhookDispatcher) RetryDeadLetters() (int, error) {
+	d.deadLetterMu.Lock()
+	letters, err := loadWebhookDeadLetters(d.policy.DeadLetterFile)
+	d.deadLetterMu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	var mu sync.Mutex
+	var remaining []*WebhookDeadLetter
+	delivered := 0
+	var retries sync.WaitGroup
+	for _, letter := range letters {
+		subscriber := d.subscriber(letter.Subscriber)
+		if subscriber == nil {
+			logWarning(fmt.Sprintf("死信 %s 的订阅者 %s 已不在配置中，保留", letter.EventId, letter.Subscriber))
+			remaining = append(remaining, letter)
+			continue
+		}
+		retries.Add(1)
+		d.enqueue(subscriber, &webhookDelivery{
+			eventId: letter.EventId,
+			body:    letter.Body,
+			done: func(attempts int, err error) {
+				defer retries.Done()
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					letter.Attempts += attempts
+					letter.LastError = err.Error()
+					letter.FailedAt = d.now().Unix()
+					remaining = append(remaining, letter)
+					return
+				}
+				logSuccess(fmt.Sprintf("已重新投递 %s 给 %s", letter.EventId, letter.Subscriber))
+				delivered++
+			},
+		})
+	}
+	retries.Wait()
+
+	// 按原有顺序保存仍失败的死信
+	slices.SortStableFunc(remaining, func(a, b *WebhookDeadLetter) int {
+		return slices.Index(letters, a) - slices.Index(letters, b)
+	})
+	d.deadLetterMu.Lock()
+	defer d.deadLetterMu.Unlock()
+	return delivered, saveWebhookDeadLetters(d.policy.DeadLetterFile, remaining)
+}
+
+// Replay 重新发送句柄中序列号 [from, to] 的事件并等待投递完成，only 不为空时只发给该订阅者，返回事件数
+func (d *WebhookDispatcher) Replay(source EventSource, stream string, from uint64, to uint64, only string) (int, error) {
+	if from > to {
+		return 0, fmt.Errorf("起始序列号 %d 大于结束序列号 %d", from, to)
+	}
+	if only != "" && d.subscriber(only) == nil {
+		return 0, fmt.Errorf("订阅者 %s 不在配置中", only)
+	}
+	defer d.Wait()
+	replayed := 0
+	for start := from; start <= to; {
+		limit := uint64(maxEventPageLimit)
+		if to-start < limit {
+			limit = to - start + 1
+		}
+		page, err := source.EventPage(stream, start, limit)
+		if err != nil {
+			return replayed, err
+		}
+		if len(page.Events) == 0 {
+			if start < page.Total {
+				return replayed, fmt.Errorf("读取 %s 从 %d 开始的事件为空", stream, start)
+			}
+			break
+		}
+		for _, event := range page.Events {
+			err = d.Dispatch(event, true, only)
+			if err != nil {
+				return replayed, err
+			}
+			replayed++
+		}
+		start = page.Events[len(page.Events)-1].SequenceNumber + 1
+	}
+	return replayed, nil
+}
+
+// webhookState webhook run 的事件跟随游标
+type webhookState struct {
+	Cursors map[string]uint64 `json:"cursors"`
+}
+
+// loadWebhookState 读取游标，文件不存在时返回空游标
+func loadWebhookState(path string) (*webhookState, error) {
+	state := &webhookState{Cursors: map[string]uint64{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取webhook状态文件失败: %v", err)
+	}
+	err = json.Unmarshal(data, state)
+	if err != nil {
+		return nil, fmt.Errorf("解析webhook状态文件失败: %v", err)
+	}
+	return state, nil
+}
+
+// save 写入游标
+func (s *webhookState) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化webhook状态失败: %v", err)
+	}
+	err = writeFileAtomic(path, data)
+	if err != nil {
+		return fmt.Errorf("写入webhook状态文件失败: %v", err)
+	}
+	return nil
+}
+
+// RunWebhookFollower 跟随桥事件并放入投递队列，每轮之后保存不超过未完成投递的游标；
+// 首次运行时从当前事件开始，历史事件用 replay 发送
+func RunWebhookFollower(ctx context.Context, source EventSource, dispatcher *WebhookDispatcher) error {
+	state, err := loadWebhookState(dispatcher.policy.StateFile)
+	if err != nil {
+		return err
+	}
+	follower, err := NewEventFollower(source, state.Cursors)
+	if err != nil {
+		return err
+	}
+	saveCursors := func() error {
+		state.Cursors = dispatcher.Cursors(follower.Cursors)
+		return state.save(dispatcher.policy.StateFile)
+	}
+	err = saveCursors()
+	if err != nil {
+		return err
+	}
+	logInfo(fmt.Sprintf("从游标 %v 开始跟随桥事件，每 %s 轮询一次", follower.Cursors, dispatcher.policy.PollInterval))
+	return follower.Run(ctx, dispatcher.policy.PollInterval, func(event SequencedBridgeEvent) error {
+		return dispatcher.Dispatch(event, false, "")
+	}, saveCursors)
+}
+
+// printWebhookDeadLetters 打印死信
+func printWebhookDeadLetters(letters []*WebhookDeadLetter) {
+	fmt.Printf("===== webhook死信 (%d 条) =====\n", len(letters))
+	for i, letter := range letters {
+		fmt.Printf("%d. %s -> %s, 尝试 %d 次, 最后失败于 %s\n   错误: %s\n", i+1, letter.EventId, letter.Subscriber, letter.Attempts,
+			time.Unix(letter.FailedAt, 0).Format("2006-01-02 15:04:05"), letter.LastError)
+	}
+}
+
+// printWebhookUsage 打印 webhook 命令的帮助
+func printWebhookUsage() {
+	fmt.Println("webhook用法(WEBHOOK_CONFIG 订阅者配置, WEBHOOK_STATE_FILE 跟随游标, WEBHOOK_DEAD_LETTER_FILE 死信文件,")
+	fmt.Println("  WEBHOOK_MAX_ATTEMPTS/WEBHOOK_INITIAL_BACKOFF_MS/WEBHOOK_MAX_BACKOFF_MS 重试策略):")
+	fmt.Println("  跟随桥事件并投递: ./main webhook run")
+	fmt.Println("  按序列号区间重放: ./main webhook replay <mint|redeem_request|redeem_prepare|token_mint|token_burn> <起始序列号> <结束序列号> [订阅者]")
+	fmt.Println("  查看死信: ./main webhook dead-letters")
+	fmt.Println("  重新投递死信: ./main webhook retry-dead")
+	fmt.Println("  配置格式: {\"subscribers\": [{\"name\", \"url\", \"secret\", \"event_types\", \"aptos_addresses\", \"btc_addresses\"}]}")
+	fmt.Printf("  请求头 %s 为 sha256=HMAC-SHA256(secret, %s + \".\" + 请求体) 的十六进制\n", webhookSignatureHeader, webhookTimestampHeader)
+}
+
+// runWebhookCommand 处理 webhook 子命令，不需要私钥
+func runWebhookCommand(args []string) {
+	if len(args) < 1 {
+		printWebhookUsage()
+		os.Exit(1)
+	}
+	policy, err := loadWebhookPolicy()
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+	if args[0] == "dead-letters" {
+		letters, err := loadWebhookDeadLetters(policy.DeadLetterFile)
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		printWebhookDeadLetters(letters)
+		return
+	}
+	subscribers, err := loadWebhookSubscribers(policy.ConfigFile)
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+	dispatcher := NewWebhookDispatcher(subscribers, policy)
+	defer dispatcher.Close()
+
+	switch args[0] {
+	case "retry-dead":
+		delivered, err := dispatcher.RetryDeadLetters()
+		if err != nil {
+			logError(err.Error())
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("重新投递成功 %d 条", delivered))
+		return
+	case "run", "replay":
+	default:
+		logError(fmt.Sprintf("未知的webhook子命令: %s", args[0]))
+		printWebhookUsage()
+		os.Exit(1)
+	}
+
+	moduleAddress, err := getModuleAddress()
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+	client, err := createClient()
+	if err != nil {
+		logError(fmt.Sprintf("创建客户端失败: %v", err))
+		os.Exit(1)
+	}
+	source, err := newEventSource(client, moduleAddress)
+	if err != nil {
+		logError(err.Error())
+		os.Exit(1)
+	}
+
+	if args[0] == "replay" {
+		if len(args) < 4 {
+			printWebhookUsage()
+			os.Exit(1)
+		}
+		from, err := strconv.ParseUint(args[2], 10, 64)
+		if err != nil {
+			logError(fmt.Sprintf("无效的起始序列号: %s", args[2]))
+			os.Exit(1)
+		}
+		to, err := strconv.ParseUint(args[3], 10, 64)
+		if err != nil {
+			logError(fmt.Sprintf("无效的结束序列号: %s", args[3]))
+			os.Exit(1)
+		}
+		only := ""
+		if len(args) > 4 {
+			only = args[4]
+		}
+		replayed, err := dispatcher.Replay(source, args[1], from, to, only)
+		if err != nil {
+			logError(fmt.Sprintf("重放失败(已重放 %d 个事件): %v", replayed, err))
+			os.Exit(1)
+		}
+		logSuccess(fmt.Sprintf("已重放 %s 的 %d 个事件", args[1], replayed))
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err = RunWebhookFollower(ctx, source, dispatcher)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		logError(err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver 测试用的 webhook 接收方，校验签名并记录收到的请求体
+type webhookReceiver struct {
+	mu        sync.Mutex
+	secret    string
+	failFirst int  // 前 failFirst 个请求返回 500
+	down      bool // 为 true 时所有请求返回 503
+	requests  int
+	payloads  []WebhookPayload
+	badSigned int
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, _ := io.ReadAll(req.Body)
+	r.requests++
+	if !VerifyWebhookSignature(r.secret, req.Header.Get(webhookTimestampHeader), body, req.Header.Get(webhookSignatureHeader)) {
+		r.badSigned++
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+		return
+	}
+	if r.down {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if r.requests <= r.failFirst {
+		http.Error(w, "try again", http.StatusInternalServerError)
+		return
+	}
+	payload := WebhookPayload{}
+	err := json.Unmarshal(body, &payload)
+	if err != nil || payload.Id != req.Header.Get(webhookEventIdHeader) {
+		http.Error(w, "bad payload", http.StatusBadRequest)
+		return
+	}
+	r.payloads = append(r.payloads, payload)
+}
+
+// ids 已收到的事件 id
+func (r *webhookReceiver) ids() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []string
+	for _, payload := range r.payloads {
+		ids = append(ids, payload.Id)
+	}
+	return ids
+}
+
+// requestCount 已收到的请求数，包括失败的请求
+func (r *webhookReceiver) requestCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests
+}
+
+func (r *webhookReceiver) setDown(down bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.down = down
+}
+
+// newTestWebhookDispatcher 为每个订阅者启动接收方，返回投递器和按订阅者名索引的接收方
+func newTestWebhookDispatcher(t *testing.T, policy WebhookPolicy, configs map[*WebhookSubscriber]*webhookReceiver) (*WebhookDispatcher, map[string]*webhookReceiver) {
+	t.Helper()
+	receivers := map[string]*webhookReceiver{}
+	var subscribers []*WebhookSubscriber
+	for subscriber, receiver := range configs {
+		subscriber.Secret = "secret-" + subscriber.Name
+		receiver.secret = subscriber.Secret
+		server := httptest.NewServer(receiver)
+		t.Cleanup(server.Close)
+		subscriber.URL = server.URL
+		err := subscriber.validate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		subscribers = append(subscribers, subscriber)
+		receivers[subscriber.Name] = receiver
+	}
+	slices.SortFunc(subscribers, func(a, b *WebhookSubscriber) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	dir := t.TempDir()
+	policy.StateFile = filepath.Join(dir, "webhook_state.json")
+	policy.DeadLetterFile = filepath.Join(dir, "webhook_dead_letters.json")
+	dispatcher := NewWebhookDispatcher(subscribers, policy)
+	t.Cleanup(dispatcher.Close)
+	return dispatcher, receivers
+}
+
+// testWebhookPolicy 重试间隔很短的策略
+func testWebhookPolicy() WebhookPolicy {
+	policy := defaultWebhookPolicy
+	policy.MaxAttempts = 3
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 2 * time.Millisecond
+	return policy
+}
+
+const (
+	testWebhookUser = "0x00000000000000000000000000000000000000000000000000000000000000aa"
+	testWebhookBtc  = "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"
+)
+
+// testWebhookEvents 用户的铸币、其他地址的铸币、用户的赎回请求和对应的销毁
+func testWebhookEvents() []SequencedBridgeEvent {
+	return []SequencedBridgeEvent{
+		{Stream: "mint", SequenceNumber: 1, Version: 10, Type: BridgeMintEventStructTag, Data: &BridgeMintEvent{BtcTxId: "deposit-user", Receiver: "0xaa", Amount: 30000}},
+		{Stream: "mint", SequenceNumber: 2, Version: 11, Type: BridgeMintEventStructTag, Data: &BridgeMintEvent{BtcTxId: "deposit-other", Receiver: "0xbb", Amount: 25000}},
+		{Stream: "redeem_request", SequenceNumber: 0, Version: 12, Type: BridgeRedeemRequestEventStructTag, Data: &BridgeRedeemRequestEvent{Sender: testWebhookUser, Amount: 15000, Receiver: testWebhookBtc}},
+		{Stream: "token_burn", SequenceNumber: 0, Version: 12, Type: TokenBurnEventStructTag, Data: &TokenBurnEvent{Burner: testWebhookUser, Amount: 14000, BtcAddress: testWebhookBtc}},
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	policy := defaultWebhookPolicy
+	policy.MaxBackoff = 2 * time.Second
+	dispatcher := NewWebhookDispatcher(nil, policy)
+	defer dispatcher.Close()
+	for attempt, expected := range []time.Duration{time.Second, 2 * time.Second, 2 * time.Second} {
+		if delay := dispatcher.backoff(attempt + 1); delay != expected {
+			t.Fatalf("第 %d 次失败后应等待 %s，实际 %s", attempt+1, expected, delay)
+		}
+	}
+}
+
+func TestWebhookDispatchFiltersAndRetries(t *testing.T) {
+	dispatcher, receivers := newTestWebhookDispatcher(t, testWebhookPolicy(), map[*WebhookSubscriber]*webhookReceiver{
+		{Name: "all"}: {},
+		{Name: "user", EventTypes: []string{"mint", "redeem_request"}, AptosAddresses: []string{testWebhookUser}}: {},
+		{Name: "btc", BtcAddresses: []string{strings.ToUpper(testWebhookBtc)}}:                                    {},
+		{Name: "flaky", EventTypes: []string{"redeem_request"}}:                                                   {failFirst: 2},
+		{Name: "down", EventTypes: []string{"mint"}}:                                                              {down: true},
+	})
+	for _, event := range testWebhookEvents() {
+		err := dispatcher.Dispatch(event, false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	dispatcher.Wait()
+
+	expected := map[string][]string{
+		"all":   {"mint:1", "mint:2", "redeem_request:0", "token_burn:0"},
+		"user":  {"mint:1", "redeem_request:0"},
+		"btc":   {"redeem_request:0", "token_burn:0"},
+		"flaky": {"redeem_request:0"},
+		"down":  nil,
+	}
+	for name, ids := range expected {
+		if !slices.Equal(receivers[name].ids(), ids) {
+			t.Fatalf("订阅者 %s 应收到 %v，实际 %v", name, ids, receivers[name].ids())
+		}
+		if receivers[name].badSigned != 0 {
+			t.Fatalf("订阅者 %s 收到签名无效的请求", name)
+		}
+	}
+	if receivers["flaky"].requestCount() != 3 || receivers["down"].requestCount() != 6 {
+		t.Fatalf("flaky 应在第 3 次尝试成功，down 的两个事件各尝试 3 次，实际 %d/%d", receivers["flaky"].requestCount(), receivers["down"].requestCount())
+	}
+
+	letters, err := loadWebhookDeadLetters(dispatcher.policy.DeadLetterFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.SortFunc(letters, func(a, b *WebhookDeadLetter) int {
+		return strings.Compare(a.EventId, b.EventId)
+	})
+	if len(letters) != 2 || letters[0].EventId != "mint:1" || letters[1].EventId != "mint:2" || letters[0].Attempts != 3 || !strings.Contains(letters[0].LastError, "503") {
+		t.Fatalf("down 的两个铸币事件应写入死信，实际 %+v", letters)
+	}
+	cursors := dispatcher.Cursors(map[string]uint64{"mint": 3, "redeem_request": 1})
+	if cursors["mint"] != 3 || cursors["redeem_request"] != 1 {
+		t.Fatalf("投递成功或写入死信后游标不应回退: %v", cursors)
+	}
+}
+
+func TestWebhookDispatchDoesNotWaitForRetries(t *testing.T) {
+	policy := testWebhookPolicy()
+	policy.InitialBackoff = time.Hour
+	policy.MaxBackoff = time.Hour
+	dispatcher, receivers := newTestWebhookDispatcher(t, policy, map[*WebhookSubscriber]*webhookReceiver{
+		{Name: "healthy"}: {},
+		{Name: "down"}:    {down: true},
+	})
+	events := testWebhookEvents()
+	started := time.Now()
+	for _, event := range events {
+		err := dispatcher.Dispatch(event, false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("Dispatch 不应等待退避重试，耗时 %s", elapsed)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(receivers["healthy"].ids()) < len(events) || receivers["down"].requestCount() < len(events) {
+		if time.Now().After(deadline) {
+			t.Fatalf("正常的订阅者不应被失败的订阅者阻塞，已收到 %v", receivers["healthy"].ids())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// down 的每个事件都已尝试一次，下一次重试排在一小时后
+	cursors := dispatcher.Cursors(map[string]uint64{"mint": 3, "redeem_request": 1, "token_burn": 1, "token_mint": 0})
+	expected := map[string]uint64{"mint": 1, "redeem_request": 0, "token_burn": 0, "token_mint": 0}
+	for stream, cursor := range expected {
+		if cursors[stream] != cursor {
+			t.Fatalf("游标应停在仍在重试的最早事件 %v，实际 %v", expected, cursors)
+		}
+	}
+}
+
+func TestWebhookRetryDeadLetters(t *testing.T) {
+	dispatcher, receivers := newTestWebhookDispatcher(t, testWebhookPolicy(), map[*WebhookSubscriber]*webhookReceiver{
+		{Name: "down", EventTypes: []string{"mint"}}: {down: true},
+	})
+	for _, event := range testWebhookEvents() {
+		err := dispatcher.Dispatch(event, false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	dispatcher.Wait()
+
+	retried, err := dispatcher.RetryDeadLetters()
+	if err != nil || retried != 0 {
+		t.Fatalf("接收方仍不可用时重新投递不应成功，实际 %d: %v", retried, err)
+	}
+	letters, err := loadWebhookDeadLetters(dispatcher.policy.DeadLetterFile)
+	if err != nil || len(letters) != 2 || letters[0].Attempts != 6 {
+		t.Fatalf("重新投递失败的死信应保留并累计尝试次数，实际 %+v: %v", letters, err)
+	}
+
+	receivers["down"].setDown(false)
+	retried, err = dispatcher.RetryDeadLetters()
+	if err != nil || retried != 2 {
+		t.Fatalf("恢复后应重新投递 2 条死信，实际 %d: %v", retried, err)
+	}
+	letters, err = loadWebhookDeadLetters(dispatcher.policy.DeadLetterFile)
+	if err != nil || len(letters) != 0 {
+		t.Fatalf("重新投递成功后死信应清空，实际 %d 条: %v", len(letters), err)
+	}
+	ids := receivers["down"].ids()
+	slices.Sort(ids)
+	if !slices.Equal(ids, []string{"mint:1", "mint:2"}) {
+		t.Fatalf("down 应收到重新投递的 mint:1、mint:2，实际 %v", ids)
+	}
+
+	// 死信文件通过临时文件重命名写入，不留下临时文件
+	entries, err := os.ReadDir(filepath.Dir(dispatcher.policy.DeadLetterFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Fatalf("不应留下临时文件 %s", entry.Name())
+		}
+	}
+}
+
+func TestWebhookReplay(t *testing.T) {
+	user := newTestAccount(t, "webhook-user")
+	_, client, admin := newTestBridgeNode(t, user)
+	moduleAddress := admin.Address.String()
+	for _, btcTxId := range []string{"deposit-0", "deposit-1", "deposit-2"} {
+		_, err := mintTWBTC(client, admin, moduleAddress, user.Address, 20000, btcTxId)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	dispatcher, receivers := newTestWebhookDispatcher(t, testWebhookPolicy(), map[*WebhookSubscriber]*webhookReceiver{
+		{Name: "all"}:  {},
+		{Name: "user"}: {},
+	})
+	source := &restEventSource{client: client, moduleAddress: moduleAddress}
+
+	replayed, err := dispatcher.Replay(source, "mint", 0, 1, "all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := receivers["all"].ids()
+	if replayed != 2 || !slices.Equal(ids, []string{"mint:0", "mint:1"}) || !receivers["all"].payloads[1].Replay {
+		t.Fatalf("应只向 all 重放 mint:0、mint:1，实际 %d: %v", replayed, ids)
+	}
+	if len(receivers["user"].ids()) != 0 {
+		t.Fatalf("指定订阅者时其他订阅者不应收到重放")
+	}
+	if cursors := dispatcher.Cursors(map[string]uint64{"mint": 3}); cursors["mint"] != 3 {
+		t.Fatalf("重放不应影响跟随游标: %v", cursors)
+	}
+	replayed, err = dispatcher.Replay(source, "mint", 5, 9, "")
+	if err != nil || replayed != 0 {
+		t.Fatalf("超出事件总数的区间不应重放，实际 %d: %v", replayed, err)
+	}
+	_, err = dispatcher.Replay(source, "mint", 0, 1, "nobody")
+	if err == nil {
+		t.Fatalf("未知订阅者应报错")
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	body := []byte(`{"id":"mint:0"}`)
+	signature := signWebhookPayload("secret-all", "1700000000", body)
+	if !VerifyWebhookSignature("secret-all", "1700000000", body, signature) ||
+		VerifyWebhookSignature("secret-user", "1700000000", body, signature) ||
+		VerifyWebhookSignature("secret-all", "1700000001", body, signature) {
+		t.Fatalf("签名只应在 secret 和时间戳都相同时通过")
+	}
+}